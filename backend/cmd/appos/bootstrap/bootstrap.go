@@ -3,6 +3,7 @@ package bootstrap
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"strconv"
 	"strings"
@@ -12,10 +13,16 @@ import (
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/audit"
 	"github.com/websoft9/appos/backend/domain/certs"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+	"github.com/websoft9/appos/backend/domain/groups"
+	"github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/savedcommands"
 	"github.com/websoft9/appos/backend/domain/secrets"
 	"github.com/websoft9/appos/backend/domain/space"
+	"github.com/websoft9/appos/backend/domain/stepup"
 )
 
 // Register binds all custom event hooks to the PocketBase app.
@@ -29,6 +36,35 @@ func Register(app *pocketbase.PocketBase, asynqClient *asynq.Client) {
 	registerEnvSetHooks(app)
 	secrets.RegisterHooks(app)
 	certs.RegisterHooks(app)
+	servers.RegisterHooks(app)
+	space.RegisterHooks(app)
+	savedcommands.RegisterHooks(app)
+	groups.RegisterHooks(app)
+	stepup.RegisterHooks(app)
+	archive.RegisterHooks(app) // after the delete guards above
+	registerSettingsDefaultsCheck(app)
+}
+
+// registerSettingsDefaultsCheck logs the active settings profile and a
+// warning at startup for every settings group whose stored row is missing or
+// lacks fields its registered defaults declare. GetGroup backfills those
+// fields, so this only flags drift.
+func registerSettingsDefaultsCheck(app *pocketbase.PocketBase) {
+	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		if profile := sysconfig.ActiveProfile(); profile != "" {
+			e.App.Logger().Info("settings profile active", "profile", profile)
+		} else if raw := strings.TrimSpace(os.Getenv(sysconfig.EnvProfile)); raw != "" {
+			e.App.Logger().Warn("ignoring invalid settings profile", "env", sysconfig.EnvProfile, "value", raw)
+		}
+		for _, drift := range sysconfig.CheckDefaults(e.App) {
+			if drift.MissingRow {
+				e.App.Logger().Warn("settings group has no stored row; using registered defaults", "module", drift.Module, "key", drift.Key)
+				continue
+			}
+			e.App.Logger().Warn("settings group is missing registered default fields", "module", drift.Module, "key", drift.Key, "fields", drift.MissingFields)
+		}
+		return e.Next()
+	})
 }
 
 // registerAppHooks registers hooks related to the apps collection.
@@ -50,6 +86,12 @@ func registerAppHooks(app *pocketbase.PocketBase) {
 // Enforces quota limits that cannot be expressed in PocketBase access rules.
 func registerSpaceHooks(app *pocketbase.PocketBase) {
 	app.OnRecordCreateRequest("user_files").BindFunc(func(e *core.RecordRequestEvent) error {
+		// Hold the owner lock across the count check and the insert so
+		// parallel creates cannot overshoot maxPerUser.
+		if owner := e.Record.GetString("owner"); owner != "" {
+			unlock := space.LockOwner(owner)
+			defer unlock()
+		}
 		if err := validateFileUpload(app, e.Record, e.Request.Header.Get("X-Space-Batch-Size")); err != nil {
 			return apis.NewBadRequestError(err.Error(), nil)
 		}
@@ -64,22 +106,17 @@ func validateFileUpload(app core.App, record *core.Record, batchSizeRaw string)
 
 	// Folders don't have a file extension — skip format check.
 	if record.GetBool("is_folder") {
-		// Reject reserved root-level folder names.
-		parent := strings.TrimSpace(record.GetString("parent"))
-		if parent == "" {
-			if space.IsReservedRootFolderName(record.GetString("name"), quota.DisallowedFolderNames) {
-				return fmt.Errorf(
-					"folder name %q is reserved by the system and cannot be used",
-					record.GetString("name"),
-				)
-			}
+		// Reject reserved root-level names and disallowed names at any depth.
+		atRoot := strings.TrimSpace(record.GetString("parent")) == ""
+		if err := space.ValidateFolderName(quota, record.GetString("name"), atRoot); err != nil {
+			return err
 		}
 		// Still enforce the count limit.
 		owner := record.GetString("owner")
 		if owner != "" {
-			existing, err := app.FindAllRecords(space.Collection, dbx.HashExp{"owner": owner})
+			existing, err := space.CountItems(app, owner)
 			if err == nil {
-				if countErr := space.ValidateItemCount(len(existing), quota.MaxPerUser); countErr != nil {
+				if countErr := space.ValidateItemCount(existing, quota.MaxPerUser); countErr != nil {
 					return countErr
 				}
 			}
@@ -116,9 +153,9 @@ func validateFileUpload(app core.App, record *core.Record, batchSizeRaw string)
 	// Check per-user file count.
 	owner := record.GetString("owner")
 	if owner != "" {
-		existing, err := app.FindAllRecords(space.Collection, dbx.HashExp{"owner": owner})
+		existing, err := space.CountItems(app, owner)
 		if err == nil {
-			if countErr := space.ValidateItemCount(len(existing), quota.MaxPerUser); countErr != nil {
+			if countErr := space.ValidateItemCount(existing, quota.MaxPerUser); countErr != nil {
 				return countErr
 			}
 		}
@@ -17,6 +17,7 @@ const monitorReachabilityCronJobID = "monitor_reachability_checks"
 const monitorHeartbeatFreshnessCronJobID = "monitor_heartbeat_freshness"
 const monitorCredentialCronJobID = "monitor_credential_checks"
 const monitorAppHealthCronJobID = "monitor_app_health_checks"
+const archivePurgeCronJobID = "archive_purge"
 
 func registerCronHooks(app *pocketbase.PocketBase, asynqClient *asynq.Client) {
 	app.Cron().MustAdd(
@@ -72,6 +73,16 @@ func registerCronHooks(app *pocketbase.PocketBase, asynqClient *asynq.Client) {
 			}
 		}),
 	)
+
+	app.Cron().MustAdd(
+		archivePurgeCronJobID,
+		"30 3 * * *",
+		cronutil.Wrap(app, archivePurgeCronJobID, func() {
+			if err := worker.EnqueueArchivePurge(asynqClient); err != nil {
+				panic(err)
+			}
+		}),
+	)
 }
 
 func runComponentsInventoryProbe() error {
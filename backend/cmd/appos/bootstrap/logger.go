@@ -0,0 +1,104 @@
+package bootstrap
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/websoft9/appos/backend/infra/logfile"
+)
+
+// Environment variables configuring the process log. They are read once at
+// startup; changing them requires a restart.
+const (
+	envLogFile       = "APPOS_LOG_FILE"
+	envLogFormat     = "APPOS_LOG_FORMAT"
+	envLogMaxSizeMB  = "APPOS_LOG_MAX_SIZE_MB"
+	envLogMaxAgeDays = "APPOS_LOG_MAX_AGE_DAYS"
+	envLogMaxBackups = "APPOS_LOG_MAX_BACKUPS"
+)
+
+// Log formats accepted by APPOS_LOG_FORMAT.
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// LoggerConfig describes where the process log goes. File is optional;
+// stderr is always written so container log collectors keep working.
+type LoggerConfig struct {
+	File       string
+	Format     string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+}
+
+// LoggerConfigFromEnv reads LoggerConfig from the APPOS_LOG_* variables.
+// Rotation defaults to 100 MB per file, 14 days and 10 backups.
+func LoggerConfigFromEnv() (LoggerConfig, error) {
+	cfg := LoggerConfig{
+		File:       strings.TrimSpace(os.Getenv(envLogFile)),
+		Format:     strings.ToLower(strings.TrimSpace(os.Getenv(envLogFormat))),
+		MaxSizeMB:  100,
+		MaxAgeDays: 14,
+		MaxBackups: 10,
+	}
+	if cfg.Format == "" {
+		cfg.Format = LogFormatConsole
+	}
+	if cfg.Format != LogFormatConsole && cfg.Format != LogFormatJSON {
+		return cfg, fmt.Errorf("%s must be %q or %q, got %q", envLogFormat, LogFormatConsole, LogFormatJSON, cfg.Format)
+	}
+	for name, target := range map[string]*int{
+		envLogMaxSizeMB:  &cfg.MaxSizeMB,
+		envLogMaxAgeDays: &cfg.MaxAgeDays,
+		envLogMaxBackups: &cfg.MaxBackups,
+	} {
+		raw := strings.TrimSpace(os.Getenv(name))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("%s must be a non-negative integer, got %q", name, raw)
+		}
+		*target = n
+	}
+	return cfg, nil
+}
+
+// SetupLogger points the standard library log (and, for JSON, slog's
+// default logger) at stderr plus the optional rotated file. PocketBase's
+// app.Logger() keeps its own handler and is not affected. The returned
+// closer flushes the file on shutdown.
+func SetupLogger(cfg LoggerConfig) (io.Closer, error) {
+	var out io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if cfg.File != "" {
+		w, err := logfile.Open(logfile.Options{
+			Path:       cfg.File,
+			MaxSizeMB:  cfg.MaxSizeMB,
+			MaxAgeDays: cfg.MaxAgeDays,
+			MaxBackups: cfg.MaxBackups,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = io.MultiWriter(os.Stderr, w)
+		closer = w
+	}
+
+	switch cfg.Format {
+	case LogFormatJSON:
+		// slog.SetDefault also routes log.Printf through the handler.
+		slog.SetDefault(slog.New(slog.NewJSONHandler(out, nil)))
+	default:
+		log.SetOutput(out)
+	}
+	return closer, nil
+}
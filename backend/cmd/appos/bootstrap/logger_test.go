@@ -0,0 +1,57 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoggerConfigFromEnv(t *testing.T) {
+	t.Setenv(envLogFile, " /var/log/appos/appos.log ")
+	t.Setenv(envLogFormat, "JSON")
+	t.Setenv(envLogMaxSizeMB, "5")
+	t.Setenv(envLogMaxAgeDays, "")
+	t.Setenv(envLogMaxBackups, "0")
+
+	cfg, err := LoggerConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.File != "/var/log/appos/appos.log" || cfg.Format != LogFormatJSON || cfg.MaxSizeMB != 5 || cfg.MaxAgeDays != 14 || cfg.MaxBackups != 0 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	t.Setenv(envLogFormat, "xml")
+	if _, err := LoggerConfigFromEnv(); err == nil {
+		t.Fatal("expected unknown format to be rejected")
+	}
+	t.Setenv(envLogFormat, "")
+	t.Setenv(envLogMaxSizeMB, "-1")
+	if _, err := LoggerConfigFromEnv(); err == nil {
+		t.Fatal("expected negative size to be rejected")
+	}
+}
+
+func TestSetupLoggerWritesConsoleLogToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appos.log")
+	closer, err := SetupLogger(LoggerConfig{File: path, Format: LogFormatConsole, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	log.Printf("logger test line")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "logger test line") {
+		t.Fatalf("expected log line in file, got %q", data)
+	}
+}
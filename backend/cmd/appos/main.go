@@ -20,6 +20,15 @@ import (
 )
 
 func main() {
+	logCfg, err := bootstrap.LoggerConfigFromEnv()
+	if err != nil {
+		log.Fatal(fmt.Errorf("logger init failed: %w", err))
+	}
+	logCloser, err := bootstrap.SetupLogger(logCfg)
+	if err != nil {
+		log.Fatal(fmt.Errorf("logger init failed: %w", err))
+	}
+
 	if err := secrets.LoadKeyFromEnv(); err != nil {
 		log.Fatal(fmt.Errorf("secrets init failed: %w", err))
 	}
@@ -70,6 +79,7 @@ func main() {
 		terminal.StopIdleMonitor()
 		platformObserver.Stop()
 		w.Shutdown()
+		defer logCloser.Close()
 		return e.Next()
 	})
 
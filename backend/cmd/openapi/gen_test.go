@@ -109,10 +109,11 @@ func TestScanFile_WithInlineGroupHelperDiscoversSecretsRoutes(t *testing.T) {
 	}
 
 	want := map[string]bool{
-		"GET /api/secrets/templates":    true,
-		"PUT /api/secrets/{id}/payload": true,
-		"POST /api/secrets/resolve":     true,
-		"GET /api/secrets/{id}/reveal":  true,
+		"GET /api/secrets/templates":     true,
+		"PUT /api/secrets/{id}/payload":  true,
+		"POST /api/secrets/resolve":      true,
+		"GET /api/secrets/{id}/reveal":   true,
+		"POST /api/secrets/{id}/restore": true,
 	}
 
 	got := map[string]bool{}
@@ -0,0 +1,108 @@
+// Package archive implements soft-delete for resource-store records.
+//
+// A collection opts in by carrying an archived_at date field. Archiving sets
+// the field instead of deleting the record; archived records are hidden from
+// default lists, can be restored, and are purged once the retention window
+// has passed.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Field is the date field marking a record as archived.
+const Field = "archived_at"
+
+// DefaultRetention is how long archived records are kept before purging.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Collections lists the collections that support archiving and are swept by
+// Purge.
+var Collections = []string{"scripts", "servers", "secrets"}
+
+// ErrNotArchived is returned when restoring a record that is not archived.
+var ErrNotArchived = errors.New("record is not archived")
+
+// Supported reports whether col has the archived_at field.
+func Supported(col *core.Collection) bool {
+	return col != nil && col.Fields.GetByName(Field) != nil
+}
+
+// IsArchived reports whether rec is archived.
+func IsArchived(rec *core.Record) bool {
+	return !rec.GetDateTime(Field).IsZero()
+}
+
+// Archive marks rec as archived now. Archiving an archived record keeps its
+// original timestamp so the retention window is not extended.
+func Archive(app core.App, rec *core.Record) error {
+	if IsArchived(rec) {
+		return nil
+	}
+	rec.Set(Field, types.NowDateTime())
+	return app.Save(rec)
+}
+
+// Restore clears the archive mark of rec.
+func Restore(app core.App, rec *core.Record) error {
+	if !IsArchived(rec) {
+		return ErrNotArchived
+	}
+	rec.Set(Field, "")
+	return app.Save(rec)
+}
+
+// Live matches records that are not archived.
+func Live() dbx.Expression {
+	return dbx.HashExp{Field: ""}
+}
+
+// FindLive returns the record of collection with id, reporting an archived
+// record as not found (sql.ErrNoRows) like a missing one.
+func FindLive(app core.App, collection, id string) (*core.Record, error) {
+	return app.FindRecordById(collection, id, func(q *dbx.SelectQuery) error {
+		q.AndWhere(Live())
+		return nil
+	})
+}
+
+// Archived matches archived records.
+func Archived() dbx.Expression {
+	return dbx.Not(dbx.HashExp{Field: ""})
+}
+
+// Purge permanently deletes records of Collections archived before
+// now - retention and returns how many were deleted.
+func Purge(app core.App, now time.Time, retention time.Duration) (int, error) {
+	cutoff, err := types.ParseDateTime(now.Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	var errs []error
+	for _, name := range Collections {
+		col, err := app.FindCollectionByNameOrId(name)
+		if err != nil || !Supported(col) {
+			continue
+		}
+		records, err := app.FindAllRecords(col, Archived(), dbx.NewExp(Field+" < {:cutoff}", dbx.Params{"cutoff": cutoff.String()}))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("list archived %s: %w", name, err))
+			continue
+		}
+		for _, rec := range records {
+			if err := app.Delete(rec); err != nil {
+				errs = append(errs, fmt.Errorf("purge %s %s: %w", name, rec.Id, err))
+				continue
+			}
+			purged++
+		}
+	}
+	return purged, errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package archive
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterHooks makes record-API deletes of Collections archive the record
+// instead, matching the resource routes. ?purge=true deletes permanently and
+// is superuser-only. Bind it after other delete guards so they still run.
+func RegisterHooks(app core.App) {
+	app.OnRecordDeleteRequest(Collections...).BindFunc(func(e *core.RecordRequestEvent) error {
+		if e.Request.URL.Query().Get("purge") == "true" {
+			if !e.HasSuperuserAuth() {
+				return apis.NewForbiddenError("only superusers can purge records", nil)
+			}
+			return e.Next()
+		}
+		if !Supported(e.Collection) {
+			return e.Next()
+		}
+		if err := Archive(e.App, e.Record); err != nil {
+			return apis.NewInternalServerError("failed to archive record", err)
+		}
+		return e.NoContent(http.StatusNoContent)
+	})
+}
+
+// HideArchived is a router middleware that keeps archived records out of
+// record-API lists of Collections unless ?archived=true, which lists only
+// the archived ones, like the resource list routes. List rules already hide
+// them from regular users; this also covers superusers, who bypass rules.
+func HideArchived(e *core.RequestEvent) error {
+	name := e.Request.PathValue("collection")
+	if e.Request.Method != http.MethodGet || name == "" || !strings.HasSuffix(e.Request.URL.Path, "/records") {
+		return e.Next()
+	}
+	col, err := e.App.FindCachedCollectionByNameOrId(name)
+	if err != nil || !slices.Contains(Collections, col.Name) || !Supported(col) {
+		return e.Next()
+	}
+	q := e.Request.URL.Query()
+	filter := Field + " = ''"
+	if q.Get("archived") == "true" {
+		filter = Field + " != ''"
+	}
+	if custom := strings.TrimSpace(q.Get("filter")); custom != "" {
+		filter = "(" + custom + ") && " + filter
+	}
+	q.Set("filter", filter)
+	e.Request.URL.RawQuery = q.Encode()
+	return e.Next()
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ActivityFilter selects the audit_logs entries recorded against one
+// resource. Action is an optional action ID or prefix pattern ending in ".*".
+type ActivityFilter struct {
+	ResourceType string
+	ResourceID   string
+	Action       string
+	Limit        int
+	Offset       int
+}
+
+// Activity returns one page of the entries recorded against a resource,
+// newest first, and the total number of matching entries.
+func Activity(app core.App, filter ActivityFilter) ([]*core.Record, int, error) {
+	where := dbx.And(dbx.HashExp{
+		"resource_type": filter.ResourceType,
+		"resource_id":   filter.ResourceID,
+	})
+	if filter.Action != "" {
+		if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+			where = dbx.And(where, dbx.Like("action", prefix).Match(false, true))
+		} else {
+			where = dbx.And(where, dbx.HashExp{"action": filter.Action})
+		}
+	}
+
+	var total int
+	if err := app.RecordQuery("audit_logs").Select("count(*)").AndWhere(where).Row(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var records []*core.Record
+	err := app.RecordQuery("audit_logs").
+		AndWhere(where).
+		OrderBy("created DESC", "id DESC").
+		Limit(int64(filter.Limit)).
+		Offset(int64(filter.Offset)).
+		All(&records)
+	if err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
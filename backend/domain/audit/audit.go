@@ -6,8 +6,12 @@ package audit
 
 import (
 	"log"
+	"regexp"
+	"strings"
 
 	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 )
 
 const (
@@ -24,6 +28,50 @@ var validStatuses = map[string]bool{
 	StatusAttentionRequired: true,
 }
 
+// Settings group holding the action filter. excludeActions lists actions
+// that are not persisted on success; includeActions always wins over it.
+const (
+	SettingsModule = "audit"
+	SettingsKey    = "actions"
+)
+
+// actionPatternRe matches an action ID ("server.ops.power") or a prefix
+// pattern ending in ".*" ("server.ops.systemd.*").
+var actionPatternRe = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)*(\.\*)?$`)
+
+// ValidActionPattern reports whether p is usable in the action filter.
+func ValidActionPattern(p string) bool {
+	return actionPatternRe.MatchString(p)
+}
+
+// MatchesAction reports whether action matches one of patterns.
+func MatchesAction(patterns []string, action string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(action, prefix) {
+				return true
+			}
+		} else if p == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Persisted reports whether entry passes the configured action filter.
+// Failures and attention-required entries are always persisted so that
+// security-relevant events can never be filtered out.
+func Persisted(app core.App, entry Entry) bool {
+	if entry.Status == StatusFailed || entry.Status == StatusAttentionRequired {
+		return true
+	}
+	cfg, _ := sysconfig.GetGroup(app, SettingsModule, SettingsKey, nil)
+	if MatchesAction(sysconfig.StringSlice(cfg, "includeActions"), entry.Action) {
+		return true
+	}
+	return !MatchesAction(sysconfig.StringSlice(cfg, "excludeActions"), entry.Action)
+}
+
 // Entry holds all fields for a single audit record.
 // Using a named struct avoids the swap-bug risk of 7 consecutive string parameters.
 type Entry struct {
@@ -56,12 +104,16 @@ type Entry struct {
 // It bypasses PocketBase access rules via app.Save(), so it works from any
 // backend handler or Asynq worker.
 // Errors are logged and swallowed — an audit failure must never break the
-// calling operation.
+// calling operation. Entries rejected by the action filter (see Persisted)
+// are dropped silently.
 func Write(app core.App, entry Entry) {
 	if !validStatuses[entry.Status] {
 		log.Printf("audit.Write: invalid status %q for action %q — skipping", entry.Status, entry.Action)
 		return
 	}
+	if !Persisted(app, entry) {
+		return
+	}
 
 	col, err := app.FindCollectionByNameOrId("audit_logs")
 	if err != nil {
@@ -7,3 +7,35 @@ func TestValidStatusesIncludesAttentionRequired(t *testing.T) {
 		t.Fatalf("expected audit status %q to be accepted", StatusAttentionRequired)
 	}
 }
+
+func TestMatchesActionSupportsPrefixPatterns(t *testing.T) {
+	patterns := []string{"server.ops.systemd.*", "secret.reveal"}
+	for action, want := range map[string]bool{
+		"server.ops.systemd.status": true,
+		"server.ops.systemd":        false,
+		"secret.reveal":             true,
+		"secret.reveal_all":         false,
+		"server.ops.power":          false,
+	} {
+		if got := MatchesAction(patterns, action); got != want {
+			t.Errorf("MatchesAction(%q) = %v, want %v", action, got, want)
+		}
+	}
+}
+
+func TestValidActionPattern(t *testing.T) {
+	for p, want := range map[string]bool{
+		"server.ops.power":    true,
+		"server.ops.*":        true,
+		"redis_ping":          true,
+		"*":                   false,
+		"server.*.power":      false,
+		"Server.Ops":          false,
+		"server ops":          false,
+		"server.ops.systemd.": false,
+	} {
+		if got := ValidActionPattern(p); got != want {
+			t.Errorf("ValidActionPattern(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Settings group holding the export cap.
+const (
+	ExportSettingsKey = "export"
+	// DefaultExportMaxRows is used when the "audit/export" group has no maxRows.
+	DefaultExportMaxRows = 100000
+)
+
+// exportBatchSize is how many rows each cursor step reads.
+const exportBatchSize = 500
+
+// ExportFilter selects the audit_logs rows to export. Zero times leave the
+// range open; Action is an action ID or a prefix pattern ending in ".*".
+type ExportFilter struct {
+	From   time.Time
+	To     time.Time
+	Action string
+	Limit  int
+}
+
+// Export walks the matching audit_logs rows oldest first and calls fn for
+// each. Rows are read in fixed-size batches with a (created, id) keyset
+// cursor, so memory stays flat however large the range is. It stops after
+// filter.Limit rows when Limit > 0 and returns the number of rows visited.
+func Export(app core.App, filter ExportFilter, fn func(*core.Record) error) (int, error) {
+	var lastCreated, lastID string
+	count := 0
+	for {
+		batch := exportBatchSize
+		if filter.Limit > 0 && filter.Limit-count < batch {
+			batch = filter.Limit - count
+		}
+		if batch <= 0 {
+			return count, nil
+		}
+
+		query := app.RecordQuery("audit_logs").
+			OrderBy("created ASC", "id ASC").
+			Limit(int64(batch))
+		if !filter.From.IsZero() {
+			query.AndWhere(dbx.NewExp("created >= {:from}", dbx.Params{"from": exportTime(filter.From)}))
+		}
+		if !filter.To.IsZero() {
+			query.AndWhere(dbx.NewExp("created < {:to}", dbx.Params{"to": exportTime(filter.To)}))
+		}
+		if filter.Action != "" {
+			if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+				query.AndWhere(dbx.Like("action", prefix).Match(false, true))
+			} else {
+				query.AndWhere(dbx.HashExp{"action": filter.Action})
+			}
+		}
+		if lastID != "" {
+			query.AndWhere(dbx.NewExp(
+				"(created > {:cursorCreated} OR (created = {:cursorCreated} AND id > {:cursorID}))",
+				dbx.Params{"cursorCreated": lastCreated, "cursorID": lastID},
+			))
+		}
+
+		var records []*core.Record
+		if err := query.All(&records); err != nil {
+			return count, err
+		}
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(records) < batch {
+			return count, nil
+		}
+		last := records[len(records)-1]
+		lastCreated = last.GetDateTime("created").String()
+		lastID = last.Id
+	}
+}
+
+func exportTime(t time.Time) string {
+	dt, _ := types.ParseDateTime(t.UTC())
+	return dt.String()
+}
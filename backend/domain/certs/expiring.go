@@ -0,0 +1,30 @@
+package certs
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Expiring returns the non-revoked certificates whose parsed expiry falls
+// between now and now+within, soonest first.
+func Expiring(app core.App, now time.Time, within time.Duration) ([]*core.Record, error) {
+	from, err := types.ParseDateTime(now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	to, err := types.ParseDateTime(now.Add(within).UTC())
+	if err != nil {
+		return nil, err
+	}
+	return app.FindRecordsByFilter(
+		"certificates",
+		"expires_at != '' && expires_at >= {:from} && expires_at <= {:to} && status != 'revoked'",
+		"expires_at",
+		0,
+		0,
+		dbx.Params{"from": from.String(), "to": to.String()},
+	)
+}
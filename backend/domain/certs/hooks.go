@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
@@ -36,28 +37,8 @@ func RegisterHooks(app *pocketbase.PocketBase) {
 		}
 
 		// PEM validation and metadata extraction
-		certPEM := e.Record.GetString("cert_pem")
-		if certPEM != "" {
-			if IsBinaryContent(certPEM) {
-				return apis.NewBadRequestError("cert_pem contains binary content; only text PEM is supported", nil)
-			}
-			if !ValidatePEMHeader(certPEM) {
-				return apis.NewBadRequestError("cert_pem must start with -----BEGIN CERTIFICATE-----", nil)
-			}
-
-			meta, err := ExtractCertMeta(certPEM)
-			if err != nil {
-				return apis.NewBadRequestError("failed to parse certificate PEM: "+err.Error(), nil)
-			}
-
-			e.Record.Set("issuer", meta.Issuer)
-			e.Record.Set("subject", meta.Subject)
-			e.Record.Set("expires_at", meta.ExpiresAt.Format(time.RFC3339))
-			e.Record.Set("issued_at", meta.IssuedAt.Format(time.RFC3339))
-			e.Record.Set("serial_number", meta.SerialNumber)
-			e.Record.Set("signature_algorithm", meta.SignatureAlgorithm)
-			e.Record.Set("key_bits", meta.KeyBits)
-			e.Record.Set("cert_version", meta.CertVersion)
+		if err := applyCertPEM(e.Record, privateKeyResolver(app, actorID(e.Auth))); err != nil {
+			return err
 		}
 
 		err := e.Next()
@@ -96,27 +77,13 @@ func RegisterHooks(app *pocketbase.PocketBase) {
 			return apis.NewBadRequestError(err.Error(), nil)
 		}
 
-		if newPEM != "" && newPEM != oldPEM {
-			if IsBinaryContent(newPEM) {
-				return apis.NewBadRequestError("cert_pem contains binary content; only text PEM is supported", nil)
-			}
-			if !ValidatePEMHeader(newPEM) {
-				return apis.NewBadRequestError("cert_pem must start with -----BEGIN CERTIFICATE-----", nil)
-			}
-
-			meta, err := ExtractCertMeta(newPEM)
-			if err != nil {
-				return apis.NewBadRequestError("failed to parse certificate PEM: "+err.Error(), nil)
+		// Re-check when the PEM, the domain it must cover or the key changes.
+		if newPEM != oldPEM ||
+			e.Record.GetString("domain") != existing.GetString("domain") ||
+			privateKeySecretID != getPrivateKeySecretID(existing) {
+			if err := applyCertPEM(e.Record, privateKeyResolver(app, actorID(e.Auth))); err != nil {
+				return err
 			}
-
-			e.Record.Set("issuer", meta.Issuer)
-			e.Record.Set("subject", meta.Subject)
-			e.Record.Set("expires_at", meta.ExpiresAt.Format(time.RFC3339))
-			e.Record.Set("issued_at", meta.IssuedAt.Format(time.RFC3339))
-			e.Record.Set("serial_number", meta.SerialNumber)
-			e.Record.Set("signature_algorithm", meta.SignatureAlgorithm)
-			e.Record.Set("key_bits", meta.KeyBits)
-			e.Record.Set("cert_version", meta.CertVersion)
 		}
 
 		err = e.Next()
@@ -154,6 +121,74 @@ func RegisterHooks(app *pocketbase.PocketBase) {
 	})
 }
 
+// applyCertPEM validates the record's cert_pem and copies its X.509 metadata,
+// including expires_at, onto the record. An empty domain is filled from the
+// first SAN; a set domain must be covered by one. When a private key secret
+// is referenced, resolveKey returns its PEM and it must match the
+// certificate. Records without cert_pem are left untouched.
+func applyCertPEM(record *core.Record, resolveKey func(secretID string) (string, error)) error {
+	certPEM := record.GetString("cert_pem")
+	if certPEM == "" {
+		return nil
+	}
+	if IsBinaryContent(certPEM) {
+		return certFieldError("cert_pem", "validation_invalid_pem", "cert_pem contains binary content; only text PEM is supported")
+	}
+	if !ValidatePEMHeader(certPEM) {
+		return certFieldError("cert_pem", "validation_invalid_pem", "cert_pem must start with -----BEGIN CERTIFICATE-----")
+	}
+
+	meta, err := ExtractCertMeta(certPEM)
+	if err != nil {
+		return certFieldError("cert_pem", "validation_invalid_pem", "failed to parse certificate PEM: "+err.Error())
+	}
+
+	record.Set("issuer", meta.Issuer)
+	record.Set("subject", meta.Subject)
+	record.Set("expires_at", meta.ExpiresAt.Format(time.RFC3339))
+	record.Set("issued_at", meta.IssuedAt.Format(time.RFC3339))
+	record.Set("serial_number", meta.SerialNumber)
+	record.Set("signature_algorithm", meta.SignatureAlgorithm)
+	record.Set("key_bits", meta.KeyBits)
+	record.Set("cert_version", meta.CertVersion)
+
+	domain := strings.TrimSpace(record.GetString("domain"))
+	switch {
+	case domain == "" && len(meta.Domains) > 0:
+		record.Set("domain", meta.Domains[0])
+	case domain != "" && !DomainMatches(domain, meta.Domains):
+		return certFieldError("domain", "validation_domain_mismatch",
+			fmt.Sprintf("domain %s is not covered by the certificate (SANs: %s)", domain, strings.Join(meta.Domains, ", ")))
+	}
+
+	if secretID := getPrivateKeySecretID(record); secretID != "" {
+		keyPEM, err := resolveKey(secretID)
+		if err != nil {
+			return certFieldError("private_key_secret", "validation_invalid_key", "failed to read private key secret: "+err.Error())
+		}
+		if err := VerifyKeyPair(certPEM, keyPEM); err != nil {
+			return certFieldError("private_key_secret", "validation_key_mismatch", err.Error())
+		}
+	}
+	return nil
+}
+
+// privateKeyResolver reads the PEM key of a tls_private_key secret as userID.
+func privateKeyResolver(app core.App, userID string) func(string) (string, error) {
+	return func(secretID string) (string, error) {
+		result, err := secrets.Resolve(app, secretID, userID)
+		if err != nil {
+			return "", err
+		}
+		return privateKeyFromPayload(result.Payload)
+	}
+}
+
+// certFieldError is a 400 response carrying message as the error of field.
+func certFieldError(field, code, message string) error {
+	return apis.NewBadRequestError(message, validation.Errors{field: validation.NewError(code, message)})
+}
+
 func validatePrivateKeySecretRef(app core.App, secretID, userID string) error {
 	return validatePrivateKeySecretRefWith(secretID, userID,
 		func(secretID, userID string) error {
@@ -3,8 +3,10 @@ package certs
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
 )
 
 func TestValidatePrivateKeySecretRef(t *testing.T) {
@@ -65,3 +67,77 @@ func TestValidatePrivateKeySecretRef(t *testing.T) {
 		}
 	})
 }
+
+func TestApplyCertPEM(t *testing.T) {
+	col := core.NewBaseCollection("certificates")
+	certPEM, keyPEM, err := GenerateSelfSigned("*.example.com", 2048, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKeyPEM, err := GenerateSelfSigned("other.example.com", 2048, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]string{"good-key": keyPEM, "other-key": otherKeyPEM}
+	resolveKey := func(secretID string) (string, error) {
+		if key, ok := keys[secretID]; ok {
+			return key, nil
+		}
+		return "", errors.New("not found")
+	}
+	newCert := func(domain, keySecret, pem string) *core.Record {
+		rec := core.NewRecord(col)
+		rec.Set("domain", domain)
+		rec.Set("cert_pem", pem)
+		setPrivateKeySecretID(rec, keySecret)
+		return rec
+	}
+	fieldError := func(t *testing.T, err error, field string) {
+		t.Helper()
+		apiErr, ok := err.(*router.ApiError)
+		if !ok || apiErr.Status != 400 {
+			t.Fatalf("expected a 400 api error, got %v", err)
+		}
+		if _, ok := apiErr.Data[field]; !ok {
+			t.Fatalf("expected an error on %s, got %v", field, apiErr.Data)
+		}
+	}
+
+	t.Run("fills expiry and domain from the certificate", func(t *testing.T) {
+		rec := newCert("", "good-key", certPEM)
+		if err := applyCertPEM(rec, resolveKey); err != nil {
+			t.Fatal(err)
+		}
+		if rec.GetString("domain") != "*.example.com" {
+			t.Fatalf("expected domain from SAN, got %q", rec.GetString("domain"))
+		}
+		expiresAt, err := time.Parse(time.RFC3339, rec.GetString("expires_at"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if days := time.Until(expiresAt).Hours() / 24; days < 89 || days > 90 {
+			t.Fatalf("expected expiry in 90 days, got %.1f", days)
+		}
+	})
+
+	t.Run("accepts a domain covered by a wildcard SAN", func(t *testing.T) {
+		if err := applyCertPEM(newCert("app.example.com", "", certPEM), resolveKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("rejects a domain outside the SANs", func(t *testing.T) {
+		fieldError(t, applyCertPEM(newCert("a.b.example.com", "", certPEM), resolveKey), "domain")
+		fieldError(t, applyCertPEM(newCert("example.org", "", certPEM), resolveKey), "domain")
+	})
+
+	t.Run("rejects malformed PEM", func(t *testing.T) {
+		broken := "-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydA==\n-----END CERTIFICATE-----\n"
+		fieldError(t, applyCertPEM(newCert("", "", broken), resolveKey), "cert_pem")
+	})
+
+	t.Run("rejects a key that does not match", func(t *testing.T) {
+		fieldError(t, applyCertPEM(newCert("", "other-key", certPEM), resolveKey), "private_key_secret")
+		fieldError(t, applyCertPEM(newCert("", "missing-key", certPEM), resolveKey), "private_key_secret")
+	})
+}
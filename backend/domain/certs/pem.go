@@ -3,6 +3,7 @@ package certs
 import (
 	"crypto/ecdsa"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -20,6 +21,9 @@ type CertMeta struct {
 	SignatureAlgorithm string
 	KeyBits            int
 	CertVersion        int
+	// Domains lists the DNS and IP subject alternative names, or the
+	// subject common name when the certificate has no SANs.
+	Domains []string
 }
 
 // ExtractCertMeta parses the first CERTIFICATE PEM block from certPEM and
@@ -53,6 +57,16 @@ func ExtractCertMeta(certPEM string) (CertMeta, error) {
 		meta.Subject = cert.Subject.String()
 	}
 
+	for _, name := range cert.DNSNames {
+		meta.Domains = append(meta.Domains, strings.ToLower(name))
+	}
+	for _, ip := range cert.IPAddresses {
+		meta.Domains = append(meta.Domains, ip.String())
+	}
+	if len(meta.Domains) == 0 && cert.Subject.CommonName != "" {
+		meta.Domains = []string{strings.ToLower(cert.Subject.CommonName)}
+	}
+
 	switch pub := cert.PublicKey.(type) {
 	case *rsa.PublicKey:
 		meta.KeyBits = pub.N.BitLen()
@@ -63,6 +77,35 @@ func ExtractCertMeta(certPEM string) (CertMeta, error) {
 	return meta, nil
 }
 
+// DomainMatches reports whether domain is covered by one of domains. A
+// "*.example.com" entry covers names exactly one label below example.com,
+// and a wildcard domain only matches the same wildcard.
+func DomainMatches(domain string, domains []string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	for _, name := range domains {
+		if name == domain {
+			return true
+		}
+		suffix, ok := strings.CutPrefix(name, "*.")
+		if !ok || strings.HasPrefix(domain, "*.") {
+			continue
+		}
+		if label, ok := strings.CutSuffix(domain, "."+suffix); ok && label != "" && !strings.Contains(label, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyKeyPair checks that keyPEM is the private key of the first
+// certificate in certPEM.
+func VerifyKeyPair(certPEM, keyPEM string) error {
+	if _, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err != nil {
+		return fmt.Errorf("private key does not match certificate: %w", err)
+	}
+	return nil
+}
+
 // IsBinaryContent checks the first 8192 bytes of data for null bytes.
 func IsBinaryContent(data string) bool {
 	probe := data
@@ -67,13 +67,9 @@ func resolveCertificateWith(certID string, callerID string, findCertificate func
 		if err != nil {
 			return nil, fmt.Errorf("resolving private key secret: %w", err)
 		}
-		v, ok := result.Payload["private_key"]
-		if !ok {
-			return nil, fmt.Errorf("resolving private key secret: missing private_key field")
-		}
-		key, ok := v.(string)
-		if !ok || strings.TrimSpace(key) == "" {
-			return nil, fmt.Errorf("resolving private key secret: private_key must be a non-empty string")
+		key, err := privateKeyFromPayload(result.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("resolving private key secret: %w", err)
 		}
 		keyPEM = key
 	}
@@ -84,3 +80,16 @@ func resolveCertificateWith(certID string, callerID string, findCertificate func
 		KeyPEM:  keyPEM,
 	}, nil
 }
+
+// privateKeyFromPayload returns the PEM key of a tls_private_key secret.
+func privateKeyFromPayload(payload map[string]any) (string, error) {
+	v, ok := payload["private_key"]
+	if !ok {
+		return "", fmt.Errorf("missing private_key field")
+	}
+	key, ok := v.(string)
+	if !ok || strings.TrimSpace(key) == "" {
+		return "", fmt.Errorf("private_key must be a non-empty string")
+	}
+	return key, nil
+}
@@ -18,7 +18,12 @@ type AttachedVar struct {
 // a flat variable list. Later attached sets appear later in the slice, so
 // lifecycle consumers can apply "later set wins" semantics by iterating in order.
 func LoadAttachedVars(app core.App, consumer *core.Record) ([]AttachedVar, error) {
-	setIDs := AttachedSetIDs(consumer)
+	return LoadSetVars(app, AttachedSetIDs(consumer))
+}
+
+// LoadSetVars expands the given env sets into a flat variable list in setIDs
+// order, like LoadAttachedVars does for a consumer's attachments.
+func LoadSetVars(app core.App, setIDs []string) ([]AttachedVar, error) {
 	if len(setIDs) == 0 {
 		return nil, nil
 	}
@@ -47,3 +52,19 @@ func LoadAttachedVars(app core.App, consumer *core.Record) ([]AttachedVar, error
 	}
 	return result, nil
 }
+
+// MergeVars applies "later set wins" to vars: one entry per key is kept, the
+// last one seen, at the position where the key first appeared.
+func MergeVars(vars []AttachedVar) []AttachedVar {
+	index := make(map[string]int, len(vars))
+	merged := make([]AttachedVar, 0, len(vars))
+	for _, item := range vars {
+		if i, ok := index[item.Var.Key]; ok {
+			merged[i] = item
+			continue
+		}
+		index[item.Var.Key] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
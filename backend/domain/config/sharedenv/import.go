@@ -0,0 +1,83 @@
+package sharedenv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// secretKeyPattern matches variable names that conventionally carry
+// credentials, e.g. DB_PASSWORD, API_KEY, GITHUB_TOKEN or AWS_SECRET_ACCESS_KEY.
+var secretKeyPattern = regexp.MustCompile(`(?i)(pass(word|wd)?|secret|token|api_?key|private_?key|access_?key|credential)`)
+
+// LooksSecret reports whether an environment variable name suggests its value
+// is a credential.
+func LooksSecret(key string) bool {
+	return secretKeyPattern.MatchString(key)
+}
+
+// CreateSet creates an env set named name.
+func CreateSet(app core.App, name, description string) (*Set, error) {
+	if app == nil {
+		return nil, fmt.Errorf("shared env create requires app context")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("shared env create requires name")
+	}
+	col, err := app.FindCollectionByNameOrId(SetCollection)
+	if err != nil {
+		return nil, err
+	}
+	record := core.NewRecord(col)
+	record.Set("name", name)
+	record.Set("description", strings.TrimSpace(description))
+	if err := app.Save(record); err != nil {
+		return nil, fmt.Errorf("create shared env set %q: %w", name, err)
+	}
+	return SetFromRecord(record), nil
+}
+
+// ImportResult counts the variables written by ImportVars.
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ImportVars writes plain (non-secret) key/value pairs into one set. A key
+// that already exists in the set has its value replaced and is no longer
+// marked secret; other variables of the set are left untouched.
+func ImportVars(app core.App, setID string, vars []Var) (ImportResult, error) {
+	var result ImportResult
+	if _, err := findSetRecord(app, setID); err != nil {
+		return result, err
+	}
+	col, err := app.FindCollectionByNameOrId(VarCollection)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range vars {
+		key := strings.TrimSpace(item.Key)
+		if key == "" {
+			continue
+		}
+		record, err := findVarRecord(app, VarLookup{SetID: setID, SourceKey: key})
+		if err != nil {
+			record = core.NewRecord(col)
+			record.Set(SetRelationField, strings.TrimSpace(setID))
+			record.Set("key", key)
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		record.Set("value", item.Value)
+		record.Set("is_secret", false)
+		record.Set(SecretRelationField, "")
+		if err := app.Save(record); err != nil {
+			return result, fmt.Errorf("import shared env variable %q: %w", key, err)
+		}
+	}
+	return result, nil
+}
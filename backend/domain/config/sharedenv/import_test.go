@@ -0,0 +1,20 @@
+package sharedenv_test
+
+import (
+	"testing"
+
+	"github.com/websoft9/appos/backend/domain/config/sharedenv"
+)
+
+func TestLooksSecret(t *testing.T) {
+	for _, key := range []string{"DB_PASSWORD", "MYSQL_ROOT_PASSWD", "GITHUB_TOKEN", "API_KEY", "AWS_SECRET_ACCESS_KEY", "apikey"} {
+		if !sharedenv.LooksSecret(key) {
+			t.Errorf("expected %s to look secret", key)
+		}
+	}
+	for _, key := range []string{"PATH", "APP_MODE", "KEYBOARD_LAYOUT", "TZ"} {
+		if sharedenv.LooksSecret(key) {
+			t.Errorf("expected %s not to look secret", key)
+		}
+	}
+}
@@ -247,3 +247,20 @@ func TestLoadAttachedVars(t *testing.T) {
 		t.Fatalf("expected nil attached vars for empty consumer, got %#v", loaded)
 	}
 }
+
+func TestMergeVarsLaterSetWins(t *testing.T) {
+	items := sharedenv.MergeVars([]sharedenv.AttachedVar{
+		{SetID: "base", Var: sharedenv.Var{Key: "APP_ENV", Value: "staging"}},
+		{SetID: "base", Var: sharedenv.Var{Key: "REGION", Value: "ap-south"}},
+		{SetID: "override", Var: sharedenv.Var{Key: "APP_ENV", Value: "prod"}},
+	})
+	if len(items) != 2 {
+		t.Fatalf("expected 2 merged vars, got %d", len(items))
+	}
+	if items[0].SetID != "override" || items[0].Var.Key != "APP_ENV" || items[0].Var.Value != "prod" {
+		t.Fatalf("expected override to win in first position, got %+v", items[0])
+	}
+	if items[1].Var.Key != "REGION" {
+		t.Fatalf("unexpected second merged var: %+v", items[1])
+	}
+}
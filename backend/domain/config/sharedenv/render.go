@@ -0,0 +1,195 @@
+package sharedenv
+
+import "strings"
+
+// MaskedValue replaces secret values in rendered output.
+const MaskedValue = "***"
+
+// Reasons reported for an UnresolvedRef.
+const (
+	UnresolvedUndefined = "undefined"
+	UnresolvedCycle     = "cycle"
+)
+
+// RenderedVar is one variable after ${VAR} interpolation. Raw is the stored
+// value. Masked is set for secret variables and for values that reference
+// one, whose Value and Raw are then MaskedValue.
+type RenderedVar struct {
+	Key        string   `json:"key"`
+	Value      string   `json:"value"`
+	Raw        string   `json:"raw"`
+	SetID      string   `json:"set_id"`
+	SetName    string   `json:"set_name"`
+	Masked     bool     `json:"masked"`
+	References []string `json:"references"`
+}
+
+// UnresolvedRef is a ${Ref} in Key's value that could not be expanded, either
+// because no variable defines Ref or because expanding it would loop back to
+// a variable being expanded. The reference is left in the value as written.
+type UnresolvedRef struct {
+	Key       string `json:"key"`
+	Reference string `json:"reference"`
+	Reason    string `json:"reason"`
+}
+
+// Rendering is the result of Render.
+type Rendering struct {
+	Vars       []RenderedVar   `json:"vars"`
+	Unresolved []UnresolvedRef `json:"unresolved"`
+}
+
+// Render merges vars with later sets winning, as MergeVars does, and expands
+// ${NAME} references against the merged set. $$ stands for a literal $.
+// Secret values are never read: secret variables, and anything that
+// references one, are masked.
+func Render(vars []AttachedVar) Rendering {
+	merged := MergeVars(vars)
+	r := &renderer{
+		scope: make(map[string]AttachedVar, len(merged)),
+		state: make(map[string]int, len(merged)),
+		done:  make(map[string]RenderedVar, len(merged)),
+		seen:  map[UnresolvedRef]bool{},
+	}
+	for _, item := range merged {
+		r.scope[item.Var.Key] = item
+	}
+
+	out := Rendering{Vars: make([]RenderedVar, 0, len(merged)), Unresolved: []UnresolvedRef{}}
+	for _, item := range merged {
+		out.Vars = append(out.Vars, r.resolve(item.Var.Key))
+	}
+	out.Unresolved = append(out.Unresolved, r.unresolved...)
+	return out
+}
+
+const (
+	renderPending = iota
+	renderActive
+	renderDone
+)
+
+type renderer struct {
+	scope      map[string]AttachedVar
+	state      map[string]int
+	done       map[string]RenderedVar
+	unresolved []UnresolvedRef
+	seen       map[UnresolvedRef]bool
+}
+
+func (r *renderer) resolve(key string) RenderedVar {
+	if r.state[key] == renderDone {
+		return r.done[key]
+	}
+	r.state[key] = renderActive
+
+	item := r.scope[key]
+	rendered := RenderedVar{
+		Key:        key,
+		Raw:        item.Var.Value,
+		SetID:      item.SetID,
+		SetName:    item.SetName,
+		References: []string{},
+	}
+	if item.Var.IsSecret {
+		rendered.Value, rendered.Raw, rendered.Masked = MaskedValue, MaskedValue, true
+		r.finish(rendered)
+		return rendered
+	}
+
+	var b strings.Builder
+	raw := item.Var.Value
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' || i+1 >= len(raw) {
+			b.WriteByte(raw[i])
+			continue
+		}
+		if raw[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		name, end := parseReference(raw, i)
+		if end < 0 {
+			b.WriteByte(raw[i])
+			continue
+		}
+		literal := raw[i:end]
+		i = end - 1
+		rendered.References = appendUnique(rendered.References, name)
+
+		if _, ok := r.scope[name]; !ok {
+			r.report(UnresolvedRef{Key: key, Reference: name, Reason: UnresolvedUndefined})
+			b.WriteString(literal)
+			continue
+		}
+		if r.state[name] == renderActive {
+			r.report(UnresolvedRef{Key: key, Reference: name, Reason: UnresolvedCycle})
+			b.WriteString(literal)
+			continue
+		}
+		ref := r.resolve(name)
+		rendered.Masked = rendered.Masked || ref.Masked
+		b.WriteString(ref.Value)
+	}
+	rendered.Value = b.String()
+	if rendered.Masked {
+		rendered.Value, rendered.Raw = MaskedValue, MaskedValue
+	}
+	r.finish(rendered)
+	return rendered
+}
+
+func (r *renderer) finish(rendered RenderedVar) {
+	r.state[rendered.Key] = renderDone
+	r.done[rendered.Key] = rendered
+}
+
+func (r *renderer) report(ref UnresolvedRef) {
+	if !r.seen[ref] {
+		r.seen[ref] = true
+		r.unresolved = append(r.unresolved, ref)
+	}
+}
+
+// parseReference parses ${NAME} starting at raw[i] == '$' and returns NAME
+// and the index just past the closing brace, or -1 when raw[i:] does not
+// start with a well-formed reference.
+func parseReference(raw string, i int) (string, int) {
+	if i+1 >= len(raw) || raw[i+1] != '{' {
+		return "", -1
+	}
+	closing := strings.IndexByte(raw[i+2:], '}')
+	if closing < 0 {
+		return "", -1
+	}
+	name := raw[i+2 : i+2+closing]
+	if !isVarName(name) {
+		return "", -1
+	}
+	return name, i + 3 + closing
+}
+
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,64 @@
+package sharedenv_test
+
+import (
+	"testing"
+
+	"github.com/websoft9/appos/backend/domain/config/sharedenv"
+)
+
+func TestRenderExpandsReferencesAndReportsProblems(t *testing.T) {
+	base := func(key, value string) sharedenv.AttachedVar {
+		return sharedenv.AttachedVar{SetID: "base", SetName: "Base", Var: sharedenv.Var{Key: key, Value: value}}
+	}
+	app := func(key, value string) sharedenv.AttachedVar {
+		return sharedenv.AttachedVar{SetID: "app", SetName: "App", Var: sharedenv.Var{Key: key, Value: value}}
+	}
+
+	rendering := sharedenv.Render([]sharedenv.AttachedVar{
+		base("DB_HOST", "db.internal"),
+		base("DB_PORT", "5432"),
+		{SetID: "base", SetName: "Base", Var: sharedenv.Var{Key: "DB_PASSWORD", IsSecret: true, SecretID: "sec1"}},
+		app("DB_URL", "postgres://${DB_HOST}:${DB_PORT}/app"),
+		app("DSN", "${DB_URL}?password=${DB_PASSWORD}"),
+		app("PRICE", "$$5 ${MISSING} ${not closed"),
+		app("A", "x${B}"),
+		app("B", "y${A}"),
+		app("DB_PORT", "6432"),
+	})
+
+	got := map[string]sharedenv.RenderedVar{}
+	for _, v := range rendering.Vars {
+		got[v.Key] = v
+	}
+	if len(rendering.Vars) != 8 {
+		t.Fatalf("expected 8 merged vars, got %+v", rendering.Vars)
+	}
+	if v := got["DB_URL"]; v.Value != "postgres://db.internal:6432/app" || v.Masked || len(v.References) != 2 {
+		t.Fatalf("unexpected DB_URL %+v", v)
+	}
+	if v := got["DB_PORT"]; v.SetID != "app" {
+		t.Fatalf("expected later set to win for DB_PORT, got %+v", v)
+	}
+	if v := got["DB_PASSWORD"]; v.Value != sharedenv.MaskedValue || !v.Masked {
+		t.Fatalf("expected secret masked, got %+v", v)
+	}
+	if v := got["DSN"]; v.Value != sharedenv.MaskedValue || v.Raw != sharedenv.MaskedValue || !v.Masked {
+		t.Fatalf("expected value referencing a secret to be masked, got %+v", v)
+	}
+	if v := got["PRICE"]; v.Value != "$5 ${MISSING} ${not closed" {
+		t.Fatalf("unexpected PRICE %+v", v)
+	}
+
+	want := []sharedenv.UnresolvedRef{
+		{Key: "PRICE", Reference: "MISSING", Reason: sharedenv.UnresolvedUndefined},
+		{Key: "B", Reference: "A", Reason: sharedenv.UnresolvedCycle},
+	}
+	if len(rendering.Unresolved) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, rendering.Unresolved)
+	}
+	for i := range want {
+		if rendering.Unresolved[i] != want[i] {
+			t.Fatalf("unresolved[%d] = %+v, want %+v", i, rendering.Unresolved[i], want[i])
+		}
+	}
+}
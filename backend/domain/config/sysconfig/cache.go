@@ -0,0 +1,129 @@
+package sysconfig
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// CacheTTL bounds how long a cached group is served without re-reading the
+// DB. Writes through PocketBase invalidate immediately; the TTL only covers
+// changes made outside the app (e.g. direct SQL).
+const CacheTTL = 30 * time.Second
+
+const groupCacheStoreKey = "sysconfig.groupCache"
+
+// groupCache holds parsed custom_settings groups for one app instance.
+// It lives in app.Store() so separate apps (and test apps) never share entries.
+type groupCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedGroup
+	// gen is bumped on every invalidation so a load that raced with a write
+	// does not store a stale value.
+	gen uint64
+}
+
+// cachedGroup is a loaded group, or a missing row (value nil, err set) so
+// hot paths without a stored row do not hit the DB either.
+type cachedGroup struct {
+	value  map[string]any
+	err    error
+	loaded time.Time
+}
+
+func groupCacheKey(module, key string) string {
+	return module + "/" + key
+}
+
+// profileCacheKey keys a profile's override row next to its global group.
+func profileCacheKey(module, key, profile string) string {
+	if profile == "" {
+		return groupCacheKey(module, key)
+	}
+	return groupCacheKey(module, key) + "@" + profile
+}
+
+// cacheFor returns the app's group cache, binding the invalidation hooks the
+// first time it is created.
+func cacheFor(app core.App) *groupCache {
+	return app.Store().GetOrSet(groupCacheStoreKey, func() any {
+		c := &groupCache{entries: map[string]cachedGroup{}}
+		invalidate := func(e *core.RecordEvent) error {
+			r := e.Record
+			c.invalidate(profileCacheKey(r.GetString("module"), r.GetString("key"), r.GetString("profile")))
+			// An update may have moved the row to a different (module, key, profile).
+			if orig := r.Original(); orig != nil {
+				c.invalidate(profileCacheKey(orig.GetString("module"), orig.GetString("key"), orig.GetString("profile")))
+			}
+			return e.Next()
+		}
+		app.OnRecordAfterCreateSuccess("custom_settings").BindFunc(invalidate)
+		app.OnRecordAfterUpdateSuccess("custom_settings").BindFunc(invalidate)
+		app.OnRecordAfterDeleteSuccess("custom_settings").BindFunc(invalidate)
+		return c
+	}).(*groupCache)
+}
+
+func (c *groupCache) get(k string) (cachedGroup, uint64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[k]
+	if ok && time.Since(entry.loaded) > CacheTTL {
+		ok = false
+	}
+	return entry, c.gen, ok
+}
+
+// put stores entry unless an invalidation happened since gen was read.
+func (c *groupCache) put(k string, entry cachedGroup, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen {
+		return
+	}
+	c.entries[k] = entry
+}
+
+func (c *groupCache) invalidate(k string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	delete(c.entries, k)
+}
+
+// InvalidateCache drops every cached settings group for app.
+func InvalidateCache(app core.App) {
+	c := cacheFor(app)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = map[string]cachedGroup{}
+}
+
+// cloneGroup deep-copies a JSON-decoded group so callers can mutate the
+// returned map without touching the cached copy.
+func cloneGroup(group map[string]any) map[string]any {
+	out := make(map[string]any, len(group))
+	for k, v := range group {
+		out[k] = cloneGroupValue(v)
+	}
+	return out
+}
+
+func cloneGroupValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		return cloneGroup(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = cloneGroupValue(item)
+		}
+		return out
+	case []string:
+		return append([]string(nil), val...)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,156 @@
+package sysconfig_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+
+	_ "github.com/websoft9/appos/backend/infra/migrations"
+)
+
+func newSettingsTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	return app
+}
+
+func TestGetGroupServesCachedCopyAndInvalidatesOnWrite(t *testing.T) {
+	app := newSettingsTestApp(t)
+
+	if err := sysconfig.SetGroup(app, "cachetest", "quota", map[string]any{"maxSizeMB": 10}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := sysconfig.GetGroup(app, "cachetest", "quota", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mutating the returned map must not leak into the cache.
+	first["maxSizeMB"] = 999
+
+	again, _ := sysconfig.GetGroup(app, "cachetest", "quota", nil)
+	if got := sysconfig.Int(again, "maxSizeMB", 0); got != 10 {
+		t.Fatalf("expected cached value 10, got %d", got)
+	}
+
+	if err := sysconfig.SetGroup(app, "cachetest", "quota", map[string]any{"maxSizeMB": 20}); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := sysconfig.GetGroup(app, "cachetest", "quota", nil)
+	if got := sysconfig.Int(updated, "maxSizeMB", 0); got != 20 {
+		t.Fatalf("expected write to invalidate cache, got %d", got)
+	}
+}
+
+func TestGetGroupCachesMissingRowUntilCreated(t *testing.T) {
+	app := newSettingsTestApp(t)
+	fallback := map[string]any{"maxSizeMB": 1}
+
+	v, err := sysconfig.GetGroup(app, "cachetest", "missing", fallback)
+	if err == nil || sysconfig.Int(v, "maxSizeMB", 0) != 1 {
+		t.Fatalf("expected fallback with error, got %v, %v", v, err)
+	}
+
+	// A write through the record API (not SetGroup) also invalidates.
+	col, err := app.FindCollectionByNameOrId("custom_settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := core.NewRecord(col)
+	rec.Set("module", "cachetest")
+	rec.Set("key", "missing")
+	rec.Set("value", map[string]any{"maxSizeMB": 5})
+	if err := app.Save(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = sysconfig.GetGroup(app, "cachetest", "missing", fallback)
+	if err != nil || sysconfig.Int(v, "maxSizeMB", 0) != 5 {
+		t.Fatalf("expected created row to be visible, got %v, %v", v, err)
+	}
+
+	if err := app.Delete(rec); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sysconfig.GetGroup(app, "cachetest", "missing", fallback); err == nil {
+		t.Fatal("expected deleted row to fall back again")
+	}
+}
+
+func TestGetGroupConcurrentReadsAndWrites(t *testing.T) {
+	app := newSettingsTestApp(t)
+	if err := sysconfig.SetGroup(app, "cachetest", "hot", map[string]any{"n": 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				v, _ := sysconfig.GetGroup(app, "cachetest", "hot", map[string]any{})
+				v["n"] = j
+			}
+		}()
+	}
+	for j := 1; j <= 5; j++ {
+		if err := sysconfig.SetGroup(app, "cachetest", "hot", map[string]any{"n": j}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	v, _ := sysconfig.GetGroup(app, "cachetest", "hot", nil)
+	if got := sysconfig.Int(v, "n", -1); got != 5 {
+		t.Fatalf("expected last written value 5, got %d", got)
+	}
+}
+
+func TestGetGroupBackfillsRegisteredDefaults(t *testing.T) {
+	app := newSettingsTestApp(t)
+	sysconfig.RegisterGroup("cachetest", "backfill", map[string]any{"a": 1, "b": "x"})
+
+	// No stored row yet: a nil fallback resolves to the registered defaults.
+	v, err := sysconfig.GetGroup(app, "cachetest", "backfill", nil)
+	if err == nil || sysconfig.Int(v, "a", 0) != 1 {
+		t.Fatalf("expected registered defaults as fallback, got %v, %v", v, err)
+	}
+
+	// A stored row missing a field is backfilled and reported as drift.
+	if err := sysconfig.SetGroup(app, "cachetest", "backfill", map[string]any{"a": 2}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = sysconfig.GetGroup(app, "cachetest", "backfill", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sysconfig.Int(v, "a", 0) != 2 || sysconfig.String(v, "b", "") != "x" {
+		t.Fatalf("expected stored a and backfilled b, got %v", v)
+	}
+
+	var found bool
+	for _, d := range sysconfig.CheckDefaults(app) {
+		if d.Module == "cachetest" && d.Key == "backfill" {
+			found = len(d.MissingFields) == 1 && d.MissingFields[0] == "b"
+		}
+	}
+	if !found {
+		t.Fatal("expected drift for missing field b")
+	}
+}
+
+func TestRegisterGroupRejectsDuplicates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected duplicate registration to panic")
+		}
+	}()
+	sysconfig.RegisterGroup("space", "quota", map[string]any{})
+}
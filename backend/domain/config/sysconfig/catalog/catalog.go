@@ -2,6 +2,8 @@ package catalog
 
 import (
 	"encoding/json"
+	"sort"
+	"strings"
 )
 
 const (
@@ -142,7 +144,8 @@ var entryCatalog = []EntrySchema{
 			{ID: "shareDefaultMinutes", Label: "Share Default Minutes", Type: "integer"},
 			{ID: "uploadAllowExts", Label: "Upload Allow Exts", Type: "string-list"},
 			{ID: "uploadDenyExts", Label: "Upload Deny Exts", Type: "string-list"},
-			{ID: "disallowedFolderNames", Label: "Disallowed Folder Names", Type: "string-list"},
+			{ID: "disallowedFolderNames", Label: "Disallowed Folder Names", Type: "string-list", HelpText: "Folder names users may not create or rename to, at any depth."},
+			{ID: "previewMimeTypes", Label: "Preview MIME Types", Type: "string-list", HelpText: "MIME types that may be previewed inline. Empty disables inline preview."},
 		},
 	},
 	{
@@ -154,7 +157,11 @@ var entryCatalog = []EntrySchema{
 		Key:     "terminal",
 		Fields: []FieldSchema{
 			{ID: "idleTimeoutSeconds", Label: "Idle Timeout Seconds", Type: "integer", HelpText: "Disconnect idle terminal sessions after this many seconds."},
+			{ID: "maxSessionSeconds", Label: "Max Session Seconds", Type: "integer", HelpText: "Disconnect terminal sessions this many seconds after they start, even when active. 0 means unlimited."},
 			{ID: "maxConnections", Label: "Max Connections", Type: "integer", HelpText: "0 means unlimited"},
+			{ID: "maxSessionsPerServer", Label: "Max Sessions Per Server", Type: "integer", HelpText: "Concurrent SSH terminal and SFTP connections to one server. Extra connections are refused as busy. 0 means unlimited."},
+			{ID: "sessionEnvAllowlist", Label: "Session Env Allowlist", Type: "string-list", HelpText: "Variable names a terminal client may set for its session with ?env=. A trailing * matches a prefix. Empty disables client env."},
+			{ID: "banner", Label: "Banner", Type: "string", HelpText: "Warning shown when an SSH terminal opens, e.g. \"Production system - actions are audited\". A server's own terminal banner takes precedence. Empty shows none."},
 		},
 	},
 	{
@@ -166,6 +173,8 @@ var entryCatalog = []EntrySchema{
 		Key:     "sftp",
 		Fields: []FieldSchema{
 			{ID: "maxUploadFiles", Label: "Max Upload Files", Type: "integer", HelpText: "Maximum number of files allowed in a single SFTP upload."},
+			{ID: "transferRateKBps", Label: "Transfer Rate KB/s", Type: "integer", HelpText: "Per-transfer bandwidth limit for SFTP download, upload and copy. 0 means unlimited."},
+			{ID: "maxArchiveMB", Label: "Max Archive MB", Type: "integer", HelpText: "Maximum total uncompressed size of a directory downloaded as a zip archive."},
 		},
 	},
 	{
@@ -177,6 +186,7 @@ var entryCatalog = []EntrySchema{
 		Key:     "preflight",
 		Fields: []FieldSchema{
 			{ID: "minFreeDiskBytes", Label: "Min Free Disk Bytes", Type: "integer", HelpText: "Block installation when available disk falls below this threshold."},
+			{ID: "healthTimeoutSeconds", Label: "Health Timeout Seconds", Type: "integer", HelpText: "How long a deploy waits after compose up for services with a healthcheck to report healthy."},
 		},
 	},
 	{
@@ -231,6 +241,37 @@ var entryCatalog = []EntrySchema{
 			{ID: "insecureRegistries", Label: "Insecure Registries", Type: "string-list"},
 		},
 	},
+	{
+		ID:          "docker-ssh",
+		Title:       "Docker SSH",
+		Description: "Connection limits for Docker operations and status checks on remote servers. Raise them for servers on slow or high-latency links.",
+		Section:     SectionWorkspace,
+		Source:      SourceCustom,
+		Module:      "docker",
+		Key:         "ssh",
+		Fields: []FieldSchema{
+			{ID: "dialTimeoutSeconds", Label: "Dial Timeout Seconds", Type: "integer", HelpText: "Time allowed for the TCP connect and SSH handshake."},
+			{ID: "commandTimeoutSeconds", Label: "Command Timeout Seconds", Type: "integer", HelpText: "Time allowed for each remote command. 0 means no limit."},
+			{ID: "retryDial", Label: "Retry Connect Once", Type: "boolean", HelpText: "Retry a failed connect once before reporting the server offline. Commands are never retried."},
+		},
+	},
+	{
+		ID:          "docker-scan",
+		Title:       "Image Vulnerability Scan",
+		Description: "Optional vulnerability scan for Docker images before deploying. Scans run trivy on the target server or post the image to an integration endpoint that returns a trivy JSON report.",
+		Section:     SectionWorkspace,
+		Source:      SourceCustom,
+		Module:      "docker",
+		Key:         "scan",
+		Fields: []FieldSchema{
+			{ID: "enabled", Label: "Enabled", Type: "boolean"},
+			{ID: "scanner", Label: "Scanner", Type: "string", HelpText: "trivy runs the trivy CLI on the image's server; endpoint posts to the integration endpoint."},
+			{ID: "endpoint", Label: "Endpoint URL", Type: "string", HelpText: "Receives {\"image\", \"server_id\"} as JSON and returns a trivy JSON report."},
+			{ID: "endpointToken", Label: "Endpoint Token", Type: "string", Sensitive: true, HelpText: "Sent as a Bearer token. Optional."},
+			{ID: "timeoutSeconds", Label: "Timeout Seconds", Type: "integer"},
+			{ID: "topLimit", Label: "Top Findings", Type: "integer", HelpText: "Number of most severe findings returned with the counts."},
+		},
+	},
 	{
 		ID:          "docker-registries",
 		Title:       "Docker Registries",
@@ -270,6 +311,86 @@ var entryCatalog = []EntrySchema{
 			},
 		},
 	},
+	{
+		ID:          "ratelimit-routes",
+		Title:       "Rate Limits",
+		Description: "Per-user (or per-IP when unauthenticated) token buckets for expensive endpoints. Requests over the limit get 429 with Retry-After.",
+		Section:     SectionSystem,
+		Source:      SourceCustom,
+		Module:      "ratelimit",
+		Key:         "routes",
+		Fields: []FieldSchema{
+			{ID: "spaceFetchPerMinute", Label: "Space Fetch Per Minute", Type: "integer", HelpText: "Remote URL fetches into Space. 0 means unlimited."},
+			{ID: "spaceFetchBurst", Label: "Space Fetch Burst", Type: "integer"},
+			{ID: "dockerPullPerMinute", Label: "Image Pull Per Minute", Type: "integer", HelpText: "Docker image pulls. 0 means unlimited."},
+			{ID: "dockerPullBurst", Label: "Image Pull Burst", Type: "integer"},
+			{ID: "serverOpsPerMinute", Label: "Server Commands Per Minute", Type: "integer", HelpText: "Server operations and container exec that run commands over SSH. 0 means unlimited."},
+			{ID: "serverOpsBurst", Label: "Server Commands Burst", Type: "integer"},
+		},
+	},
+	{
+		ID:          "http-compression",
+		Title:       "Response Compression",
+		Description: "Gzip compression of JSON and text API responses for clients that accept it. Streams, downloads and WebSocket connections are never compressed.",
+		Section:     SectionSystem,
+		Source:      SourceCustom,
+		Module:      "http",
+		Key:         "compression",
+		Fields: []FieldSchema{
+			{ID: "enabled", Label: "Enable Compression", Type: "boolean"},
+			{ID: "minSizeBytes", Label: "Min Size Bytes", Type: "integer", HelpText: "Responses smaller than this are sent uncompressed."},
+		},
+	},
+	{
+		ID:          "http-limits",
+		Title:       "Request Limits",
+		Description: "Size cap for JSON and form bodies sent to AppOS endpoints. Upload and file-editing endpoints use the IaC, Space and SFTP limits instead.",
+		Section:     SectionSystem,
+		Source:      SourceCustom,
+		Module:      "http",
+		Key:         "limits",
+		Fields: []FieldSchema{
+			{ID: "maxBodyMB", Label: "Max Body MB", Type: "integer", HelpText: "Larger request bodies are rejected with 413."},
+			{ID: "maxImportMB", Label: "Max Import MB", Type: "integer", HelpText: "Size cap for resource import documents, which are usually larger than other bodies."},
+		},
+	},
+	{
+		ID:          "security-stepup",
+		Title:       "Step-up Confirmation",
+		Description: "Actions that require a fresh TOTP code in the X-AppOS-TOTP header on every request, even within a signed-in session.",
+		Section:     SectionSystem,
+		Source:      SourceCustom,
+		Module:      "security",
+		Key:         "stepup",
+		Fields: []FieldSchema{
+			{ID: "actions", Label: "Actions", Type: "string-list", HelpText: "One of server.power, superuser.delete, secret.payload.update, docker.exec, terminal.broadcast."},
+		},
+	},
+	{
+		ID:          "audit-actions",
+		Title:       "Audit Actions",
+		Description: "Which successful actions are written to the audit log. Failed and attention-required actions are always recorded.",
+		Section:     SectionSystem,
+		Source:      SourceCustom,
+		Module:      "audit",
+		Key:         "actions",
+		Fields: []FieldSchema{
+			{ID: "excludeActions", Label: "Excluded Actions", Type: "string-list", HelpText: "Action IDs, or prefixes ending in .*, not recorded on success."},
+			{ID: "includeActions", Label: "Included Actions", Type: "string-list", HelpText: "Always recorded, even when matched by an exclusion."},
+		},
+	},
+	{
+		ID:          "audit-export",
+		Title:       "Audit Export",
+		Description: "Cap on how many audit log entries one streamed export returns.",
+		Section:     SectionSystem,
+		Source:      SourceCustom,
+		Module:      "audit",
+		Key:         "export",
+		Fields: []FieldSchema{
+			{ID: "maxRows", Label: "Max Rows", Type: "integer", HelpText: "The export limit parameter is capped to this value."},
+		},
+	},
 }
 
 var customSettingDefaults = map[string]map[string]any{
@@ -280,6 +401,12 @@ var customSettingDefaults = map[string]map[string]any{
 		"shareDefaultMinutes":   30,
 		"maxUploadFiles":        50,
 		"disallowedFolderNames": []string{},
+		"previewMimeTypes": []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp", "image/svg+xml",
+			"image/bmp", "image/x-icon", "application/pdf",
+			"audio/mpeg", "audio/wav", "audio/ogg", "audio/aac", "audio/flac", "audio/webm",
+			"video/mp4", "video/webm", "video/ogg",
+		},
 	},
 	"proxy/network": {
 		"httpProxy": "", "httpsProxy": "", "noProxy": "", "username": "", "password": "",
@@ -287,9 +414,23 @@ var customSettingDefaults = map[string]map[string]any{
 	"docker/mirror": {
 		"mirrors": []any{}, "insecureRegistries": []any{},
 	},
+	"docker/scan": {
+		"enabled": false, "scanner": "trivy", "endpoint": "", "endpointToken": "",
+		"timeoutSeconds": 300, "topLimit": 10,
+	},
 	"docker/registries": {"items": []any{}},
-	"connect/sftp":      {"maxUploadFiles": 10},
-	"connect/terminal":  {"idleTimeoutSeconds": 1800, "maxConnections": 0},
+	"docker/ssh":        {"dialTimeoutSeconds": 10, "commandTimeoutSeconds": 0, "retryDial": false},
+	"connect/sftp":      {"maxUploadFiles": 10, "transferRateKBps": 0, "maxArchiveMB": 1024},
+	"connect/terminal":  {"idleTimeoutSeconds": 1800, "maxSessionSeconds": 0, "maxConnections": 0, "maxSessionsPerServer": 10, "sessionEnvAllowlist": []any{"APPOS_*"}, "banner": ""},
+	"security/stepup":   {"actions": []any{}},
+	"audit/actions": {
+		"excludeActions": []any{
+			"server.ops.systemd.services", "server.ops.systemd.status", "server.ops.systemd.content",
+			"server.ops.systemd.logs", "server.ops.ports.list", "server.ops.port.inspect",
+		},
+		"includeActions": []any{},
+	},
+	"audit/export": {"maxRows": 100000},
 	"files/limits": {
 		"maxSizeMB":          10,
 		"maxZipSizeMB":       50,
@@ -301,7 +442,17 @@ var customSettingDefaults = map[string]map[string]any{
 		"defaultAccessMode":     "use_only",
 		"clipboardClearSeconds": 0,
 	},
-	"deploy/preflight": {"minFreeDiskBytes": 512 * 1024 * 1024},
+	"deploy/preflight": {"minFreeDiskBytes": 512 * 1024 * 1024, "healthTimeoutSeconds": 120},
+	"ratelimit/routes": {
+		"spaceFetchPerMinute": 10,
+		"spaceFetchBurst":     5,
+		"dockerPullPerMinute": 10,
+		"dockerPullBurst":     3,
+		"serverOpsPerMinute":  120,
+		"serverOpsBurst":      30,
+	},
+	"http/compression": {"enabled": true, "minSizeBytes": 1024},
+	"http/limits":      {"maxBodyMB": 2, "maxImportMB": 64},
 	"topic/share": {
 		"shareMaxMinutes":     60,
 		"shareDefaultMinutes": 30,
@@ -344,6 +495,23 @@ func DefaultGroup(module, key string) map[string]any {
 	return cloneMap(customSettingDefaults[module+"/"+key])
 }
 
+// DefaultGroups returns every declared custom settings default, sorted by
+// module/key. sysconfig registers these at init as its default registry.
+func DefaultGroups() []CustomSettingSeedRow {
+	keys := make([]string, 0, len(customSettingDefaults))
+	for k := range customSettingDefaults {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]CustomSettingSeedRow, 0, len(keys))
+	for _, k := range keys {
+		module, key, _ := strings.Cut(k, "/")
+		out = append(out, CustomSettingSeedRow{Module: module, Key: key, Value: cloneMap(customSettingDefaults[k])})
+	}
+	return out
+}
+
 func SeedRows() []CustomSettingSeedRow {
 	out := make([]CustomSettingSeedRow, 0, len(entryCatalog))
 	for _, entry := range entryCatalog {
@@ -0,0 +1,106 @@
+package sysconfig
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// EnvProfile selects the active settings profile, e.g. "dev", "staging" or
+// "prod". Unset (or invalid) means no profile: only global groups apply.
+const EnvProfile = "APPOS_SETTINGS_PROFILE"
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidProfile reports whether name can be used as a settings profile.
+func ValidProfile(name string) bool {
+	return profileNamePattern.MatchString(name)
+}
+
+// ActiveProfile returns the profile selected by EnvProfile, normalised to
+// lower case, or "" when none (or an invalid name) is configured.
+func ActiveProfile() string {
+	profile := strings.ToLower(strings.TrimSpace(os.Getenv(EnvProfile)))
+	if !ValidProfile(profile) {
+		return ""
+	}
+	return profile
+}
+
+// ProfileOverrides returns the fields profile overrides for (module, key).
+// The map is empty, not nil, when the profile overrides nothing.
+func ProfileOverrides(app core.App, profile, module, key string) (map[string]any, error) {
+	if !ValidProfile(profile) {
+		return map[string]any{}, fmt.Errorf("settings.ProfileOverrides(%s/%s): invalid profile %q", module, key, profile)
+	}
+	value, err := readGroup(app, module, key, profile)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return map[string]any{}, fmt.Errorf("settings.ProfileOverrides(%s/%s@%s): %w", module, key, profile, err)
+	}
+	if value == nil {
+		return map[string]any{}, nil
+	}
+	return value, nil
+}
+
+// SetProfileOverrides replaces the fields profile overrides for (module,
+// key). Fields left out fall back to the global group. An empty overrides
+// map deletes the profile's row.
+func SetProfileOverrides(app core.App, profile, module, key string, overrides map[string]any) error {
+	if !ValidProfile(profile) {
+		return fmt.Errorf("settings.SetProfileOverrides(%s/%s): invalid profile %q", module, key, profile)
+	}
+
+	record, err := findGroupRecord(app, module, key, profile)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("settings.SetProfileOverrides(%s/%s@%s): %w", module, key, profile, err)
+	}
+	if len(overrides) == 0 {
+		if record == nil {
+			return nil
+		}
+		if err := app.Delete(record); err != nil {
+			return fmt.Errorf("settings.SetProfileOverrides(%s/%s@%s): delete: %w", module, key, profile, err)
+		}
+		return nil
+	}
+
+	if record == nil {
+		collection, colErr := app.FindCollectionByNameOrId("custom_settings")
+		if colErr != nil {
+			return fmt.Errorf("settings.SetProfileOverrides(%s/%s@%s): find collection: %w", module, key, profile, colErr)
+		}
+		record = core.NewRecord(collection)
+		record.Set("module", module)
+		record.Set("key", key)
+		record.Set("profile", profile)
+	}
+
+	record.Set("value", overrides)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("settings.SetProfileOverrides(%s/%s@%s): save: %w", module, key, profile, err)
+	}
+	return nil
+}
+
+// Profiles lists the profiles that have at least one stored override.
+func Profiles(app core.App) ([]string, error) {
+	profiles := []string{}
+	err := app.DB().
+		Select("profile").
+		Distinct(true).
+		From("custom_settings").
+		Where(dbx.NewExp("profile != ''")).
+		OrderBy("profile").
+		Column(&profiles)
+	if err != nil {
+		return []string{}, fmt.Errorf("settings.Profiles: %w", err)
+	}
+	return profiles, nil
+}
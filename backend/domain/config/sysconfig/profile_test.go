@@ -0,0 +1,95 @@
+package sysconfig_test
+
+import (
+	"testing"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+func TestGetGroupAppliesActiveProfileOverrides(t *testing.T) {
+	app := newSettingsTestApp(t)
+
+	if err := sysconfig.SetGroup(app, "profiletest", "limits", map[string]any{"maxSizeMB": 10, "mode": "strict"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sysconfig.SetProfileOverrides(app, "dev", "profiletest", "limits", map[string]any{"maxSizeMB": 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	// No active profile: global values only.
+	t.Setenv(sysconfig.EnvProfile, "")
+	v, _ := sysconfig.GetGroup(app, "profiletest", "limits", nil)
+	if got := sysconfig.Int(v, "maxSizeMB", 0); got != 10 {
+		t.Fatalf("expected global maxSizeMB 10, got %d", got)
+	}
+
+	t.Setenv(sysconfig.EnvProfile, " DEV ")
+	if got := sysconfig.ActiveProfile(); got != "dev" {
+		t.Fatalf("expected active profile dev, got %q", got)
+	}
+	v, _ = sysconfig.GetGroup(app, "profiletest", "limits", nil)
+	if got := sysconfig.Int(v, "maxSizeMB", 0); got != 50 {
+		t.Fatalf("expected dev override 50, got %d", got)
+	}
+	if got := sysconfig.String(v, "mode", ""); got != "strict" {
+		t.Fatalf("expected fields without override to fall back to global, got %q", got)
+	}
+
+	// A profile without overrides behaves like no profile.
+	t.Setenv(sysconfig.EnvProfile, "prod")
+	v, _ = sysconfig.GetGroup(app, "profiletest", "limits", nil)
+	if got := sysconfig.Int(v, "maxSizeMB", 0); got != 10 {
+		t.Fatalf("expected prod to fall back to global 10, got %d", got)
+	}
+
+	// Global writes stay global and overrides follow their edits.
+	t.Setenv(sysconfig.EnvProfile, "dev")
+	if err := sysconfig.SetGroup(app, "profiletest", "limits", map[string]any{"maxSizeMB": 12, "mode": "relaxed"}); err != nil {
+		t.Fatal(err)
+	}
+	v, _ = sysconfig.GetGroup(app, "profiletest", "limits", nil)
+	if sysconfig.Int(v, "maxSizeMB", 0) != 50 || sysconfig.String(v, "mode", "") != "relaxed" {
+		t.Fatalf("expected dev override over updated global, got %v", v)
+	}
+	global, _ := sysconfig.GetProfileGroup(app, "", "profiletest", "limits", nil)
+	if got := sysconfig.Int(global, "maxSizeMB", 0); got != 12 {
+		t.Fatalf("expected global maxSizeMB 12, got %d", got)
+	}
+
+	// Clearing the overrides removes the row.
+	if err := sysconfig.SetProfileOverrides(app, "dev", "profiletest", "limits", nil); err != nil {
+		t.Fatal(err)
+	}
+	v, _ = sysconfig.GetGroup(app, "profiletest", "limits", nil)
+	if got := sysconfig.Int(v, "maxSizeMB", 0); got != 12 {
+		t.Fatalf("expected cleared override to fall back to 12, got %d", got)
+	}
+	profiles, err := sysconfig.Profiles(app)
+	if err != nil || len(profiles) != 0 {
+		t.Fatalf("expected no profiles, got %v (%v)", profiles, err)
+	}
+}
+
+func TestProfileOverridesFallBackToDefaultsWithoutGlobalRow(t *testing.T) {
+	app := newSettingsTestApp(t)
+	fallback := map[string]any{"maxSizeMB": 1, "mode": "strict"}
+
+	if err := sysconfig.SetProfileOverrides(app, "staging", "profiletest", "missing", map[string]any{"mode": "relaxed"}); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := sysconfig.GetProfileGroup(app, "staging", "profiletest", "missing", fallback)
+	if sysconfig.Int(v, "maxSizeMB", 0) != 1 || sysconfig.String(v, "mode", "") != "relaxed" {
+		t.Fatalf("expected defaults with override, got %v", v)
+	}
+	if sysconfig.String(fallback, "mode", "") != "strict" {
+		t.Fatalf("fallback map was modified: %v", fallback)
+	}
+
+	if err := sysconfig.SetProfileOverrides(app, "Bad Name", "profiletest", "missing", map[string]any{"mode": "x"}); err == nil {
+		t.Fatal("expected invalid profile name to be rejected")
+	}
+	profiles, _ := sysconfig.Profiles(app)
+	if len(profiles) != 1 || profiles[0] != "staging" {
+		t.Fatalf("expected [staging], got %v", profiles)
+	}
+}
@@ -0,0 +1,172 @@
+package sysconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/core"
+	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
+)
+
+// GroupDefaults is one registered settings group and its default value.
+type GroupDefaults struct {
+	Module   string
+	Key      string
+	Defaults map[string]any
+}
+
+// DefaultsDrift describes a stored group that no longer matches its registered
+// defaults: the row is missing, or it lacks fields the defaults declare.
+type DefaultsDrift struct {
+	Module        string
+	Key           string
+	MissingRow    bool
+	MissingFields []string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]GroupDefaults{}
+)
+
+// The catalog declares the built-in groups; domains may register more.
+func init() {
+	for _, row := range settingscatalog.DefaultGroups() {
+		RegisterGroup(row.Module, row.Key, row.Value)
+	}
+}
+
+// RegisterGroup declares the defaults for (module, key). The registry is the
+// single source of truth for defaults: GetGroup falls back to and backfills
+// from it, and the custom_settings seed migration writes it. Registering the
+// same group twice panics.
+func RegisterGroup(module, key string, defaults map[string]any) {
+	normalized, err := normalizeGroup(defaults)
+	if err != nil {
+		panic(fmt.Sprintf("sysconfig.RegisterGroup(%s/%s): %v", module, key, err))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	k := groupCacheKey(module, key)
+	if _, exists := registry[k]; exists {
+		panic(fmt.Sprintf("sysconfig.RegisterGroup(%s/%s): already registered", module, key))
+	}
+	registry[k] = GroupDefaults{Module: module, Key: key, Defaults: normalized}
+}
+
+// RegisteredDefaults returns a copy of the registered defaults for (module, key).
+func RegisteredDefaults(module, key string) (map[string]any, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[groupCacheKey(module, key)]
+	if !ok {
+		return nil, false
+	}
+	return cloneGroup(g.Defaults), true
+}
+
+// RegisteredGroups returns every registered group sorted by module/key.
+func RegisteredGroups() []GroupDefaults {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]GroupDefaults, 0, len(registry))
+	for _, g := range registry {
+		out = append(out, GroupDefaults{Module: g.Module, Key: g.Key, Defaults: cloneGroup(g.Defaults)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return groupCacheKey(out[i].Module, out[i].Key) < groupCacheKey(out[j].Module, out[j].Key)
+	})
+	return out
+}
+
+// SeedRegisteredGroups writes the registered defaults for every group that has
+// no stored row yet. Existing rows are left untouched.
+func SeedRegisteredGroups(app core.App) error {
+	for _, g := range RegisteredGroups() {
+		if _, err := loadGroup(app, g.Module, g.Key, ""); err == nil {
+			continue
+		}
+		if err := SetGroup(app, g.Module, g.Key, g.Defaults); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckDefaults compares stored groups against the registry. Values may have
+// been edited, so only missing rows and missing fields count as drift.
+func CheckDefaults(app core.App) []DefaultsDrift {
+	var drifts []DefaultsDrift
+	for _, g := range RegisteredGroups() {
+		stored, err := loadGroup(app, g.Module, g.Key, "")
+		if err != nil {
+			drifts = append(drifts, DefaultsDrift{Module: g.Module, Key: g.Key, MissingRow: true})
+			continue
+		}
+		var missing []string
+		for field := range g.Defaults {
+			if _, ok := stored[field]; !ok {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			drifts = append(drifts, DefaultsDrift{Module: g.Module, Key: g.Key, MissingFields: missing})
+		}
+	}
+	return drifts
+}
+
+// MatchesDefaults reports whether stored equals the registered defaults for
+// (module, key) after JSON normalisation.
+func MatchesDefaults(module, key string, stored map[string]any) bool {
+	defaults, ok := RegisteredDefaults(module, key)
+	if !ok {
+		return false
+	}
+	normalized, err := normalizeGroup(stored)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(defaults, normalized)
+}
+
+// backfillDefaults adds registered default fields that value lacks.
+func backfillDefaults(module, key string, value map[string]any) map[string]any {
+	registryMu.RLock()
+	g, ok := registry[groupCacheKey(module, key)]
+	registryMu.RUnlock()
+	if !ok {
+		return value
+	}
+	for field, def := range g.Defaults {
+		if _, exists := value[field]; !exists {
+			value[field] = cloneGroupValue(def)
+		}
+	}
+	return value
+}
+
+// normalizeGroup round-trips through JSON so registered defaults have the same
+// shapes (float64, []any) as values loaded from the DB.
+func normalizeGroup(group map[string]any) (map[string]any, error) {
+	if group == nil {
+		return map[string]any{}, nil
+	}
+	raw, err := json.Marshal(group)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+	return out, nil
+}
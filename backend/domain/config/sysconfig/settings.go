@@ -6,21 +6,34 @@
 // JSON blob containing all fields for that group.
 //
 // Design rules:
+//   - Defaults for each group are declared once via RegisterGroup (the catalog
+//     groups are registered at init). GetGroup and the seed migration both
+//     read them from that registry.
 //   - GetGroup ALWAYS returns a non-nil map.  On any error (row missing, DB
 //     failure, unmarshal error) it returns (fallback, err).  Callers that use
 //     v, _ := GetGroup(...)
 //     are therefore safe; they get the fallback map and can immediately read
 //     typed values from it.
+//   - GetGroup serves groups from a per-app in-memory cache. Any save or
+//     delete of a custom_settings record invalidates the affected group, so
+//     changes take effect on the next read.
 //   - SetGroup upserts a row: find-then-update or create-then-save.
+//   - Rows with a non-empty profile hold per-environment overrides (see
+//     profile.go). GetGroup applies the active profile's fields over the
+//     global group, so anything a profile leaves out falls back to the
+//     global value and then to the registered defaults.
 //   - Int / String are typed field readers that operate on an already-loaded
 //     group map and never panic.
 package sysconfig
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
@@ -28,24 +41,97 @@ import (
 
 // GetGroup loads the settings group identified by (module, key) from custom_settings.
 //
-// On success it returns (parsed value map, nil).
+// On success it returns (parsed value map, nil), with any field missing from
+// the stored row backfilled from the group's registered defaults.
 // On any error — row not found, DB failure, JSON parse error — it returns
-// (fallback, err).  The returned map is always non-nil so callers can safely
-// use  v, _ := GetGroup(...)  without a nil check.
+// (fallback, err).  A nil fallback means the registered defaults (or an empty
+// map for unregistered groups), so the returned map is always non-nil and
+// callers can safely use  v, _ := GetGroup(...)  without a nil check.
+//
+// When a settings profile is active (see ActiveProfile) the fields that
+// profile overrides are applied on top of the result.
 func GetGroup(app core.App, module, key string, fallback map[string]any) (map[string]any, error) {
-	record, err := app.FindFirstRecordByFilter(
-		"custom_settings",
-		"module = {:module} && key = {:key}",
-		dbx.Params{"module": module, "key": key},
-	)
+	return GetProfileGroup(app, ActiveProfile(), module, key, fallback)
+}
+
+// GetProfileGroup is GetGroup resolved for profile instead of the active
+// one. An empty profile returns the global group.
+func GetProfileGroup(app core.App, profile, module, key string, fallback map[string]any) (map[string]any, error) {
+	if fallback == nil {
+		fallback, _ = RegisteredDefaults(module, key)
+		if fallback == nil {
+			fallback = map[string]any{}
+		}
+	}
+
+	value, err := readGroup(app, module, key, "")
+	if err != nil {
+		// Row not found — return fallback so caller always has a valid map.
+		value, err = fallback, fmt.Errorf("settings.GetGroup(%s/%s): %w", module, key, err)
+	} else if value == nil {
+		value = fallback
+	} else {
+		value = backfillDefaults(module, key, value)
+	}
+	if profile == "" {
+		return value, err
+	}
+
+	overrides, oerr := readGroup(app, module, key, profile)
+	if oerr != nil && !errors.Is(oerr, sql.ErrNoRows) {
+		return value, fmt.Errorf("settings.GetGroup(%s/%s@%s): %w", module, key, profile, oerr)
+	}
+	if len(overrides) > 0 {
+		// value may be the caller's fallback; never write into it.
+		value = cloneGroup(value)
+		for field, v := range overrides {
+			value[field] = v
+		}
+	}
+	return value, err
+}
+
+// readGroup returns a private copy of the stored row for (module, key,
+// profile), served from the cache outside transactions. A missing row is
+// reported as sql.ErrNoRows.
+func readGroup(app core.App, module, key, profile string) (map[string]any, error) {
+	// Transactions may see uncommitted rows; read those straight from the DB.
+	if app.IsTransactional() {
+		return loadGroup(app, module, key, profile)
+	}
+
+	cache := cacheFor(app)
+	k := profileCacheKey(module, key, profile)
+	entry, gen, ok := cache.get(k)
+	if !ok {
+		value, err := loadGroup(app, module, key, profile)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		entry = cachedGroup{value: value, err: err, loaded: time.Now()}
+		cache.put(k, entry, gen)
+	}
+
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	if entry.value == nil {
+		return nil, nil
+	}
+	return cloneGroup(entry.value), nil
+}
+
+// loadGroup reads and parses one group from the DB. A nil map with a nil
+// error means the stored value was JSON null.
+func loadGroup(app core.App, module, key, profile string) (map[string]any, error) {
+	record, err := findGroupRecord(app, module, key, profile)
 	if err != nil {
-		// Row not found or DB error — return fallback so caller always has a valid map.
-		return fallback, fmt.Errorf("settings.GetGroup(%s/%s): %w", module, key, err)
+		return nil, err
 	}
 
 	rawValue := record.Get("value")
 	if rawValue == nil {
-		return fallback, fmt.Errorf("settings.GetGroup(%s/%s): value is nil", module, key)
+		return nil, fmt.Errorf("value is nil")
 	}
 
 	// PocketBase stores JSON fields as json.RawMessage or map — normalise to string then unmarshal.
@@ -61,16 +147,13 @@ func GetGroup(app core.App, module, key string, fallback map[string]any) (map[st
 		// Attempt re-marshal for other types (e.g. map[string]any from PB internal parsing).
 		jsonBytes, err = json.Marshal(v)
 		if err != nil {
-			return fallback, fmt.Errorf("settings.GetGroup(%s/%s): marshal raw value: %w", module, key, err)
+			return nil, fmt.Errorf("marshal raw value: %w", err)
 		}
 	}
 
 	var result map[string]any
 	if err := json.Unmarshal(jsonBytes, &result); err != nil {
-		return fallback, fmt.Errorf("settings.GetGroup(%s/%s): unmarshal: %w", module, key, err)
-	}
-	if result == nil {
-		return fallback, nil
+		return nil, fmt.Errorf("unmarshal: %w", err)
 	}
 	return result, nil
 }
@@ -81,11 +164,7 @@ func GetGroup(app core.App, module, key string, fallback map[string]any) (map[st
 // otherwise a new row is created.  The value map is stored as JSON.
 func SetGroup(app core.App, module, key string, value map[string]any) error {
 	// Try to find existing record first.
-	record, err := app.FindFirstRecordByFilter(
-		"custom_settings",
-		"module = {:module} && key = {:key}",
-		dbx.Params{"module": module, "key": key},
-	)
+	record, err := findGroupRecord(app, module, key, "")
 	if err != nil {
 		// Not found — create a new record.
 		collection, colErr := app.FindCollectionByNameOrId("custom_settings")
@@ -104,6 +183,30 @@ func SetGroup(app core.App, module, key string, value map[string]any) error {
 	return nil
 }
 
+// findGroupRecord finds the custom_settings row for (module, key, profile).
+// Before the profile column exists (the seed in the collection's create
+// migration) only global rows can be found.
+func findGroupRecord(app core.App, module, key, profile string) (*core.Record, error) {
+	col, err := app.FindCollectionByNameOrId("custom_settings")
+	if err != nil {
+		return nil, err
+	}
+	filter := "module = {:module} && key = {:key}"
+	switch {
+	case col.Fields.GetByName("profile") == nil:
+		if profile != "" {
+			return nil, sql.ErrNoRows
+		}
+	case profile == "":
+		// An empty placeholder does not compare equal to '' in PB filters.
+		filter += " && profile = ''"
+	default:
+		filter += " && profile = {:profile}"
+	}
+	return app.FindFirstRecordByFilter(col, filter,
+		dbx.Params{"module": module, "key": key, "profile": profile})
+}
+
 // Int reads an integer field from an already-loaded group map.
 //
 // It handles float64 (JSON number default), int, int64, json.Number, and
@@ -151,6 +254,27 @@ func String(group map[string]any, field string, fallback string) string {
 	return s
 }
 
+// Bool reads a boolean field from an already-loaded group map. It also
+// accepts "true"/"false" strings. Returns fallback when the field is absent
+// or unreadable.
+func Bool(group map[string]any, field string, fallback bool) bool {
+	v, ok := group[field]
+	if !ok || v == nil {
+		return fallback
+	}
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, err := strconv.ParseBool(b)
+		if err != nil {
+			return fallback
+		}
+		return parsed
+	}
+	return fallback
+}
+
 // StringSlice reads a string-array field from a loaded group map.
 //
 // Supported underlying shapes:
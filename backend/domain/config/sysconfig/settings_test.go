@@ -75,6 +75,28 @@ func TestInt_StringInvalid(t *testing.T) {
 	}
 }
 
+// ─── Bool() tests ─────────────────────────────────────────────────────────
+
+func TestBool_Present(t *testing.T) {
+	g := map[string]any{"enabled": false, "legacy": "true"}
+	if got := sysconfig.Bool(g, "enabled", true); got {
+		t.Error("expected false, got true")
+	}
+	if got := sysconfig.Bool(g, "legacy", false); !got {
+		t.Error("expected true from string \"true\", got false")
+	}
+}
+
+func TestBool_MissingOrWrongType(t *testing.T) {
+	g := map[string]any{"enabled": 1}
+	if got := sysconfig.Bool(g, "enabled", true); !got {
+		t.Error("expected fallback true for non-boolean value")
+	}
+	if got := sysconfig.Bool(g, "missing", true); !got {
+		t.Error("expected fallback true for missing field")
+	}
+}
+
 // ─── GetGroup fallback tests (no real DB required) ────────────────────────
 
 // nilApp is a minimal stub that satisfies core.App for compile-time testing.
@@ -32,6 +32,11 @@ const (
 	AdapterGitCompose    = "git-compose"
 	AdapterSourceBuild   = "source-build"
 	MaxExecutionLogBytes = 64 * 1024
+
+	// Deploy results recorded in audit detail once compose up has run:
+	// whether every service with a healthcheck reported healthy in time.
+	ResultHealthy   = "deployed_healthy"
+	ResultUnhealthy = "deployed_unhealthy"
 )
 
 var activeExecutionStatuses = []string{
@@ -0,0 +1,43 @@
+package groups
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterHooks lets record-API creates of every resource type carry a
+// CreateField array. The IDs are validated before the record is saved and the
+// memberships are written in the same transaction, so a resource is either
+// created already grouped or not at all.
+func RegisterHooks(app core.App) {
+	for _, rt := range ResourceTypes {
+		objectType := rt.ObjectType
+		app.OnRecordCreateRequest(rt.Collection).BindFunc(func(e *core.RecordRequestEvent) error {
+			info, err := e.RequestInfo()
+			if err != nil {
+				return err
+			}
+			ids := ParseIDs(info.Body[CreateField])
+			if len(ids) == 0 {
+				return e.Next()
+			}
+			// group_items can only be written by superusers.
+			if !e.HasSuperuserAuth() {
+				return validation.Errors{CreateField: validation.NewError(
+					"validation_groups_forbidden", "only superusers can assign groups",
+				)}
+			}
+			if err := ValidateIDs(e.App, ids); err != nil {
+				return err
+			}
+
+			return e.App.RunInTransaction(func(txApp core.App) error {
+				e.App = txApp
+				if err := e.Next(); err != nil {
+					return err
+				}
+				return Assign(txApp, objectType, e.Record.Id, ids)
+			})
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package groups
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ─── Group assignment on create ───────────────────────────────────────────────
+
+// CreateField is the optional request body field listing the group IDs a new
+// resource is assigned to.
+const CreateField = "groups"
+
+// ParseIDs reads a CreateField value: a JSON array of group IDs or, from
+// multipart forms, a JSON-encoded array, a single ID or a comma-separated
+// list. IDs are trimmed and de-duplicated in order.
+func ParseIDs(raw any) []string {
+	var values []string
+	switch v := raw.(type) {
+	case nil:
+	case []string:
+		values = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case string:
+		if s := strings.TrimSpace(v); strings.HasPrefix(s, "[") {
+			_ = json.Unmarshal([]byte(s), &values)
+		} else {
+			values = strings.Split(s, ",")
+		}
+	}
+
+	ids := make([]string, 0, len(values))
+	seen := map[string]struct{}{}
+	for _, id := range values {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ValidateIDs checks that every id names an existing group. Unknown IDs are
+// reported as a validation error on CreateField.
+func ValidateIDs(app core.App, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	records, err := app.FindAllRecords(Collection, dbx.In("id", values...))
+	if err != nil {
+		return fmt.Errorf("groups: lookup: %w", err)
+	}
+	found := make(map[string]struct{}, len(records))
+	for _, r := range records {
+		found[r.Id] = struct{}{}
+	}
+	var unknown []string
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return validation.Errors{CreateField: validation.NewError(
+		"validation_unknown_groups",
+		"unknown groups: "+strings.Join(unknown, ", "),
+	)}
+}
+
+// Assign adds the object to every group in ids. Existing memberships are kept.
+func Assign(app core.App, objectType ObjectType, objectID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	col, err := app.FindCollectionByNameOrId(ItemsCollection)
+	if err != nil {
+		return err
+	}
+	existing, err := app.FindRecordsByFilter(ItemsCollection,
+		"object_type = {:type} && object_id = {:id}", "", 0, 0,
+		dbx.Params{"type": string(objectType), "id": objectID})
+	if err != nil {
+		return err
+	}
+	member := make(map[string]struct{}, len(existing))
+	for _, item := range existing {
+		member[item.GetString("group_id")] = struct{}{}
+	}
+	for _, groupID := range ids {
+		if _, ok := member[groupID]; ok {
+			continue
+		}
+		item := core.NewRecord(col)
+		item.Set("group_id", groupID)
+		item.Set("object_type", string(objectType))
+		item.Set("object_id", objectID)
+		if err := app.Save(item); err != nil {
+			return fmt.Errorf("groups: assign %s %s to %s: %w", objectType, objectID, groupID, err)
+		}
+	}
+	return nil
+}
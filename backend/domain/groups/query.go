@@ -15,15 +15,17 @@ import (
 type ObjectType string
 
 const (
-	ObjectTypeServer       ObjectType = "server"
-	ObjectTypeSecret       ObjectType = "secret"
-	ObjectTypeEnvGroup     ObjectType = "env_group"
-	ObjectTypeDatabase     ObjectType = "database"
-	ObjectTypeCloudAccount ObjectType = "cloud_account"
-	ObjectTypeCertificate  ObjectType = "certificate"
-	ObjectTypeAIProvider   ObjectType = "ai_provider"
-	ObjectTypeConnector    ObjectType = "connector"
-	ObjectTypeScript       ObjectType = "script"
+	ObjectTypeServer          ObjectType = "server"
+	ObjectTypeSecret          ObjectType = "secret"
+	ObjectTypeEnvGroup        ObjectType = "env_group"
+	ObjectTypeDatabase        ObjectType = "database"
+	ObjectTypeCloudAccount    ObjectType = "cloud_account"
+	ObjectTypeCertificate     ObjectType = "certificate"
+	ObjectTypeAIProvider      ObjectType = "ai_provider"
+	ObjectTypeConnector       ObjectType = "connector"
+	ObjectTypeScript          ObjectType = "script"
+	ObjectTypeInstance        ObjectType = "instance"
+	ObjectTypeProviderAccount ObjectType = "provider_account"
 )
 
 // ─── Group membership queries ─────────────────────────────────────────────────
@@ -0,0 +1,245 @@
+package groups
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+)
+
+// ─── Resource type registry ───────────────────────────────────────────────────
+
+// ResourceType describes how group members of one object_type are resolved to
+// their backing collection. It mirrors dashboard/src/lib/object-types.ts.
+type ResourceType struct {
+	ObjectType ObjectType
+	// Key is the plural identifier accepted by the ?types= filter.
+	Key        string
+	Collection string
+	NameField  string
+}
+
+// ResourceTypes lists the object types that can be resolved by ListResources,
+// in the order they are returned.
+var ResourceTypes = []ResourceType{
+	{ObjectType: "app", Key: "apps", Collection: "apps", NameField: "name"},
+	{ObjectType: ObjectTypeServer, Key: "servers", Collection: "servers", NameField: "name"},
+	{ObjectType: "topic", Key: "topics", Collection: "topics", NameField: "title"},
+	{ObjectType: ObjectTypeSecret, Key: "secrets", Collection: "secrets", NameField: "name"},
+	{ObjectType: "env_set", Key: "env_sets", Collection: "env_sets", NameField: "name"},
+	{ObjectType: ObjectTypeInstance, Key: "instances", Collection: "instances", NameField: "name"},
+	{ObjectType: ObjectTypeAIProvider, Key: "ai_providers", Collection: "ai_providers", NameField: "name"},
+	{ObjectType: ObjectTypeProviderAccount, Key: "provider_accounts", Collection: "provider_accounts", NameField: "name"},
+	{ObjectType: ObjectTypeCertificate, Key: "certificates", Collection: "certificates", NameField: "domain"},
+	{ObjectType: ObjectTypeConnector, Key: "connectors", Collection: "connectors", NameField: "name"},
+	{ObjectType: ObjectTypeScript, Key: "scripts", Collection: "scripts", NameField: "name"},
+}
+
+// LookupResourceType resolves a ?types= token. Both the plural key ("servers")
+// and the stored object_type ("server") are accepted.
+func LookupResourceType(token string) (ResourceType, bool) {
+	token = strings.ToLower(strings.TrimSpace(token))
+	for _, rt := range ResourceTypes {
+		if token == rt.Key || token == string(rt.ObjectType) {
+			return rt, true
+		}
+	}
+	return ResourceType{}, false
+}
+
+// ─── Cross-type resource listing ──────────────────────────────────────────────
+
+// ResourceQuery filters and paginates the cross-type member list of a group.
+type ResourceQuery struct {
+	// Types restricts the result to these object types; empty means all.
+	Types  []ResourceType
+	Search string
+	Limit  int
+	Offset int
+}
+
+// ResourceItem is a single resource resolved to its backing record.
+type ResourceItem struct {
+	ObjectType  string `json:"object_type"`
+	ObjectID    string `json:"object_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Host        string `json:"host,omitempty"`
+	Updated     string `json:"updated"`
+}
+
+// ResourcePage is one page of a group's members plus per-type totals.
+type ResourcePage struct {
+	Items  []ResourceItem `json:"items"`
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// ListResources returns the members of groupID resolved across all supported
+// object types. Filtering, counting, and pagination are pushed down to the
+// database so that only the requested page of records is loaded.
+//
+// Items are ordered by type (ResourceTypes order) and then by name. Members
+// whose backing record no longer exists are not counted.
+func ListResources(app core.App, groupID string, q ResourceQuery) (ResourcePage, error) {
+	items, err := app.FindRecordsByFilter(ItemsCollection, "group_id = {:group}", "", 0, 0, dbx.Params{"group": groupID})
+	if err != nil {
+		return newResourcePage(q), err
+	}
+	idsByType := map[ObjectType][]any{}
+	for _, item := range items {
+		objectType := ObjectType(item.GetString("object_type"))
+		idsByType[objectType] = append(idsByType[objectType], item.GetString("object_id"))
+	}
+
+	return pageResources(app, q, func(col *core.Collection, rt ResourceType) dbx.Expression {
+		ids := idsByType[rt.ObjectType]
+		if len(ids) == 0 {
+			return nil
+		}
+		where := dbx.In("id", ids...)
+		if search := resourceSearch(searchFields(col, rt.NameField, "description"), q.Search); search != nil {
+			return dbx.And(where, search)
+		}
+		return where
+	})
+}
+
+// SearchResources searches every supported resource type (or q.Types) by
+// name, description and host, with the same ordering, per-type counts and
+// pagination as ListResources. Items only carry display fields, so secret
+// values are never part of a result.
+func SearchResources(app core.App, q ResourceQuery) (ResourcePage, error) {
+	return pageResources(app, q, func(col *core.Collection, rt ResourceType) dbx.Expression {
+		if search := resourceSearch(searchFields(col, rt.NameField, "description", "host"), q.Search); search != nil {
+			return search
+		}
+		return dbx.NewExp("1=1")
+	})
+}
+
+func newResourcePage(q ResourceQuery) ResourcePage {
+	return ResourcePage{
+		Items:  []ResourceItem{},
+		Counts: map[string]int{},
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	}
+}
+
+// pageResources counts and pages records across q.Types (all types when
+// empty). whereFor scopes one type's collection; a nil expression means the
+// type has nothing to return. Archived records are always excluded.
+func pageResources(app core.App, q ResourceQuery, whereFor func(col *core.Collection, rt ResourceType) dbx.Expression) (ResourcePage, error) {
+	page := newResourcePage(q)
+
+	types := q.Types
+	if len(types) == 0 {
+		types = ResourceTypes
+	}
+
+	type typeSlice struct {
+		rt    ResourceType
+		col   *core.Collection
+		where dbx.Expression
+		count int
+	}
+	slices := make([]typeSlice, 0, len(types))
+	for _, rt := range types {
+		col, err := app.FindCollectionByNameOrId(rt.Collection)
+		if err != nil {
+			// Collection not installed in this deployment; treat as empty.
+			page.Counts[rt.Key] = 0
+			continue
+		}
+		if col.Fields.GetByName(rt.NameField) == nil {
+			rt.NameField = "id"
+		}
+		where := whereFor(col, rt)
+		if where == nil {
+			page.Counts[rt.Key] = 0
+			continue
+		}
+		if archive.Supported(col) {
+			where = dbx.And(where, archive.Live())
+		}
+
+		var count int
+		if err := app.RecordQuery(col).Select("count(*)").AndWhere(where).Row(&count); err != nil {
+			return page, fmt.Errorf("count %s: %w", rt.Collection, err)
+		}
+		page.Counts[rt.Key] = count
+		page.Total += count
+		slices = append(slices, typeSlice{rt: rt, col: col, where: where, count: count})
+	}
+
+	// Walk the type slices in order, skipping whole types that fall before the
+	// offset and fetching only the window that overlaps [offset, offset+limit).
+	skip := q.Offset
+	remaining := q.Limit
+	for _, s := range slices {
+		if remaining <= 0 {
+			break
+		}
+		if skip >= s.count {
+			skip -= s.count
+			continue
+		}
+
+		var records []*core.Record
+		err := app.RecordQuery(s.col).
+			AndWhere(s.where).
+			OrderBy(s.rt.NameField+" ASC", "id ASC").
+			Offset(int64(skip)).
+			Limit(int64(remaining)).
+			All(&records)
+		if err != nil {
+			return page, fmt.Errorf("list %s: %w", s.rt.Collection, err)
+		}
+		skip = 0
+		remaining -= len(records)
+
+		for _, rec := range records {
+			page.Items = append(page.Items, ResourceItem{
+				ObjectType:  string(s.rt.ObjectType),
+				ObjectID:    rec.Id,
+				Name:        rec.GetString(s.rt.NameField),
+				Description: rec.GetString("description"),
+				Host:        rec.GetString("host"),
+				Updated:     rec.GetString("updated"),
+			})
+		}
+	}
+
+	return page, nil
+}
+
+// searchFields returns the candidates that exist on col, nameField first.
+func searchFields(col *core.Collection, nameField string, candidates ...string) []string {
+	fields := []string{nameField}
+	for _, f := range candidates {
+		if f != nameField && col.Fields.GetByName(f) != nil {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// resourceSearch matches search against any of fields; nil when search is
+// empty.
+func resourceSearch(fields []string, search string) dbx.Expression {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		return nil
+	}
+	likes := make([]dbx.Expression, 0, len(fields))
+	for _, f := range fields {
+		likes = append(likes, dbx.Like(f, search))
+	}
+	return dbx.Or(likes...)
+}
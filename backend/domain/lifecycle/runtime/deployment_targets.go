@@ -89,13 +89,9 @@ func (e sshExecutor) DockerClient() (*docker.Client, error) {
 		return nil, err
 	}
 
-	sudoEnabled := cfg.User != "root"
-	sudoPassword := ""
-	if sudoEnabled && cfg.AuthType == servers.AuthMethodPassword {
-		sudoPassword = cfg.Secret
-	}
+	sudoEnabled, sudoPassword, doas := cfg.DockerSudo()
 
-	exec := docker.NewSSHExecutor(docker.SSHConfig{
+	sshConfig := docker.SSHConfig{
 		Host:         cfg.Host,
 		Port:         cfg.Port,
 		User:         cfg.User,
@@ -103,7 +99,12 @@ func (e sshExecutor) DockerClient() (*docker.Client, error) {
 		Secret:       cfg.Secret,
 		SudoEnabled:  sudoEnabled,
 		SudoPassword: sudoPassword,
-	})
+		Doas:         doas,
+		ProxyJump:    cfg.ProxyJump,
+		LegacySSH:    cfg.LegacySSH,
+	}
+	servers.ApplyDockerSSHSettings(e.app, &sshConfig)
+	exec := docker.NewSSHExecutor(sshConfig)
 	return docker.New(exec), nil
 }
 
@@ -154,12 +155,14 @@ func resolveServerAccessConfig(app core.App, serverID string) (servers.AccessCon
 
 func terminalConfigFromServerAccess(cfg servers.AccessConfig) terminal.ConnectorConfig {
 	return terminal.ConnectorConfig{
-		Host:     cfg.Host,
-		Port:     cfg.Port,
-		User:     cfg.User,
-		AuthType: terminal.CredAuthType(cfg.AuthType),
-		Secret:   cfg.Secret,
-		Shell:    cfg.Shell,
+		Host:      cfg.Host,
+		Port:      cfg.Port,
+		User:      cfg.User,
+		AuthType:  terminal.CredAuthType(cfg.AuthType),
+		Secret:    cfg.Secret,
+		Shell:     cfg.Shell,
+		ProxyJump: cfg.ProxyJump,
+		LegacySSH: cfg.LegacySSH,
 	}
 }
 
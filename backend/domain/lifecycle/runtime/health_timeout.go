@@ -0,0 +1,23 @@
+package runtime
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// DefaultDeployHealthTimeout bounds the post-deploy health gate when the
+// deploy/preflight healthTimeoutSeconds setting is unset.
+const DefaultDeployHealthTimeout = 2 * time.Minute
+
+// DeployHealthTimeout returns how long a deploy waits for its services to
+// run and pass their healthchecks.
+func DeployHealthTimeout(app core.App) time.Duration {
+	group, _ := sysconfig.GetGroup(app, "deploy", "preflight", nil)
+	seconds := sysconfig.Int(group, "healthTimeoutSeconds", int(DefaultDeployHealthTimeout/time.Second))
+	if seconds <= 0 {
+		return DefaultDeployHealthTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -34,6 +34,8 @@ type HealthChecker func(ctx context.Context, client interface {
 type NodeExecutionHooks struct {
 	Logf        func(string)
 	HealthCheck HealthChecker
+	// HealthTimeout bounds the health_check node; DefaultDeployHealthTimeout when zero.
+	HealthTimeout time.Duration
 }
 
 type NodeExecutionResult struct {
@@ -317,8 +319,9 @@ func ExecuteNode(
 			return result, err
 		}
 		result.DockerClient = client
-		removeVolumes := operationMetadataBool(operation, "remove_volumes")
-		output, err := client.ComposeDown(ctx, operation.GetString("project_dir"), removeVolumes)
+		output, err := client.ComposeDown(ctx, operation.GetString("project_dir"), docker.ComposeDownOptions{
+			RemoveVolumes: operationMetadataBool(operation, "remove_volumes"),
+		})
 		if output != "" {
 			logf("docker compose down output:\n" + output)
 		}
@@ -332,7 +335,11 @@ func ExecuteNode(
 			return result, err
 		}
 		result.DockerClient = client
-		healthCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		healthTimeout := hooks.HealthTimeout
+		if healthTimeout <= 0 {
+			healthTimeout = DefaultDeployHealthTimeout
+		}
+		healthCtx, cancel := context.WithTimeout(ctx, healthTimeout)
 		defer cancel()
 		if err := healthCheck(healthCtx, client, operation.GetString("project_dir")); err != nil {
 			return result, err
@@ -614,6 +621,10 @@ func ensureDockerClient(executor Executor, current *docker.Client) (*docker.Clie
 	return executor.DockerClient()
 }
 
+// RunDeploymentHealthCheck waits for the project to report running
+// services, then for every service with a healthcheck to become healthy.
+// A project whose healthchecks do not pass before ctx ends fails with an
+// error wrapping docker.ErrComposeUnhealthy.
 func RunDeploymentHealthCheck(ctx context.Context, client interface {
 	Exec(context.Context, ...string) (string, error)
 }, projectDir string) error {
@@ -627,7 +638,8 @@ func RunDeploymentHealthCheck(ctx context.Context, client interface {
 		output, err := client.Exec(attemptCtx, "compose", "-f", composeFile, "ps", "--status", "running", "-q")
 		cancel()
 		if err == nil && strings.TrimSpace(output) != "" {
-			return nil
+			_, err := docker.WaitComposeHealthy(ctx, client, projectDir, 3*time.Second)
+			return err
 		}
 		if err != nil {
 			lastErr = err
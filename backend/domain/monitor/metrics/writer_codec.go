@@ -20,6 +20,7 @@ var allowedMetricSeries = map[string]struct{}{
 	"appos_platform_goroutines":                         {},
 	"appos_platform_heap_alloc_bytes":                   {},
 	"appos_platform_uptime_seconds":                     {},
+	"appos_credential_decrypt_failures_total":           {},
 	"appos_worker_running":                              {},
 	"appos_worker_uptime_seconds":                       {},
 	"appos_worker_dispatch_age_seconds":                 {},
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/monitor"
 )
 
@@ -27,7 +28,7 @@ type FactsItem struct {
 
 func IngestFacts(app core.App, input FactsIngest) (int, error) {
 	serverID := strings.TrimSpace(input.ServerID)
-	serverRecord, err := app.FindRecordById("servers", serverID)
+	serverRecord, err := archive.FindLive(app, "servers", serverID)
 	if err != nil {
 		return 0, err
 	}
@@ -39,7 +39,7 @@ func GetOrIssueAgentToken(app core.App, serverID string, rotate bool) (token str
 	}
 	if secret != nil && !rotate {
 		token, err = secrets.ReadSystemSingleValue(secret)
-		return token, false, err
+		return token, false, secrets.ReportDecryptFailure(app, err)
 	}
 
 	plaintext := GenerateAgentToken()
@@ -73,7 +73,7 @@ func ValidateAgentToken(app core.App, plaintext string) (string, error) {
 		secret := secrets.From(record)
 		value, readErr := secrets.ReadSystemSingleValue(secret)
 		if readErr != nil {
-			return "", readErr
+			return "", secrets.ReportDecryptFailure(app, readErr)
 		}
 		if value == plaintext {
 			name := strings.TrimSpace(record.GetString("name"))
@@ -8,6 +8,7 @@ import (
 	"github.com/websoft9/appos/backend/domain/monitor"
 	monitormetrics "github.com/websoft9/appos/backend/domain/monitor/metrics"
 	monitorstatus "github.com/websoft9/appos/backend/domain/monitor/status"
+	"github.com/websoft9/appos/backend/domain/secrets"
 	"github.com/websoft9/appos/backend/infra/supervisor"
 )
 
@@ -23,6 +24,8 @@ func (o *PlatformObserver) collectAppCoreTarget(now time.Time, snapshot RuntimeS
 		"num_cpu":          runtime.NumCPU(),
 		"gc_cycles":        mem.NumGC,
 		"last_gc_at":       formatUnixNano(mem.LastGC),
+		// Decrypt failures usually mean a wrong encryption key or corrupted rows.
+		"credential_decrypt_failures": secrets.DecryptFailures(),
 	}
 	if err := monitorstatus.ProjectPlatformLatestStatus(o.app, now, PlatformTargetAppOSCore, "AppOS Core", monitor.SignalSourceSelf, monitor.StatusHealthy, "", appCoreSummary); err != nil {
 		return nil, err
@@ -30,6 +33,7 @@ func (o *PlatformObserver) collectAppCoreTarget(now time.Time, snapshot RuntimeS
 	points := []monitormetrics.MetricPoint{
 		{Series: "appos_platform_goroutines", Value: float64(runtime.NumGoroutine()), Labels: platformMetricLabels(PlatformTargetAppOSCore), ObservedAt: now},
 		{Series: "appos_platform_heap_alloc_bytes", Value: float64(mem.Alloc), Labels: platformMetricLabels(PlatformTargetAppOSCore), ObservedAt: now},
+		{Series: "appos_credential_decrypt_failures_total", Value: float64(secrets.DecryptFailures()), Labels: platformMetricLabels(PlatformTargetAppOSCore), ObservedAt: now},
 	}
 	if uptime := secondsSinceFloat(now, snapshot.StartedAt); uptime > 0 {
 		points = append(points, monitormetrics.MetricPoint{Series: "appos_platform_uptime_seconds", Value: uptime, Labels: platformMetricLabels(PlatformTargetAppOSCore), ObservedAt: now})
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/monitor"
 	"github.com/websoft9/appos/backend/domain/monitor/status/store"
 	"github.com/websoft9/appos/backend/domain/secrets"
@@ -102,7 +103,7 @@ func GetTargetStatus(app core.App, targetType, targetID string) (*TargetStatusRe
 }
 
 func synthesizeServerTargetStatus(app core.App, targetID string) (*TargetStatusResponse, error) {
-	server, err := app.FindRecordById("servers", targetID)
+	server, err := archive.FindLive(app, "servers", targetID)
 	if err != nil {
 		return nil, err
 	}
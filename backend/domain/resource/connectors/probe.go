@@ -0,0 +1,211 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProbeStatusOK          = "ok"
+	ProbeStatusFailed      = "failed"
+	ProbeStatusUnsupported = "unsupported"
+)
+
+// ProbeTimeout bounds a single connection test.
+const ProbeTimeout = 10 * time.Second
+
+// ProbeResult is the outcome of a connector connection test. It never carries
+// the resolved credential.
+type ProbeResult struct {
+	Status     string `json:"status"`
+	Kind       string `json:"kind"`
+	Target     string `json:"target,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Probe performs a minimal reachability/auth check for connector based on its
+// kind. HTTP kinds send a GET to the endpoint (or config.healthPath relative to
+// it) with the connector credential applied and expect a 2xx; webhooks also
+// accept 405 since many only take POST. Registries are checked via /v2/, and
+// SMTP and DNS endpoints with a TCP dial.
+func Probe(ctx context.Context, secrets SecretResolvePort, connector *Connector) ProbeResult {
+	result := ProbeResult{Kind: connector.Kind()}
+
+	secret, err := secrets.Resolve(connector.CredentialID())
+	if err != nil {
+		result.Status = ProbeStatusFailed
+		result.Message = "credential could not be resolved"
+		return result
+	}
+	credential := probeCredential{scheme: connector.AuthScheme()}
+	config := connector.Config()
+	credential.username = stringValue(config, "username", "user")
+	credential.header = stringValue(config, "apiKeyHeader", "api_key_header")
+	if secret != nil {
+		credential.secret = stringValue(secret.Payload, "api_key", "token", "password", "value")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	switch connector.Kind() {
+	case KindRESTAPI, KindWebhook, KindMCP, KindLLM:
+		target, err := probeURL(connector.Endpoint(), stringValue(config, "healthPath", "health_path"))
+		if err != nil {
+			return failedProbe(result, err.Error(), credential)
+		}
+		accept := []int{}
+		if connector.Kind() == KindWebhook {
+			accept = append(accept, http.StatusMethodNotAllowed)
+		}
+		return probeHTTP(ctx, result, target, credential, accept...)
+	case KindRegistry:
+		host, port, scheme, err := parseEndpoint(connector.Endpoint(), "https", 443, "http", "https")
+		if err != nil {
+			return failedProbe(result, err.Error(), credential)
+		}
+		target := &url.URL{Scheme: scheme, Host: net.JoinHostPort(host, strconv.Itoa(port)), Path: "/v2/"}
+		return probeHTTP(ctx, result, target, credential)
+	case KindSMTP:
+		host, port, _, err := parseEndpoint(connector.Endpoint(), "smtp", 587, "smtp", "smtps")
+		if err != nil {
+			return failedProbe(result, err.Error(), credential)
+		}
+		return probeTCP(ctx, result, net.JoinHostPort(host, strconv.Itoa(port)), credential)
+	case KindDNS:
+		if strings.TrimSpace(connector.Endpoint()) == "" {
+			result.Status = ProbeStatusUnsupported
+			result.Message = "dns connector has no endpoint to test"
+			return result
+		}
+		host, port, _, err := parseEndpoint(connector.Endpoint(), "https", 443)
+		if err != nil {
+			return failedProbe(result, err.Error(), credential)
+		}
+		return probeTCP(ctx, result, net.JoinHostPort(host, strconv.Itoa(port)), credential)
+	default:
+		result.Status = ProbeStatusUnsupported
+		result.Message = fmt.Sprintf("connection test is not supported for kind %q", connector.Kind())
+		return result
+	}
+}
+
+type probeCredential struct {
+	scheme   string
+	username string
+	header   string
+	secret   string
+}
+
+func (c probeCredential) apply(req *http.Request) {
+	if c.secret == "" {
+		return
+	}
+	switch c.scheme {
+	case AuthSchemeBearer:
+		req.Header.Set("Authorization", "Bearer "+c.secret)
+	case AuthSchemeAPIKey:
+		if c.header != "" {
+			req.Header.Set(c.header, c.secret)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.secret)
+		}
+	case AuthSchemeBasic:
+		req.SetBasicAuth(c.username, c.secret)
+	}
+}
+
+// redact strips the secret from text that may have been derived from a request.
+func (c probeCredential) redact(text string) string {
+	if c.secret == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, c.secret, "[redacted]")
+}
+
+func probeURL(endpoint, healthPath string) (*url.URL, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("connector endpoint is required")
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("connector endpoint must be an http(s) URL")
+	}
+	if healthPath = strings.TrimSpace(healthPath); healthPath != "" {
+		ref, err := url.Parse(healthPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid healthPath %q", healthPath)
+		}
+		parsed = parsed.ResolveReference(ref)
+	}
+	return parsed, nil
+}
+
+func probeHTTP(ctx context.Context, result ProbeResult, target *url.URL, credential probeCredential, accept ...int) ProbeResult {
+	result.Target = target.Redacted()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return failedProbe(result, err.Error(), credential)
+	}
+	credential.apply(req)
+
+	client := &http.Client{
+		// Do not follow redirects: a redirect to another host would resend the credential.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return failedProbe(result, "request failed: "+err.Error(), credential)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+
+	result.HTTPStatus = resp.StatusCode
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	for _, code := range accept {
+		if resp.StatusCode == code {
+			ok = true
+		}
+	}
+	if !ok {
+		message := fmt.Sprintf("unexpected HTTP status %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			message = fmt.Sprintf("authentication rejected (HTTP %d)", resp.StatusCode)
+		}
+		return failedProbe(result, message, credential)
+	}
+	result.Status = ProbeStatusOK
+	return result
+}
+
+func probeTCP(ctx context.Context, result ProbeResult, addr string, credential probeCredential) ProbeResult {
+	result.Target = addr
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return failedProbe(result, "connect failed: "+err.Error(), credential)
+	}
+	_ = conn.Close()
+	result.Status = ProbeStatusOK
+	return result
+}
+
+func failedProbe(result ProbeResult, message string, credential probeCredential) ProbeResult {
+	result.Status = ProbeStatusFailed
+	result.Message = credential.redact(message)
+	return result
+}
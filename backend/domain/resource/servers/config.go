@@ -2,6 +2,7 @@ package servers
 
 import (
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 )
 
 // AccessAuthType identifies the credential material needed to reach a managed server.
@@ -12,6 +13,9 @@ const (
 	AuthMethodPassword AccessAuthType = "password"
 	// AuthMethodPrivateKey authenticates with an SSH private key.
 	AuthMethodPrivateKey AccessAuthType = "private_key"
+	// AuthMethodAgent authenticates with the keys of the ssh-agent at
+	// SSH_AUTH_SOCK; servers without a credential use it.
+	AuthMethodAgent AccessAuthType = "agent"
 )
 
 // AccessConfig is the servers bounded-context representation of how to reach a managed server.
@@ -21,7 +25,23 @@ type AccessConfig struct {
 	User     string
 	AuthType AccessAuthType
 	Secret   string
+	// Passphrase decrypts Secret when it is an encrypted private key.
+	Passphrase string
+	// Password is the optional login password stored with a private key; it
+	// is tried when the key is rejected and answers sudo prompts.
+	Password string
 	Shell    string
+	// ProxyJump lists intermediate SSH hosts to tunnel through, in order.
+	ProxyJump []sshconfig.Hop
+	// DefaultDir is the initial working directory (empty = login default).
+	DefaultDir string
+	// Env holds variables exported at terminal session start.
+	Env map[string]string
+	// LegacySSH enables the weak SSH algorithms old servers still require.
+	LegacySSH bool
+	// PrivilegeEscalation is the resolved strategy for root-only commands;
+	// it is never auto.
+	PrivilegeEscalation PrivilegeEscalation
 }
 
 // CredentialAuthType infers the SSH auth type from a secret's template_id.
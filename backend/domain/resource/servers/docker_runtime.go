@@ -3,8 +3,11 @@ package servers
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	"github.com/websoft9/appos/backend/infra/docker"
 )
 
@@ -46,7 +49,7 @@ func (s *ManagedServer) DockerSSHConfig(app core.App, userID string) (docker.SSH
 		return docker.SSHConfig{}, fmt.Errorf("server is required")
 	}
 
-	record, err := app.FindRecordById("servers", s.ID)
+	record, err := archive.FindLive(app, "servers", s.ID)
 	if err != nil {
 		return docker.SSHConfig{}, fmt.Errorf("server not found: %w", err)
 	}
@@ -55,23 +58,24 @@ func (s *ManagedServer) DockerSSHConfig(app core.App, userID string) (docker.SSH
 }
 
 func (s *ManagedServer) buildDockerSSHConfig(app core.App, rt TunnelRuntime, userID string) (docker.SSHConfig, error) {
-	host, port, err := s.ResolveDockerSSHAddress(rt)
-	if err != nil {
-		return docker.SSHConfig{}, err
-	}
-
 	cfg, err := s.AccessConfig(app, userID)
 	if err != nil {
 		return docker.SSHConfig{}, err
 	}
 
-	sudoEnabled := cfg.User != "root"
-	sudoPassword := ""
-	if sudoEnabled && cfg.AuthType == AuthMethodPassword {
-		sudoPassword = cfg.Secret
+	// Direct servers use the access address so ssh_config aliases apply;
+	// tunnel servers must go through their live local forward.
+	host, port := cfg.Host, cfg.Port
+	if s.IsTunnel() {
+		host, port, err = s.ResolveDockerSSHAddress(rt)
+		if err != nil {
+			return docker.SSHConfig{}, err
+		}
 	}
 
-	return docker.SSHConfig{
+	sudoEnabled, sudoPassword, doas := cfg.DockerSudo()
+
+	sshConfig := docker.SSHConfig{
 		Host:         host,
 		Port:         port,
 		User:         cfg.User,
@@ -79,7 +83,21 @@ func (s *ManagedServer) buildDockerSSHConfig(app core.App, rt TunnelRuntime, use
 		Secret:       cfg.Secret,
 		SudoEnabled:  sudoEnabled,
 		SudoPassword: sudoPassword,
-	}, nil
+		Doas:         doas,
+		ProxyJump:    cfg.ProxyJump,
+		LegacySSH:    cfg.LegacySSH,
+	}
+	ApplyDockerSSHSettings(app, &sshConfig)
+	return sshConfig, nil
+}
+
+// ApplyDockerSSHSettings copies the docker/ssh timeouts and retry setting
+// onto cfg. Missing or invalid settings leave the executor defaults.
+func ApplyDockerSSHSettings(app core.App, cfg *docker.SSHConfig) {
+	group, _ := sysconfig.GetGroup(app, "docker", "ssh", nil)
+	cfg.DialTimeout = time.Duration(sysconfig.Int(group, "dialTimeoutSeconds", 0)) * time.Second
+	cfg.CommandTimeout = time.Duration(sysconfig.Int(group, "commandTimeoutSeconds", 0)) * time.Second
+	cfg.RetryDial = sysconfig.Bool(group, "retryDial", false)
 }
 
 // ResolveDockerSSHAddress rewrites tunnel-backed servers to their active local forwarding address.
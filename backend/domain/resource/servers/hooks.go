@@ -0,0 +1,142 @@
+package servers
+
+import (
+	"fmt"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
+	sec "github.com/websoft9/appos/backend/domain/secrets"
+)
+
+// Inline credential fields accepted in the servers create body. They are not
+// collection fields; the hook turns them into a secret linked via credential.
+const (
+	InlineCredentialValueField      = "credential_value"
+	InlineCredentialTypeField       = "credential_type"
+	InlineCredentialPassphraseField = "credential_passphrase"
+)
+
+// InlineCredential is a plaintext credential submitted together with a new server.
+type InlineCredential struct {
+	Type       AccessAuthType
+	Value      string
+	Passphrase string
+}
+
+// RegisterHooks binds server record hooks.
+func RegisterHooks(app core.App) {
+	app.OnRecordValidate("servers").BindFunc(func(e *core.RecordEvent) error {
+		errs := validation.Errors{}
+		if err := ValidateDefaultDir(e.Record.GetString("default_dir")); err != nil {
+			errs["default_dir"] = validation.NewError("validation_invalid_default_dir", err.Error())
+		}
+		if _, err := ParseSessionEnv(e.Record.Get("env")); err != nil {
+			errs["env"] = validation.NewError("validation_invalid_env", err.Error())
+		}
+		if err := ValidateTerminalBanner(e.Record.GetString("terminal_banner")); err != nil {
+			errs["terminal_banner"] = validation.NewError("validation_invalid_terminal_banner", err.Error())
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return e.Next()
+	})
+
+	app.OnRecordCreateRequest("servers").BindFunc(func(e *core.RecordRequestEvent) error {
+		info, err := e.RequestInfo()
+		if err != nil {
+			return err
+		}
+		cred, ok, err := InlineCredentialFromBody(info.Body)
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		if err := applyCreateTemplate(e.App, e.Record, info.Body, ok); err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		if !ok {
+			return e.Next()
+		}
+		if e.Record.GetString("credential") != "" {
+			return apis.NewBadRequestError("credential and credential_value are mutually exclusive", nil)
+		}
+
+		secret, err := CreateInlineCredential(e.App, e.Auth, e.Record.GetString("name"), cred)
+		if err != nil {
+			return apis.NewBadRequestError("failed to create credential", err)
+		}
+		e.Record.Set("credential", secret.ID())
+
+		if err := e.Next(); err != nil {
+			// Roll back the secret so a failed create leaves no orphan credential.
+			if delErr := e.App.Delete(secret.Record()); delErr != nil {
+				e.App.Logger().Warn("failed to remove inline server credential", "secret", secret.ID(), "error", delErr)
+			}
+			return err
+		}
+
+		userID, userEmail := "system", ""
+		if e.Auth != nil {
+			userID, userEmail = e.Auth.Id, e.Auth.GetString("email")
+		}
+		audit.Write(e.App, audit.Entry{
+			UserID:       userID,
+			UserEmail:    userEmail,
+			Action:       "secret.create",
+			ResourceType: "secret",
+			ResourceID:   secret.ID(),
+			ResourceName: secret.Name(),
+			Status:       audit.StatusSuccess,
+			IP:           e.RealIP(),
+			UserAgent:    e.Request.Header.Get("User-Agent"),
+			Detail:       map[string]any{"server_id": e.Record.Id},
+		})
+		return nil
+	})
+}
+
+// InlineCredentialFromBody extracts an inline credential from a create body.
+// ok is false when no credential_value was submitted. credential_type defaults
+// to password.
+func InlineCredentialFromBody(body map[string]any) (InlineCredential, bool, error) {
+	value, _ := body[InlineCredentialValueField].(string)
+	if strings.TrimSpace(value) == "" {
+		return InlineCredential{}, false, nil
+	}
+
+	cred := InlineCredential{Type: AuthMethodPassword, Value: value}
+	if raw, _ := body[InlineCredentialTypeField].(string); strings.TrimSpace(raw) != "" {
+		cred.Type = AccessAuthType(strings.TrimSpace(raw))
+	}
+	switch cred.Type {
+	case AuthMethodPassword, AuthMethodPrivateKey:
+	default:
+		return InlineCredential{}, false, fmt.Errorf("invalid credential_type; must be %q or %q", AuthMethodPassword, AuthMethodPrivateKey)
+	}
+	cred.Passphrase, _ = body[InlineCredentialPassphraseField].(string)
+	return cred, true, nil
+}
+
+// CreateInlineCredential stores cred as an encrypted secret owned by auth.
+// Passwords use the single_value template and private keys the ssh_key
+// template, so CredentialAuthType infers the right auth method.
+func CreateInlineCredential(app core.App, auth *core.Record, serverName string, cred InlineCredential) (*sec.Secret, error) {
+	name := strings.TrimSpace(serverName)
+	if name == "" {
+		name = "server"
+	}
+
+	switch cred.Type {
+	case AuthMethodPrivateKey:
+		payload := map[string]any{"private_key": cred.Value}
+		if cred.Passphrase != "" {
+			payload["passphrase"] = cred.Passphrase
+		}
+		return sec.CreateUserSecret(app, auth, name+" SSH key", "ssh_key", payload)
+	default:
+		return sec.CreateUserSecret(app, auth, name+" password", sec.TemplateSingleValue, map[string]any{"value": cred.Value})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	sec "github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 	tunnelcore "github.com/websoft9/appos/backend/infra/tunnelcore"
 )
 
@@ -38,10 +40,23 @@ type ManagedServer struct {
 	Shell          string
 	TunnelForwards string
 	Description    string
+	// SSHConfigHost is an optional ~/.ssh/config Host alias. When set, its
+	// HostName, User, Port, IdentityFile and ProxyJump override the record.
+	SSHConfigHost string
+	// DefaultDir is the working directory for new terminal sessions and the
+	// initial SFTP listing path.
+	DefaultDir string
+	// Env holds environment variables exported at terminal session start.
+	Env map[string]string
+	// LegacySSH also offers the ciphers, key exchanges and host key
+	// algorithms x/crypto disables by default (CBC, SHA-1 DH, ssh-rsa).
+	LegacySSH bool
+	// PrivilegeEscalation selects how root-only commands are run; empty is auto.
+	PrivilegeEscalation PrivilegeEscalation
 }
 
 func LoadManagedServer(app core.App, serverID string) (*ManagedServer, error) {
-	record, err := app.FindRecordById("servers", serverID)
+	record, err := archive.FindLive(app, "servers", serverID)
 	if err != nil {
 		return nil, fmt.Errorf("server not found: %w", err)
 	}
@@ -49,7 +64,7 @@ func LoadManagedServer(app core.App, serverID string) (*ManagedServer, error) {
 }
 
 func ListManagedServers(app core.App) ([]*ManagedServer, error) {
-	records, err := app.FindAllRecords("servers")
+	records, err := app.FindAllRecords("servers", archive.Live())
 	if err != nil {
 		return nil, err
 	}
@@ -76,17 +91,30 @@ func ManagedServerFromRecord(record *core.Record) *ManagedServer {
 		ct = ConnectionModeDirect
 	}
 
+	// Invalid env is rejected on save; a legacy bad value is simply ignored here.
+	env, _ := ParseSessionEnv(record.Get("env"))
+
+	privilege := PrivilegeEscalation(record.GetString("privilege_escalation"))
+	if !privilege.IsValid() {
+		privilege = PrivilegeEscalationAuto
+	}
+
 	return &ManagedServer{
-		ID:             record.Id,
-		Name:           record.GetString("name"),
-		Host:           record.GetString("host"),
-		Port:           port,
-		User:           record.GetString("user"),
-		ConnectType:    ct,
-		CredentialID:   record.GetString("credential"),
-		Shell:          record.GetString("shell"),
-		TunnelForwards: record.GetString("tunnel_forwards"),
-		Description:    record.GetString("description"),
+		ID:                  record.Id,
+		Name:                record.GetString("name"),
+		Host:                record.GetString("host"),
+		Port:                port,
+		User:                record.GetString("user"),
+		ConnectType:         ct,
+		CredentialID:        record.GetString("credential"),
+		Shell:               record.GetString("shell"),
+		TunnelForwards:      record.GetString("tunnel_forwards"),
+		Description:         record.GetString("description"),
+		SSHConfigHost:       record.GetString("ssh_config_host"),
+		DefaultDir:          record.GetString("default_dir"),
+		Env:                 env,
+		LegacySSH:           record.GetBool("legacy_ssh"),
+		PrivilegeEscalation: privilege,
 	}
 }
 
@@ -110,7 +138,7 @@ func (s *ManagedServer) TunnelForwardSpecs() ([]tunnelcore.ForwardSpec, error) {
 }
 
 func ResolveConfigForUserID(app core.App, serverID string, userID string) (AccessConfig, error) {
-	record, err := app.FindRecordById("servers", serverID)
+	record, err := archive.FindLive(app, "servers", serverID)
 	if err != nil {
 		return AccessConfig{}, fmt.Errorf("server not found: %w", err)
 	}
@@ -131,19 +159,74 @@ func (s *ManagedServer) AccessConfig(app core.App, userID string) (AccessConfig,
 	}
 
 	cfg := AccessConfig{
-		Host:  s.Host,
-		Port:  s.Port,
-		User:  s.User,
-		Shell: s.Shell,
+		Host:       s.Host,
+		Port:       s.Port,
+		User:       s.User,
+		Shell:      s.Shell,
+		DefaultDir: s.DefaultDir,
+		Env:        s.Env,
+		LegacySSH:  s.LegacySSH,
+	}
+
+	var alias *sshconfig.Host
+	if s.SSHConfigHost != "" && s.ConnectType != ConnectionModeTunnel {
+		sshCfg, err := sshconfig.LoadDefault()
+		if err != nil {
+			return AccessConfig{}, fmt.Errorf("ssh config host %q: %w", s.SSHConfigHost, err)
+		}
+		host, err := ApplySSHConfigHost(&cfg, sshCfg, s.SSHConfigHost)
+		if err != nil {
+			return AccessConfig{}, err
+		}
+		alias = &host
 	}
 
 	if err := s.applyCredential(app, userID, &cfg); err != nil {
 		return AccessConfig{}, err
 	}
 
+	// Without a stored credential, fall back to the alias's IdentityFile.
+	if cfg.Secret == "" && alias != nil && len(alias.IdentityFiles) > 0 {
+		key, err := sshconfig.ReadIdentity(alias.IdentityFiles)
+		if err != nil {
+			return AccessConfig{}, fmt.Errorf("ssh config host %q: %w", s.SSHConfigHost, err)
+		}
+		cfg.AuthType = AuthMethodPrivateKey
+		cfg.Secret = key
+	}
+	if cfg.AuthType == "" {
+		cfg.AuthType = AuthMethodAgent
+	}
+
+	cfg.PrivilegeEscalation = s.PrivilegeEscalation.Resolve(cfg.User, cfg.AuthType)
 	return cfg, nil
 }
 
+// ApplySSHConfigHost overlays the ssh_config settings for alias onto cfg and
+// resolves its ProxyJump chain. Values set in the ssh_config take precedence
+// over the server record; record values remain as fallbacks.
+func ApplySSHConfigHost(cfg *AccessConfig, sshCfg *sshconfig.Config, alias string) (sshconfig.Host, error) {
+	host, err := sshCfg.Lookup(alias)
+	if err != nil {
+		return sshconfig.Host{}, fmt.Errorf("ssh config host %q: %w", alias, err)
+	}
+
+	cfg.Host = host.HostName
+	if host.Port != 0 {
+		cfg.Port = host.Port
+	}
+	if host.User != "" {
+		cfg.User = host.User
+	}
+
+	hops, err := sshCfg.ResolveHops(host)
+	if err != nil {
+		return sshconfig.Host{}, fmt.Errorf("ssh config host %q: %w", alias, err)
+	}
+	cfg.ProxyJump = hops
+	return host, nil
+}
+
 // ApplyBestEffortTunnel rewrites Host/Port in cfg to the locally-forwarded tunnel
 // address when this server uses ConnectionModeTunnel. rt provides the runtime
 // services map; if the ssh service is not yet advertised the cfg is left unchanged.
@@ -165,6 +248,7 @@ func (s *ManagedServer) ApplyBestEffortTunnel(cfg *AccessConfig, rt TunnelRuntim
 
 	cfg.Host = "127.0.0.1"
 	cfg.Port = sshPort
+	cfg.ProxyJump = nil
 }
 
 // ResolveDockerSSHAddress returns the effective SSH host/port for Docker API access.
@@ -210,6 +294,8 @@ func (s *ManagedServer) applyCredential(app core.App, userID string, cfg *Access
 		cfg.Secret = sec.FirstStringFromPayload(result.Payload, "password", "value")
 	default:
 		cfg.Secret = sec.FirstStringFromPayload(result.Payload, "private_key", "key", "value")
+		cfg.Passphrase = sec.FirstStringFromPayload(result.Payload, "passphrase")
+		cfg.Password = sec.FirstStringFromPayload(result.Payload, "password")
 	}
 	if cfg.Secret == "" {
 		return fmt.Errorf("credential resolve: no usable value in payload for auth_type %q", cfg.AuthType)
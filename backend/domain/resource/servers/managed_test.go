@@ -1,7 +1,10 @@
 package servers
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 )
 
 func TestManagedServerApplyBestEffortTunnel(t *testing.T) {
@@ -57,3 +60,71 @@ func TestManagedServerIsTunnel(t *testing.T) {
 		t.Fatal("expected tunnel server")
 	}
 }
+
+func TestApplySSHConfigHostOverlaysAliasSettings(t *testing.T) {
+	sshCfg, err := sshconfig.Parse(strings.NewReader(`
+Host prod-db
+    HostName 10.1.2.3
+    Port 2222
+    ProxyJump ops@bastion
+Host bastion
+    HostName bastion.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := AccessConfig{Host: "ignored.example.com", Port: 22, User: "root"}
+	if _, err := ApplySSHConfigHost(&cfg, sshCfg, "prod-db"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Host != "10.1.2.3" || cfg.Port != 2222 {
+		t.Fatalf("expected alias address, got %s:%d", cfg.Host, cfg.Port)
+	}
+	if cfg.User != "root" {
+		t.Fatalf("expected record user as fallback, got %q", cfg.User)
+	}
+	if len(cfg.ProxyJump) != 1 || cfg.ProxyJump[0].Addr() != "bastion.example.com:22" || cfg.ProxyJump[0].User != "ops" {
+		t.Fatalf("unexpected proxy jump: %+v", cfg.ProxyJump)
+	}
+}
+
+func TestPrivilegeEscalationResolve(t *testing.T) {
+	cases := []struct {
+		mode     PrivilegeEscalation
+		user     string
+		authType AccessAuthType
+		want     PrivilegeEscalation
+	}{
+		{PrivilegeEscalationAuto, "root", AuthMethodPassword, PrivilegeEscalationNone},
+		{PrivilegeEscalationAuto, "ubuntu", AuthMethodPassword, PrivilegeEscalationSudo},
+		{PrivilegeEscalationAuto, "ubuntu", AuthMethodPrivateKey, PrivilegeEscalationSudoNoPasswd},
+		{PrivilegeEscalationDoas, "root", AuthMethodPrivateKey, PrivilegeEscalationDoas},
+		{PrivilegeEscalationNone, "ubuntu", AuthMethodPassword, PrivilegeEscalationNone},
+	}
+	for _, tc := range cases {
+		if got := tc.mode.Resolve(tc.user, tc.authType); got != tc.want {
+			t.Errorf("%q for %s/%s: expected %q, got %q", tc.mode, tc.user, tc.authType, tc.want, got)
+		}
+	}
+}
+
+func TestAccessConfigDockerSudo(t *testing.T) {
+	cfg := AccessConfig{AuthType: AuthMethodPassword, Secret: "pw", PrivilegeEscalation: PrivilegeEscalationSudo}
+	if enabled, password, doas := cfg.DockerSudo(); !enabled || password != "pw" || doas {
+		t.Fatalf("sudo: got enabled=%v password=%q doas=%v", enabled, password, doas)
+	}
+	keyCfg := AccessConfig{AuthType: AuthMethodPrivateKey, Secret: "key", Password: "login-pw", PrivilegeEscalation: PrivilegeEscalationSudo}
+	if _, password, _ := keyCfg.DockerSudo(); password != "login-pw" {
+		t.Fatalf("sudo with key: expected the stored login password, got %q", password)
+	}
+	cfg.PrivilegeEscalation = PrivilegeEscalationDoas
+	if enabled, password, doas := cfg.DockerSudo(); !enabled || password != "" || !doas {
+		t.Fatalf("doas: got enabled=%v password=%q doas=%v", enabled, password, doas)
+	}
+	cfg.PrivilegeEscalation = PrivilegeEscalationNone
+	if enabled, _, _ := cfg.DockerSudo(); enabled {
+		t.Fatal("none: expected sudo disabled")
+	}
+}
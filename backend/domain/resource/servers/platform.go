@@ -0,0 +1,138 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Platform is the OS/platform detected on a server and cached on its record.
+type Platform struct {
+	// OS is the os-release ID (e.g. "ubuntu", "rocky"), or the lowercased
+	// kernel name when /etc/os-release is absent.
+	OS             string `json:"os"`
+	OSLike         string `json:"os_like,omitempty"`
+	Name           string `json:"name,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Arch           string `json:"arch,omitempty"`
+	Kernel         string `json:"kernel,omitempty"`
+	InitSystem     string `json:"init_system"`
+	PackageManager string `json:"package_manager,omitempty"`
+}
+
+// PlatformDetectCommand prints /etc/os-release followed by APPOS_* lines for
+// uname, the init system and the first package manager found. It only uses
+// POSIX sh so it runs on minimal images.
+const PlatformDetectCommand = `cat /etc/os-release 2>/dev/null; ` +
+	`echo "APPOS_KERNEL_NAME=$(uname -s 2>/dev/null)"; ` +
+	`echo "APPOS_KERNEL=$(uname -r 2>/dev/null)"; ` +
+	`echo "APPOS_ARCH=$(uname -m 2>/dev/null)"; ` +
+	`if [ -d /run/systemd/system ]; then echo APPOS_INIT=systemd; ` +
+	`elif command -v openrc >/dev/null 2>&1 || [ -d /run/openrc ]; then echo APPOS_INIT=openrc; ` +
+	`else echo "APPOS_INIT=$(cat /proc/1/comm 2>/dev/null)"; fi; ` +
+	`for pm in apt-get dnf yum zypper apk pacman; do ` +
+	`if command -v "$pm" >/dev/null 2>&1; then echo "APPOS_PKG=$pm"; break; fi; done`
+
+// ParsePlatform parses the output of PlatformDetectCommand.
+func ParsePlatform(output string) (Platform, error) {
+	values := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		values[key] = unquoteOSReleaseValue(value)
+	}
+
+	p := Platform{
+		OS:             strings.ToLower(values["ID"]),
+		OSLike:         strings.ToLower(values["ID_LIKE"]),
+		Name:           values["PRETTY_NAME"],
+		Version:        values["VERSION_ID"],
+		Arch:           values["APPOS_ARCH"],
+		Kernel:         values["APPOS_KERNEL"],
+		InitSystem:     normalizeInitSystem(values["APPOS_INIT"]),
+		PackageManager: strings.TrimSuffix(values["APPOS_PKG"], "-get"),
+	}
+	if p.OS == "" {
+		p.OS = strings.ToLower(values["APPOS_KERNEL_NAME"])
+	}
+	if p.OS == "" && p.Arch == "" {
+		return Platform{}, fmt.Errorf("platform detection returned no OS or architecture")
+	}
+	return p, nil
+}
+
+// unquoteOSReleaseValue strips the shell quoting os-release allows.
+func unquoteOSReleaseValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		if q := value[0]; (q == '"' || q == '\'') && value[len(value)-1] == q {
+			value = value[1 : len(value)-1]
+			if q == '"' {
+				value = strings.NewReplacer(`\"`, `"`, `\\`, `\`, "\\$", "$", "\\`", "`").Replace(value)
+			}
+		}
+	}
+	return value
+}
+
+func normalizeInitSystem(raw string) string {
+	switch name := strings.ToLower(strings.TrimSpace(raw)); name {
+	case "systemd", "openrc":
+		return name
+	case "init":
+		return "sysvinit"
+	case "":
+		return "unknown"
+	default:
+		// PID 1 is something else, e.g. a container entrypoint.
+		return name
+	}
+}
+
+// PlatformFromRecord returns the cached platform of a server record, or nil
+// when it has not been detected yet.
+func PlatformFromRecord(record *core.Record) *Platform {
+	var raw []byte
+	switch v := record.Get("platform").(type) {
+	case types.JSONRaw:
+		raw = v
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case nil:
+		return nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		raw = encoded
+	}
+	var p Platform
+	if err := json.Unmarshal(raw, &p); err != nil || p.OS == "" {
+		return nil
+	}
+	return &p
+}
+
+// SavePlatform caches p on the server record and stamps platform_detected_at.
+// The record is re-read inside a transaction so an edit saved while detection
+// ran is kept; only the platform fields change.
+func SavePlatform(app core.App, serverID string, p Platform) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById("servers", serverID)
+		if err != nil {
+			return err
+		}
+		record.Set("platform", p)
+		record.Set("platform_detected_at", time.Now().UTC().Format(time.RFC3339))
+		return txApp.Save(record)
+	})
+}
@@ -0,0 +1,48 @@
+package servers
+
+import "testing"
+
+func TestParsePlatform(t *testing.T) {
+	output := `NAME="Rocky Linux"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+VERSION_ID="9.3"
+PRETTY_NAME="Rocky Linux 9.3 (Blue Onyx)"
+APPOS_KERNEL_NAME=Linux
+APPOS_KERNEL=5.14.0-362.el9.x86_64
+APPOS_ARCH=x86_64
+APPOS_INIT=systemd
+APPOS_PKG=dnf
+`
+	p, err := ParsePlatform(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Platform{
+		OS:             "rocky",
+		OSLike:         "rhel centos fedora",
+		Name:           "Rocky Linux 9.3 (Blue Onyx)",
+		Version:        "9.3",
+		Arch:           "x86_64",
+		Kernel:         "5.14.0-362.el9.x86_64",
+		InitSystem:     "systemd",
+		PackageManager: "dnf",
+	}
+	if p != want {
+		t.Fatalf("got %+v, want %+v", p, want)
+	}
+}
+
+func TestParsePlatformWithoutOSRelease(t *testing.T) {
+	p, err := ParsePlatform("APPOS_KERNEL_NAME=Linux\nAPPOS_ARCH=aarch64\nAPPOS_INIT=init\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.OS != "linux" || p.Arch != "aarch64" || p.InitSystem != "sysvinit" || p.PackageManager != "" {
+		t.Fatalf("unexpected platform %+v", p)
+	}
+
+	if _, err := ParsePlatform("bash: uname: command not found\n"); err == nil {
+		t.Fatal("expected empty output to be rejected")
+	}
+}
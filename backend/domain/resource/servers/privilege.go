@@ -0,0 +1,73 @@
+package servers
+
+// PrivilegeEscalation selects how commands that need root run on a server.
+type PrivilegeEscalation string
+
+const (
+	// PrivilegeEscalationAuto runs as-is for root and through sudo otherwise,
+	// using the login password when the credential is a password.
+	PrivilegeEscalationAuto PrivilegeEscalation = ""
+	// PrivilegeEscalationNone runs commands as the login user.
+	PrivilegeEscalationNone PrivilegeEscalation = "none"
+	// PrivilegeEscalationSudo runs commands through sudo, answering its
+	// password prompt with the login password.
+	PrivilegeEscalationSudo PrivilegeEscalation = "sudo"
+	// PrivilegeEscalationSudoNoPasswd runs commands through `sudo -n`.
+	PrivilegeEscalationSudoNoPasswd PrivilegeEscalation = "sudo-nopasswd"
+	// PrivilegeEscalationDoas runs commands through `doas -n`.
+	PrivilegeEscalationDoas PrivilegeEscalation = "doas"
+)
+
+// PrivilegeEscalationValues lists the values accepted for
+// servers.privilege_escalation; empty means auto.
+var PrivilegeEscalationValues = []string{
+	string(PrivilegeEscalationNone),
+	string(PrivilegeEscalationSudo),
+	string(PrivilegeEscalationSudoNoPasswd),
+	string(PrivilegeEscalationDoas),
+}
+
+// IsValid reports whether p is auto or one of PrivilegeEscalationValues.
+func (p PrivilegeEscalation) IsValid() bool {
+	switch p {
+	case PrivilegeEscalationAuto, PrivilegeEscalationNone, PrivilegeEscalationSudo,
+		PrivilegeEscalationSudoNoPasswd, PrivilegeEscalationDoas:
+		return true
+	}
+	return false
+}
+
+// Resolve turns auto into a concrete strategy for the given login user and
+// credential kind. Explicit strategies are returned unchanged.
+func (p PrivilegeEscalation) Resolve(user string, authType AccessAuthType) PrivilegeEscalation {
+	if p != PrivilegeEscalationAuto {
+		return p
+	}
+	switch {
+	case user == "root":
+		return PrivilegeEscalationNone
+	case authType == AuthMethodPassword:
+		return PrivilegeEscalationSudo
+	default:
+		return PrivilegeEscalationSudoNoPasswd
+	}
+}
+
+// DockerSudo maps the resolved strategy onto the docker SSH executor's sudo
+// settings. sudo answers its prompt with the login password when there is one.
+func (c AccessConfig) DockerSudo() (enabled bool, password string, doas bool) {
+	switch c.PrivilegeEscalation {
+	case PrivilegeEscalationSudo:
+		if c.AuthType == AuthMethodPassword {
+			password = c.Secret
+		} else {
+			password = c.Password
+		}
+		return true, password, false
+	case PrivilegeEscalationSudoNoPasswd:
+		return true, "", false
+	case PrivilegeEscalationDoas:
+		return true, "", true
+	}
+	return false, "", false
+}
@@ -191,7 +191,13 @@ func (s TunnelService) Overview() (TunnelOverviewResult, error) {
 		items = append(items, item)
 	}
 
-	return TunnelOverviewResult{Summary: summary, Items: items}, nil
+	capacity, err := s.PortCapacity()
+	if err != nil {
+		return TunnelOverviewResult{}, err
+	}
+	summary["free_server_slots"] = capacity.FreeServerSlots
+
+	return TunnelOverviewResult{Summary: summary, Items: items, PortCapacity: capacity}, nil
 }
 
 func (s TunnelService) Session(record *core.Record) (map[string]any, error) {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -34,6 +35,7 @@ const (
 	ActionTunnelResume          = "tunnel.resume"
 	ActionTunnelTokenGenerated  = "tunnel.token_generated" // #nosec G101 -- audit action name, not a credential
 	ActionTunnelTokenRotated    = "tunnel.token_rotated"
+	ActionTunnelTokenRevealed   = "tunnel.token_revealed" // #nosec G101 -- audit action name, not a credential
 	ActionTunnelConnectRejected = "tunnel.connect_rejected"
 	ActionTunnelForwardsUpdated = "tunnel.forwards_updated"
 	ActionTunnelPauseExpired    = "tunnel.pause_expired"
@@ -51,6 +53,9 @@ type TunnelService struct {
 	Sessions  *tunnelcore.Registry
 	Tokens    TunnelTokenProvider
 	Validator tunnelcore.TokenValidator
+	// PortRange is the configured tunnel port range; the zero value means
+	// the default range.
+	PortRange tunnelcore.PortRange
 }
 
 type TunnelForwardInput struct {
@@ -66,9 +71,26 @@ type TunnelSetupResult struct {
 	Forwards       []map[string]any `json:"forwards"`
 }
 
+// TunnelInfoResult is the consolidated reconnect view of a tunnel server.
+// Unless revealed, Token and the token-bearing setup strings are masked and
+// SetupScriptURL is empty.
+type TunnelInfoResult struct {
+	TokenIssued        bool               `json:"token_issued"`
+	TokenMasked        bool               `json:"token_masked"`
+	Token              string             `json:"token"`
+	AutosshCmd         string             `json:"autossh_cmd"`
+	SystemdUnit        string             `json:"systemd_unit"`
+	SetupScriptURL     string             `json:"setup_script_url,omitempty"`
+	Forwards           []map[string]any   `json:"forwards"`
+	Status             TunnelStatusResult `json:"status"`
+	SSHPort            string             `json:"ssh_port"`
+	HostKeyFingerprint string             `json:"host_key_fingerprint,omitempty"`
+}
+
 type TunnelOverviewResult struct {
-	Summary map[string]int   `json:"summary"`
-	Items   []map[string]any `json:"items"`
+	Summary      map[string]int          `json:"summary"`
+	Items        []map[string]any        `json:"items"`
+	PortCapacity tunnelcore.PortCapacity `json:"port_capacity"`
 }
 
 type TunnelTokenIssueResult struct {
@@ -89,6 +111,22 @@ type TunnelStatusResult struct {
 	ConnectedAt string `json:"connected_at,omitempty"`
 	LastSeen    string `json:"last_seen,omitempty"`
 	Services    any    `json:"services,omitempty"`
+	// Agent is the latest report from a tunnel agent; absent for plain autossh.
+	Agent *tunnelcore.AgentStatus `json:"agent,omitempty"`
+}
+
+// TunnelServerStatus is one server's entry in TunnelStatusListResult.
+type TunnelServerStatus struct {
+	ServerID string `json:"server_id"`
+	Name     string `json:"name"`
+	TunnelStatusResult
+}
+
+// TunnelStatusListResult is the live status of every tunnel server. The port
+// capacity is reported once for the list rather than per server.
+type TunnelStatusListResult struct {
+	Items        []TunnelServerStatus    `json:"items"`
+	PortCapacity tunnelcore.PortCapacity `json:"port_capacity"`
 }
 
 type TunnelDisconnectResult struct {
@@ -134,6 +172,70 @@ func (s TunnelService) BuildSetupForServer(serverID, apposHost, sshPort string)
 	return s.BuildSetup(managedServer, rawToken, apposHost, sshPort)
 }
 
+// Info gathers everything needed to reconnect a tunnel server: token, setup
+// command and unit, current status with assigned service ports, and the
+// tunnel host-key fingerprint. A server without a token yet still gets
+// status and forwards, with TokenIssued false.
+func (s TunnelService) Info(serverID, apposHost, sshPort string, reveal bool) (TunnelInfoResult, error) {
+	record, managedServer, err := s.loadManagedServer(serverID)
+	if err != nil {
+		return TunnelInfoResult{}, fmt.Errorf("load server %s: %w", serverID, err)
+	}
+
+	status, err := s.Status(record)
+	if err != nil {
+		return TunnelInfoResult{}, fmt.Errorf("load status for %s: %w", serverID, err)
+	}
+	forwards, err := managedServer.TunnelForwardSpecs()
+	if err != nil {
+		return TunnelInfoResult{}, fmt.Errorf("parse forwards: %w", err)
+	}
+	result := TunnelInfoResult{
+		Forwards: ForwardSpecsToResponse(forwards),
+		Status:   status,
+		SSHPort:  sshPort,
+	}
+	if fingerprint, err := tunnelcore.HostKeyFingerprint(s.App.DataDir()); err == nil {
+		result.HostKeyFingerprint = fingerprint
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return TunnelInfoResult{}, fmt.Errorf("read tunnel host key: %w", err)
+	}
+
+	rawToken, found, err := s.Tokens.Get(serverID)
+	if err != nil {
+		return TunnelInfoResult{}, fmt.Errorf("get token for %s: %w", serverID, err)
+	}
+	if !found {
+		return result, nil
+	}
+
+	token := rawToken
+	if !reveal {
+		token = maskTunnelToken(rawToken)
+	}
+	setup, err := s.BuildSetup(managedServer, token, apposHost, sshPort)
+	if err != nil {
+		return TunnelInfoResult{}, err
+	}
+	result.TokenIssued = true
+	result.TokenMasked = !reveal
+	result.Token = token
+	result.AutosshCmd = setup.AutosshCmd
+	result.SystemdUnit = setup.SystemdUnit
+	if reveal {
+		result.SetupScriptURL = setup.SetupScriptURL
+	}
+	return result, nil
+}
+
+// maskTunnelToken keeps a short prefix so operators can tell tokens apart.
+func maskTunnelToken(token string) string {
+	if len(token) <= 8 {
+		return "********"
+	}
+	return token[:4] + "********"
+}
+
 func (s TunnelService) BuildSetupScriptByToken(rawToken, apposHost, sshPort string) (string, error) {
 	managedServerID, ok := s.Validator.Validate(rawToken)
 	if !ok {
@@ -244,14 +346,46 @@ func (s TunnelService) Resume(record *core.Record) (TunnelPauseResult, error) {
 	}, nil
 }
 
+// Status returns record's live tunnel status. Port capacity spans all tunnel
+// servers, so it is reported by StatusAll and the overview instead.
 func (s TunnelService) Status(record *core.Record) (TunnelStatusResult, error) {
+	return s.liveStatus(record), nil
+}
+
+// StatusAll returns the live status of every tunnel server, ordered by name,
+// from one server query. Connected servers are read from the session
+// registry; the rest fall back to their stored runtime fields.
+func (s TunnelService) StatusAll() (TunnelStatusListResult, error) {
+	records, err := s.App.FindRecordsByFilter(CollectionServers, "connect_type = 'tunnel'", "name", 0, 0)
+	if err != nil {
+		return TunnelStatusListResult{}, fmt.Errorf("list tunnel servers: %w", err)
+	}
+
+	items := make([]TunnelServerStatus, 0, len(records))
+	for _, rec := range records {
+		items = append(items, TunnelServerStatus{
+			ServerID:           rec.Id,
+			Name:               rec.GetString("name"),
+			TunnelStatusResult: s.liveStatus(rec),
+		})
+	}
+	return TunnelStatusListResult{
+		Items:        items,
+		PortCapacity: portCapacityFromRecords(s.portRange(), records),
+	}, nil
+}
+
+// liveStatus reads record's status from the session registry, falling back
+// to the DB runtime fields when the server is not connected.
+func (s TunnelService) liveStatus(record *core.Record) TunnelStatusResult {
 	if s.Sessions != nil {
 		if sess, ok := s.Sessions.Get(record.Id); ok {
 			return TunnelStatusResult{
 				Status:      string(servers.TunnelStatusOnline),
 				ConnectedAt: sess.ConnectedAt.Format(time.RFC3339),
 				Services:    sess.Services,
-			}, nil
+				Agent:       sess.AgentStatus(),
+			}
 		}
 	}
 
@@ -271,7 +405,44 @@ func (s TunnelService) Status(record *core.Record) (TunnelStatusResult, error) {
 		Status:   string(status),
 		LastSeen: servers.FormatTunnelTime(runtime.LastSeen),
 		Services: services,
-	}, nil
+	}
+}
+
+// PortCapacity sizes the configured port range against all tunnel servers.
+func (s TunnelService) PortCapacity() (tunnelcore.PortCapacity, error) {
+	return s.PortCapacityFor(s.portRange())
+}
+
+func (s TunnelService) portRange() tunnelcore.PortRange {
+	if s.PortRange.Size() == 0 {
+		return tunnelcore.DefaultPortRange()
+	}
+	return s.PortRange
+}
+
+// PortCapacityFor sizes portRange against all tunnel servers, counting the
+// desired forwards of servers that have not connected yet.
+func (s TunnelService) PortCapacityFor(portRange tunnelcore.PortRange) (tunnelcore.PortCapacity, error) {
+	records, err := s.App.FindRecordsByFilter(CollectionServers, "connect_type = 'tunnel'", "", 0, 0)
+	if err != nil {
+		return tunnelcore.PortCapacity{}, fmt.Errorf("list tunnel servers: %w", err)
+	}
+	return portCapacityFromRecords(portRange, records), nil
+}
+
+func portCapacityFromRecords(portRange tunnelcore.PortRange, records []*core.Record) tunnelcore.PortCapacity {
+	demands := make([]tunnelcore.PortDemand, 0, len(records))
+	for _, rec := range records {
+		demand := tunnelcore.PortDemand{}
+		if forwards, err := servers.ManagedServerFromRecord(rec).TunnelForwardSpecs(); err == nil {
+			demand.Forwards = len(forwards)
+		}
+		for _, svc := range servers.TunnelRuntimeFromRecord(rec).Services() {
+			demand.Assigned = append(demand.Assigned, svc.TunnelPort)
+		}
+		demands = append(demands, demand)
+	}
+	return tunnelcore.ComputePortCapacity(portRange, demands)
 }
 
 func (s TunnelService) Disconnect(managedServerID string) (TunnelDisconnectResult, error) {
@@ -0,0 +1,146 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+const (
+	// MaxClientEnvVars caps the variables one terminal client may set.
+	MaxClientEnvVars = 16
+	// MaxClientEnvValueBytes caps each client-supplied value.
+	MaxClientEnvValueBytes = 1024
+)
+
+// ParseSessionEnv decodes the servers.env JSON object into variable names and
+// string values. Scalar values are stringified; nested values are rejected.
+func ParseSessionEnv(raw any) (map[string]string, error) {
+	var data []byte
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case types.JSONRaw:
+		data = v
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("env: %w", err)
+		}
+		data = encoded
+	}
+	if s := strings.TrimSpace(string(data)); s == "" || s == "null" {
+		return nil, nil
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("env must be a JSON object of variable names to values")
+	}
+	env := make(map[string]string, len(values))
+	for name, value := range values {
+		if !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("env: invalid variable name %q", name)
+		}
+		switch v := value.(type) {
+		case string:
+			env[name] = v
+		case float64, bool:
+			env[name] = fmt.Sprint(v)
+		case nil:
+			env[name] = ""
+		default:
+			return nil, fmt.Errorf("env: value for %q must be a string, number or boolean", name)
+		}
+	}
+	return env, nil
+}
+
+// ValidEnvNamePattern reports whether p is a variable name, optionally ending
+// in "*" to match every name with that prefix.
+func ValidEnvNamePattern(p string) bool {
+	return envNamePattern.MatchString(strings.TrimSuffix(p, "*"))
+}
+
+// EnvNameAllowed reports whether name matches an allowlist entry.
+func EnvNameAllowed(name string, allowlist []string) bool {
+	for _, p := range allowlist {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseClientSessionEnv parses the variables a terminal client asks to set
+// for its session. Unlike servers.env, every name must be on allowlist and
+// values are typed into the shell, so they are limited to
+// MaxClientEnvValueBytes of UTF-8 without control characters.
+func ParseClientSessionEnv(raw string, allowlist []string) (map[string]string, error) {
+	env, err := ParseSessionEnv(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(env) > MaxClientEnvVars {
+		return nil, fmt.Errorf("env: at most %d variables may be set", MaxClientEnvVars)
+	}
+	for name, value := range env {
+		if !EnvNameAllowed(name, allowlist) {
+			return nil, fmt.Errorf("env: %q is not on the session env allowlist", name)
+		}
+		if len(value) > MaxClientEnvValueBytes {
+			return nil, fmt.Errorf("env: value for %q exceeds %d bytes", name, MaxClientEnvValueBytes)
+		}
+		if !utf8.ValidString(value) || strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			return nil, fmt.Errorf("env: value for %q contains invalid characters", name)
+		}
+	}
+	return env, nil
+}
+
+// ValidateDefaultDir checks that dir is empty or an absolute POSIX path.
+func ValidateDefaultDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if !strings.HasPrefix(dir, "/") {
+		return fmt.Errorf("default_dir must be an absolute path")
+	}
+	if strings.ContainsAny(dir, "\x00\n\r") {
+		return fmt.Errorf("default_dir contains invalid characters")
+	}
+	return nil
+}
+
+// MaxTerminalBannerLength caps a terminal banner in bytes.
+const MaxTerminalBannerLength = 2000
+
+// ValidateTerminalBanner checks that banner fits MaxTerminalBannerLength and
+// holds no control characters other than newlines and tabs, so it cannot
+// carry terminal escape sequences.
+func ValidateTerminalBanner(banner string) error {
+	if len(banner) > MaxTerminalBannerLength {
+		return fmt.Errorf("terminal banner must be at most %d bytes", MaxTerminalBannerLength)
+	}
+	if !utf8.ValidString(banner) || strings.IndexFunc(banner, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t'
+	}) >= 0 {
+		return fmt.Errorf("terminal banner contains invalid characters")
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package servers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// TemplatesCollection stores named connection presets for servers.
+const TemplatesCollection = "server_templates"
+
+// TemplateField is the servers create body field naming the template whose
+// connection fields fill in what the body leaves out. It is not a collection
+// field.
+const TemplateField = "template"
+
+// TemplateFields are the connection fields a server template carries. They
+// share their names with the servers collection fields they set.
+var TemplateFields = []string{
+	"port",
+	"user",
+	"connect_type",
+	"credential",
+	"shell",
+	"default_dir",
+	"env",
+	"legacy_ssh",
+	"privilege_escalation",
+}
+
+// DefinedTemplateFields returns the TemplateFields that tpl sets, i.e. those
+// with a non-empty value. legacy_ssh counts as set only when enabled.
+func DefinedTemplateFields(tpl *core.Record) []string {
+	defined := make([]string, 0, len(TemplateFields))
+	for _, field := range TemplateFields {
+		if !isEmptyTemplateValue(tpl.Get(field)) {
+			defined = append(defined, field)
+		}
+	}
+	return defined
+}
+
+// ValidateTemplateFields reports an error when fields names anything other
+// than TemplateFields.
+func ValidateTemplateFields(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(TemplateFields, field) {
+			return fmt.Errorf("unknown template field %q; must be one of %s", field, strings.Join(TemplateFields, ", "))
+		}
+	}
+	return nil
+}
+
+// ApplyTemplate copies fields from tpl to server as they are, including empty
+// values. The caller saves server.
+func ApplyTemplate(server, tpl *core.Record, fields []string) {
+	for _, field := range fields {
+		server.Set(field, tpl.Get(field))
+	}
+}
+
+// applyCreateTemplate fills the fields a servers create body leaves unset
+// from the template named by its template field. skipCredential keeps the
+// template credential out when the body carries an inline credential.
+func applyCreateTemplate(app core.App, server *core.Record, body map[string]any, skipCredential bool) error {
+	id, _ := body[TemplateField].(string)
+	if strings.TrimSpace(id) == "" {
+		return nil
+	}
+	tpl, err := app.FindRecordById(TemplatesCollection, strings.TrimSpace(id))
+	if err != nil {
+		return fmt.Errorf("server template %q not found", id)
+	}
+
+	fields := make([]string, 0, len(TemplateFields))
+	for _, field := range DefinedTemplateFields(tpl) {
+		if field == "credential" && skipCredential {
+			continue
+		}
+		if v, ok := body[field]; ok && !isEmptyTemplateValue(v) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	ApplyTemplate(server, tpl, fields)
+	return nil
+}
+
+func isEmptyTemplateValue(v any) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(value) == ""
+	case bool:
+		return !value
+	case float64:
+		return value == 0
+	case int:
+		return value == 0
+	case []string:
+		return len(value) == 0
+	case types.JSONRaw:
+		raw := strings.TrimSpace(string(value))
+		return raw == "" || raw == "null" || raw == "{}" || raw == "[]"
+	case map[string]any:
+		return len(value) == 0
+	case []any:
+		return len(value) == 0
+	}
+	return false
+}
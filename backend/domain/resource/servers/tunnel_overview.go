@@ -85,6 +85,8 @@ func TunnelDisconnectReasonLabel(reason string) string {
 		return "Token rotated"
 	case tunnelcore.DisconnectReasonSessionReplaced:
 		return "Replaced by newer session"
+	case tunnelcore.DisconnectReasonServerDeleted:
+		return "Server deleted"
 	case tunnelcore.DisconnectReasonKeepaliveTimeout:
 		return "Keepalive timeout"
 	case tunnelcore.DisconnectReasonConnectionError:
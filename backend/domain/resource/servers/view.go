@@ -49,6 +49,8 @@ type ServerViewItem struct {
 	Updated         string         `json:"updated"`
 	FactsJSON       any            `json:"facts_json,omitempty"`
 	FactsObservedAt string         `json:"facts_observed_at,omitempty"`
+	Platform        *Platform      `json:"platform,omitempty"`
+	PlatformAt      string         `json:"platform_detected_at,omitempty"`
 	Connection      ConnectionView `json:"connection"`
 	Access          AccessView     `json:"access"`
 	Tunnel          *TunnelView    `json:"tunnel,omitempty"`
@@ -72,6 +74,8 @@ func BuildServerViewItem(record *core.Record, credentialType string, createdByNa
 		Updated:         recordDateTime(record, "updated").Format(time.RFC3339),
 		FactsJSON:       record.Get("facts_json"),
 		FactsObservedAt: recordDateTime(record, "facts_observed_at").Format(time.RFC3339),
+		Platform:        PlatformFromRecord(record),
+		PlatformAt:      recordDateTime(record, "platform_detected_at").Format(time.RFC3339),
 		Access: AccessView{
 			Status: "unknown",
 			Reason: "",
@@ -88,6 +92,9 @@ func BuildServerViewItem(record *core.Record, credentialType string, createdByNa
 	if item.FactsObservedAt == "0001-01-01T00:00:00Z" {
 		item.FactsObservedAt = ""
 	}
+	if item.PlatformAt == "0001-01-01T00:00:00Z" {
+		item.PlatformAt = ""
+	}
 
 	if managed.ConnectType != ConnectionModeTunnel {
 		return item
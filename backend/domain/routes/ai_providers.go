@@ -7,6 +7,7 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/groups"
 	"github.com/websoft9/appos/backend/domain/resource/accounts"
 	"github.com/websoft9/appos/backend/domain/resource/aiproviders"
 	"github.com/websoft9/appos/backend/domain/secrets"
@@ -100,11 +101,23 @@ func handleAIProviderCreate(e *core.RequestEvent) error {
 	if err != nil {
 		return err
 	}
+	groupIDs, err := bindCreateGroups(e)
+	if err != nil {
+		return err
+	}
 	userID, _ := authInfo(e)
-	item, saveErr := aiproviders.CreateWithDeps(persistence.NewAIProviderRepository(e.App), input, aiproviders.SaveDeps{
-		ActorID:                     userID,
-		CredentialRefValidator:      aiProviderCredentialValidator{app: e.App},
-		ProviderAccountRefValidator: aiProviderAccountValidator{app: e.App},
+	var item *aiproviders.AIProvider
+	saveErr := createInGroups(e.App, groups.ObjectTypeAIProvider, groupIDs, func(app core.App) (string, error) {
+		created, err := aiproviders.CreateWithDeps(persistence.NewAIProviderRepository(app), input, aiproviders.SaveDeps{
+			ActorID:                     userID,
+			CredentialRefValidator:      aiProviderCredentialValidator{app: e.App},
+			ProviderAccountRefValidator: aiProviderAccountValidator{app: e.App},
+		})
+		if err != nil {
+			return "", err
+		}
+		item = created
+		return created.ID(), nil
 	})
 	if saveErr != nil {
 		writeAIProviderAudit(e, "ai_provider.create", nil, input, nil, saveErr)
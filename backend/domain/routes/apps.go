@@ -20,16 +20,11 @@ import (
 	"github.com/websoft9/appos/backend/domain/lifecycle/model"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
 	"github.com/websoft9/appos/backend/domain/terminal"
+	"github.com/websoft9/appos/backend/infra/docker"
 )
 
 const appComposeConfigMaxBytes int64 = 2 << 20
 
-type composeProjectStatus struct {
-	Name        string `json:"Name"`
-	Status      string `json:"Status"`
-	ConfigFiles string `json:"ConfigFiles"`
-}
-
 type appRuntimeContext struct {
 	ProjectDir         string
 	Source             string
@@ -43,6 +38,8 @@ func registerAppsRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	a.GET("/{id}", handleAppInstanceDetail)
 	a.GET("/{id}/releases", handleAppReleaseList)
 	a.GET("/{id}/releases/current", handleAppCurrentReleaseDetail)
+	a.GET("/{id}/image-digests", handleAppImageDigestDrift)
+	a.POST("/{id}/image-digests", handleAppImageDigestPin)
 	a.GET("/{id}/exposures", handleAppExposureList)
 	a.GET("/{id}/exposures/{exposureId}", handleAppExposureDetail)
 	a.GET("/{id}/logs", handleAppInstanceLogs)
@@ -580,6 +577,98 @@ func handleAppInstanceUninstall(e *core.RequestEvent) error {
 	return handleAppInstanceLifecycleOperationWithMetadata(e, string(model.OperationTypeUninstall), map[string]any{"remove_volumes": removeVolumes})
 }
 
+// @Summary Check app image digest drift
+// @Description Compares the images the app's compose containers run now with the image digests recorded on its current release, which are captured when a deploy succeeds. drift.changed lists services running a different image, drift.added services that were not recorded, drift.missing recorded services with no container. Returns 409 when the current release has no recorded digests. Superuser only.
+// @Tags Apps
+// @Security BearerAuth
+// @Param id path string true "app instance ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/apps/{id}/image-digests [get]
+func handleAppImageDigestDrift(e *core.RequestEvent) error {
+	_, release, current, err := currentAppImageDigests(e)
+	if err != nil {
+		return err
+	}
+	var recorded []docker.ImageDigest
+	if err := release.UnmarshalJSONField("image_digests", &recorded); err != nil || release.GetDateTime("image_digests_at").IsZero() {
+		return e.JSON(http.StatusConflict, map[string]any{"code": 409, "message": "image digests are not recorded for the current release"})
+	}
+	return e.JSON(http.StatusOK, map[string]any{
+		"release_id":  release.Id,
+		"recorded_at": release.GetDateTime("image_digests_at").String(),
+		"recorded":    recorded,
+		"current":     current,
+		"drift":       docker.DiffImageDigests(recorded, current),
+	})
+}
+
+// @Summary Pin app image digests
+// @Description Records the images the app's compose containers run now on its current release, replacing the digests captured at deploy time. Use it to accept an intended update as the new baseline for drift checks. Writes an audit entry. Superuser only.
+// @Tags Apps
+// @Security BearerAuth
+// @Param id path string true "app instance ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/apps/{id}/image-digests [post]
+func handleAppImageDigestPin(e *core.RequestEvent) error {
+	appRecord, release, current, err := currentAppImageDigests(e)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	release.Set("image_digests", current)
+	release.Set("image_digests_at", now)
+	if err := e.App.Save(release); err != nil {
+		writeAppAudit(e, appRecord, "app.image_digests.pin", audit.StatusFailed, map[string]any{"release_id": release.Id, "errorMessage": err.Error()})
+		return e.JSON(http.StatusInternalServerError, map[string]any{"code": 500, "message": "failed to record image digests"})
+	}
+	writeAppAudit(e, appRecord, "app.image_digests.pin", audit.StatusSuccess, map[string]any{"release_id": release.Id, "containers": len(current)})
+	return e.JSON(http.StatusOK, map[string]any{
+		"release_id":  release.Id,
+		"recorded_at": release.GetDateTime("image_digests_at").String(),
+		"recorded":    current,
+	})
+}
+
+// currentAppImageDigests loads the app, its current release and the image
+// digests its containers run now. A non-nil error has already been written
+// as the response.
+func currentAppImageDigests(e *core.RequestEvent) (*core.Record, *core.Record, []docker.ImageDigest, error) {
+	appRecord, err := findAppInstance(e, e.Request.PathValue("id"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	releaseID := strings.TrimSpace(appRecord.GetString("current_release"))
+	if releaseID == "" {
+		return nil, nil, nil, e.JSON(http.StatusNotFound, map[string]any{"code": 404, "message": "current release not found"})
+	}
+	release, err := e.App.FindRecordById("app_releases", releaseID)
+	if err != nil {
+		return nil, nil, nil, e.JSON(http.StatusNotFound, map[string]any{"code": 404, "message": "current release not found"})
+	}
+	runtimeContext, err := resolveAppRuntimeContext(e.App, appRecord)
+	if err != nil {
+		return nil, nil, nil, e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	client, err := servers.NewDockerClient(e.App, normalizeAppServerID(appRecord.GetString("server_id")), localDockerClient)
+	if err != nil {
+		return nil, nil, nil, e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	current, err := client.ComposeImageDigests(e.Request.Context(), runtimeContext.ProjectDir)
+	if err != nil {
+		return nil, nil, nil, e.JSON(http.StatusInternalServerError, map[string]any{"code": 500, "message": "resolve image digests failed: " + err.Error()})
+	}
+	return appRecord, release, current, nil
+}
+
 func findAppInstance(e *core.RequestEvent, id string) (*core.Record, error) {
 	if id == "" {
 		return nil, e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "id is required"})
@@ -686,24 +775,11 @@ func composeStatusIndex(app core.App, serverID string) (map[string]string, error
 	if err != nil {
 		return nil, err
 	}
-	output, err := client.ComposeLs(context.Background())
+	projects, err := client.ComposeProjects(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	var projects []composeProjectStatus
-	if strings.TrimSpace(output) == "" {
-		return map[string]string{}, nil
-	}
-	if err := json.Unmarshal([]byte(output), &projects); err != nil {
-		var single composeProjectStatus
-		if singleErr := json.Unmarshal([]byte(output), &single); singleErr == nil && single.Name != "" {
-			projects = []composeProjectStatus{single}
-		} else {
-			return nil, err
-		}
-	}
-
 	index := make(map[string]string, len(projects))
 	for _, project := range projects {
 		index[project.Name] = project.Status
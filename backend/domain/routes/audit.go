@@ -0,0 +1,208 @@
+package routes
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// registerAuditRoutes mounts /api/ext/audit with superuser-only access.
+func registerAuditRoutes(g *router.RouterGroup[*core.RequestEvent]) {
+	a := g.Group("/audit")
+	a.Bind(apis.RequireSuperuserAuth())
+	a.GET("/export", handleAuditExport)
+}
+
+// auditExportColumns is the CSV header; NDJSON rows use the same keys.
+var auditExportColumns = []string{
+	"id", "created", "user_id", "user_email", "action", "resource_type",
+	"resource_id", "resource_name", "status", "ip", "detail",
+}
+
+type auditExportRow struct {
+	ID           string          `json:"id"`
+	Created      string          `json:"created"`
+	UserID       string          `json:"user_id"`
+	UserEmail    string          `json:"user_email"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	ResourceName string          `json:"resource_name"`
+	Status       string          `json:"status"`
+	IP           string          `json:"ip"`
+	Detail       json.RawMessage `json:"detail,omitempty"`
+}
+
+func auditExportRowFromRecord(record *core.Record) auditExportRow {
+	row := auditExportRow{
+		ID:           record.Id,
+		Created:      record.GetDateTime("created").String(),
+		UserID:       record.GetString("user_id"),
+		UserEmail:    record.GetString("user_email"),
+		Action:       record.GetString("action"),
+		ResourceType: record.GetString("resource_type"),
+		ResourceID:   record.GetString("resource_id"),
+		ResourceName: record.GetString("resource_name"),
+		Status:       record.GetString("status"),
+		IP:           record.GetString("ip"),
+	}
+	if detail := record.GetString("detail"); detail != "" && detail != "null" {
+		row.Detail = json.RawMessage(detail)
+	}
+	return row
+}
+
+func (r auditExportRow) csvRecord() []string {
+	return []string{
+		r.ID, r.Created, r.UserID, r.UserEmail, r.Action, r.ResourceType,
+		r.ResourceID, r.ResourceName, r.Status, r.IP, string(r.Detail),
+	}
+}
+
+// auditExportMaxRows reads the "audit/export" maxRows cap.
+func auditExportMaxRows(app core.App) int {
+	cfg, _ := sysconfig.GetGroup(app, audit.SettingsModule, audit.ExportSettingsKey, nil)
+	return sysconfig.Int(cfg, "maxRows", audit.DefaultExportMaxRows)
+}
+
+// parseAuditExportFilter reads from, to, action and limit. limit defaults to,
+// and is capped at, maxRows.
+func parseAuditExportFilter(q map[string][]string, maxRows int) (audit.ExportFilter, error) {
+	get := func(key string) string {
+		if values := q[key]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+	filter := audit.ExportFilter{Limit: maxRows}
+	for key, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := get(key); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC3339 timestamp", key)
+			}
+			*target = t
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("from must be before to")
+	}
+	if action := get("action"); action != "" {
+		if !audit.ValidActionPattern(action) {
+			return filter, fmt.Errorf("action must be an action ID or a prefix ending in .*")
+		}
+		filter.Action = action
+	}
+	if raw := get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = min(limit, maxRows)
+	}
+	return filter, nil
+}
+
+// handleAuditExport streams audit log entries as NDJSON or CSV.
+//
+// @Summary Export audit log
+// @Description Streams audit_logs entries oldest first as newline-delimited JSON (format=ndjson, default) or CSV (format=csv) without loading the range into memory. from/to (RFC3339, to exclusive) bound the created time and action filters by action ID or prefix pattern such as server.ops.*. At most limit rows are returned, capped by the audit/export maxRows setting, which is reported in the X-Export-Max-Rows header. Superuser only.
+// @Tags Audit
+// @Security BearerAuth
+// @Param format query string false "ndjson (default) or csv"
+// @Param from query string false "RFC3339 start, inclusive"
+// @Param to query string false "RFC3339 end, exclusive"
+// @Param action query string false "action ID or prefix pattern ending in .*"
+// @Param limit query int false "maximum rows, capped by maxRows"
+// @Success 200 {string} string "NDJSON or CSV stream"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Router /api/ext/audit/export [get]
+func handleAuditExport(e *core.RequestEvent) error {
+	format := strings.ToLower(strings.TrimSpace(e.Request.URL.Query().Get("format")))
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		return e.BadRequestError("format must be ndjson or csv", nil)
+	}
+	maxRows := auditExportMaxRows(e.App)
+	filter, err := parseAuditExportFilter(e.Request.URL.Query(), maxRows)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	contentType := "application/x-ndjson"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	h := e.Response.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename=%q`, filename))
+	h.Set("X-Export-Max-Rows", strconv.Itoa(maxRows))
+	e.Response.WriteHeader(http.StatusOK)
+
+	out := bufio.NewWriterSize(e.Response, 32<<10)
+	var write func(auditExportRow) error
+	var csvOut *csv.Writer
+	if format == "csv" {
+		csvOut = csv.NewWriter(out)
+		if err := csvOut.Write(auditExportColumns); err != nil {
+			return err
+		}
+		write = func(row auditExportRow) error { return csvOut.Write(row.csvRecord()) }
+	} else {
+		enc := json.NewEncoder(out)
+		write = func(row auditExportRow) error { return enc.Encode(row) }
+	}
+
+	ctx := e.Request.Context()
+	rows, exportErr := audit.Export(e.App, filter, func(record *core.Record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return write(auditExportRowFromRecord(record))
+	})
+	if csvOut != nil {
+		csvOut.Flush()
+		if exportErr == nil {
+			exportErr = csvOut.Error()
+		}
+	}
+	if err := out.Flush(); exportErr == nil {
+		exportErr = err
+	}
+
+	status := audit.StatusSuccess
+	detail := map[string]any{"format": format, "rows": rows, "limit": filter.Limit}
+	if filter.Action != "" {
+		detail["action"] = filter.Action
+	}
+	if exportErr != nil {
+		status = audit.StatusFailed
+		detail["errorMessage"] = exportErr.Error()
+		e.App.Logger().Warn("audit export interrupted", "rows", rows, "error", exportErr)
+	}
+	userID, userEmail, ip, ua := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: "audit.export", ResourceType: "audit",
+		IP: ip, UserAgent: ua,
+		Status: status,
+		Detail: detail,
+	})
+	return nil
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+func doAudit(t *testing.T, te *testEnv, url, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(apis.RequireAuth())
+	registerAuditRoutes(g)
+
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuditExportStreamsAcrossBatches(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	// More rows than one cursor batch, so the keyset continuation is exercised.
+	const seeded = 1100
+	err := te.app.RunInTransaction(func(txApp core.App) error {
+		for i := 0; i < seeded; i++ {
+			audit.Write(txApp, audit.Entry{UserID: "u1", Action: "test.export.row", ResourceType: "test", Status: audit.StatusSuccess, Detail: map[string]any{"i": i}})
+		}
+		audit.Write(txApp, audit.Entry{UserID: "u1", Action: "other.action", ResourceType: "test", Status: audit.StatusSuccess})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := doAudit(t, te, "/api/ext/audit/export?action=test.export.*", te.token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != seeded {
+		t.Fatalf("expected %d rows, got %d", seeded, len(lines))
+	}
+	seen := make(map[string]bool, len(lines))
+	for i, line := range lines {
+		var row struct {
+			ID     string         `json:"id"`
+			Action string         `json:"action"`
+			Detail map[string]any `json:"detail"`
+		}
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+		if row.Action != "test.export.row" || seen[row.ID] {
+			t.Fatalf("row %d: unexpected or duplicate row %+v", i, row)
+		}
+		seen[row.ID] = true
+	}
+
+	rec = doAudit(t, te, "/api/ext/audit/export?format=csv&limit=5&action=test.export.row", te.token)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 6 || strings.Join(records[0], ",") != strings.Join(auditExportColumns, ",") {
+		t.Fatalf("expected header plus 5 rows, got %d: %v", len(records), records[0])
+	}
+
+	if err := sysconfig.SetGroup(te.app, audit.SettingsModule, audit.ExportSettingsKey, map[string]any{"maxRows": 10}); err != nil {
+		t.Fatal(err)
+	}
+	rec = doAudit(t, te, "/api/ext/audit/export?limit=500", te.token)
+	if got := strings.Count(rec.Body.String(), "\n"); got != 10 || rec.Header().Get("X-Export-Max-Rows") != "10" {
+		t.Fatalf("expected export capped at 10 rows, got %d (header %q)", got, rec.Header().Get("X-Export-Max-Rows"))
+	}
+	if entries := auditEntriesByAction(t, te, "audit.export"); len(entries) != 3 {
+		t.Fatalf("expected each export to be audited, got %d", len(entries))
+	}
+}
+
+func TestAuditExportValidatesQuery(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	for _, query := range []string{"format=xml", "from=yesterday", "from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z", "action=Bad Action", "limit=0"} {
+		rec := doAudit(t, te, "/api/ext/audit/export?"+strings.ReplaceAll(query, " ", "%20"), te.token)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := doAudit(t, te, "/api/ext/audit/export", createRegularUserToken(t, te)); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for regular user, got %d", rec.Code)
+	}
+}
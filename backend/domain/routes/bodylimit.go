@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// defaultMaxBodyMB is used when "http/limits" has no maxBodyMB.
+const defaultMaxBodyMB = 2
+
+// defaultMaxImportMB is used when "http/limits" has no maxImportMB.
+const defaultMaxImportMB = 64
+
+// bodyLimitOverhead covers multipart framing and JSON escaping on top of the
+// payload size an upload route allows.
+const bodyLimitOverhead = 1 << 20
+
+// requestBodyLimit caps mutating requests on the custom route groups at the
+// "http/limits" maxBodyMB setting. It shares PocketBase's body limit
+// middleware id, so it replaces the framework's 32 MB default and upload
+// routes replace it in turn by binding their own bodyLimit.
+func requestBodyLimit() *hook.Handler[*core.RequestEvent] {
+	return bodyLimit(func(app core.App) int64 {
+		cfg, _ := sysconfig.GetGroup(app, "http", "limits", nil)
+		return int64(sysconfig.Int(cfg, "maxBodyMB", defaultMaxBodyMB)) << 20
+	})
+}
+
+// importBodyLimit sizes the resource import route to the "http/limits"
+// maxImportMB setting, since sealed export documents outgrow maxBodyMB.
+func importBodyLimit(app core.App) int64 {
+	cfg, _ := sysconfig.GetGroup(app, "http", "limits", nil)
+	return int64(sysconfig.Int(cfg, "maxImportMB", defaultMaxImportMB))<<20 + bodyLimitOverhead
+}
+
+// bodyLimit returns middleware rejecting request bodies larger than limit(app)
+// bytes with 413. A Content-Length over the limit is refused up front; other
+// bodies are read through http.MaxBytesReader and the handler's error is
+// replaced with 413 once the limit is hit. A limit <= 0 disables the check.
+func bodyLimit(limit func(app core.App) int64) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id:       apis.DefaultBodyLimitMiddlewareId,
+		Priority: apis.DefaultBodyLimitMiddlewarePriority,
+		Func: func(e *core.RequestEvent) error {
+			switch e.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return e.Next()
+			}
+			maxBytes := limit(e.App)
+			if maxBytes <= 0 {
+				return e.Next()
+			}
+			if e.Request.ContentLength > maxBytes {
+				return bodyTooLargeError(maxBytes)
+			}
+
+			body := &limitedBody{}
+			// Limit below PocketBase's rereadable wrapper so rereads served
+			// from its buffer are not counted twice.
+			if rr, ok := e.Request.Body.(*router.RereadableReadCloser); ok {
+				body.ReadCloser = http.MaxBytesReader(e.Response, rr.ReadCloser, maxBytes)
+				rr.ReadCloser = body
+			} else {
+				body.ReadCloser = http.MaxBytesReader(e.Response, e.Request.Body, maxBytes)
+				e.Request.Body = body
+			}
+
+			err := e.Next()
+			if body.exceeded && err != nil && !e.Written() {
+				return bodyTooLargeError(maxBytes)
+			}
+			return err
+		},
+	}
+}
+
+func bodyTooLargeError(maxBytes int64) error {
+	return apis.NewApiError(http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("request body exceeds %d bytes limit", maxBytes), nil)
+}
+
+// limitedBody records whether the wrapped http.MaxBytesReader hit its limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+func (te *testEnv) doBodyLimited(t *testing.T, url string, body io.Reader, contentLength int64) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(requestBodyLimit())
+	echo := func(e *core.RequestEvent) error {
+		payload, err := readBody(e)
+		if err != nil {
+			return apis.NewBadRequestError("invalid JSON body", err)
+		}
+		return e.JSON(http.StatusOK, map[string]any{"size": len(bodyString(payload, "code"))})
+	}
+	g.POST("/scripts", echo)
+	g.POST("/upload", echo).Bind(bodyLimit(func(core.App) int64 { return 4 << 20 }))
+
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, url, body)
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = contentLength
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequestBodyLimitRejectsOversizedBodies(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	if err := sysconfig.SetGroup(te.app, "http", "limits", map[string]any{"maxBodyMB": 1}); err != nil {
+		t.Fatal(err)
+	}
+	large := []byte(`{"code":"` + strings.Repeat("x", 3<<19) + `"}`)
+
+	rec := te.doBodyLimited(t, "/api/ext/scripts", bytes.NewReader(large), int64(len(large)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for declared length, got %d", rec.Code)
+	}
+
+	// Without a Content-Length the limit is enforced while reading.
+	rec = te.doBodyLimited(t, "/api/ext/scripts", io.MultiReader(bytes.NewReader(large)), -1)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for streamed body, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	small := []byte(`{"code":"echo ok"}`)
+	rec = te.doBodyLimited(t, "/api/ext/scripts", bytes.NewReader(small), int64(len(small)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for small body, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRequestBodyLimitRouteOverride(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	if err := sysconfig.SetGroup(te.app, "http", "limits", map[string]any{"maxBodyMB": 1}); err != nil {
+		t.Fatal(err)
+	}
+	large := []byte(`{"code":"` + strings.Repeat("x", 3<<19) + `"}`)
+
+	rec := te.doBodyLimited(t, "/api/ext/upload", bytes.NewReader(large), int64(len(large)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected route override to accept body, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
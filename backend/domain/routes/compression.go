@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// defaultCompressionMinSize is used when "http/compression" has no
+// minSizeBytes. Smaller bodies are sent as-is since gzip overhead outweighs
+// the savings.
+const defaultCompressionMinSize = 1024
+
+// responseCompression returns middleware that gzips compressible responses
+// when the client sends Accept-Encoding: gzip. The decision is taken when the
+// handler first writes, so streams are never compressed: WebSocket upgrades,
+// Range requests, SSE, attachments and bodies that already carry a
+// Content-Encoding pass through untouched. Enabled and minSizeBytes are read
+// from the "http/compression" settings on every request.
+func responseCompression() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "responseCompression",
+		Func: func(e *core.RequestEvent) error {
+			if !acceptsGzip(e.Request) ||
+				e.Request.Header.Get("Upgrade") != "" ||
+				e.Request.Header.Get("Range") != "" ||
+				e.Request.Method == http.MethodHead {
+				return e.Next()
+			}
+			cfg, _ := sysconfig.GetGroup(e.App, "http", "compression", nil)
+			if !sysconfig.Bool(cfg, "enabled", true) {
+				return e.Next()
+			}
+
+			original := e.Response
+			w := &gzipResponseWriter{
+				ResponseWriter: original,
+				minSize:        max(sysconfig.Int(cfg, "minSizeBytes", defaultCompressionMinSize), 0),
+			}
+			e.Response = w
+			defer func() {
+				e.Response = original
+				w.finish()
+			}()
+			return e.Next()
+		},
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// compressibleResponse reports whether the response headers describe a body
+// worth compressing.
+func compressibleResponse(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if disposition, _, _ := mime.ParseMediaType(h.Get("Content-Disposition")); disposition == "attachment" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/yaml", "application/x-yaml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether
+// to compress it: the body must be compressible and reach minSize bytes.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	code    int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+	// Bodiless statuses carry nothing to compress.
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < http.StatusOK {
+		w.passthrough()
+	}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		h := w.Header()
+		if h.Get("Content-Type") == "" {
+			h.Set("Content-Type", http.DetectContentType(b))
+		}
+		if !compressibleResponse(h) {
+			w.passthrough()
+		} else {
+			n, _ := w.buf.Write(b)
+			if w.buf.Len() < w.minSize {
+				return n, nil
+			}
+			return n, w.compress()
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush commits to compression for a compressible body, since more data may
+// follow, and flushes through to the client.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if compressibleResponse(w.Header()) && w.buf.Len() > 0 {
+			_ = w.compress()
+		} else {
+			w.passthrough()
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController and the router reach the underlying
+// writer.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *gzipResponseWriter) writeStatus() {
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+}
+
+// passthrough sends the response uncompressed, releasing anything buffered.
+func (w *gzipResponseWriter) passthrough() {
+	w.decided = true
+	w.writeStatus()
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+func (w *gzipResponseWriter) compress() error {
+	w.decided = true
+	h := w.Header()
+	h.Set("Content-Encoding", "gzip")
+	h.Add("Vary", "Accept-Encoding")
+	h.Del("Content-Length")
+	w.writeStatus()
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish completes the response once the handler returns. A body that never
+// reached minSize is sent uncompressed; nothing is written for a handler that
+// wrote nothing so the router can still render its error response.
+func (w *gzipResponseWriter) finish() {
+	if w.gz != nil {
+		_ = w.gz.Close()
+		return
+	}
+	if !w.decided && (w.code != 0 || w.buf.Len() > 0) {
+		if w.buf.Len() > 0 {
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+		w.passthrough()
+	}
+}
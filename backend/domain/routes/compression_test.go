@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+func (te *testEnv) doCompressed(t *testing.T, url string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Bind(responseCompression())
+	large := strings.Repeat(`{"name":"container","state":"running"},`, 200)
+	r.GET("/large", func(e *core.RequestEvent) error {
+		return e.JSON(http.StatusOK, map[string]any{"items": large})
+	})
+	r.GET("/small", func(e *core.RequestEvent) error {
+		return e.JSON(http.StatusOK, map[string]any{"ok": true})
+	})
+	r.GET("/sse", func(e *core.RequestEvent) error {
+		e.Response.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(e.Response, "data: %s\n\n", large)
+		e.Response.(http.Flusher).Flush()
+		return nil
+	})
+	r.GET("/download", func(e *core.RequestEvent) error {
+		e.Response.Header().Set("Content-Disposition", `attachment; filename="app.log"`)
+		return e.String(http.StatusOK, large)
+	})
+	r.GET("/missing", func(e *core.RequestEvent) error {
+		return apis.NewNotFoundError("not here", nil)
+	})
+
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResponseCompressionGzipsLargeJSON(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.doCompressed(t, "/large", "Accept-Encoding", "gzip, deflate")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip response, got %d %v", rec.Code, rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil || !strings.HasPrefix(string(body), `{"items":`) {
+		t.Fatalf("unexpected decompressed body %.60q err=%v", body, err)
+	}
+
+	if rec := te.doCompressed(t, "/large"); rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("expected no compression without Accept-Encoding")
+	}
+	if rec := te.doCompressed(t, "/large", "Accept-Encoding", "gzip;q=0"); rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("expected no compression when gzip is refused")
+	}
+}
+
+func TestResponseCompressionSkipsStreamsAndSmallBodies(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	for _, url := range []string{"/small", "/sse", "/download"} {
+		rec := te.doCompressed(t, url, "Accept-Encoding", "gzip")
+		if rec.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s: expected uncompressed response", url)
+		}
+		if rec.Body.Len() == 0 {
+			t.Fatalf("%s: expected body to be written", url)
+		}
+	}
+
+	rec := te.doCompressed(t, "/missing", "Accept-Encoding", "gzip")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "Not here") {
+		t.Fatalf("expected router error response, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestResponseCompressionHonorsSettings(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	if err := sysconfig.SetGroup(te.app, "http", "compression", map[string]any{"enabled": false, "minSizeBytes": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := te.doCompressed(t, "/large", "Accept-Encoding", "gzip"); rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("expected compression to be disabled")
+	}
+
+	if err := sysconfig.SetGroup(te.app, "http", "compression", map[string]any{"enabled": true, "minSizeBytes": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := te.doCompressed(t, "/small", "Accept-Encoding", "gzip"); rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("expected small body to be compressed with minSizeBytes 0")
+	}
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// contentETag is a strong validator derived from the file content.
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// statETag is a weak validator derived from size and modification time, for
+// files that are too costly to hash before deciding whether to send them.
+func statETag(size int64, modified time.Time) string {
+	return fmt.Sprintf(`W/"%x-%x"`, modified.UnixNano(), size)
+}
+
+// writeNotModified sets the ETag and Last-Modified validators and, when the
+// request's If-None-Match or If-Modified-Since already matches them, answers
+// 304 Not Modified. It reports whether the response has been written.
+// If-None-Match takes precedence over If-Modified-Since (RFC 9110 §13.2.2).
+func writeNotModified(e *core.RequestEvent, etag string, modified time.Time) (bool, error) {
+	header := e.Response.Header()
+	header.Set("ETag", etag)
+	if !modified.IsZero() {
+		header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	header.Set("Cache-Control", "private, no-cache")
+
+	if inm := e.Request.Header.Get("If-None-Match"); inm != "" {
+		if etagListMatches(inm, etag) {
+			return true, e.NoContent(http.StatusNotModified)
+		}
+		return false, nil
+	}
+	if ims := e.Request.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err == nil && !modified.Truncate(time.Second).After(since) {
+			return true, e.NoContent(http.StatusNotModified)
+		}
+	}
+	return false, nil
+}
+
+// etagListMatches applies the weak comparison of If-None-Match.
+func etagListMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
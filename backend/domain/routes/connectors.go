@@ -8,6 +8,7 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/groups"
 	"github.com/websoft9/appos/backend/domain/resource/accounts"
 	"github.com/websoft9/appos/backend/domain/resource/connectors"
 	"github.com/websoft9/appos/backend/domain/secrets"
@@ -62,6 +63,7 @@ func registerConnectorRoutes(se *core.ServeEvent) {
 	mutations.POST("", handleConnectorCreate)
 	mutations.PUT("/{id}", handleConnectorUpdate)
 	mutations.DELETE("/{id}", handleConnectorDelete)
+	mutations.POST("/{id}/test", handleConnectorTest)
 }
 
 // handleConnectorTemplateList lists built-in connector templates.
@@ -145,7 +147,7 @@ func handleConnectorGet(e *core.RequestEvent) error {
 // handleConnectorCreate creates a connector.
 //
 // @Summary Create connector
-// @Description Creates a connector. Superuser only.
+// @Description Creates a connector. An optional groups array of group IDs assigns the new connector to those groups in the same step; unknown groups are rejected with a field error. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param body body connectorUpsertRequest true "connector payload"
@@ -160,11 +162,23 @@ func handleConnectorCreate(e *core.RequestEvent) error {
 	if err != nil {
 		return err
 	}
+	groupIDs, err := bindCreateGroups(e)
+	if err != nil {
+		return err
+	}
 	userID, _ := authInfo(e)
-	item, saveErr := connectors.CreateWithDeps(persistence.NewConnectorRepository(e.App), input, connectors.SaveDeps{
-		ActorID:                     userID,
-		CredentialRefValidator:      connectorCredentialValidator{app: e.App},
-		ProviderAccountRefValidator: connectorAccountValidator{app: e.App},
+	var item *connectors.Connector
+	saveErr := createInGroups(e.App, groups.ObjectTypeConnector, groupIDs, func(app core.App) (string, error) {
+		created, err := connectors.CreateWithDeps(persistence.NewConnectorRepository(app), input, connectors.SaveDeps{
+			ActorID:                     userID,
+			CredentialRefValidator:      connectorCredentialValidator{app: e.App},
+			ProviderAccountRefValidator: connectorAccountValidator{app: e.App},
+		})
+		if err != nil {
+			return "", err
+		}
+		item = created
+		return created.ID(), nil
 	})
 	if saveErr != nil {
 		writeConnectorAudit(e, "connector.create", nil, input, nil, saveErr)
@@ -249,6 +263,55 @@ func handleConnectorDelete(e *core.RequestEvent) error {
 	return e.NoContent(http.StatusNoContent)
 }
 
+// handleConnectorTest runs a connection test against a connector endpoint.
+//
+// @Summary Test connector
+// @Description Performs a minimal reachability/auth check based on the connector kind, using the decrypted credential. Returns the outcome and latency; the credential is never echoed. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param id path string true "connector id"
+// @Success 200 {object} connectors.ProbeResult
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/connectors/{id}/test [post]
+func handleConnectorTest(e *core.RequestEvent) error {
+	item, err := connectors.Get(persistence.NewConnectorRepository(e.App), e.Request.PathValue("id"))
+	if err != nil {
+		if isConnectorNotFound(err) {
+			return e.NotFoundError("connector not found", err)
+		}
+		return e.InternalServerError("failed to load connector", err)
+	}
+
+	result := connectors.Probe(e.Request.Context(), connectors.NewSecretResolver(e.App), item)
+
+	userID, userEmail, ip, userAgent := clientInfo(e)
+	status := audit.StatusSuccess
+	if result.Status != connectors.ProbeStatusOK {
+		status = audit.StatusFailed
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Action:       "connector.test",
+		ResourceType: "connector",
+		ResourceID:   item.ID(),
+		ResourceName: item.Name(),
+		Status:       status,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Detail: map[string]any{
+			"probe_status": result.Status,
+			"http_status":  result.HTTPStatus,
+			"latency_ms":   result.LatencyMS,
+			"message":      result.Message,
+		},
+	})
+	return e.JSON(http.StatusOK, result)
+}
+
 func bindConnectorUpsertRequest(e *core.RequestEvent) (connectors.SaveInput, error) {
 	var body connectorUpsertRequest
 	if err := e.BindBody(&body); err != nil {
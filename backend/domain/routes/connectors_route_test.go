@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/pocketbase/pocketbase/core"
@@ -333,3 +335,100 @@ func TestConnectorRejectsUnsupportedKind(t *testing.T) {
 		t.Fatalf("expected 400 for unsupported kind, got %d: %s", rec.Code, rec.Body.String())
 	}
 }
+
+func createRouteConnector(t *testing.T, te *testEnv, name, kind, templateID, endpoint, authScheme, credentialID string) *core.Record {
+	t.Helper()
+	col, err := te.app.FindCollectionByNameOrId("connectors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := core.NewRecord(col)
+	rec.Set("name", name)
+	rec.Set("kind", kind)
+	rec.Set("template_id", templateID)
+	rec.Set("endpoint", endpoint)
+	rec.Set("auth_scheme", authScheme)
+	rec.Set("credential", credentialID)
+	if err := te.app.Save(rec); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestConnectorTestAppliesCredentialWithoutEchoingIt(t *testing.T) {
+	ensureConnectorSecretRuntime(t)
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	var gotAuth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		if gotAuth != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+
+	secret := createRouteSecret(t, te, "global", "someone")
+	connector := createRouteConnector(t, te, "api", "rest_api", "generic-rest", upstream.URL, "bearer", secret.Id)
+
+	rec := te.do(t, http.MethodPost, "/api/connectors/"+connector.Id+"/test", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if gotAuth != "Bearer secret" {
+		t.Fatalf("expected credential to be sent upstream, got %q", gotAuth)
+	}
+	if strings.Contains(rec.Body.String(), "secret") {
+		t.Fatalf("response must not echo the credential: %s", rec.Body.String())
+	}
+	var result map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result["status"] != "ok" || result["http_status"] != float64(http.StatusNoContent) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if _, ok := result["latency_ms"]; !ok {
+		t.Fatalf("expected latency_ms in result: %v", result)
+	}
+	if entries := auditEntriesByAction(t, te, "connector.test"); len(entries) != 1 {
+		t.Fatalf("expected 1 test audit entry, got %d", len(entries))
+	}
+}
+
+func TestConnectorTestReportsRejectedAuth(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer upstream.Close()
+
+	connector := createRouteConnector(t, te, "hook", "webhook", "generic-webhook", upstream.URL, "none", "")
+
+	rec := te.do(t, http.MethodPost, "/api/connectors/"+connector.Id+"/test", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result["status"] != "failed" || !strings.Contains(result["message"].(string), "authentication rejected") {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestConnectorTestMissingReturnsNotFound(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.do(t, http.MethodPost, "/api/connectors/missing-id/test", "", true)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -1,23 +1,46 @@
 package routes
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 
+	"github.com/gorilla/websocket"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
 	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sharedenv"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+	"github.com/websoft9/appos/backend/domain/deploy"
+	lifecycleruntime "github.com/websoft9/appos/backend/domain/lifecycle/runtime"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/domain/stepup"
 	"github.com/websoft9/appos/backend/infra/docker"
 )
 
 // localDockerClient is the Docker client for the local host, shared across all local requests.
 var localDockerClient *docker.Client
 
+// dockerDaemonChecker caches `docker version` reachability per server ID so a
+// missing or stopped daemon surfaces as DOCKER_DAEMON_UNREACHABLE instead of
+// a raw CLI error. Failures are re-checked sooner than successes.
+var dockerDaemonChecker = docker.NewDaemonChecker(5*time.Minute, 15*time.Second)
+
 func init() {
 	exec := docker.NewLocalExecutor("")
 	if os.Getuid() != 0 {
@@ -55,6 +78,7 @@ func registerDockerRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	compose.GET("/logs", handleComposeLogs)
 	compose.GET("/config", handleComposeConfigGet)
 	compose.PUT("/config", handleComposeConfigWrite)
+	compose.POST("/apply-env", handleComposeApplyEnv)
 
 	// ─── Images ──────────────────────────────────────────
 	images := d.Group("/images")
@@ -62,7 +86,9 @@ func registerDockerRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	images.GET("/registry/status", handleImageRegistryStatus)
 	images.GET("/registry/search", handleImageRegistrySearch)
 	images.GET("/{id}/inspect", handleImageInspect)
-	images.POST("/pull", handleImagePull)
+	images.POST("/{id}/scan", handleImageScan)
+	images.POST("/pull", handleImagePull).Bind(routeRateLimit(rateLimitDockerPull))
+	images.GET("/pull-stream", handleImagePullStream).Bind(routeRateLimit(rateLimitDockerPull))
 	images.DELETE("/{id...}", handleImageRemove)
 	images.POST("/prune", handleImagePrune)
 
@@ -70,8 +96,13 @@ func registerDockerRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	containers := d.Group("/containers")
 	containers.GET("/stats", handleContainerStats)
 	containers.GET("/{id}/logs", handleContainerLogs)
+	// Browsers cannot set headers on WebSocket requests; accept ?token=.
+	containers.GET("/{id}/logs/stream", handleContainerLogStream).Bind(wsTokenAuth())
 	containers.GET("", handleContainerList)
+	containers.POST("", handleContainerCreate)
 	containers.GET("/{id}", handleContainerInspect)
+	containers.GET("/{id}/env", handleContainerEnv)
+	containers.POST("/{id}/env/export", handleContainerEnvExport)
 	containers.POST("/{id}/start", handleContainerStart)
 	containers.POST("/{id}/stop", handleContainerStop)
 	containers.POST("/{id}/restart", handleContainerRestart)
@@ -90,17 +121,48 @@ func registerDockerRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	volumes.DELETE("/{id}", handleVolumeRemove)
 	volumes.POST("/prune", handleVolumePrune)
 
+	// ─── Housekeeping ────────────────────────────────────
+	d.POST("/prune", handleDockerPrune)
+
 	// ─── Exec (arbitrary docker command) ─────────────────
-	d.POST("/exec", handleDockerExec)
+	d.POST("/exec", handleDockerExec).Bind(routeRateLimit(rateLimitServerOps), requireStepUp(stepup.ActionDockerExec))
 }
 
 // ─── Server-aware executor helper ────────────────────────────────
 
 // getDockerClient returns a Docker client for the server_id in the request query.
 // Falls back to localDockerClient when server_id is absent or "local".
+// Returns a *docker.DaemonUnreachableError when the (cached) daemon check
+// fails, or a *dockerTransportError when it could not reach the server.
 func getDockerClient(e *core.RequestEvent) (*docker.Client, error) {
 	serverID := e.Request.URL.Query().Get("server_id")
-	return servers.NewDockerClient(e.App, serverID, localDockerClient)
+	client, err := servers.NewDockerClient(e.App, serverID, localDockerClient)
+	if err != nil {
+		return nil, err
+	}
+	if err := dockerDaemonChecker.Check(e.Request.Context(), dockerServerKey(serverID), client); err != nil {
+		var unreachable *docker.DaemonUnreachableError
+		if !errors.As(err, &unreachable) {
+			return nil, &dockerTransportError{err: err}
+		}
+		return nil, err
+	}
+	return client, nil
+}
+
+// dockerTransportError is a daemon check that failed before docker ran, e.g.
+// an SSH dial or authentication failure.
+type dockerTransportError struct{ err error }
+
+func (e *dockerTransportError) Error() string { return "server unreachable: " + e.err.Error() }
+
+func (e *dockerTransportError) Unwrap() error { return e.err }
+
+func dockerServerKey(serverID string) string {
+	if serverID == "" {
+		return "local"
+	}
+	return serverID
 }
 
 // handleDockerServers returns all available servers (local + resource store servers)
@@ -152,7 +214,7 @@ func handleDockerServers(e *core.RequestEvent) error {
 					status = "online"
 					reason = ""
 				} else {
-					reason = pingErr.Error()
+					reason = pingOfflineReason(execSSH, pingErr)
 				}
 			} else {
 				reason = resolveErr.Error()
@@ -174,8 +236,46 @@ func handleDockerServers(e *core.RequestEvent) error {
 
 // ─── Helper ──────────────────────────────────────────────
 
-// dockerError returns a PocketBase-style error response.
+// pingOfflineReason appends the timeouts in effect to a failed ping, so a
+// slow-but-reachable server can be told apart from a dead one.
+func pingOfflineReason(execSSH *docker.SSHExecutor, pingErr error) string {
+	reason := fmt.Sprintf("%s (dial timeout %s", pingErr, execSSH.DialTimeout())
+	if timeout := execSSH.CommandTimeout(); timeout > 0 {
+		reason += fmt.Sprintf(", command timeout %s", timeout)
+	}
+	return reason + ")"
+}
+
+// dockerError returns a PocketBase-style error response. Errors showing the
+// docker daemon is missing or down become a 503 with reason_code
+// DOCKER_DAEMON_UNREACHABLE regardless of status and msg.
 func dockerError(e *core.RequestEvent, status int, msg string, err error) error {
+	serverKey := dockerServerKey(e.Request.URL.Query().Get("server_id"))
+	var unreachable *docker.DaemonUnreachableError
+	if !errors.As(err, &unreachable) && errors.As(docker.ClassifyDaemonError(serverKey, err), &unreachable) {
+		// The daemon went away after the cached check passed; re-check next time.
+		dockerDaemonChecker.Invalidate(serverKey)
+	}
+	if unreachable != nil {
+		message := "Docker is not running on this server"
+		if unreachable.Reason == docker.DaemonNotInstalled {
+			message = "Docker is not installed on this server"
+		}
+		return e.JSON(http.StatusServiceUnavailable, map[string]any{
+			"code":    http.StatusServiceUnavailable,
+			"message": message,
+			"data": map[string]any{
+				"reason_code": docker.ReasonDaemonUnreachable,
+				"reason":      unreachable.Reason,
+				"host":        unreachable.Host,
+				"error":       err.Error(),
+			},
+		})
+	}
+	var transport *dockerTransportError
+	if errors.As(err, &transport) {
+		status, msg = http.StatusBadGateway, "server unreachable"
+	}
 	return e.JSON(status, map[string]any{
 		"code":    status,
 		"message": msg,
@@ -239,25 +339,50 @@ func bodyMap(body map[string]any, key string) map[string]any {
 	return nil
 }
 
+// bodyStrings extracts a string array field from body, dropping blank and
+// non-string entries.
+func bodyStrings(body map[string]any, key string) []string {
+	raw, _ := body[key].([]any)
+	values := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if v, ok := item.(string); ok && strings.TrimSpace(v) != "" {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
+
 // ─── Compose Handlers ────────────────────────────────────
 
 // handleComposeLs lists all Docker Compose projects on the target server.
 //
 // @Summary List Compose projects
-// @Description Returns all docker compose projects on the specified server. Superuser only.
+// @Description Returns all docker compose projects on the specified server as raw CLI output. With format=json the response is items, one typed object per project (Name, Status, ConfigFiles), instead. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
+// @Param format query string false "json for typed items instead of raw output"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/compose/ls [get]
 func handleComposeLs(e *core.RequestEvent) error {
+	typed, err := dockerListFormat(e)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
 	client, err := getDockerClient(e)
 	if err != nil {
 		return dockerError(e, http.StatusBadRequest, "server not found", err)
 	}
+	if typed {
+		projects, err := client.ComposeProjects(e.Request.Context())
+		if err != nil {
+			return dockerError(e, http.StatusInternalServerError, "list compose projects failed", err)
+		}
+		return e.JSON(http.StatusOK, map[string]any{"items": projects, "host": client.Host()})
+	}
 	output, err := client.ComposeLs(e.Request.Context())
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "list compose projects failed", err)
@@ -268,12 +393,12 @@ func handleComposeLs(e *core.RequestEvent) error {
 // handleComposeUp deploys a Docker Compose project (docker compose up -d).
 //
 // @Summary Deploy Compose project
-// @Description Runs `docker compose up -d` in the given project directory. Writes audit entry. Superuser only.
+// @Description Runs `docker compose up -d` in the given project directory, then waits (up to the deploy/preflight healthTimeoutSeconds setting) for every service with a healthcheck to report healthy. The result is `deployed_healthy` or `deployed_unhealthy`; an unhealthy deploy is audited as failed. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
 // @Param body body object true "projectDir: absolute path to the compose project"
-// @Success 200 {object} map[string]any
+// @Success 200 {object} map[string]any "output, result, health"
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
@@ -304,24 +429,40 @@ func handleComposeUp(e *core.RequestEvent) error {
 		})
 		return dockerError(e, http.StatusInternalServerError, "compose up failed", err)
 	}
+
+	healthCtx, cancel := context.WithTimeout(e.Request.Context(), lifecycleruntime.DeployHealthTimeout(e.App))
+	defer cancel()
+	health, healthErr := docker.WaitComposeHealthy(healthCtx, client, projectDir, 3*time.Second)
+	result, status := deploy.ResultHealthy, audit.StatusSuccess
+	detail := map[string]any{"result": result, "health": health}
+	if healthErr != nil {
+		result, status = deploy.ResultUnhealthy, audit.StatusFailed
+		detail["result"] = result
+		detail["errorMessage"] = healthErr.Error()
+	}
 	audit.Write(e.App, audit.Entry{
 		UserID: userID, UserEmail: userEmail,
 		Action: "app.deploy", ResourceType: "app",
 		ResourceID: projectDir, ResourceName: projectDir,
 		IP: ip, UserAgent: ua,
-		Status: audit.StatusSuccess,
+		Status: status,
+		Detail: detail,
 	})
-	return e.JSON(http.StatusOK, map[string]any{"output": output})
+	resp := map[string]any{"output": output, "result": result, "health": health}
+	if healthErr != nil {
+		resp["message"] = healthErr.Error()
+	}
+	return e.JSON(http.StatusOK, resp)
 }
 
 // handleComposeDown tears down a Docker Compose project (docker compose down).
 //
 // @Summary Tear down Compose project
-// @Description Runs `docker compose down` in the given project directory. Writes audit entry. Superuser only.
+// @Description Runs `docker compose down` in the given project directory. removeOrphans adds --remove-orphans; removeImages adds --rmi ("all" or "local", true means "all"). The audit entry records which removals were requested. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
-// @Param body body object true "projectDir, removeVolumes (optional bool)"
+// @Param body body object true "projectDir, removeVolumes, removeOrphans (optional bool), removeImages (optional bool, \"all\" or \"local\")"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
@@ -340,17 +481,26 @@ func handleComposeDown(e *core.RequestEvent) error {
 	if projectDir == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "projectDir is required"})
 	}
+	opts, err := composeDownOptions(body)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	detail := map[string]any{
+		"removeVolumes": opts.RemoveVolumes,
+		"removeOrphans": opts.RemoveOrphans,
+		"removeImages":  opts.RemoveImages,
+	}
 	userID, userEmail, ip, ua := clientInfo(e)
-	removeVolumes := bodyBool(body, "removeVolumes")
-	output, err := client.ComposeDown(e.Request.Context(), projectDir, removeVolumes)
+	output, err := client.ComposeDown(e.Request.Context(), projectDir, opts)
 	if err != nil {
+		detail["errorMessage"] = err.Error()
 		audit.Write(e.App, audit.Entry{
 			UserID: userID, UserEmail: userEmail,
 			Action: "app.delete", ResourceType: "app",
 			ResourceID: projectDir, ResourceName: projectDir,
 			IP: ip, UserAgent: ua,
 			Status: audit.StatusFailed,
-			Detail: map[string]any{"errorMessage": err.Error()},
+			Detail: detail,
 		})
 		return dockerError(e, http.StatusInternalServerError, "compose down failed", err)
 	}
@@ -360,10 +510,38 @@ func handleComposeDown(e *core.RequestEvent) error {
 		ResourceID: projectDir, ResourceName: projectDir,
 		IP: ip, UserAgent: ua,
 		Status: audit.StatusSuccess,
+		Detail: detail,
 	})
 	return e.JSON(http.StatusOK, map[string]any{"output": output})
 }
 
+// composeDownOptions reads the optional removal flags of a compose down
+// request. removeImages accepts a bool (true selects "all") or an explicit
+// --rmi scope.
+func composeDownOptions(body map[string]any) (docker.ComposeDownOptions, error) {
+	opts := docker.ComposeDownOptions{
+		RemoveVolumes: bodyBool(body, "removeVolumes"),
+		RemoveOrphans: bodyBool(body, "removeOrphans"),
+	}
+	switch v := body["removeImages"].(type) {
+	case nil:
+	case bool:
+		if v {
+			opts.RemoveImages = docker.ComposeRemoveImagesAll
+		}
+	case string:
+		switch v {
+		case "", docker.ComposeRemoveImagesAll, docker.ComposeRemoveImagesLocal:
+			opts.RemoveImages = v
+		default:
+			return opts, fmt.Errorf("removeImages must be %q or %q", docker.ComposeRemoveImagesAll, docker.ComposeRemoveImagesLocal)
+		}
+	default:
+		return opts, errors.New("removeImages must be a bool or string")
+	}
+	return opts, nil
+}
+
 // handleComposeStart starts a stopped Docker Compose project.
 //
 // @Summary Start Compose project
@@ -615,30 +793,149 @@ func handleComposeConfigWrite(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, map[string]any{"message": "saved"})
 }
 
+// handleComposeApplyEnv writes the merged env of the given env sets into a
+// project's .env file and brings the project up with it (local only).
+//
+// @Summary Apply env sets to a Compose project
+// @Description Merges the env sets in env_set_ids in order (later set wins, secret variables resolved), sets the result on top of the project's existing .env and runs docker compose up -d, which recreates only the services whose configuration changed. Variables the .env already holds that no env set defines are kept; keys listed in remove_keys are deleted. The response and audit entry list the added, changed and removed keys, never values. The .env file is left untouched when nothing changed. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param body body object true "projectDir, env_set_ids, remove_keys (optional)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/compose/apply-env [post]
+func handleComposeApplyEnv(e *core.RequestEvent) error {
+	body, err := readBody(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "invalid request body", err)
+	}
+	projectDir := bodyString(body, "projectDir")
+	setIDs := bodyStrings(body, "env_set_ids")
+	if projectDir == "" || len(setIDs) == 0 {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "projectDir and env_set_ids are required"})
+	}
+	userID, userEmail, ip, ua := clientInfo(e)
+	values, err := resolveEnvSetValues(e.App, setIDs, userID)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	current, err := localDockerClient.ComposeEnvRead(projectDir)
+	if err != nil {
+		return dockerError(e, http.StatusInternalServerError, "read env failed", err)
+	}
+	currentVars := docker.ParseDotEnv(current)
+	next := docker.MergeEnv(currentVars, values, bodyStrings(body, "remove_keys"))
+	diff := docker.DiffEnv(currentVars, next)
+	detail := map[string]any{"env_set_ids": setIDs, "added": diff.Added, "changed": diff.Changed, "removed": diff.Removed}
+	fail := func(msg string, err error) error {
+		detail["errorMessage"] = err.Error()
+		audit.Write(e.App, audit.Entry{
+			UserID: userID, UserEmail: userEmail,
+			Action: "app.env_update", ResourceType: "app",
+			ResourceID: projectDir, ResourceName: projectDir,
+			IP: ip, UserAgent: ua,
+			Status: audit.StatusFailed,
+			Detail: detail,
+		})
+		return dockerError(e, http.StatusInternalServerError, msg, err)
+	}
+	if !diff.Empty() {
+		if err := localDockerClient.ComposeEnvWrite(projectDir, docker.RenderDotEnv(next)); err != nil {
+			return fail("write env failed", err)
+		}
+	}
+	output, err := localDockerClient.ComposeUp(e.Request.Context(), projectDir)
+	if err != nil {
+		return fail("compose up failed", err)
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: "app.env_update", ResourceType: "app",
+		ResourceID: projectDir, ResourceName: projectDir,
+		IP: ip, UserAgent: ua,
+		Status: audit.StatusSuccess,
+		Detail: detail,
+	})
+	return e.JSON(http.StatusOK, map[string]any{
+		"output":  output,
+		"added":   diff.Added,
+		"changed": diff.Changed,
+		"removed": diff.Removed,
+	})
+}
+
+// resolveEnvSetValues merges the vars of setIDs with later sets winning and
+// resolves secret vars to their plaintext value.
+func resolveEnvSetValues(app core.App, setIDs []string, userID string) (map[string]string, error) {
+	vars, err := sharedenv.LoadSetVars(app, setIDs)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(vars))
+	for _, item := range sharedenv.MergeVars(vars) {
+		if !item.Var.IsSecret {
+			values[item.Var.Key] = item.Var.Value
+			continue
+		}
+		if item.Var.SecretID == "" {
+			return nil, fmt.Errorf("secret variable %s in env set %q has no secret reference", item.Var.Key, item.SetName)
+		}
+		resolved, err := secrets.Resolve(app, item.Var.SecretID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve secret variable %s: %w", item.Var.Key, err)
+		}
+		value, ok := resolved.Payload["value"].(string)
+		if !ok {
+			return nil, fmt.Errorf("secret variable %s: secret has no string value", item.Var.Key)
+		}
+		values[item.Var.Key] = value
+	}
+	return values, nil
+}
+
 // ─── Image Handlers ──────────────────────────────────────
 
 // handleImageList returns all Docker images on the target server.
 //
 // @Summary List Docker images
-// @Description Returns all local images on the specified server. Superuser only.
+// @Description Returns all local images on the specified server, optionally narrowed by repeatable filter parameters passed to docker image ls --filter (label=key, label=key=value, reference=pattern or dangling=true|false). items holds one object per image with Labels parsed into a map. With format=json only typed items (ID, Repository, Tag, Digest, CreatedAt, CreatedSince, Size, Containers, Labels) are returned, without the raw output. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
+// @Param filter query []string false "docker filter, e.g. label=com.example.team=ops" collectionFormat(multi)
+// @Param format query string false "json for typed items only"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/images [get]
 func handleImageList(e *core.RequestEvent) error {
+	filters, err := dockerListFilters(e.Request.URL.Query()["filter"], imageListFilters)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	typed, err := dockerListFormat(e)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
 	client, err := getDockerClient(e)
 	if err != nil {
 		return dockerError(e, http.StatusBadRequest, "server not found", err)
 	}
-	output, err := client.ImageList(e.Request.Context())
+	if typed {
+		images, err := client.Images(e.Request.Context(), filters...)
+		if err != nil {
+			return dockerError(e, http.StatusInternalServerError, "list images failed", err)
+		}
+		return e.JSON(http.StatusOK, map[string]any{"items": images, "host": client.Host()})
+	}
+	output, err := client.ImageList(e.Request.Context(), filters...)
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "list images failed", err)
 	}
-	return e.JSON(http.StatusOK, map[string]any{"output": output, "host": client.Host()})
+	return e.JSON(http.StatusOK, map[string]any{"output": output, "items": dockerListItems(output), "host": client.Host()})
 }
 
 // handleImageRegistryStatus checks whether Docker Hub is reachable from the target server.
@@ -739,17 +1036,159 @@ func handleImageInspect(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, map[string]any{"output": output})
 }
 
+const (
+	dockerScannerTrivy    = "trivy"
+	dockerScannerEndpoint = "endpoint"
+
+	// dockerScanMaxReportBytes caps the report read from an integration
+	// endpoint; trivy reports for large images run to a few MB.
+	dockerScanMaxReportBytes = 32 << 20
+)
+
+// handleImageScan scans an image for known vulnerabilities with the scanner
+// configured in the docker/scan settings and returns severity counts plus the
+// most severe findings.
+//
+// @Summary Scan Docker image for vulnerabilities
+// @Description Runs the configured vulnerability scanner against the image: trivy on the target server, or the integration endpoint, which must return a trivy JSON report. Returns critical/high/medium/low/unknown counts and the top findings. Scanning is disabled until enabled in settings (docker-scan). An id that is not a plain image ID or reference is rejected with 400. Scans are audited. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param id path string true "image ID or reference"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/images/{id}/scan [post]
+func handleImageScan(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	if id == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "id is required"})
+	}
+	if err := docker.ValidateImageRef(id); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	cfg, _ := sysconfig.GetGroup(e.App, "docker", "scan", nil)
+	if enabled, _ := cfg["enabled"].(bool); !enabled {
+		return e.JSON(http.StatusConflict, map[string]any{"code": 409, "message": "image scanning is disabled"})
+	}
+	scanner, _ := cfg["scanner"].(string)
+	if scanner == "" {
+		scanner = dockerScannerTrivy
+	}
+	serverID := e.Request.URL.Query().Get("server_id")
+
+	ctx, cancel := context.WithTimeout(e.Request.Context(), time.Duration(sysconfig.Int(cfg, "timeoutSeconds", 300))*time.Second)
+	defer cancel()
+
+	var raw []byte
+	var scanErr error
+	switch scanner {
+	case dockerScannerTrivy:
+		client, err := getDockerClient(e)
+		if err != nil {
+			return dockerError(e, http.StatusBadRequest, "server not found", err)
+		}
+		output, err := client.ImageScan(ctx, id)
+		raw, scanErr = []byte(output), err
+	case dockerScannerEndpoint:
+		endpoint, _ := cfg["endpoint"].(string)
+		token, _ := cfg["endpointToken"].(string)
+		raw, scanErr = scanImageViaEndpoint(ctx, endpoint, token, id, serverID)
+	default:
+		return e.JSON(http.StatusConflict, map[string]any{"code": 409, "message": "unknown scanner " + strconv.Quote(scanner)})
+	}
+
+	var summary docker.ScanSummary
+	if scanErr == nil {
+		var report docker.ScanReport
+		report, scanErr = docker.ParseScanReport(raw)
+		summary = report.Summarize(sysconfig.Int(cfg, "topLimit", 10))
+		if summary.Image == "" {
+			summary.Image = id
+		}
+	}
+
+	userID, userEmail, ip, ua := clientInfo(e)
+	detail := map[string]any{"scanner": scanner, "server_id": dockerServerKey(serverID)}
+	if scanErr != nil {
+		detail["errorMessage"] = scanErr.Error()
+		audit.Write(e.App, audit.Entry{
+			UserID: userID, UserEmail: userEmail,
+			Action: "docker.image.scan", ResourceType: "docker_image",
+			ResourceID: id, ResourceName: id,
+			IP: ip, UserAgent: ua,
+			Status: audit.StatusFailed,
+			Detail: detail,
+		})
+		return dockerError(e, http.StatusInternalServerError, "scan image failed", scanErr)
+	}
+	detail["total"] = summary.Total
+	detail["critical"] = summary.Critical
+	detail["high"] = summary.High
+	detail["medium"] = summary.Medium
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: "docker.image.scan", ResourceType: "docker_image",
+		ResourceID: id, ResourceName: summary.Image,
+		IP: ip, UserAgent: ua,
+		Status: audit.StatusSuccess,
+		Detail: detail,
+	})
+	return e.JSON(http.StatusOK, map[string]any{"scanner": scanner, "summary": summary})
+}
+
+// scanImageViaEndpoint posts the image reference to the integration endpoint
+// and returns its trivy JSON report.
+func scanImageViaEndpoint(ctx context.Context, endpoint, token, image, serverID string) ([]byte, error) {
+	if endpoint == "" {
+		return nil, errors.New("scan endpoint is not configured")
+	}
+	payload, err := json.Marshal(map[string]string{"image": image, "server_id": serverID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scan endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dockerScanMaxReportBytes))
+	if err != nil {
+		return nil, fmt.Errorf("scan endpoint: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		return nil, fmt.Errorf("scan endpoint returned %s: %s", resp.Status, msg)
+	}
+	return body, nil
+}
+
 // handleImagePull pulls a Docker image from the registry.
 //
 // @Summary Pull Docker image
-// @Description Pulls the specified image from the registry. Superuser only.
+// @Description Pulls the specified image from the registry. credential names a registry_auth secret: docker logs into the secret's registry before the pull and out again after it, with the password passed on stdin so it never appears in a command line. The image must be hosted on that registry. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
-// @Param body body object true "name: image name/tag"
+// @Param body body object true "name: image name/tag; credential: optional registry_auth secret ID"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/images/pull [post]
 func handleImagePull(e *core.RequestEvent) error {
@@ -765,13 +1204,126 @@ func handleImagePull(e *core.RequestEvent) error {
 	if name == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "name is required"})
 	}
-	output, err := client.ImagePull(e.Request.Context(), name)
+	credentialID := strings.TrimSpace(bodyString(body, "credential"))
+	if credentialID == "" {
+		output, err := client.ImagePull(e.Request.Context(), name)
+		if err != nil {
+			return dockerError(e, http.StatusInternalServerError, "pull image failed", err)
+		}
+		return e.JSON(http.StatusOK, map[string]any{"output": output})
+	}
+
+	auth, err := registryAuthFromSecret(e, credentialID)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	if err := auth.ValidateFor(name); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	output, err := client.ImagePullWithAuth(e.Request.Context(), name, auth)
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "pull image failed", err)
 	}
 	return e.JSON(http.StatusOK, map[string]any{"output": output})
 }
 
+// registryAuthTemplateID is the secret template holding a registry login.
+const registryAuthTemplateID = "registry_auth"
+
+// registryAuthFromSecret decrypts a registry_auth secret on behalf of the
+// requesting user.
+func registryAuthFromSecret(e *core.RequestEvent, secretID string) (docker.RegistryAuth, error) {
+	userID, _, _, _ := clientInfo(e)
+	resolved, err := secrets.Resolve(e.App, secretID, userID)
+	if err != nil {
+		return docker.RegistryAuth{}, fmt.Errorf("credential: %w", err)
+	}
+	if resolved.TemplateID != registryAuthTemplateID {
+		return docker.RegistryAuth{}, fmt.Errorf("credential must be a %s secret", registryAuthTemplateID)
+	}
+	return docker.RegistryAuth{
+		Registry: strings.TrimSpace(secrets.FirstStringFromPayload(resolved.Payload, "registry")),
+		Username: secrets.FirstStringFromPayload(resolved.Payload, "username"),
+		Password: secrets.FirstStringFromPayload(resolved.Payload, "password"),
+	}, nil
+}
+
+// handleImagePullStream pulls a Docker image and streams its progress as SSE.
+//
+// @Summary Pull Docker image with progress
+// @Description Pulls the specified image and streams Server-Sent Events while it runs: start, then progress events carrying the updated layer (id, status, current and total bytes, percent) and the overall percent, then a final done event with the digest and docker's status line, or an error event. Works for local and SSH servers. Disconnecting cancels the pull. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param name query string true "image name/tag"
+// @Success 200 {string} string "SSE stream (text/event-stream)"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/images/pull-stream [get]
+func handleImagePullStream(e *core.RequestEvent) error {
+	name := strings.TrimSpace(e.Request.URL.Query().Get("name"))
+	if name == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "name is required"})
+	}
+	client, err := getDockerClient(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "server not found", err)
+	}
+	flusher, ok := e.Response.(http.Flusher)
+	if !ok {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"code": 500, "message": "streaming unsupported"})
+	}
+
+	// The request context ends when the client disconnects, which stops
+	// the pull through the stream.
+	ctx := e.Request.Context()
+	stream, err := client.ImagePullStream(ctx, name)
+	if err != nil {
+		return dockerError(e, http.StatusInternalServerError, "pull image failed", err)
+	}
+	defer stream.Close()
+	go func() {
+		<-ctx.Done()
+		_ = stream.Close()
+	}()
+
+	e.Response.Header().Set("Content-Type", "text/event-stream")
+	e.Response.Header().Set("Cache-Control", "no-cache")
+	e.Response.Header().Set("Connection", "keep-alive")
+	push := func(event string, payload map[string]any) {
+		b, _ := json.Marshal(payload)
+		_, _ = fmt.Fprintf(e.Response, "event: %s\ndata: %s\n\n", event, string(b))
+		flusher.Flush()
+	}
+
+	push("start", map[string]any{"name": name})
+	progress := docker.NewPullProgress()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		if layer := progress.Feed(scanner.Text()); layer != nil {
+			push("progress", map[string]any{"layer": layer, "percent": progress.Percent()})
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	switch {
+	case progress.Complete():
+		push("done", map[string]any{"name": name, "digest": progress.Digest, "status": progress.Status})
+	case progress.Error != "":
+		push("error", map[string]any{"message": progress.Error})
+	case scanner.Err() != nil:
+		push("error", map[string]any{"message": scanner.Err().Error()})
+	default:
+		push("error", map[string]any{"message": "docker pull ended without completing"})
+	}
+	return nil
+}
+
 // handleImageRemove removes a Docker image by ID or name.
 //
 // @Summary Remove Docker image
@@ -830,38 +1382,168 @@ func handleImagePrune(e *core.RequestEvent) error {
 // handleContainerList returns all Docker containers on the target server.
 //
 // @Summary List containers
-// @Description Returns all containers (running and stopped) on the specified server. Superuser only.
+// @Description Returns all containers (running and stopped) on the specified server, optionally narrowed by repeatable filter parameters passed to docker ps --filter (label=key, label=key=value, name, status, ancestor or network). items holds one object per container with Labels parsed into a map, e.g. to group by com.docker.compose.project. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
+// @Param filter query []string false "docker filter, e.g. label=com.docker.compose.project=web" collectionFormat(multi)
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/containers [get]
 func handleContainerList(e *core.RequestEvent) error {
+	filters, err := dockerListFilters(e.Request.URL.Query()["filter"], containerListFilters)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
 	client, err := getDockerClient(e)
 	if err != nil {
 		return dockerError(e, http.StatusBadRequest, "server not found", err)
 	}
-	output, err := client.ContainerList(e.Request.Context())
+	output, err := client.ContainerList(e.Request.Context(), filters...)
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "list containers failed", err)
 	}
-	return e.JSON(http.StatusOK, map[string]any{"output": output, "host": client.Host()})
+	return e.JSON(http.StatusOK, map[string]any{"output": output, "items": dockerListItems(output), "host": client.Host()})
+}
+
+// Filter keys accepted by the container and image list endpoints.
+var (
+	containerListFilters = map[string]bool{"label": true, "name": true, "status": true, "ancestor": true, "network": true}
+	imageListFilters     = map[string]bool{"label": true, "reference": true, "dangling": true}
+)
+
+var dockerLabelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// dockerListFormat reports whether the list endpoint should return typed
+// items only (format=json). An empty format keeps the raw CLI output.
+func dockerListFormat(e *core.RequestEvent) (bool, error) {
+	switch format := strings.TrimSpace(e.Request.URL.Query().Get("format")); format {
+	case "":
+		return false, nil
+	case "json":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported format %q: expected json", format)
+	}
+}
+
+// dockerListFilters validates key=value filter expressions against allowed.
+// Label filters take "key" or "key=value".
+func dockerListFilters(raw []string, allowed map[string]bool) ([]string, error) {
+	filters := make([]string, 0, len(raw))
+	for _, f := range raw {
+		f = strings.TrimSpace(f)
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid filter %q: expected key=value", f)
+		}
+		if !allowed[key] {
+			return nil, fmt.Errorf("unsupported filter %q", key)
+		}
+		if len(value) > 256 || strings.ContainsFunc(value, unicode.IsControl) {
+			return nil, fmt.Errorf("invalid %s filter value", key)
+		}
+		if key == "label" {
+			labelKey, _, _ := strings.Cut(value, "=")
+			if !dockerLabelKeyPattern.MatchString(labelKey) {
+				return nil, fmt.Errorf("invalid label key %q", labelKey)
+			}
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// dockerListItems decodes docker's one-JSON-object-per-line list output and
+// turns each Labels summary into a map. Undecodable lines are skipped.
+func dockerListItems(output string) []map[string]any {
+	items := []map[string]any{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var item map[string]any
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			continue
+		}
+		labels, _ := item["Labels"].(string)
+		item["Labels"] = docker.ParseLabels(labels)
+		items = append(items, item)
+	}
+	return items
+}
+
+// handleContainerCreate creates and starts a container from a structured
+// configuration.
+//
+// @Summary Create container
+// @Description Runs a new detached container from image with optional name, published ports, volume mounts, environment, restart policy and network. Every value is validated and passed to docker run as a separate argument; nothing is parsed by a shell. The audit log records the resolved docker run flags with environment values masked. server_id selects a remote server. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param body body docker.RunOptions true "container configuration"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/containers [post]
+func handleContainerCreate(e *core.RequestEvent) error {
+	var opts docker.RunOptions
+	if err := json.NewDecoder(e.Request.Body).Decode(&opts); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid JSON body"})
+	}
+	opts.Image = strings.TrimSpace(opts.Image)
+	opts.Name = strings.TrimSpace(opts.Name)
+	if err := opts.Validate(); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	client, err := getDockerClient(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "server not found", err)
+	}
+
+	id, runErr := client.ContainerRun(e.Request.Context(), opts)
+
+	userID, userEmail, ip, ua := clientInfo(e)
+	detail := map[string]any{
+		"server_id": dockerServerKey(e.Request.URL.Query().Get("server_id")),
+		"image":     opts.Image,
+		"args":      opts.RedactedArgs(),
+	}
+	status := audit.StatusSuccess
+	if runErr != nil {
+		status = audit.StatusFailed
+		detail["errorMessage"] = runErr.Error()
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: "docker.container.create", ResourceType: "docker_container",
+		ResourceID: id, ResourceName: opts.Name,
+		IP: ip, UserAgent: ua,
+		Status: status,
+		Detail: detail,
+	})
+	if runErr != nil {
+		return dockerError(e, http.StatusInternalServerError, "create container failed", runErr)
+	}
+	return e.JSON(http.StatusOK, map[string]any{"id": id, "name": opts.Name})
 }
 
 // handleContainerInspect returns detailed metadata for a container.
 //
 // @Summary Inspect container
-// @Description Returns docker inspect output for the given container ID. Superuser only.
+// @Description Returns docker inspect for the given container ID. inspect is the inspect object itself (not wrapped in an array); container holds typed fields for detail views: state and health, restart policy, env, labels, mounts, networks and ports. output keeps the raw CLI text for older clients; when it cannot be parsed, only output is returned. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
 // @Param id path string true "container ID or name"
-// @Success 200 {object} map[string]any
+// @Success 200 {object} map[string]any "output, inspect, container"
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/containers/{id} [get]
 func handleContainerInspect(e *core.RequestEvent) error {
@@ -874,7 +1556,139 @@ func handleContainerInspect(e *core.RequestEvent) error {
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "inspect container failed", err)
 	}
-	return e.JSON(http.StatusOK, map[string]any{"output": output})
+	details, raw, err := docker.ParseContainerInspect(output)
+	if errors.Is(err, docker.ErrContainerNotFound) {
+		return e.NotFoundError("container not found", err)
+	}
+	if err != nil {
+		// Output the parser does not understand still reaches the client raw.
+		return e.JSON(http.StatusOK, map[string]any{"output": output})
+	}
+	return e.JSON(http.StatusOK, map[string]any{"output": output, "inspect": raw, "container": details})
+}
+
+// containerEnvMask replaces secret-looking values when masking is requested.
+const containerEnvMask = "***"
+
+// handleContainerEnv returns the environment a container was created with.
+//
+// @Summary Get container environment
+// @Description Returns the container's Config.Env as key/value items. Each item flags whether its name looks like a credential; mask=true replaces those values with "***". Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param id path string true "container ID or name"
+// @Param mask query bool false "mask values of secret-looking variables"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/containers/{id}/env [get]
+func handleContainerEnv(e *core.RequestEvent) error {
+	mask := false
+	if raw := e.Request.URL.Query().Get("mask"); raw != "" {
+		var err error
+		if mask, err = strconv.ParseBool(raw); err != nil {
+			return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "mask must be true or false"})
+		}
+	}
+	client, err := getDockerClient(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "server not found", err)
+	}
+	vars, err := client.ContainerEnv(e.Request.Context(), e.Request.PathValue("id"))
+	if err != nil {
+		return dockerError(e, http.StatusInternalServerError, "read container env failed", err)
+	}
+
+	items := make([]map[string]any, 0, len(vars))
+	for _, v := range vars {
+		secret := sharedenv.LooksSecret(v.Key)
+		value := v.Value
+		if secret && mask {
+			value = containerEnvMask
+		}
+		items = append(items, map[string]any{"key": v.Key, "value": value, "secret": secret})
+	}
+	return e.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// handleContainerEnvExport copies a container's environment into an env set.
+//
+// @Summary Export container environment to an env set
+// @Description Writes the container's Config.Env into an existing env set (set_id) or a new one (name, description). Existing keys are overwritten. Secret-looking variables are skipped and listed in skipped unless include_secrets is true. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param id path string true "container ID or name"
+// @Param body body object true "set_id, or name and optional description; include_secrets"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/containers/{id}/env/export [post]
+func handleContainerEnvExport(e *core.RequestEvent) error {
+	body, err := readBody(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "invalid request body", err)
+	}
+	setID := strings.TrimSpace(bodyString(body, "set_id"))
+	name := strings.TrimSpace(bodyString(body, "name"))
+	if (setID == "") == (name == "") {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "exactly one of set_id or name is required"})
+	}
+	if setID != "" {
+		if _, err := sharedenv.GetSet(e.App, setID); err != nil {
+			return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+		}
+	}
+
+	client, err := getDockerClient(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "server not found", err)
+	}
+	vars, err := client.ContainerEnv(e.Request.Context(), e.Request.PathValue("id"))
+	if err != nil {
+		return dockerError(e, http.StatusInternalServerError, "read container env failed", err)
+	}
+
+	includeSecrets := bodyBool(body, "include_secrets")
+	toImport := make([]sharedenv.Var, 0, len(vars))
+	skipped := []string{}
+	for _, v := range vars {
+		if !includeSecrets && sharedenv.LooksSecret(v.Key) {
+			skipped = append(skipped, v.Key)
+			continue
+		}
+		toImport = append(toImport, sharedenv.Var{Key: v.Key, Value: v.Value})
+	}
+
+	var set *sharedenv.Set
+	var result sharedenv.ImportResult
+	err = e.App.RunInTransaction(func(txApp core.App) error {
+		if setID == "" {
+			created, err := sharedenv.CreateSet(txApp, name, bodyString(body, "description"))
+			if err != nil {
+				return err
+			}
+			setID = created.ID
+		}
+		var err error
+		if set, err = sharedenv.GetSet(txApp, setID); err != nil {
+			return err
+		}
+		result, err = sharedenv.ImportVars(txApp, setID, toImport)
+		return err
+	})
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "export container env failed", err)
+	}
+	return e.JSON(http.StatusOK, map[string]any{
+		"set":     map[string]any{"id": set.ID, "name": set.Name},
+		"created": result.Created,
+		"updated": result.Updated,
+		"skipped": skipped,
+	})
 }
 
 // handleContainerStats returns real-time resource usage stats for all running containers.
@@ -901,21 +1715,35 @@ func handleContainerStats(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, map[string]any{"output": output, "host": client.Host()})
 }
 
+// Without a time window the logs endpoint returns the last
+// containerLogsDefaultTail lines; with one, at most containerLogsWindowTail.
+const (
+	containerLogsDefaultTail = 200
+	containerLogsWindowTail  = 5000
+)
+
 // handleContainerLogs returns recent log output for a container.
 //
 // @Summary Get container logs
-// @Description Returns recent stdout/stderr output for the given container. Superuser only.
+// @Description Returns stdout/stderr output for the given container. since and until narrow the output to a time window and accept an RFC3339 time or a duration counted back from now (e.g. 1h, 15m). Without a window the last 200 lines are returned; with one, the last 5000 lines of the window unless tail is set. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
 // @Param id path string true "container ID or name"
-// @Param tail query integer false "number of log lines (default 200)"
+// @Param tail query integer false "number of log lines (default 200, or 5000 with since/until)"
+// @Param since query string false "start of the window: RFC3339 time or relative duration such as 1h"
+// @Param until query string false "end of the window: RFC3339 time or relative duration such as 10m"
+// @Param timestamps query boolean false "prefix each line with its timestamp"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/containers/{id}/logs [get]
 func handleContainerLogs(e *core.RequestEvent) error {
+	opts, err := containerLogOptions(e.Request.URL.Query(), time.Now())
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
 	client, err := getDockerClient(e)
 	if err != nil {
 		return dockerError(e, http.StatusBadRequest, "server not found", err)
@@ -924,22 +1752,177 @@ func handleContainerLogs(e *core.RequestEvent) error {
 	if id == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "id is required"})
 	}
-	tail := 200
-	if t := e.Request.URL.Query().Get("tail"); t != "" {
-		if parsed, err := strconv.Atoi(t); err == nil {
-			tail = parsed
-		}
-	}
-	if tail <= 0 {
-		tail = 200
-	}
-	output, err := client.ContainerLogs(e.Request.Context(), id, tail)
+	output, err := client.ContainerLogs(e.Request.Context(), id, opts)
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "container logs failed", err)
 	}
 	return e.JSON(http.StatusOK, map[string]any{"output": output})
 }
 
+// containerLogStreamWriteTimeout bounds each WebSocket write so a stalled
+// client cannot hold the follow process open.
+const containerLogStreamWriteTimeout = 10 * time.Second
+
+// handleContainerLogStream follows container logs over a WebSocket.
+//
+// @Summary Stream container logs
+// @Description Upgrades to a WebSocket and relays docker logs -f output for the container, one JSON message {"type":"line","line":...} per log line, followed by {"type":"end"} when the container's log stream ends or {"type":"error","message":...} on failure. Works for local and remote servers; the follow process is stopped when the socket closes. since accepts an RFC3339 time or a duration counted back from now; tail (default 200) sets the backlog sent first. Browsers may pass the auth token as ?token=. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param id path string true "container ID or name"
+// @Param since query string false "start time: RFC3339 time or relative duration such as 1h"
+// @Param tail query integer false "number of backlog lines (default 200, or 5000 with since)"
+// @Param timestamps query boolean false "prefix each line with its timestamp"
+// @Success 101 {string} string "switching protocols"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Router /api/ext/docker/containers/{id}/logs/stream [get]
+func handleContainerLogStream(e *core.RequestEvent) error {
+	q := e.Request.URL.Query()
+	if q.Get("until") != "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "until is not supported when streaming"})
+	}
+	opts, err := containerLogOptions(q, time.Now())
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
+	id := e.Request.PathValue("id")
+	if id == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "id is required"})
+	}
+	client, err := getDockerClient(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "server not found", err)
+	}
+
+	conn, err := wsUpgrader.Upgrade(e.Response, e.Request, nil)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	send := func(msg map[string]any) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(containerLogStreamWriteTimeout))
+		return conn.WriteJSON(msg)
+	}
+
+	// Cancelling ctx stops the follow process; the read loop cancels it as
+	// soon as the client closes or the connection drops.
+	ctx, cancel := context.WithCancel(e.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	stream, err := client.ContainerLogsStream(ctx, id, opts)
+	if err != nil {
+		_ = send(map[string]any{"type": "error", "message": err.Error()})
+		return nil
+	}
+	defer stream.Close()
+	// Closing the stream also unblocks the scanner below.
+	go func() {
+		<-ctx.Done()
+		_ = stream.Close()
+	}()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		if err := send(map[string]any{"type": "line", "line": scanner.Text()}); err != nil {
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		_ = send(map[string]any{"type": "error", "message": err.Error()})
+	} else {
+		_ = send(map[string]any{"type": "end"})
+	}
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	return nil
+}
+
+// containerLogOptions reads tail, since, until and timestamps. An invalid
+// tail falls back to the default; invalid times and booleans are errors.
+func containerLogOptions(q url.Values, now time.Time) (docker.LogOptions, error) {
+	opts := docker.LogOptions{Since: q.Get("since"), Until: q.Get("until")}
+
+	var since, until time.Time
+	var err error
+	if opts.Since != "" {
+		if since, err = docker.ParseLogTime(opts.Since, now); err != nil {
+			return opts, fmt.Errorf("since: %w", err)
+		}
+	}
+	if opts.Until != "" {
+		if until, err = docker.ParseLogTime(opts.Until, now); err != nil {
+			return opts, fmt.Errorf("until: %w", err)
+		}
+	}
+	if opts.Since != "" && opts.Until != "" && !since.Before(until) {
+		return opts, errors.New("since must be before until")
+	}
+	if raw := q.Get("timestamps"); raw != "" {
+		if opts.Timestamps, err = strconv.ParseBool(raw); err != nil {
+			return opts, errors.New("timestamps must be true or false")
+		}
+	}
+
+	opts.Tail = containerLogsDefaultTail
+	if opts.Since != "" || opts.Until != "" {
+		opts.Tail = containerLogsWindowTail
+	}
+	if t := q.Get("tail"); t != "" {
+		if parsed, err := strconv.Atoi(t); err == nil && parsed > 0 {
+			opts.Tail = parsed
+		}
+	}
+	return opts, nil
+}
+
+// containerStopMaxTimeout caps the ?timeout= grace period of stop and
+// restart so a request cannot hold the docker CLI open indefinitely.
+const containerStopMaxTimeout = 3600
+
+// containerStopSignalPattern matches signal names such as SIGTERM, TERM or
+// SIGRTMIN+3; numeric signals are checked separately.
+var containerStopSignalPattern = regexp.MustCompile(`^(SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?$`)
+
+// containerStopOptions parses the ?timeout= and ?signal= parameters of the
+// stop and restart endpoints. Omitted parameters keep docker's defaults.
+func containerStopOptions(q url.Values) (docker.StopOptions, error) {
+	var opts docker.StopOptions
+	if raw := q.Get("timeout"); raw != "" {
+		timeout, err := strconv.Atoi(raw)
+		if err != nil || timeout < 0 || timeout > containerStopMaxTimeout {
+			return opts, fmt.Errorf("timeout must be an integer between 0 and %d seconds", containerStopMaxTimeout)
+		}
+		opts.Timeout = &timeout
+	}
+	if raw := strings.TrimSpace(q.Get("signal")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil {
+			if n < 1 || n > 64 {
+				return opts, errors.New("signal number must be between 1 and 64")
+			}
+		} else if raw = strings.ToUpper(raw); !containerStopSignalPattern.MatchString(raw) {
+			return opts, errors.New("signal must be a signal name such as SIGTERM or a number")
+		}
+		opts.Signal = raw
+	}
+	return opts, nil
+}
+
 // handleContainerStart starts a stopped Docker container.
 //
 // @Summary Start container
@@ -969,23 +1952,29 @@ func handleContainerStart(e *core.RequestEvent) error {
 // handleContainerStop stops a running Docker container.
 //
 // @Summary Stop container
-// @Description Stops the specified container. Superuser only.
+// @Description Stops the specified container. timeout sets the seconds docker waits after the stop signal before killing the container, and signal overrides the container's stop signal; both default to the container's own settings. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
 // @Param id path string true "container ID or name"
+// @Param timeout query integer false "seconds to wait before killing the container (0-3600)"
+// @Param signal query string false "stop signal name or number, e.g. SIGINT or 2"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/containers/{id}/stop [post]
 func handleContainerStop(e *core.RequestEvent) error {
+	opts, err := containerStopOptions(e.Request.URL.Query())
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
 	client, err := getDockerClient(e)
 	if err != nil {
 		return dockerError(e, http.StatusBadRequest, "server not found", err)
 	}
 	id := e.Request.PathValue("id")
-	output, err := client.ContainerStop(e.Request.Context(), id)
+	output, err := client.ContainerStop(e.Request.Context(), id, opts)
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "stop container failed", err)
 	}
@@ -995,23 +1984,29 @@ func handleContainerStop(e *core.RequestEvent) error {
 // handleContainerRestart restarts a Docker container.
 //
 // @Summary Restart container
-// @Description Restarts the specified container. Superuser only.
+// @Description Restarts the specified container. timeout sets the seconds docker waits after the stop signal before killing the container, and signal overrides the container's stop signal; both default to the container's own settings. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param server_id query string false "server ID (omit for local)"
 // @Param id path string true "container ID or name"
+// @Param timeout query integer false "seconds to wait before killing the container (0-3600)"
+// @Param signal query string false "stop signal name or number, e.g. SIGINT or 2"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/ext/docker/containers/{id}/restart [post]
 func handleContainerRestart(e *core.RequestEvent) error {
+	opts, err := containerStopOptions(e.Request.URL.Query())
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": err.Error()})
+	}
 	client, err := getDockerClient(e)
 	if err != nil {
 		return dockerError(e, http.StatusBadRequest, "server not found", err)
 	}
 	id := e.Request.PathValue("id")
-	output, err := client.ContainerRestart(e.Request.Context(), id)
+	output, err := client.ContainerRestart(e.Request.Context(), id, opts)
 	if err != nil {
 		return dockerError(e, http.StatusInternalServerError, "restart container failed", err)
 	}
@@ -1237,6 +2232,87 @@ func handleVolumePrune(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, map[string]any{"output": output})
 }
 
+// ─── Housekeeping Handler ────────────────────────────────
+
+// handleDockerPrune previews or runs a combined prune of unused containers,
+// images, networks, build cache and, on request, volumes.
+//
+// @Summary Prune unused Docker objects
+// @Description Without confirm, returns what each prune would remove and the reclaimable bytes, changing nothing. With confirm=true, prunes stopped containers, all unused images, unused networks and the build cache, plus unused volumes only when volumes=true, and returns the reclaimed bytes per target and in total. A failed target does not stop the others. Confirmed runs are audited. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param server_id query string false "server ID (omit for local)"
+// @Param body body object false "confirm (bool) runs the prune; volumes (bool) includes unused volumes"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/docker/prune [post]
+func handleDockerPrune(e *core.RequestEvent) error {
+	body, err := readBody(e)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return e.JSON(http.StatusBadRequest, map[string]any{"code": 400, "message": "invalid JSON body"})
+	}
+	confirm := bodyBool(body, "confirm")
+	includeVolumes := bodyBool(body, "volumes")
+
+	client, err := getDockerClient(e)
+	if err != nil {
+		return dockerError(e, http.StatusBadRequest, "server not found", err)
+	}
+	if !confirm {
+		preview, err := client.PrunePreview(e.Request.Context())
+		if err != nil {
+			return dockerError(e, http.StatusInternalServerError, "prune preview failed", err)
+		}
+		var total int64
+		for _, est := range preview {
+			if est.Target != docker.PruneVolumes || includeVolumes {
+				total += est.ReclaimableBytes
+			}
+		}
+		return e.JSON(http.StatusOK, map[string]any{
+			"confirmed":               false,
+			"volumes":                 includeVolumes,
+			"preview":                 preview,
+			"total_reclaimable_bytes": total,
+		})
+	}
+
+	results := client.PruneAll(e.Request.Context(), includeVolumes)
+	var total int64
+	var failed []string
+	for _, result := range results {
+		total += result.ReclaimedBytes
+		if result.Error != "" {
+			failed = append(failed, result.Target)
+		}
+	}
+
+	serverKey := dockerServerKey(e.Request.URL.Query().Get("server_id"))
+	userID, userEmail, ip, ua := clientInfo(e)
+	detail := map[string]any{"volumes": includeVolumes, "reclaimed_bytes": total}
+	status := audit.StatusSuccess
+	if len(failed) > 0 {
+		status = audit.StatusFailed
+		detail["failed"] = failed
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: "docker.prune", ResourceType: "server",
+		ResourceID: serverKey, ResourceName: serverKey,
+		IP: ip, UserAgent: ua,
+		Status: status,
+		Detail: detail,
+	})
+	return e.JSON(http.StatusOK, map[string]any{
+		"confirmed":       true,
+		"volumes":         includeVolumes,
+		"results":         results,
+		"reclaimed_bytes": total,
+	})
+}
+
 // ─── Exec Handler ────────────────────────────────────────
 
 // handleDockerExec runs an arbitrary Docker CLI command on the target server.
@@ -1250,6 +2326,8 @@ func handleVolumePrune(e *core.RequestEvent) error {
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Router /api/ext/docker/exec [post]
 func handleDockerExec(e *core.RequestEvent) error {
 	client, err := getDockerClient(e)
@@ -1,14 +1,29 @@
 package routes
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sharedenv"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/infra/docker"
 )
 
 func TestTunnelSSHPortFromServices(t *testing.T) {
@@ -128,3 +143,947 @@ func TestDockerRoutesRequireSuperuser(t *testing.T) {
 		t.Fatalf("expected 200 for superuser, got %d: %s", rec.Code, rec.Body.String())
 	}
 }
+
+// fakeDockerExecutor fails every docker command with err.
+type fakeDockerExecutor struct {
+	err   error
+	calls int
+}
+
+func (f *fakeDockerExecutor) Run(context.Context, string, ...string) (string, error) {
+	f.calls++
+	return "", f.err
+}
+
+func (f *fakeDockerExecutor) RunStream(context.Context, string, ...string) (io.ReadCloser, error) {
+	return nil, f.err
+}
+
+func (f *fakeDockerExecutor) Ping(context.Context) error { return nil }
+func (f *fakeDockerExecutor) Host() string               { return "local" }
+
+func TestDockerRoutesReportDaemonUnreachable(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	fake := &fakeDockerExecutor{err: errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?: exit status 1")}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(fake)
+	dockerDaemonChecker.Invalidate("local")
+	t.Cleanup(func() {
+		localDockerClient = prevClient
+		dockerDaemonChecker.Invalidate("local")
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers", "", te.token)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Message string         `json:"message"`
+			Data    map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Data["reason_code"] != docker.ReasonDaemonUnreachable || body.Data["reason"] != docker.DaemonNotRunning {
+			t.Fatalf("unexpected error body: %s", rec.Body.String())
+		}
+		if body.Message != "Docker is not running on this server" {
+			t.Fatalf("unexpected message %q", body.Message)
+		}
+	}
+	// The failed check is cached: the second request does not re-run docker.
+	if fake.calls != 1 {
+		t.Fatalf("expected a single docker version call, got %d", fake.calls)
+	}
+
+	// A transport failure is reported as such and cached the same way.
+	fake.err, fake.calls = errors.New("ssh: handshake failed: ssh: unable to authenticate"), 0
+	dockerDaemonChecker.Invalidate("local")
+	for i := 0; i < 2; i++ {
+		rec := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers", "", te.token)
+		if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "unable to authenticate") {
+			t.Fatalf("expected 502 with the transport error, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if fake.calls != 1 {
+		t.Fatalf("expected the transport failure to be cached, got %d docker version calls", fake.calls)
+	}
+}
+
+// recordingDockerExecutor returns output for every command and records the
+// last one.
+type recordingDockerExecutor struct {
+	fakeDockerExecutor
+	output string
+	args   []string
+}
+
+func (r *recordingDockerExecutor) Run(_ context.Context, _ string, args ...string) (string, error) {
+	r.args = args
+	return r.output, nil
+}
+
+func useRecordingDocker(t *testing.T, output string) *recordingDockerExecutor {
+	t.Helper()
+	rec := &recordingDockerExecutor{output: output}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(rec)
+	dockerDaemonChecker.Invalidate("local")
+	t.Cleanup(func() {
+		localDockerClient = prevClient
+		dockerDaemonChecker.Invalidate("local")
+	})
+	return rec
+}
+
+func TestContainerLogsTimeWindow(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, "line\n")
+
+	res := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web/logs?since=2026-01-02T10:00:00Z&until=30m&timestamps=true", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	want := "logs --tail 5000 --since 2026-01-02T10:00:00Z --until 30m --timestamps web"
+	if got := strings.Join(rec.args, " "); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	res = doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web/logs", "", te.token)
+	if got := strings.Join(rec.args, " "); res.Code != http.StatusOK || got != "logs --tail 200 web" {
+		t.Fatalf("expected default tail, got %d %q", res.Code, got)
+	}
+
+	for _, query := range []string{"since=yesterday", "until=-1h", "since=10m&until=1h", "timestamps=maybe"} {
+		res = doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web/logs?"+query, "", te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestContainerStopTimeoutAndSignal(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, "web\n")
+
+	cases := map[string]string{
+		"/api/ext/docker/containers/web/stop":                           "stop web",
+		"/api/ext/docker/containers/web/stop?timeout=120&signal=sigint": "stop -t 120 --signal SIGINT web",
+		"/api/ext/docker/containers/web/restart?timeout=0":              "restart -t 0 web",
+		"/api/ext/docker/containers/web/restart?signal=15":              "restart --signal 15 web",
+	}
+	for path, want := range cases {
+		res := doDocker(t, te, http.MethodPost, path, "", te.token)
+		if res.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, res.Code, res.Body.String())
+		}
+		if got := strings.Join(rec.args, " "); got != want {
+			t.Fatalf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+
+	for _, query := range []string{"timeout=-1", "timeout=3601", "timeout=soon", "signal=0", "signal=99", "signal=TERM%3Bls"} {
+		res := doDocker(t, te, http.MethodPost, "/api/ext/docker/containers/web/stop?"+query, "", te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestContainerCreateRunsStructuredConfigAndAudits(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, "3f2a9c1d7e\n")
+
+	body := `{"image":"nginx:1.27","name":"web","ports":[{"host_port":8080,"container_port":80}],` +
+		`"volumes":[{"source":"/srv/www","target":"/usr/share/nginx/html","read_only":true}],` +
+		`"env":{"TOKEN":"s3cret; reboot"},"restart":"unless-stopped","network":"frontend"}`
+	res := doDocker(t, te, http.MethodPost, "/api/ext/docker/containers", body, te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"id":"3f2a9c1d7e"`) {
+		t.Fatalf("expected the container id, got %s", res.Body.String())
+	}
+	want := []string{
+		"run", "--detach", "--name=web", "--publish=8080:80/tcp",
+		"--volume=/srv/www:/usr/share/nginx/html:ro", "--env=TOKEN=s3cret; reboot",
+		"--restart=unless-stopped", "--network=frontend", "nginx:1.27",
+	}
+	if strings.Join(rec.args, "\x00") != strings.Join(want, "\x00") {
+		t.Fatalf("expected %q, got %q", want, rec.args)
+	}
+
+	entries := auditEntriesByAction(t, te, "docker.container.create")
+	if len(entries) != 1 || entries[0].GetString("status") != audit.StatusSuccess {
+		t.Fatalf("expected one successful audit entry, got %d", len(entries))
+	}
+	if detail := entries[0].GetString("detail"); strings.Contains(detail, "s3cret") || !strings.Contains(detail, "--env=TOKEN=***") {
+		t.Fatalf("expected resolved flags with masked env values in audit detail, got %s", detail)
+	}
+
+	rec.args = nil
+	for _, bad := range []string{
+		`{"name":"web"}`,
+		`{"image":"--privileged"}`,
+		`{"image":"nginx","ports":[{"container_port":0}]}`,
+		`{"image":"nginx","volumes":[{"source":"/","target":"/host,readonly"}]}`,
+		`{"image":"nginx","restart":"always;reboot"}`,
+		`not json`,
+	} {
+		res := doDocker(t, te, http.MethodPost, "/api/ext/docker/containers", bad, te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", bad, res.Code, res.Body.String())
+		}
+	}
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/containers?server_id=missing", `{"image":"nginx"}`, te.token)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("unknown server: expected 400, got %d: %s", res.Code, res.Body.String())
+	}
+	if rec.args != nil {
+		t.Fatalf("expected rejected requests not to reach docker, got %q", rec.args)
+	}
+}
+
+// pruneDockerExecutor answers the prune preview commands and reports a
+// reclaimed total for each prune it runs.
+type pruneDockerExecutor struct {
+	fakeDockerExecutor
+	commands []string
+}
+
+func (p *pruneDockerExecutor) Run(_ context.Context, _ string, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	p.commands = append(p.commands, cmd)
+	switch {
+	case strings.HasPrefix(cmd, "system df"):
+		return `{"Active":"1","Reclaimable":"500MB (50%)","TotalCount":"3","Type":"Images"}` + "\n" +
+			`{"Active":"0","Reclaimable":"2GB (100%)","TotalCount":"2","Type":"Local Volumes"}` + "\n", nil
+	case strings.HasPrefix(cmd, "network ls"):
+		return "", nil
+	case strings.HasPrefix(cmd, "builder prune"):
+		return "", errors.New("builder prune is not supported")
+	}
+	return "Total reclaimed space: 1MB\n", nil
+}
+
+func TestDockerPrunePreviewsThenPrunesWithVolumesOptIn(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	exec := &pruneDockerExecutor{}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(exec)
+	dockerDaemonChecker.Invalidate("local")
+	t.Cleanup(func() {
+		localDockerClient = prevClient
+		dockerDaemonChecker.Invalidate("local")
+	})
+
+	res := doDocker(t, te, http.MethodPost, "/api/ext/docker/prune", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("preview: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var preview struct {
+		Confirmed bool                   `json:"confirmed"`
+		Preview   []docker.PruneEstimate `json:"preview"`
+		Total     int64                  `json:"total_reclaimable_bytes"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &preview); err != nil {
+		t.Fatal(err)
+	}
+	if preview.Confirmed || len(preview.Preview) != 5 || preview.Total != 500_000_000 {
+		t.Fatalf("expected a preview without volumes in the total, got %s", res.Body.String())
+	}
+	for _, cmd := range exec.commands {
+		if strings.Contains(cmd, "prune") {
+			t.Fatalf("expected the preview not to prune, ran %q", exec.commands)
+		}
+	}
+
+	exec.commands = nil
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/prune", `{"confirm":true}`, te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("prune: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if strings.Contains(strings.Join(exec.commands, "\n"), "volume") {
+		t.Fatalf("expected volumes to be opt-in, ran %q", exec.commands)
+	}
+	if !strings.Contains(res.Body.String(), `"reclaimed_bytes":3000000`) || !strings.Contains(res.Body.String(), "builder prune is not supported") {
+		t.Fatalf("expected totals and the failed target, got %s", res.Body.String())
+	}
+	entries := auditEntriesByAction(t, te, "docker.prune")
+	if len(entries) != 1 || entries[0].GetString("status") != audit.StatusFailed {
+		t.Fatalf("expected one failed prune audit entry, got %d", len(entries))
+	}
+
+	exec.commands = nil
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/prune", `{"confirm":true,"volumes":true}`, te.token)
+	if res.Code != http.StatusOK || exec.commands[len(exec.commands)-1] != "volume prune -f" {
+		t.Fatalf("expected volumes to be pruned when requested, got %d, ran %q", res.Code, exec.commands)
+	}
+}
+
+// inputDockerExecutor records every command and the stdin it was given.
+type inputDockerExecutor struct {
+	fakeDockerExecutor
+	commands []string
+	inputs   []string
+}
+
+func (i *inputDockerExecutor) Run(ctx context.Context, command string, args ...string) (string, error) {
+	return i.RunInput(ctx, "", command, args...)
+}
+
+func (i *inputDockerExecutor) RunInput(_ context.Context, input string, _ string, args ...string) (string, error) {
+	i.commands = append(i.commands, strings.Join(args, " "))
+	i.inputs = append(i.inputs, input)
+	return "", nil
+}
+
+func TestImagePullLogsIntoRegistryWithCredential(t *testing.T) {
+	ensureConnectorSecretRuntime(t)
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	routeRateLimiters.Clear()
+	defer routeRateLimiters.Clear()
+
+	exec := &inputDockerExecutor{}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(exec)
+	dockerDaemonChecker.Invalidate("local")
+	t.Cleanup(func() {
+		localDockerClient = prevClient
+		dockerDaemonChecker.Invalidate("local")
+	})
+
+	secret := createRouteSecret(t, te, "global", "")
+	secret.Set("name", "ghcr-login")
+	secret.Set("template_id", "registry_auth")
+	enc, err := secrets.EncryptPayload(map[string]any{"registry": "ghcr.io", "username": "deploy", "password": "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Set("payload_encrypted", enc)
+	if err := te.app.Save(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	res := doDocker(t, te, http.MethodPost, "/api/ext/docker/images/pull", `{"name":"ghcr.io/team/app:1","credential":"`+secret.Id+`"}`, te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	// The first command is the daemon check.
+	want := []string{"login --username=deploy --password-stdin ghcr.io", "pull ghcr.io/team/app:1", "logout ghcr.io"}
+	if len(exec.commands) != 4 || strings.Join(exec.commands[1:], "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %q, got %q", want, exec.commands)
+	}
+	if exec.inputs[1] != "s3cret" || strings.Contains(strings.Join(exec.commands, " "), "s3cret") {
+		t.Fatalf("expected the password on stdin only, got %q", exec.commands)
+	}
+
+	exec.commands = nil
+	other := createRouteSecret(t, te, "global", "")
+	for _, body := range []string{
+		`{"name":"nginx","credential":"` + secret.Id + `"}`,
+		`{"name":"ghcr.io/team/app","credential":"` + other.Id + `"}`,
+		`{"name":"ghcr.io/team/app","credential":"missing"}`,
+	} {
+		routeRateLimiters.Clear()
+		res := doDocker(t, te, http.MethodPost, "/api/ext/docker/images/pull", body, te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", body, res.Code, res.Body.String())
+		}
+	}
+	if len(exec.commands) != 0 {
+		t.Fatalf("expected rejected pulls not to run docker, got %q", exec.commands)
+	}
+}
+
+// streamingDockerExecutor serves RunStream from a pipe the test writes to and
+// records whether the stream was closed.
+type streamingDockerExecutor struct {
+	recordingDockerExecutor
+	pw     *io.PipeWriter
+	closed chan struct{}
+}
+
+func (s *streamingDockerExecutor) RunStream(_ context.Context, _ string, args ...string) (io.ReadCloser, error) {
+	s.args = args
+	pr, pw := io.Pipe()
+	s.pw = pw
+	return &closeNotifier{PipeReader: pr, closed: s.closed}, nil
+}
+
+type closeNotifier struct {
+	*io.PipeReader
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *closeNotifier) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.PipeReader.Close()
+}
+
+func TestContainerLogStreamRelaysLinesAndStopsOnDisconnect(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	fake := &streamingDockerExecutor{closed: make(chan struct{})}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(fake)
+	dockerDaemonChecker.Invalidate("local")
+	t.Cleanup(func() {
+		localDockerClient = prevClient
+		dockerDaemonChecker.Invalidate("local")
+	})
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(apis.RequireAuth())
+	registerDockerRoutes(g)
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if rec := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web/logs/stream?until=1h", "", te.token); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for until, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/ext/docker/containers/web/logs/stream?since=10m&token=" + url.QueryEscape(te.token)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() { _, _ = io.WriteString(fake.pw, "first\nsecond\n") }()
+	for _, want := range []string{"first", "second"} {
+		var msg map[string]any
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg["type"] != "line" || msg["line"] != want {
+			t.Fatalf("expected line %q, got %v", want, msg)
+		}
+	}
+	if got := strings.Join(fake.args, " "); got != "logs --tail 5000 --since 10m --follow web" {
+		t.Fatalf("unexpected docker args %q", got)
+	}
+
+	conn.Close()
+	select {
+	case <-fake.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("log stream was not closed after the client disconnected")
+	}
+}
+
+func TestImagePullStreamReportsProgressAndStopsOnDisconnect(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	fake := &streamingDockerExecutor{closed: make(chan struct{})}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(fake)
+	dockerDaemonChecker.Invalidate("local")
+	t.Cleanup(func() {
+		localDockerClient = prevClient
+		dockerDaemonChecker.Invalidate("local")
+	})
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(apis.RequireAuth())
+	registerDockerRoutes(g)
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if rec := doDocker(t, te, http.MethodGet, "/api/ext/docker/images/pull-stream", "", te.token); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without name, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	pull := func(ctx context.Context) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/ext/docker/images/pull-stream?name=nginx:latest", nil)
+		req.Header.Set("Authorization", te.token)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", res.StatusCode)
+		}
+		return res
+	}
+
+	res := pull(context.Background())
+	go func() {
+		_, _ = io.WriteString(fake.pw, "latest: Pulling from library/nginx\n"+
+			"a2abf6c4d29d: Pulling fs layer\n"+
+			"a2abf6c4d29d: Download complete\n"+
+			"a2abf6c4d29d: Pull complete\n"+
+			"Digest: sha256:abc\n"+
+			"Status: Downloaded newer image for nginx:latest\n")
+		_ = fake.pw.Close()
+	}()
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.args, " "); got != "pull nginx:latest" {
+		t.Fatalf("unexpected docker args %q", got)
+	}
+	stream := string(body)
+	for _, want := range []string{
+		`event: start`,
+		`"percent":70`,
+		`event: done` + "\n" + `data: {"digest":"sha256:abc","name":"nginx:latest","status":"Downloaded newer image for nginx:latest"}`,
+	} {
+		if !strings.Contains(stream, want) {
+			t.Fatalf("expected %q in stream:\n%s", want, stream)
+		}
+	}
+
+	// Disconnecting stops the pull.
+	fake.closed = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	res = pull(ctx)
+	cancel()
+	res.Body.Close()
+	select {
+	case <-fake.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pull stream was not closed after the client disconnected")
+	}
+}
+
+func TestContainerListLabelFilters(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, `{"ID":"abc","Names":"web-1","Labels":"com.docker.compose.project=web,com.docker.compose.service=app"}`+"\n")
+
+	res := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers?filter=label%3Dcom.docker.compose.project%3Dweb&filter=status%3Drunning", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	want := "ps -a --format json --filter label=com.docker.compose.project=web --filter status=running"
+	if got := strings.Join(rec.args, " "); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	var body struct {
+		Items []struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Items) != 1 || body.Items[0].Labels["com.docker.compose.project"] != "web" {
+		t.Fatalf("expected parsed labels, got %s", res.Body.String())
+	}
+
+	for _, query := range []string{"filter=label", "filter=before%3Dabc", "filter=label%3D%3Dx", "filter=reference%3Dnginx"} {
+		res = doDocker(t, te, http.MethodGet, "/api/ext/docker/containers?"+query, "", te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, res.Code, res.Body.String())
+		}
+	}
+
+	res = doDocker(t, te, http.MethodGet, "/api/ext/docker/images?filter=reference%3Dnginx", "", te.token)
+	if got := strings.Join(rec.args, " "); res.Code != http.StatusOK || got != "image ls --format {{json .}} --filter reference=nginx" {
+		t.Fatalf("expected image filter, got %d %q", res.Code, got)
+	}
+}
+
+func TestContainerEnvMaskAndExport(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, `["PATH=/usr/bin","DB_PASSWORD=s3cret","APP_MODE=prod","EMPTY="]`+"\n")
+
+	res := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web/env?mask=true", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if got := strings.Join(rec.args, " "); got != "inspect --format {{json .Config.Env}} web" {
+		t.Fatalf("unexpected docker args %q", got)
+	}
+	var body struct {
+		Items []struct {
+			Key    string `json:"key"`
+			Value  string `json:"value"`
+			Secret bool   `json:"secret"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Items) != 4 || body.Items[1].Value != "***" || !body.Items[1].Secret || body.Items[2].Value != "prod" {
+		t.Fatalf("unexpected items: %s", res.Body.String())
+	}
+
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/containers/web/env/export", `{"name":"web-env"}`, te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var exported struct {
+		Set     struct{ ID string } `json:"set"`
+		Created int                 `json:"created"`
+		Skipped []string            `json:"skipped"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.Created != 3 || len(exported.Skipped) != 1 || exported.Skipped[0] != "DB_PASSWORD" {
+		t.Fatalf("unexpected export result: %s", res.Body.String())
+	}
+
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/containers/web/env/export", `{"set_id":"`+exported.Set.ID+`","include_secrets":true}`, te.token)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"created":1`) || !strings.Contains(res.Body.String(), `"updated":3`) {
+		t.Fatalf("unexpected re-export result %d: %s", res.Code, res.Body.String())
+	}
+	record, err := te.app.FindFirstRecordByFilter("env_set_vars", "set = {:set} && key = 'DB_PASSWORD'", map[string]any{"set": exported.Set.ID})
+	if err != nil || record.GetString("value") != "s3cret" {
+		t.Fatalf("expected exported secret value, got %v (%v)", record, err)
+	}
+
+	for _, payload := range []string{`{}`, `{"set_id":"x","name":"y"}`, `{"set_id":"missing"}`} {
+		res = doDocker(t, te, http.MethodPost, "/api/ext/docker/containers/web/env/export", payload, te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", payload, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestComposeDownRemovalOptions(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, "")
+
+	res := doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/down", `{"projectDir":"/apps/web","removeVolumes":true,"removeOrphans":true,"removeImages":"local"}`, te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	want := "compose -f /apps/web/docker-compose.yml down -v --remove-orphans --rmi local"
+	if got := strings.Join(rec.args, " "); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	entries := auditEntriesByAction(t, te, "app.delete")
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(entries))
+	}
+	var detail map[string]any
+	if err := entries[0].UnmarshalJSONField("detail", &detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail["removeOrphans"] != true || detail["removeImages"] != "local" || detail["removeVolumes"] != true {
+		t.Fatalf("expected removals in audit detail, got %v", detail)
+	}
+
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/down", `{"projectDir":"/apps/web","removeImages":true}`, te.token)
+	if got := strings.Join(rec.args, " "); res.Code != http.StatusOK || got != "compose -f /apps/web/docker-compose.yml down --rmi all" {
+		t.Fatalf("expected --rmi all, got %d %q", res.Code, got)
+	}
+
+	for _, payload := range []string{`{"projectDir":"/apps/web","removeImages":"none"}`, `{"projectDir":"/apps/web","removeImages":1}`} {
+		res = doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/down", payload, te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", payload, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestComposeApplyEnvWritesMergedSets(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, "")
+
+	base, err := sharedenv.CreateSet(te.app, "base", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	override, err := sharedenv.CreateSet(te.app, "override", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sharedenv.ImportVars(te.app, base.ID, []sharedenv.Var{{Key: "APP_ENV", Value: "staging"}, {Key: "REGION", Value: "eu west"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sharedenv.ImportVars(te.app, override.ID, []sharedenv.Var{{Key: "APP_ENV", Value: "prod"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("APP_ENV=staging\nOLD=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `{"projectDir":"` + dir + `","env_set_ids":["` + base.ID + `","` + override.ID + `"]}`
+	res := doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/apply-env", payload, te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if got, want := strings.Join(rec.args, " "), "compose -f "+dir+"/docker-compose.yml up -d"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	content, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(content); got != "APP_ENV=prod\nOLD=1\nREGION='eu west'\n" {
+		t.Fatalf("expected env set values on top of the existing .env, got %q", got)
+	}
+	if body := res.Body.String(); !strings.Contains(body, `"added":["REGION"]`) || !strings.Contains(body, `"changed":["APP_ENV"]`) || !strings.Contains(body, `"removed":[]`) {
+		t.Fatalf("unexpected diff: %s", body)
+	}
+	entries := auditEntriesByAction(t, te, "app.env_update")
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(entries))
+	}
+	if detail := entries[0].GetString("detail"); strings.Contains(detail, "prod") || !strings.Contains(detail, "APP_ENV") {
+		t.Fatalf("expected audit detail with keys only, got %s", detail)
+	}
+
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/apply-env", payload, te.token)
+	if body := res.Body.String(); res.Code != http.StatusOK || !strings.Contains(body, `"changed":[]`) || !strings.Contains(body, `"added":[]`) {
+		t.Fatalf("expected unchanged apply, got %d: %s", res.Code, body)
+	}
+
+	removePayload := `{"projectDir":"` + dir + `","env_set_ids":["` + base.ID + `","` + override.ID + `"],"remove_keys":["OLD"]}`
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/apply-env", removePayload, te.token)
+	if body := res.Body.String(); res.Code != http.StatusOK || !strings.Contains(body, `"removed":["OLD"]`) {
+		t.Fatalf("expected OLD removed on request, got %d: %s", res.Code, body)
+	}
+	if content, _ := os.ReadFile(envPath); string(content) != "APP_ENV=prod\nREGION='eu west'\n" {
+		t.Fatalf("unexpected .env content after remove_keys %q", content)
+	}
+
+	for _, payload := range []string{`{"projectDir":"` + dir + `"}`, `{"env_set_ids":["` + base.ID + `"]}`, `{"projectDir":"` + dir + `","env_set_ids":["missing"]}`} {
+		res = doDocker(t, te, http.MethodPost, "/api/ext/docker/compose/apply-env", payload, te.token)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", payload, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestDockerServersOfflineReasonReportsTimeouts(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	if err := sysconfig.SetGroup(te.app, "docker", "ssh", map[string]any{"dialTimeoutSeconds": 3, "commandTimeoutSeconds": 20, "retryDial": true}); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing listens on port 1, so the ping fails immediately.
+	createServerRecord(t, te, "unreachable", "127.0.0.1", 1, "root", "password")
+
+	rec := doDocker(t, te, http.MethodGet, "/api/ext/docker/servers", "", te.token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected local + 1 server, got %v", entries)
+	}
+	reason, _ := entries[1]["reason"].(string)
+	if entries[1]["status"] != "offline" || !strings.Contains(reason, "(dial timeout 3s, command timeout 20s)") {
+		t.Fatalf("unexpected entry: %v", entries[1])
+	}
+}
+
+func TestDockerListsTypedJSONFormat(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, `{"ID":"sha256:abc","Repository":"nginx","Tag":"1.27","Labels":"tier=web"}`+"\n")
+	res := doDocker(t, te, http.MethodGet, "/api/ext/docker/images?format=json", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var images struct {
+		Output *string        `json:"output"`
+		Items  []docker.Image `json:"items"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &images); err != nil {
+		t.Fatal(err)
+	}
+	if images.Output != nil || len(images.Items) != 1 || images.Items[0].Labels["tier"] != "web" {
+		t.Fatalf("unexpected typed images: %s", res.Body.String())
+	}
+
+	rec.output = `[{"Name":"web","Status":"running(1)","ConfigFiles":"/srv/web/docker-compose.yml"}]`
+	res = doDocker(t, te, http.MethodGet, "/api/ext/docker/compose/ls?format=json", "", te.token)
+	var projects struct {
+		Items []docker.ComposeProject `json:"items"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &projects); err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != http.StatusOK || len(projects.Items) != 1 || projects.Items[0].Status != "running(1)" {
+		t.Fatalf("unexpected typed projects %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doDocker(t, te, http.MethodGet, "/api/ext/docker/compose/ls?format=table", "", te.token)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported format, got %d", res.Code)
+	}
+}
+
+func TestContainerInspectReturnsStructuredDetails(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := useRecordingDocker(t, `[{"Id":"abc123","Name":"/web-1","Config":{"Image":"nginx:1.27"},"HostConfig":{"RestartPolicy":{"Name":"always"}}}]`)
+	res := doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web-1", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var body struct {
+		Output    string                  `json:"output"`
+		Inspect   map[string]any          `json:"inspect"`
+		Container docker.ContainerDetails `json:"container"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Output == "" || body.Inspect["Id"] != "abc123" {
+		t.Fatalf("expected raw output and unwrapped inspect, got %s", res.Body.String())
+	}
+	if body.Container.Name != "web-1" || body.Container.RestartPolicy.Name != "always" {
+		t.Fatalf("unexpected container details %+v", body.Container)
+	}
+
+	rec.output = "[]"
+	res = doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/missing", "", te.token)
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", res.Code, res.Body.String())
+	}
+
+	rec.output = "WARNING: unexpected plugin output\n[{}]"
+	res = doDocker(t, te, http.MethodGet, "/api/ext/docker/containers/web-1", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected unparsable output to still return 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var rawOnly map[string]any
+	if err := json.Unmarshal(res.Body.Bytes(), &rawOnly); err != nil {
+		t.Fatal(err)
+	}
+	if rawOnly["output"] != rec.output || rawOnly["container"] != nil || rawOnly["inspect"] != nil {
+		t.Fatalf("expected only the raw output, got %s", res.Body.String())
+	}
+}
+
+func TestImageScanSummarizesTrivyAndEndpointReports(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	report := `{"ArtifactName":"nginx:1.27","Results":[{"Target":"debian","Vulnerabilities":[
+		{"VulnerabilityID":"CVE-2024-0001","PkgName":"zlib","Severity":"HIGH"},
+		{"VulnerabilityID":"CVE-2024-0002","PkgName":"openssl","Severity":"CRITICAL"},
+		{"VulnerabilityID":"CVE-2024-0003","PkgName":"libc6","Severity":"MEDIUM"}]}]}`
+	rec := useRecordingDocker(t, report)
+
+	res := doDocker(t, te, http.MethodPost, "/api/ext/docker/images/nginx:1.27/scan", "", te.token)
+	if res.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while scanning is disabled, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if err := sysconfig.SetGroup(te.app, "docker", "scan", map[string]any{"enabled": true, "scanner": "trivy", "timeoutSeconds": 60, "topLimit": 2}); err != nil {
+		t.Fatal(err)
+	}
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/images/nginx:1.27/scan", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if got := strings.Join(rec.args, " "); got != "image --format json --quiet --scanners vuln -- nginx:1.27" {
+		t.Fatalf("unexpected trivy args %q", got)
+	}
+	rec.args = nil
+	if bad := doDocker(t, te, http.MethodPost, "/api/ext/docker/images/--output=%2Ftmp%2Fx/scan", "", te.token); bad.Code != http.StatusBadRequest || rec.args != nil {
+		t.Fatalf("expected a flag-like id to be rejected before trivy runs, got %d: %s (args %q)", bad.Code, bad.Body.String(), rec.args)
+	}
+	var body struct {
+		Scanner string             `json:"scanner"`
+		Summary docker.ScanSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	s := body.Summary
+	if body.Scanner != "trivy" || s.Critical != 1 || s.High != 1 || s.Medium != 1 || len(s.Top) != 2 || s.Top[0].VulnerabilityID != "CVE-2024-0002" {
+		t.Fatalf("unexpected scan response %+v", body)
+	}
+	entries := auditEntriesByAction(t, te, "docker.image.scan")
+	if len(entries) != 1 || entries[0].GetString("status") != "success" || entries[0].GetString("resource_id") != "nginx:1.27" {
+		t.Fatalf("expected one successful scan audit entry, got %d", len(entries))
+	}
+
+	var gotAuth, gotImage string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		gotImage = req["image"]
+		_, _ = io.WriteString(w, report)
+	}))
+	defer upstream.Close()
+	if err := sysconfig.SetGroup(te.app, "docker", "scan", map[string]any{"enabled": true, "scanner": "endpoint", "endpoint": upstream.URL, "endpointToken": "s3cret", "timeoutSeconds": 60, "topLimit": 10}); err != nil {
+		t.Fatal(err)
+	}
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/images/nginx:1.27/scan", "", te.token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 from endpoint scanner, got %d: %s", res.Code, res.Body.String())
+	}
+	if gotAuth != "Bearer s3cret" || gotImage != "nginx:1.27" {
+		t.Fatalf("unexpected endpoint request auth=%q image=%q", gotAuth, gotImage)
+	}
+
+	rec.output = "FATAL unable to find the specified image"
+	if err := sysconfig.SetGroup(te.app, "docker", "scan", map[string]any{"enabled": true, "scanner": "trivy"}); err != nil {
+		t.Fatal(err)
+	}
+	res = doDocker(t, te, http.MethodPost, "/api/ext/docker/images/missing/scan", "", te.token)
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for unparseable scanner output, got %d: %s", res.Code, res.Body.String())
+	}
+	failed := 0
+	for _, entry := range auditEntriesByAction(t, te, "docker.image.scan") {
+		if entry.GetString("status") == "failed" {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("expected one failed scan audit entry, got %d", failed)
+	}
+}
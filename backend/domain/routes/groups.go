@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/groups"
+)
+
+// registerGroupRoutes registers cross-type group read routes.
+// Group and group_items CRUD stays on the PocketBase native records API.
+//
+//	/api/ext/groups/{id}/resources
+func registerGroupRoutes(g *router.RouterGroup[*core.RequestEvent]) {
+	gr := g.Group("/groups")
+	gr.GET("/{id}/resources", handleGroupResources)
+}
+
+// handleGroupResources returns one page of a group's members resolved across all object types.
+//
+// @Summary List group resources
+// @Description Returns the group's members across all supported object types with server-side type filtering, name/description search, pagination, and per-type totals.
+// @Tags Groups
+// @Security BearerAuth
+// @Param id path string true "group ID"
+// @Param types query string false "comma-separated type filter, e.g. servers,secrets"
+// @Param q query string false "search across name and description"
+// @Param limit query integer false "page size (default 50, max 200)"
+// @Param offset query integer false "number of items to skip (default 0)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/groups/{id}/resources [get]
+func handleGroupResources(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	if _, err := e.App.FindRecordById(groups.Collection, id); err != nil {
+		return e.NotFoundError("group not found", err)
+	}
+
+	query, err := resourcePageQuery(e)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	page, err := groups.ListResources(e.App, id, query)
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "failed to list group resources", err)
+	}
+	return e.JSON(http.StatusOK, page)
+}
+
+func resourcePageQuery(e *core.RequestEvent) (groups.ResourceQuery, error) {
+	q := e.Request.URL.Query()
+
+	limit := 50
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return groups.ResourceQuery{}, fmt.Errorf("invalid limit; must be a positive integer")
+		}
+		if parsed > 200 {
+			parsed = 200
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if raw := strings.TrimSpace(q.Get("offset")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return groups.ResourceQuery{}, fmt.Errorf("invalid offset; must be a non-negative integer")
+		}
+		offset = parsed
+	}
+
+	var types []groups.ResourceType
+	if raw := strings.TrimSpace(q.Get("types")); raw != "" {
+		seen := map[string]bool{}
+		for _, token := range strings.Split(raw, ",") {
+			if strings.TrimSpace(token) == "" {
+				continue
+			}
+			rt, ok := groups.LookupResourceType(token)
+			if !ok {
+				return groups.ResourceQuery{}, fmt.Errorf("invalid types; unknown type %q", strings.TrimSpace(token))
+			}
+			if seen[rt.Key] {
+				continue
+			}
+			seen[rt.Key] = true
+			types = append(types, rt)
+		}
+	}
+
+	return groups.ResourceQuery{
+		Types:  types,
+		Search: strings.TrimSpace(q.Get("q")),
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
@@ -0,0 +1,266 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/groups"
+)
+
+func (te *testEnv) doGroups(t *testing.T, method, url string, authenticated bool) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(apis.RequireAuth())
+	registerGroupRoutes(g)
+
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, url, strings.NewReader(""))
+	if authenticated {
+		req.Header.Set("Authorization", te.token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func seedGroupWithScripts(t *testing.T, te *testEnv, names ...string) string {
+	t.Helper()
+
+	groupsCol, err := te.app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := core.NewRecord(groupsCol)
+	group.Set("name", "ops")
+	if err := te.app.Save(group); err != nil {
+		t.Fatal(err)
+	}
+
+	scriptsCol, err := te.app.FindCollectionByNameOrId("scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemsCol, err := te.app.FindCollectionByNameOrId("group_items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		script := core.NewRecord(scriptsCol)
+		script.Set("name", name)
+		script.Set("language", "bash")
+		script.Set("code", "echo "+name)
+		script.Set("description", "script "+name)
+		if err := te.app.Save(script); err != nil {
+			t.Fatal(err)
+		}
+		item := core.NewRecord(itemsCol)
+		item.Set("group_id", group.Id)
+		item.Set("object_type", "script")
+		item.Set("object_id", script.Id)
+		if err := te.app.Save(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return group.Id
+}
+
+func TestGroupResourcesRequiresAuth(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.doGroups(t, http.MethodGet, "/api/ext/groups/any/resources", false)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGroupResourcesPaginatesFiltersAndCounts(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	groupID := seedGroupWithScripts(t, te, "backup", "cleanup", "deploy")
+
+	rec := te.doGroups(t, http.MethodGet, "/api/ext/groups/"+groupID+"/resources?types=scripts&limit=2&offset=1", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var page struct {
+		Items []struct {
+			ObjectType string `json:"object_type"`
+			Name       string `json:"name"`
+		} `json:"items"`
+		Counts map[string]int `json:"counts"`
+		Total  int            `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || page.Counts["scripts"] != 3 {
+		t.Fatalf("expected 3 scripts in total, got total=%d counts=%v", page.Total, page.Counts)
+	}
+	if _, ok := page.Counts["servers"]; ok {
+		t.Fatalf("expected counts to be limited to the requested types, got %v", page.Counts)
+	}
+	if len(page.Items) != 2 || page.Items[0].Name != "cleanup" || page.Items[1].Name != "deploy" {
+		t.Fatalf("unexpected page items: %+v", page.Items)
+	}
+
+	rec = te.doGroups(t, http.MethodGet, "/api/ext/groups/"+groupID+"/resources?q=back", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].Name != "backup" {
+		t.Fatalf("expected search to match only backup, got %+v", page)
+	}
+}
+
+func TestGroupResourcesRejectsUnknownType(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	groupID := seedGroupWithScripts(t, te)
+
+	rec := te.doGroups(t, http.MethodGet, "/api/ext/groups/"+groupID+"/resources?types=servers,widgets", true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGroupResourcesUnknownGroupReturns404(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.doGroups(t, http.MethodGet, "/api/ext/groups/missing/resources", true)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func seedGroup(t *testing.T, te *testEnv, name string) string {
+	t.Helper()
+	col, err := te.app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := core.NewRecord(col)
+	group.Set("name", name)
+	if err := te.app.Save(group); err != nil {
+		t.Fatal(err)
+	}
+	return group.Id
+}
+
+func groupMemberIDs(t *testing.T, te *testEnv, objectType, objectID string) []string {
+	t.Helper()
+	items, err := te.app.FindRecordsByFilter("group_items", "object_type = {:type} && object_id = {:id}", "group_id", 0, 0,
+		map[string]any{"type": objectType, "id": objectID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.GetString("group_id"))
+	}
+	return ids
+}
+
+func TestResourceCreateAssignsGroups(t *testing.T) {
+	ensureConnectorSecretRuntime(t)
+	te := newTestEnv(t)
+	defer te.cleanup()
+	groupA := seedGroup(t, te, "edge")
+	groupB := seedGroup(t, te, "prod")
+
+	rec := te.do(t, http.MethodPost, "/api/connectors",
+		`{"name":"hook","kind":"webhook","template_id":"generic-webhook","endpoint":"https://hooks.example.com/deploy","groups":["`+groupA+`","`+groupB+`","`+groupA+`"]}`,
+		true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if got := groupMemberIDs(t, te, "connector", created.ID); len(got) != 2 {
+		t.Fatalf("expected connector in 2 groups, got %v", got)
+	}
+
+	rec = te.do(t, http.MethodPost, "/api/ext/resources/scripts",
+		`{"name":"cleanup","language":"bash","code":"echo ok","groups":["`+groupB+`"]}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if got := groupMemberIDs(t, te, "script", created.ID); len(got) != 1 || got[0] != groupB {
+		t.Fatalf("expected script in group %s, got %v", groupB, got)
+	}
+}
+
+func TestResourceCreateRejectsUnknownGroups(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+	groupA := seedGroup(t, te, "edge")
+
+	rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts",
+		`{"name":"cleanup","language":"bash","code":"echo ok","groups":["`+groupA+`","missing-group"]}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"groups"`) || !strings.Contains(body, "missing-group") {
+		t.Fatalf("expected groups field error naming the unknown group, got %s", body)
+	}
+	if n, _ := te.app.CountRecords("scripts"); n != 0 {
+		t.Fatalf("expected no script to be created, got %d", n)
+	}
+}
+
+func TestRecordAPICreateAssignsGroups(t *testing.T) {
+	te := newSecretsTestEnv(t)
+	defer te.cleanup()
+	groups.RegisterHooks(te.app)
+	groupA := seedGroup(t, te, "edge")
+
+	rec := te.createServerRecordViaAPI(t, `{"name":"web-1","host":"10.0.0.5","port":22,"user":"root","groups":["missing-group"]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "validation_unknown_groups") {
+		t.Fatalf("expected groups field error, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if n, _ := te.app.CountRecords("servers"); n != 0 {
+		t.Fatalf("expected no server to be created, got %d", n)
+	}
+
+	rec = te.createServerRecordViaAPI(t, `{"name":"web-1","host":"10.0.0.5","port":22,"user":"root","groups":["`+groupA+`"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if got := groupMemberIDs(t, te, "server", created.ID); len(got) != 1 || got[0] != groupA {
+		t.Fatalf("expected server in group %s, got %v", groupA, got)
+	}
+}
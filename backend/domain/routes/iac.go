@@ -8,8 +8,10 @@ package routes
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -19,27 +21,38 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
-	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
 	"github.com/websoft9/appos/backend/infra/fileutil"
 )
 
-const (
-	libraryBasePath     = "/appos/library"
-	filesAllowedArchive = ".zip"
-)
+const filesAllowedArchive = ".zip"
 
 var (
 	filesBasePath       = "/appos/data"
+	libraryBasePath     = "/appos/library"
 	filesAllowedRoots   = []string{"apps", "workflows", "templates"}
 	libraryAllowedRoots = []string{"apps"}
-	defaultFileSettings = settingscatalog.DefaultGroup("files", "limits")
 )
 
 func loadIacFileLimits(app core.App) map[string]any {
-	limits, _ := sysconfig.GetGroup(app, "files", "limits", defaultFileSettings)
+	limits, _ := sysconfig.GetGroup(app, "files", "limits", nil)
 	return limits
 }
 
+// iacContentBodyLimit sizes JSON bodies carrying file content to the IaC max
+// file size, doubled to allow for JSON escaping.
+func iacContentBodyLimit(app core.App) int64 {
+	cfg := loadIacFileLimits(app)
+	return 2*int64(sysconfig.Int(cfg, "maxSizeMB", 10))<<20 + bodyLimitOverhead
+}
+
+// iacUploadBodyLimit sizes multipart uploads to the larger of the IaC file and
+// ZIP limits.
+func iacUploadBodyLimit(app core.App) int64 {
+	cfg := loadIacFileLimits(app)
+	maxMB := max(sysconfig.Int(cfg, "maxSizeMB", 10), sysconfig.Int(cfg, "maxZipSizeMB", 50))
+	return int64(maxMB)<<20 + bodyLimitOverhead
+}
+
 // registerIaCRoutes mounts /api/ext/iac with superuser-only access.
 func registerIaCRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	iac := g.Group("/iac")
@@ -48,19 +61,22 @@ func registerIaCRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	// Story 14.1
 	iac.GET("", handleFileList)
 	iac.GET("/content", handleFileRead)
+	iac.GET("/tail", handleFileTail)
 
 	// Story 14.2
-	iac.POST("", handleFileCreate)
-	iac.PUT("/content", handleFileUpdate)
+	iac.POST("", handleFileCreate).Bind(bodyLimit(iacContentBodyLimit))
+	iac.PUT("/content", handleFileUpdate).Bind(bodyLimit(iacContentBodyLimit))
 	iac.DELETE("", handleFileDelete)
 	iac.POST("/move", handleFileMove)
-	iac.POST("/upload", handleFileUpload)
+	iac.POST("/copy", handleFileCopy)
+	iac.POST("/upload", handleFileUpload).Bind(bodyLimit(iacUploadBodyLimit))
 	iac.GET("/download", handleFileDownload)
 
 	// Story 5.5: Read-only access to /appos/library/apps/ for custom-app template pre-fill.
 	iac.GET("/library", handleLibraryList)
 	iac.GET("/library/content", handleLibraryRead)
 	iac.POST("/library/copy", handleLibraryCopy)
+	iac.GET("/library/updates", handleLibraryUpdates)
 }
 
 // ─── GET /api/ext/iac?path=<rel> ────────────────────────────────────────────
@@ -158,11 +174,14 @@ type contentResponse struct {
 // handleFileRead reads the text content of a single IaC file.
 //
 // @Summary Read IaC file content
-// @Description Returns the UTF-8 text content of a file under /appos/data. Binary files are rejected. Superuser only.
+// @Description Returns the UTF-8 text content of a file under /appos/data. Binary files are rejected. Responses carry a content-hash ETag and Last-Modified; a matching If-None-Match or If-Modified-Since gets 304. Superuser only.
 // @Tags IaC
 // @Security BearerAuth
 // @Param path query string true "relative file path (e.g. apps/myapp/docker-compose.yml)"
+// @Param If-None-Match header string false "ETag from a previous read"
+// @Param If-Modified-Since header string false "Last-Modified from a previous read"
 // @Success 200 {object} contentResponse
+// @Success 304 "not modified"
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 404 {object} map[string]any
@@ -208,6 +227,10 @@ func handleFileRead(e *core.RequestEvent) error {
 			"binary files are not supported", nil)
 	}
 
+	if done, err := writeNotModified(e, contentETag(data), info.ModTime()); done {
+		return err
+	}
+
 	return e.JSON(http.StatusOK, contentResponse{
 		Path:       rel,
 		Content:    string(data),
@@ -229,9 +252,10 @@ type createRequest struct {
 // handleFileCreate creates a new file or directory under an IaC root.
 //
 // @Summary Create IaC file or directory
-// @Description Creates a file (with optional initial content) or an empty directory. Superuser only.
+// @Description Creates a file (with optional initial content) or an empty directory. When the path already exists the request fails with 409, unless ifExists=suffix, which creates the next free name ("file (1).yml") instead. The response path is the one actually used. Superuser only.
 // @Tags IaC
 // @Security BearerAuth
+// @Param ifExists query string false "collision mode: omit to fail with 409, or suffix to auto-rename"
 // @Param body body createRequest true "path, type (file|dir), content (optional)"
 // @Success 201 {object} map[string]any
 // @Failure 400 {object} map[string]any
@@ -244,13 +268,26 @@ func handleFileCreate(e *core.RequestEvent) error {
 		return apis.NewBadRequestError("invalid request body", err)
 	}
 
+	ifExists := e.Request.URL.Query().Get("ifExists")
+	if ifExists != "" && ifExists != "suffix" {
+		return apis.NewBadRequestError("invalid ifExists; expected suffix", nil)
+	}
+
 	abs, err := fileutil.ResolveSafePath(filesBasePath, req.Path, filesAllowedRoots)
 	if err != nil {
 		return apis.NewBadRequestError("invalid path", err)
 	}
 
 	if _, err := os.Stat(abs); err == nil {
-		return apis.NewApiError(http.StatusConflict, "path already exists", nil)
+		if ifExists != "suffix" {
+			return apis.NewApiError(http.StatusConflict, "path already exists", nil)
+		}
+		next, ok := nextAvailableName(abs, req.Type == "dir")
+		if !ok {
+			return apis.NewApiError(http.StatusConflict, "no free name available", nil)
+		}
+		abs = next
+		req.Path = path.Join(path.Dir(path.Clean(filepath.ToSlash(req.Path))), filepath.Base(next))
 	}
 
 	if req.Type == "dir" {
@@ -276,6 +313,28 @@ func handleFileCreate(e *core.RequestEvent) error {
 	})
 }
 
+// maxNameSuffix bounds the " (n)" search of nextAvailableName.
+const maxNameSuffix = 999
+
+// nextAvailableName returns the first free sibling of abs named
+// "name (n).ext" (or "name (n)" for directories and extension-less files).
+func nextAvailableName(abs string, isDir bool) (string, bool) {
+	dir, base := filepath.Split(abs)
+	stem, ext := base, ""
+	if !isDir {
+		if e := filepath.Ext(base); e != "" && e != base {
+			stem, ext = strings.TrimSuffix(base, e), e
+		}
+	}
+	for n := 1; n <= maxNameSuffix; n++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s (%d)%s", stem, n, ext))
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
 // ─── PUT /api/ext/iac/content ───────────────────────────────────────────────
 // Body: {"path":"apps/myapp/docker-compose.yml","content":"..."}
 
@@ -442,6 +501,95 @@ func handleFileMove(e *core.RequestEvent) error {
 	})
 }
 
+// ─── POST /api/ext/iac/copy ─────────────────────────────────────────────────
+// Body: {"from":"apps/a","to":"apps/a-copy"}
+
+// handleFileCopy duplicates a file or directory within the IaC workspace.
+//
+// @Summary Copy IaC path
+// @Description Copies a file or directory tree to a new path within the same root under /appos/data. The destination must not exist, and a directory cannot be copied into itself. Sources containing symlinks are rejected. Superuser only.
+// @Tags IaC
+// @Security BearerAuth
+// @Param body body moveRequest true "from, to (relative paths)"
+// @Success 201 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any "destination already exists"
+// @Router /api/ext/iac/copy [post]
+func handleFileCopy(e *core.RequestEvent) error {
+	var req moveRequest
+	if err := e.BindBody(&req); err != nil {
+		return apis.NewBadRequestError("invalid request body", err)
+	}
+
+	fromAbs, err := fileutil.ResolveSafePath(filesBasePath, req.From, filesAllowedRoots)
+	if err != nil {
+		return apis.NewBadRequestError("invalid 'from' path", err)
+	}
+	toAbs, err := fileutil.ResolveSafePath(filesBasePath, req.To, filesAllowedRoots)
+	if err != nil {
+		return apis.NewBadRequestError("invalid 'to' path", err)
+	}
+
+	// Disallow cross-root copies (e.g. apps/ → workflows/).
+	if rootOf(req.From) != rootOf(req.To) {
+		return apis.NewBadRequestError("cross-root copies are not allowed", nil)
+	}
+
+	info, err := os.Stat(fromAbs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return apis.NewNotFoundError("source path not found", nil)
+		}
+		return apis.NewBadRequestError("cannot stat source", err)
+	}
+	if _, err := os.Lstat(toAbs); err == nil {
+		return apis.NewApiError(http.StatusConflict, "destination already exists", nil)
+	}
+
+	if info.IsDir() {
+		if toAbs == fromAbs || strings.HasPrefix(toAbs, fromAbs+string(filepath.Separator)) {
+			return apis.NewBadRequestError("cannot copy a directory into itself", nil)
+		}
+		if hasSymlink(fromAbs) {
+			return apis.NewBadRequestError("source contains symlinks", nil)
+		}
+		if err := fileutil.CopyDir(fromAbs, toAbs); err != nil {
+			return apis.NewBadRequestError("cannot copy directory", err)
+		}
+	} else if err := fileutil.CopyFile(fromAbs, toAbs); err != nil {
+		return apis.NewBadRequestError("cannot copy file", err)
+	}
+
+	entryType := "file"
+	if info.IsDir() {
+		entryType = "dir"
+	}
+	return e.JSON(http.StatusCreated, map[string]string{
+		"from": req.From,
+		"to":   req.To,
+		"type": entryType,
+	})
+}
+
+// hasSymlink reports whether the tree rooted at dir contains a symlink,
+// which CopyDir would follow out of the workspace.
+func hasSymlink(dir string) bool {
+	found := false
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
 // ─── POST /api/ext/iac/upload ───────────────────────────────────────────────
 // multipart/form-data fields: file (file), path (string, target directory)
 
@@ -743,6 +891,10 @@ func handleLibraryRead(e *core.RequestEvent) error {
 			"binary files are not supported", nil)
 	}
 
+	if done, err := writeNotModified(e, contentETag(data), info.ModTime()); done {
+		return err
+	}
+
 	return e.JSON(http.StatusOK, contentResponse{
 		Path:       rel,
 		Content:    string(data),
@@ -754,11 +906,11 @@ func handleLibraryRead(e *core.RequestEvent) error {
 // handleLibraryCopy copies a library app template into the IaC workspace templates directory.
 //
 // @Summary Copy library app to workspace
-// @Description Copies /appos/library/apps/{sourceKey}/ to /appos/data/templates/apps/{destKey}/. Superuser only.
+// @Description Copies /appos/library/apps/{sourceKey}/ to /appos/data/templates/apps/{destKey}/ and records the source key and a content hash in .library-origin.json so GET /api/ext/iac/library/updates can report upstream changes. Superuser only.
 // @Tags IaC
 // @Security BearerAuth
 // @Param body body object true "sourceKey (library app name), destKey (optional, defaults to sourceKey)"
-// @Success 200 {object} map[string]any "source, destination"
+// @Success 200 {object} map[string]any "source, destination, hash"
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Failure 404 {object} map[string]any
@@ -792,19 +944,31 @@ func handleLibraryCopy(e *core.RequestEvent) error {
 	}
 
 	// Destination under data/templates/apps/{destKey}.
-	dstRel := "templates/apps/" + req.DestKey
+	dstRel := libraryTemplatesRel + "/" + req.DestKey
 	dstAbs, err := fileutil.ResolveSafePath(filesBasePath, dstRel, filesAllowedRoots)
 	if err != nil {
 		return apis.NewBadRequestError("invalid destination", err)
 	}
 
+	hash, err := libraryContentHash(srcAbs)
+	if err != nil {
+		return apis.NewBadRequestError("failed to hash library app", err)
+	}
+
 	// Copy directory tree.
 	if err := fileutil.CopyDir(srcAbs, dstAbs); err != nil {
 		return apis.NewBadRequestError("failed to copy library app", err)
 	}
 
+	// Record provenance so library updates can be detected later.
+	origin := libraryOrigin{SourceKey: req.SourceKey, Hash: hash, CopiedAt: time.Now().UTC()}
+	if err := writeLibraryOrigin(dstAbs, origin); err != nil {
+		return apis.NewBadRequestError("failed to record library origin", err)
+	}
+
 	return e.JSON(http.StatusOK, map[string]string{
 		"source":      srcRel,
 		"destination": dstRel,
+		"hash":        hash,
 	})
 }
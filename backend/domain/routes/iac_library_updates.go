@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/infra/fileutil"
+)
+
+// libraryOriginFile is written into a template copied from the library and
+// records where it came from. It is excluded from the content hashes.
+const libraryOriginFile = ".library-origin.json"
+
+// libraryTemplatesRel is where handleLibraryCopy places library copies.
+const libraryTemplatesRel = "templates/apps"
+
+// libraryOrigin links a workspace template back to its library source.
+type libraryOrigin struct {
+	SourceKey string    `json:"sourceKey"`
+	Hash      string    `json:"hash"`
+	CopiedAt  time.Time `json:"copiedAt"`
+}
+
+// libraryUpdate reports how a library copy relates to its current source.
+type libraryUpdate struct {
+	DestKey         string    `json:"destKey"`
+	Path            string    `json:"path"`
+	SourceKey       string    `json:"sourceKey"`
+	CopiedAt        time.Time `json:"copiedAt"`
+	CopiedHash      string    `json:"copiedHash"`
+	LibraryHash     string    `json:"libraryHash,omitempty"`
+	UpdateAvailable bool      `json:"updateAvailable"`
+	LocallyModified bool      `json:"locallyModified"`
+	SourceMissing   bool      `json:"sourceMissing,omitempty"`
+}
+
+func libraryContentHash(dir string) (string, error) {
+	return fileutil.HashDir(dir, func(rel string) bool { return rel == libraryOriginFile })
+}
+
+func writeLibraryOrigin(dir string, origin libraryOrigin) error {
+	data, err := json.MarshalIndent(origin, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, libraryOriginFile), append(data, '\n'), 0o644)
+}
+
+func readLibraryOrigin(dir string) (libraryOrigin, bool) {
+	var origin libraryOrigin
+	data, err := os.ReadFile(filepath.Join(dir, libraryOriginFile))
+	if err != nil || json.Unmarshal(data, &origin) != nil || origin.SourceKey == "" {
+		return libraryOrigin{}, false
+	}
+	return origin, true
+}
+
+// handleLibraryUpdates compares library copies with the current library.
+//
+// @Summary List library template updates
+// @Description Checks every template under /appos/data/templates/apps that was copied from the library and reports whether the library version changed since the copy (updateAvailable), whether the copy was edited (locallyModified), and whether the library app was removed (sourceMissing). Templates copied before provenance was recorded are not listed. Superuser only.
+// @Tags IaC
+// @Security BearerAuth
+// @Success 200 {object} map[string]any "items"
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/iac/library/updates [get]
+func handleLibraryUpdates(e *core.RequestEvent) error {
+	templatesAbs := filepath.Join(filesBasePath, filepath.FromSlash(libraryTemplatesRel))
+	entries, err := os.ReadDir(templatesAbs)
+	if err != nil && !os.IsNotExist(err) {
+		return apis.NewApiError(http.StatusInternalServerError, "cannot read templates directory", err)
+	}
+
+	items := make([]libraryUpdate, 0)
+	libraryHashes := map[string]string{}
+	for _, de := range entries {
+		if !de.IsDir() {
+			continue
+		}
+		dir := filepath.Join(templatesAbs, de.Name())
+		origin, ok := readLibraryOrigin(dir)
+		if !ok {
+			continue
+		}
+		item := libraryUpdate{
+			DestKey:    de.Name(),
+			Path:       libraryTemplatesRel + "/" + de.Name(),
+			SourceKey:  origin.SourceKey,
+			CopiedAt:   origin.CopiedAt,
+			CopiedHash: origin.Hash,
+		}
+		if local, err := libraryContentHash(dir); err == nil {
+			item.LocallyModified = local != origin.Hash
+		}
+
+		libHash, seen := libraryHashes[origin.SourceKey]
+		if !seen {
+			if srcAbs, err := fileutil.ResolveSafePath(libraryBasePath, "apps/"+origin.SourceKey, libraryAllowedRoots); err == nil {
+				if info, err := os.Stat(srcAbs); err == nil && info.IsDir() {
+					libHash, _ = libraryContentHash(srcAbs)
+				}
+			}
+			libraryHashes[origin.SourceKey] = libHash
+		}
+		item.LibraryHash = libHash
+		item.SourceMissing = libHash == ""
+		item.UpdateAvailable = libHash != "" && libHash != origin.Hash
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].DestKey < items[j].DestKey })
+	return e.JSON(http.StatusOK, map[string]any{"items": items})
+}
@@ -0,0 +1,254 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/terminal"
+	"github.com/websoft9/appos/backend/infra/fileutil"
+)
+
+// ─── GET /api/ext/iac/tail?path=<rel>&lines=<n> ─────────────────────────────
+
+const (
+	iacTailDefaultLines = 100
+	iacTailMaxLines     = 1000
+	// iacTailBacklogBytes bounds how far back the initial lines are searched.
+	iacTailBacklogBytes = 256 * 1024
+	// iacTailMaxChunk bounds a single poll read so a burst of writes cannot
+	// stall the stream.
+	iacTailMaxChunk = 1024 * 1024
+)
+
+var (
+	iacTailPollInterval = time.Second
+	iacTailHeartbeat    = 15 * time.Second
+)
+
+// handleFileTail streams lines appended to an IaC file as Server-Sent Events.
+//
+// @Summary Tail IaC file
+// @Description Streams a text file under /appos/data like `tail -f`: a "lines" event with the last N lines is sent first, then further "lines" events as the file grows. A "truncated" event is sent when the file shrinks or is replaced (rotation) and the tail restarts from its beginning; "error" ends the stream. The file is polled once per second. Binary files are rejected. Superuser only.
+// @Tags IaC
+// @Security BearerAuth
+// @Param path query string true "relative file path (e.g. apps/myapp/logs/app.log)"
+// @Param lines query integer false "initial lines to send (default 100, max 1000)"
+// @Success 200 {string} string "SSE stream (text/event-stream)"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 415 {object} map[string]any
+// @Router /api/ext/iac/tail [get]
+func handleFileTail(e *core.RequestEvent) error {
+	rel := e.Request.URL.Query().Get("path")
+	lines := iacTailDefaultLines
+	if raw := strings.TrimSpace(e.Request.URL.Query().Get("lines")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return apis.NewBadRequestError("invalid lines; must be a non-negative integer", nil)
+		}
+		lines = min(parsed, iacTailMaxLines)
+	}
+
+	abs, err := fileutil.ResolveSafePath(filesBasePath, rel, filesAllowedRoots)
+	if err != nil {
+		return apis.NewBadRequestError("invalid path", err)
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return apis.NewNotFoundError("file not found", nil)
+		}
+		return apis.NewBadRequestError("cannot open file", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return apis.NewBadRequestError("cannot stat file", err)
+	}
+	if info.IsDir() {
+		return apis.NewBadRequestError("path is a directory", nil)
+	}
+	head := make([]byte, 512)
+	n, _ := f.ReadAt(head, 0)
+	if !isTextMIME(http.DetectContentType(head[:n])) {
+		return apis.NewApiError(http.StatusUnsupportedMediaType, "binary files are not supported", nil)
+	}
+
+	tailer := &fileTailer{path: abs, f: f}
+	defer tailer.close()
+	initial, err := tailer.start(lines)
+	if err != nil {
+		return apis.NewBadRequestError("cannot read file", err)
+	}
+
+	flusher, ok := e.Response.(http.Flusher)
+	if !ok {
+		return e.InternalServerError("streaming unsupported", nil)
+	}
+
+	var bytesOut atomic.Int64
+	ctx, untrack := trackStream(e, terminal.ConnectionMeta{
+		Type:     terminal.ConnectionFileTail,
+		Target:   rel,
+		BytesOut: &bytesOut,
+	}, nil)
+	defer untrack()
+
+	e.Response.Header().Set("Content-Type", "text/event-stream")
+	e.Response.Header().Set("Cache-Control", "no-cache")
+	e.Response.Header().Set("Connection", "keep-alive")
+
+	push := func(event string, payload any) {
+		b, _ := json.Marshal(payload)
+		n, _ := fmt.Fprintf(e.Response, "event: %s\ndata: %s\n\n", event, string(b))
+		bytesOut.Add(int64(n))
+		flusher.Flush()
+	}
+
+	push("lines", map[string]any{"path": rel, "lines": initial})
+
+	poll := time.NewTicker(iacTailPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(iacTailHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(e.Response, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-poll.C:
+			appended, truncated, err := tailer.poll()
+			if err != nil {
+				push("error", map[string]any{"message": err.Error()})
+				return nil
+			}
+			if truncated {
+				push("truncated", map[string]any{"path": rel})
+			}
+			if len(appended) > 0 {
+				push("lines", map[string]any{"path": rel, "lines": appended})
+			}
+		}
+	}
+}
+
+// fileTailer follows a growing file, emitting complete lines only. A
+// trailing partial line is held until its newline arrives. When the path is
+// replaced (rename-based rotation) the new file is followed from its start.
+type fileTailer struct {
+	path    string
+	f       *os.File
+	offset  int64
+	partial []byte
+}
+
+func (t *fileTailer) close() {
+	if t.f != nil {
+		_ = t.f.Close()
+	}
+}
+
+// start positions the tailer at the end of the file and returns up to n of
+// the last complete lines.
+func (t *fileTailer) start(n int) ([]string, error) {
+	info, err := t.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	from := max(size-iacTailBacklogBytes, 0)
+	buf := make([]byte, size-from)
+	if _, err := t.f.ReadAt(buf, from); err != nil && err != io.EOF {
+		return nil, err
+	}
+	t.offset = size
+
+	// Hold back an unterminated last line; it is completed by later polls.
+	if i := bytes.LastIndexByte(buf, '\n'); i < len(buf)-1 {
+		t.partial = append([]byte(nil), buf[i+1:]...)
+		buf = buf[:i+1]
+	}
+	// Drop a leading fragment when the backlog window cut a line in half.
+	if from > 0 {
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			buf = buf[i+1:]
+		}
+	}
+	all := splitLines(buf)
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return all, nil
+}
+
+// poll returns the complete lines appended since the last call. truncated
+// reports that the file shrank or was replaced, in which case reading
+// restarts at offset 0.
+func (t *fileTailer) poll() (lines []string, truncated bool, err error) {
+	info, err := t.f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if current, statErr := os.Stat(t.path); statErr == nil && !os.SameFile(info, current) {
+		f, openErr := os.Open(t.path)
+		if openErr != nil {
+			return nil, false, openErr
+		}
+		t.close()
+		t.f, info = f, current
+		t.offset = math.MaxInt64
+	}
+	size := info.Size()
+	if size < t.offset {
+		t.offset, t.partial, truncated = 0, nil, true
+	}
+	if size == t.offset {
+		return nil, truncated, nil
+	}
+
+	chunk := make([]byte, min(size-t.offset, iacTailMaxChunk))
+	read, err := t.f.ReadAt(chunk, t.offset)
+	if err != nil && err != io.EOF {
+		return nil, truncated, err
+	}
+	t.offset += int64(read)
+
+	data := append(t.partial, chunk[:read]...)
+	i := bytes.LastIndexByte(data, '\n')
+	t.partial = append([]byte(nil), data[i+1:]...)
+	lines = splitLines(data[:i+1])
+	// Emit an overlong unterminated line rather than buffering it forever.
+	if len(t.partial) >= iacTailMaxChunk {
+		lines = append(lines, string(t.partial))
+		t.partial = nil
+	}
+	return lines, truncated, nil
+}
+
+// splitLines splits newline-terminated data into lines without their
+// terminators (CRLF included).
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return []string{}
+	}
+	parts := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i, p := range parts {
+		parts[i] = strings.TrimSuffix(p, "\r")
+	}
+	return parts
+}
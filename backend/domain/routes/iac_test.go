@@ -0,0 +1,303 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+)
+
+func (te *testEnv) doIaC(t *testing.T, method, url, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	registerIaCRoutes(g)
+
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", te.token)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIaCCreateSuffixesOnCollision(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	previous := filesBasePath
+	filesBasePath = t.TempDir()
+	defer func() { filesBasePath = previous }()
+
+	body := `{"path":"apps/demo/docker-compose.yml","content":"services: {}"}`
+	if rec := te.doIaC(t, http.MethodPost, "/api/ext/iac", body); rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := te.doIaC(t, http.MethodPost, "/api/ext/iac", body); rec.Code != http.StatusConflict {
+		t.Fatalf("default collision: expected 409, got %d", rec.Code)
+	}
+
+	for _, want := range []string{"apps/demo/docker-compose (1).yml", "apps/demo/docker-compose (2).yml"} {
+		rec := te.doIaC(t, http.MethodPost, "/api/ext/iac?ifExists=suffix", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("suffix: expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["path"] != want {
+			t.Fatalf("expected path %q, got %q", want, resp["path"])
+		}
+		if _, err := os.Stat(filepath.Join(filesBasePath, want)); err != nil {
+			t.Fatalf("expected %s on disk: %v", want, err)
+		}
+	}
+
+	rec := te.doIaC(t, http.MethodPost, "/api/ext/iac?ifExists=suffix", `{"path":"apps/demo","type":"dir"}`)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"apps/demo (1)"`) {
+		t.Fatalf("dir suffix: expected apps/demo (1), got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := te.doIaC(t, http.MethodPost, "/api/ext/iac?ifExists=overwrite", body); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown mode: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestIaCCopyDuplicatesWithinRoot(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	previous := filesBasePath
+	filesBasePath = t.TempDir()
+	defer func() { filesBasePath = previous }()
+
+	src := filepath.Join(filesBasePath, "apps", "demo")
+	if err := os.MkdirAll(filepath.Join(src, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "conf", "app.env"), []byte("A=1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := te.doIaC(t, http.MethodPost, "/api/ext/iac/copy", `{"from":"apps/demo","to":"apps/demo-copy"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("copy dir: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(filesBasePath, "apps", "demo-copy", "conf", "app.env")); err != nil || string(data) != "A=1" {
+		t.Fatalf("expected copied file, got %q, %v", data, err)
+	}
+
+	cases := map[string]struct {
+		body string
+		code int
+	}{
+		"existing destination": {`{"from":"apps/demo","to":"apps/demo-copy"}`, http.StatusConflict},
+		"cross root":           {`{"from":"apps/demo","to":"workflows/demo"}`, http.StatusBadRequest},
+		"into itself":          {`{"from":"apps/demo","to":"apps/demo/nested"}`, http.StatusBadRequest},
+		"missing source":       {`{"from":"apps/nope","to":"apps/nope-copy"}`, http.StatusNotFound},
+		"escaping path":        {`{"from":"apps/demo","to":"apps/../../etc"}`, http.StatusBadRequest},
+	}
+	for name, tc := range cases {
+		if rec := te.doIaC(t, http.MethodPost, "/api/ext/iac/copy", tc.body); rec.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec = te.doIaC(t, http.MethodPost, "/api/ext/iac/copy", `{"from":"apps/demo/conf/app.env","to":"apps/other/app.env"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("copy file: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIaCReadHonorsConditionalHeaders(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	previous := filesBasePath
+	filesBasePath = t.TempDir()
+	defer func() { filesBasePath = previous }()
+
+	file := filepath.Join(filesBasePath, "apps", "demo", "docker-compose.yml")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("services: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	url := "/api/ext/iac/content?path=apps/demo/docker-compose.yml"
+
+	rec := te.doIaC(t, http.MethodGet, url, "")
+	etag, lastModified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	if rec.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("expected 200 with validators, got %d etag=%q last-modified=%q", rec.Code, etag, lastModified)
+	}
+
+	if rec := te.doIaC(t, http.MethodGet, url, "", "If-None-Match", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("If-None-Match: expected empty 304, got %d", rec.Code)
+	}
+	if rec := te.doIaC(t, http.MethodGet, url, "", "If-Modified-Since", lastModified); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since: expected 304, got %d", rec.Code)
+	}
+	// A stale ETag wins over a matching date.
+	if rec := te.doIaC(t, http.MethodGet, url, "", "If-None-Match", `"stale"`, "If-Modified-Since", lastModified); rec.Code != http.StatusOK {
+		t.Fatalf("stale ETag: expected 200, got %d", rec.Code)
+	}
+
+	if err := os.WriteFile(file, []byte("services: {web: {}}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if rec := te.doIaC(t, http.MethodGet, url, "", "If-None-Match", etag); rec.Code != http.StatusOK {
+		t.Fatalf("changed content: expected 200, got %d", rec.Code)
+	}
+}
+
+func TestFileTailerFollowsAppendsAndTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\npart"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tailer := &fileTailer{path: path, f: f}
+	defer tailer.close()
+
+	initial, err := tailer.start(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(initial, ",") != "two,three" {
+		t.Fatalf("expected last two complete lines, got %q", initial)
+	}
+
+	appendFile := func(s string) {
+		w, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		if _, err := w.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendFile("ial\nfour\r\n")
+	lines, truncated, err := tailer.poll()
+	if err != nil || truncated || strings.Join(lines, ",") != "partial,four" {
+		t.Fatalf("unexpected poll result %q truncated=%v err=%v", lines, truncated, err)
+	}
+
+	if err := os.WriteFile(path, []byte("fresh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines, truncated, err = tailer.poll()
+	if err != nil || !truncated || strings.Join(lines, ",") != "fresh" {
+		t.Fatalf("expected truncation restart, got %q truncated=%v err=%v", lines, truncated, err)
+	}
+}
+
+func TestIaCTailRejectsBinaryFiles(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	previous := filesBasePath
+	filesBasePath = t.TempDir()
+	defer func() { filesBasePath = previous }()
+
+	dir := filepath.Join(filesBasePath, "apps", "demo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "blob.bin"), []byte{0x00, 0x01, 0x02, 0xff}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := te.doIaC(t, http.MethodGet, "/api/ext/iac/tail?path=apps/demo/blob.bin", "")
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = te.doIaC(t, http.MethodGet, "/api/ext/iac/tail?path=apps/demo/blob.bin&lines=-1", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for negative lines, got %d", rec.Code)
+	}
+}
+
+func TestIaCLibraryUpdatesDetectsUpstreamChanges(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	previous, previousLibrary := filesBasePath, libraryBasePath
+	filesBasePath, libraryBasePath = t.TempDir(), t.TempDir()
+	defer func() { filesBasePath, libraryBasePath = previous, previousLibrary }()
+
+	src := filepath.Join(libraryBasePath, "apps", "wordpress")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "docker-compose.yml"), []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := te.doIaC(t, http.MethodPost, "/api/ext/iac/library/copy", `{"sourceKey":"wordpress","destKey":"blog"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("copy: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	updates := func() []libraryUpdate {
+		t.Helper()
+		rec := te.doIaC(t, http.MethodGet, "/api/ext/iac/library/updates", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("updates: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Items []libraryUpdate `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Items
+	}
+
+	items := updates()
+	if len(items) != 1 || items[0].DestKey != "blog" || items[0].SourceKey != "wordpress" ||
+		items[0].UpdateAvailable || items[0].LocallyModified {
+		t.Fatalf("expected one up-to-date copy, got %+v", items)
+	}
+
+	if err := os.WriteFile(filepath.Join(src, "docker-compose.yml"), []byte("services:\n  web: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(filesBasePath, "templates", "apps", "blog")
+	if err := os.WriteFile(filepath.Join(dst, ".env"), []byte("PORT=8080\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	items = updates()
+	if len(items) != 1 || !items[0].UpdateAvailable || !items[0].LocallyModified {
+		t.Fatalf("expected upstream and local changes, got %+v", items)
+	}
+
+	if err := os.RemoveAll(src); err != nil {
+		t.Fatal(err)
+	}
+	if items = updates(); len(items) != 1 || !items[0].SourceMissing || items[0].UpdateAvailable {
+		t.Fatalf("expected missing source, got %+v", items)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/groups"
 	monitorchecks "github.com/websoft9/appos/backend/domain/monitor/signals/checks"
 	"github.com/websoft9/appos/backend/domain/resource/accounts"
 	"github.com/websoft9/appos/backend/domain/resource/instances"
@@ -192,7 +193,7 @@ func handleInstanceGet(e *core.RequestEvent) error {
 }
 
 // @Summary Create instance
-// @Description Creates a service instance. Superuser only.
+// @Description Creates a service instance. An optional groups array of group IDs assigns the new instance to those groups in the same step; unknown groups are rejected with a field error. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param body body instanceUpsertRequest true "instance payload"
@@ -207,11 +208,23 @@ func handleInstanceCreate(e *core.RequestEvent) error {
 	if err != nil {
 		return err
 	}
+	groupIDs, err := bindCreateGroups(e)
+	if err != nil {
+		return err
+	}
 	userID, _ := authInfo(e)
-	item, saveErr := instances.CreateWithDeps(persistence.NewInstanceRepository(e.App), input, instances.SaveDeps{
-		ActorID:                     userID,
-		CredentialRefValidator:      instanceCredentialValidator{app: e.App},
-		ProviderAccountRefValidator: instanceAccountValidator{app: e.App},
+	var item *instances.Instance
+	saveErr := createInGroups(e.App, groups.ObjectTypeInstance, groupIDs, func(app core.App) (string, error) {
+		created, err := instances.CreateWithDeps(persistence.NewInstanceRepository(app), input, instances.SaveDeps{
+			ActorID:                     userID,
+			CredentialRefValidator:      instanceCredentialValidator{app: e.App},
+			ProviderAccountRefValidator: instanceAccountValidator{app: e.App},
+		})
+		if err != nil {
+			return "", err
+		}
+		item = created
+		return created.ID(), nil
 	})
 	if saveErr != nil {
 		writeInstanceAudit(e, "instance.create", nil, input, nil, saveErr)
@@ -165,6 +165,10 @@ func releaseResponse(record *core.Record) map[string]any {
 	if value := record.GetDateTime("superseded_at"); !value.IsZero() {
 		result["superseded_at"] = value.String()
 	}
+	if value := record.GetDateTime("image_digests_at"); !value.IsZero() {
+		result["image_digests"] = record.Get("image_digests")
+		result["image_digests_at"] = value.String()
+	}
 	return result
 }
 
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/infra/docker"
 )
 
 func (te *testEnv) doLifecycleResources(t *testing.T, method, url, body string, authenticated bool) *httptest.ResponseRecorder {
@@ -283,3 +285,81 @@ func TestReleaseRoutesExposeSourceBuildCandidateAndPromotedArtifactInfo(t *testi
 		t.Fatalf("unexpected current release source_ref: %v", current["source_ref"])
 	}
 }
+
+// digestDockerExecutor answers the compose ps and inspect calls that resolve a
+// project's image digests; imageID is what the web container runs.
+type digestDockerExecutor struct {
+	fakeDockerExecutor
+	imageID string
+}
+
+func (d *digestDockerExecutor) Run(_ context.Context, _ string, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	switch {
+	case strings.HasPrefix(cmd, "compose "):
+		return `[{"Service":"web","Name":"demo-web-1"}]`, nil
+	case strings.HasPrefix(cmd, "container inspect "):
+		return `[{"Name":"/demo-web-1","Image":"` + d.imageID + `","Config":{"Image":"nginx:latest","Labels":{"com.docker.compose.service":"web"}}}]`, nil
+	case strings.HasPrefix(cmd, "image inspect "):
+		return `[{"Id":"` + d.imageID + `","RepoDigests":[]}]`, nil
+	}
+	return "", nil
+}
+
+func TestAppImageDigestPinAndDrift(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	exec := &digestDockerExecutor{imageID: "sha256:v1"}
+	prevClient := localDockerClient
+	localDockerClient = docker.New(exec)
+	t.Cleanup(func() { localDockerClient = prevClient })
+
+	appRecord := seedAppInstance(t, te, "demo-app")
+	release, _ := seedReleaseAndExposure(t, te, appRecord)
+	digestsURL := "/api/apps/" + appRecord.Id + "/image-digests"
+
+	rec := te.doLifecycleResources(t, http.MethodGet, digestsURL, "", true)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 before digests are recorded, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = te.doLifecycleResources(t, http.MethodPost, digestsURL, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pin: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := parseJSON(t, rec); body["release_id"] != release.Id {
+		t.Fatalf("unexpected pin response %v", body)
+	}
+	if entries := auditEntriesByAction(t, te, "app.image_digests.pin"); len(entries) != 1 {
+		t.Fatalf("expected one pin audit entry, got %d", len(entries))
+	}
+
+	rec = te.doLifecycleResources(t, http.MethodGet, digestsURL, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("drift: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if drift := parseJSON(t, rec)["drift"].(map[string]any); drift["drifted"] != false {
+		t.Fatalf("expected no drift right after pinning, got %v", drift)
+	}
+
+	// The mutable tag now resolves to a different image.
+	exec.imageID = "sha256:v2"
+	rec = te.doLifecycleResources(t, http.MethodGet, digestsURL, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("drift: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	drift := parseJSON(t, rec)["drift"].(map[string]any)
+	changed, _ := drift["changed"].([]any)
+	if drift["drifted"] != true || len(changed) != 1 {
+		t.Fatalf("expected one changed service, got %v", drift)
+	}
+	if change := changed[0].(map[string]any); change["recorded_image_id"] != "sha256:v1" || change["current_image_id"] != "sha256:v2" {
+		t.Fatalf("unexpected change %v", change)
+	}
+
+	rec = te.doLifecycleResources(t, http.MethodGet, "/api/apps/"+appRecord.Id+"/releases/current", "", true)
+	if detail := parseJSON(t, rec); detail["image_digests_at"] == nil || detail["image_digests"] == nil {
+		t.Fatalf("expected release detail to include recorded digests, got %v", detail)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/monitor"
 	monitormetrics "github.com/websoft9/appos/backend/domain/monitor/metrics"
 	agentsignals "github.com/websoft9/appos/backend/domain/monitor/signals/agent"
@@ -480,7 +481,7 @@ func handleMonitorTargetStatus(e *core.RequestEvent) error {
 }
 
 func findMonitorServer(app core.App, serverID string) (*core.Record, error) {
-	return app.FindRecordById("servers", strings.TrimSpace(serverID))
+	return archive.FindLive(app, "servers", strings.TrimSpace(serverID))
 }
 
 func monitorBearerToken(header string) (string, error) {
@@ -8,6 +8,7 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/groups"
 	"github.com/websoft9/appos/backend/domain/resource/accounts"
 	"github.com/websoft9/appos/backend/domain/secrets"
 	persistence "github.com/websoft9/appos/backend/infra/persistence"
@@ -131,7 +132,7 @@ func handleProviderAccountGet(e *core.RequestEvent) error {
 }
 
 // @Summary Create provider account
-// @Description Creates a provider account. Superuser only.
+// @Description Creates a provider account. An optional groups array of group IDs assigns the new account to those groups in the same step; unknown groups are rejected with a field error. Superuser only.
 // @Tags Resource
 // @Security BearerAuth
 // @Param body body providerAccountUpsertRequest true "provider account payload"
@@ -146,10 +147,22 @@ func handleProviderAccountCreate(e *core.RequestEvent) error {
 	if err != nil {
 		return err
 	}
+	groupIDs, err := bindCreateGroups(e)
+	if err != nil {
+		return err
+	}
 	userID, _ := authInfo(e)
-	item, saveErr := accounts.CreateWithDeps(persistence.NewProviderAccountRepository(e.App), input, accounts.SaveDeps{
-		ActorID:                userID,
-		CredentialRefValidator: providerAccountCredentialValidator{app: e.App},
+	var item *accounts.ProviderAccount
+	saveErr := createInGroups(e.App, groups.ObjectTypeProviderAccount, groupIDs, func(app core.App) (string, error) {
+		created, err := accounts.CreateWithDeps(persistence.NewProviderAccountRepository(app), input, accounts.SaveDeps{
+			ActorID:                userID,
+			CredentialRefValidator: providerAccountCredentialValidator{app: e.App},
+		})
+		if err != nil {
+			return "", err
+		}
+		item = created
+		return created.ID(), nil
 	})
 	if saveErr != nil {
 		writeProviderAccountAudit(e, "provider_account.create", nil, input, nil, saveErr)
@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"golang.org/x/time/rate"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// Route groups with their own token bucket. Each group reads
+// <group>PerMinute and <group>Burst from the "ratelimit/routes" settings;
+// a PerMinute of 0 disables limiting for the group.
+const (
+	rateLimitSpaceFetch = "spaceFetch"
+	rateLimitDockerPull = "dockerPull"
+	rateLimitServerOps  = "serverOps"
+)
+
+// rateLimitReasonCode is returned in data.reason_code on 429 responses.
+const rateLimitReasonCode = "RATE_LIMITED"
+
+// routeRateLimiters holds one limiter per group and caller (user ID, or client
+// IP for unauthenticated requests). Idle entries are evicted after
+// limiterEntryTTL, like setupScriptLimiters.
+var routeRateLimiters sync.Map // group|subject → *ipLimiterEntry
+
+func init() {
+	go func() {
+		for {
+			time.Sleep(30 * time.Minute)
+			cutoff := time.Now().UnixNano() - limiterEntryTTL
+			routeRateLimiters.Range(func(key, value any) bool {
+				if value.(*ipLimiterEntry).lastUsed.Load() < cutoff {
+					routeRateLimiters.Delete(key)
+				}
+				return true
+			})
+		}
+	}()
+}
+
+// routeRateLimit returns middleware enforcing the token bucket configured for
+// group. Rejected requests get 429 with a Retry-After header.
+func routeRateLimit(group string) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "routeRateLimit:" + group,
+		Func: func(e *core.RequestEvent) error {
+			cfg, _ := sysconfig.GetGroup(e.App, "ratelimit", "routes", nil)
+			perMinute := sysconfig.Int(cfg, group+"PerMinute", 0)
+			if perMinute <= 0 {
+				return e.Next()
+			}
+			burst := max(sysconfig.Int(cfg, group+"Burst", 1), 1)
+
+			limiter := routeLimiter(group, rateLimitSubject(e), rate.Limit(float64(perMinute)/60), burst)
+			reservation := limiter.Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				retryAfter := int(math.Ceil(delay.Seconds()))
+				e.Response.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				return e.JSON(http.StatusTooManyRequests, map[string]any{
+					"message": "rate limit exceeded — try again later",
+					"data": map[string]any{
+						"reason_code":         rateLimitReasonCode,
+						"retry_after_seconds": retryAfter,
+					},
+				})
+			}
+			return e.Next()
+		},
+	}
+}
+
+func rateLimitSubject(e *core.RequestEvent) string {
+	if e.Auth != nil {
+		return "user:" + e.Auth.Id
+	}
+	return "ip:" + e.RealIP()
+}
+
+// routeLimiter returns the caller's limiter for group, applying limit and
+// burst so settings changes take effect without a restart.
+func routeLimiter(group, subject string, limit rate.Limit, burst int) *rate.Limiter {
+	now := time.Now().UnixNano()
+	key := group + "|" + subject
+	val, ok := routeRateLimiters.Load(key)
+	if !ok {
+		entry := &ipLimiterEntry{limiter: rate.NewLimiter(limit, burst)}
+		val, _ = routeRateLimiters.LoadOrStore(key, entry)
+	}
+	entry := val.(*ipLimiterEntry)
+	entry.lastUsed.Store(now)
+	if entry.limiter.Limit() != limit {
+		entry.limiter.SetLimit(limit)
+	}
+	if entry.limiter.Burst() != burst {
+		entry.limiter.SetBurst(burst)
+	}
+	return entry.limiter
+}
@@ -1,24 +1,180 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/search"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/certs"
+	"github.com/websoft9/appos/backend/domain/config/sharedenv"
+	"github.com/websoft9/appos/backend/domain/groups"
+	"github.com/websoft9/appos/backend/domain/scriptlint"
+	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/domain/transfer"
+	"github.com/websoft9/appos/backend/infra/dbprobe"
 )
 
 // registerResourceRoutes registers all Resource Store CRUD routes.
 //
 // Route groups:
 //
-//	/api/ext/resources/scripts/*
+//	/api/ext/resources/search
+//	/api/ext/resources/env-sets/{id}/render
+//	/api/ext/resources/scripts/*  (CRUD, restore, lint)
+//	/api/ext/resources/databases/{id}/test
+//	/api/ext/resources/certificates/expiring
+//	/api/ext/resources/{type}/{id}/activity
+//	/api/ext/resources/export
+//	/api/ext/resources/import
 func registerResourceRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	r := g.Group("/resources")
 
+	r.GET("/search", handleResourceSearch).Bind(apis.RequireSuperuserAuth())
+	r.GET("/env-sets/{id}/render", handleEnvSetRender).Bind(apis.RequireSuperuserAuth())
+	r.POST("/databases/{id}/test", handleDatabaseTest).Bind(apis.RequireSuperuserAuth())
+	r.GET("/certificates/expiring", handleCertificatesExpiring).Bind(apis.RequireSuperuserAuth())
+	r.GET("/{type}/{id}/activity", handleResourceActivity).Bind(apis.RequireSuperuserAuth())
+	r.GET("/export", handleResourceExport).Bind(apis.RequireSuperuserAuth())
+	r.POST("/import", handleResourceImport).Bind(apis.RequireSuperuserAuth(), bodyLimit(importBodyLimit))
 	registerScriptsCRUD(r)
 }
 
+// handleResourceSearch searches all resource types at once.
+//
+// @Summary Search resources
+// @Description Searches every resource type (apps, servers, secrets, connectors, …) by name, description and host. Results are tagged with object_type, ordered by type then name, and paginated with per-type totals. Only display fields are returned; secret values are never included. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param q query string false "case-insensitive text matched against name, description and host"
+// @Param types query string false "comma-separated type filter, e.g. servers,secrets"
+// @Param limit query integer false "page size (default 50, max 200)"
+// @Param offset query integer false "number of items to skip (default 0)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/resources/search [get]
+func handleResourceSearch(e *core.RequestEvent) error {
+	query, err := resourcePageQuery(e)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	page, err := groups.SearchResources(e.App, query)
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "failed to search resources", err)
+	}
+	return e.JSON(http.StatusOK, page)
+}
+
+// handleEnvSetRender previews an env set with ${VAR} references expanded.
+//
+// @Summary Render env set
+// @Description Expands ${VAR} references in an env set's values and returns the resolved variables plus every reference that could not be expanded (reason undefined or cycle). Sets listed in include are layered underneath in order, as if attached before this set, so references to them resolve too; later sets win on duplicate keys. $$ is a literal $. Secret variables and values that reference them are masked; secrets are never decrypted. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param id path string true "env set ID"
+// @Param include query string false "comma-separated env set IDs to resolve references against"
+// @Success 200 {object} map[string]any "set_id, include, resolved, vars, unresolved"
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/ext/resources/env-sets/{id}/render [get]
+func handleEnvSetRender(e *core.RequestEvent) error {
+	setID := e.Request.PathValue("id")
+	include := []string{}
+	for _, id := range strings.Split(e.Request.URL.Query().Get("include"), ",") {
+		if id = strings.TrimSpace(id); id != "" && id != setID && !slices.Contains(include, id) {
+			include = append(include, id)
+		}
+	}
+
+	vars, err := sharedenv.LoadSetVars(e.App, append(slices.Clone(include), setID))
+	if err != nil {
+		return resourceError(e, http.StatusNotFound, "env set not found", err)
+	}
+	rendering := sharedenv.Render(vars)
+	return e.JSON(http.StatusOK, map[string]any{
+		"set_id":     setID,
+		"include":    include,
+		"resolved":   len(rendering.Unresolved) == 0,
+		"vars":       rendering.Vars,
+		"unresolved": rendering.Unresolved,
+	})
+}
+
+// handleResourceActivity lists the audit entries recorded against one
+// resource.
+//
+// @Summary Resource activity
+// @Description Returns the audit log entries whose resource_type and resource_id match the resource, newest first, so a resource's detail page can show its recent actions (deploys, restarts, edits, …). type is a resource type key such as servers or apps (the singular object type is accepted too). The resource need not still exist. action filters by action ID or prefix pattern such as app.*. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param type path string true "resource type, e.g. servers, apps, secrets"
+// @Param id path string true "resource ID"
+// @Param action query string false "action ID or prefix pattern ending in .*"
+// @Param limit query integer false "page size (default 50, max 200)"
+// @Param offset query integer false "number of entries to skip (default 0)"
+// @Success 200 {object} map[string]any "items, total, limit, offset"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/resources/{type}/{id}/activity [get]
+func handleResourceActivity(e *core.RequestEvent) error {
+	rt, ok := groups.LookupResourceType(e.Request.PathValue("type"))
+	if !ok {
+		return e.NotFoundError("unknown resource type", nil)
+	}
+	page, err := resourcePageQuery(e)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+	filter := audit.ActivityFilter{
+		ResourceType: string(rt.ObjectType),
+		ResourceID:   e.Request.PathValue("id"),
+		Limit:        page.Limit,
+		Offset:       page.Offset,
+	}
+	if action := strings.TrimSpace(e.Request.URL.Query().Get("action")); action != "" {
+		if !audit.ValidActionPattern(action) {
+			return e.BadRequestError("action must be an action ID or a prefix ending in .*", nil)
+		}
+		filter.Action = action
+	}
+
+	records, total, err := audit.Activity(e.App, filter)
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "failed to load activity", err)
+	}
+	items := make([]auditExportRow, 0, len(records))
+	for _, record := range records {
+		items = append(items, auditExportRowFromRecord(record))
+	}
+	return e.JSON(http.StatusOK, map[string]any{
+		"items":  items,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
 // ═══════════════════════════════════════════════════════════
 // Generic helpers
 // ═══════════════════════════════════════════════════════════
@@ -36,18 +192,154 @@ func resourceError(e *core.RequestEvent, status int, msg string, err error) erro
 	})
 }
 
-// listRecords returns all records from a collection.
+// List pagination defaults for listRecords.
+const (
+	listDefaultPerPage = 50
+	listMaxPerPage     = 500
+)
+
+// listSortPattern matches one sort token: a field name with an optional
+// direction prefix.
+var listSortPattern = regexp.MustCompile(`^[-+]?[a-z][a-z0-9_]*$`)
+
+// listQuery is the parsed query of a listRecords request. filter and params
+// form a PocketBase filter expression whose values are all bound parameters.
+type listQuery struct {
+	page    int
+	perPage int
+	sort    string
+	filter  string
+	params  dbx.Params
+}
+
+// parseListQuery reads page, perPage, sort, filter (name contains), group
+// (group membership) and archived. sort may only name non-hidden fields and
+// defaults to created where the collection has it, else insertion order.
+func parseListQuery(e *core.RequestEvent, col *core.Collection) (listQuery, error) {
+	q := e.Request.URL.Query()
+	query := listQuery{page: 1, perPage: listDefaultPerPage, params: dbx.Params{}}
+	if col.Fields.GetByName("created") != nil {
+		query.sort = "created"
+	}
+	if raw := strings.TrimSpace(q.Get("page")); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return query, errors.New("page must be a positive integer")
+		}
+		query.page = page
+	}
+	if raw := strings.TrimSpace(q.Get("perPage")); raw != "" {
+		perPage, err := strconv.Atoi(raw)
+		if err != nil || perPage < 1 {
+			return query, errors.New("perPage must be a positive integer")
+		}
+		query.perPage = min(perPage, listMaxPerPage)
+	}
+	if raw := strings.TrimSpace(q.Get("sort")); raw != "" {
+		for _, token := range strings.Split(raw, ",") {
+			token = strings.TrimSpace(token)
+			field := col.Fields.GetByName(strings.TrimLeft(token, "+-"))
+			if !listSortPattern.MatchString(token) || field == nil || field.GetHidden() {
+				return query, fmt.Errorf("cannot sort by %q", token)
+			}
+		}
+		query.sort = raw
+	}
+
+	var conditions []string
+	if archive.Supported(col) {
+		if q.Get("archived") == "true" {
+			conditions = append(conditions, archive.Field+" != ''")
+		} else {
+			conditions = append(conditions, archive.Field+" = ''")
+		}
+	}
+	if text := strings.TrimSpace(q.Get("filter")); text != "" {
+		nameField := "name"
+		for _, rt := range groups.ResourceTypes {
+			if rt.Collection == col.Name {
+				nameField = rt.NameField
+			}
+		}
+		conditions = append(conditions, nameField+" ~ {:filter}")
+		query.params["filter"] = text
+	}
+	if groupID := strings.TrimSpace(q.Get("group")); groupID != "" {
+		objectType := ""
+		for _, rt := range groups.ResourceTypes {
+			if rt.Collection == col.Name {
+				objectType = string(rt.ObjectType)
+			}
+		}
+		if objectType == "" {
+			return query, errors.New("records of this type cannot be filtered by group")
+		}
+		conditions = append(conditions,
+			"@collection.group_items:membership.group_id ?= {:group}",
+			"@collection.group_items:membership.object_type ?= {:objectType}",
+			"@collection.group_items:membership.object_id ?= id",
+		)
+		query.params["group"] = groupID
+		query.params["objectType"] = objectType
+	}
+	query.filter = strings.Join(conditions, " && ")
+	return query, nil
+}
+
+// listRecords returns one page of a collection's records as
+// {items, page, perPage, totalItems, totalPages}. For collections that
+// support archiving, archived records are hidden unless ?archived=true, which
+// lists only the archived ones. See parseListQuery for the other parameters.
 func listRecords(e *core.RequestEvent, collection string) error {
-	records, err := e.App.FindAllRecords(collection)
+	col, err := e.App.FindCollectionByNameOrId(collection)
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "collection not found", err)
+	}
+	query, err := parseListQuery(e, col)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	total, err := countRecordsByFilter(e.App, col, query.filter, query.params)
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "failed to count records", err)
+	}
+	records, err := e.App.FindRecordsByFilter(col, query.filter, query.sort, query.perPage, (query.page-1)*query.perPage, query.params)
 	if err != nil {
 		return resourceError(e, http.StatusInternalServerError, "failed to list records", err)
 	}
 
-	result := make([]map[string]any, 0, len(records))
+	items := make([]map[string]any, 0, len(records))
 	for _, r := range records {
-		result = append(result, recordToMap(r))
+		items = append(items, recordToMap(r))
 	}
-	return e.JSON(http.StatusOK, result)
+	return e.JSON(http.StatusOK, map[string]any{
+		"items":      items,
+		"page":       query.page,
+		"perPage":    query.perPage,
+		"totalItems": total,
+		"totalPages": (total + query.perPage - 1) / query.perPage,
+	})
+}
+
+// countRecordsByFilter counts the records FindRecordsByFilter would return
+// for filter without a limit.
+func countRecordsByFilter(app core.App, col *core.Collection, filter string, params dbx.Params) (int, error) {
+	q := app.RecordQuery(col).Select("COUNT(DISTINCT [[" + col.Name + ".id]])")
+	if filter != "" {
+		resolver := core.NewRecordFieldResolver(app, col, nil, true)
+		expr, err := search.FilterData(filter).BuildExpr(resolver, params)
+		if err != nil {
+			return 0, err
+		}
+		q.AndWhere(expr)
+		if err := resolver.UpdateQuery(q); err != nil {
+			return 0, err
+		}
+	}
+	var total int
+	err := q.Row(&total)
+	return total, err
 }
 
 // getRecord returns a single record by ID.
@@ -60,19 +352,46 @@ func getRecord(e *core.RequestEvent, collection string) error {
 	return e.JSON(http.StatusOK, recordToMap(record))
 }
 
-// deleteRecord deletes a record by ID.
+// deleteRecord archives a record by ID when its collection supports
+// archiving, and deletes it otherwise. ?purge=true deletes permanently and is
+// superuser-only.
 func deleteRecord(e *core.RequestEvent, collection string) error {
 	id := e.Request.PathValue("id")
 	record, err := e.App.FindRecordById(collection, id)
 	if err != nil {
 		return e.NotFoundError("Record not found", err)
 	}
+	purge := e.Request.URL.Query().Get("purge") == "true"
+	if purge && !e.HasSuperuserAuth() {
+		return e.ForbiddenError("only superusers can purge records", nil)
+	}
+	if !purge && archive.Supported(record.Collection()) {
+		if err := archive.Archive(e.App, record); err != nil {
+			return resourceError(e, http.StatusInternalServerError, "failed to archive record", err)
+		}
+		return e.NoContent(http.StatusNoContent)
+	}
 	if err := e.App.Delete(record); err != nil {
 		return resourceError(e, http.StatusInternalServerError, "failed to delete record", err)
 	}
 	return e.NoContent(http.StatusNoContent)
 }
 
+// restoreRecord brings the archived record id of collection back.
+func restoreRecord(e *core.RequestEvent, collection, id string) error {
+	record, err := e.App.FindRecordById(collection, id)
+	if err != nil {
+		return e.NotFoundError("Record not found", err)
+	}
+	if err := archive.Restore(e.App, record); err != nil {
+		if errors.Is(err, archive.ErrNotArchived) {
+			return resourceError(e, http.StatusConflict, err.Error(), nil)
+		}
+		return resourceError(e, http.StatusInternalServerError, "failed to restore record", err)
+	}
+	return e.JSON(http.StatusOK, recordToMap(record))
+}
+
 // recordToMap converts a PocketBase record to a JSON-friendly map.
 func recordToMap(r *core.Record) map[string]any {
 	m := map[string]any{
@@ -94,6 +413,42 @@ func recordToMap(r *core.Record) map[string]any {
 	return m
 }
 
+// bindCreateGroups reads the optional "groups" array of a create body and
+// checks that every referenced group exists. Unknown groups are reported as a
+// field error.
+func bindCreateGroups(e *core.RequestEvent) ([]string, error) {
+	info, err := e.RequestInfo()
+	if err != nil {
+		return nil, e.BadRequestError("Invalid request body", err)
+	}
+	ids := groups.ParseIDs(info.Body[groups.CreateField])
+	if err := groups.ValidateIDs(e.App, ids); err != nil {
+		var fieldErr validation.Errors
+		if errors.As(err, &fieldErr) {
+			return nil, e.BadRequestError("Invalid groups", fieldErr)
+		}
+		return nil, e.InternalServerError("failed to load groups", err)
+	}
+	return ids, nil
+}
+
+// createInGroups runs create and assigns the new object to groupIDs in one
+// transaction, so a failed assignment also undoes the create. create returns
+// the new object's ID.
+func createInGroups(app core.App, objectType groups.ObjectType, groupIDs []string, create func(app core.App) (string, error)) error {
+	if len(groupIDs) == 0 {
+		_, err := create(app)
+		return err
+	}
+	return app.RunInTransaction(func(txApp core.App) error {
+		id, err := create(txApp)
+		if err != nil {
+			return err
+		}
+		return groups.Assign(txApp, objectType, id, groupIDs)
+	})
+}
+
 // bindAndSave binds JSON body fields to a record and saves it.
 func bindAndSave(e *core.RequestEvent, record *core.Record, fields []string) error {
 	var body map[string]any
@@ -108,11 +463,22 @@ func bindAndSave(e *core.RequestEvent, record *core.Record, fields []string) err
 	}
 
 	if err := e.App.Save(record); err != nil {
-		return e.BadRequestError("Validation failed", err)
+		return saveError(e, err)
 	}
 	return e.JSON(http.StatusOK, recordToMap(record))
 }
 
+// saveError reports a failed record save. Field validation errors become a
+// 400 whose data maps each failing field to its code and message, the shape
+// of the native record API; anything else is a 500.
+func saveError(e *core.RequestEvent, err error) error {
+	var fieldErr validation.Errors
+	if errors.As(err, &fieldErr) {
+		return e.BadRequestError("Failed to save record.", fieldErr)
+	}
+	return e.InternalServerError("Failed to save record.", err)
+}
+
 // ═══════════════════════════════════════════════════════════
 // Scripts
 // ═══════════════════════════════════════════════════════════
@@ -123,9 +489,7 @@ func registerScriptsCRUD(r *router.RouterGroup[*core.RequestEvent]) {
 	sc := r.Group("/scripts")
 	sc.Bind(apis.RequireSuperuserAuth())
 
-	sc.GET("", func(e *core.RequestEvent) error {
-		return listRecords(e, "scripts")
-	})
+	sc.GET("", handleScriptList)
 	sc.GET("/{id}", func(e *core.RequestEvent) error {
 		return getRecord(e, "scripts")
 	})
@@ -134,8 +498,30 @@ func registerScriptsCRUD(r *router.RouterGroup[*core.RequestEvent]) {
 		if err != nil {
 			return resourceError(e, http.StatusInternalServerError, "collection not found", err)
 		}
+		groupIDs, err := bindCreateGroups(e)
+		if err != nil {
+			return err
+		}
+		var body map[string]any
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body", err)
+		}
 		record := core.NewRecord(col)
-		return bindAndSave(e, record, scriptFields)
+		for _, f := range scriptFields {
+			if v, ok := body[f]; ok {
+				record.Set(f, v)
+			}
+		}
+		err = createInGroups(e.App, groups.ObjectTypeScript, groupIDs, func(app core.App) (string, error) {
+			if err := app.Save(record); err != nil {
+				return "", err
+			}
+			return record.Id, nil
+		})
+		if err != nil {
+			return saveError(e, err)
+		}
+		return e.JSON(http.StatusOK, recordToMap(record))
 	})
 	sc.PUT("/{id}", func(e *core.RequestEvent) error {
 		id := e.Request.PathValue("id")
@@ -148,4 +534,347 @@ func registerScriptsCRUD(r *router.RouterGroup[*core.RequestEvent]) {
 	sc.DELETE("/{id}", func(e *core.RequestEvent) error {
 		return deleteRecord(e, "scripts")
 	})
+	sc.POST("/{id}/restore", func(e *core.RequestEvent) error {
+		return restoreRecord(e, "scripts", e.Request.PathValue("id"))
+	})
+	sc.POST("/lint", handleScriptLintDraft)
+	sc.POST("/{id}/lint", handleScriptLint)
+}
+
+// handleScriptList lists scripts one page at a time.
+//
+// @Summary List scripts
+// @Description Returns one page of scripts as {items, page, perPage, totalItems, totalPages}. filter matches names containing the text (case-insensitive) and group limits the list to members of that group; both are bound as parameters, never parsed as filter syntax. sort is a comma-separated list of field names, each optionally prefixed with - for descending. Archived scripts are listed only with archived=true. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param page query integer false "page number (default 1)"
+// @Param perPage query integer false "page size (default 50, max 500)"
+// @Param sort query string false "e.g. -created,name"
+// @Param filter query string false "name contains"
+// @Param group query string false "group ID"
+// @Param archived query boolean false "list only archived scripts"
+// @Success 200 {object} map[string]any "items, page, perPage, totalItems, totalPages"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Router /api/ext/resources/scripts [get]
+func handleScriptList(e *core.RequestEvent) error {
+	return listRecords(e, "scripts")
+}
+
+// handleScriptLint syntax-checks a stored script.
+//
+// @Summary Lint script
+// @Description Runs a parse-only syntax check for the script's language (bash -n, Python compile, node --check) without executing it, and returns the errors with line numbers. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param id path string true "script ID"
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 422 {object} map[string]any
+// @Failure 503 {object} map[string]any
+// @Router /api/ext/resources/scripts/{id}/lint [post]
+func handleScriptLint(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("scripts", e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("Record not found", err)
+	}
+	return lintScript(e, record.GetString("language"), record.GetString("code"))
+}
+
+// handleScriptLintDraft syntax-checks code that has not been saved yet.
+//
+// @Summary Lint script draft
+// @Description Syntax-checks the posted language and code the same way as the stored-script lint, so editors can validate before saving. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param body body object true "language and code"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 422 {object} map[string]any
+// @Failure 503 {object} map[string]any
+// @Router /api/ext/resources/scripts/lint [post]
+func handleScriptLintDraft(e *core.RequestEvent) error {
+	var body struct {
+		Language string `json:"language"`
+		Code     string `json:"code"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+	if body.Language == "" {
+		return e.BadRequestError("language is required", nil)
+	}
+	return lintScript(e, body.Language, body.Code)
+}
+
+func lintScript(e *core.RequestEvent, language, code string) error {
+	result, err := scriptlint.Check(e.Request.Context(), language, code)
+	switch {
+	case errors.Is(err, scriptlint.ErrUnsupportedLanguage):
+		return resourceError(e, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, scriptlint.ErrCheckerUnavailable):
+		return resourceError(e, http.StatusServiceUnavailable, err.Error(), nil)
+	case err != nil:
+		return resourceError(e, http.StatusInternalServerError, "syntax check failed", err)
+	}
+	return e.JSON(http.StatusOK, result)
+}
+
+// ═══════════════════════════════════════════════════════════
+// Databases
+// ═══════════════════════════════════════════════════════════
+
+// handleDatabaseTest opens a real connection to a database resource.
+//
+// @Summary Test database connection
+// @Description Connects to the database with its host, port, user and decrypted password, authenticates and runs a trivial round trip for its type (SELECT 1 on PostgreSQL, COM_PING on MySQL, PING on Redis, ping on MongoDB). The connection is encrypted as the database's tls field asks (disable, require or verify); without TLS, a server asking for authentication that would expose the password (a PostgreSQL cleartext password, a MySQL RSA key exchange) is refused with status error. Returns status (online, offline, auth_failed or error), latency_ms, server_version and, when the test fails, a reason. An unreachable server is reported as status offline, not as an error response. The password is never echoed. The test is audited. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param id path string true "database ID"
+// @Param timeout query integer false "seconds to wait for the server (default 5, max 60)"
+// @Success 200 {object} map[string]any "status, latency_ms, server_version, reason"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/ext/resources/databases/{id}/test [post]
+func handleDatabaseTest(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("databases", e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("Record not found", err)
+	}
+	timeout := dbprobe.DefaultTimeout
+	if raw := strings.TrimSpace(e.Request.URL.Query().Get("timeout")); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 1 || time.Duration(seconds)*time.Second > dbprobe.MaxTimeout {
+			return e.BadRequestError("timeout must be between 1 and 60 seconds", nil)
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	userID, userEmail, ip, userAgent := clientInfo(e)
+	dbType := record.GetString("type")
+	target := dbprobe.Target{
+		Host:     record.GetString("host"),
+		Port:     record.GetInt("port"),
+		Database: record.GetString("db_name"),
+		User:     record.GetString("user"),
+		TLS:      record.GetString("tls"),
+	}
+	var result dbprobe.Result
+	if secretID := record.GetString("password"); secretID != "" {
+		resolved, err := secrets.Resolve(e.App, secretID, userID)
+		if err != nil {
+			reason := err.Error()
+			var resolveErr *secrets.ResolveError
+			if errors.As(err, &resolveErr) {
+				reason = resolveErr.Reason
+			}
+			result = dbprobe.Result{Status: dbprobe.StatusError, Reason: "password could not be resolved: " + reason}
+		} else {
+			target.Password = secrets.FirstStringFromPayload(resolved.Payload, "password", "value")
+		}
+	}
+	if result.Status == "" {
+		result = dbprobe.Probe(e.Request.Context(), dbType, target, timeout)
+	}
+
+	status := audit.StatusSuccess
+	if result.Status != dbprobe.StatusOnline {
+		status = audit.StatusFailed
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Action:       "database.test",
+		ResourceType: "database",
+		ResourceID:   record.Id,
+		ResourceName: record.GetString("name"),
+		Status:       status,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Detail: map[string]any{
+			"type":         dbType,
+			"probe_status": result.Status,
+			"latency_ms":   result.LatencyMS,
+			"reason":       result.Reason,
+		},
+	})
+	return e.JSON(http.StatusOK, result)
+}
+
+// ═══════════════════════════════════════════════════════════
+// Certificates
+// ═══════════════════════════════════════════════════════════
+
+// Window bounds for handleCertificatesExpiring, in days.
+const (
+	certExpiringDefaultDays = 30
+	certExpiringMaxDays     = 3650
+)
+
+// handleCertificatesExpiring lists certificates that expire soon.
+//
+// @Summary List expiring certificates
+// @Description Returns the non-revoked certificates whose expiry, parsed from cert_pem when it was saved, falls between now and now plus days, soonest first. Each item carries id, name, domain, status, expires_at and days_left. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param days query integer false "window in days (default 30, max 3650)"
+// @Success 200 {object} map[string]any "items, days"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/resources/certificates/expiring [get]
+func handleCertificatesExpiring(e *core.RequestEvent) error {
+	days := certExpiringDefaultDays
+	if raw := strings.TrimSpace(e.Request.URL.Query().Get("days")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > certExpiringMaxDays {
+			return e.BadRequestError("days must be between 1 and 3650", nil)
+		}
+		days = n
+	}
+
+	now := time.Now().UTC()
+	records, err := certs.Expiring(e.App, now, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "failed to list certificates", err)
+	}
+	items := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		expiresAt := record.GetDateTime("expires_at").Time()
+		items = append(items, map[string]any{
+			"id":         record.Id,
+			"name":       record.GetString("name"),
+			"domain":     record.GetString("domain"),
+			"status":     record.GetString("status"),
+			"expires_at": expiresAt.Format(time.RFC3339),
+			"days_left":  int(expiresAt.Sub(now).Hours() / 24),
+		})
+	}
+	return e.JSON(http.StatusOK, map[string]any{"items": items, "days": days})
+}
+
+// ═══════════════════════════════════════════════════════════
+// Export / import
+// ═══════════════════════════════════════════════════════════
+
+// exportPassphraseHeader carries the export passphrase, keeping it out of
+// URLs and access logs.
+const exportPassphraseHeader = "X-Export-Passphrase"
+
+// handleResourceExport dumps resources as one portable JSON document.
+//
+// @Summary Export resources
+// @Description Returns every group and every non-archived resource (secrets, servers, env sets, instances, AI providers, provider accounts, certificates, connectors, scripts) as one JSON document. Secret payloads are decrypted and re-encrypted under the passphrase sent in the X-Export-Passphrase header (at least 8 characters), so the document can be imported on another instance. Secrets whose payload cannot be decrypted are listed under skipped. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param X-Export-Passphrase header string true "passphrase sealing secret payloads"
+// @Param types query string false "comma-separated type filter, e.g. servers,secrets"
+// @Success 200 {object} map[string]any "format, version, exported_at, encryption, groups, resources, skipped"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/resources/export [get]
+func handleResourceExport(e *core.RequestEvent) error {
+	var only []string
+	for _, token := range strings.Split(e.Request.URL.Query().Get("types"), ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if _, ok := groups.LookupResourceType(token); !ok {
+			return e.BadRequestError("unknown resource type: "+token, nil)
+		}
+		only = append(only, token)
+	}
+
+	userID, userEmail, ip, userAgent := clientInfo(e)
+	doc, err := transfer.Export(e.App, e.Request.Header.Get(exportPassphraseHeader), only)
+	if errors.Is(err, transfer.ErrWeakPassphrase) {
+		return e.BadRequestError(err.Error(), nil)
+	}
+	if err != nil {
+		return resourceError(e, http.StatusInternalServerError, "failed to export resources", err)
+	}
+
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Action:       "resource.export",
+		ResourceType: "resource",
+		Status:       audit.StatusSuccess,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Detail: map[string]any{
+			"types":     only,
+			"groups":    len(doc.Groups),
+			"resources": len(doc.Resources),
+			"skipped":   len(doc.Skipped),
+		},
+	})
+	e.Response.Header().Set("Content-Disposition", `attachment; filename="appos-resources.json"`)
+	return e.JSON(http.StatusOK, doc)
+}
+
+// handleResourceImport recreates resources from an export document.
+//
+// @Summary Import resources
+// @Description Imports a document produced by the export endpoint. Groups are matched by name and created when missing; resources are matched by type and name, so importing the same document again creates nothing new. Existing resources are skipped unless overwrite is true, in which case they are updated. Relations and secretRef values are remapped to the imported records and secret payloads are validated against their template and re-encrypted with this instance's key. Each resource is saved on its own; the response reports created, updated, skipped or failed per group and resource. The body may be up to the http/limits maxImportMB setting (default 64 MB) rather than maxBodyMB. Superuser only.
+// @Tags Resource
+// @Security BearerAuth
+// @Param body body object true "passphrase, document, overwrite"
+// @Success 200 {object} map[string]any "groups, resources, created, updated, skipped, failed"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Router /api/ext/resources/import [post]
+func handleResourceImport(e *core.RequestEvent) error {
+	var body struct {
+		Passphrase string             `json:"passphrase"`
+		Document   *transfer.Document `json:"document"`
+		Overwrite  bool               `json:"overwrite"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("invalid request body", err)
+	}
+	if body.Document == nil {
+		return e.BadRequestError("document is required", nil)
+	}
+
+	userID, userEmail, ip, userAgent := clientInfo(e)
+	report, err := transfer.Import(e.App, body.Document, body.Passphrase, transfer.ImportOptions{
+		Overwrite: body.Overwrite,
+		UserID:    userID,
+	})
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	status := audit.StatusSuccess
+	if report.Failed > 0 {
+		status = audit.StatusFailed
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Action:       "resource.import",
+		ResourceType: "resource",
+		Status:       status,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Detail: map[string]any{
+			"overwrite": body.Overwrite,
+			"created":   report.Created,
+			"updated":   report.Updated,
+			"skipped":   report.Skipped,
+			"failed":    report.Failed,
+		},
+	})
+	return e.JSON(http.StatusOK, report)
 }
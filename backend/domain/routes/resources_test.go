@@ -1,25 +1,43 @@
 package routes
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tests"
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/audit"
 	"github.com/websoft9/appos/backend/domain/config/sharedenv"
+	"github.com/websoft9/appos/backend/domain/groups"
 	"github.com/websoft9/appos/backend/domain/resource/accounts"
 	"github.com/websoft9/appos/backend/domain/resource/aiproviders"
 	"github.com/websoft9/appos/backend/domain/resource/connectors"
 	"github.com/websoft9/appos/backend/domain/resource/instances"
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/scriptlint"
+	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/domain/terminal"
+	"github.com/websoft9/appos/backend/domain/transfer"
 
 	_ "github.com/websoft9/appos/backend/infra/migrations"
 )
@@ -942,7 +960,7 @@ func TestCloudAccountsRouteRemovedFromExt(t *testing.T) {
 }
 
 // ═══════════════════════════════════════════════════════════
-// Env Sets (native PocketBase API — no custom ext routes)
+// Env Sets
 // ═══════════════════════════════════════════════════════════
 
 // TestEnvSetsNativeAPI verifies that env_sets and env_set_vars are accessible
@@ -982,3 +1000,944 @@ func TestEnvSetsNativeAPI(t *testing.T) {
 		t.Fatalf("expected 1 env_set_var, got %v", got["totalItems"])
 	}
 }
+
+// TestEnvSetRenderResolvesAcrossIncludedSets verifies render expands
+// references against included sets, masks secrets and reports problems.
+func TestEnvSetRenderResolvesAcrossIncludedSets(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	createSet := func(name string) string {
+		rec := te.do(t, http.MethodPost, "/api/collections/"+sharedenv.SetCollection+"/records", `{"name":"`+name+`"}`, true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("create env_set: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return parseJSON(t, rec)["id"].(string)
+	}
+	createVar := func(setID, body string) {
+		rec := te.do(t, http.MethodPost, "/api/collections/"+sharedenv.VarCollection+"/records", `{"set":"`+setID+`",`+body+`}`, true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("create env_set_var: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	shared := createSet("shared-db")
+	createVar(shared, `"key":"DB_HOST","value":"db.internal"`)
+	createVar(shared, `"key":"DB_PASSWORD","value":"","is_secret":true`)
+	app := createSet("app-env")
+	createVar(app, `"key":"DB_URL","value":"postgres://${DB_HOST}/app"`)
+	createVar(app, `"key":"DB_AUTH","value":"${DB_PASSWORD}"`)
+	createVar(app, `"key":"CACHE_URL","value":"${CACHE_HOST}:6379"`)
+
+	rec := te.do(t, http.MethodGet, "/api/ext/resources/env-sets/"+app+"/render", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("render: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	alone := parseJSON(t, rec)
+	if alone["resolved"] != false || len(alone["unresolved"].([]any)) != 3 {
+		t.Fatalf("expected DB_HOST, DB_PASSWORD and CACHE_HOST unresolved without include, got %s", rec.Body.String())
+	}
+
+	rec = te.do(t, http.MethodGet, "/api/ext/resources/env-sets/"+app+"/render?include="+shared, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("render with include: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		Resolved   bool                      `json:"resolved"`
+		Vars       []sharedenv.RenderedVar   `json:"vars"`
+		Unresolved []sharedenv.UnresolvedRef `json:"unresolved"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]sharedenv.RenderedVar{}
+	for _, v := range out.Vars {
+		values[v.Key] = v
+	}
+	if values["DB_URL"].Value != "postgres://db.internal/app" {
+		t.Fatalf("expected DB_URL resolved across sets, got %+v", values["DB_URL"])
+	}
+	if v := values["DB_AUTH"]; !v.Masked || v.Value != sharedenv.MaskedValue {
+		t.Fatalf("expected DB_AUTH masked, got %+v", v)
+	}
+	if out.Resolved || len(out.Unresolved) != 1 || out.Unresolved[0].Reference != "CACHE_HOST" {
+		t.Fatalf("expected only CACHE_HOST unresolved, got %+v", out.Unresolved)
+	}
+
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/env-sets/missing/render", "", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown set, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/env-sets/"+app+"/render", "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d", rec.Code)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// Search
+// ═══════════════════════════════════════════════════════════
+
+func TestResourceSearchAcrossTypes(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	createServerRecord(t, te, "web-1", "prod-db.internal", 22, "root", "password")
+	scriptsCol, err := te.app.FindCollectionByNameOrId("scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"prod-db-backup", "cleanup"} {
+		script := core.NewRecord(scriptsCol)
+		script.Set("name", name)
+		script.Set("language", "bash")
+		script.Set("code", "echo "+name)
+		if err := te.app.Save(script); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/search?q=prod-db", "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+
+	rec := te.do(t, http.MethodGet, "/api/ext/resources/search?q=prod-db", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var page struct {
+		Items []struct {
+			ObjectType string `json:"object_type"`
+			Name       string `json:"name"`
+			Host       string `json:"host"`
+		} `json:"items"`
+		Counts map[string]int `json:"counts"`
+		Total  int            `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.Counts["servers"] != 1 || page.Counts["scripts"] != 1 {
+		t.Fatalf("expected one server and one script, got total=%d counts=%v", page.Total, page.Counts)
+	}
+	if page.Items[0].ObjectType != "server" || page.Items[0].Host != "prod-db.internal" {
+		t.Fatalf("expected the server to match on host first, got %+v", page.Items[0])
+	}
+	if page.Items[1].ObjectType != "script" || page.Items[1].Name != "prod-db-backup" {
+		t.Fatalf("expected the script second, got %+v", page.Items[1])
+	}
+
+	rec = te.do(t, http.MethodGet, "/api/ext/resources/search?q=prod-db&types=scripts&limit=1", "", true)
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ObjectType != "script" {
+		t.Fatalf("expected the type filter to keep only scripts, got %+v", page)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// Archive
+// ═══════════════════════════════════════════════════════════
+
+func TestScriptDeleteArchivesRestoresAndPurges(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts", `{"name":"rotate-logs","language":"bash","code":"echo hi"}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	id := created["id"].(string)
+
+	listNames := func(url string) []string {
+		t.Helper()
+		rec := te.do(t, http.MethodGet, url, "", true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var page struct {
+			Items []map[string]any `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			names = append(names, item["name"].(string))
+		}
+		return names
+	}
+
+	if rec := te.do(t, http.MethodDelete, "/api/ext/resources/scripts/"+id, "", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("archive: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if names := listNames("/api/ext/resources/scripts"); len(names) != 0 {
+		t.Fatalf("expected archived script hidden from default list, got %v", names)
+	}
+	if names := listNames("/api/ext/resources/scripts?archived=true"); len(names) != 1 {
+		t.Fatalf("expected archived script in archived list, got %v", names)
+	}
+
+	if rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts/"+id+"/restore", "", true); rec.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if names := listNames("/api/ext/resources/scripts"); len(names) != 1 {
+		t.Fatalf("expected restored script listed, got %v", names)
+	}
+	if rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts/"+id+"/restore", "", true); rec.Code != http.StatusConflict {
+		t.Fatalf("restore live record: expected 409, got %d", rec.Code)
+	}
+
+	// Archived records are purged only after the retention window.
+	if rec := te.do(t, http.MethodDelete, "/api/ext/resources/scripts/"+id, "", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("archive: expected 204, got %d", rec.Code)
+	}
+	if purged, err := archive.Purge(te.app, time.Now(), archive.DefaultRetention); err != nil || purged != 0 {
+		t.Fatalf("expected nothing purged inside retention, got %d, %v", purged, err)
+	}
+	if purged, err := archive.Purge(te.app, time.Now().Add(archive.DefaultRetention+time.Hour), archive.DefaultRetention); err != nil || purged != 1 {
+		t.Fatalf("expected one purged record, got %d, %v", purged, err)
+	}
+	if _, err := te.app.FindRecordById("scripts", id); err == nil {
+		t.Fatal("expected purged script to be gone")
+	}
+}
+
+func TestScriptDeletePurgeRemovesImmediately(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts", `{"name":"one-off","language":"bash","code":"true"}`, true)
+	var created map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	id := created["id"].(string)
+
+	if rec := te.do(t, http.MethodDelete, "/api/ext/resources/scripts/"+id+"?purge=true", "", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("purge: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := te.app.FindRecordById("scripts", id); err == nil {
+		t.Fatal("expected purged script to be gone")
+	}
+}
+
+func TestRecordsAPIDeleteArchivesServersAndSecrets(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+	ensureConnectorSecretRuntime(t)
+	archive.RegisterHooks(te.app)
+
+	server := createServerRecord(t, te, "web-1", "10.0.0.1", 22, "root", "")
+	secret := createRouteSecret(t, te, "global", "")
+
+	for _, rec := range []*core.Record{server, secret} {
+		url := "/api/collections/" + rec.Collection().Name + "/records/" + rec.Id
+		if res := te.do(t, http.MethodDelete, url, "", true); res.Code != http.StatusNoContent {
+			t.Fatalf("%s: expected 204, got %d: %s", rec.Collection().Name, res.Code, res.Body.String())
+		}
+		stored, err := te.app.FindRecordById(rec.Collection().Name, rec.Id)
+		if err != nil {
+			t.Fatalf("%s: expected the record to be archived, not deleted: %v", rec.Collection().Name, err)
+		}
+		if !archive.IsArchived(stored) {
+			t.Fatalf("%s: expected archived_at to be set", rec.Collection().Name)
+		}
+	}
+
+	if _, err := secrets.Resolve(te.app, secret.Id, ""); err == nil || !strings.Contains(err.Error(), secrets.ReasonArchived) {
+		t.Fatalf("expected an archived secret not to resolve, got %v", err)
+	}
+
+	url := "/api/collections/servers/records/" + server.Id + "?purge=true"
+	if res := te.do(t, http.MethodDelete, url, "", true); res.Code != http.StatusNoContent {
+		t.Fatalf("purge: expected 204, got %d: %s", res.Code, res.Body.String())
+	}
+	if _, err := te.app.FindRecordById("servers", server.Id); err == nil {
+		t.Fatal("expected the purged server to be gone")
+	}
+}
+
+func TestArchivedServersAndSecretsAreHiddenAndRestorable(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+	ensureConnectorSecretRuntime(t)
+	archive.RegisterHooks(te.app)
+	auth := map[string]string{"Authorization": te.token}
+
+	server := createServerRecord(t, te, "web-1", "10.0.0.1", 22, "root", "")
+	secret := createRouteSecret(t, te, "global", "")
+
+	closed := false
+	terminal.Track("test-archive-server", closerFunc(func() { closed = true }), terminal.ConnectionMeta{
+		Type:     terminal.ConnectionSFTPCopy,
+		Target:   server.Id + ":/tmp/a",
+		ServerID: server.Id,
+	})
+	defer terminal.Unregister("test-archive-server")
+
+	listTotal := func(collection, query string) int {
+		t.Helper()
+		res := te.doRegisteredRoute(t, http.MethodGet, "/api/collections/"+collection+"/records"+query, "", auth)
+		var page struct {
+			TotalItems int `json:"totalItems"`
+		}
+		if res.Code != http.StatusOK || json.Unmarshal(res.Body.Bytes(), &page) != nil {
+			t.Fatalf("%s list: got %d: %s", collection, res.Code, res.Body.String())
+		}
+		return page.TotalItems
+	}
+
+	for _, rec := range []*core.Record{server, secret} {
+		name := rec.Collection().Name
+		if res := te.doRegisteredRoute(t, http.MethodDelete, "/api/collections/"+name+"/records/"+rec.Id, "", auth); res.Code != http.StatusNoContent {
+			t.Fatalf("%s: expected 204, got %d: %s", name, res.Code, res.Body.String())
+		}
+		if got := listTotal(name, ""); got != 0 {
+			t.Fatalf("%s: expected the archived record to be hidden, got %d", name, got)
+		}
+		if got := listTotal(name, "?archived=true&filter="+url.QueryEscape("id != ''")); got != 1 {
+			t.Fatalf("%s: expected one archived record, got %d", name, got)
+		}
+	}
+
+	if !closed {
+		t.Fatal("expected archiving the server to close its connections")
+	}
+	if _, err := servers.ResolveConfigForUserID(te.app, server.Id, ""); err == nil {
+		t.Fatal("expected an archived server not to resolve")
+	}
+	if res := te.doRegisteredRoute(t, http.MethodGet, "/api/servers/"+server.Id+"/summary", "", auth); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an archived server summary, got %d", res.Code)
+	}
+	if res := te.doRegisteredRoute(t, http.MethodGet, "/api/servers/connection", "", auth); strings.Contains(res.Body.String(), server.Id) {
+		t.Fatalf("expected the archived server to be left out of the view: %s", res.Body.String())
+	}
+
+	for _, url := range []string{"/api/servers/" + server.Id + "/restore", "/api/secrets/" + secret.Id + "/restore"} {
+		if res := te.doRegisteredRoute(t, http.MethodPost, url, "", auth); res.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", url, res.Code, res.Body.String())
+		}
+		if res := te.doRegisteredRoute(t, http.MethodPost, url, "", auth); res.Code != http.StatusConflict {
+			t.Fatalf("%s: expected 409 restoring twice, got %d: %s", url, res.Code, res.Body.String())
+		}
+	}
+	if listTotal("servers", "") != 1 || listTotal("secrets", "") != 1 {
+		t.Fatal("expected restored records to be listed again")
+	}
+	if _, err := servers.ResolveConfigForUserID(te.app, server.Id, ""); err != nil {
+		t.Fatalf("expected a restored server to resolve, got %v", err)
+	}
+	if entries := auditEntriesByAction(t, te, "secret.restore"); len(entries) != 1 {
+		t.Fatalf("expected one secret.restore audit entry, got %d", len(entries))
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// Lint
+// ═══════════════════════════════════════════════════════════
+
+func TestScriptLintReportsSyntaxErrors(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts", `{"name":"broken","language":"bash","code":"if true; then\necho x\n"}`, true)
+	var created map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	id := created["id"].(string)
+
+	rec = te.do(t, http.MethodPost, "/api/ext/resources/scripts/"+id+"/lint", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lint: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result scriptlint.Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.OK || len(result.Issues) == 0 || result.Issues[0].Line == 0 {
+		t.Fatalf("expected a located syntax error, got %+v", result)
+	}
+
+	rec = te.do(t, http.MethodPost, "/api/ext/resources/scripts/lint", `{"language":"bash","code":"echo ok"}`, true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ok":true`) {
+		t.Fatalf("draft lint: expected clean result, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = te.do(t, http.MethodPost, "/api/ext/resources/scripts/lint", `{"language":"cobol","code":"x"}`, true)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("draft lint: expected 422 for unknown language, got %d", rec.Code)
+	}
+}
+
+func TestScriptSaveReportsFieldErrors(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	fieldCode := func(body []byte, field string) string {
+		t.Helper()
+		var resp struct {
+			Message string                    `json:"message"`
+			Data    map[string]map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Message != "Failed to save record." {
+			t.Fatalf("unexpected message %q", resp.Message)
+		}
+		if msg, _ := resp.Data[field]["message"].(string); msg == "" {
+			t.Fatalf("expected a message for %s, got %+v", field, resp.Data)
+		}
+		code, _ := resp.Data[field]["code"].(string)
+		return code
+	}
+
+	rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts", `{"language":"bash","code":"echo hi"}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("create: expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := fieldCode(rec.Body.Bytes(), "name"); code != "validation_required" {
+		t.Fatalf("create: expected validation_required for name, got %q", code)
+	}
+
+	rec = te.do(t, http.MethodPost, "/api/ext/resources/scripts", `{"name":"rotate-logs","language":"bash","code":"echo hi"}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = te.do(t, http.MethodPut, "/api/ext/resources/scripts/"+created["id"].(string), `{"language":"cobol"}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("update: expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := fieldCode(rec.Body.Bytes(), "language"); code != "validation_invalid_value" {
+		t.Fatalf("update: expected validation_invalid_value for language, got %q", code)
+	}
+}
+
+func TestDatabaseConnectionTest(t *testing.T) {
+	te := resolverTestEnv(t)
+	defer te.cleanup()
+
+	// A Redis stand-in that only accepts the secret's password.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					// Commands arrive as RESP arrays; the first bulk string is the name.
+					header, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					count, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+					args := make([]string, 0, count)
+					for range count {
+						sizeLine, _ := r.ReadString('\n')
+						size, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(sizeLine, "$")))
+						arg := make([]byte, size+2)
+						if _, err := io.ReadFull(r, arg); err != nil {
+							return
+						}
+						args = append(args, string(arg[:size]))
+					}
+					if len(args) == 0 {
+						return
+					}
+					switch strings.ToUpper(args[0]) {
+					case "AUTH":
+						if args[len(args)-1] != "s3cret" {
+							_, _ = io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+							continue
+						}
+						_, _ = io.WriteString(conn, "+OK\r\n")
+					case "PING":
+						_, _ = io.WriteString(conn, "+PONG\r\n")
+					case "INFO":
+						info := "redis_version:7.2.4\r\n"
+						_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(info), info)
+					default:
+						_, _ = fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+					}
+				}
+			}()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	secretID := createTestSecret(t, te, "cache-password", "active", "global", "user1", map[string]any{"value": "s3cret"})
+	col, err := te.app.FindCollectionByNameOrId("databases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDatabase := func(name string, port int) string {
+		t.Helper()
+		record := core.NewRecord(col)
+		record.Set("name", name)
+		record.Set("type", "redis")
+		record.Set("host", "127.0.0.1")
+		record.Set("port", port)
+		record.Set("password", secretID)
+		if err := te.app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+		return record.Id
+	}
+	probe := func(id, query string) map[string]any {
+		t.Helper()
+		rec := te.do(t, http.MethodPost, "/api/ext/resources/databases/"+id+"/test"+query, "", true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var result map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	cacheID := newDatabase("cache", port)
+	result := probe(cacheID, "")
+	if result["status"] != "online" || result["server_version"] != "7.2.4" {
+		t.Fatalf("expected online 7.2.4, got %v", result)
+	}
+	if strings.Contains(fmt.Sprint(result), "s3cret") {
+		t.Fatalf("response leaks the password: %v", result)
+	}
+	if entries := auditEntriesByAction(t, te, "database.test"); len(entries) != 1 || entries[0].GetString("resource_id") != cacheID {
+		t.Fatalf("expected one database.test audit entry, got %d", len(entries))
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	_ = closed.Close()
+	result = probe(newDatabase("gone", closedPort), "?timeout=2")
+	if result["status"] != "offline" || result["reason"] == "" {
+		t.Fatalf("expected offline with a reason, got %v", result)
+	}
+
+	if rec := te.do(t, http.MethodPost, "/api/ext/resources/databases/"+cacheID+"/test?timeout=0", "", true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid timeout, got %d", rec.Code)
+	}
+	if rec := te.do(t, http.MethodPost, "/api/ext/resources/databases/missing/test", "", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown database, got %d", rec.Code)
+	}
+	if rec := te.do(t, http.MethodPost, "/api/ext/resources/databases/"+cacheID+"/test", "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d", rec.Code)
+	}
+}
+
+func TestResourceActivityListsAuditEntriesNewestFirst(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	for _, entry := range []audit.Entry{
+		{UserID: "u1", Action: "server.update", ResourceType: "server", ResourceID: "srv1", Status: audit.StatusSuccess},
+		{UserID: "u1", Action: "server.ops.power", ResourceType: "server", ResourceID: "srv1", Status: audit.StatusFailed},
+		{UserID: "u1", Action: "server.update", ResourceType: "server", ResourceID: "srv2", Status: audit.StatusSuccess},
+		{UserID: "u1", Action: "app.update", ResourceType: "app", ResourceID: "srv1", Status: audit.StatusSuccess},
+	} {
+		audit.Write(te.app, entry)
+		time.Sleep(2 * time.Millisecond) // distinct created timestamps
+	}
+
+	activity := func(url string) (actions []string, total int) {
+		t.Helper()
+		rec := te.do(t, http.MethodGet, url, "", true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", url, rec.Code, rec.Body.String())
+		}
+		var page struct {
+			Items []struct {
+				Action     string `json:"action"`
+				ResourceID string `json:"resource_id"`
+			} `json:"items"`
+			Total int `json:"total"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			actions = append(actions, item.Action)
+		}
+		return actions, page.Total
+	}
+
+	actions, total := activity("/api/ext/resources/servers/srv1/activity")
+	if total != 2 || strings.Join(actions, ",") != "server.ops.power,server.update" {
+		t.Fatalf("expected srv1's two server entries newest first, got %v (total %d)", actions, total)
+	}
+	actions, total = activity("/api/ext/resources/server/srv1/activity?limit=1")
+	if total != 2 || len(actions) != 1 || actions[0] != "server.ops.power" {
+		t.Fatalf("expected one entry of two, got %v (total %d)", actions, total)
+	}
+	actions, _ = activity("/api/ext/resources/servers/srv1/activity?action=server.update")
+	if strings.Join(actions, ",") != "server.update" {
+		t.Fatalf("expected the action filter to apply, got %v", actions)
+	}
+	if actions, total = activity("/api/ext/resources/apps/srv1/activity"); total != 1 || actions[0] != "app.update" {
+		t.Fatalf("expected the app entry only, got %v", actions)
+	}
+
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/widgets/srv1/activity", "", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown type, got %d", rec.Code)
+	}
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/servers/srv1/activity?action=Bad%20Action", "", true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid action, got %d", rec.Code)
+	}
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/servers/srv1/activity", "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d", rec.Code)
+	}
+}
+
+func TestScriptListPaginatesFiltersAndSorts(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+	groupID := seedGroup(t, te, "ops")
+
+	for i, name := range []string{"backup-db", "rotate-logs", "backup-files", "cleanup", "deploy"} {
+		body := fmt.Sprintf(`{"name":%q,"language":"bash","code":"echo %d"}`, name, i)
+		if name == "backup-db" || name == "cleanup" {
+			body = fmt.Sprintf(`{"name":%q,"language":"bash","code":"echo %d","groups":[%q]}`, name, i, groupID)
+		}
+		if rec := te.do(t, http.MethodPost, "/api/ext/resources/scripts", body, true); rec.Code != http.StatusOK {
+			t.Fatalf("create %s: expected 200, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	type listPage struct {
+		Items      []map[string]any `json:"items"`
+		Page       int              `json:"page"`
+		PerPage    int              `json:"perPage"`
+		TotalItems int              `json:"totalItems"`
+		TotalPages int              `json:"totalPages"`
+	}
+	list := func(query string) (listPage, []string) {
+		t.Helper()
+		rec := te.do(t, http.MethodGet, "/api/ext/resources/scripts"+query, "", true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list %s: expected 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var page listPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			names = append(names, item["name"].(string))
+		}
+		return page, names
+	}
+
+	page, names := list("")
+	if page.Page != 1 || page.PerPage != 50 || page.TotalItems != 5 || page.TotalPages != 1 || len(names) != 5 {
+		t.Fatalf("unexpected default page: %+v", page)
+	}
+	page, names = list("?sort=-name&perPage=2&page=2")
+	if page.TotalItems != 5 || page.TotalPages != 3 || strings.Join(names, ",") != "cleanup,backup-files" {
+		t.Fatalf("unexpected second page: %v (%+v)", names, page)
+	}
+	if page, _ = list("?perPage=5000"); page.PerPage != 500 {
+		t.Fatalf("expected perPage to be capped at 500, got %d", page.PerPage)
+	}
+	page, names = list("?filter=BACKUP&sort=name")
+	if page.TotalItems != 2 || strings.Join(names, ",") != "backup-db,backup-files" {
+		t.Fatalf("expected the name filter to match both backups, got %v", names)
+	}
+	page, names = list("?group=" + groupID + "&sort=name")
+	if page.TotalItems != 2 || strings.Join(names, ",") != "backup-db,cleanup" {
+		t.Fatalf("expected the group's two members, got %v (%+v)", names, page)
+	}
+	page, names = list("?group=" + groupID + "&filter=clean")
+	if page.TotalItems != 1 || strings.Join(names, ",") != "cleanup" {
+		t.Fatalf("expected filters to combine, got %v", names)
+	}
+	// Filter values are bound, so an expression in them is matched literally.
+	if page, _ = list("?filter=" + url.QueryEscape("x' || id != '")); page.TotalItems != 0 {
+		t.Fatalf("expected the filter text to be matched literally, got %d items", page.TotalItems)
+	}
+
+	// Hidden fields are neither returned nor sortable.
+	col, err := te.app.FindCollectionByNameOrId("scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	col.Fields.GetByName("code").SetHidden(true)
+	if err := te.app.Save(col); err != nil {
+		t.Fatal(err)
+	}
+	if page, _ = list(""); page.Items[0]["code"] != nil {
+		t.Fatalf("hidden field returned: %v", page.Items[0])
+	}
+	for _, query := range []string{"?sort=code", "?sort=name%3Bdrop", "?page=0", "?perPage=-1"} {
+		if rec := te.do(t, http.MethodGet, "/api/ext/resources/scripts"+query, "", true); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCertificatesExpiringWindow(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	col, err := te.app.FindCollectionByNameOrId("certificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, c := range []struct {
+		name, status string
+		expiresIn    time.Duration
+	}{
+		{"soon", "active", 10 * 24 * time.Hour},
+		{"sooner", "active", 2 * 24 * time.Hour},
+		{"later", "active", 60 * 24 * time.Hour},
+		{"past", "expired", -24 * time.Hour},
+		{"revoked", "revoked", 5 * 24 * time.Hour},
+	} {
+		rec := core.NewRecord(col)
+		rec.Set("name", c.name)
+		rec.Set("domain", c.name+".example.com")
+		rec.Set("status", c.status)
+		rec.Set("expires_at", now.Add(c.expiresIn).Format(time.RFC3339))
+		if err := te.app.Save(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expiring := func(url string) []string {
+		t.Helper()
+		rec := te.do(t, http.MethodGet, url, "", true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", url, rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Items []struct {
+				Name     string `json:"name"`
+				DaysLeft int    `json:"days_left"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, item := range body.Items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	if got := expiring("/api/ext/resources/certificates/expiring"); !slices.Equal(got, []string{"sooner", "soon"}) {
+		t.Fatalf("expected sooner, soon within 30 days, got %v", got)
+	}
+	if got := expiring("/api/ext/resources/certificates/expiring?days=90"); !slices.Equal(got, []string{"sooner", "soon", "later"}) {
+		t.Fatalf("expected three certificates within 90 days, got %v", got)
+	}
+	if rec := te.do(t, http.MethodGet, "/api/ext/resources/certificates/expiring?days=0", "", true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for days=0, got %d", rec.Code)
+	}
+}
+
+func TestResourceExportImportRoundTrip(t *testing.T) {
+	ensureConnectorSecretRuntime(t)
+	if err := secrets.LoadTemplatesFromDefaultPath(); err != nil {
+		t.Fatal(err)
+	}
+	src := newTestEnv(t)
+	defer src.cleanup()
+
+	secretsCol, err := src.app.FindCollectionByNameOrId("secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := core.NewRecord(secretsCol)
+	secret.Set("name", "deploy-password")
+	secret.Set("template_id", "single_value")
+	secret.Set("scope", "global")
+	secret.Set("access_mode", "use_only")
+	secret.Set("status", "active")
+	secret.Set("created_by", "u1")
+	enc, err := secrets.EncryptPayload(map[string]any{"value": "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Set("payload_encrypted", enc)
+	if err := src.app.Save(secret); err != nil {
+		t.Fatal(err)
+	}
+	server := createServerRecord(t, src, "web-1", "10.0.0.1", 22, "root", "password")
+	server.Set("credential", secret.Id)
+	if err := src.app.Save(server); err != nil {
+		t.Fatal(err)
+	}
+	groupsCol, err := src.app.FindCollectionByNameOrId(groups.Collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := core.NewRecord(groupsCol)
+	group.Set("name", "production")
+	group.Set("created_by", "u1")
+	if err := src.app.Save(group); err != nil {
+		t.Fatal(err)
+	}
+	if err := groups.Assign(src.app, groups.ObjectTypeServer, server.Id, []string{group.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := src.do(t, http.MethodGet, "/api/ext/resources/export", "", true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a passphrase, got %d: %s", rec.Code, rec.Body.String())
+	}
+	doc, err := transfer.Export(src.app, "correct horse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "hunter2") {
+		t.Fatal("export must not contain secret values in clear text")
+	}
+
+	dst := newTestEnv(t)
+	defer dst.cleanup()
+	importDoc := func(passphrase string) *httptest.ResponseRecorder {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{"passphrase": passphrase, "document": doc})
+		return dst.do(t, http.MethodPost, "/api/ext/resources/import", string(body), true)
+	}
+
+	if rec := importDoc("wrong horse"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a wrong passphrase, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := importDoc("correct horse")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report transfer.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 2 || report.Failed != 0 {
+		t.Fatalf("expected 2 created and none failed, got %+v", report)
+	}
+
+	imported, err := dst.app.FindFirstRecordByData("servers", "name", "web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	importedSecret, err := dst.app.FindFirstRecordByData("secrets", "name", "deploy-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := imported.GetString("credential"); got != importedSecret.Id {
+		t.Fatalf("expected credential remapped to %s, got %s", importedSecret.Id, got)
+	}
+	payload, err := secrets.DecryptPayload(importedSecret.GetString("payload_encrypted"))
+	if err != nil || payload["value"] != "hunter2" {
+		t.Fatalf("expected payload to survive the round trip, got %v (%v)", payload, err)
+	}
+	items, err := dst.app.FindAllRecords(groups.ItemsCollection, dbx.HashExp{"object_id": imported.Id})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected the server to keep its group membership, got %d items (%v)", len(items), err)
+	}
+
+	rec = importDoc("correct horse")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on re-import, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || report.Skipped != 2 || report.Groups[0].Status != transfer.StatusExists {
+		t.Fatalf("expected re-import to skip everything, got %+v", report)
+	}
+}
+
+func TestResourceImportAcceptsExportsOverDefaultBodyLimit(t *testing.T) {
+	ensureConnectorSecretRuntime(t)
+	src := newTestEnv(t)
+	defer src.cleanup()
+
+	scriptsCol, err := src.app.FindCollectionByNameOrId("scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := make([]byte, 2400)
+	for i := 0; i < 500; i++ {
+		if _, err := rand.Read(chunk); err != nil {
+			t.Fatal(err)
+		}
+		script := core.NewRecord(scriptsCol)
+		script.Set("name", fmt.Sprintf("script-%03d", i))
+		script.Set("language", "bash")
+		script.Set("code", hex.EncodeToString(chunk))
+		if err := src.app.Save(script); err != nil {
+			t.Fatal(err)
+		}
+	}
+	doc, err := transfer.Export(src.app, "correct horse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(map[string]any{"passphrase": "correct horse", "document": doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) <= defaultMaxBodyMB<<20 {
+		t.Fatalf("expected an import body over %d MB, got %d bytes", defaultMaxBodyMB, len(body))
+	}
+
+	dst := newTestEnv(t)
+	defer dst.cleanup()
+	r, err := apis.NewRouter(dst.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(requestBodyLimit())
+	registerResourceRoutes(g)
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/ext/resources/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", dst.token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %.300s", rec.Code, rec.Body.String())
+	}
+	var report transfer.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 500 || report.Failed != 0 {
+		t.Fatalf("expected 500 scripts created, got created=%d failed=%d", report.Created, report.Failed)
+	}
+}
@@ -6,6 +6,8 @@
 //   - /api/ext/system     — system metrics, file browser
 //   - /api/ext/backup     — backup/restore operations
 //   - /api/ext/resources  — Resource Store CRUD (Epic 8)
+//   - /api/ext/groups     — cross-type group member listing
+//   - /api/ext/stepup     — TOTP enrollment for step-up confirmation
 //   - /api/space         — User private space (Epic 9)
 //   - /api/components     — component inventory and runtime service diagnostics (Epic 6)
 //   - /api/catalog        — app catalog normalized read APIs
@@ -26,6 +28,10 @@ import (
 	"github.com/hibiken/asynq"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/terminal"
 )
 
 // asynqClient is set by main via SetAsynqClient after creating the worker.
@@ -39,6 +45,12 @@ func SetAsynqClient(c *asynq.Client) {
 
 // Register mounts all custom route groups on the PocketBase router.
 func Register(se *core.ServeEvent) {
+	// Gzip for compressible responses (configurable via http/compression)
+	se.Router.Bind(responseCompression())
+
+	// Archived servers, secrets and scripts stay out of record-API lists
+	se.Router.BindFunc(archive.HideArchived)
+
 	// OpenAPI docs — public, no auth required
 	registerOpenAPIRoutes(se)
 
@@ -86,11 +98,18 @@ func Register(se *core.ServeEvent) {
 	terminalGroup.Bind(wsTokenAuth())
 	terminalGroup.Bind(apis.RequireSuperuserAuth())
 
+	// Cap mutating request bodies; upload routes bind larger limits
+	for _, group := range []*router.RouterGroup[*core.RequestEvent]{g, components, deployments, servers, softwareGroup, terminalGroup} {
+		group.Bind(requestBodyLimit())
+	}
+
 	registerDockerRoutes(g)
 	registerProxyRoutes(g)
 	registerSystemRoutes(g)
 	registerBackupRoutes(g)
 	registerResourceRoutes(g)
+	registerGroupRoutes(g)
+	registerStepUpRoutes(g)
 	registerAIProviderRoutes(se)
 	registerConnectorRoutes(se)
 	registerInstanceRoutes(se)
@@ -103,13 +122,17 @@ func Register(se *core.ServeEvent) {
 	registerReleaseRoutes(deployments)
 	registerExposureRoutes(deployments)
 	registerIaCRoutes(g)
+	registerAuditRoutes(g)
 	registerServerRoutes(servers)
 	registerSoftwareRoutes(servers)
 	registerLocalSoftwareRoutes(softwareGroup)
 	registerTerminalRoutes(terminalGroup)
+	terminal.SetSessionLimits(func() terminal.SessionLimits { return terminalSessionLimits(se.App) })
 	registerTunnelRoutes(se)
+	registerServerDeleteHooks(se.App)
 	registerMonitorRoutes(se)
 	registerSecretsRoutes(se)
+	registerSavedCommandRoutes(se)
 	registerCertificatesRoutes(se)
 	registerCronLogsRoute(se)
 }
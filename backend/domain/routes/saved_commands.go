@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/savedcommands"
+	"github.com/websoft9/appos/backend/domain/terminal"
+)
+
+// maxSavedCommandOutput caps the command output kept in the audit log.
+const maxSavedCommandOutput = 4096
+
+func registerSavedCommandRoutes(se *core.ServeEvent) {
+	registerSavedCommandGroup(se.Router.Group("/api/saved-commands"))
+}
+
+func registerSavedCommandGroup(g *router.RouterGroup[*core.RequestEvent]) {
+	// Any authenticated user may call; the command itself decides who runs it.
+	g.Bind(apis.RequireAuth())
+	g.Bind(routeRateLimit(rateLimitServerOps))
+	g.POST("/{id}/run", handleSavedCommandRun)
+}
+
+// handleSavedCommandRun runs an approved command template on a server.
+//
+// @Summary Run a saved command
+// @Description Renders a saved command with the given parameters and runs it on a server over SSH. Superusers may run any enabled command; other users only enabled commands that list them in allowed_users. Each value must fully match its parameter pattern and is shell-quoted before substitution. The command must be approved for the server (an empty servers list approves every server). Superusers may set as_user to run the command as that account through the server's sudo or doas. Every run is audited.
+// @Tags Servers
+// @Security BearerAuth
+// @Param id path string true "saved command ID"
+// @Param body body object true "server_id, params (name → value) and optional as_user"
+// @Success 200 {object} map[string]any "command_id, server_id, status, output"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 502 {object} map[string]any "command failed; output included"
+// @Router /api/saved-commands/{id}/run [post]
+func handleSavedCommandRun(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById(savedcommands.Collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("saved command not found", err)
+	}
+	command := savedcommands.From(record)
+
+	var body struct {
+		ServerID string            `json:"server_id"`
+		Params   map[string]string `json:"params"`
+		AsUser   string            `json:"as_user"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("invalid request body", err)
+	}
+	body.ServerID = strings.TrimSpace(body.ServerID)
+	if body.ServerID == "" {
+		return e.BadRequestError("server_id is required", nil)
+	}
+
+	if err := command.CheckRunner(e.Auth); err != nil {
+		if errors.Is(err, savedcommands.ErrDisabled) {
+			return e.BadRequestError(err.Error(), nil)
+		}
+		return e.ForbiddenError(err.Error(), nil)
+	}
+	if err := command.CheckServer(body.ServerID); err != nil {
+		return e.ForbiddenError(err.Error(), nil)
+	}
+	rendered, err := command.Render(body.Params)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	asUser, err := parseRunAsUser(e, body.AsUser)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+
+	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, body.ServerID)
+	if resolveErr != nil {
+		return serverConfigError(e, resolveErr)
+	}
+	cfg.RunAs = asUser
+	rendered, err = cfg.AsUser(rendered)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	output, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, rendered, command.Timeout())
+
+	userID, _, ip, _ := clientInfo(e)
+	status := audit.StatusSuccess
+	detail := map[string]any{
+		"command_id":   command.ID(),
+		"command_name": command.Name(),
+		"params":       body.Params,
+		"output":       truncateSavedCommandOutput(output),
+	}
+	if cfg.RunAs != "" {
+		detail["as_user"] = cfg.RunAs
+	}
+	if runErr != nil {
+		status = audit.StatusFailed
+		detail["error"] = runErr.Error()
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "server.ops.saved_command.run",
+		ResourceType: "server",
+		ResourceID:   body.ServerID,
+		Status:       status,
+		IP:           ip,
+		Detail:       detail,
+	})
+
+	if runErr != nil {
+		return e.JSON(http.StatusBadGateway, map[string]any{
+			"message":    runErr.Error(),
+			"command_id": command.ID(),
+			"server_id":  body.ServerID,
+			"status":     "failed",
+			"output":     output,
+		})
+	}
+	return e.JSON(http.StatusOK, map[string]any{
+		"command_id": command.ID(),
+		"server_id":  body.ServerID,
+		"status":     "succeeded",
+		"output":     output,
+	})
+}
+
+func truncateSavedCommandOutput(output string) string {
+	if len(output) <= maxSavedCommandOutput {
+		return output
+	}
+	return output[:maxSavedCommandOutput] + "…(truncated)"
+}
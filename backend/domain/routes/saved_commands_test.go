@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/savedcommands"
+)
+
+func doSavedCommand(t *testing.T, te *testEnv, url, body, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerSavedCommandGroup(r.Group("/api/saved-commands"))
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSavedCommandRunEnforcesDelegation(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+	savedcommands.RegisterHooks(te.app)
+
+	approved := createTunnelServerRecord(t, te, "ops-approved")
+	other := createTunnelServerRecord(t, te, "ops-other")
+	userToken := createRegularUserToken(t, te)
+	user, err := te.app.FindAuthRecordByEmail("users", "user@test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	col, err := te.app.FindCollectionByNameOrId(savedcommands.Collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := core.NewRecord(col)
+	command.Set("name", "restart-unit")
+	command.Set("command", "systemctl restart {{unit}}")
+	command.Set("params", `[{"name":"unit","pattern":"[a-z0-9-]+"}]`)
+	command.Set("servers", []string{approved.Id})
+	command.Set("enabled", true)
+	if err := te.app.Save(command); err != nil {
+		t.Fatal(err)
+	}
+	runURL := "/api/saved-commands/" + command.Id + "/run"
+	runBody := func(serverID, unit string) string {
+		return `{"server_id":"` + serverID + `","params":{"unit":"` + unit + `"}}`
+	}
+
+	if rec := doSavedCommand(t, te, runURL, runBody(approved.Id, "nginx"), ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doSavedCommand(t, te, runURL, runBody(approved.Id, "nginx"), userToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a user not in allowed_users, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	command.Set("allowed_users", []string{user.Id})
+	if err := te.app.Save(command); err != nil {
+		t.Fatal(err)
+	}
+	if rec := doSavedCommand(t, te, runURL, runBody(other.Id, "nginx"), userToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for an unapproved server, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doSavedCommand(t, te, runURL, runBody(approved.Id, "nginx; reboot"), userToken); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a value outside the pattern, got %d: %s", rec.Code, rec.Body.String())
+	}
+	asUserBody := `{"server_id":"` + approved.Id + `","params":{"unit":"nginx"},"as_user":"postgres"}`
+	if rec := doSavedCommand(t, te, runURL, asUserBody, userToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for as_user from a regular user, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	command.Set("enabled", false)
+	if err := te.app.Save(command); err != nil {
+		t.Fatal(err)
+	}
+	if rec := doSavedCommand(t, te, runURL, runBody(approved.Id, "nginx"), te.token); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a disabled command, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	command.Set("command", "rm -rf {{path}}")
+	if err := te.app.Save(command); err == nil {
+		t.Fatal("expected undeclared placeholder to be rejected on save")
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/audit"
 	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/domain/stepup"
 )
 
 func registerSecretsRoutes(se *core.ServeEvent) {
@@ -32,7 +34,7 @@ func registerSecretsGroup(secretsGroup *router.RouterGroup[*core.RequestEvent])
 			return apis.NewUnauthorizedError("authentication required", nil)
 		}
 		id := e.Request.PathValue("id")
-		rec, err := e.App.FindRecordById("secrets", id)
+		rec, err := archive.FindLive(e.App, "secrets", id)
 		if err != nil {
 			return e.NotFoundError("secret not found", err)
 		}
@@ -119,7 +121,7 @@ func registerSecretsGroup(secretsGroup *router.RouterGroup[*core.RequestEvent])
 		})
 
 		return e.JSON(http.StatusOK, map[string]any{"ok": true, "version": newVersion})
-	}).Bind(apis.RequireAuth())
+	}).Bind(apis.RequireAuth(), requireStepUp(stepup.ActionSecretPayloadUpdate))
 
 	secretsGroup.POST("/resolve", func(e *core.RequestEvent) error {
 		internalToken := strings.TrimSpace(os.Getenv("APPOS_INTERNAL_TOKEN"))
@@ -163,12 +165,16 @@ func registerSecretsGroup(secretsGroup *router.RouterGroup[*core.RequestEvent])
 					return e.NotFoundError("secret not found", err)
 				case secrets.ReasonRevoked:
 					return apis.NewForbiddenError("secret is revoked", nil)
+				case secrets.ReasonArchived:
+					return apis.NewForbiddenError("secret is archived", nil)
 				case secrets.ReasonExpired:
 					return apis.NewForbiddenError("secret has expired", nil)
 				case secrets.ReasonAccessDenied:
 					return apis.NewForbiddenError("secret is not accessible", nil)
 				case "secret has no payload":
 					return e.BadRequestError("secret has no payload", nil)
+				case secrets.ReasonDecryptFailed:
+					return credentialDecryptFailed(e)
 				}
 			}
 			return e.InternalServerError("resolve failed", err)
@@ -181,6 +187,36 @@ func registerSecretsGroup(secretsGroup *router.RouterGroup[*core.RequestEvent])
 		return e.JSON(http.StatusOK, resp)
 	})
 
+	// Restore a secret archived by a records-API delete; like deleting, only
+	// superusers may.
+	secretsGroup.POST("/{id}/restore", func(e *core.RequestEvent) error {
+		id := e.Request.PathValue("id")
+		rec, err := e.App.FindRecordById("secrets", id)
+		if err != nil {
+			return apis.NewNotFoundError("secret not found", err)
+		}
+		if err := archive.Restore(e.App, rec); err != nil {
+			if errors.Is(err, archive.ErrNotArchived) {
+				return apis.NewApiError(http.StatusConflict, err.Error(), nil)
+			}
+			return apis.NewInternalServerError("failed to restore secret", err)
+		}
+
+		s := secrets.From(rec)
+		audit.Write(e.App, audit.Entry{
+			UserID:       e.Auth.Id,
+			UserEmail:    e.Auth.GetString("email"),
+			Action:       "secret.restore",
+			ResourceType: "secret",
+			ResourceID:   s.ID(),
+			ResourceName: s.Name(),
+			Status:       audit.StatusSuccess,
+			IP:           e.RealIP(),
+			UserAgent:    e.Request.Header.Get("User-Agent"),
+		})
+		return e.JSON(http.StatusOK, recordToMap(rec))
+	}).Bind(apis.RequireSuperuserAuth())
+
 	reveal := secretsGroup.Group("/{id}/reveal")
 	reveal.Bind(apis.RequireAuth())
 	reveal.GET("", func(e *core.RequestEvent) error {
@@ -201,6 +237,8 @@ func registerSecretsGroup(secretsGroup *router.RouterGroup[*core.RequestEvent])
 				return apis.NewForbiddenError("secret has expired", nil)
 			case errors.Is(err, secrets.ErrRevealNotAllowed):
 				return apis.NewForbiddenError("reveal disabled", nil)
+			case errors.Is(err, secrets.ErrDecryptFailed):
+				return credentialDecryptFailed(e)
 			default:
 				return apis.NewBadRequestError("reveal failed", err)
 			}
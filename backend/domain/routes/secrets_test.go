@@ -294,3 +294,41 @@ func TestSecretsPayloadUpdateLegacyTunnelTokenForbidden(t *testing.T) {
 		t.Fatalf("expected reason code in response, got %s", res.Body.String())
 	}
 }
+
+func TestSecretsResolveReportsDecryptFailure(t *testing.T) {
+	te := newSecretsTestEnv(t)
+	defer te.cleanup()
+
+	col, err := te.app.FindCollectionByNameOrId("secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := core.NewRecord(col)
+	rec.Set("name", "route-secret-corrupted")
+	rec.Set("template_id", "single_value")
+	rec.Set("scope", "global")
+	rec.Set("access_mode", "use_only")
+	rec.Set("status", "active")
+	rec.Set("created_by", "u1")
+	rec.Set("payload_encrypted", `{"nonce":"AAAAAAAAAAAAAAAA","ciphertext":"AAAAAAAAAAAAAAAAAAAAAAAA"}`)
+	rec.Set("version", 1)
+	if err := te.app.Save(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	res := doSecretsRoute(t, te, http.MethodPost, "/api/secrets/resolve", `{"secret_id":"`+rec.Id+`"}`, false, true)
+	if res.Code != http.StatusInternalServerError || !strings.Contains(res.Body.String(), secrets.ReasonCodeDecryptFailed) {
+		t.Fatalf("expected 500 %s, got %d: %s", secrets.ReasonCodeDecryptFailed, res.Code, res.Body.String())
+	}
+	if strings.Contains(res.Body.String(), "AAAAAAAA") {
+		t.Fatalf("response must not echo the ciphertext: %s", res.Body.String())
+	}
+
+	entries := auditEntriesByAction(t, te, "secret.decrypt_failed")
+	if len(entries) != 1 || entries[0].GetString("resource_id") != rec.Id {
+		t.Fatalf("expected one decrypt failure audit entry for %s, got %d", rec.Id, len(entries))
+	}
+	if strings.Contains(entries[0].GetString("detail"), "AAAAAAAA") {
+		t.Fatalf("audit detail must not contain the ciphertext: %s", entries[0].GetString("detail"))
+	}
+}
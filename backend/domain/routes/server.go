@@ -153,7 +153,26 @@ func registerServerRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 
 	g.GET("/connection", handleServersView)
 	g.GET("/local/docker-bridge", handleLocalDockerBridge)
+	g.GET("/{serverId}/summary", handleServerSummary)
+	g.POST("/{serverId}/restore", handleServerRestore)
 	registerServerOpsRoutes(g)
+	registerServerTemplateRoutes(g)
+}
+
+// handleServerRestore brings back a server archived by a records-API delete.
+//
+// @Summary Restore server
+// @Description Clears the archive mark of a server, so it is listed and connectable again. Superuser only.
+// @Tags Servers
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any "server is not archived"
+// @Router /api/servers/{serverId}/restore [post]
+func handleServerRestore(e *core.RequestEvent) error {
+	return restoreRecord(e, "servers", e.Request.PathValue("serverId"))
 }
 
 func handleLocalDockerBridge(e *core.RequestEvent) error {
@@ -184,6 +203,8 @@ func handleLocalDockerBridge(e *core.RequestEvent) error {
 func registerTerminalRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	registerServerShellRoutes(g)
 	registerServerFileRoutes(g)
+	registerServerBroadcastRoutes(g)
+	registerServerFilePathRoutes(g)
 	registerServerContainerRoutes(g)
 	registerLocalTerminalRoutes(g)
 }
@@ -1,11 +1,24 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/secrets"
 	"github.com/websoft9/appos/backend/domain/terminal"
 )
 
+// serverBusyReasonCode is returned in data.reason_code when a server has no
+// free SSH/SFTP session slot.
+const serverBusyReasonCode = "SERVER_BUSY"
+
 func resolveTerminalConfig(app core.App, auth *core.Record, serverID string) (terminal.ConnectorConfig, error) {
 	access, err := servers.ResolveConfig(app, auth, serverID)
 	if err != nil {
@@ -16,11 +29,117 @@ func resolveTerminalConfig(app core.App, auth *core.Record, serverID string) (te
 
 func terminalConfigFromServerAccess(access servers.AccessConfig) terminal.ConnectorConfig {
 	return terminal.ConnectorConfig{
-		Host:     access.Host,
-		Port:     access.Port,
-		User:     access.User,
-		AuthType: terminal.CredAuthType(access.AuthType),
-		Secret:   access.Secret,
-		Shell:    access.Shell,
+		Host:       access.Host,
+		Port:       access.Port,
+		User:       access.User,
+		AuthType:   terminal.CredAuthType(access.AuthType),
+		Secret:     access.Secret,
+		Passphrase: access.Passphrase,
+		Password:   access.Password,
+		Shell:      access.Shell,
+		ProxyJump:  access.ProxyJump,
+		DefaultDir: access.DefaultDir,
+		Env:        access.Env,
+		LegacySSH:  access.LegacySSH,
+		Privilege:  terminal.PrivilegeEscalation(access.PrivilegeEscalation),
+	}
+}
+
+// parseRunAsUser validates an as_user value. Switching accounts is reserved
+// for superusers and the name must be a valid login name; empty is allowed.
+func parseRunAsUser(e *core.RequestEvent, asUser string) (string, error) {
+	asUser = strings.TrimSpace(asUser)
+	if asUser == "" {
+		return "", nil
+	}
+	if !e.HasSuperuserAuth() {
+		return "", errRunAsForbidden
+	}
+	if !terminal.ValidRunAsUser(asUser) {
+		return "", fmt.Errorf("as_user %q is not a valid user name", asUser)
+	}
+	return asUser, nil
+}
+
+// auditRunAs records that serverID was accessed as another account.
+func auditRunAs(e *core.RequestEvent, serverID, asUser, operation string, err error) {
+	status := audit.StatusSuccess
+	detail := map[string]any{"as_user": asUser, "operation": operation}
+	if err != nil {
+		status = audit.StatusFailed
+		detail["errorMessage"] = err.Error()
+	}
+	userID, _, ip, _ := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "server.run_as",
+		ResourceType: "server",
+		ResourceID:   serverID,
+		Status:       status,
+		IP:           ip,
+		Detail:       detail,
+	})
+}
+
+// acquireServerSession reserves one of serverID's connect/terminal
+// maxSessionsPerServer slots before an SSH or SFTP connection is opened.
+func acquireServerSession(app core.App, serverID string) (func(), error) {
+	cfg, _ := sysconfig.GetGroup(app, "connect", "terminal", nil)
+	return terminal.AcquireServerSlot(serverID, sysconfig.Int(cfg, "maxSessionsPerServer", 0))
+}
+
+// terminalSessionLimits reads the connect/terminal idle timeout and maximum
+// session duration enforced by the terminal session janitor.
+func terminalSessionLimits(app core.App) terminal.SessionLimits {
+	cfg, _ := sysconfig.GetGroup(app, "connect", "terminal", nil)
+	return terminal.SessionLimits{
+		Idle:        time.Duration(sysconfig.Int(cfg, "idleTimeoutSeconds", 1800)) * time.Second,
+		MaxDuration: time.Duration(sysconfig.Int(cfg, "maxSessionSeconds", 0)) * time.Second,
+	}
+}
+
+// credentialDecryptFailed writes the stable 500 returned when a stored
+// credential cannot be decrypted. The failure itself was already counted and
+// audited by secrets.ReportDecryptFailure.
+func credentialDecryptFailed(e *core.RequestEvent) error {
+	return e.JSON(http.StatusInternalServerError, map[string]any{
+		"message": "stored credential could not be decrypted; check the encryption key configuration",
+		"data": map[string]any{
+			"reason_code": secrets.ReasonCodeDecryptFailed,
+		},
+	})
+}
+
+// serverConfigError writes a resolveTerminalConfig failure: 500 with
+// CREDENTIAL_DECRYPT_FAILED for decrypt failures and 400 otherwise.
+func serverConfigError(e *core.RequestEvent, err error) error {
+	if errors.Is(err, secrets.ErrDecryptFailed) {
+		return credentialDecryptFailed(e)
+	}
+	return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+}
+
+// errRunAsForbidden rejects as_user from callers who are not superusers.
+var errRunAsForbidden = errors.New("as_user requires superuser")
+
+// serverSessionError writes 429 for a busy server, 500 for an undecryptable
+// credential, 403 for a forbidden as_user and 400 otherwise.
+func serverSessionError(e *core.RequestEvent, err error) error {
+	if errors.Is(err, secrets.ErrDecryptFailed) {
+		return credentialDecryptFailed(e)
+	}
+	if errors.Is(err, errRunAsForbidden) {
+		return e.JSON(http.StatusForbidden, map[string]any{"message": err.Error()})
+	}
+	var busy *terminal.ServerBusyError
+	if errors.As(err, &busy) {
+		return e.JSON(http.StatusTooManyRequests, map[string]any{
+			"message": err.Error(),
+			"data": map[string]any{
+				"reason_code": serverBusyReasonCode,
+				"limit":       busy.Limit,
+			},
+		})
 	}
+	return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
 }
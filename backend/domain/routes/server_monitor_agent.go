@@ -84,7 +84,7 @@ func handleMonitorAgentDeploy(e *core.RequestEvent, resultStatus string) error {
 
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
 	}
 
 	installArgs := []string{"--non-interactive", "--native-only", "--release-channel", monitorAgentStableChannelName}
@@ -106,11 +106,13 @@ func handleMonitorAgentDeploy(e *core.RequestEvent, resultStatus string) error {
 		fmt.Sprintf("if command -v curl >/dev/null 2>&1; then curl -fsSL %s -o \"$tmp_script\"; elif command -v wget >/dev/null 2>&1; then wget -qO \"$tmp_script\" %s; else echo 'curl or wget is required to install Netdata' >&2; exit 1; fi", terminal.ShellQuote(monitorAgentKickstartURL), terminal.ShellQuote(monitorAgentKickstartURL)),
 		"chmod +x \"$tmp_script\"",
 		fmt.Sprintf("printf %s > \"$tmp_config\"", terminal.ShellQuote(exportingConfig)),
-		fmt.Sprintf("(sudo -n env DISABLE_TELEMETRY=1 sh \"$tmp_script\" %s || env DISABLE_TELEMETRY=1 sh \"$tmp_script\" %s)", strings.Join(quotedArgs, " "), strings.Join(quotedArgs, " ")),
-		fmt.Sprintf("(sudo -n install -D -m 0644 \"$tmp_config\" %s || install -D -m 0644 \"$tmp_config\" %s)", terminal.ShellQuote(monitorAgentRemoteExporting), terminal.ShellQuote(monitorAgentRemoteExporting)),
-		fmt.Sprintf("(sudo -n systemctl enable --now %s || systemctl enable --now %s)", terminal.ShellQuote(monitorAgentServiceName), terminal.ShellQuote(monitorAgentServiceName)),
-		fmt.Sprintf("(sudo -n systemctl restart %s || systemctl restart %s)", terminal.ShellQuote(monitorAgentServiceName), terminal.ShellQuote(monitorAgentServiceName)),
+		fmt.Sprintf("env DISABLE_TELEMETRY=1 sh \"$tmp_script\" %s", strings.Join(quotedArgs, " ")),
+		fmt.Sprintf("install -D -m 0644 \"$tmp_config\" %s", terminal.ShellQuote(monitorAgentRemoteExporting)),
+		fmt.Sprintf("systemctl enable --now %s", terminal.ShellQuote(monitorAgentServiceName)),
+		fmt.Sprintf("systemctl restart %s", terminal.ShellQuote(monitorAgentServiceName)),
 	}, " && ")
+	// The whole script runs escalated so sudo prompts at most once.
+	installCmd = cfg.Privileged(installCmd)
 
 	output, runErr := executeSSHCommand(e.Request.Context(), cfg, installCmd, 60*time.Second)
 	if runErr != nil {
@@ -12,15 +12,19 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
 
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/audit"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/stepup"
 	"github.com/websoft9/appos/backend/domain/terminal"
 )
 
 func registerServerOpsRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	serverOps := g.Group("/{serverId}/ops")
+	serverOps.Bind(routeRateLimit(rateLimitServerOps))
 	serverOps.GET("/connectivity", handleServerConnectivity)
-	serverOps.POST("/power", handleServerPower)
+	serverOps.POST("/power", handleServerPower).Bind(requireStepUp(stepup.ActionServerPower))
+	serverOps.POST("/platform/detect", handleServerPlatformDetect)
 	serverOps.GET("/ports", handleServerPortsList)
 	serverOps.GET("/ports/{port}", handleServerPortInspect)
 	serverOps.POST("/ports/{port}/release", handleServerPortRelease)
@@ -33,6 +37,9 @@ func registerServerOpsRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	serverOps.PUT("/systemd/{service}/unit", handleSystemdServiceUnitWrite)
 	serverOps.POST("/systemd/{service}/unit/verify", handleSystemdServiceUnitVerify)
 	serverOps.POST("/systemd/{service}/unit/apply", handleSystemdServiceUnitApply)
+	serverOps.GET("/systemd/{service}/dropins", handleSystemdDropInsList)
+	serverOps.GET("/systemd/{service}/dropins/{name}", handleSystemdDropInRead)
+	serverOps.PUT("/systemd/{service}/dropins/{name}", handleSystemdDropInWrite)
 	serverOps.POST("/monitor-agent/install", handleMonitorAgentInstall)
 	serverOps.POST("/monitor-agent/update", handleMonitorAgentUpdate)
 }
@@ -115,7 +122,7 @@ func handleServerConnectivity(e *core.RequestEvent) error {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "serverId required"})
 	}
 
-	server, err := e.App.FindRecordById("servers", serverID)
+	server, err := archive.FindLive(e.App, "servers", serverID)
 	if err != nil {
 		return e.NotFoundError("server not found", err)
 	}
@@ -164,6 +171,7 @@ func handleServerConnectivity(e *core.RequestEvent) error {
 		_ = sess.Close()
 		response["status"] = "online"
 		response["latency_ms"] = time.Since(start).Milliseconds()
+		detectServerPlatformOnFirstConnect(e.App, serverID, cfg)
 		return e.JSON(http.StatusOK, response)
 	default:
 		probe := directServerAccessProbe(ms.Host, ms.Port)
@@ -194,19 +202,20 @@ func handleServerPower(e *core.RequestEvent) error {
 	var command string
 	switch action {
 	case "restart":
-		command = "(sudo -n systemctl reboot || sudo -n reboot || systemctl reboot || reboot)"
+		command = "systemctl reboot || reboot"
 	case "shutdown":
-		command = "(sudo -n systemctl poweroff || sudo -n shutdown -h now || systemctl poweroff || shutdown -h now)"
+		command = "systemctl poweroff || shutdown -h now"
 	default:
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "action must be restart or shutdown"})
 	}
 
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
 	}
 
-	output, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, command, 20*time.Second)
+	// The fallback covers hosts without systemd, not a missing privilege.
+	output, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, cfg.Privileged(command), 20*time.Second)
 	expectedDisconnect := runErr != nil && isExpectedPowerDisconnect(runErr)
 	userID, _, ip, _ := clientInfo(e)
 	status := audit.StatusSuccess
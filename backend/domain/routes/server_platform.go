@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	"github.com/websoft9/appos/backend/domain/terminal"
+)
+
+const platformDetectTimeout = 20 * time.Second
+
+// platformDetectInFlight dedupes background detections per server ID.
+var platformDetectInFlight sync.Map
+
+// detectServerPlatform runs the platform probe over SSH and caches the result
+// on the server record.
+func detectServerPlatform(ctx context.Context, app core.App, serverID string, cfg terminal.ConnectorConfig) (servers.Platform, error) {
+	output, err := executeSSHCommand(ctx, cfg, servers.PlatformDetectCommand, platformDetectTimeout)
+	if err != nil {
+		return servers.Platform{}, err
+	}
+	platform, err := servers.ParsePlatform(output)
+	if err != nil {
+		return servers.Platform{}, err
+	}
+	if err := servers.SavePlatform(app, serverID, platform); err != nil {
+		return servers.Platform{}, err
+	}
+	return platform, nil
+}
+
+// detectServerPlatformOnFirstConnect detects the platform in the background
+// after a successful SSH connect, unless it is already cached on the record.
+func detectServerPlatformOnFirstConnect(app core.App, serverID string, cfg terminal.ConnectorConfig) {
+	record, err := app.FindRecordById("servers", serverID)
+	if err != nil || servers.PlatformFromRecord(record) != nil {
+		return
+	}
+	if _, running := platformDetectInFlight.LoadOrStore(serverID, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer platformDetectInFlight.Delete(serverID)
+		if _, err := detectServerPlatform(context.Background(), app, serverID, cfg); err != nil {
+			log.Printf("[server-platform] detection failed serverId=%s err=%v", serverID, err)
+		}
+	}()
+}
+
+// handleServerPlatformDetect re-detects and caches the server platform.
+//
+// @Summary Detect server platform
+// @Description Runs OS/platform detection (/etc/os-release, uname, init system, package manager) over SSH and caches the result on the server record. Detection also runs automatically on the first successful SSH connect. Superuser only.
+// @Tags Servers
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 502 {object} map[string]any
+// @Router /api/servers/{serverId}/ops/platform/detect [post]
+func handleServerPlatformDetect(e *core.RequestEvent) error {
+	serverID := strings.TrimSpace(e.Request.PathValue("serverId"))
+	if serverID == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "serverId required"})
+	}
+
+	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
+	if err != nil {
+		return serverConfigError(e, err)
+	}
+
+	platform, detectErr := detectServerPlatform(e.Request.Context(), e.App, serverID, cfg)
+	userID, _, ip, _ := clientInfo(e)
+	status := audit.StatusSuccess
+	if detectErr != nil {
+		status = audit.StatusFailed
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "server.ops.platform_detect",
+		ResourceType: "server",
+		ResourceID:   serverID,
+		Status:       status,
+		IP:           ip,
+	})
+	if detectErr != nil {
+		return e.JSON(http.StatusBadGateway, map[string]any{"message": detectErr.Error()})
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{"server_id": serverID, "platform": platform})
+}
@@ -32,7 +32,7 @@ func handleServerPortsList(e *core.RequestEvent) error {
 
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
 	}
 
 	occupancyByPort := map[int]map[string]any{}
@@ -138,7 +138,7 @@ func handleServerPortInspect(e *core.RequestEvent) error {
 
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
 	}
 
 	result := map[string]any{
@@ -218,7 +218,7 @@ func handleServerPortRelease(e *core.RequestEvent) error {
 
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
 	}
 
 	before, occupancyErr := detectPortOccupancy(e.Request.Context(), cfg, port, protocol)
@@ -252,10 +252,10 @@ func handleServerPortRelease(e *core.RequestEvent) error {
 		var releaseCmd string
 		if mode == "force" {
 			actionTaken = "docker kill"
-			releaseCmd = fmt.Sprintf("(sudo -n docker kill %s || docker kill %s)", terminal.ShellQuote(containerID), terminal.ShellQuote(containerID))
+			releaseCmd = cfg.Privileged("docker kill " + terminal.ShellQuote(containerID))
 		} else {
 			actionTaken = "docker stop"
-			releaseCmd = fmt.Sprintf("(sudo -n docker stop %s || docker stop %s)", terminal.ShellQuote(containerID), terminal.ShellQuote(containerID))
+			releaseCmd = cfg.Privileged("docker stop " + terminal.ShellQuote(containerID))
 		}
 		output, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, releaseCmd, 30*time.Second)
 		if runErr != nil {
@@ -276,13 +276,13 @@ func handleServerPortRelease(e *core.RequestEvent) error {
 		for _, pid := range pidTargets {
 			pidParts = append(pidParts, strconv.Itoa(pid))
 		}
-		termCmd := fmt.Sprintf("for p in %s; do (sudo -n kill -TERM \"$p\" || kill -TERM \"$p\") 2>/dev/null || true; done", strings.Join(pidParts, " "))
+		termCmd := cfg.Privileged(fmt.Sprintf("for p in %s; do kill -TERM \"$p\" 2>/dev/null || true; done", strings.Join(pidParts, " ")))
 		if _, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, termCmd, 20*time.Second); runErr != nil {
 			return e.JSON(http.StatusInternalServerError, map[string]any{"message": runErr.Error()})
 		}
 		if mode == "force" {
 			actionTaken = "kill -TERM then kill -KILL"
-			killCmd := fmt.Sprintf("sleep 1; for p in %s; do (sudo -n kill -KILL \"$p\" || kill -KILL \"$p\") 2>/dev/null || true; done", strings.Join(pidParts, " "))
+			killCmd := "sleep 1; " + cfg.Privileged(fmt.Sprintf("for p in %s; do kill -KILL \"$p\" 2>/dev/null || true; done", strings.Join(pidParts, " ")))
 			if _, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, killCmd, 20*time.Second); runErr != nil {
 				return e.JSON(http.StatusInternalServerError, map[string]any{"message": runErr.Error()})
 			}
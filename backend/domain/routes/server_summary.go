@@ -0,0 +1,258 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	serversvc "github.com/websoft9/appos/backend/domain/resource/servers/service"
+	"github.com/websoft9/appos/backend/domain/terminal"
+	"github.com/websoft9/appos/backend/infra/docker"
+)
+
+// The summary gives up on the whole request after serverSummaryTimeout and on
+// any single section after serverSummarySectionTimeout, so one slow probe
+// cannot hold up the rest.
+var (
+	serverSummaryTimeout        = 15 * time.Second
+	serverSummarySectionTimeout = 8 * time.Second
+)
+
+// serverMetricsCommand prints load averages, memory and root filesystem usage
+// in one SSH round trip; parseServerMetrics reads its output.
+const serverMetricsCommand = "cat /proc/loadavg; grep -E '^(MemTotal|MemAvailable):' /proc/meminfo; df -Pk /"
+
+type serverSummary struct {
+	ServerID     string                    `json:"server_id"`
+	Name         string                    `json:"name"`
+	ConnectType  string                    `json:"connect_type"`
+	Reachability serverSummaryReachability `json:"reachability"`
+	Metrics      serverSummaryMetrics      `json:"metrics"`
+	Docker       serverSummaryDocker       `json:"docker"`
+	// Tunnel is only reported for tunnel servers.
+	Tunnel *serverSummaryTunnel `json:"tunnel,omitempty"`
+}
+
+type serverSummaryReachability struct {
+	Status    string `json:"status"`
+	Mode      string `json:"mode"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type serverSummaryMetrics struct {
+	Load1              float64 `json:"load1"`
+	Load5              float64 `json:"load5"`
+	Load15             float64 `json:"load15"`
+	MemTotalBytes      int64   `json:"mem_total_bytes"`
+	MemAvailableBytes  int64   `json:"mem_available_bytes"`
+	DiskTotalBytes     int64   `json:"disk_total_bytes"`
+	DiskUsedBytes      int64   `json:"disk_used_bytes"`
+	DiskAvailableBytes int64   `json:"disk_available_bytes"`
+	Error              string  `json:"error,omitempty"`
+}
+
+type serverSummaryDocker struct {
+	// Status is running, not_installed, not_running or unknown.
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type serverSummaryTunnel struct {
+	serversvc.TunnelStatusResult
+	Error string `json:"error,omitempty"`
+}
+
+// handleServerSummary gathers a server's reachability, metrics, docker daemon
+// status and tunnel status concurrently, so a dashboard card needs one request.
+//
+// @Summary Get server summary
+// @Description Collects reachability (tunnel session or TCP probe), load/memory/root disk metrics over SSH, docker daemon status and, for tunnel servers, tunnel status in parallel. Each section reports its own error and is cut off after a per-section timeout; a failing section does not fail the request. Superuser only.
+// @Tags Servers
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Success 200 {object} map[string]any "server_id, name, connect_type, reachability, metrics, docker, tunnel"
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/servers/{serverId}/summary [get]
+func handleServerSummary(e *core.RequestEvent) error {
+	serverID := e.Request.PathValue("serverId")
+	record, err := archive.FindLive(e.App, "servers", serverID)
+	if err != nil {
+		return e.NotFoundError("server not found", err)
+	}
+	ms := servers.ManagedServerFromRecord(record)
+
+	ctx, cancel := context.WithTimeout(e.Request.Context(), serverSummaryTimeout)
+	defer cancel()
+
+	summary := serverSummary{
+		ServerID:    record.Id,
+		Name:        ms.Name,
+		ConnectType: string(ms.ConnectType),
+	}
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		summary.Reachability = summarizeServerReachability(ctx, ms)
+	}()
+	go func() {
+		defer wg.Done()
+		summary.Metrics = summarizeServerMetrics(ctx, e.App, e.Auth, serverID)
+	}()
+	go func() {
+		defer wg.Done()
+		summary.Docker = summarizeServerDocker(ctx, e.App, serverID)
+	}()
+	if ms.IsTunnel() {
+		tunnel := &serverSummaryTunnel{}
+		status, err := tunnelService(e.App).Status(record)
+		if err != nil {
+			tunnel.Error = err.Error()
+		} else {
+			tunnel.TunnelStatusResult = status
+		}
+		summary.Tunnel = tunnel
+	}
+	wg.Wait()
+
+	return e.JSON(http.StatusOK, summary)
+}
+
+// summarySection runs fn with a per-section deadline. It returns as soon as
+// the deadline passes even when fn ignores its context.
+func summarySection[T any](ctx context.Context, fn func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, serverSummarySectionTimeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn(ctx)
+		done <- result{value, err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return zero, fmt.Errorf("timed out")
+		}
+		return zero, ctx.Err()
+	}
+}
+
+func summarizeServerReachability(ctx context.Context, ms *servers.ManagedServer) serverSummaryReachability {
+	if ms.IsTunnel() {
+		status := "offline"
+		if tunnelSessions != nil {
+			if _, ok := tunnelSessions.Get(ms.ID); ok {
+				status = "online"
+			}
+		}
+		return serverSummaryReachability{Status: status, Mode: "tunnel"}
+	}
+
+	out := serverSummaryReachability{Status: "offline", Mode: "tcp"}
+	probe, err := summarySection(ctx, func(context.Context) (directAccessProbeResult, error) {
+		return directServerAccessProbe(ms.Host, ms.Port), nil
+	})
+	switch {
+	case err != nil:
+		out.Error = err.Error()
+	case probe.Access.Status != "available":
+		out.Error = probe.Detail
+	default:
+		out.Status = "online"
+		out.LatencyMS = probe.LatencyMS
+	}
+	return out
+}
+
+func summarizeServerMetrics(ctx context.Context, app core.App, auth *core.Record, serverID string) serverSummaryMetrics {
+	metrics, err := summarySection(ctx, func(ctx context.Context) (serverSummaryMetrics, error) {
+		cfg, err := resolveTerminalConfig(app, auth, serverID)
+		if err != nil {
+			return serverSummaryMetrics{}, err
+		}
+		raw, err := terminal.ExecuteSSHCommand(ctx, cfg, serverMetricsCommand, serverSummarySectionTimeout)
+		if err != nil {
+			return serverSummaryMetrics{}, err
+		}
+		return parseServerMetrics(raw)
+	})
+	if err != nil {
+		metrics.Error = err.Error()
+	}
+	return metrics
+}
+
+func summarizeServerDocker(ctx context.Context, app core.App, serverID string) serverSummaryDocker {
+	version, err := summarySection(ctx, func(ctx context.Context) (string, error) {
+		client, err := servers.NewDockerClient(app, serverID, localDockerClient)
+		if err != nil {
+			return "", err
+		}
+		return client.Version(ctx)
+	})
+	if err == nil {
+		return serverSummaryDocker{Status: "running", Version: strings.TrimSpace(version)}
+	}
+	out := serverSummaryDocker{Status: "unknown", Error: err.Error()}
+	var unreachable *docker.DaemonUnreachableError
+	if errors.As(err, &unreachable) {
+		out.Status = unreachable.Reason
+	}
+	return out
+}
+
+// parseServerMetrics reads the output of serverMetricsCommand.
+func parseServerMetrics(raw string) (serverSummaryMetrics, error) {
+	var m serverSummaryMetrics
+	var haveLoad, haveMem, haveDisk bool
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "MemTotal:" && len(fields) >= 2:
+			m.MemTotalBytes = parseKiB(fields[1])
+			haveMem = true
+		case fields[0] == "MemAvailable:" && len(fields) >= 2:
+			m.MemAvailableBytes = parseKiB(fields[1])
+		case !haveLoad && len(fields) == 5 && strings.Contains(fields[3], "/"):
+			m.Load1, _ = strconv.ParseFloat(fields[0], 64)
+			m.Load5, _ = strconv.ParseFloat(fields[1], 64)
+			m.Load15, _ = strconv.ParseFloat(fields[2], 64)
+			haveLoad = true
+		case len(fields) >= 6 && fields[len(fields)-1] == "/":
+			m.DiskTotalBytes = parseKiB(fields[1])
+			m.DiskUsedBytes = parseKiB(fields[2])
+			m.DiskAvailableBytes = parseKiB(fields[3])
+			haveDisk = true
+		}
+	}
+	if !haveLoad || !haveMem || !haveDisk {
+		return m, fmt.Errorf("unexpected metrics output")
+	}
+	return m, nil
+}
+
+func parseKiB(value string) int64 {
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n * 1024
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+)
+
+func TestParseServerMetrics(t *testing.T) {
+	raw := "0.52 0.40 0.31 2/345 6789\n" +
+		"MemTotal:        8048576 kB\n" +
+		"MemAvailable:    4024288 kB\n" +
+		"Filesystem     1024-blocks     Used Available Capacity Mounted on\n" +
+		"/dev/vda1         41152736 10288184  28950168      27% /\n"
+	m, err := parseServerMetrics(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Load1 != 0.52 || m.Load5 != 0.40 || m.Load15 != 0.31 {
+		t.Fatalf("unexpected load: %+v", m)
+	}
+	if m.MemTotalBytes != 8048576*1024 || m.MemAvailableBytes != 4024288*1024 {
+		t.Fatalf("unexpected memory: %+v", m)
+	}
+	if m.DiskTotalBytes != 41152736*1024 || m.DiskUsedBytes != 10288184*1024 || m.DiskAvailableBytes != 28950168*1024 {
+		t.Fatalf("unexpected disk: %+v", m)
+	}
+
+	if _, err := parseServerMetrics("sh: 1: cannot open /proc/loadavg\n"); err == nil {
+		t.Fatal("expected error for unexpected output")
+	}
+}
+
+func TestSummarySectionTimesOut(t *testing.T) {
+	original := serverSummarySectionTimeout
+	serverSummarySectionTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { serverSummarySectionTimeout = original })
+
+	block := make(chan struct{})
+	defer close(block)
+	start := time.Now()
+	_, err := summarySection(context.Background(), func(context.Context) (string, error) {
+		<-block
+		return "late", nil
+	})
+	if err == nil || err.Error() != "timed out" {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("section did not return at its deadline: %s", elapsed)
+	}
+}
+
+func TestServerSummaryReportsSectionErrors(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	originalProbe := directServerAccessProbe
+	directServerAccessProbe = func(host string, port int) directAccessProbeResult {
+		return directAccessProbeResult{Access: servers.AccessView{Status: "available"}, LatencyMS: 7}
+	}
+	t.Cleanup(func() { directServerAccessProbe = originalProbe })
+
+	server := createServerRecord(t, te, "summary-a", "10.0.0.9", 22, "root", "password")
+
+	rec := te.doServer(t, http.MethodGet, "/api/servers/"+server.Id+"/summary", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var summary serverSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.ServerID != server.Id || summary.Name != "summary-a" {
+		t.Fatalf("unexpected identity: %+v", summary)
+	}
+	if summary.Reachability.Status != "online" || summary.Reachability.Mode != "tcp" || summary.Reachability.LatencyMS != 7 {
+		t.Fatalf("unexpected reachability: %+v", summary.Reachability)
+	}
+	if summary.Metrics.Error == "" {
+		t.Fatalf("expected metrics error without credentials, got %+v", summary.Metrics)
+	}
+	if summary.Docker.Status != "unknown" || summary.Docker.Error == "" {
+		t.Fatalf("expected docker error without credentials, got %+v", summary.Docker)
+	}
+	if summary.Tunnel != nil {
+		t.Fatalf("expected no tunnel section for a direct server, got %+v", summary.Tunnel)
+	}
+
+	rec = te.doServer(t, http.MethodGet, "/api/servers/missing/summary", "", true)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = te.doServer(t, http.MethodGet, "/api/servers/"+server.Id+"/summary", "", false)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
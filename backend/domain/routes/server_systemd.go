@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -27,7 +29,7 @@ func handleSystemdServices(e *core.RequestEvent) error {
 
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
 	}
 
 	raw, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, "systemctl list-units --type=service --all --no-legend --no-pager", 20*time.Second)
@@ -83,7 +85,7 @@ func handleSystemdServiceStatus(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
 	showCmd := fmt.Sprintf("systemctl show %s --no-pager --property=Id,Description,LoadState,ActiveState,SubState,UnitFileState,MainPID,ExecMainStatus,ExecMainCode,StateChangeTimestamp", service)
@@ -149,7 +151,7 @@ func handleSystemdServiceLogs(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
 	cmd := fmt.Sprintf("journalctl -u %s -n %d --no-pager --output=short-iso", service, lines)
@@ -196,7 +198,7 @@ func handleSystemdServiceContent(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
 	cmd := fmt.Sprintf("systemctl cat %s --no-pager", service)
@@ -251,10 +253,10 @@ func handleSystemdServiceAction(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
-	cmd := fmt.Sprintf("(sudo -n systemctl %s %s || systemctl %s %s)", action, service, action, service)
+	cmd := cfg.Privileged(fmt.Sprintf("systemctl %s %s", action, service))
 	output, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, cmd, 25*time.Second)
 
 	userID, _, ip, _ := clientInfo(e)
@@ -294,7 +296,7 @@ func handleSystemdServiceUnitRead(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
 	unitPath, pathErr := resolveSystemdUnitPath(e.Request.Context(), cfg, service)
@@ -335,10 +337,21 @@ func handleSystemdServiceUnitWrite(e *core.RequestEvent) error {
 	if len(body.Content) > maxUnitContentBytes {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "content too large (max 64KB)"})
 	}
+	// force=true skips validation for units the checks cannot judge fairly,
+	// e.g. ones referencing binaries that are installed afterwards.
+	force := e.Request.URL.Query().Get("force") == "true"
+	if !force {
+		if problems := lintSystemdUnit(body.Content, true); len(problems) > 0 {
+			return e.JSON(http.StatusBadRequest, map[string]any{
+				"message": "unit content is invalid; pass force=true to save anyway",
+				"errors":  problems,
+			})
+		}
+	}
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
 	unitPath, pathErr := resolveSystemdUnitPath(e.Request.Context(), cfg, service)
@@ -346,8 +359,20 @@ func handleSystemdServiceUnitWrite(e *core.RequestEvent) error {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": pathErr.Error()})
 	}
 
+	verifyOutput := ""
+	if !force {
+		output, verifyErr := verifySystemdUnitContent(e.Request.Context(), cfg, path.Base(unitPath), body.Content)
+		if verifyErr != nil {
+			return e.JSON(http.StatusBadRequest, map[string]any{
+				"message":       "systemd-analyze verify failed; pass force=true to save anyway",
+				"verify_output": output,
+			})
+		}
+		verifyOutput = output
+	}
+
 	encoded := base64.StdEncoding.EncodeToString([]byte(body.Content))
-	writeCmd := fmt.Sprintf("printf '%%s' '%s' | base64 -d | (sudo -n tee %s >/dev/null || tee %s >/dev/null)", encoded, terminal.ShellQuote(unitPath), terminal.ShellQuote(unitPath))
+	writeCmd := cfg.Privileged(fmt.Sprintf("printf '%%s' '%s' | base64 -d | tee %s >/dev/null", encoded, terminal.ShellQuote(unitPath)))
 	writeOutput, writeErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, writeCmd, 25*time.Second)
 	if writeErr != nil {
 		return e.JSON(http.StatusInternalServerError, map[string]any{"message": writeErr.Error(), "output": writeOutput})
@@ -365,15 +390,18 @@ func handleSystemdServiceUnitWrite(e *core.RequestEvent) error {
 			"service": service,
 			"path":    unitPath,
 			"output":  writeOutput,
+			"forced":  force,
 		},
 	})
 
 	return e.JSON(http.StatusOK, map[string]any{
-		"server_id": serverID,
-		"service":   service,
-		"path":      unitPath,
-		"status":    "saved",
-		"output":    writeOutput,
+		"server_id":     serverID,
+		"service":       service,
+		"path":          unitPath,
+		"status":        "saved",
+		"output":        writeOutput,
+		"verify_output": verifyOutput,
+		"forced":        force,
 	})
 }
 
@@ -386,7 +414,7 @@ func handleSystemdServiceUnitVerify(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
 	unitPath, pathErr := resolveSystemdUnitPath(e.Request.Context(), cfg, service)
@@ -394,7 +422,7 @@ func handleSystemdServiceUnitVerify(e *core.RequestEvent) error {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": pathErr.Error()})
 	}
 
-	verifyCmd := fmt.Sprintf("(sudo -n systemd-analyze verify %s || systemd-analyze verify %s)", terminal.ShellQuote(unitPath), terminal.ShellQuote(unitPath))
+	verifyCmd := cfg.Privileged("systemd-analyze verify " + terminal.ShellQuote(unitPath))
 	verifyOutput, verifyErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, verifyCmd, 25*time.Second)
 
 	userID, _, ip, _ := clientInfo(e)
@@ -438,16 +466,16 @@ func handleSystemdServiceUnitApply(e *core.RequestEvent) error {
 
 	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if resolveErr != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+		return serverConfigError(e, resolveErr)
 	}
 
-	reloadCmd := "(sudo -n systemctl daemon-reload || systemctl daemon-reload)"
+	reloadCmd := cfg.Privileged("systemctl daemon-reload")
 	reloadOutput, reloadErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, reloadCmd, 20*time.Second)
 	if reloadErr != nil {
 		return e.JSON(http.StatusInternalServerError, map[string]any{"message": reloadErr.Error(), "reload_output": reloadOutput})
 	}
 
-	applyCmd := fmt.Sprintf("(sudo -n systemctl try-restart %s || systemctl try-restart %s)", service, service)
+	applyCmd := cfg.Privileged("systemctl try-restart " + service)
 	applyOutput, applyErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, applyCmd, 25*time.Second)
 
 	userID, _, ip, _ := clientInfo(e)
@@ -494,3 +522,76 @@ func resolveSystemdUnitPath(ctx context.Context, cfg terminal.ConnectorConfig, s
 	}
 	return unitPath, nil
 }
+
+var (
+	systemdSectionPattern = regexp.MustCompile(`^\[[A-Za-z][A-Za-z0-9 ._-]*\]$`)
+	systemdKeyPattern     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+)
+
+// lintSystemdUnit performs the structural checks systemd applies when
+// parsing a service unit: well-formed section headers, Key=Value lines
+// inside a section and, with requireService, a [Service] section (drop-ins
+// may override only [Unit] or [Install]). It returns one message per
+// problem, prefixed with the line number.
+func lintSystemdUnit(content string, requireService bool) []string {
+	var problems []string
+	section := ""
+	hasService := false
+	continued := false
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if continued {
+			continued = strings.HasSuffix(line, "\\")
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		continued = strings.HasSuffix(line, "\\")
+		if strings.HasPrefix(line, "[") {
+			if !systemdSectionPattern.MatchString(line) {
+				problems = append(problems, fmt.Sprintf("line %d: malformed section header %q", i+1, line))
+				continue
+			}
+			section = strings.Trim(line, "[]")
+			if section == "Service" {
+				hasService = true
+			}
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !ok || !systemdKeyPattern.MatchString(key):
+			problems = append(problems, fmt.Sprintf("line %d: expected Key=Value, got %q", i+1, line))
+		case section == "":
+			problems = append(problems, fmt.Sprintf("line %d: %q appears before any section header", i+1, key))
+		}
+	}
+	if requireService && !hasService {
+		problems = append(problems, "missing [Service] section")
+	}
+	return problems
+}
+
+// systemdAnalyzeUnavailable is echoed when the target lacks systemd-analyze;
+// validation then relies on lintSystemdUnit alone.
+const systemdAnalyzeUnavailable = "__SYSTEMD_ANALYZE_NOT_AVAILABLE__"
+
+// verifySystemdUnitContent runs systemd-analyze verify on content staged in
+// a temporary directory on the server, under the unit's own file name so the
+// unit type is recognised. A non-nil error means verification failed.
+func verifySystemdUnitContent(ctx context.Context, cfg terminal.ConnectorConfig, unitName, content string) (string, error) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	staged := fmt.Sprintf(`"$d"/%s`, terminal.ShellQuote(unitName))
+	verifyCmd := fmt.Sprintf(
+		"if ! command -v systemd-analyze >/dev/null 2>&1; then echo '%s'; exit 0; fi; "+
+			"d=$(mktemp -d) || exit 1; printf '%%s' '%s' | base64 -d > %s && systemd-analyze verify %s 2>&1; rc=$?; rm -rf \"$d\"; exit $rc",
+		systemdAnalyzeUnavailable, encoded, staged, staged,
+	)
+	output, err := terminal.ExecuteSSHCommand(ctx, cfg, verifyCmd, 25*time.Second)
+	if strings.Contains(output, systemdAnalyzeUnavailable) {
+		return "", nil
+	}
+	return output, err
+}
@@ -0,0 +1,223 @@
+package routes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/terminal"
+)
+
+// ════════════════════════════════════════════════════════════
+// Systemd drop-in overrides
+// ════════════════════════════════════════════════════════════
+//
+// Drop-ins are edited the way `systemctl edit` does: files under
+// /etc/systemd/system/<unit>.d/ layered over the package-managed unit,
+// which is never touched. Changes take effect through the unit apply
+// endpoint (daemon-reload + try-restart).
+
+var systemdDropInNamePattern = regexp.MustCompile(`^[A-Za-z0-9_@-][A-Za-z0-9_.@-]*\.conf$`)
+
+const (
+	systemdDropInListSeparator = "__APPOS_DROPIN_DIR__"
+	systemdDropInNotFound      = "__APPOS_DROPIN_NOT_FOUND__"
+)
+
+// systemdDropInDir returns the administrator drop-in directory of service.
+func systemdDropInDir(service string) string {
+	return path.Join("/etc/systemd/system", service+".d")
+}
+
+// resolveSystemdDropInPath validates a drop-in file name and returns its
+// path inside the service's override directory.
+func resolveSystemdDropInPath(service, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if !systemdDropInNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid drop-in name; expected e.g. override.conf")
+	}
+	return path.Join(systemdDropInDir(service), name), nil
+}
+
+// parseSystemdDropIns merges the drop-ins systemd has loaded (DropInPaths,
+// first section of output) with the .conf files present in overrideDir
+// (second section), so files written but not yet reloaded are listed too.
+// Only files inside overrideDir are editable.
+func parseSystemdDropIns(output, overrideDir string) []map[string]any {
+	loadedRaw, dirRaw, _ := strings.Cut(output, systemdDropInListSeparator)
+	loaded := strings.Fields(loadedRaw)
+
+	paths := slices.Clone(loaded)
+	for _, name := range strings.Fields(dirRaw) {
+		if !systemdDropInNamePattern.MatchString(name) {
+			continue
+		}
+		if p := path.Join(overrideDir, name); !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+
+	items := make([]map[string]any, 0, len(paths))
+	for _, p := range paths {
+		items = append(items, map[string]any{
+			"name":     path.Base(p),
+			"path":     p,
+			"editable": path.Dir(p) == overrideDir,
+			"loaded":   slices.Contains(loaded, p),
+		})
+	}
+	return items
+}
+
+func handleSystemdDropInsList(e *core.RequestEvent) error {
+	serverID := e.Request.PathValue("serverId")
+	service, err := normalizeServiceName(e.Request.PathValue("service"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+
+	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
+	if resolveErr != nil {
+		return serverConfigError(e, resolveErr)
+	}
+
+	overrideDir := systemdDropInDir(service)
+	listCmd := fmt.Sprintf(
+		"systemctl show %s --property=DropInPaths --value --no-pager; echo '%s'; ls -1 %s 2>/dev/null; true",
+		service, systemdDropInListSeparator, terminal.ShellQuote(overrideDir),
+	)
+	raw, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, listCmd, 20*time.Second)
+	if runErr != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": runErr.Error()})
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"server_id":    serverID,
+		"service":      service,
+		"override_dir": overrideDir,
+		"items":        parseSystemdDropIns(raw, overrideDir),
+	})
+}
+
+func handleSystemdDropInRead(e *core.RequestEvent) error {
+	serverID := e.Request.PathValue("serverId")
+	service, err := normalizeServiceName(e.Request.PathValue("service"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+	dropInPath, err := resolveSystemdDropInPath(service, e.Request.PathValue("name"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+
+	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
+	if resolveErr != nil {
+		return serverConfigError(e, resolveErr)
+	}
+
+	readCmd := fmt.Sprintf("if [ -f %s ]; then cat %s; else echo '%s'; fi", terminal.ShellQuote(dropInPath), terminal.ShellQuote(dropInPath), systemdDropInNotFound)
+	raw, runErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, readCmd, 20*time.Second)
+	if runErr != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": runErr.Error()})
+	}
+	if strings.TrimSpace(raw) == systemdDropInNotFound {
+		return e.JSON(http.StatusNotFound, map[string]any{"message": "drop-in not found"})
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"server_id": serverID,
+		"service":   service,
+		"name":      path.Base(dropInPath),
+		"path":      dropInPath,
+		"content":   raw,
+	})
+}
+
+// handleSystemdDropInWrite creates or replaces a drop-in. Content gets the
+// same structural lint as unit files (without requiring [Service]), which
+// ?force=true skips.
+func handleSystemdDropInWrite(e *core.RequestEvent) error {
+	serverID := e.Request.PathValue("serverId")
+	service, err := normalizeServiceName(e.Request.PathValue("service"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+	dropInPath, err := resolveSystemdDropInPath(service, e.Request.PathValue("name"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+
+	var body struct {
+		Content string `json:"content"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "invalid request body"})
+	}
+	if strings.TrimSpace(body.Content) == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "content required"})
+	}
+	const maxDropInContentBytes = 64 * 1024
+	if len(body.Content) > maxDropInContentBytes {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "content too large (max 64KB)"})
+	}
+	force := e.Request.URL.Query().Get("force") == "true"
+	if !force {
+		if problems := lintSystemdUnit(body.Content, false); len(problems) > 0 {
+			return e.JSON(http.StatusBadRequest, map[string]any{
+				"message": "drop-in content is invalid; pass force=true to save anyway",
+				"errors":  problems,
+			})
+		}
+	}
+
+	cfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
+	if resolveErr != nil {
+		return serverConfigError(e, resolveErr)
+	}
+
+	dir := terminal.ShellQuote(path.Dir(dropInPath))
+	quotedPath := terminal.ShellQuote(dropInPath)
+	encoded := base64.StdEncoding.EncodeToString([]byte(body.Content))
+	writeCmd := cfg.Privileged(fmt.Sprintf(
+		"mkdir -p %s && printf '%%s' '%s' | base64 -d | tee %s >/dev/null",
+		dir, encoded, quotedPath,
+	))
+	writeOutput, writeErr := terminal.ExecuteSSHCommand(e.Request.Context(), cfg, writeCmd, 25*time.Second)
+	if writeErr != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": writeErr.Error(), "output": writeOutput})
+	}
+
+	userID, _, ip, _ := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "server.ops.systemd.dropin.write",
+		ResourceType: "server",
+		ResourceID:   serverID,
+		Status:       audit.StatusSuccess,
+		IP:           ip,
+		Detail: map[string]any{
+			"service": service,
+			"path":    dropInPath,
+			"output":  writeOutput,
+			"forced":  force,
+		},
+	})
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"server_id": serverID,
+		"service":   service,
+		"name":      path.Base(dropInPath),
+		"path":      dropInPath,
+		"status":    "saved",
+		"output":    writeOutput,
+		"forced":    force,
+	})
+}
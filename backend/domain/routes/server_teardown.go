@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/terminal"
+	tunnelcore "github.com/websoft9/appos/backend/infra/tunnelcore"
+)
+
+// registerServerDeleteHooks tears down live sessions when a server record is
+// deleted or archived, so no terminal, SFTP stream or tunnel keeps running
+// against a server that is gone.
+func registerServerDeleteHooks(app core.App) {
+	app.OnRecordAfterDeleteSuccess("servers").BindFunc(func(e *core.RecordEvent) error {
+		teardownServerSessions(e.App, e.Record.Id)
+		return e.Next()
+	})
+	app.OnRecordAfterUpdateSuccess("servers").BindFunc(func(e *core.RecordEvent) error {
+		if archive.IsArchived(e.Record) && !archive.IsArchived(e.Record.Original()) {
+			teardownServerSessions(e.App, e.Record.Id)
+		}
+		return e.Next()
+	})
+}
+
+// teardownServerSessions closes every registered connection and the tunnel
+// session for serverID, and releases its reserved tunnel ports.
+func teardownServerSessions(app core.App, serverID string) {
+	closed := terminal.TerminateServer(serverID)
+
+	tunnelClosed := false
+	if tunnelSessions != nil {
+		if _, ok := tunnelSessions.Get(serverID); ok {
+			tunnelSessions.Disconnect(serverID, tunnelcore.DisconnectReasonServerDeleted)
+			tunnelClosed = true
+		}
+	}
+	if tunnelPortPool != nil {
+		tunnelPortPool.Release(serverID)
+	}
+
+	if closed > 0 || tunnelClosed {
+		app.Logger().Info("server removed; closed active sessions",
+			"server", serverID,
+			"connections", closed,
+			"tunnel", tunnelClosed,
+		)
+	}
+}
@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/audit"
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+)
+
+// templateApplyMaxServers caps the servers one template apply may update.
+const templateApplyMaxServers = 500
+
+func registerServerTemplateRoutes(g *router.RouterGroup[*core.RequestEvent]) {
+	g.POST("/templates/{id}/apply", handleServerTemplateApply)
+}
+
+// templateApplyResult is the outcome of applying a template to one server.
+type templateApplyResult struct {
+	ServerID string `json:"server_id"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// handleServerTemplateApply copies a server template's connection fields to
+// existing servers.
+//
+// @Summary Apply server template
+// @Description Copies the connection fields of a server template (port, user, connect_type, credential, shell, default_dir, env, legacy_ssh, privilege_escalation) to each listed server and returns per-server status. Without fields only the fields the template sets are copied; with fields exactly those are copied, empty values included. A server that fails validation does not stop the others. The apply is audited once. Superuser only.
+// @Tags Servers
+// @Security BearerAuth
+// @Param id path string true "server template ID"
+// @Param body body object true "server_ids (max 500) and optional fields"
+// @Success 200 {object} map[string]any "template_id, fields, results, updated, failed"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/servers/templates/{id}/apply [post]
+func handleServerTemplateApply(e *core.RequestEvent) error {
+	tpl, err := e.App.FindRecordById(servers.TemplatesCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("server template not found", err)
+	}
+
+	var body struct {
+		ServerIDs []string `json:"server_ids"`
+		Fields    []string `json:"fields"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("invalid request body", err)
+	}
+	serverIDs := make([]string, 0, len(body.ServerIDs))
+	seen := map[string]bool{}
+	for _, id := range body.ServerIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			serverIDs = append(serverIDs, id)
+		}
+	}
+	if len(serverIDs) == 0 {
+		return e.BadRequestError("server_ids is required", nil)
+	}
+	if len(serverIDs) > templateApplyMaxServers {
+		return e.BadRequestError("too many servers (max 500)", nil)
+	}
+	fields := body.Fields
+	if len(fields) == 0 {
+		fields = servers.DefinedTemplateFields(tpl)
+	} else if err := servers.ValidateTemplateFields(fields); err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+	if len(fields) == 0 {
+		return e.BadRequestError("template sets no fields; pass fields to copy empty values", nil)
+	}
+
+	results := make([]templateApplyResult, 0, len(serverIDs))
+	updated := 0
+	for _, serverID := range serverIDs {
+		result := templateApplyResult{ServerID: serverID, Status: "updated"}
+		server, err := archive.FindLive(e.App, "servers", serverID)
+		if err == nil {
+			servers.ApplyTemplate(server, tpl, fields)
+			err = e.App.Save(server)
+		}
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+		} else {
+			updated++
+		}
+		results = append(results, result)
+	}
+	failed := len(results) - updated
+
+	status := audit.StatusSuccess
+	if failed > 0 {
+		status = audit.StatusFailed
+	}
+	userID, _, ip, _ := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "server.template.apply",
+		ResourceType: "server_template",
+		ResourceID:   tpl.Id,
+		ResourceName: tpl.GetString("name"),
+		Status:       status,
+		IP:           ip,
+		Detail: map[string]any{
+			"server_ids": serverIDs,
+			"fields":     fields,
+			"updated":    updated,
+			"failed":     failed,
+			"results":    results,
+		},
+	})
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"template_id": tpl.Id,
+		"fields":      fields,
+		"results":     results,
+		"updated":     updated,
+		"failed":      failed,
+	})
+}
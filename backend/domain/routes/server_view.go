@@ -11,6 +11,7 @@ import (
 
 	"github.com/pocketbase/pocketbase/core"
 
+	"github.com/websoft9/appos/backend/domain/archive"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
 )
 
@@ -58,7 +59,7 @@ var directServerAccessProbe = probeDirectServerAccess
 // @Failure 500 {object} map[string]any
 // @Router /api/servers/connection [get]
 func handleServersView(e *core.RequestEvent) error {
-	records, err := e.App.FindAllRecords("servers")
+	records, err := e.App.FindAllRecords("servers", archive.Live())
 	if err != nil {
 		return e.InternalServerError("failed to load servers", err)
 	}
@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/pocketbase/pocketbase/apis"
@@ -10,6 +12,7 @@ import (
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
 	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/domain/stepup"
 )
 
 type connectorManagedSettingsError struct {
@@ -28,6 +31,7 @@ func RegisterSettings(se *core.ServeEvent) {
 	g := se.Router.Group("/api/settings")
 	g.Bind(apis.RequireSuperuserAuth())
 	g.GET("/schema", handleSettingsSchema)
+	g.GET("/profiles", handleSettingsProfiles)
 	g.GET("/entries", handleSettingsEntriesList)
 	g.GET("/entries/{entryId}", handleSettingsEntryGet)
 	g.PATCH("/entries/{entryId}", handleSettingsEntryPatch)
@@ -56,6 +60,28 @@ func handleSettingsSchema(e *core.RequestEvent) error {
 	})
 }
 
+// handleSettingsProfiles returns the active settings profile and the profiles
+// that have stored overrides.
+//
+// @Summary List settings profiles
+// @Description Returns the settings profile selected by the APPOS_SETTINGS_PROFILE environment variable (active, empty when none) and every profile with stored overrides. Overrides of the active profile are applied on top of the global values at runtime. Superuser only.
+// @Tags Settings
+// @Security BearerAuth
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/settings/profiles [get]
+func handleSettingsProfiles(e *core.RequestEvent) error {
+	profiles, err := sysconfig.Profiles(e.App)
+	if err != nil {
+		return e.InternalServerError("failed to list settings profiles", err)
+	}
+	return e.JSON(http.StatusOK, map[string]any{
+		"active": sysconfig.ActiveProfile(),
+		"items":  profiles,
+	})
+}
+
 // handleSettingsEntriesList returns the current values for all settings entries.
 //
 // @Summary List settings entries
@@ -85,10 +111,11 @@ func handleSettingsEntriesList(e *core.RequestEvent) error {
 // handleSettingsEntryGet returns one settings entry by its unified identifier.
 //
 // @Summary Get settings entry
-// @Description Returns the current masked or normalized value for a single settings entry. Superuser only.
+// @Description Returns the current masked or normalized value for a single settings entry. With profile, value is the entry as resolved for that profile and overrides holds only the fields the profile overrides; profiles apply to custom settings entries only. Superuser only.
 // @Tags Settings
 // @Security BearerAuth
 // @Param entryId path string true "settings entry id"
+// @Param profile query string false "settings profile, e.g. dev, staging or prod"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
@@ -100,6 +127,23 @@ func handleSettingsEntryGet(e *core.RequestEvent) error {
 	if !ok {
 		return e.BadRequestError("unknown settings entry: "+entryID, nil)
 	}
+	profile, err := settingsProfileParam(e, entry)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	if profile != "" {
+		value, overrides, err := getCustomSettingsProfileValue(e.App, profile, entry.Module, entry.Key)
+		if err != nil {
+			return e.InternalServerError("failed to load settings entry "+entryID, err)
+		}
+		return e.JSON(http.StatusOK, map[string]any{
+			"id":        entryID,
+			"profile":   profile,
+			"value":     value,
+			"overrides": overrides,
+		})
+	}
 
 	value, err := loadSettingsEntryValue(e.App, entry)
 	if err != nil {
@@ -115,14 +159,16 @@ func handleSettingsEntryGet(e *core.RequestEvent) error {
 // handleSettingsEntryPatch updates one settings entry by its unified identifier.
 //
 // @Summary Patch settings entry
-// @Description Updates a single settings entry while preserving masking, defaults, and validation rules for its source. Superuser only.
+// @Description Updates a single settings entry while preserving masking, defaults, and validation rules for its source. Non-blocking advisories, such as tunnel port range capacity, are returned in warnings. With profile, the submitted fields become that profile's overrides (null removes an override so the field falls back to the global value) and the resolved entry is validated as a whole. A security-stepup change that stops requiring an action needs a valid TOTP code in the X-AppOS-TOTP header. Superuser only.
 // @Tags Settings
 // @Security BearerAuth
 // @Param entryId path string true "settings entry id"
+// @Param profile query string false "settings profile, e.g. dev, staging or prod"
 // @Param body body object true "settings entry payload"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 422 {object} map[string]any
 // @Router /api/settings/entries/{entryId} [patch]
 func handleSettingsEntryPatch(e *core.RequestEvent) error {
@@ -131,13 +177,22 @@ func handleSettingsEntryPatch(e *core.RequestEvent) error {
 	if !ok {
 		return e.BadRequestError("unknown settings entry: "+entryID, nil)
 	}
+	profile, err := settingsProfileParam(e, entry)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
 
 	var body map[string]any
 	if err := e.BindBody(&body); err != nil {
 		return e.BadRequestError("invalid JSON body", err)
 	}
 
-	value, err := patchSettingsEntryValue(e, entry, body)
+	var value, overrides map[string]any
+	if profile != "" {
+		value, overrides, err = patchCustomSettingsProfile(e, profile, entry.Module, entry.Key, body)
+	} else {
+		value, err = patchSettingsEntryValue(e, entry, body)
+	}
 	if err != nil {
 		if fieldErr, ok := err.(*settingsValidationError); ok {
 			return e.JSON(http.StatusUnprocessableEntity, map[string]any{"errors": fieldErr.Fields})
@@ -145,13 +200,24 @@ func handleSettingsEntryPatch(e *core.RequestEvent) error {
 		if managedErr, ok := err.(*connectorManagedSettingsError); ok {
 			return e.BadRequestError(managedErr.Error(), nil)
 		}
+		if challenge, ok := err.(*stepup.ChallengeError); ok {
+			return challenge.Respond(e)
+		}
 		return e.BadRequestError("failed to update settings entry "+entryID, err)
 	}
 
-	return e.JSON(http.StatusOK, map[string]any{
+	resp := map[string]any{
 		"id":    entryID,
 		"value": value,
-	})
+	}
+	if profile != "" {
+		resp["profile"] = profile
+		resp["overrides"] = overrides
+	}
+	if warnings := settingsEntryWarnings(e.App, entry, value); len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	return e.JSON(http.StatusOK, resp)
 }
 
 // handleSettingsAction executes a settings-related action bound to a schema entry.
@@ -240,26 +306,38 @@ func patchSettingsEntryValue(e *core.RequestEvent, entry settingscatalog.EntrySc
 	return patchCustomSettingsEntry(e, entry.Module, entry.Key, value)
 }
 
+// getCustomSettingsEntryValue returns the global value of a custom entry.
 func getCustomSettingsEntryValue(app core.App, module, key string) (map[string]any, error) {
+	return resolveCustomSettingsEntryValue(app, "", module, key), nil
+}
+
+// resolveCustomSettingsEntryValue returns the masked entry value resolved for
+// profile ("" for global).
+func resolveCustomSettingsEntryValue(app core.App, profile, module, key string) map[string]any {
 	fallback := fallbackForKey(module, key)
-	value, err := sysconfig.GetGroup(app, module, key, fallback)
+	value, err := sysconfig.GetProfileGroup(app, profile, module, key, fallback)
 	if err != nil {
-		app.Logger().Debug("settings fallback used", "module", module, "key", key, "error", err)
+		app.Logger().Debug("settings fallback used", "module", module, "key", key, "profile", profile, "error", err)
 	}
 	if module == secrets.SettingsModule && key == secrets.PolicySettingsKey {
 		value = secrets.NormalizePolicy(value).ToMap()
 	}
-	return maskValue(value), nil
+	return maskValue(value)
 }
 
 func patchCustomSettingsEntry(e *core.RequestEvent, module, key string, value map[string]any) (map[string]any, error) {
+	// Edit the global row only; the active profile's overrides must not leak
+	// into it.
 	fallback := fallbackForKey(module, key)
-	existing, _ := sysconfig.GetGroup(e.App, module, key, fallback)
+	existing, _ := sysconfig.GetProfileGroup(e.App, "", module, key, fallback)
 	merged := preserveSensitive(value, existing)
 
 	if validationErrors := validateCustomSettingsEntry(e, module, key, merged); validationErrors != nil {
 		return nil, &settingsValidationError{Fields: validationErrors}
 	}
+	if challenge := checkStepUpWeakening(e, module, key, existing, merged); challenge != nil {
+		return nil, challenge
+	}
 
 	if err := sysconfig.SetGroup(e.App, module, key, merged); err != nil {
 		return nil, err
@@ -269,8 +347,93 @@ func patchCustomSettingsEntry(e *core.RequestEvent, module, key string, value ma
 	return stored, nil
 }
 
+// ─── Profile overrides ─────────────────────────────────────────────────────
+
+// settingsProfileParam reads the optional ?profile= selector. Profiles only
+// exist for custom_settings entries.
+func settingsProfileParam(e *core.RequestEvent, entry settingscatalog.EntrySchema) (string, error) {
+	profile := strings.ToLower(strings.TrimSpace(e.Request.URL.Query().Get("profile")))
+	if profile == "" {
+		return "", nil
+	}
+	if !sysconfig.ValidProfile(profile) {
+		return "", errors.New("invalid settings profile: use up to 32 lowercase letters, digits, '-' or '_'")
+	}
+	if entry.Source == settingscatalog.SourceNative || entry.ID == "smtp" || entry.ID == "docker-registries" {
+		return "", errors.New("settings profiles apply only to custom settings entries")
+	}
+	return profile, nil
+}
+
+func getCustomSettingsProfileValue(app core.App, profile, module, key string) (map[string]any, map[string]any, error) {
+	overrides, err := sysconfig.ProfileOverrides(app, profile, module, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolveCustomSettingsEntryValue(app, profile, module, key), maskValue(overrides), nil
+}
+
+// patchCustomSettingsProfile applies body as changes to profile's overrides.
+// A null field drops its override. The entry resolved with the new overrides
+// is validated like a global patch, and the validated values are stored.
+func patchCustomSettingsProfile(e *core.RequestEvent, profile, module, key string, body map[string]any) (map[string]any, map[string]any, error) {
+	fallback := fallbackForKey(module, key)
+	existing, _ := sysconfig.GetProfileGroup(e.App, profile, module, key, fallback)
+	overrides, err := sysconfig.ProfileOverrides(e.App, profile, module, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body = preserveSensitive(body, existing)
+	for field, v := range body {
+		if v == nil {
+			delete(overrides, field)
+		} else {
+			overrides[field] = v
+		}
+	}
+
+	merged, _ := sysconfig.GetProfileGroup(e.App, "", module, key, fallback)
+	for field, v := range overrides {
+		merged[field] = v
+	}
+	if validationErrors := validateCustomSettingsEntry(e, module, key, merged); validationErrors != nil {
+		return nil, nil, &settingsValidationError{Fields: validationErrors}
+	}
+	if challenge := checkStepUpWeakening(e, module, key, existing, merged); challenge != nil {
+		return nil, nil, challenge
+	}
+	// Validators may normalise values; keep the normalised form.
+	for field := range overrides {
+		overrides[field] = merged[field]
+	}
+
+	if err := sysconfig.SetProfileOverrides(e.App, profile, module, key, overrides); err != nil {
+		return nil, nil, err
+	}
+	return getCustomSettingsProfileValue(e.App, profile, module, key)
+}
+
+// checkStepUpWeakening demands a step-up code when a security/stepup change
+// stops requiring an action, so a stolen session cannot switch step-up off.
+func checkStepUpWeakening(e *core.RequestEvent, module, key string, before, after map[string]any) *stepup.ChallengeError {
+	if module != stepup.SettingsModule || key != stepup.SettingsKey || !stepup.Weakens(before, after) {
+		return nil
+	}
+	return stepup.Demand(e, stepup.ActionChange)
+}
+
 // ─── Validation dispatch ───────────────────────────────────────────────────
 
+// settingsEntryWarnings returns non-blocking advisories for a saved entry.
+func settingsEntryWarnings(app core.App, entry settingscatalog.EntrySchema, value map[string]any) []string {
+	switch entry.Module + "/" + entry.Key {
+	case "tunnel/port_range":
+		return tunnelPortRangeWarnings(app, value)
+	}
+	return nil
+}
+
 type settingsValidationError struct {
 	Fields map[string]string
 }
@@ -291,6 +454,22 @@ func validateCustomSettingsEntry(e *core.RequestEvent, module, key string, value
 		return validateTunnelPortRange(value)
 	case "deploy/preflight":
 		return validateDeployPreflight(value)
+	case "ratelimit/routes":
+		return validateRateLimitRoutes(value)
+	case "docker/ssh":
+		return validateDockerSSH(value)
+	case "docker/scan":
+		return validateDockerScan(value)
+	case "http/compression":
+		return validateHTTPCompression(value)
+	case "http/limits":
+		return validateHTTPLimits(value)
+	case "security/stepup":
+		return validateSecurityStepUp(value)
+	case "audit/actions":
+		return validateAuditActions(value)
+	case "audit/export":
+		return validateAuditExport(value)
 	case "files/limits":
 		return validateIacFiles(value)
 	case "secrets/policy":
@@ -4,11 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"mime"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
 	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/domain/stepup"
 	tunnelcore "github.com/websoft9/appos/backend/infra/tunnelcore"
 )
 
@@ -89,12 +97,73 @@ func validateSpaceQuota(v map[string]any) map[string]string {
 		errors["shareDefaultMinutes"] = "must be <= shareMaxMinutes"
 	}
 
+	if raw, ok := v["previewMimeTypes"]; ok {
+		mimeTypes, msg := normalizePreviewMimeTypes(raw)
+		if msg != "" {
+			errors["previewMimeTypes"] = msg
+		} else {
+			v["previewMimeTypes"] = mimeTypes
+		}
+	}
+
 	if len(errors) == 0 {
 		return nil
 	}
 	return errors
 }
 
+// previewMimeTypeDenylist holds types that execute script when rendered
+// same-origin, so they can never be allowed for inline preview.
+var previewMimeTypeDenylist = map[string]bool{
+	"text/html":              true,
+	"application/xhtml+xml":  true,
+	"text/javascript":        true,
+	"application/javascript": true,
+	"text/xml":               true,
+	"application/xml":        true,
+}
+
+// normalizePreviewMimeTypes validates the preview allowlist and returns it
+// lowercased and deduplicated, or a field error message.
+func normalizePreviewMimeTypes(raw any) ([]string, string) {
+	var items []string
+	switch list := raw.(type) {
+	case nil:
+		return []string{}, ""
+	case []string:
+		items = list
+	case []any:
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, "must be a list of MIME types"
+			}
+			items = append(items, s)
+		}
+	default:
+		return nil, "must be a list of MIME types"
+	}
+
+	out := make([]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(item)
+		if err != nil || len(params) > 0 || mediaType != item || !strings.Contains(item, "/") || strings.Contains(item, "*") {
+			return nil, fmt.Sprintf("%q is not a valid MIME type", item)
+		}
+		if previewMimeTypeDenylist[item] {
+			return nil, fmt.Sprintf("%q cannot be previewed inline", item)
+		}
+		seen[item] = true
+		out = append(out, item)
+	}
+	return out, ""
+}
+
 func parseIntWithDefault(raw any, defaultValue int) (int, error) {
 	if raw == nil {
 		return defaultValue, nil
@@ -143,6 +212,15 @@ func validateConnectTerminal(v map[string]any) map[string]string {
 		v["idleTimeoutSeconds"] = idleTimeoutSeconds
 	}
 
+	maxSessionSeconds, err := parseIntWithDefault(v["maxSessionSeconds"], 0)
+	if err != nil {
+		errors["maxSessionSeconds"] = "must be an integer"
+	} else if maxSessionSeconds != 0 && maxSessionSeconds < 60 {
+		errors["maxSessionSeconds"] = "must be 0 or >= 60"
+	} else {
+		v["maxSessionSeconds"] = maxSessionSeconds
+	}
+
 	maxConnections, err := parseIntWithDefault(v["maxConnections"], 0)
 	if err != nil {
 		errors["maxConnections"] = "must be an integer"
@@ -152,6 +230,31 @@ func validateConnectTerminal(v map[string]any) map[string]string {
 		v["maxConnections"] = maxConnections
 	}
 
+	maxSessionsPerServer, err := parseIntWithDefault(v["maxSessionsPerServer"], 10)
+	if err != nil {
+		errors["maxSessionsPerServer"] = "must be an integer"
+	} else if maxSessionsPerServer < 0 {
+		errors["maxSessionsPerServer"] = "must be >= 0"
+	} else {
+		v["maxSessionsPerServer"] = maxSessionsPerServer
+	}
+
+	if allowlist, err := parseEnvAllowlist(v["sessionEnvAllowlist"]); err != "" {
+		errors["sessionEnvAllowlist"] = err
+	} else if allowlist != nil {
+		v["sessionEnvAllowlist"] = allowlist
+	}
+
+	switch banner := v["banner"].(type) {
+	case nil:
+	case string:
+		if err := servers.ValidateTerminalBanner(banner); err != nil {
+			errors["banner"] = err.Error()
+		}
+	default:
+		errors["banner"] = "must be a string"
+	}
+
 	if len(errors) == 0 {
 		return nil
 	}
@@ -195,6 +298,17 @@ func validateTunnelPortRange(v map[string]any) map[string]string {
 	return errors
 }
 
+// tunnelPortRangeWarnings sizes the saved port range against existing tunnel
+// servers so operators learn about exhaustion before connections are rejected.
+func tunnelPortRangeWarnings(app core.App, v map[string]any) []string {
+	capacity, err := tunnelService(app).PortCapacityFor(tunnelcore.NormalizePortRange(v))
+	if err != nil {
+		app.Logger().Warn("tunnel port range capacity check failed", "error", err)
+		return nil
+	}
+	return capacity.Warnings()
+}
+
 func validateDeployPreflight(v map[string]any) map[string]string {
 	errors := map[string]string{}
 
@@ -209,12 +323,306 @@ func validateDeployPreflight(v map[string]any) map[string]string {
 		v["minFreeDiskBytes"] = minFreeDiskBytes
 	}
 
+	healthTimeoutSeconds, err := parseIntWithDefault(v["healthTimeoutSeconds"], 120)
+	if err != nil {
+		errors["healthTimeoutSeconds"] = "must be an integer"
+	} else if healthTimeoutSeconds < 10 {
+		errors["healthTimeoutSeconds"] = "must be >= 10"
+	} else if healthTimeoutSeconds > 3600 {
+		errors["healthTimeoutSeconds"] = "must be <= 3600"
+	} else {
+		v["healthTimeoutSeconds"] = healthTimeoutSeconds
+	}
+
 	if len(errors) == 0 {
 		return nil
 	}
 	return errors
 }
 
+func validateRateLimitRoutes(v map[string]any) map[string]string {
+	errors := map[string]string{}
+	defaults, _ := sysconfig.RegisteredDefaults("ratelimit", "routes")
+
+	for _, group := range []string{rateLimitSpaceFetch, rateLimitDockerPull, rateLimitServerOps} {
+		perMinuteField, burstField := group+"PerMinute", group+"Burst"
+
+		perMinute, err := parseIntWithDefault(v[perMinuteField], sysconfig.Int(defaults, perMinuteField, 0))
+		if err != nil {
+			errors[perMinuteField] = "must be an integer"
+		} else if perMinute < 0 {
+			errors[perMinuteField] = "must be >= 0"
+		} else {
+			v[perMinuteField] = perMinute
+		}
+
+		burst, err := parseIntWithDefault(v[burstField], sysconfig.Int(defaults, burstField, 1))
+		if err != nil {
+			errors[burstField] = "must be an integer"
+		} else if burst < 1 {
+			errors[burstField] = "must be >= 1"
+		} else {
+			v[burstField] = burst
+		}
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+func validateDockerSSH(v map[string]any) map[string]string {
+	errors := map[string]string{}
+
+	dialTimeout, err := parseIntWithDefault(v["dialTimeoutSeconds"], 10)
+	if err != nil {
+		errors["dialTimeoutSeconds"] = "must be an integer"
+	} else if dialTimeout < 1 || dialTimeout > 300 {
+		errors["dialTimeoutSeconds"] = "must be between 1 and 300"
+	} else {
+		v["dialTimeoutSeconds"] = dialTimeout
+	}
+
+	commandTimeout, err := parseIntWithDefault(v["commandTimeoutSeconds"], 0)
+	if err != nil {
+		errors["commandTimeoutSeconds"] = "must be an integer"
+	} else if commandTimeout < 0 {
+		errors["commandTimeoutSeconds"] = "must be >= 0"
+	} else {
+		v["commandTimeoutSeconds"] = commandTimeout
+	}
+
+	if raw, ok := v["retryDial"]; !ok || raw == nil {
+		v["retryDial"] = false
+	} else if _, ok := raw.(bool); !ok {
+		errors["retryDial"] = "must be a boolean"
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+func validateDockerScan(v map[string]any) map[string]string {
+	errors := map[string]string{}
+
+	enabled := false
+	if raw, ok := v["enabled"]; !ok || raw == nil {
+		v["enabled"] = false
+	} else if b, ok := raw.(bool); !ok {
+		errors["enabled"] = "must be a boolean"
+	} else {
+		enabled = b
+	}
+
+	scanner, _ := v["scanner"].(string)
+	scanner = strings.TrimSpace(scanner)
+	if scanner == "" {
+		scanner = dockerScannerTrivy
+	}
+	if scanner != dockerScannerTrivy && scanner != dockerScannerEndpoint {
+		errors["scanner"] = "must be trivy or endpoint"
+	} else {
+		v["scanner"] = scanner
+	}
+
+	endpoint, _ := v["endpoint"].(string)
+	endpoint = strings.TrimSpace(endpoint)
+	v["endpoint"] = endpoint
+	if endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors["endpoint"] = "must be an http or https URL"
+		}
+	} else if enabled && scanner == dockerScannerEndpoint {
+		errors["endpoint"] = "is required when scanner is endpoint"
+	}
+
+	if _, ok := v["endpointToken"].(string); !ok {
+		v["endpointToken"] = ""
+	}
+
+	timeout, err := parseIntWithDefault(v["timeoutSeconds"], 300)
+	if err != nil {
+		errors["timeoutSeconds"] = "must be an integer"
+	} else if timeout < 10 || timeout > 3600 {
+		errors["timeoutSeconds"] = "must be between 10 and 3600"
+	} else {
+		v["timeoutSeconds"] = timeout
+	}
+
+	topLimit, err := parseIntWithDefault(v["topLimit"], 10)
+	if err != nil {
+		errors["topLimit"] = "must be an integer"
+	} else if topLimit < 0 || topLimit > 100 {
+		errors["topLimit"] = "must be between 0 and 100"
+	} else {
+		v["topLimit"] = topLimit
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+func validateHTTPCompression(v map[string]any) map[string]string {
+	errors := map[string]string{}
+
+	if raw, ok := v["enabled"]; !ok || raw == nil {
+		v["enabled"] = true
+	} else if _, ok := raw.(bool); !ok {
+		errors["enabled"] = "must be a boolean"
+	}
+
+	minSize, err := parseIntWithDefault(v["minSizeBytes"], defaultCompressionMinSize)
+	if err != nil {
+		errors["minSizeBytes"] = "must be an integer"
+	} else if minSize < 0 {
+		errors["minSizeBytes"] = "must be >= 0"
+	} else {
+		v["minSizeBytes"] = minSize
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+func validateHTTPLimits(v map[string]any) map[string]string {
+	maxBodyMB, err := parseIntWithDefault(v["maxBodyMB"], defaultMaxBodyMB)
+	if err != nil {
+		return map[string]string{"maxBodyMB": "must be an integer"}
+	}
+	if maxBodyMB < 1 || maxBodyMB > 1024 {
+		return map[string]string{"maxBodyMB": "must be between 1 and 1024"}
+	}
+	v["maxBodyMB"] = maxBodyMB
+	maxImportMB, err := parseIntWithDefault(v["maxImportMB"], defaultMaxImportMB)
+	if err != nil {
+		return map[string]string{"maxImportMB": "must be an integer"}
+	}
+	if maxImportMB < 1 || maxImportMB > 1024 {
+		return map[string]string{"maxImportMB": "must be between 1 and 1024"}
+	}
+	v["maxImportMB"] = maxImportMB
+	return nil
+}
+
+func validateSecurityStepUp(v map[string]any) map[string]string {
+	var items []any
+	switch list := v["actions"].(type) {
+	case nil:
+	case []any:
+		items = list
+	case []string:
+		for _, item := range list {
+			items = append(items, item)
+		}
+	default:
+		return map[string]string{"actions": "must be a list of action IDs"}
+	}
+
+	actions := make([]string, 0, len(items))
+	for _, item := range items {
+		id, ok := item.(string)
+		id = strings.TrimSpace(id)
+		if !ok || !stepup.KnownAction(id) {
+			return map[string]string{"actions": fmt.Sprintf("unknown step-up action %v", item)}
+		}
+		if !slices.Contains(actions, id) {
+			actions = append(actions, id)
+		}
+	}
+	v["actions"] = actions
+	return nil
+}
+
+// parseEnvAllowlist normalizes a list of variable names and "PREFIX_*"
+// patterns. A nil list means the field was not submitted.
+func parseEnvAllowlist(raw any) ([]string, string) {
+	var items []any
+	switch list := raw.(type) {
+	case nil:
+		return nil, ""
+	case []any:
+		items = list
+	case []string:
+		for _, item := range list {
+			items = append(items, item)
+		}
+	default:
+		return nil, "must be a list of variable names"
+	}
+
+	patterns := make([]string, 0, len(items))
+	for _, item := range items {
+		p, ok := item.(string)
+		p = strings.TrimSpace(p)
+		if ok && p == "" {
+			continue
+		}
+		if !ok || !servers.ValidEnvNamePattern(p) {
+			return nil, fmt.Sprintf("invalid variable name pattern %v", item)
+		}
+		if !slices.Contains(patterns, p) {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, ""
+}
+
+func validateAuditActions(v map[string]any) map[string]string {
+	errors := map[string]string{}
+	for _, field := range []string{"excludeActions", "includeActions"} {
+		var items []any
+		switch list := v[field].(type) {
+		case nil:
+		case []any:
+			items = list
+		case []string:
+			for _, item := range list {
+				items = append(items, item)
+			}
+		default:
+			errors[field] = "must be a list of action IDs"
+			continue
+		}
+
+		patterns := make([]string, 0, len(items))
+		for _, item := range items {
+			p, ok := item.(string)
+			p = strings.TrimSpace(p)
+			if !ok || !audit.ValidActionPattern(p) {
+				errors[field] = fmt.Sprintf("invalid action pattern %v", item)
+				break
+			}
+			if !slices.Contains(patterns, p) {
+				patterns = append(patterns, p)
+			}
+		}
+		v[field] = patterns
+	}
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func validateAuditExport(v map[string]any) map[string]string {
+	maxRows, err := parseIntWithDefault(v["maxRows"], audit.DefaultExportMaxRows)
+	if err != nil {
+		return map[string]string{"maxRows": "must be an integer"}
+	}
+	if maxRows < 1 || maxRows > 10000000 {
+		return map[string]string{"maxRows": "must be between 1 and 10000000"}
+	}
+	v["maxRows"] = maxRows
+	return nil
+}
+
 func validateIacFiles(v map[string]any) map[string]string {
 	errors := map[string]string{}
 
@@ -266,6 +674,24 @@ func validateConnectSftp(v map[string]any) map[string]string {
 		v["maxUploadFiles"] = maxUploadFiles
 	}
 
+	transferRateKBps, err := parseIntWithDefault(v["transferRateKBps"], 0)
+	if err != nil {
+		errors["transferRateKBps"] = "must be an integer"
+	} else if transferRateKBps < 0 {
+		errors["transferRateKBps"] = "must be >= 0"
+	} else {
+		v["transferRateKBps"] = transferRateKBps
+	}
+
+	maxArchiveMB, err := parseIntWithDefault(v["maxArchiveMB"], 1024)
+	if err != nil {
+		errors["maxArchiveMB"] = "must be an integer"
+	} else if maxArchiveMB < 1 {
+		errors["maxArchiveMB"] = "must be >= 1"
+	} else {
+		v["maxArchiveMB"] = maxArchiveMB
+	}
+
 	if len(errors) == 0 {
 		return nil
 	}
@@ -274,10 +700,9 @@ func validateConnectSftp(v map[string]any) map[string]string {
 
 // ─── Defaults ──────────────────────────────────────────────────────────────
 
-// fallbackForKey returns the code-level fallback for a given (module, key) pair.
+// fallbackForKey returns the registered defaults for a given (module, key) pair.
 func fallbackForKey(module, key string) map[string]any {
-	fallback := settingscatalog.DefaultGroup(module, key)
-	if len(fallback) != 0 {
+	if fallback, ok := sysconfig.RegisteredDefaults(module, key); ok {
 		return fallback
 	}
 	return map[string]any{}
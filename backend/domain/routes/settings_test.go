@@ -10,6 +10,7 @@ import (
 
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	"github.com/websoft9/appos/backend/domain/secrets"
 )
@@ -216,6 +217,54 @@ func TestSettingsEntryPatchValidation(t *testing.T) {
 	if !strings.Contains(rec.Body.String(), "maxSizeMB") {
 		t.Fatalf("expected iac-files validation error, got %s", rec.Body.String())
 	}
+
+	badPreview := `{"previewMimeTypes":["image/png","text/html"]}`
+	rec = doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/space-quota", badPreview, true)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for active preview MIME type, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "previewMimeTypes") {
+		t.Fatalf("expected previewMimeTypes validation error, got %s", rec.Body.String())
+	}
+}
+
+func TestAuditActionFilterSkipsRoutineReads(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	write := func(action, status string) {
+		audit.Write(te.app, audit.Entry{UserID: "system", Action: action, ResourceType: "server", Status: status})
+	}
+
+	// Default settings drop successful routine reads but keep their failures.
+	write("server.ops.systemd.status", audit.StatusSuccess)
+	write("server.ops.systemd.status", audit.StatusFailed)
+	write("server.ops.power", audit.StatusSuccess)
+	if got := len(auditEntriesByAction(t, te, "server.ops.systemd.status")); got != 1 {
+		t.Fatalf("expected only the failed systemd.status entry, got %d", got)
+	}
+	if got := len(auditEntriesByAction(t, te, "server.ops.power")); got != 1 {
+		t.Fatalf("expected server.ops.power to be audited, got %d", got)
+	}
+
+	rec := doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/audit-actions", `{"excludeActions":["Bad Pattern"]}`, true)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "excludeActions") {
+		t.Fatalf("expected 422 for invalid pattern, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := `{"excludeActions":["server.ops.*"],"includeActions":["server.ops.systemd.status"]}`
+	rec = doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/audit-actions", body, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	write("server.ops.systemd.status", audit.StatusSuccess)
+	write("server.ops.power", audit.StatusSuccess)
+	if got := len(auditEntriesByAction(t, te, "server.ops.systemd.status")); got != 2 {
+		t.Fatalf("expected included action to be audited, got %d entries", got)
+	}
+	if got := len(auditEntriesByAction(t, te, "server.ops.power")); got != 1 {
+		t.Fatalf("expected prefix exclusion to drop server.ops.power, got %d entries", got)
+	}
 }
 
 func TestSettingsEntryPatchPersistsUnifiedValues(t *testing.T) {
@@ -344,3 +393,91 @@ func TestSecretsRevealDisabledByPolicy(t *testing.T) {
 		t.Fatalf("expected admin-disabled message, got %s", res.Body.String())
 	}
 }
+
+func TestSettingsTunnelPortRangeWarnsOnLowCapacity(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	createTunnelServerRecord(t, te, "edge-1")
+	createTunnelServerRecord(t, te, "edge-2")
+
+	rec := doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/tunnel-port-range", `{"start":41000,"end":41002}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for tunnel entry patch, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "new tunnel connections will be rejected") {
+		t.Fatalf("expected exhaustion warning, got %s", rec.Body.String())
+	}
+
+	rec = doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/tunnel-port-range", `{"start":41000,"end":41999}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for tunnel entry patch, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "warnings") {
+		t.Fatalf("expected no warnings for a roomy range, got %s", rec.Body.String())
+	}
+}
+
+func TestSettingsEntryProfileOverrides(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/iac-files?profile=dev", `{"maxSizeMB":40}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for profile patch, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Profile   string         `json:"profile"`
+		Value     map[string]any `json:"value"`
+		Overrides map[string]any `json:"overrides"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Profile != "dev" || len(body.Overrides) != 1 || sysconfig.Int(body.Value, "maxSizeMB", 0) != 40 {
+		t.Fatalf("unexpected profile patch response: %+v", body)
+	}
+
+	// The global entry is untouched, and the resolved profile entry is
+	// validated as a whole.
+	global, _ := sysconfig.GetProfileGroup(te.app, "", "files", "limits", nil)
+	if got := sysconfig.Int(global, "maxSizeMB", 0); got == 40 {
+		t.Fatal("profile patch leaked into the global entry")
+	}
+	rec = doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/iac-files?profile=dev", `{"maxZipSizeMB":0}`, true)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for invalid override, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	t.Setenv(sysconfig.EnvProfile, "dev")
+	active, _ := sysconfig.GetGroup(te.app, "files", "limits", nil)
+	if got := sysconfig.Int(active, "maxSizeMB", 0); got != 40 {
+		t.Fatalf("expected active dev profile to resolve maxSizeMB 40, got %d", got)
+	}
+
+	// Global edits under an active profile still write the global row.
+	rec = doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/iac-files", `{"maxSizeMB":15}`, true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"maxSizeMB":15`) {
+		t.Fatalf("expected global patch to return global value, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doSettingsRoute(t, te, http.MethodGet, "/api/settings/profiles", "", true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"active":"dev"`) || !strings.Contains(rec.Body.String(), `"items":["dev"]`) {
+		t.Fatalf("unexpected profiles response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// null drops the override again.
+	rec = doSettingsRoute(t, te, http.MethodPatch, "/api/settings/entries/iac-files?profile=dev", `{"maxSizeMB":null}`, true)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"overrides":{}`) {
+		t.Fatalf("expected override removed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doSettingsRoute(t, te, http.MethodGet, "/api/settings/entries/basic?profile=dev", "", true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for profile on a native entry, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = doSettingsRoute(t, te, http.MethodGet, "/api/settings/entries/iac-files?profile=Bad%20Name", "", true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid profile, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"os"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,6 +18,7 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/pocketbase/pocketbase/tools/hook"
 	sharedshare "github.com/websoft9/appos/backend/domain/share"
 	"github.com/websoft9/appos/backend/domain/space"
 	"github.com/websoft9/appos/backend/infra/safefetch"
@@ -25,6 +30,12 @@ import (
 //
 // GET    /api/space/quota         — current effective quota limits (for UI pre-check)
 // POST   /api/space/fetch         — fetch remote URL into user space
+// POST   /api/space/upload        — multipart batch upload (all or nothing)
+// POST   /api/space/rename/{id}   — rename a file or folder
+// POST   /api/space/mime/{id}     — correct a file's stored MIME type
+// POST   /api/space/trash/{id}    — move a file or folder to trash
+// POST   /api/space/restore/{id}  — restore a trashed item to its folder
+// DELETE /api/space/trash         — permanently delete everything in trash
 // POST   /api/space/share/{id}    — create or refresh share token
 // DELETE /api/space/share/{id}    — revoke share
 func registerSpaceRoutes(se *core.ServeEvent) {
@@ -32,7 +43,13 @@ func registerSpaceRoutes(se *core.ServeEvent) {
 	f.Bind(apis.RequireAuth())
 
 	f.GET("/quota", handleSpaceQuota)
-	f.POST("/fetch", handleSpaceFetch)
+	f.POST("/fetch", handleSpaceFetch).Bind(routeRateLimit(rateLimitSpaceFetch))
+	f.POST("/upload", handleSpaceUpload).Bind(spaceUploadBodyLimit())
+	f.POST("/rename/{id}", handleSpaceRename)
+	f.POST("/mime/{id}", handleSpaceSetMimeType)
+	f.POST("/trash/{id}", handleSpaceTrash)
+	f.POST("/restore/{id}", handleSpaceRestore)
+	f.DELETE("/trash", handleSpaceEmptyTrash)
 	f.POST("/share/{id}", handleFileShareCreate)
 	f.DELETE("/share/{id}", handleFileShareRevoke)
 }
@@ -51,7 +68,9 @@ func registerSpacePublicRoutes(se *core.ServeEvent) {
 
 // ─── Handlers ──────────────────────────────────────────────────────────────
 
-// handleSpaceQuota returns the currently active quota limits.
+// handleSpaceQuota returns the currently active quota limits, along with the
+// editable formats and preview MIME types the UI needs to choose between
+// preview, edit and download.
 //
 // @Summary Get space quota limits
 // @Description Returns effective upload/share quota limits for the authenticated user. Auth required.
@@ -62,6 +81,10 @@ func registerSpacePublicRoutes(se *core.ServeEvent) {
 // @Router /api/space/quota [get]
 func handleSpaceQuota(e *core.RequestEvent) error {
 	quota := space.GetQuota(e.App)
+	previewMimeTypes := quota.PreviewMimeTypes
+	if previewMimeTypes == nil {
+		previewMimeTypes = []string{}
+	}
 	return e.JSON(http.StatusOK, map[string]any{
 		"max_size_mb":             quota.MaxSizeMB,
 		"editable_formats":        strings.Split(space.EditableFormats, ","),
@@ -73,13 +96,15 @@ func handleSpaceQuota(e *core.RequestEvent) error {
 		"share_default_minutes":   quota.ShareDefaultMinutes,
 		"reserved_folder_names":   strings.Split(space.ReservedFolderNames, ","),
 		"disallowed_folder_names": quota.DisallowedFolderNames,
+		"preview_mime_types":      previewMimeTypes,
 	})
 }
 
 // handleSpacePreview streams a file for authenticated inline preview.
 //
 // Supports auth via Authorization header OR ?token= query param (for browser embed).
-// Only MIME types in space.PreviewMimeTypes are allowed; others return 415.
+// Only MIME types in the space previewMimeTypes setting (default
+// space.PreviewMimeTypes) are allowed; others return 415.
 //
 // @Summary Preview file inline
 // @Description Streams a file for inline browser preview. Public route (token validated internally).
@@ -120,7 +145,7 @@ func handleSpacePreview(e *core.RequestEvent) error {
 	if uf.IsFolder() {
 		return e.BadRequestError("Folders cannot be previewed", nil)
 	}
-	if !uf.IsPreviewable() {
+	if !uf.IsPreviewable(space.GetQuota(e.App).PreviewMimeTypes) {
 		return e.JSON(http.StatusUnsupportedMediaType,
 			fileError("preview not supported for this file type"))
 	}
@@ -158,6 +183,233 @@ func handleSpacePreview(e *core.RequestEvent) error {
 	return nil
 }
 
+// handleSpaceRename changes the display name of a user_files record. The
+// stored blob is untouched. Names must be unique among live siblings, folder
+// names must pass the reserved/disallowed folder policy, and a file's new
+// extension must pass the upload allow/deny lists.
+//
+// @Summary Rename file or folder
+// @Description Renames a file or folder owned by the caller. Auth required.
+// @Tags Space
+// @Security BearerAuth
+// @Param id path string true "user_files record ID"
+// @Param body body object true "name"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/space/rename/{id} [post]
+func handleSpaceRename(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+
+	record, err := e.App.FindRecordById(space.Collection, id)
+	if err != nil {
+		return e.NotFoundError("File not found", err)
+	}
+
+	uf := space.From(record)
+	if !uf.IsOwnedBy(e.Auth) {
+		return e.ForbiddenError("Access denied", nil)
+	}
+	if uf.IsDeleted() {
+		return e.BadRequestError("cannot rename an item in trash", nil)
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+	name := strings.TrimSpace(body.Name)
+	if err := space.ValidateItemName(name); err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	quota := space.GetQuota(e.App)
+	if uf.IsFolder() {
+		if err := space.ValidateFolderName(quota, name, uf.Parent() == ""); err != nil {
+			return e.BadRequestError(err.Error(), nil)
+		}
+	} else if err := space.ValidateExt(quota, space.NormalizeExt(path.Ext(name))); err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	if name != uf.Name() {
+		siblings, err := e.App.FindAllRecords(space.Collection, dbx.HashExp{
+			"owner":      uf.Owner(),
+			"parent":     uf.Parent(),
+			"name":       name,
+			"is_deleted": false,
+		})
+		if err != nil {
+			return e.JSON(http.StatusInternalServerError, fileError("failed to check existing names"))
+		}
+		for _, sibling := range siblings {
+			if sibling.Id != record.Id {
+				return e.JSON(http.StatusConflict, fileError(fmt.Sprintf("an item named %q already exists in this folder", name)))
+			}
+		}
+
+		record.Set("name", name)
+		if err := e.App.Save(record); err != nil {
+			return e.JSON(http.StatusInternalServerError, fileError("failed to rename"))
+		}
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"id":        record.Id,
+		"name":      name,
+		"parent":    uf.Parent(),
+		"is_folder": uf.IsFolder(),
+	})
+}
+
+// handleSpaceSetMimeType corrects the stored mime_type of a file whose
+// detected type was wrong, e.g. so it becomes previewable.
+//
+// @Summary Correct file MIME type
+// @Description Overrides the stored MIME type of a file owned by the caller. The type must be known (built-in extension types or the preview allowlist) and active content types (HTML, XML/SVG, script) must match the file extension. Auth required.
+// @Tags Space
+// @Security BearerAuth
+// @Param id path string true "user_files record ID"
+// @Param body body object true "mime_type"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/space/mime/{id} [post]
+func handleSpaceSetMimeType(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+
+	record, err := e.App.FindRecordById(space.Collection, id)
+	if err != nil {
+		return e.NotFoundError("File not found", err)
+	}
+
+	uf := space.From(record)
+	if !uf.IsOwnedBy(e.Auth) {
+		return e.ForbiddenError("Access denied", nil)
+	}
+	if uf.IsFolder() {
+		return e.BadRequestError("folders have no MIME type", nil)
+	}
+
+	var body struct {
+		MimeType string `json:"mime_type"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+	mimeType, err := space.ValidateMimeOverride(uf.Name(), body.MimeType, space.GetQuota(e.App).PreviewMimeTypes)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	record.Set("mime_type", mimeType)
+	if err := e.App.Save(record); err != nil {
+		return e.JSON(http.StatusInternalServerError, fileError("failed to save MIME type"))
+	}
+	return e.JSON(http.StatusOK, map[string]any{"id": record.Id, "mime_type": mimeType})
+}
+
+// handleSpaceTrash moves a file or folder to trash.
+//
+// @Summary Move to trash
+// @Description Moves a file or folder owned by the caller to trash, remembering its folder for restore and revoking any share link. A folder's contents go with it. Auth required.
+// @Tags Space
+// @Security BearerAuth
+// @Param id path string true "user_files record ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/space/trash/{id} [post]
+func handleSpaceTrash(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById(space.Collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("File not found", err)
+	}
+
+	uf := space.From(record)
+	if !uf.IsOwnedBy(e.Auth) {
+		return e.ForbiddenError("Access denied", nil)
+	}
+	if err := space.MoveToTrash(e.App, uf); err != nil {
+		if errors.Is(err, space.ErrAlreadyInTrash) {
+			return e.BadRequestError(err.Error(), nil)
+		}
+		return e.JSON(http.StatusInternalServerError, fileError("failed to move to trash"))
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"id":              record.Id,
+		"is_deleted":      true,
+		"original_parent": uf.OriginalParent(),
+	})
+}
+
+// handleSpaceRestore restores a trashed file or folder to its original folder.
+//
+// @Summary Restore from trash
+// @Description Restores a trashed item owned by the caller into the folder it was trashed from. Fails with 409 when that folder was permanently deleted or is itself in trash, or when it already holds an item of the same name. Auth required.
+// @Tags Space
+// @Security BearerAuth
+// @Param id path string true "user_files record ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/space/restore/{id} [post]
+func handleSpaceRestore(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById(space.Collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("File not found", err)
+	}
+
+	uf := space.From(record)
+	if !uf.IsOwnedBy(e.Auth) {
+		return e.ForbiddenError("Access denied", nil)
+	}
+	if err := space.Restore(e.App, uf); err != nil {
+		switch {
+		case errors.Is(err, space.ErrNotInTrash):
+			return e.BadRequestError(err.Error(), nil)
+		case errors.Is(err, space.ErrRestoreParentMissing),
+			errors.Is(err, space.ErrRestoreParentTrashed),
+			errors.Is(err, space.ErrRestoreNameTaken):
+			return e.JSON(http.StatusConflict, fileError(err.Error()))
+		}
+		return e.JSON(http.StatusInternalServerError, fileError("failed to restore"))
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"id":         record.Id,
+		"parent":     uf.Parent(),
+		"is_deleted": false,
+	})
+}
+
+// handleSpaceEmptyTrash permanently deletes the caller's trashed items.
+//
+// @Summary Empty trash
+// @Description Permanently deletes every trashed item owned by the caller, including the contents of trashed folders, and removes their stored files. Auth required.
+// @Tags Space
+// @Security BearerAuth
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/space/trash [delete]
+func handleSpaceEmptyTrash(e *core.RequestEvent) error {
+	removed, err := space.PurgeTrash(e.App, e.Auth.Id)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, fileError("failed to empty trash"))
+	}
+	return e.JSON(http.StatusOK, map[string]any{"removed": removed})
+}
+
 // handleFileShareCreate creates or refreshes a time-limited share token for a file.
 //
 // @Summary Create file share token
@@ -329,13 +581,14 @@ func handleFileShareDownload(e *core.RequestEvent) error {
 // handleSpaceFetch fetches a remote resource and saves it to the user's space.
 //
 // @Summary Fetch remote file into space
-// @Description Downloads a remote URL and stores the result as a user_files record. Auth required.
+// @Description Downloads a remote URL and stores the result as a user_files record. The response carries the content_hash (SHA-256) and, when the owner already stores identical content, duplicate_of. Auth required.
 // @Tags Space
 // @Security BearerAuth
 // @Param body body object true "url, optional name and parent folder ID"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/space/fetch [post]
 func handleSpaceFetch(e *core.RequestEvent) error {
@@ -364,28 +617,15 @@ func handleSpaceFetch(e *core.RequestEvent) error {
 
 	// Validate parent folder if provided.
 	body.Parent = strings.TrimSpace(body.Parent)
-	if body.Parent != "" {
-		parent, err := e.App.FindRecordById(space.Collection, body.Parent)
-		if err != nil {
-			return e.BadRequestError("parent folder not found", nil)
-		}
-		parentFile := space.From(parent)
-		if !parentFile.IsOwnedByID(authRecord.Id) {
-			return e.ForbiddenError("access denied to parent folder", nil)
-		}
-		if !parentFile.IsFolder() {
-			return e.BadRequestError("parent must be a folder", nil)
-		}
-		if parentFile.IsDeleted() {
-			return e.BadRequestError("cannot save into trash folder", nil)
-		}
+	if err := requireSpaceParentFolder(e, authRecord.Id, body.Parent); err != nil {
+		return err
 	}
 
-	// Enforce per-user item limit.
+	// Enforce per-user item limit early; it is checked again under the owner
+	// lock right before the record is saved.
 	if quota.MaxPerUser > 0 {
-		existing, err := e.App.FindAllRecords(space.Collection, dbx.HashExp{"owner": authRecord.Id})
-		if err == nil {
-			if itemErr := space.ValidateItemCount(len(existing), quota.MaxPerUser); itemErr != nil {
+		if existing, err := space.CountItems(e.App, authRecord.Id); err == nil {
+			if itemErr := space.ValidateItemCount(existing, quota.MaxPerUser); itemErr != nil {
 				return e.BadRequestError(itemErr.Error(), nil)
 			}
 		}
@@ -420,8 +660,9 @@ func handleSpaceFetch(e *core.RequestEvent) error {
 
 	client := safefetch.NewClient()
 
-	// Optional early rejection via HEAD.
-	headCtx, headCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	// Optional early rejection via HEAD. Both requests follow the client
+	// request context so navigating away cancels the fetch.
+	headCtx, headCancel := context.WithTimeout(e.Request.Context(), 15*time.Second)
 	defer headCancel()
 	if headReq, headErr := http.NewRequestWithContext(headCtx, http.MethodHead, body.URL, nil); headErr == nil {
 		if headResp, err := client.Do(headReq); err == nil {
@@ -434,7 +675,7 @@ func handleSpaceFetch(e *core.RequestEvent) error {
 	}
 
 	// Download with a hard size cap and timeout.
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	ctx, cancel := context.WithTimeout(e.Request.Context(), 120*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, body.URL, nil)
@@ -452,28 +693,24 @@ func handleSpaceFetch(e *core.RequestEvent) error {
 			fmt.Sprintf("remote server returned HTTP %d", getResp.StatusCode), nil)
 	}
 
-	data, err := io.ReadAll(io.LimitReader(getResp.Body, maxBytes+1))
+	spooled, err := spoolRemoteFile(getResp.Body, name, maxBytes)
 	if err != nil {
+		if errors.Is(err, errSpoolTooLarge) {
+			return e.BadRequestError(
+				fmt.Sprintf("remote file exceeds size limit (%d MB)", quota.MaxSizeMB), nil)
+		}
 		return e.BadRequestError("failed to read remote content: "+err.Error(), nil)
 	}
-	if int64(len(data)) > maxBytes {
-		return e.BadRequestError(
-			fmt.Sprintf("remote file exceeds size limit (%d MB)", quota.MaxSizeMB), nil)
-	}
+	defer spooled.cleanup()
 
-	// Detect MIME type; prefer server's Content-Type header.
-	mimeType := http.DetectContentType(data)
-	if ct := getResp.Header.Get("Content-Type"); ct != "" {
-		if idx := strings.Index(ct, ";"); idx >= 0 {
-			ct = ct[:idx]
-		}
-		ct = strings.TrimSpace(ct)
-		if ct != "" && ct != "application/octet-stream" {
-			mimeType = ct
-		}
+	// The sniffed type is authoritative; mismatches with the extension are
+	// rejected so the stored mime_type cannot be used for content confusion.
+	mimeType, err := space.ResolveFetchedMimeType(ext, getResp.Header.Get("Content-Type"), spooled.head)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
 	}
 
-	pbFile, err := filesystem.NewFileFromBytes(data, name)
+	pbFile, err := filesystem.NewFileFromPath(spooled.path)
 	if err != nil {
 		return e.JSON(http.StatusInternalServerError, fileError("failed to create file object"))
 	}
@@ -483,11 +720,23 @@ func handleSpaceFetch(e *core.RequestEvent) error {
 		return e.JSON(http.StatusInternalServerError, fileError("user_files collection not found"))
 	}
 
+	unlock := space.LockOwner(authRecord.Id)
+	defer unlock()
+	if quota.MaxPerUser > 0 {
+		existing, err := space.CountItems(e.App, authRecord.Id)
+		if err != nil {
+			return e.JSON(http.StatusInternalServerError, fileError("failed to count existing items"))
+		}
+		if itemErr := space.ValidateItemCount(existing, quota.MaxPerUser); itemErr != nil {
+			return e.BadRequestError(itemErr.Error(), nil)
+		}
+	}
+
 	newRecord := core.NewRecord(col)
 	newRecord.Set("owner", authRecord.Id)
 	newRecord.Set("name", name)
 	newRecord.Set("mime_type", mimeType)
-	newRecord.Set("size", len(data))
+	newRecord.Set("size", spooled.size)
 	newRecord.Set("parent", body.Parent)
 	newRecord.Set("is_folder", false)
 	newRecord.Set("content", pbFile)
@@ -496,20 +745,404 @@ func handleSpaceFetch(e *core.RequestEvent) error {
 		return e.JSON(http.StatusInternalServerError, fileError("failed to save file: "+err.Error()))
 	}
 
-	return e.JSON(http.StatusOK, map[string]any{
-		"id":        newRecord.Id,
-		"name":      newRecord.GetString("name"),
-		"size":      newRecord.GetInt("size"),
-		"mime_type": newRecord.GetString("mime_type"),
+	return e.JSON(http.StatusOK, spaceStoredFileResult(e.App, newRecord))
+}
+
+// handleSpaceUpload stores several uploaded files in one all-or-nothing batch.
+//
+// @Summary Batch upload files into space
+// @Description Accepts multipart/form-data with one or more "files" parts and an optional "parent" folder ID. The whole batch is checked against the space quota (maxUploadFiles, per-user item limit, per-file extension and size) before anything is stored, and all records are created in one transaction: either every file is saved or none is. For folder uploads send one "paths" entry per file (e.g. "photos/2024/a.jpg"); missing folders are created under parent, existing ones with the same name are reused, reserved root folder names are rejected, and new folders count toward the item limit. The response includes the folder tree touched by the upload, and each stored item carries its content_hash (SHA-256) plus duplicate_of when the owner already stores identical content. On rejection the response lists a per-file error under results. Auth required.
+// @Tags Space
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Param files formData file true "files to upload (repeatable)"
+// @Param parent formData string false "parent folder ID"
+// @Param paths formData string false "relative path per file, in the same order as files (repeatable)"
+// @Success 200 {object} map[string]any "items, folders"
+// @Failure 400 {object} map[string]any "message, results"
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 413 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/space/upload [post]
+func handleSpaceUpload(e *core.RequestEvent) error {
+	authRecord := e.Auth
+	quota := space.GetQuota(e.App)
+
+	// Files beyond the in-memory budget are spooled to temp files by net/http.
+	if err := e.Request.ParseMultipartForm(32 << 20); err != nil {
+		return e.BadRequestError("invalid multipart body", err)
+	}
+	defer e.Request.MultipartForm.RemoveAll()
+	headers := e.Request.MultipartForm.File["files"]
+
+	parentID := strings.TrimSpace(e.Request.FormValue("parent"))
+	if err := requireSpaceParentFolder(e, authRecord.Id, parentID); err != nil {
+		return err
+	}
+
+	// Hold the owner lock from the item count until the batch is stored so
+	// parallel uploads cannot overshoot maxPerUser.
+	unlock := space.LockOwner(authRecord.Id)
+	defer unlock()
+	existing, err := space.CountItems(e.App, authRecord.Id)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, fileError("failed to count existing items"))
+	}
+
+	// Folder uploads send one relative path per file in "paths"; multipart
+	// filenames cannot carry one as Go strips directories from them.
+	paths := e.Request.MultipartForm.Value["paths"]
+	if len(paths) > 0 && len(paths) != len(headers) {
+		return e.BadRequestError("paths must have one entry per file", nil)
+	}
+
+	items := make([]space.UploadItem, len(headers))
+	itemDirs := make([]string, len(headers))
+	pathErrs := make([]error, len(headers))
+	var dirSegments [][]string
+	for i, fh := range headers {
+		rel := fh.Filename
+		if len(paths) > 0 {
+			rel = paths[i]
+		}
+		dirs, name, perr := space.SplitUploadPath(rel)
+		if perr != nil {
+			name, pathErrs[i] = strings.TrimSpace(rel), perr
+		}
+		items[i] = space.UploadItem{Name: name, Size: fh.Size}
+		itemDirs[i] = strings.Join(dirs, "/")
+		dirSegments = append(dirSegments, dirs)
+	}
+
+	folders, err := planSpaceUploadFolders(e.App, authRecord.Id, parentID, dirSegments)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, fileError("failed to resolve folders"))
+	}
+	newFolders := 0
+	for _, folder := range folders {
+		if folder.id == "" {
+			newFolders++
+			folder.err = space.ValidateFolderName(quota, folder.name, folder.parent == "" && parentID == "")
+		}
+	}
+
+	itemErrs, err := space.ValidateUploadBatch(quota, existing, newFolders, items)
+	if err != nil {
+		return e.BadRequestError(err.Error(), nil)
+	}
+	results := make([]map[string]any, len(items))
+	rejected := false
+	for i, item := range items {
+		results[i] = map[string]any{"name": item.Name}
+		if itemDirs[i] != "" {
+			results[i]["path"] = itemDirs[i] + "/" + item.Name
+		}
+		itemErr := itemErrs[i]
+		if pathErrs[i] != nil {
+			itemErr = pathErrs[i]
+		}
+		for dir := itemDirs[i]; itemErr == nil && dir != ""; dir = folders[dir].parent {
+			itemErr = folders[dir].err
+		}
+		if itemErr != nil {
+			results[i]["error"] = itemErr.Error()
+			rejected = true
+		}
+	}
+	if rejected {
+		return e.JSON(http.StatusBadRequest, map[string]any{
+			"message": "upload rejected; no files were stored",
+			"results": results,
+		})
+	}
+
+	col, err := e.App.FindCollectionByNameOrId(space.Collection)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, fileError("user_files collection not found"))
+	}
+
+	created := make([]*core.Record, 0, len(headers))
+	err = e.App.RunInTransaction(func(txApp core.App) error {
+		folderID := func(dir string) string {
+			if dir == "" {
+				return parentID
+			}
+			return folders[dir].id
+		}
+		for _, dir := range sortedSpaceUploadFolderPaths(folders) {
+			folder := folders[dir]
+			if folder.id != "" {
+				continue
+			}
+			record := core.NewRecord(col)
+			record.Set("owner", authRecord.Id)
+			record.Set("name", folder.name)
+			record.Set("parent", folderID(folder.parent))
+			record.Set("is_folder", true)
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("%s: %w", dir, err)
+			}
+			folder.id = record.Id
+			folder.created = true
+		}
+		for i, fh := range headers {
+			pbFile, err := filesystem.NewFileFromMultipart(fh)
+			if err != nil {
+				return fmt.Errorf("%s: %w", items[i].Name, err)
+			}
+			record := core.NewRecord(col)
+			record.Set("owner", authRecord.Id)
+			record.Set("name", items[i].Name)
+			record.Set("mime_type", uploadedMimeType(fh))
+			record.Set("size", fh.Size)
+			record.Set("parent", folderID(itemDirs[i]))
+			record.Set("is_folder", false)
+			record.Set("content", pbFile)
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("%s: %w", items[i].Name, err)
+			}
+			created = append(created, record)
+		}
+		return nil
 	})
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, fileError("failed to save files: "+err.Error()))
+	}
+
+	out := make([]map[string]any, 0, len(created))
+	for _, record := range created {
+		result := spaceStoredFileResult(e.App, record)
+		result["parent"] = record.GetString("parent")
+		out = append(out, result)
+	}
+	return e.JSON(http.StatusOK, map[string]any{"items": out, "folders": spaceUploadFolderTree(folders)})
 }
 
 // ─── Helpers ───────────────────────────────────────────────────────────────
 
+// spaceUploadBodyLimit replaces PocketBase's default 32 MB body limit for the
+// batch upload route with one sized to a full batch at the current quota.
+func spaceUploadBodyLimit() *hook.Handler[*core.RequestEvent] {
+	return bodyLimit(func(app core.App) int64 {
+		quota := space.GetQuota(app)
+		return int64(quota.MaxUploadFiles)*int64(quota.MaxSizeMB)<<20 + apis.DefaultMaxBodySize
+	})
+}
+
+// requireSpaceParentFolder checks that parentID (if set) is a live folder
+// owned by ownerID, returning the API error to send otherwise.
+func requireSpaceParentFolder(e *core.RequestEvent, ownerID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	parent, err := e.App.FindRecordById(space.Collection, parentID)
+	if err != nil {
+		return e.BadRequestError("parent folder not found", nil)
+	}
+	parentFile := space.From(parent)
+	if !parentFile.IsOwnedByID(ownerID) {
+		return e.ForbiddenError("access denied to parent folder", nil)
+	}
+	if !parentFile.IsFolder() {
+		return e.BadRequestError("parent must be a folder", nil)
+	}
+	if parentFile.IsDeleted() {
+		return e.BadRequestError("cannot save into trash folder", nil)
+	}
+	return nil
+}
+
+// spaceUploadFolder is one folder of a folder upload, keyed by its path
+// relative to the upload parent.
+type spaceUploadFolder struct {
+	name    string
+	parent  string // relative path of the parent folder; "" is the upload parent
+	id      string // empty until the folder exists
+	created bool
+	err     error
+}
+
+// planSpaceUploadFolders resolves every folder named by dirSegments,
+// reusing live folders of the same name under the same parent so repeated
+// uploads merge into the existing tree. Folders still lacking an id must be
+// created.
+func planSpaceUploadFolders(app core.App, ownerID, parentID string, dirSegments [][]string) (map[string]*spaceUploadFolder, error) {
+	folders := map[string]*spaceUploadFolder{}
+	for _, dirs := range dirSegments {
+		for depth := range dirs {
+			dir := strings.Join(dirs[:depth+1], "/")
+			if folders[dir] == nil {
+				folders[dir] = &spaceUploadFolder{name: dirs[depth], parent: strings.Join(dirs[:depth], "/")}
+			}
+		}
+	}
+	for _, dir := range sortedSpaceUploadFolderPaths(folders) {
+		folder := folders[dir]
+		lookupParent := parentID
+		if folder.parent != "" {
+			lookupParent = folders[folder.parent].id
+			if lookupParent == "" {
+				continue // parent is new, so this one is too
+			}
+		}
+		records, err := app.FindAllRecords(space.Collection, dbx.HashExp{
+			"owner":      ownerID,
+			"parent":     lookupParent,
+			"name":       folder.name,
+			"is_folder":  true,
+			"is_deleted": false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 {
+			folder.id = records[0].Id
+		}
+	}
+	return folders, nil
+}
+
+// sortedSpaceUploadFolderPaths orders folder paths so parents precede
+// their children.
+func sortedSpaceUploadFolderPaths(folders map[string]*spaceUploadFolder) []string {
+	dirs := make([]string, 0, len(folders))
+	for dir := range folders {
+		dirs = append(dirs, dir)
+	}
+	slices.SortFunc(dirs, func(a, b string) int {
+		if n := strings.Count(a, "/") - strings.Count(b, "/"); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	return dirs
+}
+
+// spaceUploadFolderTree nests the folders of an upload for the response.
+func spaceUploadFolderTree(folders map[string]*spaceUploadFolder) []map[string]any {
+	nodes := make(map[string]map[string]any, len(folders))
+	roots := []map[string]any{}
+	for _, dir := range sortedSpaceUploadFolderPaths(folders) {
+		folder := folders[dir]
+		node := map[string]any{
+			"id":       folder.id,
+			"name":     folder.name,
+			"path":     dir,
+			"created":  folder.created,
+			"children": []map[string]any{},
+		}
+		nodes[dir] = node
+		if folder.parent == "" {
+			roots = append(roots, node)
+			continue
+		}
+		parent := nodes[folder.parent]
+		parent["children"] = append(parent["children"].([]map[string]any), node)
+	}
+	return roots
+}
+
+// spaceStoredFileResult describes a newly stored file for upload and fetch
+// responses. duplicate_of names an older file of the same owner with
+// identical content, so clients can warn about (or clean up) re-uploads.
+func spaceStoredFileResult(app core.App, record *core.Record) map[string]any {
+	result := map[string]any{
+		"id":           record.Id,
+		"name":         record.GetString("name"),
+		"size":         record.GetInt("size"),
+		"mime_type":    record.GetString("mime_type"),
+		"content_hash": record.GetString("content_hash"),
+	}
+	dup, err := space.FindDuplicate(app, record.GetString("owner"), record.GetString("content_hash"), record.Id)
+	if err != nil {
+		app.Logger().Warn("space: duplicate lookup failed", "id", record.Id, "error", err)
+	}
+	if dup != nil {
+		result["duplicate_of"] = map[string]any{
+			"id":     dup.Id,
+			"name":   dup.GetString("name"),
+			"parent": dup.GetString("parent"),
+		}
+	}
+	return result
+}
+
+// uploadedMimeType returns the MIME type to store for an uploaded part.
+func uploadedMimeType(fh *multipart.FileHeader) string {
+	return space.DetectUploadMimeType(fh.Filename, fh.Header.Get("Content-Type"))
+}
+
 func findByShareToken(e *core.RequestEvent, token string) (*core.Record, error) {
 	return e.App.FindFirstRecordByData(space.Collection, "share_token", token)
 }
 
+// errSpoolTooLarge reports that a remote body exceeded the size limit.
+var errSpoolTooLarge = errors.New("remote file exceeds size limit")
+
+// spooledFile is a remote body written to a private temp directory.
+type spooledFile struct {
+	path string
+	size int64
+	// head holds the first bytes for http.DetectContentType.
+	head []byte
+	dir  string
+}
+
+func (s *spooledFile) cleanup() {
+	_ = os.RemoveAll(s.dir)
+}
+
+// spoolRemoteFile streams r to a temp file named name without buffering it in
+// memory, failing with errSpoolTooLarge past maxBytes. The temp file is what
+// gets uploaded to the PocketBase filesystem, which needs a seekable source.
+func spoolRemoteFile(r io.Reader, name string, maxBytes int64) (*spooledFile, error) {
+	dir, err := os.MkdirTemp("", "appos-space-fetch-*")
+	if err != nil {
+		return nil, err
+	}
+	spooled := &spooledFile{dir: dir, path: filepath.Join(dir, filepath.Base(name))}
+
+	f, err := os.Create(spooled.path)
+	if err != nil {
+		spooled.cleanup()
+		return nil, err
+	}
+	head := &prefixWriter{limit: 512}
+	n, copyErr := io.Copy(io.MultiWriter(f, head), io.LimitReader(r, maxBytes+1))
+	closeErr := f.Close()
+	switch {
+	case copyErr != nil:
+		err = copyErr
+	case closeErr != nil:
+		err = closeErr
+	case n > maxBytes:
+		err = errSpoolTooLarge
+	case n == 0:
+		err = errors.New("remote file is empty")
+	}
+	if err != nil {
+		spooled.cleanup()
+		return nil, err
+	}
+	spooled.size = n
+	spooled.head = head.buf
+	return spooled, nil
+}
+
+// prefixWriter keeps the first limit bytes written to it.
+type prefixWriter struct {
+	buf   []byte
+	limit int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.buf); room > 0 {
+		w.buf = append(w.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
 func fileError(msg string) map[string]any {
 	return map[string]any{"message": msg}
 }
@@ -1,15 +1,24 @@
 package routes
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
 	"github.com/websoft9/appos/backend/domain/space"
 )
 
@@ -144,3 +153,518 @@ func TestFileShareResolveRejectsExpiredShare(t *testing.T) {
 		t.Fatalf("expected expired share message, got %s", rec.Body.String())
 	}
 }
+
+func TestSpaceFetchRateLimited(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	routeRateLimiters.Clear()
+	defer routeRateLimiters.Clear()
+	if err := sysconfig.SetGroup(te.app, "ratelimit", "routes", map[string]any{
+		"spaceFetchPerMinute": 1,
+		"spaceFetchBurst":     2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := te.doSpace(t, http.MethodPost, "/api/space/fetch", `{}`, true)
+		if rec.Code == http.StatusTooManyRequests {
+			t.Fatalf("request %d: unexpected 429 within burst", i+1)
+		}
+	}
+
+	rec := te.doSpace(t, http.MethodPost, "/api/space/fetch", `{}`, true)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after burst, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter == "" || retryAfter == "0" {
+		t.Fatalf("expected positive Retry-After, got %q", retryAfter)
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data["reason_code"] != rateLimitReasonCode {
+		t.Fatalf("expected reason_code %s, got %v", rateLimitReasonCode, body.Data["reason_code"])
+	}
+
+	// Disabling the group lifts the limit immediately.
+	if err := sysconfig.SetGroup(te.app, "ratelimit", "routes", map[string]any{"spaceFetchPerMinute": 0}); err != nil {
+		t.Fatal(err)
+	}
+	rec = te.doSpace(t, http.MethodPost, "/api/space/fetch", `{}`, true)
+	if rec.Code == http.StatusTooManyRequests {
+		t.Fatal("expected no 429 when the group is disabled")
+	}
+}
+
+func TestSpoolRemoteFileStreamsToTempFileWithinLimit(t *testing.T) {
+	content := strings.Repeat("a", 2048)
+	spooled, err := spoolRemoteFile(strings.NewReader(content), "notes.txt", 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spooled.size != int64(len(content)) || len(spooled.head) != 512 {
+		t.Fatalf("expected size %d and 512-byte head, got %d / %d", len(content), spooled.size, len(spooled.head))
+	}
+	if filepath.Base(spooled.path) != "notes.txt" {
+		t.Fatalf("expected temp file to keep the target name, got %s", spooled.path)
+	}
+	written, err := os.ReadFile(spooled.path)
+	if err != nil || string(written) != content {
+		t.Fatalf("temp file content mismatch (err=%v)", err)
+	}
+	spooled.cleanup()
+	if _, err := os.Stat(spooled.path); !os.IsNotExist(err) {
+		t.Fatalf("expected cleanup to remove the temp file, stat err=%v", err)
+	}
+
+	if _, err := spoolRemoteFile(strings.NewReader(content), "notes.txt", 1024); !errors.Is(err, errSpoolTooLarge) {
+		t.Fatalf("expected errSpoolTooLarge, got %v", err)
+	}
+}
+
+func TestSpacePreviewUsesConfiguredMimeAllowlist(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := seedSpaceFileForRouteTest(t, te)
+	url := "/api/space/preview/" + rec.Id
+
+	// text/plain is not in the default allowlist.
+	res := te.doSpace(t, http.MethodGet, url, "", true)
+	if res.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 with default allowlist, got %d: %s", res.Code, res.Body.String())
+	}
+
+	quota, _ := sysconfig.GetGroup(te.app, space.SettingsModule, space.SettingsKey, nil)
+	quota["previewMimeTypes"] = []string{"text/plain"}
+	if err := sysconfig.SetGroup(te.app, space.SettingsModule, space.SettingsKey, quota); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file passes the allowlist and fails later because it has no content.
+	res = te.doSpace(t, http.MethodGet, url, "", true)
+	if res.Code == http.StatusUnsupportedMediaType {
+		t.Fatalf("expected configured allowlist to permit text/plain, got %d", res.Code)
+	}
+}
+
+func TestSpaceQuotaReportsPreviewMimeTypes(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	quota, _ := sysconfig.GetGroup(te.app, space.SettingsModule, space.SettingsKey, nil)
+	quota["previewMimeTypes"] = []string{"image/png", "text/plain"}
+	if err := sysconfig.SetGroup(te.app, space.SettingsModule, space.SettingsKey, quota); err != nil {
+		t.Fatal(err)
+	}
+
+	res := te.doSpace(t, http.MethodGet, "/api/space/quota", "", true)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var body struct {
+		PreviewMimeTypes []string `json:"preview_mime_types"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(body.PreviewMimeTypes, ",") != "image/png,text/plain" {
+		t.Fatalf("unexpected preview_mime_types: %v", body.PreviewMimeTypes)
+	}
+}
+
+func TestSpaceRenameValidatesAndUpdatesName(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := seedSpaceFileForRouteTest(t, te)
+	sibling := seedSpaceFileForRouteTest(t, te)
+	sibling.Set("name", "taken.txt")
+	if err := te.app.Save(sibling); err != nil {
+		t.Fatal(err)
+	}
+	url := "/api/space/rename/" + rec.Id
+
+	cases := []struct {
+		body string
+		code int
+	}{
+		{`{"name":"a/b.txt"}`, http.StatusBadRequest},
+		{`{"name":"taken.txt"}`, http.StatusConflict},
+		{`{"name":"renamed.txt"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		res := te.doSpace(t, http.MethodPost, url, tc.body, true)
+		if res.Code != tc.code {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.body, tc.code, res.Code, res.Body.String())
+		}
+	}
+
+	reloaded, err := te.app.FindRecordById(space.Collection, rec.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.GetString("name") != "renamed.txt" {
+		t.Fatalf("expected renamed.txt, got %q", reloaded.GetString("name"))
+	}
+
+	folder := seedSpaceFileForRouteTest(t, te)
+	folder.Set("name", "docs")
+	folder.Set("is_folder", true)
+	if err := te.app.Save(folder); err != nil {
+		t.Fatal(err)
+	}
+	res := te.doSpace(t, http.MethodPost, "/api/space/rename/"+folder.Id, `{"name":"deploy"}`, true)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("reserved root folder name: expected 400, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestSpaceTrashRestoreAndEmpty(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	folder := seedSpaceFileForRouteTest(t, te)
+	folder.Set("name", "docs")
+	folder.Set("is_folder", true)
+	if err := te.app.Save(folder); err != nil {
+		t.Fatal(err)
+	}
+	child := seedSpaceFileForRouteTest(t, te)
+	child.Set("parent", folder.Id)
+	content, err := filesystem.NewFileFromBytes([]byte("hello"), "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child.Set("content", content)
+	child.Set("share_token", "child-share")
+	if err := te.app.Save(child); err != nil {
+		t.Fatal(err)
+	}
+	storageKey := path.Join(child.Collection().Id, child.Id, child.GetString("content"))
+
+	res := te.doSpace(t, http.MethodPost, "/api/space/trash/"+child.Id, "", true)
+	if res.Code != http.StatusOK {
+		t.Fatalf("trash: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	trashed, _ := te.app.FindRecordById(space.Collection, child.Id)
+	if !trashed.GetBool("is_deleted") || trashed.GetString("parent") != "" || trashed.GetString("trashed_parent") != folder.Id || trashed.GetString("share_token") != "" {
+		t.Fatalf("unexpected trashed record %v", trashed.FieldsData())
+	}
+	if res := te.doSpace(t, http.MethodPost, "/api/space/trash/"+child.Id, "", true); res.Code != http.StatusBadRequest {
+		t.Fatalf("trash twice: expected 400, got %d", res.Code)
+	}
+
+	// A live item with the same name blocks the restore.
+	clash := seedSpaceFileForRouteTest(t, te)
+	clash.Set("parent", folder.Id)
+	if err := te.app.Save(clash); err != nil {
+		t.Fatal(err)
+	}
+	if res := te.doSpace(t, http.MethodPost, "/api/space/restore/"+child.Id, "", true); res.Code != http.StatusConflict {
+		t.Fatalf("name clash: expected 409, got %d: %s", res.Code, res.Body.String())
+	}
+	if err := te.app.Delete(clash); err != nil {
+		t.Fatal(err)
+	}
+	res = te.doSpace(t, http.MethodPost, "/api/space/restore/"+child.Id, "", true)
+	if res.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	restored, _ := te.app.FindRecordById(space.Collection, child.Id)
+	if restored.GetBool("is_deleted") || restored.GetString("parent") != folder.Id {
+		t.Fatalf("expected the file back in its folder, got %v", restored.FieldsData())
+	}
+
+	// Emptying the trash removes a trashed folder's contents and their files.
+	if res := te.doSpace(t, http.MethodPost, "/api/space/trash/"+folder.Id, "", true); res.Code != http.StatusOK {
+		t.Fatalf("trash folder: expected 200, got %d", res.Code)
+	}
+	kept := seedSpaceFileForRouteTest(t, te)
+	res = te.doSpace(t, http.MethodDelete, "/api/space/trash", "", true)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"removed":2`) {
+		t.Fatalf("empty trash: expected 2 removed, got %d: %s", res.Code, res.Body.String())
+	}
+	if _, err := te.app.FindRecordById(space.Collection, child.Id); err == nil {
+		t.Fatal("expected the folder contents to be purged")
+	}
+	if _, err := te.app.FindRecordById(space.Collection, kept.Id); err != nil {
+		t.Fatal("expected live items to survive emptying the trash")
+	}
+	fsys, err := te.app.NewFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+	if exists, _ := fsys.Exists(storageKey); exists {
+		t.Fatalf("expected %s to be removed from storage", storageKey)
+	}
+}
+
+func TestSpaceRestoreRejectsMissingParent(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	folder := seedSpaceFileForRouteTest(t, te)
+	folder.Set("name", "docs")
+	folder.Set("is_folder", true)
+	if err := te.app.Save(folder); err != nil {
+		t.Fatal(err)
+	}
+	child := seedSpaceFileForRouteTest(t, te)
+	child.Set("parent", folder.Id)
+	if err := te.app.Save(child); err != nil {
+		t.Fatal(err)
+	}
+	if res := te.doSpace(t, http.MethodPost, "/api/space/trash/"+child.Id, "", true); res.Code != http.StatusOK {
+		t.Fatalf("trash: expected 200, got %d", res.Code)
+	}
+	if err := te.app.Delete(folder); err != nil {
+		t.Fatal(err)
+	}
+	res := te.doSpace(t, http.MethodPost, "/api/space/restore/"+child.Id, "", true)
+	if res.Code != http.StatusConflict || !strings.Contains(res.Body.String(), "no longer exists") {
+		t.Fatalf("expected 409 for a purged parent, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestSpaceSetMimeTypeValidatesOverride(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := seedSpaceFileForRouteTest(t, te)
+	url := "/api/space/mime/" + rec.Id
+
+	res := te.doSpace(t, http.MethodPost, url, `{"mime_type":"image/svg+xml"}`, true)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("active type for .txt: expected 400, got %d: %s", res.Code, res.Body.String())
+	}
+	res = te.doSpace(t, http.MethodPost, url, `{"mime_type":"text/markdown"}`, true)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	reloaded, err := te.app.FindRecordById(space.Collection, rec.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.GetString("mime_type") != "text/markdown" {
+		t.Fatalf("expected text/markdown, got %q", reloaded.GetString("mime_type"))
+	}
+}
+
+func doSpaceUpload(t *testing.T, te *testEnv, files map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doSpaceUploadParts(t, te, files, false)
+}
+
+// doSpaceUploadParts posts files (keyed by name, or by relative path when
+// withPaths is set) to the batch upload route.
+func doSpaceUploadParts(t *testing.T, te *testEnv, files map[string]string, withPaths bool) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerSpaceRoutes(&core.ServeEvent{Router: r})
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		part, err := writer.CreateFormFile("files", path.Base(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write([]byte(files[name]))
+		if withPaths {
+			if err := writer.WriteField("paths", name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/space/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", te.token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSpaceUploadBatchIsAllOrNothing(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	countFiles := func() int64 {
+		n, err := te.app.CountRecords(space.Collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	rec := doSpaceUpload(t, te, map[string]string{"a.txt": "alpha", "noext": "beta"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for batch with an invalid file, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var rejected struct {
+		Results []map[string]any `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rejected); err != nil {
+		t.Fatal(err)
+	}
+	if len(rejected.Results) != 2 || rejected.Results[0]["error"] != nil || rejected.Results[1]["error"] == nil {
+		t.Fatalf("expected only the second file to be flagged, got %+v", rejected.Results)
+	}
+	if n := countFiles(); n != 0 {
+		t.Fatalf("expected no records after rejected batch, got %d", n)
+	}
+
+	rec = doSpaceUpload(t, te, map[string]string{"a.txt": "alpha", "b.md": "# beta"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var accepted struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &accepted); err != nil {
+		t.Fatal(err)
+	}
+	if len(accepted.Items) != 2 || countFiles() != 2 {
+		t.Fatalf("expected 2 stored files, got items=%d records=%d", len(accepted.Items), countFiles())
+	}
+}
+
+func TestSpaceUploadReportsContentHashDuplicates(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+	space.RegisterHooks(te.app)
+
+	type uploadBody struct {
+		Items []struct {
+			ID          string         `json:"id"`
+			ContentHash string         `json:"content_hash"`
+			DuplicateOf map[string]any `json:"duplicate_of"`
+		} `json:"items"`
+	}
+	upload := func(files map[string]string) uploadBody {
+		t.Helper()
+		rec := doSpaceUpload(t, te, files)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body uploadBody
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	first := upload(map[string]string{"a.txt": "same content"})
+	// sha256("same content")
+	const wantHash = "a636bd7cd42060a4d07fa1bfbcc010eb7794c2ba721e1e3e4c20335a15b66eaf"
+	if got := first.Items[0].ContentHash; got != wantHash {
+		t.Fatalf("expected content_hash %s, got %q", wantHash, got)
+	}
+	if first.Items[0].DuplicateOf != nil {
+		t.Fatalf("first upload should not be a duplicate: %+v", first.Items[0].DuplicateOf)
+	}
+	record, err := te.app.FindRecordById(space.Collection, first.Items[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.GetString("content_hash") != first.Items[0].ContentHash {
+		t.Fatalf("stored hash %q differs from response %q", record.GetString("content_hash"), first.Items[0].ContentHash)
+	}
+
+	second := upload(map[string]string{"copy.txt": "same content", "other.txt": "different"})
+	for _, item := range second.Items {
+		isCopy := item.ContentHash == first.Items[0].ContentHash
+		if isCopy && (item.DuplicateOf == nil || item.DuplicateOf["id"] != first.Items[0].ID) {
+			t.Fatalf("expected copy to reference %s, got %+v", first.Items[0].ID, item.DuplicateOf)
+		}
+		if !isCopy && item.DuplicateOf != nil {
+			t.Fatalf("unexpected duplicate_of for distinct content: %+v", item.DuplicateOf)
+		}
+	}
+}
+
+func TestSpaceUploadFolderPreservesStructure(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	rec := doSpaceUploadParts(t, te, map[string]string{"deploy/a.txt": "alpha"}, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for reserved root folder, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if n, _ := te.app.CountRecords(space.Collection); n != 0 {
+		t.Fatalf("expected no records after rejected folder upload, got %d", n)
+	}
+
+	rec = doSpaceUploadParts(t, te, map[string]string{
+		"docs/a.txt":       "alpha",
+		"docs/notes/b.md":  "# beta",
+		"docs/notes/c.txt": "gamma",
+	}, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Items   []map[string]any `json:"items"`
+		Folders []struct {
+			ID       string `json:"id"`
+			Path     string `json:"path"`
+			Created  bool   `json:"created"`
+			Children []struct {
+				ID   string `json:"id"`
+				Path string `json:"path"`
+			} `json:"children"`
+		} `json:"folders"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Folders) != 1 || body.Folders[0].Path != "docs" || !body.Folders[0].Created ||
+		len(body.Folders[0].Children) != 1 || body.Folders[0].Children[0].Path != "docs/notes" {
+		t.Fatalf("unexpected folder tree: %s", rec.Body.String())
+	}
+	docsID, notesID := body.Folders[0].ID, body.Folders[0].Children[0].ID
+	for _, item := range body.Items {
+		want := notesID
+		if item["name"] == "a.txt" {
+			want = docsID
+		}
+		if item["parent"] != want {
+			t.Fatalf("file %v linked to parent %v, want %s", item["name"], item["parent"], want)
+		}
+	}
+
+	// A second upload into the same folder reuses it instead of duplicating.
+	rec = doSpaceUploadParts(t, te, map[string]string{"docs/d.txt": "delta"}, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Folders) != 1 || body.Folders[0].ID != docsID || body.Folders[0].Created {
+		t.Fatalf("expected existing folder to be reused, got %s", rec.Body.String())
+	}
+}
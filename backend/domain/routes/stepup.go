@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/stepup"
+)
+
+// requireStepUp returns middleware that demands a fresh TOTP code for action
+// when the security/stepup settings list it. Bind it after authentication.
+func requireStepUp(action string) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "requireStepUp:" + action,
+		Func: func(e *core.RequestEvent) error {
+			if challenge := stepup.Check(e, action); challenge != nil {
+				return challenge.Respond(e)
+			}
+			return e.Next()
+		},
+	}
+}
+
+// registerStepUpRoutes registers the caller's TOTP enrollment routes.
+//
+// Endpoints:
+//
+//	GET    /api/ext/stepup              — enrollment status and protected actions
+//	POST   /api/ext/stepup/totp         — start (or replace) an enrollment
+//	POST   /api/ext/stepup/totp/confirm — activate a pending enrollment
+//	DELETE /api/ext/stepup/totp         — remove the enrollment
+func registerStepUpRoutes(g *router.RouterGroup[*core.RequestEvent]) {
+	s := g.Group("/stepup")
+	s.GET("", handleStepUpStatus)
+	s.POST("/totp", handleStepUpEnroll)
+	s.POST("/totp/confirm", handleStepUpConfirm)
+	s.DELETE("/totp", handleStepUpRemove)
+}
+
+// handleStepUpStatus returns the caller's enrollment and the action list.
+//
+// @Summary Get step-up status
+// @Description Returns whether the caller has a confirmed TOTP enrollment and, for every step-up capable action, whether it currently requires a code in the X-AppOS-TOTP header.
+// @Tags Step-up
+// @Security BearerAuth
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/stepup [get]
+func handleStepUpStatus(e *core.RequestEvent) error {
+	status, err := stepup.GetStatus(e.App, e.Auth)
+	if err != nil {
+		return e.InternalServerError("failed to read step-up enrollment", err)
+	}
+	required := stepup.RequiredActions(e.App)
+	actions := make([]map[string]any, 0, len(stepup.Actions))
+	for _, a := range stepup.Actions {
+		actions = append(actions, map[string]any{
+			"id":       a.ID,
+			"title":    a.Title,
+			"required": slices.Contains(required, a.ID),
+		})
+	}
+	return e.JSON(http.StatusOK, map[string]any{
+		"enrolled":  status.Enrolled,
+		"confirmed": status.Confirmed,
+		"header":    stepup.Header,
+		"actions":   actions,
+	})
+}
+
+// handleStepUpEnroll generates a new TOTP secret for the caller.
+//
+// @Summary Enroll TOTP authenticator
+// @Description Generates a TOTP secret and returns it with an otpauth:// URI for authenticator apps. The enrollment is pending until confirmed. Replacing a confirmed enrollment requires a valid current code in the X-AppOS-TOTP header.
+// @Tags Step-up
+// @Security BearerAuth
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/stepup/totp [post]
+func handleStepUpEnroll(e *core.RequestEvent) error {
+	if challenge := verifyCurrentEnrollment(e, "stepup.enroll"); challenge != nil {
+		return challenge.Respond(e)
+	}
+	secret, uri, err := stepup.Enroll(e.App, e.Auth)
+	if err != nil {
+		return e.InternalServerError("failed to enroll TOTP authenticator", err)
+	}
+	writeStepUpAudit(e, "stepup.enroll")
+	return e.JSON(http.StatusOK, map[string]any{
+		"secret":    secret,
+		"uri":       uri,
+		"confirmed": false,
+	})
+}
+
+// handleStepUpConfirm activates the caller's pending enrollment.
+//
+// @Summary Confirm TOTP enrollment
+// @Description Activates the pending enrollment with a code from the authenticator app.
+// @Tags Step-up
+// @Security BearerAuth
+// @Param body body object true "code: current 6-digit TOTP code"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/stepup/totp/confirm [post]
+func handleStepUpConfirm(e *core.RequestEvent) error {
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("invalid request body", err)
+	}
+	err := stepup.Confirm(e.App, e.Auth, strings.TrimSpace(body.Code), time.Now())
+	switch {
+	case errors.Is(err, stepup.ErrNotEnrolled):
+		return e.BadRequestError("no pending TOTP enrollment", nil)
+	case errors.Is(err, stepup.ErrInvalidCode):
+		return e.BadRequestError("invalid or already used TOTP code", nil)
+	case err != nil:
+		return e.InternalServerError("failed to confirm TOTP enrollment", err)
+	}
+	writeStepUpAudit(e, "stepup.confirm")
+	return e.JSON(http.StatusOK, map[string]any{"confirmed": true})
+}
+
+// handleStepUpRemove deletes the caller's enrollment.
+//
+// @Summary Remove TOTP enrollment
+// @Description Removes the caller's TOTP enrollment. A confirmed enrollment can only be removed with a valid current code in the X-AppOS-TOTP header.
+// @Tags Step-up
+// @Security BearerAuth
+// @Success 204 "No Content"
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/ext/stepup/totp [delete]
+func handleStepUpRemove(e *core.RequestEvent) error {
+	if challenge := verifyCurrentEnrollment(e, "stepup.remove"); challenge != nil {
+		return challenge.Respond(e)
+	}
+	if err := stepup.Remove(e.App, e.Auth); err != nil {
+		return e.InternalServerError("failed to remove TOTP enrollment", err)
+	}
+	writeStepUpAudit(e, "stepup.remove")
+	return e.NoContent(http.StatusNoContent)
+}
+
+// verifyCurrentEnrollment requires a valid code from a confirmed enrollment
+// before it is replaced or removed, so a stolen session cannot swap the
+// authenticator. Pending or missing enrollments pass.
+func verifyCurrentEnrollment(e *core.RequestEvent, action string) *stepup.ChallengeError {
+	status, err := stepup.GetStatus(e.App, e.Auth)
+	if err != nil || !status.Confirmed {
+		return nil
+	}
+	code := strings.TrimSpace(e.Request.Header.Get(stepup.Header))
+	if code == "" {
+		return &stepup.ChallengeError{Action: action, ReasonCode: stepup.ReasonRequired, Message: "changing a confirmed enrollment requires a TOTP code in the " + stepup.Header + " header"}
+	}
+	if err := stepup.Verify(e.App, e.Auth, code, time.Now()); err != nil {
+		return &stepup.ChallengeError{Action: action, ReasonCode: stepup.ReasonInvalid, Message: "invalid or already used TOTP code"}
+	}
+	return nil
+}
+
+func writeStepUpAudit(e *core.RequestEvent, action string) {
+	userID, userEmail, ip, ua := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: action, ResourceType: "stepup", ResourceID: e.Auth.Id,
+		IP: ip, UserAgent: ua,
+		Status: audit.StatusSuccess,
+	})
+}
@@ -0,0 +1,196 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+	"github.com/websoft9/appos/backend/domain/stepup"
+)
+
+func TestStepUpGatesDockerExec(t *testing.T) {
+	te := newSecretsTestEnv(t)
+	defer te.cleanup()
+	useRecordingDocker(t, "ok")
+
+	auth := map[string]string{"Authorization": te.token}
+	withCode := func(code string) map[string]string {
+		return map[string]string{"Authorization": te.token, stepup.Header: code}
+	}
+
+	// Not required yet: exec runs without a code.
+	res := te.doRegisteredRoute(t, http.MethodPost, "/api/ext/docker/exec", `{"command":"info"}`, auth)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 before step-up is enabled, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if err := sysconfig.SetGroup(te.app, stepup.SettingsModule, stepup.SettingsKey, map[string]any{"actions": []string{stepup.ActionDockerExec}}); err != nil {
+		t.Fatal(err)
+	}
+	sysconfig.InvalidateCache(te.app)
+
+	res = te.doRegisteredRoute(t, http.MethodPost, "/api/ext/docker/exec", `{"command":"info"}`, withCode("123456"))
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), stepup.ReasonNotEnrolled) {
+		t.Fatalf("expected 403 %s, got %d: %s", stepup.ReasonNotEnrolled, res.Code, res.Body.String())
+	}
+
+	res = te.doRegisteredRoute(t, http.MethodPost, "/api/ext/stepup/totp", "", auth)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected enroll 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var enrolled struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &enrolled); err != nil {
+		t.Fatal(err)
+	}
+	if enrolled.Secret == "" || !strings.HasPrefix(enrolled.URI, "otpauth://totp/") {
+		t.Fatalf("unexpected enrollment: %s", res.Body.String())
+	}
+
+	now := time.Now()
+	previous, _ := stepup.Code(enrolled.Secret, now.Add(-30*time.Second))
+	current, _ := stepup.Code(enrolled.Secret, now)
+	res = te.doRegisteredRoute(t, http.MethodPost, "/api/ext/stepup/totp/confirm", `{"code":"`+previous+`"}`, auth)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected confirm 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = te.doRegisteredRoute(t, http.MethodPost, "/api/ext/docker/exec", `{"command":"info"}`, auth)
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), stepup.ReasonRequired) {
+		t.Fatalf("expected 403 %s without a code, got %d: %s", stepup.ReasonRequired, res.Code, res.Body.String())
+	}
+	res = te.doRegisteredRoute(t, http.MethodPost, "/api/ext/docker/exec", `{"command":"info"}`, withCode(current))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 with a valid code, got %d: %s", res.Code, res.Body.String())
+	}
+	res = te.doRegisteredRoute(t, http.MethodPost, "/api/ext/docker/exec", `{"command":"info"}`, withCode(current))
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), stepup.ReasonInvalid) {
+		t.Fatalf("expected replayed code to be rejected, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = te.doRegisteredRoute(t, http.MethodGet, "/api/ext/stepup", "", auth)
+	if !strings.Contains(res.Body.String(), `"confirmed":true`) || !strings.Contains(res.Body.String(), `"required":true`) {
+		t.Fatalf("unexpected status: %s", res.Body.String())
+	}
+
+	// A confirmed enrollment cannot be removed without a code.
+	res = te.doRegisteredRoute(t, http.MethodDelete, "/api/ext/stepup/totp", "", auth)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 removing without a code, got %d: %s", res.Code, res.Body.String())
+	}
+
+	entries := auditEntriesByAction(t, te, "stepup.verify")
+	if len(entries) != 4 {
+		t.Fatalf("expected four step-up audit entries, got %d", len(entries))
+	}
+	var failed int
+	for _, entry := range entries {
+		if entry.GetString("status") == "failed" {
+			failed++
+		}
+	}
+	if failed != 3 {
+		t.Fatalf("expected three failed challenges, got %d", failed)
+	}
+}
+
+func TestStepUpGatesTerminalBroadcast(t *testing.T) {
+	te := newSecretsTestEnv(t)
+	defer te.cleanup()
+
+	if err := sysconfig.SetGroup(te.app, stepup.SettingsModule, stepup.SettingsKey, map[string]any{"actions": []string{stepup.ActionTerminalBroadcast}}); err != nil {
+		t.Fatal(err)
+	}
+	sysconfig.InvalidateCache(te.app)
+
+	res := te.doRegisteredRoute(t, http.MethodPost, "/api/terminal/broadcast", `{"command":"uptime","server_ids":["x"]}`, map[string]string{"Authorization": te.token})
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), stepup.ReasonRequired) {
+		t.Fatalf("expected 403 %s, got %d: %s", stepup.ReasonRequired, res.Code, res.Body.String())
+	}
+}
+
+func TestValidateSecurityStepUpRejectsUnknownActions(t *testing.T) {
+	value := map[string]any{"actions": []any{stepup.ActionServerPower, " " + stepup.ActionServerPower, stepup.ActionDockerExec}}
+	if errs := validateSecurityStepUp(value); errs != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got := value["actions"].([]string); len(got) != 2 {
+		t.Fatalf("expected de-duplicated actions, got %v", got)
+	}
+	if errs := validateSecurityStepUp(map[string]any{"actions": []any{"system.rm-rf"}}); errs["actions"] == "" {
+		t.Fatal("expected unknown action to be rejected")
+	}
+}
+
+func TestStepUpGuardsItsOwnSettingsAndEnrollments(t *testing.T) {
+	te := newSecretsTestEnv(t)
+	defer te.cleanup()
+	stepup.RegisterHooks(te.app)
+
+	auth := map[string]string{"Authorization": te.token}
+	withCode := func(code string) map[string]string {
+		return map[string]string{"Authorization": te.token, stepup.Header: code}
+	}
+
+	res := te.doRegisteredRoute(t, http.MethodPost, "/api/ext/stepup/totp", "", auth)
+	var enrolled struct {
+		Secret string `json:"secret"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &enrolled); err != nil || enrolled.Secret == "" {
+		t.Fatalf("expected an enrollment, got %d: %s", res.Code, res.Body.String())
+	}
+	now := time.Now()
+	previous, _ := stepup.Code(enrolled.Secret, now.Add(-30*time.Second))
+	current, _ := stepup.Code(enrolled.Secret, now)
+	if res := te.doRegisteredRoute(t, http.MethodPost, "/api/ext/stepup/totp/confirm", `{"code":"`+previous+`"}`, auth); res.Code != http.StatusOK {
+		t.Fatalf("expected confirm 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if err := sysconfig.SetGroup(te.app, stepup.SettingsModule, stepup.SettingsKey, map[string]any{"actions": []string{stepup.ActionDockerExec}}); err != nil {
+		t.Fatal(err)
+	}
+	sysconfig.InvalidateCache(te.app)
+
+	// Adding actions needs no code; dropping one does, globally or per profile.
+	both := `{"actions":["` + stepup.ActionDockerExec + `","` + stepup.ActionServerPower + `"]}`
+	if res := te.doRegisteredRoute(t, http.MethodPatch, "/api/settings/entries/security-stepup", both, auth); res.Code != http.StatusOK {
+		t.Fatalf("expected adding an action to pass, got %d: %s", res.Code, res.Body.String())
+	}
+	for _, url := range []string{"/api/settings/entries/security-stepup", "/api/settings/entries/security-stepup?profile=prod"} {
+		res := te.doRegisteredRoute(t, http.MethodPatch, url, `{"actions":[]}`, auth)
+		if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), stepup.ReasonRequired) {
+			t.Fatalf("%s: expected 403 %s, got %d: %s", url, stepup.ReasonRequired, res.Code, res.Body.String())
+		}
+	}
+	if !stepup.Required(te.app, stepup.ActionDockerExec) {
+		t.Fatal("expected the rejected patches to leave step-up enabled")
+	}
+	if res := te.doRegisteredRoute(t, http.MethodPatch, "/api/settings/entries/security-stepup", `{"actions":[]}`, withCode(current)); res.Code != http.StatusOK {
+		t.Fatalf("expected disabling with a code to pass, got %d: %s", res.Code, res.Body.String())
+	}
+
+	// The record API cannot bypass the settings route or the enrollment routes.
+	enrollment, err := te.app.FindFirstRecordByFilter(stepup.Collection, "confirmed = true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = te.doRegisteredRoute(t, http.MethodDelete, "/api/collections/"+stepup.Collection+"/records/"+enrollment.Id, "", auth)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 deleting an enrollment through the record API, got %d: %s", res.Code, res.Body.String())
+	}
+	row, err := te.app.FindFirstRecordByFilter("custom_settings", "module = 'security' && key = 'stepup'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = te.doRegisteredRoute(t, http.MethodDelete, "/api/collections/custom_settings/records/"+row.Id, "", auth)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 deleting the step-up settings row through the record API, got %d: %s", res.Code, res.Body.String())
+	}
+	if _, err := te.app.FindRecordById(stepup.Collection, enrollment.Id); err != nil {
+		t.Fatal("expected the enrollment to survive")
+	}
+}
@@ -6,20 +6,74 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+	tunnelpb "github.com/websoft9/appos/backend/infra/tunnelpb"
 )
 
 // registerSystemRoutes registers system-level routes.
 //
 // Endpoints:
 //
-//	GET  /api/ext/system/metrics   — CPU, memory, disk usage
-//	GET  /api/ext/system/files     — file browser listing
+//	GET    /api/ext/system/metrics           — CPU, memory, disk usage
+//	GET    /api/ext/system/files             — file browser listing
+//	POST   /api/ext/system/reload            — re-read settings into hot components
+//	GET    /api/ext/system/connections       — active terminal sessions and streams
+//	DELETE /api/ext/system/connections/{id}  — terminate one connection
 func registerSystemRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	sys := g.Group("/system")
 	sys.Bind(apis.RequireSuperuserAuth())
 
 	sys.GET("/metrics", handleSystemMetrics)
 	sys.GET("/files", handleFileBrowser)
+	sys.POST("/reload", handleSystemReload)
+	sys.GET("/connections", handleSystemConnections)
+	sys.DELETE("/connections/{id}", handleSystemConnectionTerminate)
+}
+
+// systemRestartOnly lists what a reload cannot apply: the process environment
+// (APPOS_* variables such as APPOS_SETTINGS_PROFILE or APPOS_ENCRYPTION_KEY)
+// and the tunnel SSH listener, which binds once at startup.
+var systemRestartOnly = []string{"environment", "tunnel_listener"}
+
+// handleSystemReload drops the settings cache so every settings consumer
+// (compression, body and rate limits, secrets policy, ...) re-reads its group
+// on next use, and applies tunnel/port_range to the running tunnel pool.
+//
+// @Summary Reload settings
+// @Description Re-reads settings without restarting AppOS: clears the settings cache and applies the tunnel port range to the running tunnel server. reloaded lists each component with what changed; restart_required lists what only a process restart applies. Superuser only.
+// @Tags Runtime Operations
+// @Security BearerAuth
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Router /api/ext/system/reload [post]
+func handleSystemReload(e *core.RequestEvent) error {
+	sysconfig.InvalidateCache(e.App)
+	reloaded := []map[string]any{{"component": "settings_cache"}}
+
+	if tunnelPortPool != nil {
+		previous := tunnelPortPool.Range()
+		current := tunnelpb.LoadPortRange(e.App)
+		item := map[string]any{"component": "tunnel_port_range", "range": current.ToMap()}
+		if changed := tunnelPortPool.SetRange(current); changed {
+			item["previous"] = previous.ToMap()
+		}
+		item["changed"] = item["previous"] != nil
+		reloaded = append(reloaded, item)
+	}
+
+	userID, userEmail, ip, ua := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID: userID, UserEmail: userEmail,
+		Action: "system.reload", ResourceType: "system",
+		IP: ip, UserAgent: ua,
+		Status: audit.StatusSuccess,
+		Detail: map[string]any{"reloaded": reloaded},
+	})
+	return e.JSON(http.StatusOK, map[string]any{
+		"reloaded":         reloaded,
+		"restart_required": systemRestartOnly,
+	})
 }
 
 // handleSystemMetrics returns host CPU, memory, and disk usage metrics.
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/terminal"
+)
+
+// handleSystemConnections lists active long-lived connections: terminal
+// sessions (SSH, local, docker exec) and SSE streams.
+//
+// @Summary List active connections
+// @Description Returns every open terminal session and streaming connection with its type, user, target, start time, last activity and byte counters, oldest first. Superuser only.
+// @Tags Runtime Operations
+// @Security BearerAuth
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Router /api/ext/system/connections [get]
+func handleSystemConnections(e *core.RequestEvent) error {
+	items := terminal.Connections()
+	return e.JSON(http.StatusOK, map[string]any{"items": items, "total": len(items)})
+}
+
+// handleSystemConnectionTerminate closes one active connection.
+//
+// @Summary Terminate connection
+// @Description Closes the terminal session or stream with the given ID and writes an audit entry. Superuser only.
+// @Tags Runtime Operations
+// @Security BearerAuth
+// @Param id path string true "connection ID"
+// @Success 204
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/ext/system/connections/{id} [delete]
+func handleSystemConnectionTerminate(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	var target *terminal.Connection
+	for _, c := range terminal.Connections() {
+		if c.ID == id {
+			target = &c
+			break
+		}
+	}
+	if target == nil || !terminal.Terminate(id) {
+		return e.JSON(http.StatusNotFound, map[string]any{"message": "connection not found"})
+	}
+
+	userID, userEmail, ip, userAgent := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Action:       "system.connection.terminate",
+		ResourceType: "connection",
+		ResourceID:   id,
+		ResourceName: target.Type + ":" + target.Target,
+		Status:       audit.StatusSuccess,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Detail: map[string]any{
+			"type":       target.Type,
+			"target":     target.Target,
+			"owner_id":   target.UserID,
+			"started_at": target.StartedAt.Format(time.RFC3339),
+		},
+	})
+	return e.NoContent(http.StatusNoContent)
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func()
+
+func (f closerFunc) Close() error {
+	f()
+	return nil
+}
+
+// trackStream registers an SSE stream in the connection registry. The
+// returned context ends when the client goes away or the stream is
+// terminated; terminating also closes onTerminate, if set, to abort work
+// that does not watch the context. untrack must run when the handler returns.
+func trackStream(e *core.RequestEvent, meta terminal.ConnectionMeta, onTerminate io.Closer) (ctx context.Context, untrack func()) {
+	ctx, cancel := context.WithCancel(e.Request.Context())
+	id := uuid.NewString()
+	meta.UserID, _, _, _ = clientInfo(e)
+	terminal.Track(id, closerFunc(func() {
+		cancel()
+		if onTerminate != nil {
+			_ = onTerminate.Close()
+		}
+	}), meta)
+	return ctx, func() {
+		terminal.Unregister(id)
+		cancel()
+	}
+}
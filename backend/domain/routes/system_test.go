@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+	"github.com/websoft9/appos/backend/domain/terminal"
+	tunnelcore "github.com/websoft9/appos/backend/infra/tunnelcore"
+	tunnelpb "github.com/websoft9/appos/backend/infra/tunnelpb"
+)
+
+func (te *testEnv) doSystem(t *testing.T, method, url string, authenticated bool) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/ext")
+	g.Bind(apis.RequireAuth())
+	registerSystemRoutes(g)
+
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, url, strings.NewReader(""))
+	if authenticated {
+		req.Header.Set("Authorization", te.token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSystemReloadAppliesTunnelPortRange(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	prevPool := tunnelPortPool
+	tunnelPortPool = tunnelcore.NewPortPool(40000, 49999)
+	t.Cleanup(func() { tunnelPortPool = prevPool })
+
+	if err := sysconfig.SetGroup(te.app, tunnelpb.SettingsModule, tunnelpb.PortRangeKey, map[string]any{"start": 40000, "end": 49999}); err != nil {
+		t.Fatal(err)
+	}
+	// Prime the cache, then change the row behind its back.
+	_ = tunnelpb.LoadPortRange(te.app)
+	if _, err := te.app.DB().NewQuery("UPDATE custom_settings SET value = {:value} WHERE module = 'tunnel' AND `key` = 'port_range' AND profile = ''").
+		Bind(dbx.Params{"value": `{"start":41000,"end":41999}`}).Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := te.doSystem(t, http.MethodPost, "/api/ext/system/reload", false); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d", res.Code)
+	}
+
+	res := te.doSystem(t, http.MethodPost, "/api/ext/system/reload", true)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var body struct {
+		Reloaded        []map[string]any `json:"reloaded"`
+		RestartRequired []string         `json:"restart_required"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Reloaded) != 2 || body.Reloaded[1]["component"] != "tunnel_port_range" || body.Reloaded[1]["changed"] != true {
+		t.Fatalf("unexpected reload result: %s", res.Body.String())
+	}
+	if len(body.RestartRequired) == 0 {
+		t.Fatalf("expected restart-only components to be listed: %s", res.Body.String())
+	}
+	if r := tunnelPortPool.Range(); r.Start != 41000 || r.End != 41999 {
+		t.Fatalf("expected pool range 41000-41999, got %d-%d", r.Start, r.End)
+	}
+
+	res = te.doSystem(t, http.MethodPost, "/api/ext/system/reload", true)
+	if !strings.Contains(res.Body.String(), `"changed":false`) {
+		t.Fatalf("expected unchanged range on second reload: %s", res.Body.String())
+	}
+	if entries := auditEntriesByAction(t, te, "system.reload"); len(entries) != 2 {
+		t.Fatalf("expected two audit entries, got %d", len(entries))
+	}
+}
+
+func TestSystemConnectionsListAndTerminate(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	closed := false
+	terminal.Track("test-system-conn", closerFunc(func() { closed = true }), terminal.ConnectionMeta{
+		Type:   terminal.ConnectionFileTail,
+		UserID: "u1",
+		Target: "logs/app.log",
+	})
+	defer terminal.Unregister("test-system-conn")
+
+	res := te.doSystem(t, http.MethodGet, "/api/ext/system/connections", true)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var body struct {
+		Items []terminal.Connection `json:"items"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, c := range body.Items {
+		if c.ID == "test-system-conn" && c.Type == terminal.ConnectionFileTail && c.Target == "logs/app.log" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("tracked connection missing from %s", res.Body.String())
+	}
+
+	res = te.doSystem(t, http.MethodDelete, "/api/ext/system/connections/test-system-conn", true)
+	if res.Code != http.StatusNoContent || !closed {
+		t.Fatalf("expected 204 and closed stream, got %d (closed=%v)", res.Code, closed)
+	}
+	if entries := auditEntriesByAction(t, te, "system.connection.terminate"); len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(entries))
+	}
+	res = te.doSystem(t, http.MethodDelete, "/api/ext/system/connections/test-system-conn", true)
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for terminated connection, got %d", res.Code)
+	}
+}
@@ -0,0 +1,202 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/stepup"
+	"github.com/websoft9/appos/backend/domain/terminal"
+)
+
+const (
+	// broadcastMaxServers caps the servers one broadcast may target.
+	broadcastMaxServers = 100
+	// broadcastConcurrency bounds the servers a broadcast runs on at once.
+	broadcastConcurrency = 10
+	// broadcastDefaultTimeout and broadcastMaxTimeout bound the per-server
+	// command timeout.
+	broadcastDefaultTimeout = 30 * time.Second
+	broadcastMaxTimeout     = 10 * time.Minute
+	// broadcastMaxOutput caps the output returned per server.
+	broadcastMaxOutput = 64 << 10
+)
+
+func registerServerBroadcastRoutes(g *router.RouterGroup[*core.RequestEvent]) {
+	g.POST("/broadcast", handleTerminalBroadcast).Bind(routeRateLimit(rateLimitServerOps), requireStepUp(stepup.ActionTerminalBroadcast))
+}
+
+// broadcastResult is the outcome of a broadcast command on one server.
+// ExitCode is nil when the command did not run to completion.
+type broadcastResult struct {
+	ServerID   string `json:"server_id"`
+	Status     string `json:"status"`
+	ExitCode   *int   `json:"exit_code"`
+	Output     string `json:"output"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// handleTerminalBroadcast runs one command on many servers.
+//
+// @Summary Broadcast command
+// @Description Runs a shell command over SSH on each listed server, at most 10 at a time, and returns per-server status, exit code, output (capped at 64 KB) and error. A server that fails does not stop the others. timeout is per server in seconds (default 30, max 600). Servers already at the connect/terminal session limit are reported as failed. The run is audited once as a fleet operation. Superuser only.
+// @Tags Terminal SSH
+// @Security BearerAuth
+// @Param body body object true "command, server_ids (max 100), optional timeout (seconds) and as_user"
+// @Success 200 {object} map[string]any "results, succeeded, failed"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Router /api/terminal/broadcast [post]
+func handleTerminalBroadcast(e *core.RequestEvent) error {
+	var body struct {
+		Command   string   `json:"command"`
+		ServerIDs []string `json:"server_ids"`
+		Timeout   int      `json:"timeout"`
+		AsUser    string   `json:"as_user"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("invalid request body", err)
+	}
+	if strings.TrimSpace(body.Command) == "" {
+		return e.BadRequestError("command is required", nil)
+	}
+	serverIDs := make([]string, 0, len(body.ServerIDs))
+	seen := map[string]bool{}
+	for _, id := range body.ServerIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			serverIDs = append(serverIDs, id)
+		}
+	}
+	if len(serverIDs) == 0 {
+		return e.BadRequestError("server_ids is required", nil)
+	}
+	if len(serverIDs) > broadcastMaxServers {
+		return e.BadRequestError("too many servers (max 100)", nil)
+	}
+	timeout := broadcastDefaultTimeout
+	if body.Timeout < 0 || time.Duration(body.Timeout)*time.Second > broadcastMaxTimeout {
+		return e.BadRequestError("timeout must be between 0 and 600 seconds", nil)
+	}
+	if body.Timeout > 0 {
+		timeout = time.Duration(body.Timeout) * time.Second
+	}
+	asUser, err := parseRunAsUser(e, body.AsUser)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+
+	results := make([]broadcastResult, len(serverIDs))
+	slots := make(chan struct{}, broadcastConcurrency)
+	var wg sync.WaitGroup
+	for i, serverID := range serverIDs {
+		wg.Add(1)
+		go func(idx int, serverID string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[idx] = runBroadcastCommand(e, serverID, body.Command, asUser, timeout)
+		}(i, serverID)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	auditResults := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		if r.Status == "succeeded" {
+			succeeded++
+		}
+		entry := map[string]any{"server_id": r.ServerID, "status": r.Status, "exit_code": r.ExitCode}
+		if r.Error != "" {
+			entry["error"] = r.Error
+		}
+		auditResults = append(auditResults, entry)
+	}
+	failed := len(results) - succeeded
+
+	status := audit.StatusSuccess
+	if failed > 0 {
+		status = audit.StatusFailed
+	}
+	detail := map[string]any{
+		"command":    body.Command,
+		"server_ids": serverIDs,
+		"succeeded":  succeeded,
+		"failed":     failed,
+		"results":    auditResults,
+	}
+	if asUser != "" {
+		detail["as_user"] = asUser
+	}
+	userID, userEmail, ip, userAgent := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Action:       "terminal.broadcast",
+		ResourceType: "fleet",
+		Status:       status,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Detail:       detail,
+	})
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    failed,
+	})
+}
+
+// runBroadcastCommand runs command on one server, holding one of its session
+// slots for the duration.
+func runBroadcastCommand(e *core.RequestEvent, serverID, command, asUser string, timeout time.Duration) (result broadcastResult) {
+	result = broadcastResult{ServerID: serverID, Status: "failed"}
+	started := time.Now()
+	defer func() { result.DurationMS = time.Since(started).Milliseconds() }()
+
+	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	cfg.RunAs = asUser
+	command, err = cfg.AsUser(command)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	release, err := acquireServerSession(e.App, serverID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer release()
+
+	output, runErr := executeSSHCommand(e.Request.Context(), cfg, command, timeout)
+	result.Output = truncateBroadcastOutput(output)
+	if code, ok := terminal.ExitCode(runErr); ok {
+		result.ExitCode = &code
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+		return result
+	}
+	result.Status = "succeeded"
+	return result
+}
+
+func truncateBroadcastOutput(output string) string {
+	if len(output) <= broadcastMaxOutput {
+		return output
+	}
+	return output[:broadcastMaxOutput] + "…(truncated)"
+}
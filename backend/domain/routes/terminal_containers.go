@@ -22,12 +22,17 @@ func registerServerContainerRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 
 // handleDockerExecTerminal upgrades to a WebSocket PTY for docker exec on a container.
 //
+// A session idle for connect/terminal idleTimeoutSeconds or open longer than
+// maxSessionSeconds is closed after a {"type":"timeout","reason":...} control
+// frame and audited as terminal.docker.timeout.
+//
 // @Summary Docker exec WebSocket terminal
 // @Description Upgrades to a WebSocket PTY session inside the given container via docker exec. Supports remote servers via server_id. Superuser only.
 // @Tags Terminal Docker
 // @Security BearerAuth
 // @Param containerId path string true "container ID or name"
 // @Param server_id query string false "server ID (omit for local)"
+// @Param Sec-WebSocket-Protocol header string false "appos-terminal-v1 for JSON control in text frames and raw data in binary frames; appos-terminal-legacy or none for 0x00-prefixed binary control frames"
 // @Param shell query string false "shell binary" Enums(/bin/sh, /bin/bash, /bin/zsh)
 // @Success 101 {string} string "WebSocket upgrade"
 // @Failure 400 {object} map[string]any
@@ -56,11 +61,11 @@ func handleDockerExecTerminal(e *core.RequestEvent) error {
 		serverID = "local"
 	}
 
-	conn, err := wsUpgrader.Upgrade(e.Response, e.Request, nil)
+	ws, err := upgradeTerminalWS(e.Response, e.Request)
 	if err != nil {
 		return nil
 	}
-	defer conn.Close()
+	defer ws.Close()
 
 	var cfg terminal.ConnectorConfig
 	var connector terminal.Connector
@@ -70,8 +75,14 @@ func handleDockerExecTerminal(e *core.RequestEvent) error {
 	} else {
 		resolvedCfg, resolveErr := resolveTerminalConfig(e.App, e.Auth, serverID)
 		if resolveErr != nil {
-			return e.JSON(http.StatusBadRequest, map[string]any{"message": resolveErr.Error()})
+			return serverConfigError(e, resolveErr)
+		}
+		release, slotErr := acquireServerSession(e.App, serverID)
+		if slotErr != nil {
+			closeWSWithError(ws, slotErr)
+			return nil
 		}
+		defer release()
 		resolvedCfg.Shell = fmt.Sprintf("docker exec -it %s %s", containerID, shell)
 		cfg = resolvedCfg
 		connector = &terminal.SSHConnector{}
@@ -79,7 +90,7 @@ func handleDockerExecTerminal(e *core.RequestEvent) error {
 
 	sess, err := connector.Connect(e.Request.Context(), cfg)
 	if err != nil {
-		closeWSWithError(conn, err)
+		closeWSWithError(ws, err)
 		return nil
 	}
 
@@ -88,24 +99,39 @@ func handleDockerExecTerminal(e *core.RequestEvent) error {
 	startedAt := time.Now().UTC()
 	var bytesOut, bytesIn atomic.Int64
 
-	terminal.Register(sessionID, sess)
+	expiry := &terminalExpiry{ws: ws}
+	terminal.Register(sessionID, sess, terminal.ConnectionMeta{
+		Type:     terminal.ConnectionDockerExec,
+		UserID:   userID,
+		Target:   serverID + "/" + containerID,
+		ServerID: serverID,
+		BytesIn:  &bytesIn,
+		BytesOut: &bytesOut,
+		OnExpire: expiry.notify,
+	})
 	defer func() {
 		terminal.Unregister(sessionID)
 		_ = sess.Close()
+		detail := map[string]any{
+			"session_id": sessionID,
+			"started_at": startedAt.Format(time.RFC3339),
+			"ended_at":   time.Now().UTC().Format(time.RFC3339),
+			"bytes_in":   bytesIn.Load(),
+			"bytes_out":  bytesOut.Load(),
+		}
+		action := "terminal.docker.disconnect"
+		if reason := expiry.Reason(); reason != "" {
+			action = "terminal.docker.timeout"
+			detail["reason"] = reason
+		}
 		audit.Write(e.App, audit.Entry{
 			UserID:       userID,
-			Action:       "terminal.docker.disconnect",
+			Action:       action,
 			ResourceType: "container",
 			ResourceID:   containerID,
 			Status:       audit.StatusSuccess,
 			IP:           ip,
-			Detail: map[string]any{
-				"session_id": sessionID,
-				"started_at": startedAt.Format(time.RFC3339),
-				"ended_at":   time.Now().UTC().Format(time.RFC3339),
-				"bytes_in":   bytesIn.Load(),
-				"bytes_out":  bytesOut.Load(),
-			},
+			Detail:       detail,
 		})
 	}()
 
@@ -129,7 +155,7 @@ func handleDockerExecTerminal(e *core.RequestEvent) error {
 				break
 			}
 			bytesOut.Add(int64(n))
-			if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+			if err := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
 				break
 			}
 		}
@@ -138,13 +164,13 @@ func handleDockerExecTerminal(e *core.RequestEvent) error {
 	go func() {
 		defer func() { _ = sess.Close() }() // unblock Read goroutine on client disconnect
 		for {
-			mt, msg, err := conn.ReadMessage()
+			control, msg, err := ws.readFrame()
 			if err != nil {
 				break
 			}
 			terminal.Touch(sessionID)
-			if mt == websocket.TextMessage || (len(msg) > 0 && msg[0] == 0x00) {
-				handleControlFrame(sess, msg)
+			if control {
+				handleControlFrame(ws, ws.framing, sess, msg)
 				continue
 			}
 			bytesIn.Add(int64(len(msg)))
@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/sftppaths"
+)
+
+func registerServerFilePathRoutes(g *router.RouterGroup[*core.RequestEvent]) {
+	sftp := g.Group("/sftp/{serverId}")
+	sftp.GET("/bookmarks", handleSFTPBookmarksList)
+	sftp.POST("/bookmarks", handleSFTPBookmarkAdd)
+	sftp.DELETE("/bookmarks/{id}", handleSFTPBookmarkDelete)
+	sftp.GET("/recent", handleSFTPRecentList)
+}
+
+// handleSFTPBookmarksList returns the caller's bookmarked paths on a server.
+//
+// @Summary List path bookmarks
+// @Description Returns the authenticated user's bookmarked paths on the server, ordered by path. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/bookmarks [get]
+func handleSFTPBookmarksList(e *core.RequestEvent) error {
+	serverID, ok, err := sftpPathServer(e)
+	if !ok {
+		return err
+	}
+	items, err := sftppaths.Bookmarks(e.App, e.Auth.Id, serverID)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+	}
+	return e.JSON(http.StatusOK, map[string]any{"server_id": serverID, "items": items})
+}
+
+// handleSFTPBookmarkAdd bookmarks a path on a server for the caller.
+//
+// @Summary Add path bookmark
+// @Description Bookmarks an absolute path on the server for the authenticated user. Re-adding an existing path updates its label. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param body body object true "path and optional label"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/bookmarks [post]
+func handleSFTPBookmarkAdd(e *core.RequestEvent) error {
+	serverID, ok, err := sftpPathServer(e)
+	if !ok {
+		return err
+	}
+	var body struct {
+		Path  string `json:"path"`
+		Label string `json:"label"`
+	}
+	if err := json.NewDecoder(e.Request.Body).Decode(&body); err != nil || body.Path == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
+	}
+
+	item, err := sftppaths.AddBookmark(e.App, e.Auth.Id, serverID, body.Path, body.Label)
+	switch {
+	case errors.Is(err, sftppaths.ErrInvalidPath):
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	case errors.Is(err, sftppaths.ErrTooManyBookmarks):
+		return e.JSON(http.StatusConflict, map[string]any{"message": err.Error()})
+	case err != nil:
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+	}
+	return e.JSON(http.StatusOK, item)
+}
+
+// handleSFTPBookmarkDelete removes one of the caller's bookmarks.
+//
+// @Summary Delete path bookmark
+// @Description Removes a bookmark owned by the authenticated user on the server. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param id path string true "bookmark ID"
+// @Success 204
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/bookmarks/{id} [delete]
+func handleSFTPBookmarkDelete(e *core.RequestEvent) error {
+	serverID, ok, err := sftpPathServer(e)
+	if !ok {
+		return err
+	}
+	err = sftppaths.DeleteBookmark(e.App, e.Auth.Id, serverID, e.Request.PathValue("id"))
+	switch {
+	case errors.Is(err, sftppaths.ErrNotFound):
+		return e.JSON(http.StatusNotFound, map[string]any{"message": err.Error()})
+	case err != nil:
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+// handleSFTPRecentList returns the directories the caller listed most recently.
+//
+// @Summary List recent paths
+// @Description Returns the authenticated user's most recently listed directories on the server, newest first. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Success 200 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/recent [get]
+func handleSFTPRecentList(e *core.RequestEvent) error {
+	serverID, ok, err := sftpPathServer(e)
+	if !ok {
+		return err
+	}
+	items, err := sftppaths.Recent(e.App, e.Auth.Id, serverID)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+	}
+	return e.JSON(http.StatusOK, map[string]any{"server_id": serverID, "items": items})
+}
+
+// sftpPathServer resolves the serverId path value to an existing server.
+// When ok is false the error response has already been written.
+func sftpPathServer(e *core.RequestEvent) (serverID string, ok bool, err error) {
+	serverID = e.Request.PathValue("serverId")
+	if _, findErr := archive.FindLive(e.App, "servers", serverID); findErr != nil {
+		return serverID, false, e.JSON(http.StatusNotFound, map[string]any{"message": "server not found"})
+	}
+	return serverID, true, nil
+}
+
+// recordSFTPRecentPath remembers a listed directory for the caller's quick
+// navigation. Failures are logged and never affect the listing itself.
+func recordSFTPRecentPath(e *core.RequestEvent, serverID, dirPath string) {
+	if e.Auth == nil {
+		return
+	}
+	if err := sftppaths.RecordRecent(e.App, e.Auth.Id, serverID, dirPath); err != nil && !errors.Is(err, sftppaths.ErrInvalidPath) {
+		e.App.Logger().Warn("sftp: record recent path failed", "server", serverID, "path", dirPath, "error", err)
+	}
+}
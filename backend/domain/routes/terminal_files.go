@@ -1,20 +1,25 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
 
 	"github.com/websoft9/appos/backend/domain/audit"
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
-	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
+	"github.com/websoft9/appos/backend/domain/sftpjails"
 	"github.com/websoft9/appos/backend/domain/terminal"
 )
 
@@ -24,8 +29,13 @@ func registerServerFileRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	sftp.GET("/search", handleSFTPSearch)
 	sftp.GET("/constraints", handleSFTPConstraints)
 	sftp.GET("/stat", handleSFTPStat)
+	sftp.GET("/checksum", handleSFTPChecksum)
 	sftp.GET("/download", handleSFTPDownload)
-	sftp.POST("/upload", handleSFTPUpload)
+	sftp.GET("/download-archive", handleSFTPDownloadArchive)
+	sftp.POST("/upload", handleSFTPUpload).Bind(bodyLimit(sftpUploadBodyLimit))
+	sftp.POST("/upload/init", handleSFTPUploadInit)
+	sftp.POST("/upload/chunk", handleSFTPUploadChunk).Bind(bodyLimit(sftpUploadBodyLimit))
+	sftp.POST("/upload/complete", handleSFTPUploadComplete)
 	sftp.POST("/mkdir", handleSFTPMkdir)
 	sftp.POST("/rename", handleSFTPRename)
 	sftp.POST("/chmod", handleSFTPChmod)
@@ -36,9 +46,18 @@ func registerServerFileRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	sftp.POST("/move", handleSFTPMove)
 	sftp.DELETE("/delete", handleSFTPDelete)
 	sftp.GET("/read", handleSFTPRead)
-	sftp.POST("/write", handleSFTPWrite)
+	sftp.POST("/read-batch", handleSFTPReadBatch)
+	sftp.POST("/write", handleSFTPWrite).Bind(bodyLimit(sftpWriteBodyLimit))
 }
 
+// sftpMaxUploadBytes caps a single SFTP upload.
+const sftpMaxUploadBytes = 50 << 20
+
+func sftpUploadBodyLimit(core.App) int64 { return sftpMaxUploadBytes + bodyLimitOverhead }
+
+// sftpWriteBodyLimit allows a JSON-escaped file of the editor's read limit.
+func sftpWriteBodyLimit(core.App) int64 { return 2*sftpMaxReadBytes + bodyLimitOverhead }
+
 // ════════════════════════════════════════════════════════════
 // SFTP REST handlers
 // ════════════════════════════════════════════════════════════
@@ -46,37 +65,62 @@ func registerServerFileRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 // handleSFTPList returns a directory listing on the remote server via SFTP.
 //
 // @Summary List directory
-// @Description Returns a directory listing for the given path on the remote server. Superuser only.
+// @Description Returns a directory listing for the given path on the remote server. When the caller has an SFTP jail (sftp_jails) for the server, every SFTP route rejects paths outside the jail root, including through .. and symlinks, with 403, and the default path is the jail root; the terminal, command and records APIs are not confined. Superuser only.
 // @Tags Terminal SFTP
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
-// @Param path query string false "directory path (default /)"
+// @Param path query string false "directory path (default: the server's default_dir, else /)"
+// @Param offset query int false "index of the first entry to return (default 0)"
+// @Param limit query int false "page size (default 1000, max 5000)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/list [get]
 func handleSFTPList(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
 	dirPath := e.Request.URL.Query().Get("path")
 	if dirPath == "" {
-		dirPath = "/"
+		dirPath = client.DefaultDir()
 	}
 
-	entries, err := client.ListDir(dirPath)
+	var opts terminal.ListOptions
+	if raw := e.Request.URL.Query().Get("offset"); raw != "" {
+		if parsed, convErr := strconv.Atoi(raw); convErr == nil && parsed > 0 {
+			opts.Offset = parsed
+		}
+	}
+	if raw := e.Request.URL.Query().Get("limit"); raw != "" {
+		if parsed, convErr := strconv.Atoi(raw); convErr == nil && parsed > 0 {
+			opts.Limit = parsed
+		}
+	}
+
+	listing, err := client.ListDir(e.Request.Context(), dirPath, opts)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
+	}
+	if opts.Offset == 0 {
+		recordSFTPRecentPath(e, serverID, dirPath)
 	}
 
 	return e.JSON(http.StatusOK, map[string]any{
 		"path":      dirPath,
 		"server_id": serverID,
-		"entries":   entries,
+		"entries":   listing.Entries,
+		"total":     listing.Total,
+		"offset":    listing.Offset,
+		"limit":     listing.Limit,
+		"has_more":  listing.HasMore(),
+		"truncated": listing.Truncated,
 	})
 }
 
@@ -87,32 +131,35 @@ func handleSFTPList(e *core.RequestEvent) error {
 // @Tags Terminal SFTP
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
-// @Param path query string false "base path (default /)"
+// @Param path query string false "base path (default: the server's default_dir, else /)"
 // @Param query query string true "search term"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/search [get]
 func handleSFTPSearch(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
 	basePath := e.Request.URL.Query().Get("path")
 	if basePath == "" {
-		basePath = "/"
+		basePath = client.DefaultDir()
 	}
 	query := e.Request.URL.Query().Get("query")
 	if query == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "query required"})
 	}
 
-	results, err := client.SearchFiles(basePath, query)
+	results, truncated, err := client.SearchFiles(e.Request.Context(), basePath, query)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 
 	return e.JSON(http.StatusOK, map[string]any{
@@ -120,13 +167,14 @@ func handleSFTPSearch(e *core.RequestEvent) error {
 		"server_id": serverID,
 		"query":     query,
 		"results":   results,
+		"truncated": truncated,
 	})
 }
 
 // handleSFTPConstraints returns the effective SFTP upload constraints (from settings).
 //
 // @Summary File constraints
-// @Description Returns effective upload limits (max_upload_files) from sysconfig. Superuser only.
+// @Description Returns effective upload limits (max_upload_files), the default per-transfer bandwidth limit (transfer_rate_kbps, 0 = unlimited) and the directory archive size limit (max_archive_mb) from sysconfig. Superuser only.
 // @Tags Terminal SFTP
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
@@ -134,9 +182,11 @@ func handleSFTPSearch(e *core.RequestEvent) error {
 // @Failure 401 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/constraints [get]
 func handleSFTPConstraints(e *core.RequestEvent) error {
-	cfg, _ := sysconfig.GetGroup(e.App, "connect", "sftp", settingscatalog.DefaultGroup("connect", "sftp"))
+	cfg, _ := sysconfig.GetGroup(e.App, "connect", "sftp", nil)
 	return e.JSON(http.StatusOK, map[string]any{
-		"max_upload_files": sysconfig.Int(cfg, "maxUploadFiles", 10),
+		"max_upload_files":   sysconfig.Int(cfg, "maxUploadFiles", 10),
+		"transfer_rate_kbps": max(sysconfig.Int(cfg, "transferRateKBps", 0), 0),
+		"max_archive_mb":     max(sysconfig.Int(cfg, "maxArchiveMB", 1024), 1),
 	})
 }
 
@@ -148,15 +198,18 @@ func handleSFTPConstraints(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param path query string true "remote path"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/stat [get]
 func handleSFTPStat(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -167,7 +220,7 @@ func handleSFTPStat(e *core.RequestEvent) error {
 
 	attrs, err := client.Stat(filePath)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 
 	return e.JSON(http.StatusOK, map[string]any{
@@ -176,6 +229,55 @@ func handleSFTPStat(e *core.RequestEvent) error {
 	})
 }
 
+// handleSFTPChecksum returns the digest of a remote file so clients can verify transfers.
+//
+// @Summary File checksum
+// @Description Computes a sha256, sha1, or md5 digest of a remote file. Uses the remote checksum command when available, otherwise streams the file through a hash. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param path query string true "remote file path"
+// @Param algo query string false "checksum algorithm (default sha256)" Enums(sha256, sha1, md5)
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/checksum [get]
+func handleSFTPChecksum(e *core.RequestEvent) error {
+	filePath := e.Request.URL.Query().Get("path")
+	if filePath == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
+	}
+	algo := strings.ToLower(strings.TrimSpace(e.Request.URL.Query().Get("algo")))
+	if algo == "" {
+		algo = "sha256"
+	}
+	if !terminal.IsChecksumAlgorithm(algo) {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "algo must be one of sha256, sha1, md5"})
+	}
+
+	client, serverID, err := openSFTPClient(e)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer client.Close()
+
+	digest, err := client.Checksum(e.Request.Context(), filePath, algo)
+	if err != nil {
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"server_id": serverID,
+		"path":      filePath,
+		"algo":      algo,
+		"checksum":  digest,
+	})
+}
+
 // handleSFTPDownload streams a remote file as a download attachment.
 //
 // @Summary Download file
@@ -184,15 +286,19 @@ func handleSFTPStat(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param path query string true "remote file path"
+// @Param rate_kbps query int false "per-transfer bandwidth limit in KB/s, overrides the setting (0 = unlimited)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {string} string "file content"
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/download [get]
 func handleSFTPDownload(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -200,12 +306,15 @@ func handleSFTPDownload(e *core.RequestEvent) error {
 	if filePath == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
 	}
+	if err := applySFTPTransferRate(e, client); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
 
 	filename := path.Base(filePath)
 	e.Response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
 	e.Response.Header().Set("Content-Type", "application/octet-stream")
 
-	downloadErr := client.Download(filePath, e.Response)
+	downloadErr := client.Download(e.Request.Context(), filePath, e.Response)
 
 	// Audit after the operation so status reflects actual outcome.
 	userID, _, ip, _ := clientInfo(e)
@@ -223,28 +332,117 @@ func handleSFTPDownload(e *core.RequestEvent) error {
 		Detail:       map[string]any{"path": filePath},
 	})
 
+	if errors.Is(downloadErr, terminal.ErrOutsideRoot) {
+		// Refused before any byte was written, so the error can still be JSON.
+		e.Response.Header().Del("Content-Disposition")
+		return e.JSON(http.StatusForbidden, map[string]any{"message": downloadErr.Error()})
+	}
 	return downloadErr
 }
 
+// handleSFTPDownloadArchive streams a remote directory as a zip download.
+//
+// @Summary Download directory as zip
+// @Description Walks a remote directory without following symlinks and streams it as a zip attachment, keeping relative paths, file modes and modification times. The total uncompressed size is capped by connect/sftp maxArchiveMB (413 before any data is sent). Writes an audit entry with the root path and bytes archived. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param path query string true "remote directory path"
+// @Param rate_kbps query int false "per-transfer bandwidth limit in KB/s, overrides the setting (0 = unlimited)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
+// @Success 200 {string} string "zip archive"
+// @Failure 400 {object} map[string]any
+// @Failure 413 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/download-archive [get]
+func handleSFTPDownloadArchive(e *core.RequestEvent) error {
+	client, serverID, err := openSFTPClient(e)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer client.Close()
+
+	dirPath := e.Request.URL.Query().Get("path")
+	if dirPath == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
+	}
+	if err := applySFTPTransferRate(e, client); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+	cfg, _ := sysconfig.GetGroup(e.App, "connect", "sftp", nil)
+	maxBytes := int64(max(sysconfig.Int(cfg, "maxArchiveMB", 1024), 1)) << 20
+
+	plan, err := client.PlanArchive(e.Request.Context(), dirPath, maxBytes)
+	if err != nil {
+		if errors.Is(err, terminal.ErrArchiveTooLarge) {
+			return e.JSON(http.StatusRequestEntityTooLarge, map[string]any{
+				"message": fmt.Sprintf("directory exceeds the %d MB archive limit", maxBytes>>20),
+			})
+		}
+		if errors.Is(err, terminal.ErrOutsideRoot) {
+			return e.JSON(http.StatusForbidden, map[string]any{"message": err.Error()})
+		}
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+
+	filename := path.Base(plan.Root)
+	if filename == "/" {
+		filename = "root"
+	}
+	e.Response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	e.Response.Header().Set("Content-Type", "application/zip")
+
+	written, archiveErr := client.WriteZip(e.Request.Context(), plan, e.Response, maxBytes)
+
+	userID, _, ip, _ := clientInfo(e)
+	auditStatus := audit.StatusSuccess
+	detail := map[string]any{"path": plan.Root, "files": plan.Files, "bytes": written}
+	if archiveErr != nil {
+		auditStatus = audit.StatusFailed
+		detail["error"] = archiveErr.Error()
+	}
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "terminal.sftp.download_archive",
+		ResourceType: "server",
+		ResourceID:   serverID,
+		Status:       auditStatus,
+		IP:           ip,
+		Detail:       detail,
+	})
+
+	return archiveErr
+}
+
 // handleSFTPUpload uploads a file to a remote directory via SFTP.
 //
 // @Summary Upload file
-// @Description Accepts a multipart upload and saves the file to the given remote directory. Writes an audit entry. Superuser only.
+// @Description Accepts a multipart upload and saves the file to the given remote directory, optionally under a new name. An existing destination is rejected with 409 unless overwrite=true. Writes an audit entry. Superuser only.
 // @Tags Terminal SFTP
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param path query string true "remote destination directory"
+// @Param name query string false "remote file name (defaults to the uploaded file name)"
+// @Param overwrite query bool false "replace an existing destination file (default false)"
+// @Param rate_kbps query int false "per-transfer bandwidth limit in KB/s, overrides the setting (0 = unlimited)"
 // @Param file formData file true "file to upload"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 413 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/upload [post]
 func handleSFTPUpload(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -252,9 +450,18 @@ func handleSFTPUpload(e *core.RequestEvent) error {
 	if remotePath == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
 	}
+	overwrite := false
+	if raw := e.Request.URL.Query().Get("overwrite"); raw != "" {
+		if overwrite, err = strconv.ParseBool(raw); err != nil {
+			return e.JSON(http.StatusBadRequest, map[string]any{"message": "overwrite must be a boolean"})
+		}
+	}
+	if err := applySFTPTransferRate(e, client); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
 
-	// Parse multipart — limit to 50 MB + overhead
-	if err := e.Request.ParseMultipartForm(50 << 20); err != nil {
+	// Parse multipart — the body is capped at 50 MB + overhead by sftpUploadBodyLimit
+	if err := e.Request.ParseMultipartForm(sftpMaxUploadBytes); err != nil {
 		return e.JSON(http.StatusRequestEntityTooLarge, map[string]any{"message": "file too large (max 50 MB)"})
 	}
 
@@ -264,9 +471,20 @@ func handleSFTPUpload(e *core.RequestEvent) error {
 	}
 	defer file.Close()
 
-	dest := path.Join(remotePath, header.Filename)
-	if err := client.Upload(dest, file); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+	name := e.Request.URL.Query().Get("name")
+	if name == "" {
+		name = header.Filename
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "invalid file name"})
+	}
+
+	dest := path.Join(remotePath, name)
+	if err := client.Upload(e.Request.Context(), dest, file, overwrite); err != nil {
+		if errors.Is(err, terminal.ErrRemoteExists) {
+			return e.JSON(http.StatusConflict, map[string]any{"message": fmt.Sprintf("%s already exists", dest)})
+		}
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 
 	// Audit upload
@@ -278,12 +496,273 @@ func handleSFTPUpload(e *core.RequestEvent) error {
 		ResourceID:   serverID,
 		Status:       audit.StatusSuccess,
 		IP:           ip,
-		Detail:       map[string]any{"path": dest, "size": header.Size},
+		Detail:       map[string]any{"path": dest, "size": header.Size, "overwrite": overwrite},
 	})
 
 	return e.JSON(http.StatusOK, map[string]any{"path": dest, "size": header.Size})
 }
 
+// sftpChunkedUploadTTL is how long an idle chunked upload is kept before its
+// staging file is removed.
+const sftpChunkedUploadTTL = time.Hour
+
+func init() {
+	go func() {
+		for {
+			time.Sleep(5 * time.Minute)
+			for _, u := range terminal.ExpireChunkedUploads(time.Now()) {
+				removeChunkedUploadPart(u)
+			}
+		}
+	}()
+}
+
+// removeChunkedUploadPart deletes an abandoned upload's staging file on a
+// fresh SFTP session. Errors are ignored: the server may be gone.
+func removeChunkedUploadPart(u *terminal.ChunkedUpload) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := terminal.NewSFTPClient(ctx, u.Config)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	_ = client.Delete(u.PartPath)
+}
+
+// handleSFTPUploadInit starts a chunked upload for files larger than the
+// single-request upload limit. Chunks are staged in a hidden file next to
+// the destination and renamed into place by handleSFTPUploadComplete.
+//
+// @Summary Start chunked upload
+// @Description Starts a resumable upload of size bytes to path/name and returns its upload_id. Send the bytes in order with upload/chunk, then call upload/complete. Uploads idle for an hour are discarded. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param body body object true "path: remote destination directory, name: file name, size: total bytes, overwrite: replace an existing file (default false)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/upload/init [post]
+func handleSFTPUploadInit(e *core.RequestEvent) error {
+	var body struct {
+		Path      string `json:"path"`
+		Name      string `json:"name"`
+		Size      int64  `json:"size"`
+		Overwrite bool   `json:"overwrite"`
+	}
+	if err := json.NewDecoder(e.Request.Body).Decode(&body); err != nil || body.Path == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
+	}
+	if body.Name == "" || body.Name == "." || body.Name == ".." || strings.ContainsAny(body.Name, "/\\") {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "invalid file name"})
+	}
+	if body.Size < 0 {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "size must be non-negative"})
+	}
+
+	serverID := e.Request.PathValue("serverId")
+	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	client, _, err := openSFTPClient(e)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer client.Close()
+
+	dest := path.Join(body.Path, body.Name)
+	if !body.Overwrite {
+		if _, err := client.Stat(dest); err == nil {
+			return e.JSON(http.StatusConflict, map[string]any{"message": fmt.Sprintf("%s already exists", dest)})
+		}
+	}
+
+	id := uuid.NewString()
+	partPath := path.Join(body.Path, "."+body.Name+"."+id+".part")
+	if _, err := client.WriteChunk(e.Request.Context(), partPath, 0, strings.NewReader(""), 0); err != nil {
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
+	}
+
+	userID, _, _, _ := clientInfo(e)
+	cfg.RunAs = strings.TrimSpace(e.Request.URL.Query().Get("as_user"))
+	terminal.RegisterChunkedUpload(&terminal.ChunkedUpload{
+		ID:        id,
+		ServerID:  serverID,
+		UserID:    userID,
+		Dest:      dest,
+		PartPath:  partPath,
+		Size:      body.Size,
+		Overwrite: body.Overwrite,
+		Config:    cfg,
+	}, sftpChunkedUploadTTL)
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"upload_id":       id,
+		"path":            dest,
+		"size":            body.Size,
+		"offset":          0,
+		"max_chunk_bytes": sftpMaxUploadBytes,
+	})
+}
+
+// handleSFTPUploadChunk writes the request body at offset of a chunked upload.
+// The offset must equal the bytes received so far; on a mismatch the current
+// offset is returned with 409 so the client can resume from it. A chunk that
+// failed part-way may be resent at the same offset.
+//
+// @Summary Upload chunk
+// @Description Writes the raw request body (at most 50 MB) at offset of a chunked upload. Returns 409 with the expected offset when offset does not match. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param upload_id query string true "upload ID returned by upload/init"
+// @Param offset query int true "byte offset of this chunk"
+// @Param rate_kbps query int false "per-transfer bandwidth limit in KB/s, overrides the setting (0 = unlimited)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 413 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/upload/chunk [post]
+func handleSFTPUploadChunk(e *core.RequestEvent) error {
+	upload, ok := lookupChunkedUpload(e, e.Request.URL.Query().Get("upload_id"))
+	if !ok {
+		return e.JSON(http.StatusNotFound, map[string]any{"message": "upload not found or expired"})
+	}
+	offset, err := strconv.ParseInt(e.Request.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "offset must be a non-negative integer"})
+	}
+
+	upload.Lock()
+	defer upload.Unlock()
+	if offset != upload.Offset {
+		return e.JSON(http.StatusConflict, map[string]any{
+			"message": fmt.Sprintf("expected offset %d", upload.Offset),
+			"offset":  upload.Offset,
+		})
+	}
+
+	client, _, err := openSFTPClient(e)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer client.Close()
+	if err := applySFTPTransferRate(e, client); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+
+	n, err := client.WriteChunk(e.Request.Context(), upload.PartPath, offset, e.Request.Body, min(sftpMaxUploadBytes, upload.Size-offset))
+	if err != nil {
+		if errors.Is(err, terminal.ErrChunkTooLarge) {
+			return e.JSON(http.StatusRequestEntityTooLarge, map[string]any{
+				"message": fmt.Sprintf("chunk exceeds 50 MB or the remaining %d bytes", upload.Size-offset),
+			})
+		}
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error(), "offset": upload.Offset})
+	}
+	upload.Offset += n
+
+	return e.JSON(http.StatusOK, map[string]any{"upload_id": upload.ID, "offset": upload.Offset, "size": upload.Size})
+}
+
+// handleSFTPUploadComplete renames a fully received chunked upload into place.
+//
+// @Summary Complete chunked upload
+// @Description Moves a chunked upload whose bytes have all been received to its destination. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param body body object true "upload_id: upload ID returned by upload/init"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/upload/complete [post]
+func handleSFTPUploadComplete(e *core.RequestEvent) error {
+	var body struct {
+		UploadID string `json:"upload_id"`
+	}
+	if err := json.NewDecoder(e.Request.Body).Decode(&body); err != nil || body.UploadID == "" {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "upload_id required"})
+	}
+	upload, ok := lookupChunkedUpload(e, body.UploadID)
+	if !ok {
+		return e.JSON(http.StatusNotFound, map[string]any{"message": "upload not found or expired"})
+	}
+
+	upload.Lock()
+	defer upload.Unlock()
+	if upload.Offset != upload.Size {
+		return e.JSON(http.StatusConflict, map[string]any{
+			"message": fmt.Sprintf("upload incomplete: %d of %d bytes received", upload.Offset, upload.Size),
+			"offset":  upload.Offset,
+		})
+	}
+
+	client, serverID, err := openSFTPClient(e)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer client.Close()
+
+	if err := client.CommitUpload(upload.PartPath, upload.Dest, upload.Overwrite); err != nil {
+		if errors.Is(err, terminal.ErrRemoteExists) {
+			return e.JSON(http.StatusConflict, map[string]any{"message": fmt.Sprintf("%s already exists", upload.Dest)})
+		}
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
+	}
+	terminal.RemoveChunkedUpload(upload.ID)
+
+	userID, _, ip, _ := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "terminal.sftp.upload",
+		ResourceType: "server",
+		ResourceID:   serverID,
+		Status:       audit.StatusSuccess,
+		IP:           ip,
+		Detail:       map[string]any{"path": upload.Dest, "size": upload.Size, "overwrite": upload.Overwrite, "chunked": true},
+	})
+
+	return e.JSON(http.StatusOK, map[string]any{"path": upload.Dest, "size": upload.Size})
+}
+
+// lookupChunkedUpload returns the upload with id if it was started by the
+// caller on this server as the same account.
+func lookupChunkedUpload(e *core.RequestEvent, id string) (*terminal.ChunkedUpload, bool) {
+	if id == "" {
+		return nil, false
+	}
+	upload, ok := terminal.LookupChunkedUpload(id, sftpChunkedUploadTTL)
+	if !ok {
+		return nil, false
+	}
+	userID, _, _, _ := clientInfo(e)
+	if upload.UserID != userID || upload.ServerID != e.Request.PathValue("serverId") ||
+		upload.Config.RunAs != strings.TrimSpace(e.Request.URL.Query().Get("as_user")) {
+		return nil, false
+	}
+	return upload, true
+}
+
 // handleSFTPMkdir creates a directory (mkdir -p) on the remote server.
 //
 // @Summary Create directory
@@ -292,15 +771,18 @@ func handleSFTPUpload(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "path: directory to create"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/mkdir [post]
 func handleSFTPMkdir(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -312,7 +794,7 @@ func handleSFTPMkdir(e *core.RequestEvent) error {
 	}
 
 	if err := client.Mkdir(body.Path); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 	return e.JSON(http.StatusOK, map[string]any{"path": body.Path})
 }
@@ -325,15 +807,18 @@ func handleSFTPMkdir(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "from, to (remote paths)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/rename [post]
 func handleSFTPRename(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -346,7 +831,7 @@ func handleSFTPRename(e *core.RequestEvent) error {
 	}
 
 	if err := client.Rename(body.From, body.To); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 	return e.JSON(http.StatusOK, map[string]any{"from": body.From, "to": body.To})
 }
@@ -359,15 +844,18 @@ func handleSFTPRename(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "path, mode (octal string, e.g. \"755\"), recursive (bool)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/chmod [post]
 func handleSFTPChmod(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -391,7 +879,7 @@ func handleSFTPChmod(e *core.RequestEvent) error {
 		err = client.Chmod(body.Path, os.FileMode(val))
 	}
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 	return e.JSON(http.StatusOK, map[string]any{"path": body.Path, "mode": body.Mode, "recursive": body.Recursive})
 }
@@ -404,15 +892,18 @@ func handleSFTPChmod(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "path, owner (username string), group (group name string)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/chown [post]
 func handleSFTPChown(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -437,7 +928,7 @@ func handleSFTPChown(e *core.RequestEvent) error {
 	}
 
 	if err := client.ChownByName(body.Path, owner, group); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 	return e.JSON(http.StatusOK, map[string]any{"path": body.Path, "owner": owner, "group": group})
 }
@@ -450,15 +941,18 @@ func handleSFTPChown(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "target (link destination), link_path (new symlink path)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/symlink [post]
 func handleSFTPSymlink(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -471,7 +965,7 @@ func handleSFTPSymlink(e *core.RequestEvent) error {
 	}
 
 	if err := client.Symlink(body.Target, body.LinkPath); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 	return e.JSON(http.StatusOK, map[string]any{"target": body.Target, "link_path": body.LinkPath})
 }
@@ -484,15 +978,19 @@ func handleSFTPSymlink(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "from, to (remote paths)"
+// @Param rate_kbps query int false "per-transfer bandwidth limit in KB/s, overrides the setting (0 = unlimited)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/copy [post]
 func handleSFTPCopy(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -503,14 +1001,17 @@ func handleSFTPCopy(e *core.RequestEvent) error {
 	if err := json.NewDecoder(e.Request.Body).Decode(&body); err != nil || body.From == "" || body.To == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "from and to required"})
 	}
+	if err := applySFTPTransferRate(e, client); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
 
 	var copied, total int64
-	_, err = client.Copy(body.From, body.To, func(done, sum int64) {
+	_, err = client.Copy(e.Request.Context(), body.From, body.To, func(done, sum int64) {
 		copied = done
 		total = sum
 	})
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error(), "progress": map[string]any{"copied": copied, "total": total}})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error(), "progress": map[string]any{"copied": copied, "total": total}})
 	}
 
 	return e.JSON(http.StatusOK, map[string]any{"from": body.From, "to": body.To, "progress": map[string]any{"copied": copied, "total": total}})
@@ -525,14 +1026,18 @@ func handleSFTPCopy(e *core.RequestEvent) error {
 // @Param serverId path string true "server record ID"
 // @Param from query string true "source remote path"
 // @Param to query string true "destination remote path"
+// @Param rate_kbps query int false "per-transfer bandwidth limit in KB/s, overrides the setting (0 = unlimited)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {string} string "SSE stream (text/event-stream)"
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/copy-stream [get]
 func handleSFTPCopyStream(e *core.RequestEvent) error {
-	client, _, err := openSFTPClient(e)
+	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -541,25 +1046,38 @@ func handleSFTPCopyStream(e *core.RequestEvent) error {
 	if from == "" || to == "" {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "from and to required"})
 	}
+	if err := applySFTPTransferRate(e, client); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
 
 	flusher, ok := e.Response.(http.Flusher)
 	if !ok {
 		return e.JSON(http.StatusInternalServerError, map[string]any{"message": "streaming unsupported"})
 	}
 
+	// Terminating the stream closes the client, which aborts the copy.
+	var bytesOut atomic.Int64
+	_, untrack := trackStream(e, terminal.ConnectionMeta{
+		Type:     terminal.ConnectionSFTPCopy,
+		Target:   serverID + ":" + from,
+		ServerID: serverID,
+		BytesOut: &bytesOut,
+	}, client)
+	defer untrack()
+
 	e.Response.Header().Set("Content-Type", "text/event-stream")
 	e.Response.Header().Set("Cache-Control", "no-cache")
 	e.Response.Header().Set("Connection", "keep-alive")
 
 	push := func(event string, payload map[string]any) {
 		b, _ := json.Marshal(payload)
-		_, _ = fmt.Fprintf(e.Response, "event: %s\n", event)
-		_, _ = fmt.Fprintf(e.Response, "data: %s\n\n", string(b))
+		n, _ := fmt.Fprintf(e.Response, "event: %s\ndata: %s\n\n", event, string(b))
+		bytesOut.Add(int64(n))
 		flusher.Flush()
 	}
 
 	push("start", map[string]any{"from": from, "to": to})
-	_, err = client.Copy(from, to, func(copied, total int64) {
+	_, err = client.Copy(e.Request.Context(), from, to, func(copied, total int64) {
 		push("progress", map[string]any{"copied": copied, "total": total})
 	})
 	if err != nil {
@@ -579,15 +1097,18 @@ func handleSFTPCopyStream(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "from, to (remote paths)"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/move [post]
 func handleSFTPMove(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -600,7 +1121,7 @@ func handleSFTPMove(e *core.RequestEvent) error {
 	}
 
 	if err := client.Rename(body.From, body.To); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 	return e.JSON(http.StatusOK, map[string]any{"from": body.From, "to": body.To})
 }
@@ -608,20 +1129,24 @@ func handleSFTPMove(e *core.RequestEvent) error {
 // handleSFTPDelete deletes a file or directory on the remote server.
 //
 // @Summary Delete
-// @Description Deletes the file or directory at the given remote path. Writes an audit entry. Superuser only.
+// @Description Deletes the file or directory at the given remote path. Without recursive, a directory must be empty. With recursive=true, a directory is removed with everything under it; symlinks are removed, not followed. If part of the tree cannot be removed, the rest is still removed and the response lists the failed paths in failed. Writes an audit entry. Superuser only.
 // @Tags Terminal SFTP
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param path query string true "remote path to delete"
+// @Param recursive query bool false "remove a non-empty directory with its contents"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 204 {string} string "no content"
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/delete [delete]
 func handleSFTPDelete(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -630,11 +1155,40 @@ func handleSFTPDelete(e *core.RequestEvent) error {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
 	}
 
-	if err := client.Delete(filePath); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+	recursive := false
+	if raw := e.Request.URL.Query().Get("recursive"); raw != "" {
+		if recursive, err = strconv.ParseBool(raw); err != nil {
+			return e.JSON(http.StatusBadRequest, map[string]any{"message": "recursive must be a boolean"})
+		}
+	}
+
+	if !recursive {
+		if err := client.Delete(filePath); err != nil {
+			return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
+		}
+	} else if err := client.DeleteRecursive(filePath); err != nil {
+		var partial *terminal.DeleteError
+		if !errors.As(err, &partial) {
+			return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
+		}
+		userID, _, ip, _ := clientInfo(e)
+		audit.Write(e.App, audit.Entry{
+			UserID:       userID,
+			Action:       "terminal.sftp.delete",
+			ResourceType: "server",
+			ResourceID:   serverID,
+			Status:       audit.StatusFailed,
+			IP:           ip,
+			Detail:       map[string]any{"path": filePath, "recursive": true, "failed": partial.Failed},
+		})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error(), "failed": partial.Failed})
 	}
 
 	// Audit delete
+	detail := map[string]any{"path": filePath}
+	if recursive {
+		detail["recursive"] = true
+	}
 	userID, _, ip, _ := clientInfo(e)
 	audit.Write(e.App, audit.Entry{
 		UserID:       userID,
@@ -643,7 +1197,7 @@ func handleSFTPDelete(e *core.RequestEvent) error {
 		ResourceID:   serverID,
 		Status:       audit.StatusSuccess,
 		IP:           ip,
-		Detail:       map[string]any{"path": filePath},
+		Detail:       detail,
 	})
 
 	return e.NoContent(http.StatusNoContent)
@@ -656,21 +1210,27 @@ const sftpMaxReadBytes = 2 << 20 // 2 MB — reasonable limit for text editing
 // handleSFTPRead returns the text content of a remote file (up to 2 MB).
 //
 // @Summary Read file
-// @Description Returns UTF-8 text content of a remote file via SFTP (max 2 MB). Superuser only.
+// @Description Returns UTF-8 text content of a remote file via SFTP (max 2 MB). Responses carry an ETag built from size and mtime plus Last-Modified; a matching If-None-Match or If-Modified-Since gets 304 without transferring the file. Superuser only.
 // @Tags Terminal SFTP
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param path query string true "remote file path"
+// @Param If-None-Match header string false "ETag from a previous read"
+// @Param If-Modified-Since header string false "Last-Modified from a previous read"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
+// @Success 304 "not modified"
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 413 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/read [get]
 func handleSFTPRead(e *core.RequestEvent) error {
 	client, _, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -679,9 +1239,16 @@ func handleSFTPRead(e *core.RequestEvent) error {
 		return e.JSON(http.StatusBadRequest, map[string]any{"message": "path required"})
 	}
 
+	// Validate with a cheap stat before transferring the content.
+	if attrs, statErr := client.Stat(filePath); statErr == nil && attrs.Type == "file" {
+		if done, err := writeNotModified(e, statETag(attrs.Size, attrs.ModifiedAt), attrs.ModifiedAt); done {
+			return err
+		}
+	}
+
 	content, err := client.ReadFile(filePath, sftpMaxReadBytes)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 
 	return e.JSON(http.StatusOK, map[string]any{
@@ -690,6 +1257,63 @@ func handleSFTPRead(e *core.RequestEvent) error {
 	})
 }
 
+// Batch reads share one connection; the total cap keeps the JSON response
+// bounded however many paths are asked for.
+const (
+	sftpMaxBatchPaths     = 20
+	sftpMaxBatchReadBytes = 8 << 20 // 8 MB
+)
+
+// handleSFTPReadBatch returns the text content of several remote files.
+//
+// @Summary Read files
+// @Description Reads up to 20 remote files over one SFTP connection, e.g. a compose file and its .env. Each result carries either content or error, in request order. Each file is limited to 2 MB like read, and files that would bring the returned content past 8 MB in total get an error instead. A path outside the caller's jail gets a per-path error. Superuser only.
+// @Tags Terminal SFTP
+// @Security BearerAuth
+// @Param serverId path string true "server record ID"
+// @Param body body object true "paths: remote file paths"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/terminal/sftp/{serverId}/read-batch [post]
+func handleSFTPReadBatch(e *core.RequestEvent) error {
+	var body struct {
+		Paths []string `json:"paths"`
+	}
+	if err := json.NewDecoder(e.Request.Body).Decode(&body); err != nil || len(body.Paths) == 0 {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "paths required"})
+	}
+	if len(body.Paths) > sftpMaxBatchPaths {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": fmt.Sprintf("at most %d paths per request", sftpMaxBatchPaths)})
+	}
+	for _, p := range body.Paths {
+		if p == "" {
+			return e.JSON(http.StatusBadRequest, map[string]any{"message": "paths must not be empty"})
+		}
+	}
+
+	client, _, err := openSFTPClient(e)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer client.Close()
+
+	results := client.ReadFiles(e.Request.Context(), body.Paths, sftpMaxReadBytes, sftpMaxBatchReadBytes)
+	files := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			files = append(files, map[string]any{"path": r.Path, "error": r.Err.Error()})
+			continue
+		}
+		files = append(files, map[string]any{"path": r.Path, "content": r.Content})
+	}
+	return e.JSON(http.StatusOK, map[string]any{"files": files})
+}
+
 // handleSFTPWrite writes text content to a remote file via SFTP.
 //
 // @Summary Write file
@@ -698,15 +1322,18 @@ func handleSFTPRead(e *core.RequestEvent) error {
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param body body object true "path, content"
+// @Param as_user query string false "run as this account via the server's sudo or doas (superuser only, audited)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 429 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 403 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/terminal/sftp/{serverId}/write [post]
 func handleSFTPWrite(e *core.RequestEvent) error {
 	client, serverID, err := openSFTPClient(e)
 	if err != nil {
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverSessionError(e, err)
 	}
 	defer client.Close()
 
@@ -719,7 +1346,7 @@ func handleSFTPWrite(e *core.RequestEvent) error {
 	}
 
 	if err := client.WriteFile(body.Path, body.Content); err != nil {
-		return e.JSON(http.StatusInternalServerError, map[string]any{"message": err.Error()})
+		return e.JSON(sftpErrorStatus(err), map[string]any{"message": err.Error()})
 	}
 
 	// Audit write
@@ -737,17 +1364,86 @@ func handleSFTPWrite(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, map[string]any{"path": body.Path, "size": len(body.Content)})
 }
 
-// openSFTPClient resolves server config and opens an SFTP session.
+// openSFTPClient resolves server config and opens an SFTP session. The
+// as_user query parameter (superuser only) opens it as that account through
+// the server's sudo or doas; each such session is audited.
 // Returns the client, serverID, and any error.
 func openSFTPClient(e *core.RequestEvent) (*terminal.SFTPClient, string, error) {
 	serverID := e.Request.PathValue("serverId")
+	asUser, err := parseRunAsUser(e, e.Request.URL.Query().Get("as_user"))
+	if err != nil {
+		return nil, serverID, err
+	}
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
 		return nil, serverID, err
 	}
+	cfg.RunAs = asUser
+	root, err := sftpJailRoot(e, serverID)
+	if err != nil {
+		return nil, serverID, err
+	}
+	release, err := acquireServerSession(e.App, serverID)
+	if err != nil {
+		return nil, serverID, err
+	}
 	client, err := terminal.NewSFTPClient(e.Request.Context(), cfg)
+	if cfg.RunAs != "" {
+		auditRunAs(e, serverID, cfg.RunAs, "sftp "+path.Base(e.Request.URL.Path), err)
+	}
 	if err != nil {
+		release()
+		return nil, serverID, err
+	}
+	client.ReleaseOnClose(release)
+	if err := client.SetRoot(root); err != nil {
+		client.Close()
 		return nil, serverID, err
 	}
 	return client, serverID, nil
 }
+
+// sftpJailRoot returns the directory the caller's SFTP access to serverID is
+// confined to, or "" when the caller is not jailed. The jail applies to the
+// SFTP routes only; see package sftpjails.
+func sftpJailRoot(e *core.RequestEvent, serverID string) (string, error) {
+	if e.Auth == nil {
+		return "", nil
+	}
+	root, err := sftpjails.Root(e.App, e.Auth.Id, serverID)
+	if err != nil {
+		return "", fmt.Errorf("sftp jail lookup failed: %w", err)
+	}
+	return root, nil
+}
+
+// sftpErrorStatus maps an SFTP operation error to its response status: a
+// path outside the caller's jail is forbidden, anything else a server error.
+func sftpErrorStatus(err error) int {
+	if errors.Is(err, terminal.ErrOutsideRoot) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// applySFTPTransferRate sets the per-transfer bandwidth limit on client from
+// the connect/sftp settings. Superusers may override it per request with the
+// rate_kbps query parameter (0 = unlimited).
+func applySFTPTransferRate(e *core.RequestEvent, client *terminal.SFTPClient) error {
+	cfg, _ := sysconfig.GetGroup(e.App, "connect", "sftp", nil)
+	kbps := sysconfig.Int(cfg, "transferRateKBps", 0)
+
+	if raw := strings.TrimSpace(e.Request.URL.Query().Get("rate_kbps")); raw != "" {
+		if !e.HasSuperuserAuth() {
+			return fmt.Errorf("rate_kbps override requires superuser")
+		}
+		override, err := strconv.Atoi(raw)
+		if err != nil || override < 0 {
+			return fmt.Errorf("rate_kbps must be a non-negative integer")
+		}
+		kbps = override
+	}
+
+	client.SetTransferRate(kbps)
+	return nil
+}
@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Terminal WebSocket subprotocols.
+//
+// appos-terminal-v1: text frames carry JSON control messages in both
+// directions; binary frames carry raw terminal data only. A data frame that
+// starts with 0x00 is delivered to the PTY unchanged.
+//
+// appos-terminal-legacy (also used when the client offers no subprotocol):
+// the original convention, where a binary frame starting with 0x00 is a
+// control frame and server control frames are sent as 0x00-prefixed binary.
+const (
+	terminalProtocolV1     = "appos-terminal-v1"
+	terminalProtocolLegacy = "appos-terminal-legacy"
+)
+
+// terminalWSUpgrader negotiates the terminal subprotocol. Server order wins,
+// so v1 is chosen whenever the client offers it.
+var terminalWSUpgrader = websocket.Upgrader{
+	CheckOrigin:  allowWebSocketOrigin,
+	Subprotocols: []string{terminalProtocolV1, terminalProtocolLegacy},
+}
+
+// terminalFraming is the framing contract of one terminal WebSocket.
+type terminalFraming struct {
+	strict bool
+}
+
+var (
+	terminalFramingV1     = terminalFraming{strict: true}
+	terminalFramingLegacy = terminalFraming{}
+)
+
+func terminalFramingFor(subprotocol string) terminalFraming {
+	if subprotocol == terminalProtocolV1 {
+		return terminalFramingV1
+	}
+	return terminalFramingLegacy
+}
+
+// isControl reports whether an inbound frame is a control message.
+func (f terminalFraming) isControl(messageType int, msg []byte) bool {
+	if messageType == websocket.TextMessage {
+		return true
+	}
+	return !f.strict && len(msg) > 0 && msg[0] == 0x00
+}
+
+// controlPayload strips the legacy 0x00 marker from an inbound control frame.
+func (f terminalFraming) controlPayload(raw []byte) []byte {
+	if !f.strict && len(raw) > 0 && raw[0] == 0x00 {
+		return raw[1:]
+	}
+	return raw
+}
+
+// encodeControl frames an outbound JSON control message.
+func (f terminalFraming) encodeControl(data []byte) (int, []byte) {
+	if f.strict {
+		return websocket.TextMessage, data
+	}
+	return websocket.BinaryMessage, append([]byte{0x00}, data...)
+}
+
+// upgradeTerminalWS upgrades a terminal request and wraps the connection with
+// the negotiated framing.
+func upgradeTerminalWS(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	conn, err := terminalWSUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(maxTerminalFrameBytes)
+	return &wsConn{Conn: conn, framing: terminalFramingFor(conn.Subprotocol())}, nil
+}
+
+// readFrame reads the next inbound frame and reports whether it is a control
+// frame. Control frames are read through a maxControlFrameBytes limit, so an
+// oversized one is never buffered: it is answered with an error control
+// frame and skipped. Data frames are bounded by the connection's read limit.
+func (c *wsConn) readFrame() (bool, []byte, error) {
+	for {
+		mt, r, err := c.NextReader()
+		if err != nil {
+			return false, nil, err
+		}
+		// The legacy framing marks a control frame by its first byte.
+		head := make([]byte, 1)
+		n, err := io.ReadFull(r, head)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, nil, err
+		}
+		head = head[:n]
+		if !c.framing.isControl(mt, head) {
+			rest, err := io.ReadAll(r)
+			return false, append(head, rest...), err
+		}
+
+		limit := maxControlFrameBytes + len(head) - len(c.framing.controlPayload(head))
+		rest, err := io.ReadAll(io.LimitReader(r, int64(limit-len(head)+1)))
+		if err != nil {
+			return true, nil, err
+		}
+		if msg := append(head, rest...); len(msg) <= limit {
+			return true, msg, nil
+		}
+		_ = writeWSControl(c, c.framing, "error", fmt.Sprintf("control frame exceeds %d bytes", maxControlFrameBytes))
+	}
+}
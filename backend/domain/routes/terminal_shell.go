@@ -3,85 +3,198 @@ package routes
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"maps"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
 	"github.com/pocketbase/pocketbase/tools/router"
 
 	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+	servers "github.com/websoft9/appos/backend/domain/resource/servers"
 	"github.com/websoft9/appos/backend/domain/terminal"
 )
 
 func registerServerShellRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 	g.GET("/ssh/{serverId}", handleSSHTerminal)
+	g.GET("/recordings/{sessionId}", handleSSHRecordingDownload)
+}
+
+const (
+	// sshRecordingMaxBytes caps the terminal output kept in one recording.
+	sshRecordingMaxBytes = 100 << 20
+	// sshRecordingPrefix is the storage directory for session recordings.
+	sshRecordingPrefix = "terminal_recordings"
+)
+
+// parseTerminalSessionEnv validates the ?env= variables a terminal client
+// asks for against connect/terminal sessionEnvAllowlist.
+func parseTerminalSessionEnv(app core.App, raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	cfg, _ := sysconfig.GetGroup(app, "connect", "terminal", nil)
+	return servers.ParseClientSessionEnv(raw, sysconfig.StringSlice(cfg, "sessionEnvAllowlist"))
+}
+
+// sshRecordingKey is the storage key of the recording for sessionID.
+func sshRecordingKey(sessionID string) string {
+	return sshRecordingPrefix + "/" + sessionID + ".cast"
 }
 
 // handleSSHTerminal upgrades the HTTP connection to a WebSocket SSH PTY session for the given server.
 //
+// Besides shell output the client receives JSON control frames: a
+// {"type":"banner","message":...} frame right after connect when the server's
+// terminal_banner or connect/terminal banner is set, and a
+// {"type":"timeout","reason":...} frame before the session is closed for
+// exceeding connect/terminal idleTimeoutSeconds or maxSessionSeconds (audited
+// as terminal.ssh.timeout). A recording's key is carried by the disconnect
+// audit entry and the cast is served by /api/terminal/recordings/{sessionId}.
+//
 // @Summary SSH WebSocket terminal
 // @Description Upgrades to a WebSocket PTY session for the given server via SSH. Auth via ?token= or Authorization header. Superuser only.
 // @Tags Terminal SSH
 // @Security BearerAuth
 // @Param serverId path string true "server record ID"
 // @Param token query string false "auth token (for WebSocket clients that cannot set headers)"
+// @Param Sec-WebSocket-Protocol header string false "appos-terminal-v1 for JSON control in text frames and raw data in binary frames; appos-terminal-legacy or none for 0x00-prefixed binary control frames"
+// @Param record query bool false "record the session output and resizes as an asciinema v2 cast (first 100 MB of output)"
+// @Param env query string false "JSON object of variables exported before the prompt, overriding the server's env; names must match connect/terminal sessionEnvAllowlist (a trailing * matches a prefix), at most 16 with values up to 1024 bytes and no control characters, e.g. {\"APPOS_APP\":\"wordpress\"}"
 // @Success 101 {string} string "WebSocket upgrade"
 // @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
+// @Failure 429 {object} map[string]any "server already has connect/terminal maxSessionsPerServer SSH/SFTP sessions open"
 // @Router /api/terminal/ssh/{serverId} [get]
 func handleSSHTerminal(e *core.RequestEvent) error {
 	serverID := e.Request.PathValue("serverId")
+	record := false
+	if raw := e.Request.URL.Query().Get("record"); raw != "" {
+		var err error
+		if record, err = strconv.ParseBool(raw); err != nil {
+			return e.JSON(http.StatusBadRequest, map[string]any{"message": "record must be a boolean"})
+		}
+	}
+	sessionEnv, err := parseTerminalSessionEnv(e.App, e.Request.URL.Query().Get("env"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
 	cfg, err := resolveTerminalConfig(e.App, e.Auth, serverID)
 	if err != nil {
 		log.Printf("[server-shell] resolveServerConfig failed serverId=%s err=%v", serverID, err)
-		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return serverConfigError(e, err)
+	}
+	if len(sessionEnv) > 0 {
+		cfg.Env = maps.Clone(cfg.Env)
+		if cfg.Env == nil {
+			cfg.Env = make(map[string]string, len(sessionEnv))
+		}
+		maps.Copy(cfg.Env, sessionEnv)
 	}
+	release, err := acquireServerSession(e.App, serverID)
+	if err != nil {
+		return serverSessionError(e, err)
+	}
+	defer release()
 
-	conn, err := wsUpgrader.Upgrade(e.Response, e.Request, nil)
+	ws, err := upgradeTerminalWS(e.Response, e.Request)
 	if err != nil {
 		log.Printf("[server-shell] websocket upgrade failed serverId=%s err=%v", serverID, err)
 		return nil
 	}
-	defer conn.Close()
+	defer ws.Close()
 
 	connector := &terminal.SSHConnector{}
 	sess, err := connector.Connect(e.Request.Context(), cfg)
 	if err != nil {
 		log.Printf("[server-shell] ssh connect failed serverId=%s host=%s port=%d user=%s authType=%s err=%v", serverID, cfg.Host, cfg.Port, cfg.User, cfg.AuthType, err)
-		closeWSWithError(conn, err)
+		closeWSWithError(ws, err)
 		return nil
 	}
+	detectServerPlatformOnFirstConnect(e.App, serverID, cfg)
+	banner := terminalBanner(e.App, serverID)
+	if banner != "" {
+		sendTerminalBanner(ws, banner)
+	}
+
+	var recorder *terminal.CastRecorder
+	if record {
+		if recorder, err = terminal.NewCastRecorder(sshRecordingMaxBytes); err != nil {
+			_ = sess.Close()
+			closeWSWithError(ws, err)
+			return nil
+		}
+		defer recorder.Close()
+		sess = terminal.RecordSession(sess, recorder)
+	}
 
 	sessionID := uuid.NewString()
 	userID, _, ip, _ := clientInfo(e)
 	startedAt := time.Now().UTC()
 	var bytesOut, bytesIn atomic.Int64
 
-	terminal.Register(sessionID, sess)
+	expiry := &terminalExpiry{ws: ws}
+	terminal.Register(sessionID, sess, terminal.ConnectionMeta{
+		Type:     terminal.ConnectionSSH,
+		UserID:   userID,
+		Target:   serverID,
+		ServerID: serverID,
+		BytesIn:  &bytesIn,
+		BytesOut: &bytesOut,
+		OnExpire: expiry.notify,
+	})
 	defer func() {
 		terminal.Unregister(sessionID)
 		_ = sess.Close()
+		detail := map[string]any{
+			"session_id": sessionID,
+			"started_at": startedAt.Format(time.RFC3339),
+			"ended_at":   time.Now().UTC().Format(time.RFC3339),
+			"bytes_in":   bytesIn.Load(),
+			"bytes_out":  bytesOut.Load(),
+		}
+		if recorder != nil {
+			if err := saveSSHRecording(e.App, sessionID, recorder); err != nil {
+				log.Printf("[server-shell] save recording failed serverId=%s sessionId=%s err=%v", serverID, sessionID, err)
+				detail["recording_error"] = err.Error()
+			} else {
+				detail["recording_key"] = sshRecordingKey(sessionID)
+				detail["recording_truncated"] = recorder.Truncated()
+			}
+		}
+		action := "terminal.ssh.disconnect"
+		if reason := expiry.Reason(); reason != "" {
+			action = "terminal.ssh.timeout"
+			detail["reason"] = reason
+		}
 		audit.Write(e.App, audit.Entry{
 			UserID:       userID,
-			Action:       "terminal.ssh.disconnect",
+			Action:       action,
 			ResourceType: "server",
 			ResourceID:   serverID,
 			Status:       audit.StatusSuccess,
 			IP:           ip,
-			Detail: map[string]any{
-				"session_id": sessionID,
-				"started_at": startedAt.Format(time.RFC3339),
-				"ended_at":   time.Now().UTC().Format(time.RFC3339),
-				"bytes_in":   bytesIn.Load(),
-				"bytes_out":  bytesOut.Load(),
-			},
+			Detail:       detail,
 		})
 	}()
 
+	connectDetail := map[string]any{"session_id": sessionID, "record": record, "banner": banner != ""}
+	if len(sessionEnv) > 0 {
+		connectDetail["env"] = slices.Sorted(maps.Keys(sessionEnv))
+	}
 	audit.Write(e.App, audit.Entry{
 		UserID:       userID,
 		Action:       "terminal.ssh.connect",
@@ -89,7 +202,7 @@ func handleSSHTerminal(e *core.RequestEvent) error {
 		ResourceID:   serverID,
 		Status:       audit.StatusSuccess,
 		IP:           ip,
-		Detail:       map[string]any{"session_id": sessionID},
+		Detail:       connectDetail,
 	})
 
 	done := make(chan struct{})
@@ -104,7 +217,7 @@ func handleSSHTerminal(e *core.RequestEvent) error {
 				break
 			}
 			bytesOut.Add(int64(n))
-			if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+			if err := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
 				log.Printf("[server-shell] websocket write failed serverId=%s sessionId=%s err=%v", serverID, sessionID, err)
 				break
 			}
@@ -114,15 +227,15 @@ func handleSSHTerminal(e *core.RequestEvent) error {
 	go func() {
 		defer func() { _ = sess.Close() }() // unblock Read goroutine on client disconnect
 		for {
-			mt, msg, err := conn.ReadMessage()
+			control, msg, err := ws.readFrame()
 			if err != nil {
 				log.Printf("[server-shell] websocket read closed serverId=%s sessionId=%s err=%v", serverID, sessionID, err)
 				break
 			}
 			terminal.Touch(sessionID)
 
-			if mt == websocket.TextMessage || (len(msg) > 0 && msg[0] == 0x00) {
-				handleControlFrame(sess, msg)
+			if control {
+				handleControlFrame(ws, ws.framing, sess, msg)
 				continue
 			}
 			bytesIn.Add(int64(len(msg)))
@@ -137,50 +250,168 @@ func handleSSHTerminal(e *core.RequestEvent) error {
 	return nil
 }
 
-func handleControlFrame(sess terminal.Session, raw []byte) {
-	if len(raw) > 0 && raw[0] == 0x00 {
-		raw = raw[1:]
+// saveSSHRecording uploads the finished recording of sessionID to the app
+// filesystem under sshRecordingKey.
+func saveSSHRecording(app core.App, sessionID string, recorder *terminal.CastRecorder) error {
+	spool, err := os.CreateTemp("", "appos-cast-upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	_, err = recorder.WriteTo(spool)
+	if closeErr := spool.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	file, err := filesystem.NewFileFromPath(spool.Name())
+	if err != nil {
+		return err
+	}
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+	return fsys.UploadFile(file, sshRecordingKey(sessionID))
+}
+
+// handleSSHRecordingDownload serves the recording of an SSH session.
+//
+// @Summary Download SSH session recording
+// @Description Returns the asciinema v2 cast recorded for an SSH session opened with record=true. The session ID is the one in the terminal.ssh.connect and terminal.ssh.disconnect audit entries. Each download is audited. Superuser only.
+// @Tags Terminal SSH
+// @Security BearerAuth
+// @Param sessionId path string true "terminal session ID"
+// @Success 200 {string} string "asciinema v2 cast"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/terminal/recordings/{sessionId} [get]
+func handleSSHRecordingDownload(e *core.RequestEvent) error {
+	sessionID := e.Request.PathValue("sessionId")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": "invalid session id"})
+	}
+	fsys, err := e.App.NewFilesystem()
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"message": "storage unavailable"})
+	}
+	defer fsys.Close()
+
+	key := sshRecordingKey(sessionID)
+	reader, err := fsys.GetReader(key)
+	if err != nil {
+		return e.JSON(http.StatusNotFound, map[string]any{"message": "recording not found"})
 	}
+	defer reader.Close()
+
+	userID, _, ip, _ := clientInfo(e)
+	audit.Write(e.App, audit.Entry{
+		UserID:       userID,
+		Action:       "terminal.ssh.recording.download",
+		ResourceType: "terminal_session",
+		ResourceID:   sessionID,
+		Status:       audit.StatusSuccess,
+		IP:           ip,
+		Detail:       map[string]any{"recording_key": key},
+	})
+
+	e.Response.Header().Set("Content-Type", "application/x-asciicast")
+	e.Response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sessionID+".cast"))
+	e.Response.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(e.Response, reader)
+	return nil
+}
+
+const (
+	// maxControlFrameBytes bounds control frames while they are read, before
+	// they are JSON-decoded. A resize message is ~40 bytes; anything much
+	// larger is malformed or abusive.
+	maxControlFrameBytes = 1024
+	// maxTerminalFrameBytes is the WebSocket read limit, which bounds data
+	// frames such as a large paste; the connection is closed beyond it.
+	maxTerminalFrameBytes = 1 << 20
+	// maxTerminalRows and maxTerminalCols clamp resize requests to sane PTY sizes.
+	maxTerminalRows = 1000
+	maxTerminalCols = 1000
+)
+
+// wsMessageWriter is the subset of *websocket.Conn used to emit control frames.
+type wsMessageWriter interface {
+	WriteMessage(messageType int, data []byte) error
+}
+
+// wsConn serialises data-frame writes on a terminal WebSocket. The PTY output
+// pump and the control-frame handler write from different goroutines, and
+// gorilla/websocket supports only one concurrent writer. framing is the
+// contract negotiated via the WebSocket subprotocol.
+type wsConn struct {
+	*websocket.Conn
+	mu      sync.Mutex
+	framing terminalFraming
+}
+
+func (c *wsConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// handleControlFrame applies an out-of-band control message (currently only
+// "resize") to the session. Malformed or out-of-range frames are rejected
+// with an error control frame instead of reaching the PTY; oversized ones
+// never get here (see readFrame).
+func handleControlFrame(w wsMessageWriter, f terminalFraming, sess terminal.Session, raw []byte) {
+	raw = f.controlPayload(raw)
 	var ctrl struct {
 		Type string `json:"type"`
-		Rows uint16 `json:"rows"`
-		Cols uint16 `json:"cols"`
+		Rows int    `json:"rows"`
+		Cols int    `json:"cols"`
 	}
 	if err := json.Unmarshal(raw, &ctrl); err != nil {
+		_ = writeWSControl(w, f, "error", "invalid control frame")
 		return
 	}
-	if ctrl.Type == "resize" && ctrl.Rows > 0 && ctrl.Cols > 0 {
-		_ = sess.Resize(ctrl.Rows, ctrl.Cols)
+	if ctrl.Type != "resize" {
+		return
+	}
+	if ctrl.Rows <= 0 || ctrl.Cols <= 0 {
+		_ = writeWSControl(w, f, "error", "invalid resize: rows and cols must be positive")
+		return
 	}
+	rows := min(ctrl.Rows, maxTerminalRows)
+	cols := min(ctrl.Cols, maxTerminalCols)
+	_ = sess.Resize(uint16(rows), uint16(cols))
 }
 
-func writeWSControl(conn *websocket.Conn, msgType, message string) error {
+func writeWSControl(w wsMessageWriter, f terminalFraming, msgType, message string) error {
 	ctrl := map[string]string{"type": msgType, "message": message}
 	data, _ := json.Marshal(ctrl)
-	payload := append([]byte{0x00}, data...)
-	return conn.WriteMessage(websocket.BinaryMessage, payload)
+	return w.WriteMessage(f.encodeControl(data))
 }
 
 // writeWSConnectError sends a structured error control frame with category.
-func writeWSConnectError(conn *websocket.Conn, ce *terminal.ConnectError) error {
+func writeWSConnectError(w wsMessageWriter, f terminalFraming, ce *terminal.ConnectError) error {
 	ctrl := map[string]string{
 		"type":     "error",
 		"category": string(ce.Category),
 		"message":  ce.Message,
 	}
 	data, _ := json.Marshal(ctrl)
-	payload := append([]byte{0x00}, data...)
-	return conn.WriteMessage(websocket.BinaryMessage, payload)
+	return w.WriteMessage(f.encodeControl(data))
 }
 
-func closeWSWithError(conn *websocket.Conn, err error) {
+func closeWSWithError(ws *wsConn, err error) {
 	var ce *terminal.ConnectError
 	if errors.As(err, &ce) {
-		_ = writeWSConnectError(conn, ce)
+		_ = writeWSConnectError(ws, ws.framing, ce)
 	} else {
-		_ = writeWSControl(conn, "error", err.Error())
+		_ = writeWSControl(ws, ws.framing, "error", err.Error())
 	}
-	_ = conn.WriteControl(
+	_ = ws.WriteControl(
 		websocket.CloseMessage,
 		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, truncateCloseReason(err.Error())),
 		time.Now().Add(2*time.Second),
@@ -190,6 +421,56 @@ func closeWSWithError(conn *websocket.Conn, err error) {
 	time.Sleep(75 * time.Millisecond)
 }
 
+// terminalExpiry tells a terminal WebSocket client why the session janitor
+// closed its session and remembers the reason for the disconnect audit entry.
+// notify is the session's ConnectionMeta.OnExpire.
+type terminalExpiry struct {
+	ws     *wsConn
+	reason atomic.Value
+}
+
+func (x *terminalExpiry) notify(reason string) {
+	x.reason.Store(reason)
+	message := "session closed after the idle timeout"
+	if reason == terminal.ExpiredMaxDuration {
+		message = "session closed after reaching its maximum duration"
+	}
+	ctrl := map[string]string{"type": "timeout", "reason": reason, "message": message}
+	data, _ := json.Marshal(ctrl)
+	_ = x.ws.WriteMessage(x.ws.framing.encodeControl(data))
+	_ = x.ws.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, message),
+		time.Now().Add(2*time.Second),
+	)
+}
+
+// Reason returns terminal.ExpiredIdle or terminal.ExpiredMaxDuration when
+// the janitor closed the session, or "" when it ended any other way.
+func (x *terminalExpiry) Reason() string {
+	reason, _ := x.reason.Load().(string)
+	return reason
+}
+
+// terminalBanner returns the banner shown when an SSH terminal to serverID
+// opens: the server's terminal_banner, else connect/terminal banner.
+func terminalBanner(app core.App, serverID string) string {
+	if record, err := app.FindRecordById("servers", serverID); err == nil {
+		if banner := strings.TrimSpace(record.GetString("terminal_banner")); banner != "" {
+			return banner
+		}
+	}
+	cfg, _ := sysconfig.GetGroup(app, "connect", "terminal", nil)
+	banner, _ := cfg["banner"].(string)
+	return strings.TrimSpace(banner)
+}
+
+// sendTerminalBanner sends banner as a {"type":"banner"} control frame.
+func sendTerminalBanner(ws *wsConn, banner string) {
+	data, _ := json.Marshal(map[string]string{"type": "banner", "message": banner})
+	_ = ws.WriteMessage(ws.framing.encodeControl(data))
+}
+
 // truncateCloseReason ensures the WS close reason fits within the 123-byte limit.
 func truncateCloseReason(s string) string {
 	if len(s) <= 123 {
@@ -206,26 +487,39 @@ func registerLocalTerminalRoutes(g *router.RouterGroup[*core.RequestEvent]) {
 
 // handleLocalTerminal upgrades the connection to a WebSocket PTY session on the local host.
 //
+// A session idle for connect/terminal idleTimeoutSeconds or open longer than
+// maxSessionSeconds is closed after a {"type":"timeout","reason":...} control
+// frame and audited as terminal.local.timeout.
+//
 // @Summary Local WebSocket terminal
 // @Description Upgrades to a WebSocket PTY session on the local server. Auth via ?token= or Authorization header. Superuser only.
 // @Tags Terminal Local
 // @Security BearerAuth
+// @Param token query string false "auth token (for WebSocket clients that cannot set headers)"
+// @Param Sec-WebSocket-Protocol header string false "appos-terminal-v1 for JSON control in text frames and raw data in binary frames; appos-terminal-legacy or none for 0x00-prefixed binary control frames"
+// @Param env query string false "JSON object of variables added to the shell's environment, overriding the AppOS process env; names must match connect/terminal sessionEnvAllowlist (a trailing * matches a prefix), at most 16 with values up to 1024 bytes and no control characters, e.g. {\"APPOS_APP\":\"wordpress\"}"
 // @Success 101 {string} string "WebSocket upgrade"
+// @Failure 400 {object} map[string]any
 // @Failure 401 {object} map[string]any
 // @Router /api/terminal/local [get]
 func handleLocalTerminal(e *core.RequestEvent) error {
-	conn, err := wsUpgrader.Upgrade(e.Response, e.Request, nil)
+	sessionEnv, err := parseTerminalSessionEnv(e.App, e.Request.URL.Query().Get("env"))
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]any{"message": err.Error()})
+	}
+
+	ws, err := upgradeTerminalWS(e.Response, e.Request)
 	if err != nil {
 		log.Printf("[terminal-local] websocket upgrade failed err=%v", err)
 		return nil
 	}
-	defer conn.Close()
+	defer ws.Close()
 
 	connector := &terminal.LocalConnector{}
-	sess, err := connector.Connect(e.Request.Context(), terminal.ConnectorConfig{})
+	sess, err := connector.Connect(e.Request.Context(), terminal.ConnectorConfig{Env: sessionEnv})
 	if err != nil {
 		log.Printf("[terminal-local] local session start failed err=%v", err)
-		closeWSWithError(conn, err)
+		closeWSWithError(ws, err)
 		return nil
 	}
 
@@ -234,27 +528,45 @@ func handleLocalTerminal(e *core.RequestEvent) error {
 	startedAt := time.Now().UTC()
 	var bytesOut, bytesIn atomic.Int64
 
-	terminal.Register(sessionID, sess)
+	expiry := &terminalExpiry{ws: ws}
+	terminal.Register(sessionID, sess, terminal.ConnectionMeta{
+		Type:     terminal.ConnectionLocal,
+		UserID:   userID,
+		Target:   "local",
+		BytesIn:  &bytesIn,
+		BytesOut: &bytesOut,
+		OnExpire: expiry.notify,
+	})
 	defer func() {
 		terminal.Unregister(sessionID)
 		_ = sess.Close()
+		detail := map[string]any{
+			"session_id": sessionID,
+			"started_at": startedAt.Format(time.RFC3339),
+			"ended_at":   time.Now().UTC().Format(time.RFC3339),
+			"bytes_in":   bytesIn.Load(),
+			"bytes_out":  bytesOut.Load(),
+		}
+		action := "terminal.local.disconnect"
+		if reason := expiry.Reason(); reason != "" {
+			action = "terminal.local.timeout"
+			detail["reason"] = reason
+		}
 		audit.Write(e.App, audit.Entry{
 			UserID:       userID,
-			Action:       "terminal.local.disconnect",
+			Action:       action,
 			ResourceType: "system",
 			ResourceID:   "local",
 			Status:       audit.StatusSuccess,
 			IP:           ip,
-			Detail: map[string]any{
-				"session_id": sessionID,
-				"started_at": startedAt.Format(time.RFC3339),
-				"ended_at":   time.Now().UTC().Format(time.RFC3339),
-				"bytes_in":   bytesIn.Load(),
-				"bytes_out":  bytesOut.Load(),
-			},
+			Detail:       detail,
 		})
 	}()
 
+	connectDetail := map[string]any{"session_id": sessionID}
+	if len(sessionEnv) > 0 {
+		connectDetail["env"] = slices.Sorted(maps.Keys(sessionEnv))
+	}
 	audit.Write(e.App, audit.Entry{
 		UserID:       userID,
 		Action:       "terminal.local.connect",
@@ -262,7 +574,7 @@ func handleLocalTerminal(e *core.RequestEvent) error {
 		ResourceID:   "local",
 		Status:       audit.StatusSuccess,
 		IP:           ip,
-		Detail:       map[string]any{"session_id": sessionID},
+		Detail:       connectDetail,
 	})
 
 	done := make(chan struct{})
@@ -276,7 +588,7 @@ func handleLocalTerminal(e *core.RequestEvent) error {
 				break
 			}
 			bytesOut.Add(int64(n))
-			if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+			if err := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
 				log.Printf("[terminal-local] websocket write failed sessionId=%s err=%v", sessionID, err)
 				break
 			}
@@ -286,14 +598,14 @@ func handleLocalTerminal(e *core.RequestEvent) error {
 	go func() {
 		defer func() { _ = sess.Close() }()
 		for {
-			mt, msg, err := conn.ReadMessage()
+			control, msg, err := ws.readFrame()
 			if err != nil {
 				log.Printf("[terminal-local] websocket read closed sessionId=%s err=%v", sessionID, err)
 				break
 			}
 			terminal.Touch(sessionID)
-			if mt == websocket.TextMessage || (len(msg) > 0 && msg[0] == 0x00) {
-				handleControlFrame(sess, msg)
+			if control {
+				handleControlFrame(ws, ws.framing, sess, msg)
 				continue
 			}
 			bytesIn.Add(int64(len(msg)))
@@ -28,6 +28,10 @@ var tunnelSessions *tunnelcore.Registry
 // on create/rotate.  Thread-safe via sync.Map.
 var tunnelTokenCache sync.Map
 
+// tunnelPortPool is the running tunnel server's port pool, set by
+// startTunnelRuntime. System reload applies tunnel/port_range changes to it.
+var tunnelPortPool *tunnelcore.PortPool
+
 type tunnelForwardsRequest struct {
 	Forwards []tunnelForwardBody `json:"forwards"`
 }
@@ -84,7 +88,7 @@ func registerTunnelRoutes(se *core.ServeEvent) {
 
 func startTunnelRuntime(se *core.ServeEvent) {
 	tunnelSessions = tunnelcore.NewRegistry()
-	tunnelpb.Start(
+	tunnelPortPool = tunnelpb.Start(
 		se.App,
 		tunnelSessions,
 		&tunnelTokenCache,
@@ -104,6 +108,7 @@ func tunnelService(app core.App) serversvc.TunnelService {
 		Sessions:  tunnelSessions,
 		Tokens:    tokens,
 		Validator: validator,
+		PortRange: tunnelpb.LoadPortRange(app),
 	}
 }
 
@@ -127,9 +132,15 @@ func registerAuthenticatedTunnelRoutes(se *core.ServeEvent) {
 	t.GET("/servers/{id}/setup", func(e *core.RequestEvent) error {
 		return handleTunnelSetup(e)
 	})
+	t.GET("/servers/{id}/info", func(e *core.RequestEvent) error {
+		return handleTunnelInfo(e)
+	})
 	t.GET("/servers/{id}/status", func(e *core.RequestEvent) error {
 		return handleTunnelStatus(e)
 	})
+	t.GET("/servers/{id}/status/stream", func(e *core.RequestEvent) error {
+		return handleTunnelStatusStream(e)
+	})
 	t.GET("/servers/{id}/forwards", func(e *core.RequestEvent) error {
 		return handleTunnelForwards(e)
 	})
@@ -139,6 +150,9 @@ func registerAuthenticatedTunnelRoutes(se *core.ServeEvent) {
 	t.GET("/servers/{id}/logs", func(e *core.RequestEvent) error {
 		return handleTunnelLogs(e)
 	})
+	t.GET("/status", func(e *core.RequestEvent) error {
+		return handleTunnelStatusAll(e)
+	})
 	t.GET("/overview", func(e *core.RequestEvent) error {
 		return handleTunnelOverview(e)
 	})
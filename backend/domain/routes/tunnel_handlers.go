@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"sync"
@@ -14,6 +16,8 @@ import (
 	"github.com/websoft9/appos/backend/domain/audit"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
 	serversvc "github.com/websoft9/appos/backend/domain/resource/servers/service"
+	"github.com/websoft9/appos/backend/domain/terminal"
+	"github.com/websoft9/appos/backend/infra/tunnelcore"
 )
 
 // setupScriptLimiters is an IP-based rate limiter for the unauthenticated
@@ -190,6 +194,52 @@ func handleTunnelSetup(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, setup)
 }
 
+// ─────────────────────────────────────────────────────────────────────────────
+// GET /api/tunnel/servers/:id/info
+// ─────────────────────────────────────────────────────────────────────────────
+
+// handleTunnelInfo returns the consolidated reconnect view of a tunnel server.
+// The token is masked unless ?reveal=true; revealing it is audited.
+//
+// @Summary Get tunnel server info
+// @Description Returns everything needed to (re)connect a tunnel server in one response: token (masked unless reveal=true), autossh command, systemd unit, setup script URL (revealed only), desired forwards, current status with assigned service ports, SSH port and the tunnel host-key SHA256 fingerprint. Servers without a token report token_issued=false. Superuser only.
+// @Tags Tunnel
+// @Security BearerAuth
+// @Param id path string true "server record ID"
+// @Param reveal query boolean false "set true to return the full token and setup script URL"
+// @Success 200 {object} map[string]any "token_issued, token_masked, token, autossh_cmd, systemd_unit, setup_script_url, forwards, status, ssh_port, host_key_fingerprint"
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/tunnel/servers/{id}/info [get]
+func handleTunnelInfo(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	reveal := e.Request.URL.Query().Get("reveal") == "true"
+
+	info, err := tunnelService(e.App).Info(id, resolveApposHost(e), tunnelSSHPort(), reveal)
+	if mapped := tunnelServiceServerError(e, err); mapped != err {
+		return mapped
+	}
+	if err != nil {
+		return e.InternalServerError("failed to load tunnel info", err)
+	}
+
+	if reveal && info.TokenIssued {
+		userID, _, ip, _ := clientInfo(e)
+		audit.Write(e.App, audit.Entry{
+			UserID:       userID,
+			Action:       serversvc.ActionTunnelTokenRevealed,
+			ResourceType: "server",
+			ResourceID:   id,
+			Status:       audit.StatusSuccess,
+			IP:           ip,
+		})
+	}
+
+	return e.JSON(http.StatusOK, info)
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // GET /api/tunnel/servers/:id/forwards
 // ─────────────────────────────────────────────────────────────────────────────
@@ -346,11 +396,11 @@ func handleTunnelResume(e *core.RequestEvent) error {
 // Falls back to the DB value when the server is offline.
 //
 // @Summary Get tunnel status
-// @Description Returns live tunnel connection status for a server (online/offline, services, last seen). Superuser only.
+// @Description Returns live tunnel connection status for a server (online/offline, services, last seen). When the client runs a tunnel agent, the latest agent report (hostname, health, metrics) is included as agent. Superuser only.
 // @Tags Tunnel
 // @Security BearerAuth
 // @Param id path string true "server record ID"
-// @Success 200 {object} map[string]any "status, services, connected_at/last_seen"
+// @Success 200 {object} map[string]any "status, services, connected_at/last_seen, agent"
 // @Failure 401 {object} map[string]any
 // @Failure 404 {object} map[string]any
 // @Router /api/tunnel/servers/{id}/status [get]
@@ -368,6 +418,118 @@ func handleTunnelStatus(e *core.RequestEvent) error {
 	return e.JSON(http.StatusOK, result)
 }
 
+// ─────────────────────────────────────────────────────────────────────────────
+// GET /api/tunnel/status
+// ─────────────────────────────────────────────────────────────────────────────
+
+// handleTunnelStatusAll returns live tunnel state for every tunnel server in
+// one call, so dashboards do not need a status request per server.
+//
+// @Summary List tunnel statuses
+// @Description Returns live tunnel status for all tunnel servers: online servers from the session registry (services, connected_at, agent), offline ones from the stored runtime fields (last_seen, services). port_capacity is reported once for the whole list. Superuser only.
+// @Tags Tunnel
+// @Security BearerAuth
+// @Success 200 {object} map[string]any "items, port_capacity"
+// @Failure 401 {object} map[string]any
+// @Router /api/tunnel/status [get]
+func handleTunnelStatusAll(e *core.RequestEvent) error {
+	result, err := tunnelService(e.App).StatusAll()
+	if err != nil {
+		return e.InternalServerError("failed to load tunnel status", err)
+	}
+	return e.JSON(http.StatusOK, result)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// GET /api/tunnel/servers/:id/status/stream
+// ─────────────────────────────────────────────────────────────────────────────
+
+// tunnelStatusHeartbeat keeps idle status streams open through proxies.
+const tunnelStatusHeartbeat = 15 * time.Second
+
+// handleTunnelStatusStream pushes tunnel status transitions as Server-Sent
+// Events by subscribing to the session registry.
+//
+// @Summary Watch tunnel status
+// @Description Streams Server-Sent Events for one tunnel server. A "status" event with the current status is sent first, followed by "connected" (services, port conflicts resolved at connect) and "disconnected" (reason) events as the tunnel session changes. Superuser only.
+// @Tags Tunnel
+// @Security BearerAuth
+// @Param id path string true "server record ID"
+// @Success 200 {string} string "SSE stream (text/event-stream)"
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/tunnel/servers/{id}/status/stream [get]
+func handleTunnelStatusStream(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	server, _, err := requireTunnelServer(e, id)
+	if err != nil {
+		return err
+	}
+
+	flusher, ok := e.Response.(http.Flusher)
+	if !ok {
+		return e.InternalServerError("streaming unsupported", nil)
+	}
+
+	// Subscribe before taking the snapshot so no transition is missed.
+	events, unsubscribe := tunnelSessions.Subscribe(id)
+	defer unsubscribe()
+
+	initial, err := tunnelService(e.App).Status(server)
+	if err != nil {
+		return e.InternalServerError("failed to load tunnel status", err)
+	}
+
+	var bytesOut atomic.Int64
+	ctx, untrack := trackStream(e, terminal.ConnectionMeta{
+		Type:     terminal.ConnectionTunnelStatus,
+		Target:   id,
+		ServerID: id,
+		BytesOut: &bytesOut,
+	}, nil)
+	defer untrack()
+
+	e.Response.Header().Set("Content-Type", "text/event-stream")
+	e.Response.Header().Set("Cache-Control", "no-cache")
+	e.Response.Header().Set("Connection", "keep-alive")
+
+	push := func(event string, payload any) {
+		b, _ := json.Marshal(payload)
+		n, _ := fmt.Fprintf(e.Response, "event: %s\ndata: %s\n\n", event, string(b))
+		bytesOut.Add(int64(n))
+		flusher.Flush()
+	}
+
+	push("status", initial)
+
+	heartbeat := time.NewTicker(tunnelStatusHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(e.Response, ": heartbeat\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			status := servers.TunnelStatusOnline
+			if event.Type == tunnelcore.SessionEventDisconnected {
+				status = servers.TunnelStatusOffline
+			}
+			push(string(event.Type), map[string]any{
+				"status":    string(status),
+				"services":  event.Services,
+				"conflicts": event.Conflicts,
+				"reason":    event.Reason,
+				"at":        event.At.Format(time.RFC3339),
+			})
+		}
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // GET /api/tunnel/overview
 // ─────────────────────────────────────────────────────────────────────────────
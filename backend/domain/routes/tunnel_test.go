@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"bufio"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/websoft9/appos/backend/domain/audit"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
+	serversvc "github.com/websoft9/appos/backend/domain/resource/servers/service"
 	appcrypto "github.com/websoft9/appos/backend/infra/crypto"
 	tunnelcore "github.com/websoft9/appos/backend/infra/tunnelcore"
 	tunnelpb "github.com/websoft9/appos/backend/infra/tunnelpb"
@@ -31,9 +33,11 @@ func (te *testEnv) doTunnel(t *testing.T, method, url, body string, authenticate
 	g.Bind(apis.RequireSuperuserAuth())
 	g.POST("/servers/{id}/token", func(e *core.RequestEvent) error { return handleTunnelToken(e) })
 	g.GET("/servers/{id}/setup", func(e *core.RequestEvent) error { return handleTunnelSetup(e) })
+	g.GET("/servers/{id}/info", func(e *core.RequestEvent) error { return handleTunnelInfo(e) })
 	g.GET("/servers/{id}/forwards", func(e *core.RequestEvent) error { return handleTunnelForwards(e) })
 	g.PUT("/servers/{id}/forwards", func(e *core.RequestEvent) error { return handleTunnelForwardsPut(e) })
 	g.GET("/servers/{id}/logs", func(e *core.RequestEvent) error { return handleTunnelLogs(e) })
+	g.GET("/status", func(e *core.RequestEvent) error { return handleTunnelStatusAll(e) })
 	g.GET("/overview", func(e *core.RequestEvent) error { return handleTunnelOverview(e) })
 	g.GET("/servers/{id}/session", func(e *core.RequestEvent) error { return handleTunnelSession(e) })
 	g.POST("/servers/{id}/disconnect", func(e *core.RequestEvent) error { return handleTunnelDisconnect(e) })
@@ -144,6 +148,93 @@ func TestTunnelOverviewReturnsEmptyCollections(t *testing.T) {
 	}
 }
 
+func TestTunnelOverviewReportsPortCapacity(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	tunnelSessions = tunnelcore.NewRegistry()
+	createTunnelServerRecord(t, te, "edge-1")
+	createTunnelServerRecord(t, te, "edge-2")
+
+	rec := te.doTunnel(t, http.MethodGet, "/api/tunnel/overview", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var payload struct {
+		Summary      map[string]int          `json:"summary"`
+		PortCapacity tunnelcore.PortCapacity `json:"port_capacity"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	capacity := payload.PortCapacity
+	if capacity.TotalPorts != 10000 || capacity.Servers != 2 || capacity.RequiredPorts != 4 {
+		t.Fatalf("unexpected port capacity: %+v", capacity)
+	}
+	if capacity.FreeServerSlots != 4998 || payload.Summary["free_server_slots"] != 4998 {
+		t.Fatalf("expected 4998 free server slots, got %+v summary=%v", capacity, payload.Summary)
+	}
+	if capacity.Level != tunnelcore.CapacityOK {
+		t.Fatalf("expected ok capacity level, got %s", capacity.Level)
+	}
+}
+
+func TestTunnelStatusAllCombinesRegistryAndStoredStatus(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	tunnelSessions = tunnelcore.NewRegistry()
+	online := createTunnelServerRecord(t, te, "edge-a")
+	offline := createTunnelServerRecord(t, te, "edge-b")
+	offline.Set("tunnel_status", "offline")
+	offline.Set("tunnel_last_seen", "2026-01-02 03:04:05.000Z")
+	if err := te.app.Save(offline); err != nil {
+		t.Fatal(err)
+	}
+	tunnelSessions.Register(online.Id, &tunnelcore.Session{
+		ClientID:    online.Id,
+		ConnectedAt: time.Now().UTC(),
+		Services:    []tunnelcore.Service{{Name: "ssh", LocalPort: 22, TunnelPort: 40010}},
+	})
+
+	if rec := te.doTunnel(t, http.MethodGet, "/api/tunnel/status", "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth, got %d", rec.Code)
+	}
+
+	rec := te.doTunnel(t, http.MethodGet, "/api/tunnel/status", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Items []struct {
+			ServerID    string           `json:"server_id"`
+			Name        string           `json:"name"`
+			Status      string           `json:"status"`
+			ConnectedAt string           `json:"connected_at"`
+			LastSeen    string           `json:"last_seen"`
+			Services    []map[string]any `json:"services"`
+		} `json:"items"`
+		PortCapacity tunnelcore.PortCapacity `json:"port_capacity"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if len(payload.Items) != 2 || payload.Items[0].Name != "edge-a" || payload.Items[1].Name != "edge-b" {
+		t.Fatalf("expected both servers ordered by name, got %+v", payload.Items)
+	}
+	a, b := payload.Items[0], payload.Items[1]
+	if a.ServerID != online.Id || a.Status != "online" || a.ConnectedAt == "" || len(a.Services) != 1 || a.Services[0]["tunnel_port"] != float64(40010) {
+		t.Fatalf("unexpected online entry: %+v", a)
+	}
+	if b.ServerID != offline.Id || b.Status != "offline" || b.LastSeen == "" {
+		t.Fatalf("unexpected offline entry: %+v", b)
+	}
+	if payload.PortCapacity.Servers != 2 {
+		t.Fatalf("expected port capacity across 2 servers, got %+v", payload.PortCapacity)
+	}
+}
+
 func TestTunnelSessionReturnsDisconnectReasonLabel(t *testing.T) {
 	te := newTestEnv(t)
 	defer te.cleanup()
@@ -310,6 +401,59 @@ func TestTunnelSetupUsesDesiredForwards(t *testing.T) {
 	}
 }
 
+func TestTunnelInfoMasksTokenUnlessRevealed(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	server := createTunnelServerRecord(t, te, "edge-info")
+	infoURL := "/api/tunnel/servers/" + server.Id + "/info"
+
+	rec := te.doTunnel(t, http.MethodGet, infoURL, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 before a token exists, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["token_issued"] != false || payload["status"] == nil {
+		t.Fatalf("expected status without a token, got %+v", payload)
+	}
+
+	const token = "info-token-0123456789"
+	createTunnelTokenSecret(t, te, server.Id, token)
+
+	rec = te.doTunnel(t, http.MethodGet, infoURL, "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); strings.Contains(body, token) {
+		t.Fatalf("expected token to be masked, got %s", body)
+	}
+	payload = nil
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["token_masked"] != true || payload["autossh_cmd"] == "" || payload["setup_script_url"] != nil {
+		t.Fatalf("unexpected masked info: %+v", payload)
+	}
+
+	rec = te.doTunnel(t, http.MethodGet, infoURL+"?reveal=true", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	payload = nil
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["token"] != token || payload["setup_script_url"] != "/tunnel/setup/"+token {
+		t.Fatalf("expected revealed token and setup script URL, got %+v", payload)
+	}
+	if _, err := te.app.FindFirstRecordByData("audit_logs", "action", serversvc.ActionTunnelTokenRevealed); err != nil {
+		t.Fatalf("expected token reveal to be audited: %v", err)
+	}
+}
+
 func TestTunnelPauseAndResumePersistPauseUntil(t *testing.T) {
 	te := newTestEnv(t)
 	defer te.cleanup()
@@ -806,3 +950,81 @@ func TestTunnelSetupScriptReturnsShellForPayloadBackedToken(t *testing.T) {
 		t.Fatalf("expected token in shell script, got %q", body)
 	}
 }
+
+func TestTunnelStatusStreamPushesTransitions(t *testing.T) {
+	te := newTestEnv(t)
+	defer te.cleanup()
+
+	tunnelSessions = tunnelcore.NewRegistry()
+	record := createTunnelServerRecord(t, te, "edge-stream")
+
+	r, err := apis.NewRouter(te.app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Group("/api/tunnel")
+	g.Bind(apis.RequireSuperuserAuth())
+	g.GET("/servers/{id}/status/stream", func(e *core.RequestEvent) error { return handleTunnelStatusStream(e) })
+	mux, err := r.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/tunnel/servers/"+record.Id+"/status/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", te.token)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.StatusCode)
+	}
+
+	lines := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	waitFor := func(want string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("stream closed before %q", want)
+				}
+				if strings.Contains(line, want) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", want)
+			}
+		}
+	}
+
+	waitFor("event: status")
+	sess := &tunnelcore.Session{
+		ClientID:    record.Id,
+		ConnectedAt: time.Now().UTC(),
+		Services:    []tunnelcore.Service{{Name: "ssh", LocalPort: 22, TunnelPort: 40002}},
+		Conflicts:   []tunnelcore.ConflictResolution{{ServiceName: "ssh", OldPort: 40001, NewPort: 40002}},
+	}
+	tunnelSessions.Register(record.Id, sess)
+	waitFor("event: connected")
+	waitFor(`"new_port":40002`)
+
+	sess.SetDisconnectReason(tunnelcore.DisconnectReasonOperatorDisconnect)
+	tunnelSessions.Unregister(record.Id)
+	waitFor("event: disconnected")
+	waitFor(`"reason":"operator_disconnect"`)
+}
@@ -0,0 +1,21 @@
+package savedcommands
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterHooks rejects saved command definitions whose template or
+// parameters could not be rendered safely.
+func RegisterHooks(app core.App) {
+	app.OnRecordValidate(Collection).BindFunc(func(e *core.RecordEvent) error {
+		params, err := ParseParams(e.Record.Get("params"))
+		if err != nil {
+			return validation.Errors{"params": validation.NewError("validation_invalid_params", err.Error())}
+		}
+		if err := Validate(e.Record.GetString("command"), params); err != nil {
+			return validation.Errors{"command": validation.NewError("validation_invalid_command", err.Error())}
+		}
+		return e.Next()
+	})
+}
@@ -0,0 +1,200 @@
+// Package savedcommands models superuser-approved command templates that
+// delegated users may run on servers instead of getting an open shell.
+package savedcommands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/terminal"
+)
+
+// Collection is the PocketBase collection holding saved commands.
+const Collection = "saved_commands"
+
+// DefaultTimeout applies when a command has no timeout_seconds.
+const DefaultTimeout = 60 * time.Second
+
+var (
+	ErrDisabled         = errors.New("saved command is disabled")
+	ErrNotAllowed       = errors.New("not allowed to run this saved command")
+	ErrServerNotAllowed = errors.New("saved command is not approved for this server")
+	ErrInvalidParams    = errors.New("invalid command parameters")
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	paramNamePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Param declares one placeholder of a command template. Pattern is a Go
+// regular expression every supplied value must match in full.
+type Param struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Description string `json:"description,omitempty"`
+}
+
+// Command is a read view over a saved_commands record.
+type Command struct {
+	rec *core.Record
+}
+
+// From wraps a saved_commands record.
+func From(rec *core.Record) *Command { return &Command{rec: rec} }
+
+func (c *Command) ID() string       { return c.rec.Id }
+func (c *Command) Name() string     { return c.rec.GetString("name") }
+func (c *Command) Template() string { return c.rec.GetString("command") }
+func (c *Command) Enabled() bool    { return c.rec.GetBool("enabled") }
+
+// Timeout returns the execution timeout, DefaultTimeout when unset.
+func (c *Command) Timeout() time.Duration {
+	if seconds := c.rec.GetInt("timeout_seconds"); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultTimeout
+}
+
+// Params decodes the declared parameters.
+func (c *Command) Params() ([]Param, error) {
+	return ParseParams(c.rec.Get("params"))
+}
+
+// CheckRunner reports whether auth may run the command: superusers always,
+// other users only when enabled and listed in allowed_users.
+func (c *Command) CheckRunner(auth *core.Record) error {
+	if !c.Enabled() {
+		return ErrDisabled
+	}
+	if auth == nil {
+		return ErrNotAllowed
+	}
+	if auth.IsSuperuser() {
+		return nil
+	}
+	if !slices.Contains(c.rec.GetStringSlice("allowed_users"), auth.Id) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// CheckServer reports whether the command may run on serverID. An empty
+// servers list approves the command for every server.
+func (c *Command) CheckServer(serverID string) error {
+	approved := c.rec.GetStringSlice("servers")
+	if len(approved) > 0 && !slices.Contains(approved, serverID) {
+		return ErrServerNotAllowed
+	}
+	return nil
+}
+
+// Render substitutes values into the template. Every placeholder must be
+// declared, every declared parameter supplied, and every value must match
+// its pattern; values are shell-quoted so they can never alter the
+// command's structure.
+func (c *Command) Render(values map[string]string) (string, error) {
+	params, err := c.Params()
+	if err != nil {
+		return "", err
+	}
+	return Render(c.Template(), params, values)
+}
+
+// Render is the record-free form of Command.Render.
+func Render(template string, params []Param, values map[string]string) (string, error) {
+	patterns, err := compileParams(template, params)
+	if err != nil {
+		return "", err
+	}
+	for name := range values {
+		if _, ok := patterns[name]; !ok {
+			return "", fmt.Errorf("%w: unknown parameter %q", ErrInvalidParams, name)
+		}
+	}
+	for name, pattern := range patterns {
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("%w: missing parameter %q", ErrInvalidParams, name)
+		}
+		if !pattern.MatchString(value) {
+			return "", fmt.Errorf("%w: %q does not match %s", ErrInvalidParams, name, pattern.String())
+		}
+	}
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		return terminal.ShellQuote(values[name])
+	}), nil
+}
+
+// Validate checks a command definition: a non-empty template, well-formed
+// unique parameter names with compilable patterns, and no undeclared
+// placeholders.
+func Validate(template string, params []Param) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("command is required")
+	}
+	_, err := compileParams(template, params)
+	return err
+}
+
+// ParseParams decodes the params JSON field.
+func ParseParams(raw any) ([]Param, error) {
+	var data []byte
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var params []Param
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("params must be a list of {name, pattern}: %w", err)
+	}
+	return params, nil
+}
+
+// compileParams anchors each parameter pattern and checks the template only
+// references declared parameters.
+func compileParams(template string, params []Param) (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp, len(params))
+	for _, p := range params {
+		if !paramNamePattern.MatchString(p.Name) {
+			return nil, fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if _, dup := patterns[p.Name]; dup {
+			return nil, fmt.Errorf("duplicate parameter %q", p.Name)
+		}
+		if strings.TrimSpace(p.Pattern) == "" {
+			return nil, fmt.Errorf("parameter %q needs a pattern", p.Name)
+		}
+		re, err := regexp.Compile(`^(?:` + p.Pattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: invalid pattern: %w", p.Name, err)
+		}
+		patterns[p.Name] = re
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := patterns[match[1]]; !ok {
+			return nil, fmt.Errorf("placeholder {{%s}} is not a declared parameter", match[1])
+		}
+	}
+	return patterns, nil
+}
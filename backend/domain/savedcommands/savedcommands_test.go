@@ -0,0 +1,55 @@
+package savedcommands
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenderQuotesValidatedValues(t *testing.T) {
+	params := []Param{
+		{Name: "unit", Pattern: `[a-z0-9-]+`},
+		{Name: "dir", Pattern: `/var/cache/[A-Za-z0-9 ._-]+`},
+	}
+	got, err := Render("systemctl restart {{unit}} && rm -rf {{ dir }}/*", params, map[string]string{
+		"unit": "nginx",
+		"dir":  "/var/cache/my app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "systemctl restart 'nginx' && rm -rf '/var/cache/my app'/*"; got != want {
+		t.Fatalf("Render = %q, want %q", got, want)
+	}
+
+	for name, values := range map[string]map[string]string{
+		"partial match": {"unit": "nginx; reboot", "dir": "/var/cache/x"},
+		"missing":       {"unit": "nginx"},
+		"unknown":       {"unit": "nginx", "dir": "/var/cache/x", "extra": "1"},
+	} {
+		if _, err := Render("systemctl restart {{unit}} {{dir}}", params, values); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: expected ErrInvalidParams, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateRejectsUnsafeDefinitions(t *testing.T) {
+	cases := map[string]struct {
+		template string
+		params   []Param
+	}{
+		"empty command":          {"  ", nil},
+		"undeclared placeholder": {"echo {{name}}", nil},
+		"missing pattern":        {"echo {{name}}", []Param{{Name: "name"}}},
+		"bad pattern":            {"echo {{name}}", []Param{{Name: "name", Pattern: "("}}},
+		"duplicate":              {"echo {{a}}", []Param{{Name: "a", Pattern: "x"}, {Name: "a", Pattern: "y"}}},
+		"bad name":               {"echo", []Param{{Name: "1a", Pattern: "x"}}},
+	}
+	for name, tc := range cases {
+		if err := Validate(tc.template, tc.params); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+	if err := Validate("uptime", nil); err != nil {
+		t.Errorf("plain command should validate: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+// Package scriptlint syntax-checks stored scripts without running them.
+//
+// Each language maps to a parse-only checker (bash -n, Python compile(),
+// node --check). The code is written to a private temp directory and the
+// checker runs there with a minimal environment, a timeout and capped output.
+package scriptlint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single check.
+const DefaultTimeout = 10 * time.Second
+
+// maxOutput caps the checker output kept for parsing and reporting.
+const maxOutput = 64 * 1024
+
+var (
+	ErrUnsupportedLanguage = errors.New("no syntax checker for this language")
+	ErrCheckerUnavailable  = errors.New("syntax checker is not installed")
+)
+
+// Issue is one syntax error. Line and Column are 1-based; 0 means unknown.
+type Issue struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of a check. OK is false when the checker reported
+// issues or failed without a parseable message, in which case Output holds
+// its raw output.
+type Result struct {
+	Language string  `json:"language"`
+	Checker  string  `json:"checker"`
+	OK       bool    `json:"ok"`
+	Issues   []Issue `json:"issues"`
+	Output   string  `json:"output,omitempty"`
+}
+
+// pythonCheck compiles the file without executing it or writing bytecode.
+const pythonCheck = `import sys
+path = sys.argv[1]
+with open(path, encoding="utf-8") as fh:
+    source = fh.read()
+try:
+    compile(source, path, "exec")
+except SyntaxError as err:
+    print("%d:%d:%s" % (err.lineno or 0, err.offset or 0, err.msg))
+    sys.exit(1)
+`
+
+type checker struct {
+	name  string
+	file  string
+	args  func(path string) []string
+	parse func(output, file string) []Issue
+}
+
+var checkers = map[string]checker{
+	"bash": {
+		name:  "bash -n",
+		file:  "script.sh",
+		args:  func(path string) []string { return []string{"bash", "-n", path} },
+		parse: parseBash,
+	},
+	"python3": {
+		name:  "python3 compile",
+		file:  "script.py",
+		args:  func(path string) []string { return []string{"python3", "-I", "-c", pythonCheck, path} },
+		parse: parsePython,
+	},
+	"node": {
+		name:  "node --check",
+		file:  "script.js",
+		args:  func(path string) []string { return []string{"node", "--check", path} },
+		parse: parseNode,
+	},
+}
+
+// Languages lists the languages that can be checked.
+func Languages() []string {
+	out := make([]string, 0, len(checkers))
+	for lang := range checkers {
+		out = append(out, lang)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Check syntax-checks code written in language. The error is non-nil only
+// when no check could be made; syntax problems are reported in the Result.
+func Check(ctx context.Context, language, code string) (Result, error) {
+	c, ok := checkers[language]
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %q", ErrUnsupportedLanguage, language)
+	}
+	args := c.args("")
+	bin, err := exec.LookPath(args[0])
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %s", ErrCheckerUnavailable, args[0])
+	}
+
+	dir, err := os.MkdirTemp("", "appos-lint-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, c.file)
+	if err := os.WriteFile(path, []byte(code), 0o600); err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	args = c.args(path)
+	// #nosec G204 -- the binary and flags come from the fixed checker table; only the temp file path varies.
+	cmd := exec.CommandContext(ctx, bin, args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "LANG=C.UTF-8", "PYTHONDONTWRITEBYTECODE=1"}
+	cmd.WaitDelay = time.Second
+	var out cappedBuffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	result := Result{Language: language, Checker: c.name, Issues: []Issue{}}
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return Result{}, fmt.Errorf("%s timed out: %w", c.name, ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return Result{}, fmt.Errorf("%s: %w", c.name, runErr)
+	}
+
+	output := strings.ReplaceAll(out.String(), path, c.file)
+	result.Issues = c.parse(output, c.file)
+	result.OK = runErr == nil && len(result.Issues) == 0
+	if !result.OK {
+		result.Output = strings.TrimSpace(output)
+	}
+	return result, nil
+}
+
+// bash -n: "script.sh: line 3: syntax error near unexpected token `fi'".
+var bashIssuePattern = regexp.MustCompile(`^.*?: line (\d+): (.*)$`)
+
+func parseBash(output, _ string) []Issue {
+	issues := []Issue{}
+	for _, line := range strings.Split(output, "\n") {
+		m := bashIssuePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		msg := strings.TrimSpace(m[2])
+		// bash echoes the offending source line as "`text'" after the error.
+		if strings.HasPrefix(msg, "`") && strings.HasSuffix(msg, "'") && len(issues) > 0 {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		issues = append(issues, Issue{Line: n, Message: msg})
+	}
+	return issues
+}
+
+func parsePython(output, _ string) []Issue {
+	issues := []Issue{}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		n, err1 := strconv.Atoi(parts[0])
+		col, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		issues = append(issues, Issue{Line: n, Column: col, Message: parts[2]})
+	}
+	return issues
+}
+
+// node --check prints "script.js:3", the source line, a caret line and
+// finally "SyntaxError: message".
+func parseNode(output, file string) []Issue {
+	issue := Issue{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, file+":"); ok && issue.Line == 0 {
+			issue.Line, _ = strconv.Atoi(rest)
+		}
+		if strings.HasPrefix(line, "SyntaxError:") {
+			issue.Message = strings.TrimSpace(strings.TrimPrefix(line, "SyntaxError:"))
+		}
+	}
+	if issue.Message == "" {
+		return []Issue{}
+	}
+	return []Issue{issue}
+}
+
+// cappedBuffer keeps the first maxOutput bytes written to it.
+type cappedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
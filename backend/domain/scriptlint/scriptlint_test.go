@@ -0,0 +1,68 @@
+package scriptlint
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func requireBinary(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not installed", name)
+	}
+}
+
+func TestCheckReportsLineNumbers(t *testing.T) {
+	cases := []struct {
+		language string
+		bin      string
+		bad      string
+		good     string
+		line     int
+	}{
+		{"bash", "bash", "echo start\nif true; then\n  echo x\nfi fi\n", "echo ok\n", 4},
+		{"python3", "python3", "import os\n\ndef f(:\n    pass\n", "print('ok')\n", 3},
+		{"node", "node", "const a = 1\nconst = 2\n", "console.log('ok')\n", 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.language, func(t *testing.T) {
+			requireBinary(t, tc.bin)
+
+			res, err := Check(context.Background(), tc.language, tc.good)
+			if err != nil || !res.OK || len(res.Issues) != 0 {
+				t.Fatalf("expected clean result, got %+v err=%v", res, err)
+			}
+
+			res, err = Check(context.Background(), tc.language, tc.bad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.OK || len(res.Issues) == 0 {
+				t.Fatalf("expected issues, got %+v", res)
+			}
+			if res.Issues[0].Line != tc.line || res.Issues[0].Message == "" {
+				t.Fatalf("expected issue on line %d, got %+v (output %q)", tc.line, res.Issues, res.Output)
+			}
+			if strings.Contains(res.Output, "appos-lint-") {
+				t.Fatalf("output leaks temp path: %q", res.Output)
+			}
+		})
+	}
+}
+
+func TestCheckDoesNotExecute(t *testing.T) {
+	requireBinary(t, "python3")
+	res, err := Check(context.Background(), "python3", "import sys\nsys.exit(3)\n")
+	if err != nil || !res.OK {
+		t.Fatalf("expected syntax-only check to pass, got %+v err=%v", res, err)
+	}
+}
+
+func TestCheckUnsupportedLanguage(t *testing.T) {
+	if _, err := Check(context.Background(), "cobol", "DISPLAY 'x'."); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Fatalf("expected ErrUnsupportedLanguage, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
+)
+
+// ReasonCodeDecryptFailed is the stable error code APIs return when a stored
+// credential cannot be decrypted.
+const ReasonCodeDecryptFailed = "CREDENTIAL_DECRYPT_FAILED"
+
+// ReasonDecryptFailed is the ResolveError reason for decrypt failures.
+const ReasonDecryptFailed = "credential decrypt failed"
+
+// ErrDecryptFailed matches every DecryptError via errors.Is.
+var ErrDecryptFailed = errors.New(ReasonDecryptFailed)
+
+// decryptFailures counts decrypt failures since process start. A spike
+// usually means a wrong APPOS_SECRET_KEY / APPOS_ENCRYPTION_KEY or corrupted
+// rows rather than a user error.
+var decryptFailures atomic.Int64
+
+// DecryptFailures returns the number of decrypt failures since process start.
+func DecryptFailures() int64 {
+	return decryptFailures.Load()
+}
+
+// DecryptError is a failed decrypt of a stored credential. It names where the
+// ciphertext came from, never the ciphertext itself.
+type DecryptError struct {
+	// Source identifies the caller, e.g. "secret.resolve" or "stepup.totp".
+	Source string
+	// ResourceID is the record whose ciphertext failed to decrypt.
+	ResourceID string
+	Cause      error
+}
+
+func (e *DecryptError) Error() string {
+	return fmt.Sprintf("%s: %s %s: %v", ReasonDecryptFailed, e.Source, e.ResourceID, e.Cause)
+}
+
+func (e *DecryptError) Unwrap() error { return e.Cause }
+
+func (e *DecryptError) Is(target error) bool { return target == ErrDecryptFailed }
+
+// NewDecryptError wraps a decrypt failure without reporting it. Use it where
+// no app is at hand or inside a transaction; report it once the caller can.
+func NewDecryptError(source, resourceID string, cause error) *DecryptError {
+	return &DecryptError{Source: source, ResourceID: resourceID, Cause: cause}
+}
+
+// ReportDecryptFailure counts, logs and audits err when it is a DecryptError
+// and returns it unchanged, so callers can write
+// `return secrets.ReportDecryptFailure(app, err)`.
+func ReportDecryptFailure(app core.App, err error) error {
+	var decryptErr *DecryptError
+	if !errors.As(err, &decryptErr) {
+		return err
+	}
+	decryptFailures.Add(1)
+	log.Printf("secrets: %s failed to decrypt %s: %v", decryptErr.Source, decryptErr.ResourceID, decryptErr.Cause)
+	audit.Write(app, audit.Entry{
+		UserID:       CreatedSourceSystem,
+		Action:       "secret.decrypt_failed",
+		ResourceType: "credential",
+		ResourceID:   decryptErr.ResourceID,
+		Status:       audit.StatusFailed,
+		Detail: map[string]any{
+			"source":      decryptErr.Source,
+			"reason_code": ReasonCodeDecryptFailed,
+			"error":       decryptErr.Cause.Error(),
+		},
+	})
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/audit"
 )
 
@@ -165,10 +166,15 @@ func RegisterHooks(app *pocketbase.PocketBase) {
 		id := s.ID()
 		err := e.Next()
 		if err == nil {
+			// archive.RegisterHooks may have archived the secret instead.
+			action := "secret.delete"
+			if archive.IsArchived(e.Record) {
+				action = "secret.archive"
+			}
 			audit.Write(app, audit.Entry{
 				UserID:       actorID(e.Auth),
 				UserEmail:    actorEmail(e.Auth),
-				Action:       "secret.delete",
+				Action:       action,
 				ResourceType: "secret",
 				ResourceID:   id,
 				ResourceName: name,
@@ -58,6 +58,7 @@ func ensureSecretsCollection(t *testing.T, app *tests.TestApp) {
 	col.Fields.Add(&core.TextField{Name: "status"})
 	col.Fields.Add(&core.TextField{Name: "value"})
 	col.Fields.Add(&core.TextField{Name: "payload_encrypted"})
+	col.Fields.Add(&core.DateField{Name: "archived_at"})
 	col.Fields.Add(&core.JSONField{Name: "payload_meta"})
 	col.Fields.Add(&core.NumberField{Name: "version"})
 	col.Fields.Add(&core.TextField{Name: "type"})
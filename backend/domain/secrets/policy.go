@@ -9,7 +9,6 @@ import (
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
-	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
 )
 
 const (
@@ -173,7 +172,7 @@ func GetPolicy(app core.App) Policy {
 	if app == nil {
 		return DefaultPolicy()
 	}
-	policy, _ := sysconfig.GetGroup(app, SettingsModule, PolicySettingsKey, settingscatalog.DefaultGroup(SettingsModule, PolicySettingsKey))
+	policy, _ := sysconfig.GetGroup(app, SettingsModule, PolicySettingsKey, nil)
 	return NormalizePolicy(policy)
 }
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	"github.com/websoft9/appos/backend/domain/audit"
 )
 
@@ -39,6 +40,7 @@ func ExtractSecretID(v string) (string, bool) {
 const (
 	ReasonNotFound     = "secret not found"
 	ReasonRevoked      = "secret has been revoked"
+	ReasonArchived     = "secret has been archived"
 	ReasonExpired      = "secret has expired"
 	ReasonAccessDenied = "access denied: secret is private to another user"
 )
@@ -94,6 +96,9 @@ func Resolve(app core.App, secretID, userID string) (*ResolveResult, error) {
 	if s.IsRevoked() {
 		return nil, &ResolveError{SecretID: secretID, Reason: ReasonRevoked}
 	}
+	if archive.IsArchived(rec) {
+		return nil, &ResolveError{SecretID: secretID, Reason: ReasonArchived}
+	}
 	if s.IsExpired() {
 		return nil, &ResolveError{SecretID: secretID, Reason: ReasonExpired}
 	}
@@ -107,14 +112,14 @@ func Resolve(app core.App, secretID, userID string) (*ResolveResult, error) {
 		// New Epic-19 format: AES-256-GCM with nonce, base64 JSON blob.
 		payload, err = DecryptPayload(enc)
 		if err != nil {
-			return nil, &ResolveError{SecretID: secretID, Reason: "decrypt failed", Cause: err}
+			return nil, &ResolveError{SecretID: secretID, Reason: ReasonDecryptFailed, Cause: ReportDecryptFailure(app, NewDecryptError("secret.resolve", secretID, err))}
 		}
 	} else if legacyVal := rec.GetString("value"); legacyVal != "" {
 		// Legacy pre-Epic-19 format: hex AES-256-GCM via APPOS_ENCRYPTION_KEY.
 		// TODO(story-19.4): remove this branch once all records are migrated to payload_encrypted.
 		plain, decErr := DecryptLegacyValue(legacyVal)
 		if decErr != nil {
-			return nil, &ResolveError{SecretID: secretID, Reason: "legacy decrypt failed", Cause: decErr}
+			return nil, &ResolveError{SecretID: secretID, Reason: ReasonDecryptFailed, Cause: ReportDecryptFailure(app, NewDecryptError("secret.resolve.legacy", secretID, decErr))}
 		}
 		payload = map[string]any{"value": plain}
 	} else {
@@ -164,6 +169,9 @@ func ValidateRef(app core.App, secretID, userID string) error {
 	if s.IsRevoked() {
 		return &ResolveError{SecretID: secretID, Reason: ReasonRevoked}
 	}
+	if archive.IsArchived(rec) {
+		return &ResolveError{SecretID: secretID, Reason: ReasonArchived}
+	}
 	if s.IsExpired() {
 		return &ResolveError{SecretID: secretID, Reason: ReasonExpired}
 	}
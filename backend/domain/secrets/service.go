@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/archive"
 )
 
 // applyDefaultAccessMode sets access_mode on record to the policy default when
@@ -69,6 +71,52 @@ func payloadFromAny(v any) (map[string]any, error) {
 	return m, nil
 }
 
+// ─── CreateUserSecret ─────────────────────────────────────────────────────────
+
+// CreateUserSecret creates a user-sourced encrypted secret outside the records
+// API, applying the same policy defaults as the secrets create hook. It is used
+// by flows that create a secret on behalf of the caller, e.g. inline server
+// credentials.
+//
+// It does NOT write audit — the caller is responsible for that.
+func CreateUserSecret(app core.App, auth *core.Record, name, templateID string, payload map[string]any) (*Secret, error) {
+	tpl, ok := FindTemplate(templateID)
+	if !ok {
+		return nil, fmt.Errorf("invalid template_id")
+	}
+	if err := ValidatePayload(payload, tpl); err != nil {
+		return nil, err
+	}
+	enc, err := EncryptPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	col, err := app.FindCollectionByNameOrId("secrets")
+	if err != nil {
+		return nil, err
+	}
+	record := core.NewRecord(col)
+	record.Set("name", name)
+	record.Set("template_id", templateID)
+	record.Set("scope", ScopeGlobal)
+	record.Set("payload_encrypted", enc)
+	record.Set("payload_meta", BuildPayloadMeta(payload, tpl))
+	record.Set("version", 1)
+	record.Set("status", StatusActive)
+	record.Set("created_source", CreatedSourceUser)
+	if auth != nil {
+		record.Set("created_by", auth.Id)
+	}
+	applyDefaultAccessMode(app, record)
+	applyExpiryPolicy(app, record)
+
+	if err := app.Save(record); err != nil {
+		return nil, err
+	}
+	return From(record), nil
+}
+
 // ─── RevealPayload ────────────────────────────────────────────────────────────
 
 // RevealResult carries the decrypted payload together with record metadata so
@@ -106,7 +154,7 @@ func RevealPayload(app core.App, secretID string, auth *core.Record) (*RevealRes
 			return ErrRevealDisabled
 		}
 
-		rec, err := txApp.FindRecordById("secrets", secretID)
+		rec, err := archive.FindLive(txApp, "secrets", secretID)
 		if err != nil {
 			return ErrRevealNotFound
 		}
@@ -127,7 +175,7 @@ func RevealPayload(app core.App, secretID string, auth *core.Record) (*RevealRes
 
 		payload, err := DecryptPayload(rec.GetString("payload_encrypted"))
 		if err != nil {
-			return fmt.Errorf("decrypt_failed: %w", err)
+			return NewDecryptError("secret.reveal", rec.Id, err)
 		}
 
 		if s.AccessMode() == AccessModeRevealOnce {
@@ -143,7 +191,8 @@ func RevealPayload(app core.App, secretID string, auth *core.Record) (*RevealRes
 		return nil
 	})
 	if txErr != nil {
-		return nil, txErr
+		// Reported after the transaction so the audit entry survives its rollback.
+		return nil, ReportDecryptFailure(app, txErr)
 	}
 
 	return &result, nil
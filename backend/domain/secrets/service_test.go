@@ -277,3 +277,60 @@ func TestReadSystemSingleValue_PrefersPayloadEncrypted(t *testing.T) {
 		t.Errorf("expected new-value (payload_encrypted preferred), got %q", val)
 	}
 }
+
+func TestRevealPayload_DecryptFailure(t *testing.T) {
+	app := newSecretsApp(t)
+	defer app.Cleanup()
+	setupTestKey(t)
+
+	suCol, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	su := core.NewRecord(suCol)
+	su.Set("email", "decrypt-test@test.com")
+	su.SetPassword("1234567890")
+	if err := app.Save(su); err != nil {
+		t.Fatal(err)
+	}
+
+	col, err := app.FindCollectionByNameOrId("secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := core.NewRecord(col)
+	rec.Set("name", "corrupted-secret")
+	rec.Set("scope", ScopeGlobal)
+	rec.Set("access_mode", AccessModeRevealAllowed)
+	rec.Set("status", StatusActive)
+	rec.Set("created_source", CreatedSourceUser)
+	rec.Set("created_by", su.Id)
+	rec.Set("template_id", "single_value")
+	rec.Set("version", 1)
+	rec.Set("payload_encrypted", `{"nonce":"AAAAAAAAAAAAAAAA","ciphertext":"AAAAAAAAAAAAAAAAAAAAAAAA"}`)
+	if err := app.Save(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	before := DecryptFailures()
+	_, err = RevealPayload(app, rec.Id, su)
+	if !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("expected ErrDecryptFailed, got %v", err)
+	}
+	var decryptErr *DecryptError
+	if !errors.As(err, &decryptErr) || decryptErr.Source != "secret.reveal" || decryptErr.ResourceID != rec.Id {
+		t.Fatalf("unexpected decrypt error: %#v", err)
+	}
+	if got := DecryptFailures(); got != before+1 {
+		t.Fatalf("expected decrypt failure counter to advance by 1, got %d -> %d", before, got)
+	}
+
+	_, err = Resolve(app, rec.Id, su.Id)
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) || resolveErr.Reason != ReasonDecryptFailed || !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("expected resolve decrypt failure, got %v", err)
+	}
+	if got := DecryptFailures(); got != before+2 {
+		t.Fatalf("expected decrypt failure counter to advance by 2, got %d -> %d", before, got)
+	}
+}
@@ -33,11 +33,15 @@ func ReadSystemSingleValue(secret *Secret) (string, error) {
 	if enc := secret.Record().GetString("payload_encrypted"); enc != "" {
 		payload, err := DecryptPayload(enc)
 		if err != nil {
-			return "", err
+			return "", NewDecryptError("secret.system", secret.Record().Id, err)
 		}
 		return FirstStringFromPayload(payload, "value"), nil
 	}
-	return DecryptLegacyValue(secret.Record().GetString("value"))
+	plain, err := DecryptLegacyValue(secret.Record().GetString("value"))
+	if err != nil {
+		return "", NewDecryptError("secret.system", secret.Record().Id, err)
+	}
+	return plain, nil
 }
 
 // UpsertSystemSingleValue creates or updates a system-managed single-value secret using payload_encrypted.
@@ -162,7 +162,7 @@ func TestEmbeddedTemplatesValid(t *testing.T) {
 		seen[tpl.ID] = true
 	}
 
-	requiredIDs := []string{"single_value", "ssh_key", "tls_private_key"}
+	requiredIDs := []string{"single_value", "ssh_key", "tls_private_key", "registry_auth"}
 	for _, id := range requiredIDs {
 		if !seen[id] {
 			t.Errorf("expected embedded template id %q", id)
@@ -0,0 +1,55 @@
+// Package sftpjails looks up the directory a user's SFTP file access is
+// confined to. A jail applies to one server, or to every server when its
+// server is empty; a server-specific jail wins over the user-wide one.
+//
+// A jail only restricts the SFTP file routes; it is not a security boundary.
+// Those routes are superuser-only, and a jailed superuser can still open an
+// SSH terminal, run commands on the server, or edit their own jail through the
+// records API. Use it to keep the file browser on one tree, not to contain a
+// user.
+package sftpjails
+
+import (
+	"errors"
+	"path"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Collection is the PocketBase collection holding the jails.
+const Collection = "sftp_jails"
+
+// ErrInvalidRoot is returned for a stored root that is not an absolute path.
+var ErrInvalidRoot = errors.New("sftp jail root must be an absolute path")
+
+// Root returns the directory userID is confined to on serverID, or "" when
+// the user is not jailed there.
+func Root(app core.App, userID, serverID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	records, err := app.FindRecordsByFilter(
+		Collection,
+		"user_id = {:user} && (server = {:server} || server = '')",
+		"", 0, 0,
+		dbx.Params{"user": userID, "server": serverID},
+	)
+	if err != nil {
+		return "", err
+	}
+	var jail *core.Record
+	for _, rec := range records {
+		if jail == nil || rec.GetString("server") != "" {
+			jail = rec
+		}
+	}
+	if jail == nil {
+		return "", nil
+	}
+	root := jail.GetString("root")
+	if !path.IsAbs(root) {
+		return "", ErrInvalidRoot
+	}
+	return path.Clean(root), nil
+}
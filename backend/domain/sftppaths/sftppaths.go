@@ -0,0 +1,197 @@
+// Package sftppaths stores per-user, per-server quick-navigation paths for
+// the SFTP file manager: explicit bookmarks and a short history of recently
+// listed directories.
+package sftppaths
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Collection is the PocketBase collection holding bookmarks and recents.
+const Collection = "sftp_paths"
+
+const (
+	KindBookmark = "bookmark"
+	KindRecent   = "recent"
+)
+
+const (
+	// MaxBookmarks caps the bookmarks one user keeps per server.
+	MaxBookmarks = 50
+	// MaxRecent is how many recently listed paths are kept per user and server.
+	MaxRecent = 20
+)
+
+var (
+	ErrNotFound         = errors.New("sftp path not found")
+	ErrInvalidPath      = errors.New("path must be absolute")
+	ErrTooManyBookmarks = errors.New("bookmark limit reached")
+)
+
+// Entry is one stored bookmark or recent path.
+type Entry struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Label     string    `json:"label,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Bookmarks returns the user's bookmarks on serverID ordered by path.
+func Bookmarks(app core.App, userID, serverID string) ([]Entry, error) {
+	return list(app, userID, serverID, KindBookmark, "path ASC", 0)
+}
+
+// Recent returns the user's recently listed paths on serverID, newest first.
+func Recent(app core.App, userID, serverID string) ([]Entry, error) {
+	return list(app, userID, serverID, KindRecent, "updated DESC", MaxRecent)
+}
+
+// AddBookmark saves p as a bookmark, updating the label when the path is
+// already bookmarked.
+func AddBookmark(app core.App, userID, serverID, p, label string) (Entry, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return Entry{}, err
+	}
+	rec, err := find(app, userID, serverID, KindBookmark, p)
+	if err != nil {
+		return Entry{}, err
+	}
+	if rec == nil {
+		count, err := app.CountRecords(Collection, ownerFilter(userID, serverID, KindBookmark))
+		if err != nil {
+			return Entry{}, err
+		}
+		if count >= MaxBookmarks {
+			return Entry{}, ErrTooManyBookmarks
+		}
+		if rec, err = newRecord(app, userID, serverID, KindBookmark, p); err != nil {
+			return Entry{}, err
+		}
+	}
+	rec.Set("label", strings.TrimSpace(label))
+	if err := app.Save(rec); err != nil {
+		return Entry{}, err
+	}
+	return entryFrom(rec), nil
+}
+
+// DeleteBookmark removes bookmark id when it belongs to the user and server.
+func DeleteBookmark(app core.App, userID, serverID, id string) error {
+	rec, err := app.FindRecordById(Collection, id)
+	if err != nil {
+		return ErrNotFound
+	}
+	if rec.GetString("user_id") != userID || rec.GetString("server") != serverID || rec.GetString("kind") != KindBookmark {
+		return ErrNotFound
+	}
+	return app.Delete(rec)
+}
+
+// RecordRecent moves p to the top of the user's recent paths on serverID and
+// drops entries beyond MaxRecent.
+func RecordRecent(app core.App, userID, serverID, p string) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+	rec, err := find(app, userID, serverID, KindRecent, p)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		if rec, err = newRecord(app, userID, serverID, KindRecent, p); err != nil {
+			return err
+		}
+	}
+	// Saving an existing record bumps its updated autodate to the top.
+	if err := app.Save(rec); err != nil {
+		return err
+	}
+
+	var stale []*core.Record
+	err = app.RecordQuery(Collection).
+		AndWhere(ownerFilter(userID, serverID, KindRecent)).
+		OrderBy("updated DESC").
+		Offset(MaxRecent).
+		Limit(1000).
+		All(&stale)
+	if err != nil {
+		return err
+	}
+	for _, old := range stale {
+		if err := app.Delete(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func list(app core.App, userID, serverID, kind, orderBy string, limit int) ([]Entry, error) {
+	query := app.RecordQuery(Collection).AndWhere(ownerFilter(userID, serverID, kind)).OrderBy(orderBy)
+	if limit > 0 {
+		query = query.Limit(int64(limit))
+	}
+	var records []*core.Record
+	if err := query.All(&records); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, entryFrom(rec))
+	}
+	return entries, nil
+}
+
+func find(app core.App, userID, serverID, kind, p string) (*core.Record, error) {
+	records, err := app.FindRecordsByFilter(
+		Collection,
+		"user_id = {:user} && server = {:server} && kind = {:kind} && path = {:path}",
+		"", 1, 0,
+		dbx.Params{"user": userID, "server": serverID, "kind": kind, "path": p},
+	)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return records[0], nil
+}
+
+func newRecord(app core.App, userID, serverID, kind, p string) (*core.Record, error) {
+	col, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return nil, err
+	}
+	rec := core.NewRecord(col)
+	rec.Set("user_id", userID)
+	rec.Set("server", serverID)
+	rec.Set("kind", kind)
+	rec.Set("path", p)
+	return rec, nil
+}
+
+func ownerFilter(userID, serverID, kind string) dbx.Expression {
+	return dbx.HashExp{"user_id": userID, "server": serverID, "kind": kind}
+}
+
+func cleanPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if !strings.HasPrefix(p, "/") {
+		return "", ErrInvalidPath
+	}
+	return path.Clean(p), nil
+}
+
+func entryFrom(rec *core.Record) Entry {
+	return Entry{
+		ID:        rec.Id,
+		Path:      rec.GetString("path"),
+		Label:     rec.GetString("label"),
+		UpdatedAt: rec.GetDateTime("updated").Time().UTC(),
+	}
+}
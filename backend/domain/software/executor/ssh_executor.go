@@ -50,12 +50,17 @@ func NewSSHExecutor(app core.App, serverID, userID string) (*SSHExecutor, error)
 	}
 	return &SSHExecutor{
 		cfg: terminal.ConnectorConfig{
-			Host:     access.Host,
-			Port:     access.Port,
-			User:     access.User,
-			AuthType: terminal.CredAuthType(access.AuthType),
-			Secret:   access.Secret,
-			Shell:    access.Shell,
+			Host:       access.Host,
+			Port:       access.Port,
+			User:       access.User,
+			AuthType:   terminal.CredAuthType(access.AuthType),
+			Secret:     access.Secret,
+			Passphrase: access.Passphrase,
+			Password:   access.Password,
+			Shell:      access.Shell,
+			ProxyJump:  access.ProxyJump,
+			LegacySSH:  access.LegacySSH,
+			Privilege:  terminal.PrivilegeEscalation(access.PrivilegeEscalation),
 		},
 	}, nil
 }
@@ -133,11 +138,15 @@ func (e *SSHExecutor) RunPreflight(ctx context.Context, _ string, tpl software.R
 	if tpl.Preflight.RequireRoot {
 		uidOut, err := executeSSHCommand(ctx, e.cfg, "id -u", preflightTimeout)
 		if err != nil || strings.TrimSpace(uidOut) != "0" {
-			// Accept passwordless sudo as an equivalent to root
-			_, sudoErr := executeSSHCommand(ctx, e.cfg, "sudo -n true 2>/dev/null", preflightTimeout)
-			if sudoErr != nil {
+			// Accept the server's privilege escalation as an equivalent to root
+			escalated := e.cfg.Privilege != "" && e.cfg.Privilege != terminal.PrivilegeNone
+			if escalated {
+				_, escalateErr := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged("true"), preflightTimeout)
+				escalated = escalateErr == nil
+			}
+			if !escalated {
 				result.PrivilegeOK = false
-				result.Issues = append(result.Issues, "privilege_required: neither root nor passwordless sudo available")
+				result.Issues = append(result.Issues, "privilege_required: neither root nor working privilege escalation available")
 			}
 		}
 	}
@@ -201,7 +210,7 @@ func (e *SSHExecutor) Install(ctx context.Context, serverID string, tpl software
 		if err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("install %s via package manager: %w", tpl.ComponentKey, err)
 		}
-		if _, err := executeSSHCommand(ctx, e.cfg, withSudo(cmd), installTimeout); err != nil {
+		if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(cmd), installTimeout); err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("install %s via package manager: %w", tpl.ComponentKey, err)
 		}
 	case "script":
@@ -235,7 +244,7 @@ func (e *SSHExecutor) Upgrade(ctx context.Context, serverID string, tpl software
 		if err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("upgrade %s via package manager: %w", tpl.ComponentKey, err)
 		}
-		if _, err := executeSSHCommand(ctx, e.cfg, withSudo(cmd), upgradeTimeout); err != nil {
+		if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(cmd), upgradeTimeout); err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("upgrade %s via package manager: %w", tpl.ComponentKey, err)
 		}
 	case "script":
@@ -265,7 +274,7 @@ func (e *SSHExecutor) Start(ctx context.Context, _ string, tpl software.Resolved
 		return software.SoftwareComponentDetail{}, fmt.Errorf("component %s does not support start", tpl.ComponentKey)
 	}
 	cmd := fmt.Sprintf("systemctl start %s", terminal.ShellQuote(tpl.Verify.ServiceName))
-	if _, err := executeSSHCommand(ctx, e.cfg, withSudo(cmd), verifyTimeout); err != nil {
+	if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(cmd), verifyTimeout); err != nil {
 		return software.SoftwareComponentDetail{}, fmt.Errorf("start %s via systemd: %w", tpl.ComponentKey, err)
 	}
 	return software.SoftwareComponentDetail{SoftwareComponentSummary: software.SoftwareComponentSummary{ComponentKey: tpl.ComponentKey, TemplateKind: tpl.TemplateKind}, ServiceName: tpl.Verify.ServiceName}, nil
@@ -276,7 +285,7 @@ func (e *SSHExecutor) Stop(ctx context.Context, _ string, tpl software.ResolvedT
 		return software.SoftwareComponentDetail{}, fmt.Errorf("component %s does not support stop", tpl.ComponentKey)
 	}
 	cmd := fmt.Sprintf("systemctl stop %s", terminal.ShellQuote(tpl.Verify.ServiceName))
-	if _, err := executeSSHCommand(ctx, e.cfg, withSudo(cmd), verifyTimeout); err != nil {
+	if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(cmd), verifyTimeout); err != nil {
 		return software.SoftwareComponentDetail{}, fmt.Errorf("stop %s via systemd: %w", tpl.ComponentKey, err)
 	}
 	return software.SoftwareComponentDetail{SoftwareComponentSummary: software.SoftwareComponentSummary{ComponentKey: tpl.ComponentKey, TemplateKind: tpl.TemplateKind}, ServiceName: tpl.Verify.ServiceName}, nil
@@ -287,7 +296,7 @@ func (e *SSHExecutor) Restart(ctx context.Context, _ string, tpl software.Resolv
 		return software.SoftwareComponentDetail{}, fmt.Errorf("component %s does not support restart", tpl.ComponentKey)
 	}
 	cmd := fmt.Sprintf("systemctl restart %s", terminal.ShellQuote(tpl.Verify.ServiceName))
-	if _, err := executeSSHCommand(ctx, e.cfg, withSudo(cmd), verifyTimeout); err != nil {
+	if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(cmd), verifyTimeout); err != nil {
 		return software.SoftwareComponentDetail{}, fmt.Errorf("restart %s via systemd: %w", tpl.ComponentKey, err)
 	}
 	return software.SoftwareComponentDetail{SoftwareComponentSummary: software.SoftwareComponentSummary{ComponentKey: tpl.ComponentKey, TemplateKind: tpl.TemplateKind}, ServiceName: tpl.Verify.ServiceName}, nil
@@ -298,7 +307,7 @@ func (e *SSHExecutor) Restart(ctx context.Context, _ string, tpl software.Resolv
 func (e *SSHExecutor) Uninstall(ctx context.Context, serverID string, tpl software.ResolvedTemplate) (software.SoftwareComponentDetail, error) {
 	if tpl.Verify.Strategy == "systemd" && strings.TrimSpace(tpl.Verify.ServiceName) != "" {
 		stopCmd := fmt.Sprintf("systemctl stop %s", terminal.ShellQuote(tpl.Verify.ServiceName))
-		if _, err := executeSSHCommand(ctx, e.cfg, withSudo(stopCmd), uninstallTimeout); err != nil {
+		if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(stopCmd), uninstallTimeout); err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("stop %s before uninstall: %w", tpl.ComponentKey, err)
 		}
 	}
@@ -309,7 +318,7 @@ func (e *SSHExecutor) Uninstall(ctx context.Context, serverID string, tpl softwa
 		if err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("uninstall %s via package manager: %w", tpl.ComponentKey, err)
 		}
-		if _, err := executeSSHCommand(ctx, e.cfg, withSudo(cmd), uninstallTimeout); err != nil {
+		if _, err := executeSSHCommand(ctx, e.cfg, e.cfg.Privileged(cmd), uninstallTimeout); err != nil {
 			return software.SoftwareComponentDetail{}, fmt.Errorf("uninstall %s via package manager: %w", tpl.ComponentKey, err)
 		}
 	case "script":
@@ -662,13 +671,6 @@ func (e *SSHExecutor) buildPackageActionCommand(ctx context.Context, action, pac
 	return "", fmt.Errorf("unsupported package manager/action combination: manager=%s action=%s", managerName, action)
 }
 
-// withSudo wraps a shell command to attempt execution via passwordless sudo first,
-// falling back to direct execution if sudo is not available.
-// This matches the pattern used in server_monitor_agent.go.
-func withSudo(cmd string) string {
-	return fmt.Sprintf("(sudo -n sh -c %s 2>/dev/null || sh -c %s)", terminal.ShellQuote(cmd), terminal.ShellQuote(cmd))
-}
-
 // firstLine returns the first non-empty line from a multi-line string.
 func firstLine(s string) string {
 	for _, line := range strings.Split(s, "\n") {
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -158,17 +159,31 @@ func TestBuildManagedScriptCommand_EmbeddedScriptWithEnv(t *testing.T) {
 	}
 }
 
-// ─── withSudo ─────────────────────────────────────────────────────────────────
+// ─── Privilege escalation ─────────────────────────────────────────────────────
 
-func TestWithSudo_ContainsFallback(t *testing.T) {
-	cmd := withSudo("apt-get install -y docker")
-	// Must attempt sudo first, then fall back
-	if !containsSubstring(cmd, "sudo -n") {
-		t.Errorf("expected sudo attempt, got: %s", cmd)
+func TestInstall_UsesServerPrivilegeEscalation(t *testing.T) {
+	orig := executeSSHCommand
+	defer func() { executeSSHCommand = orig }()
+
+	var commands []string
+	executeSSHCommand = func(_ context.Context, _ terminal.ConnectorConfig, cmd string, _ time.Duration) (string, error) {
+		commands = append(commands, cmd)
+		if containsSubstring(cmd, "command -v apt-get") {
+			return "apt-get", nil
+		}
+		return "", nil
+	}
+
+	ex := &SSHExecutor{cfg: terminal.ConnectorConfig{Privilege: terminal.PrivilegeDoas}}
+	if _, err := ex.Install(context.Background(), "srv-1", packageTemplate("docker-ce", "docker.service")); err != nil {
+		t.Fatalf("Install error: %v", err)
+	}
+	if len(commands) == 0 {
+		t.Fatal("expected install command")
 	}
-	// Must have the sh -c fallback
-	if !containsSubstring(cmd, "sh -c") {
-		t.Errorf("expected sh -c fallback, got: %s", cmd)
+	cmd := commands[len(commands)-1]
+	if !strings.HasPrefix(cmd, "doas -n -- sh -c ") {
+		t.Errorf("expected doas escalation, got: %s", cmd)
 	}
 }
 
@@ -1,9 +1,10 @@
 package space
 
 import (
+	"strings"
+
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
-	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
 )
 
 const (
@@ -11,8 +12,6 @@ const (
 	SettingsKey    = "quota"
 )
 
-var defaultQuota = settingscatalog.DefaultGroup(SettingsModule, SettingsKey)
-
 // Quota holds all effective quota values for the space domain.
 type Quota struct {
 	MaxSizeMB             int
@@ -23,12 +22,14 @@ type Quota struct {
 	UploadAllowExts       []string
 	UploadDenyExts        []string
 	DisallowedFolderNames []string
+	// PreviewMimeTypes is the inline preview whitelist; empty disables preview.
+	PreviewMimeTypes []string
 }
 
 // GetQuota loads the effective space quota configuration from sysconfig.
 // Falls back to catalog defaults when the DB row is absent.
 func GetQuota(app core.App) Quota {
-	cfg, _ := sysconfig.GetGroup(app, SettingsModule, SettingsKey, defaultQuota)
+	cfg, _ := sysconfig.GetGroup(app, SettingsModule, SettingsKey, nil)
 
 	maxUploadFiles := sysconfig.Int(cfg, "maxUploadFiles", 50)
 	if maxUploadFiles < 1 {
@@ -43,6 +44,11 @@ func GetQuota(app core.App) Quota {
 		disallowedFolders = []string{}
 	}
 
+	previewMimeTypes := DefaultPreviewMimeTypes()
+	if _, ok := cfg["previewMimeTypes"]; ok {
+		previewMimeTypes = sysconfig.StringSlice(cfg, "previewMimeTypes")
+	}
+
 	return Quota{
 		MaxSizeMB:             sysconfig.Int(cfg, "maxSizeMB", 10),
 		MaxPerUser:            sysconfig.Int(cfg, "maxPerUser", 100),
@@ -52,5 +58,11 @@ func GetQuota(app core.App) Quota {
 		UploadAllowExts:       NormalizeExts(sysconfig.StringSlice(cfg, "uploadAllowExts")),
 		UploadDenyExts:        NormalizeExts(sysconfig.StringSlice(cfg, "uploadDenyExts")),
 		DisallowedFolderNames: disallowedFolders,
+		PreviewMimeTypes:      previewMimeTypes,
 	}
 }
+
+// DefaultPreviewMimeTypes returns PreviewMimeTypes as a list.
+func DefaultPreviewMimeTypes() []string {
+	return strings.Split(PreviewMimeTypes, ",")
+}
@@ -0,0 +1,80 @@
+package space
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// RegisterHooks binds user_files record hooks. Every path that stores file
+// content (the PocketBase records API, batch upload, fetch) goes through
+// these model hooks, so content_hash always matches the stored blob.
+func RegisterHooks(app core.App) {
+	setHash := func(e *core.RecordEvent) error {
+		if err := applyContentHash(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	}
+	app.OnRecordCreate(Collection).BindFunc(setHash)
+	app.OnRecordUpdate(Collection).BindFunc(setHash)
+}
+
+// applyContentHash sets content_hash from a newly attached content file, or
+// clears it when the content was removed. Records whose content is
+// unchanged keep their stored hash.
+func applyContentHash(record *core.Record) error {
+	if files := record.GetUnsavedFiles("content"); len(files) > 0 {
+		hash, err := ContentHash(files[len(files)-1])
+		if err != nil {
+			return err
+		}
+		record.Set("content_hash", hash)
+		return nil
+	}
+	if record.GetString("content") == "" {
+		record.Set("content_hash", "")
+	}
+	return nil
+}
+
+// ContentHash returns the hex-encoded SHA-256 of f's content.
+func ContentHash(f *filesystem.File) (string, error) {
+	r, err := f.Reader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FindDuplicate returns the oldest live file owned by ownerID with the given
+// content hash, excluding the record excludeID, or nil when there is none.
+func FindDuplicate(app core.App, ownerID, hash, excludeID string) (*core.Record, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	records, err := app.FindAllRecords(Collection,
+		dbx.HashExp{"owner": ownerID, "content_hash": hash, "is_folder": false, "is_deleted": false},
+		dbx.Not(dbx.HashExp{"id": excludeID}),
+	)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	oldest := records[0]
+	for _, record := range records[1:] {
+		if record.GetDateTime("created").Before(oldest.GetDateTime("created")) {
+			oldest = record
+		}
+	}
+	return oldest, nil
+}
@@ -0,0 +1,179 @@
+package space
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// MIME families used to cross-check fetched content against its extension.
+// Active families can carry script when rendered by a browser.
+const (
+	mimeFamilyHTML   = "html"
+	mimeFamilyXML    = "xml"
+	mimeFamilyScript = "script"
+)
+
+// extMimeTypes maps common extensions to the MIME type stored for them. It
+// takes precedence over the host's mime.types, which is often missing or
+// incomplete in containers, so detection does not vary between hosts.
+var extMimeTypes = map[string]string{
+	"txt": "text/plain", "log": "text/plain", "conf": "text/plain", "cfg": "text/plain",
+	"ini": "text/plain", "env": "text/plain", "properties": "text/plain",
+	"md": "text/markdown", "csv": "text/csv",
+	"json": "application/json", "yaml": "application/yaml", "yml": "application/yaml",
+	"toml": "application/toml", "sql": "application/sql",
+	"sh": "application/x-sh", "bash": "application/x-sh", "zsh": "application/x-sh",
+	"png": "image/png", "jpg": "image/jpeg", "jpeg": "image/jpeg", "gif": "image/gif",
+	"webp": "image/webp", "svg": "image/svg+xml", "bmp": "image/bmp", "ico": "image/x-icon",
+	"pdf": "application/pdf",
+	"mp3": "audio/mpeg", "wav": "audio/wav", "ogg": "audio/ogg", "oga": "audio/ogg", "aac": "audio/aac",
+	"flac": "audio/flac", "weba": "audio/webm",
+	"mp4": "video/mp4", "webm": "video/webm", "ogv": "video/ogg",
+}
+
+// MimeTypeByExt returns the base MIME type for extension ext (with or
+// without the leading dot), or "" when it is unknown.
+func MimeTypeByExt(ext string) string {
+	ext = NormalizeExt(ext)
+	if ext == "" {
+		return ""
+	}
+	if t, ok := extMimeTypes[ext]; ok {
+		return t
+	}
+	return baseMimeType(mime.TypeByExtension("." + ext))
+}
+
+// DetectUploadMimeType returns the MIME type to store for an uploaded file.
+// A specific declared type wins; generic ones (missing, octet-stream, or
+// plain text for a more specific textual extension) fall back to the type
+// implied by the file name, then to application/octet-stream.
+func DetectUploadMimeType(name, declared string) string {
+	declared = baseMimeType(declared)
+	implied := MimeTypeByExt(path.Ext(name))
+	switch {
+	case declared == "" || declared == "application/octet-stream":
+		if implied != "" {
+			return implied
+		}
+	case declared == "text/plain" && implied != "" && isTextMimeType(implied):
+		return implied
+	default:
+		if _, _, err := mime.ParseMediaType(declared); err == nil {
+			return declared
+		}
+		if implied != "" {
+			return implied
+		}
+	}
+	return "application/octet-stream"
+}
+
+// ValidateMimeOverride checks a manual mime_type correction for file name.
+// The type must be known (from the extension table or extra, e.g. the preview
+// allowlist) and may only be active content (HTML, XML/SVG, script) when the
+// extension implies that same family. It returns the normalized type.
+func ValidateMimeOverride(name, mimeType string, extra []string) (string, error) {
+	t := baseMimeType(mimeType)
+	if _, _, err := mime.ParseMediaType(t); err != nil || !strings.Contains(t, "/") {
+		return "", fmt.Errorf("invalid MIME type %q", mimeType)
+	}
+	allowed := t == "application/octet-stream"
+	for _, known := range extMimeTypes {
+		allowed = allowed || known == t
+	}
+	for _, known := range extra {
+		allowed = allowed || baseMimeType(known) == t
+	}
+	if !allowed {
+		return "", fmt.Errorf("MIME type %q is not allowed", t)
+	}
+	if family := mimeFamily(t); isActiveMimeFamily(family) && family != mimeFamily(MimeTypeByExt(path.Ext(name))) {
+		return "", fmt.Errorf("MIME type %s does not match the file extension", t)
+	}
+	return t, nil
+}
+
+// ResolveFetchedMimeType returns the MIME type to store for remote content
+// saved under extension ext. The type sniffed from head (the first bytes of
+// the body) is authoritative; the server-declared Content-Type is only checked,
+// never trusted. It fails when either the sniffed or declared type is active
+// content (HTML, XML/SVG, script) the extension does not imply, or when the
+// sniffed type belongs to a different family than the extension, e.g. a
+// ".txt" URL that serves HTML or an image.
+func ResolveFetchedMimeType(ext, declared string, head []byte) (string, error) {
+	sniffed := baseMimeType(http.DetectContentType(head))
+	implied := MimeTypeByExt(ext)
+	impliedFamily := mimeFamily(implied)
+	if implied == "text/plain" {
+		impliedFamily = "text"
+	}
+
+	if family := mimeFamily(sniffed); family != "" && family != impliedFamily &&
+		(isActiveMimeFamily(family) || impliedFamily != "") {
+		return "", fmt.Errorf("remote content looks like %s, which does not match the .%s extension", sniffed, ext)
+	}
+	if declared = baseMimeType(declared); declared != "" {
+		if family := mimeFamily(declared); isActiveMimeFamily(family) && family != impliedFamily {
+			return "", fmt.Errorf("remote server declared %s, which does not match the .%s extension", declared, ext)
+		}
+	}
+
+	// Generic or container sniffs (plain text, zip-based documents, Ogg, XML
+	// for SVG) are refined by a compatible extension type.
+	if implied != "" {
+		switch {
+		case sniffed == "text/plain" && isTextMimeType(implied),
+			sniffed == "application/octet-stream" && !isTextMimeType(implied),
+			sniffed == "application/zip" && strings.HasPrefix(implied, "application/"),
+			sniffed == "application/ogg" && (impliedFamily == "audio" || impliedFamily == "video"),
+			sniffed == "text/xml" && impliedFamily == mimeFamilyXML:
+			return implied, nil
+		}
+	}
+	return sniffed, nil
+}
+
+// isTextMimeType reports whether t is a textual type that sniffs as text/plain.
+func isTextMimeType(t string) bool {
+	if strings.HasPrefix(t, "text/") || isActiveMimeFamily(mimeFamily(t)) {
+		return true
+	}
+	switch t {
+	case "application/json", "application/yaml", "application/toml", "application/x-sh", "application/sql":
+		return true
+	}
+	return strings.HasSuffix(t, "+json")
+}
+
+func baseMimeType(v string) string {
+	if idx := strings.Index(v, ";"); idx >= 0 {
+		v = v[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(v))
+}
+
+// mimeFamily groups t for compatibility checks. Generic types that say
+// nothing about the content (plain text, octet-stream, the Ogg container)
+// map to "".
+func mimeFamily(t string) string {
+	switch t {
+	case "", "text/plain", "application/octet-stream", "application/ogg":
+		return ""
+	case "text/html", "application/xhtml+xml":
+		return mimeFamilyHTML
+	case "text/xml", "application/xml", "image/svg+xml":
+		return mimeFamilyXML
+	case "text/javascript", "application/javascript", "application/x-javascript":
+		return mimeFamilyScript
+	}
+	top, _, _ := strings.Cut(t, "/")
+	return top
+}
+
+func isActiveMimeFamily(family string) bool {
+	return family == mimeFamilyHTML || family == mimeFamilyXML || family == mimeFamilyScript
+}
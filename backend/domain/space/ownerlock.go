@@ -0,0 +1,38 @@
+package space
+
+import (
+	"sync"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var ownerLocks = struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}{
+	locks: map[string]*sync.Mutex{},
+}
+
+// LockOwner serializes the per-user item count check and the inserts that
+// follow it, so parallel uploads by the same owner cannot both pass the
+// maxPerUser check. Call the returned unlock once the new records are saved.
+func LockOwner(ownerID string) (unlock func()) {
+	ownerLocks.mu.Lock()
+	lock, ok := ownerLocks.locks[ownerID]
+	if !ok {
+		lock = &sync.Mutex{}
+		ownerLocks.locks[ownerID] = lock
+	}
+	ownerLocks.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// CountItems returns how many user_files records (files and folders,
+// including trashed ones) ownerID holds.
+func CountItems(app core.App, ownerID string) (int, error) {
+	n, err := app.CountRecords(Collection, dbx.HashExp{"owner": ownerID})
+	return int(n), err
+}
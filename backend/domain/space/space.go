@@ -20,13 +20,14 @@ import (
 
 const Collection = "user_files"
 
-// ─── Format lists (static policy, not configurable) ──────────────────────────
+// ─── Format lists ─────────────────────────────────────────────────────────────
 
 const (
 	// ReservedFolderNames are root-level folder names reserved by the system.
 	ReservedFolderNames = "deploy,artifact"
 
-	// PreviewMimeTypes is the whitelist of MIME types allowed for inline preview.
+	// PreviewMimeTypes is the default whitelist of MIME types allowed for inline
+	// preview; operators override it with the previewMimeTypes quota setting.
 	// SVG is included — the frontend renders it via <img> which blocks JS execution.
 	PreviewMimeTypes = "image/png,image/jpeg,image/gif,image/webp,image/svg+xml," +
 		"image/bmp,image/x-icon,application/pdf," +
@@ -130,14 +131,15 @@ func (f *UserFile) RevokeShare() {
 	f.rec.Set("share_expires_at", "")
 }
 
-// IsPreviewable reports whether this file's MIME type is in the preview whitelist.
-func (f *UserFile) IsPreviewable() bool {
-	mt := f.rec.GetString("mime_type")
+// IsPreviewable reports whether this file's MIME type is in the allowed preview
+// list (see Quota.PreviewMimeTypes).
+func (f *UserFile) IsPreviewable(allowed []string) bool {
+	mt := strings.ToLower(f.rec.GetString("mime_type"))
 	if mt == "" {
 		return false
 	}
-	for _, allowed := range strings.Split(PreviewMimeTypes, ",") {
-		if strings.TrimSpace(allowed) == mt {
+	for _, a := range allowed {
+		if strings.ToLower(strings.TrimSpace(a)) == mt {
 			return true
 		}
 	}
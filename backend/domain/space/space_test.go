@@ -43,3 +43,179 @@ func TestMessageForErrorReturnsCanonicalMessages(t *testing.T) {
 		t.Fatalf("expected expired message, got %q", got)
 	}
 }
+
+func TestResolveFetchedMimeTypeRejectsMismatches(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	html := []byte("<!DOCTYPE html><html><script>alert(1)</script></html>")
+
+	cases := []struct {
+		name     string
+		ext      string
+		declared string
+		head     []byte
+		want     string
+		wantErr  bool
+	}{
+		{name: "plain text", ext: "txt", declared: "text/plain", head: []byte("hello"), want: "text/plain"},
+		{name: "declared type is ignored", ext: "png", declared: "application/octet-stream", head: png, want: "image/png"},
+		{name: "svg refined from xml sniff", ext: "svg", declared: "image/svg+xml", head: []byte(`<?xml version="1.0"?><svg/>`), want: "image/svg+xml"},
+		{name: "json refined from text sniff", ext: "json", head: []byte(`{"a":1}`), want: "application/json"},
+		{name: "html behind txt", ext: "txt", declared: "text/plain", head: html, wantErr: true},
+		{name: "html behind unknown extension", ext: "conf", head: html, wantErr: true},
+		{name: "html declared for txt", ext: "txt", declared: "text/html; charset=utf-8", head: []byte("hello"), wantErr: true},
+		{name: "image behind txt", ext: "txt", head: png, wantErr: true},
+		{name: "html for html", ext: "html", declared: "text/html", head: html, want: "text/html"},
+		{name: "ogg container refined by extension", ext: "ogg", head: []byte("OggS\x00\x02"), want: "audio/ogg"},
+	}
+	for _, tc := range cases {
+		got, err := ResolveFetchedMimeType(tc.ext, tc.declared, tc.head)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %q", tc.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestValidateUploadBatch(t *testing.T) {
+	quota := Quota{MaxSizeMB: 1, MaxPerUser: 5, MaxUploadFiles: 3, UploadDenyExts: []string{"exe"}}
+
+	if _, err := ValidateUploadBatch(quota, 0, 0, nil); err == nil {
+		t.Error("expected error for empty batch")
+	}
+	if _, err := ValidateUploadBatch(quota, 0, 0, make([]UploadItem, 4)); err == nil {
+		t.Error("expected error when batch exceeds maxUploadFiles")
+	}
+	if _, err := ValidateUploadBatch(quota, 3, 0, []UploadItem{{Name: "a.txt", Size: 1}, {Name: "b.txt", Size: 1}, {Name: "c.txt", Size: 1}}); err == nil {
+		t.Error("expected error when batch would exceed the per-user item limit")
+	}
+	if _, err := ValidateUploadBatch(quota, 2, 2, []UploadItem{{Name: "a.txt", Size: 1}, {Name: "b.txt", Size: 1}}); err == nil {
+		t.Error("expected new folders to count toward the per-user item limit")
+	}
+
+	itemErrs, err := ValidateUploadBatch(quota, 0, 0, []UploadItem{
+		{Name: "ok.txt", Size: 10},
+		{Name: "tool.exe", Size: 10},
+		{Name: "big.txt", Size: 2 << 20},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if itemErrs[0] != nil || itemErrs[1] == nil || itemErrs[2] == nil {
+		t.Fatalf("unexpected per-item errors: %v", itemErrs)
+	}
+}
+
+func TestSplitUploadPath(t *testing.T) {
+	dirs, name, err := SplitUploadPath(`photos\2024/ a.jpg `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "a.jpg" || len(dirs) != 2 || dirs[0] != "photos" || dirs[1] != "2024" {
+		t.Fatalf("unexpected split: dirs=%v name=%q", dirs, name)
+	}
+	if dirs, name, err := SplitUploadPath("a.txt"); err != nil || len(dirs) != 0 || name != "a.txt" {
+		t.Fatalf("plain file name: dirs=%v name=%q err=%v", dirs, name, err)
+	}
+	for _, rel := range []string{"", "/etc/passwd", "a//b.txt", "../b.txt", "a/./b.txt", "a/"} {
+		if _, _, err := SplitUploadPath(rel); err == nil {
+			t.Errorf("expected %q to be rejected", rel)
+		}
+	}
+}
+
+func TestValidateItemName(t *testing.T) {
+	if err := ValidateItemName(" notes.txt "); err != nil {
+		t.Fatalf("expected valid name, got %v", err)
+	}
+	for _, name := range []string{"", "  ", ".", "..", "a/b.txt", `a\b.txt`} {
+		if err := ValidateItemName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestLockOwnerSerializesSameOwner(t *testing.T) {
+	unlock := LockOwner("owner-a")
+
+	acquired := make(chan struct{})
+	go func() {
+		release := LockOwner("owner-a")
+		close(acquired)
+		release()
+	}()
+
+	// Another owner is not blocked.
+	LockOwner("owner-b")()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lock for the same owner acquired while held")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second lock not acquired after unlock")
+	}
+}
+
+func TestDetectUploadMimeType(t *testing.T) {
+	cases := []struct{ name, declared, want string }{
+		{"notes.md", "", "text/markdown"},
+		{"notes.md", "text/plain", "text/markdown"},
+		{"icon.ico", "application/octet-stream", "image/x-icon"},
+		{"photo.png", "image/png; charset=binary", "image/png"},
+		{"blob.unknownext", "", "application/octet-stream"},
+	}
+	for _, tc := range cases {
+		if got := DetectUploadMimeType(tc.name, tc.declared); got != tc.want {
+			t.Errorf("%s (%q): got %q, want %q", tc.name, tc.declared, got, tc.want)
+		}
+	}
+}
+
+func TestValidateMimeOverride(t *testing.T) {
+	if got, err := ValidateMimeOverride("readme.md", "Text/Markdown", nil); err != nil || got != "text/markdown" {
+		t.Fatalf("expected text/markdown, got %q, %v", got, err)
+	}
+	if _, err := ValidateMimeOverride("clip.bin", "video/x-custom", []string{"video/x-custom"}); err != nil {
+		t.Fatalf("expected extra type to be allowed, got %v", err)
+	}
+	for _, tc := range []struct{ name, mimeType string }{
+		{"a.txt", "not a type"},
+		{"a.txt", "application/x-unknown"},
+		{"a.txt", "image/svg+xml"},
+	} {
+		if _, err := ValidateMimeOverride(tc.name, tc.mimeType, nil); err == nil {
+			t.Errorf("%s as %q: expected error", tc.name, tc.mimeType)
+		}
+	}
+	if _, err := ValidateMimeOverride("logo.svg", "image/svg+xml", nil); err != nil {
+		t.Fatalf("expected svg for .svg to be allowed, got %v", err)
+	}
+}
+
+func TestValidateFolderName(t *testing.T) {
+	quota := Quota{DisallowedFolderNames: []string{"Secrets"}}
+
+	if err := ValidateFolderName(quota, "deploy", true); err == nil {
+		t.Error("expected reserved name to be rejected at root")
+	}
+	if err := ValidateFolderName(quota, "deploy", false); err != nil {
+		t.Errorf("expected reserved name to be allowed below root, got %v", err)
+	}
+	for _, atRoot := range []bool{true, false} {
+		if err := ValidateFolderName(quota, " secrets ", atRoot); err == nil {
+			t.Errorf("expected disallowed name to be rejected (atRoot=%v)", atRoot)
+		}
+	}
+	if err := ValidateFolderName(quota, "docs", true); err != nil {
+		t.Errorf("expected plain name to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package space
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Trash errors returned by MoveToTrash and Restore.
+var (
+	ErrAlreadyInTrash       = errors.New("item is already in trash")
+	ErrNotInTrash           = errors.New("item is not in trash")
+	ErrRestoreParentMissing = errors.New("the original folder no longer exists")
+	ErrRestoreParentTrashed = errors.New("the original folder is in trash; restore it first")
+	ErrRestoreNameTaken     = errors.New("an item with the same name already exists in the original folder")
+)
+
+// OriginalParent returns the folder a trashed item is restored into. Items
+// trashed before trashed_parent existed still carry their folder in parent.
+func (f *UserFile) OriginalParent() string {
+	if p := f.rec.GetString("trashed_parent"); p != "" {
+		return p
+	}
+	return f.Parent()
+}
+
+// MoveToTrash marks the item deleted, remembers its folder for Restore,
+// detaches it to the trash root and revokes any share. The contents of a
+// folder stay attached to it and come back with it.
+func MoveToTrash(app core.App, f *UserFile) error {
+	if f.IsDeleted() {
+		return ErrAlreadyInTrash
+	}
+	f.rec.Set("trashed_parent", f.Parent())
+	f.rec.Set("parent", "")
+	f.rec.Set("is_deleted", true)
+	f.RevokeShare()
+	return f.Save(app)
+}
+
+// Restore puts a trashed item back into its original folder. It fails when
+// that folder was purged or is itself in trash, or when the folder already
+// holds a live item of the same name.
+func Restore(app core.App, f *UserFile) error {
+	if !f.IsDeleted() {
+		return ErrNotInTrash
+	}
+	parent := f.OriginalParent()
+	if parent != "" {
+		rec, err := app.FindRecordById(Collection, parent)
+		if err != nil {
+			return ErrRestoreParentMissing
+		}
+		folder := From(rec)
+		if !folder.IsFolder() || !folder.IsOwnedByID(f.Owner()) {
+			return ErrRestoreParentMissing
+		}
+		if folder.IsDeleted() {
+			return ErrRestoreParentTrashed
+		}
+	}
+	n, err := app.CountRecords(Collection, dbx.HashExp{
+		"owner":      f.Owner(),
+		"parent":     parent,
+		"name":       f.Name(),
+		"is_deleted": false,
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrRestoreNameTaken
+	}
+	f.rec.Set("parent", parent)
+	f.rec.Set("trashed_parent", "")
+	f.rec.Set("is_deleted", false)
+	return f.Save(app)
+}
+
+// PurgeTrash permanently deletes every trashed item ownerID holds, together
+// with the contents of trashed folders, and returns how many records were
+// removed. The records go in one transaction; their stored files are then
+// removed from the PocketBase filesystem before PurgeTrash returns, rather
+// than by PocketBase's own background cleanup.
+func PurgeTrash(app core.App, ownerID string) (int, error) {
+	var purged []*core.Record
+	err := app.RunInTransaction(func(txApp core.App) error {
+		trashed, err := txApp.FindAllRecords(Collection, dbx.HashExp{"owner": ownerID, "is_deleted": true})
+		if err != nil {
+			return err
+		}
+		// Collect folder contents breadth-first; a trashed folder's
+		// children keep their own is_deleted = false.
+		queue := trashed
+		seen := map[string]bool{}
+		var all []*core.Record
+		for len(queue) > 0 {
+			rec := queue[0]
+			queue = queue[1:]
+			if seen[rec.Id] {
+				continue
+			}
+			seen[rec.Id] = true
+			all = append(all, rec)
+			if !rec.GetBool("is_folder") {
+				continue
+			}
+			children, err := txApp.FindAllRecords(Collection, dbx.HashExp{"owner": ownerID, "parent": rec.Id})
+			if err != nil {
+				return err
+			}
+			queue = append(queue, children...)
+		}
+		// Delete deepest first so no folder outlives its contents.
+		for i := len(all) - 1; i >= 0; i-- {
+			if err := txApp.Delete(all[i]); err != nil {
+				return err
+			}
+		}
+		purged = all
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleteStoredFiles(app, purged)
+	return len(purged), nil
+}
+
+// deleteStoredFiles removes the storage directories of deleted records.
+// Failures are logged: the records are already gone.
+func deleteStoredFiles(app core.App, records []*core.Record) {
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		app.Logger().Warn("space: open filesystem to purge trash", "error", err)
+		return
+	}
+	defer fsys.Close()
+	for _, rec := range records {
+		if rec.GetString("content") == "" {
+			continue
+		}
+		prefix := strings.TrimRight(rec.BaseFilesPath(), "/") + "/"
+		for _, err := range fsys.DeletePrefix(prefix) {
+			app.Logger().Warn("space: delete purged file", "prefix", prefix, "error", err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package space
 
 import (
 	"fmt"
+	"path"
 	"slices"
 	"strings"
 )
@@ -55,19 +56,116 @@ func ValidateItemCount(current, max int) error {
 	return nil
 }
 
-// IsReservedRootFolderName reports whether name is a system-reserved or
-// dynamically disallowed root folder name.
-func IsReservedRootFolderName(name string, extraDisallowed []string) bool {
+// IsReservedRootFolderName reports whether name is a system-reserved root
+// folder name.
+func IsReservedRootFolderName(name string) bool {
 	name = strings.ToLower(strings.TrimSpace(name))
 	for _, reserved := range strings.Split(ReservedFolderNames, ",") {
 		if name == strings.TrimSpace(reserved) {
 			return true
 		}
 	}
-	for _, d := range extraDisallowed {
+	return false
+}
+
+// IsDisallowedFolderName reports whether name is in the operator-configured
+// disallowed list, which applies at any depth.
+func IsDisallowedFolderName(name string, disallowed []string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, d := range disallowed {
 		if strings.ToLower(strings.TrimSpace(d)) == name {
 			return true
 		}
 	}
 	return false
 }
+
+// ValidateFolderName rejects reserved names for root folders (atRoot) and
+// names on the quota's disallowed list at any depth.
+func ValidateFolderName(quota Quota, name string, atRoot bool) error {
+	if atRoot && IsReservedRootFolderName(name) {
+		return fmt.Errorf("folder name %q is reserved by the system and cannot be used", strings.TrimSpace(name))
+	}
+	if IsDisallowedFolderName(name, quota.DisallowedFolderNames) {
+		return fmt.Errorf("folder name %q is not allowed by the space policy", strings.TrimSpace(name))
+	}
+	return nil
+}
+
+// ValidateItemName checks a file or folder display name: it must be
+// non-empty, not "." or "..", and free of path separators.
+func ValidateItemName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	return nil
+}
+
+// SplitUploadPath splits a file's path relative to the upload target (as
+// sent by a folder upload, e.g. "photos/2024/a.jpg") into its folder names
+// and file name. Backslashes are treated as separators; absolute paths and
+// empty, "." or ".." segments are rejected.
+func SplitUploadPath(rel string) (dirs []string, name string, err error) {
+	rel = strings.ReplaceAll(strings.TrimSpace(rel), "\\", "/")
+	if strings.HasPrefix(rel, "/") {
+		return nil, "", fmt.Errorf("upload path must be relative")
+	}
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." || segment == ".." {
+			return nil, "", fmt.Errorf("invalid upload path %q", rel)
+		}
+		segments[i] = segment
+	}
+	return segments[:len(segments)-1], segments[len(segments)-1], nil
+}
+
+// UploadItem describes one file of a batch upload.
+type UploadItem struct {
+	Name string
+	Size int64
+}
+
+// ValidateUploadBatch checks a whole batch against quota before anything is
+// stored: batch size, the per-user item limit (existingItems already used,
+// counting newFolders the batch will create alongside its files) and, per
+// item, extension and size. err is a batch-level failure; itemErrs
+// has one entry per item, nil for valid items. The batch is acceptable only
+// when err is nil and every itemErrs entry is nil.
+func ValidateUploadBatch(quota Quota, existingItems, newFolders int, items []UploadItem) (itemErrs []error, err error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no files uploaded")
+	}
+	if len(items) > quota.MaxUploadFiles {
+		return nil, fmt.Errorf("upload batch size %d exceeds maxUploadFiles (%d)", len(items), quota.MaxUploadFiles)
+	}
+	if quota.MaxPerUser > 0 && existingItems+newFolders+len(items) > quota.MaxPerUser {
+		return nil, fmt.Errorf(
+			"item limit reached (%d); %d item(s) used, cannot add %d more", quota.MaxPerUser, existingItems, newFolders+len(items),
+		)
+	}
+
+	maxBytes := int64(quota.MaxSizeMB) * 1024 * 1024
+	itemErrs = make([]error, len(items))
+	for i, item := range items {
+		ext := NormalizeExt(path.Ext(item.Name))
+		switch {
+		case strings.TrimSpace(item.Name) == "":
+			itemErrs[i] = fmt.Errorf("file name is required")
+		case ext == "":
+			itemErrs[i] = fmt.Errorf("file extension is missing")
+		case item.Size <= 0:
+			itemErrs[i] = fmt.Errorf("file is empty")
+		case maxBytes > 0 && item.Size > maxBytes:
+			itemErrs[i] = fmt.Errorf("file exceeds size limit (%d MB)", quota.MaxSizeMB)
+		default:
+			itemErrs[i] = ValidateExt(quota, ext)
+		}
+	}
+	return itemErrs, nil
+}
@@ -0,0 +1,156 @@
+package stepup
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/secrets"
+)
+
+// Collection stores one TOTP enrollment per auth record.
+const Collection = "stepup_totp"
+
+// Issuer is the account issuer shown by authenticator apps.
+const Issuer = "AppOS"
+
+var (
+	// ErrNotEnrolled means the auth record has no confirmed TOTP enrollment.
+	ErrNotEnrolled = errors.New("stepup: no confirmed TOTP enrollment")
+	// ErrInvalidCode means the code did not match or was already used.
+	ErrInvalidCode = errors.New("stepup: invalid TOTP code")
+)
+
+// Status describes an auth record's enrollment.
+type Status struct {
+	Enrolled  bool `json:"enrolled"`
+	Confirmed bool `json:"confirmed"`
+}
+
+func findEnrollment(app core.App, auth *core.Record) (*core.Record, error) {
+	record, err := app.FindFirstRecordByFilter(Collection,
+		"auth_collection = {:collection} && auth_id = {:id}",
+		dbx.Params{"collection": auth.Collection().Id, "id": auth.Id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return record, err
+}
+
+// GetStatus returns the enrollment status of auth.
+func GetStatus(app core.App, auth *core.Record) (Status, error) {
+	record, err := findEnrollment(app, auth)
+	if err != nil || record == nil {
+		return Status{}, err
+	}
+	return Status{Enrolled: true, Confirmed: record.GetBool("confirmed")}, nil
+}
+
+// Enroll generates a new secret for auth and stores it unconfirmed,
+// replacing any previous enrollment. The secret only takes effect after
+// Confirm; callers must verify the current code before re-enrolling a
+// confirmed record.
+func Enroll(app core.App, auth *core.Record) (secret, uri string, err error) {
+	secret, err = GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, err := secrets.EncryptPayload(map[string]any{"secret": secret})
+	if err != nil {
+		return "", "", fmt.Errorf("stepup: encrypt secret: %w", err)
+	}
+
+	record, err := findEnrollment(app, auth)
+	if err != nil {
+		return "", "", err
+	}
+	if record == nil {
+		col, colErr := app.FindCollectionByNameOrId(Collection)
+		if colErr != nil {
+			return "", "", colErr
+		}
+		record = core.NewRecord(col)
+		record.Set("auth_collection", auth.Collection().Id)
+		record.Set("auth_id", auth.Id)
+	}
+	record.Set("secret", encrypted)
+	record.Set("confirmed", false)
+	record.Set("last_step", 0)
+	if err := app.Save(record); err != nil {
+		return "", "", fmt.Errorf("stepup: save enrollment: %w", err)
+	}
+
+	account := auth.GetString("email")
+	if account == "" {
+		account = auth.Id
+	}
+	return secret, ProvisioningURI(Issuer, account, secret), nil
+}
+
+// Confirm activates a pending enrollment once code proves the authenticator
+// was set up.
+func Confirm(app core.App, auth *core.Record, code string, now time.Time) error {
+	record, err := findEnrollment(app, auth)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return ErrNotEnrolled
+	}
+	if err := consumeCode(app, record, code, now); err != nil {
+		return err
+	}
+	record.Set("confirmed", true)
+	return app.Save(record)
+}
+
+// Verify checks code against auth's confirmed enrollment. A code is accepted
+// once; replaying it fails with ErrInvalidCode.
+func Verify(app core.App, auth *core.Record, code string, now time.Time) error {
+	record, err := findEnrollment(app, auth)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.GetBool("confirmed") {
+		return ErrNotEnrolled
+	}
+	return consumeCode(app, record, code, now)
+}
+
+// Remove deletes auth's enrollment, if any.
+func Remove(app core.App, auth *core.Record) error {
+	record, err := findEnrollment(app, auth)
+	if err != nil || record == nil {
+		return err
+	}
+	return app.Delete(record)
+}
+
+func consumeCode(app core.App, record *core.Record, code string, now time.Time) error {
+	payload, err := secrets.DecryptPayload(record.GetString("secret"))
+	if err != nil {
+		return secrets.ReportDecryptFailure(app, secrets.NewDecryptError("stepup.totp", record.Id, err))
+	}
+	secret, _ := payload["secret"].(string)
+	step, ok := verifyCode(secret, code, now, int64(record.GetInt("last_step")))
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	// Conditional update so two concurrent requests cannot both spend the
+	// same code.
+	result, err := app.DB().NewQuery("UPDATE " + Collection + " SET last_step = {:step} WHERE id = {:id} AND last_step < {:step}").
+		Bind(dbx.Params{"step": step, "id": record.Id}).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("stepup: record code use: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvalidCode
+	}
+	record.Set("last_step", step)
+	return nil
+}
@@ -0,0 +1,41 @@
+package stepup
+
+import "github.com/pocketbase/pocketbase/core"
+
+// RegisterHooks enforces step-up on record-API actions that have no custom
+// route: deleting a superuser, and any write to enrollments or to the step-up
+// settings rows, which would otherwise switch step-up off without a code.
+func RegisterHooks(app core.App) {
+	app.OnRecordDeleteRequest(core.CollectionNameSuperusers).BindFunc(func(e *core.RecordRequestEvent) error {
+		if challenge := Check(e.RequestEvent, ActionSuperuserDelete); challenge != nil {
+			return challenge.Respond(e.RequestEvent)
+		}
+		return e.Next()
+	})
+
+	guard := func(e *core.RecordRequestEvent) error {
+		if e.Collection.Name == Collection || isSettingsRecord(e.Record) {
+			if challenge := Demand(e.RequestEvent, ActionChange); challenge != nil {
+				return challenge.Respond(e.RequestEvent)
+			}
+		}
+		return e.Next()
+	}
+	app.OnRecordCreateRequest(Collection, settingsCollection).BindFunc(guard)
+	app.OnRecordUpdateRequest(Collection, settingsCollection).BindFunc(guard)
+	app.OnRecordDeleteRequest(Collection, settingsCollection).BindFunc(guard)
+}
+
+// settingsCollection stores the security/stepup settings group.
+const settingsCollection = "custom_settings"
+
+// isSettingsRecord reports whether rec is, or was before this update, a row of
+// the step-up settings group (global or a profile's overrides).
+func isSettingsRecord(rec *core.Record) bool {
+	for _, r := range []*core.Record{rec, rec.Original()} {
+		if r != nil && r.GetString("module") == SettingsModule && r.GetString("key") == SettingsKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+// Package stepup implements TOTP step-up confirmation for dangerous actions.
+//
+// Login MFA protects a session once; step-up asks for a fresh TOTP code on
+// each designated request, so a stolen session token alone cannot power off
+// servers or delete superusers. Which actions require step-up is configured
+// in the security/stepup settings group; the code travels in the Header
+// request header.
+package stepup
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/audit"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// Header carries the TOTP code for a step-up protected request.
+const Header = "X-AppOS-TOTP"
+
+// Settings group listing the actions that require step-up.
+const (
+	SettingsModule = "security"
+	SettingsKey    = "stepup"
+)
+
+// Actions that can be configured to require step-up.
+const (
+	ActionServerPower         = "server.power"
+	ActionSuperuserDelete     = "superuser.delete"
+	ActionSecretPayloadUpdate = "secret.payload.update"
+	ActionDockerExec          = "docker.exec"
+	ActionTerminalBroadcast   = "terminal.broadcast"
+)
+
+// ActionChange covers weakening step-up itself: dropping actions from its
+// settings, or writing settings rows and enrollments through the record API.
+// It always requires a code and is not configurable.
+const ActionChange = "stepup.change"
+
+// Action describes one step-up capable action.
+type Action struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Actions lists every action that can require step-up.
+var Actions = []Action{
+	{ID: ActionServerPower, Title: "Power off or reboot a server"},
+	{ID: ActionSuperuserDelete, Title: "Delete a superuser"},
+	{ID: ActionSecretPayloadUpdate, Title: "Replace a secret's value"},
+	{ID: ActionDockerExec, Title: "Run an arbitrary docker command"},
+	{ID: ActionTerminalBroadcast, Title: "Run a command on many servers at once"},
+}
+
+// Reason codes returned in data.reason_code when a step-up check fails.
+const (
+	ReasonRequired    = "STEPUP_REQUIRED"
+	ReasonInvalid     = "STEPUP_INVALID"
+	ReasonNotEnrolled = "STEPUP_NOT_ENROLLED"
+)
+
+// KnownAction reports whether id names an entry of Actions.
+func KnownAction(id string) bool {
+	return slices.ContainsFunc(Actions, func(a Action) bool { return a.ID == id })
+}
+
+// RequiredActions returns the configured actions that require step-up.
+func RequiredActions(app core.App) []string {
+	cfg, _ := sysconfig.GetGroup(app, SettingsModule, SettingsKey, nil)
+	return sysconfig.StringSlice(cfg, "actions")
+}
+
+// Required reports whether action currently requires step-up.
+func Required(app core.App, action string) bool {
+	return slices.Contains(RequiredActions(app), action)
+}
+
+// ChallengeError is a failed step-up check. Respond writes it as a 403 with
+// data.reason_code so clients can prompt for a code and retry.
+type ChallengeError struct {
+	Action     string
+	ReasonCode string
+	Message    string
+}
+
+func (c *ChallengeError) Error() string {
+	return c.Message
+}
+
+// Respond writes c as the response of e.
+func (c *ChallengeError) Respond(e *core.RequestEvent) error {
+	return e.JSON(http.StatusForbidden, map[string]any{
+		"message": c.Message,
+		"data": map[string]any{
+			"reason_code": c.ReasonCode,
+			"action":      c.Action,
+			"header":      Header,
+		},
+	})
+}
+
+// Check enforces step-up for action on e. It returns nil when action does not
+// require step-up or Header holds a valid, unused code for the caller's
+// enrollment. Every challenge is audited.
+func Check(e *core.RequestEvent, action string) *ChallengeError {
+	if !Required(e.App, action) {
+		return nil
+	}
+	return Demand(e, action)
+}
+
+// Demand is Check for an action that always requires step-up, whatever the
+// settings say.
+func Demand(e *core.RequestEvent, action string) *ChallengeError {
+	var challenge *ChallengeError
+	code := strings.TrimSpace(e.Request.Header.Get(Header))
+	switch {
+	case e.Auth == nil:
+		challenge = &ChallengeError{Action: action, ReasonCode: ReasonRequired, Message: "step-up confirmation requires an authenticated session"}
+	case code == "":
+		challenge = &ChallengeError{Action: action, ReasonCode: ReasonRequired, Message: fmt.Sprintf("%s requires a TOTP code in the %s header", action, Header)}
+	default:
+		err := Verify(e.App, e.Auth, code, time.Now())
+		switch {
+		case errors.Is(err, ErrNotEnrolled):
+			challenge = &ChallengeError{Action: action, ReasonCode: ReasonNotEnrolled, Message: fmt.Sprintf("%s requires step-up; enroll a TOTP authenticator first", action)}
+		case err != nil:
+			challenge = &ChallengeError{Action: action, ReasonCode: ReasonInvalid, Message: "invalid or already used TOTP code"}
+		}
+	}
+
+	writeAudit(e, action, challenge)
+	return challenge
+}
+
+// Weakens reports whether moving the step-up settings from before to after
+// stops requiring any action.
+func Weakens(before, after map[string]any) bool {
+	next := sysconfig.StringSlice(after, "actions")
+	for _, action := range sysconfig.StringSlice(before, "actions") {
+		if !slices.Contains(next, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAudit(e *core.RequestEvent, action string, challenge *ChallengeError) {
+	entry := audit.Entry{
+		Action:       "stepup.verify",
+		ResourceType: "stepup",
+		ResourceID:   action,
+		ResourceName: action,
+		IP:           e.RealIP(),
+		UserAgent:    e.Request.UserAgent(),
+		Status:       audit.StatusSuccess,
+	}
+	if e.Auth != nil {
+		entry.UserID = e.Auth.Id
+		entry.UserEmail = e.Auth.GetString("email")
+	}
+	if challenge != nil {
+		entry.Status = audit.StatusFailed
+		entry.Detail = map[string]any{"reason_code": challenge.ReasonCode}
+	}
+	audit.Write(e.App, entry)
+}
@@ -0,0 +1,55 @@
+package stepup
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B SHA-1 vectors, truncated to 6 digits.
+func TestCodeMatchesRFC6238(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := Code(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Code at %d: got %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestVerifyCodeRejectsReplayAndSkew(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, _ := Code(secret, now)
+
+	step, ok := verifyCode(secret, code, now, 0)
+	if !ok || step != timeStep(now) {
+		t.Fatalf("expected current code to verify at step %d, got %d %v", timeStep(now), step, ok)
+	}
+	if _, ok := verifyCode(secret, code, now, step); ok {
+		t.Fatal("expected replayed code to be rejected")
+	}
+
+	previous, _ := Code(secret, now.Add(-30*time.Second))
+	if _, ok := verifyCode(secret, previous, now, 0); !ok {
+		t.Fatal("expected previous step to be accepted within skew")
+	}
+	stale, _ := Code(secret, now.Add(-90*time.Second))
+	if _, ok := verifyCode(secret, stale, now, 0); ok {
+		t.Fatal("expected code outside skew to be rejected")
+	}
+	if _, ok := verifyCode(secret, "12345", now, 0); ok {
+		t.Fatal("expected malformed code to be rejected")
+	}
+}
@@ -0,0 +1,179 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Default terminal size written to a cast header when the client never sent
+// a resize.
+const (
+	castDefaultCols = 80
+	castDefaultRows = 24
+)
+
+// CastRecorder records terminal output and resizes as an asciinema v2 cast
+// (https://docs.asciinema.org/manual/asciicast/v2/). Events are spooled to a
+// temporary file because the header needs the initial terminal size, which
+// only arrives with the client's first resize. Once maxBytes of output have
+// been recorded further output is dropped and Truncated reports true.
+type CastRecorder struct {
+	mu        sync.Mutex
+	start     time.Time
+	file      *os.File
+	events    *bufio.Writer
+	cols      int
+	rows      int
+	sized     bool
+	pending   []byte
+	recorded  int64
+	maxBytes  int64
+	truncated bool
+	err       error
+}
+
+// NewCastRecorder starts a recording at the current time.
+func NewCastRecorder(maxBytes int64) (*CastRecorder, error) {
+	f, err := os.CreateTemp("", "appos-cast-*")
+	if err != nil {
+		return nil, fmt.Errorf("create cast spool: %w", err)
+	}
+	return &CastRecorder{
+		start:    time.Now(),
+		file:     f,
+		events:   bufio.NewWriter(f),
+		cols:     castDefaultCols,
+		rows:     castDefaultRows,
+		maxBytes: maxBytes,
+	}, nil
+}
+
+// Output records bytes written to the terminal. A multi-byte UTF-8 sequence
+// split across calls is held back until it is complete, since cast events
+// are JSON strings.
+func (r *CastRecorder) Output(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.truncated || len(p) == 0 {
+		return
+	}
+	data := append(r.pending, p...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut == 0 {
+		return
+	}
+	if r.recorded+int64(cut) > r.maxBytes {
+		r.truncated = true
+		r.pending = nil
+		return
+	}
+	r.recorded += int64(cut)
+	r.writeEvent("o", string(data[:cut]))
+}
+
+// Resize records a terminal size change. The first size becomes the cast
+// header's width and height; later ones are written as resize events.
+func (r *CastRecorder) Resize(rows, cols uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.sized {
+		r.cols, r.rows, r.sized = int(cols), int(rows), true
+		return
+	}
+	r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *CastRecorder) writeEvent(code, data string) {
+	if r.err != nil {
+		return
+	}
+	elapsed := time.Since(r.start).Seconds()
+	line, _ := json.Marshal([]any{elapsed, code, data})
+	line = append(line, '\n')
+	_, r.err = r.events.Write(line)
+}
+
+// Truncated reports whether output was dropped after reaching maxBytes.
+func (r *CastRecorder) Truncated() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.truncated
+}
+
+// WriteTo writes the cast, header first, to w.
+func (r *CastRecorder) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return 0, fmt.Errorf("write cast spool: %w", r.err)
+	}
+	if err := r.events.Flush(); err != nil {
+		return 0, fmt.Errorf("flush cast spool: %w", err)
+	}
+	header, _ := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     r.cols,
+		"height":    r.rows,
+		"timestamp": r.start.Unix(),
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	n, err := w.Write(append(header, '\n'))
+	if err != nil {
+		return int64(n), err
+	}
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return int64(n), fmt.Errorf("rewind cast spool: %w", err)
+	}
+	copied, err := io.Copy(w, r.file)
+	return int64(n) + copied, err
+}
+
+// Close removes the spool file.
+func (r *CastRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.file.Close()
+	return os.Remove(r.file.Name())
+}
+
+// RecordSession wraps sess so that its output and successful resizes are
+// recorded to rec.
+func RecordSession(sess Session, rec *CastRecorder) Session {
+	return &recordingSession{Session: sess, rec: rec}
+}
+
+type recordingSession struct {
+	Session
+	rec *CastRecorder
+}
+
+func (s *recordingSession) Read(p []byte) (int, error) {
+	n, err := s.Session.Read(p)
+	if n > 0 {
+		s.rec.Output(p[:n])
+	}
+	return n, err
+}
+
+func (s *recordingSession) Resize(rows, cols uint16) error {
+	if err := s.Session.Resize(rows, cols); err != nil {
+		return err
+	}
+	s.rec.Resize(rows, cols)
+	return nil
+}
@@ -0,0 +1,160 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrChunkOffset is returned by WriteChunk when the staging file is shorter
+// than the requested offset, i.e. an earlier chunk is missing.
+var ErrChunkOffset = errors.New("sftp: chunk offset beyond end of upload")
+
+// ErrChunkTooLarge is returned by WriteChunk when src holds more than maxBytes.
+var ErrChunkTooLarge = errors.New("sftp: chunk too large")
+
+// ChunkedUpload is an upload assembled from sequential chunks in PartPath
+// and renamed to Dest when complete. Lock it while writing a chunk; Offset is
+// the number of bytes durably written so far.
+type ChunkedUpload struct {
+	sync.Mutex
+
+	ID        string
+	ServerID  string
+	UserID    string
+	Dest      string
+	PartPath  string
+	Size      int64
+	Overwrite bool
+	Offset    int64
+	// Config opens the SFTP session that removes PartPath on expiry.
+	Config ConnectorConfig
+
+	expiresAt time.Time
+}
+
+// chunkedUploads tracks in-progress chunked uploads by ID. Each access
+// extends an upload's deadline; uploads idle past it are dropped by
+// ExpireChunkedUploads.
+var chunkedUploads = struct {
+	mu      sync.Mutex
+	uploads map[string]*ChunkedUpload
+}{uploads: make(map[string]*ChunkedUpload)}
+
+// RegisterChunkedUpload adds u, keeping it for ttl after its last use.
+func RegisterChunkedUpload(u *ChunkedUpload, ttl time.Duration) {
+	chunkedUploads.mu.Lock()
+	defer chunkedUploads.mu.Unlock()
+	u.expiresAt = time.Now().Add(ttl)
+	chunkedUploads.uploads[u.ID] = u
+}
+
+// LookupChunkedUpload returns the upload with id and extends its deadline by
+// ttl. Expired uploads are not returned.
+func LookupChunkedUpload(id string, ttl time.Duration) (*ChunkedUpload, bool) {
+	chunkedUploads.mu.Lock()
+	defer chunkedUploads.mu.Unlock()
+	u, ok := chunkedUploads.uploads[id]
+	if !ok || time.Now().After(u.expiresAt) {
+		return nil, false
+	}
+	u.expiresAt = time.Now().Add(ttl)
+	return u, true
+}
+
+// RemoveChunkedUpload forgets the upload with id.
+func RemoveChunkedUpload(id string) {
+	chunkedUploads.mu.Lock()
+	defer chunkedUploads.mu.Unlock()
+	delete(chunkedUploads.uploads, id)
+}
+
+// ExpireChunkedUploads removes and returns the uploads whose deadline passed
+// before now, so the caller can delete their staging files.
+func ExpireChunkedUploads(now time.Time) []*ChunkedUpload {
+	chunkedUploads.mu.Lock()
+	defer chunkedUploads.mu.Unlock()
+	var expired []*ChunkedUpload
+	for id, u := range chunkedUploads.uploads {
+		if now.After(u.expiresAt) {
+			expired = append(expired, u)
+			delete(chunkedUploads.uploads, id)
+		}
+	}
+	return expired
+}
+
+// WriteChunk writes src to remotePath at offset and returns the bytes
+// written. The file is created when missing and cut back to offset first, so
+// a retried chunk replaces whatever a failed attempt left behind. Reading
+// more than maxBytes from src fails and leaves the file at offset, as does
+// cancelling ctx.
+func (c *SFTPClient) WriteChunk(ctx context.Context, remotePath string, offset int64, src io.Reader, maxBytes int64) (int64, error) {
+	if err := c.confine(remotePath); err != nil {
+		return 0, err
+	}
+	f, err := c.sftpClient.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		return 0, fmt.Errorf("sftp: open %q: %w", remotePath, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("sftp: stat %q: %w", remotePath, err)
+	}
+	if fi.Size() < offset {
+		return 0, fmt.Errorf("%w: %q has %d bytes, chunk starts at %d", ErrChunkOffset, remotePath, fi.Size(), offset)
+	}
+	if fi.Size() > offset {
+		if err := f.Truncate(offset); err != nil {
+			return 0, fmt.Errorf("sftp: truncate %q: %w", remotePath, err)
+		}
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("sftp: seek %q: %w", remotePath, err)
+	}
+
+	limited := ThrottleReader(ctx, contextReader{ctx, io.LimitReader(src, maxBytes+1)}, c.transferKBps)
+	n, err := io.Copy(f, limited)
+	if err == nil && n > maxBytes {
+		err = fmt.Errorf("%w: exceeds %d bytes", ErrChunkTooLarge, maxBytes)
+	}
+	if err != nil {
+		_ = f.Truncate(offset)
+		return 0, fmt.Errorf("sftp: write %q: %w", remotePath, err)
+	}
+	return n, nil
+}
+
+// CommitUpload renames the finished staging file to dest. Without overwrite
+// an existing dest is left untouched and ErrRemoteExists is returned.
+func (c *SFTPClient) CommitUpload(partPath, dest string, overwrite bool) error {
+	if err := c.confineEntry(partPath); err != nil {
+		return err
+	}
+	if err := c.confineEntry(dest); err != nil {
+		return err
+	}
+	if _, err := c.sftpClient.Lstat(dest); err == nil {
+		if !overwrite {
+			return fmt.Errorf("%w: %q", ErrRemoteExists, dest)
+		}
+		// posix-rename replaces dest atomically; fall back to remove+rename
+		// on servers without the extension.
+		if err := c.sftpClient.PosixRename(partPath, dest); err == nil {
+			return nil
+		}
+		if err := c.sftpClient.Remove(dest); err != nil {
+			return fmt.Errorf("sftp: remove %q: %w", dest, err)
+		}
+	}
+	if err := c.sftpClient.Rename(partPath, dest); err != nil {
+		return fmt.Errorf("sftp: rename %q→%q: %w", partPath, dest, err)
+	}
+	return nil
+}
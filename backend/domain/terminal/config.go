@@ -1,5 +1,7 @@
 package terminal
 
+import "github.com/websoft9/appos/backend/infra/sshconfig"
+
 // CredAuthType identifies the authentication method used to connect to a server.
 type CredAuthType string
 
@@ -8,6 +10,9 @@ const (
 	AuthMethodPassword CredAuthType = "password"
 	// AuthMethodPrivateKey authenticates using an SSH private key (PEM).
 	AuthMethodPrivateKey CredAuthType = "private_key"
+	// AuthMethodAgent authenticates with the keys held by the ssh-agent
+	// listening on SSH_AUTH_SOCK.
+	AuthMethodAgent CredAuthType = "agent"
 )
 
 // ConnectorConfig carries the transport parameters required to open a terminal connection.
@@ -20,12 +25,34 @@ type ConnectorConfig struct {
 	Port int
 	// User is the login username.
 	User string
-	// AuthType identifies the credential kind: AuthMethodPassword or AuthMethodPrivateKey.
-	// Unused for Docker exec.
+	// AuthType identifies the credential kind: AuthMethodPassword,
+	// AuthMethodPrivateKey or AuthMethodAgent. Unused for Docker exec.
 	AuthType CredAuthType
 	// Secret is the decrypted credential value (password or PEM private key).
-	// Unused for Docker exec.
+	// Unused for Docker exec and agent auth.
 	Secret string
+	// Passphrase decrypts Secret when it is an encrypted private key.
+	Passphrase string
+	// Password, when set with private key or agent auth, is tried after that
+	// method fails and answers sudo prompts. Unused for Docker exec.
+	Password string
 	// Shell overrides the login shell (empty = server default).
 	Shell string
+	// ProxyJump lists intermediate SSH hosts to tunnel through, in order.
+	// Unused for Docker exec.
+	ProxyJump []sshconfig.Hop
+	// DefaultDir is the directory an SSH session changes into at start and the
+	// initial SFTP listing path (empty = login default).
+	DefaultDir string
+	// Env holds variables exported at SSH or local session start.
+	Env map[string]string
+	// LegacySSH enables the weak SSH algorithms old servers still require.
+	// Unused for Docker exec.
+	LegacySSH bool
+	// Privilege is the resolved strategy Privileged uses for root-only
+	// commands; empty runs them unchanged. Unused for Docker exec.
+	Privilege PrivilegeEscalation
+	// RunAs switches SFTP sessions and AsUser commands to this account through
+	// Privilege; empty keeps the login user. Unused for Docker exec.
+	RunAs string
 }
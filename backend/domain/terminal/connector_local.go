@@ -21,12 +21,19 @@ type LocalSession struct {
 // LocalConnector creates local-host PTY sessions.
 type LocalConnector struct{}
 
-// NewLocalSession creates a local shell PTY session.
-func NewLocalSession(ctx context.Context, shell string) (*LocalSession, error) {
+// NewLocalSession creates a local shell PTY session. env is added to the
+// AppOS process environment, overriding variables of the same name.
+func NewLocalSession(ctx context.Context, shell string, env map[string]string) (*LocalSession, error) {
 	if shell == "" {
 		shell = "bash"
 	}
 	cmd := exec.CommandContext(ctx, shell)
+	if len(env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range sortedKeys(env) {
+			cmd.Env = append(cmd.Env, name+"="+env[name])
+		}
+	}
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("start local PTY: %w", err)
@@ -40,7 +47,7 @@ func NewLocalSession(ctx context.Context, shell string) (*LocalSession, error) {
 
 // Connect creates a new local shell PTY session.
 func (c *LocalConnector) Connect(ctx context.Context, cfg ConnectorConfig) (Session, error) {
-	sess, err := NewLocalSession(ctx, cfg.Shell)
+	sess, err := NewLocalSession(ctx, cfg.Shell, cfg.Env)
 	if err != nil {
 		return nil, NewConnectError(ErrCatSessionFailed, "failed to start local shell session", err)
 	}
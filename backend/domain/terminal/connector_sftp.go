@@ -2,17 +2,28 @@ package terminal
 
 import (
 	"context"
+	"crypto/md5"  // #nosec G501 -- md5 is offered for integrity comparison, not security
+	"crypto/sha1" // #nosec G505 -- sha1 is offered for integrity comparison, not security
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/sftp"
 	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/time/rate"
+
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 )
 
 const sftpMaxUploadBytes = 50 << 20 // 50 MB
@@ -23,19 +34,33 @@ const sftpMaxWriteBytes = 2 << 20   // 2 MB — consistent with the text-read li
 type SFTPClient struct {
 	sshClient  *cryptossh.Client
 	sftpClient *sftp.Client
+	defaultDir string
+	// transferKBps limits Download, Upload and Copy throughput; 0 = unlimited.
+	transferKBps int
+	// release frees the server slot held by this client, if any.
+	release func()
+	// runAs, when set, runs helper commands as runAs.RunAs like the SFTP
+	// session itself.
+	runAs *ConnectorConfig
+	// root, when set, confines every path to that directory; see SetRoot.
+	root string
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // NewSFTPClient dials SSH and opens an SFTP subsystem session.
 // The caller must call Close when done.
 func NewSFTPClient(ctx context.Context, cfg ConnectorConfig) (*SFTPClient, error) {
-	authMethod, err := AuthMethodFromConfig(cfg)
+	authMethods, releaseAuth, err := AuthMethodsFromConfig(cfg)
 	if err != nil {
 		return nil, NewConnectError(ErrCatCredentialInvalid, fmt.Sprintf("credential config error for user %q", cfg.User), err)
 	}
+	defer releaseAuth()
 
 	clientCfg := &cryptossh.ClientConfig{
 		User:    cfg.User,
-		Auth:    []cryptossh.AuthMethod{authMethod},
+		Auth:    authMethods,
 		Timeout: sshDialTimeout,
 	}
 
@@ -44,6 +69,9 @@ func NewSFTPClient(ctx context.Context, cfg ConnectorConfig) (*SFTPClient, error
 		return nil, NewConnectError(ErrCatCredentialInvalid, fmt.Sprintf("ssh host key verification setup failed for %q", cfg.Host), err)
 	}
 	clientCfg.HostKeyCallback = hostKeyCallback
+	if cfg.LegacySSH {
+		sshconfig.EnableLegacyAlgorithms(clientCfg)
+	}
 
 	addr := net.JoinHostPort(cfg.Host, fmt.Sprintf("%d", cfg.Port))
 
@@ -53,7 +81,7 @@ func NewSFTPClient(ctx context.Context, cfg ConnectorConfig) (*SFTPClient, error
 	}
 	ch := make(chan dialResult, 1)
 	go func() {
-		cl, err := cryptossh.Dial("tcp", addr, clientCfg)
+		cl, err := sshconfig.Dial(addr, clientCfg, cfg.ProxyJump)
 		ch <- dialResult{cl, err}
 	}()
 
@@ -68,19 +96,119 @@ func NewSFTPClient(ctx context.Context, cfg ConnectorConfig) (*SFTPClient, error
 		sshClient = r.client
 	}
 
+	if cfg.RunAs != "" {
+		sftpClient, err := openRunAsSFTP(sshClient, cfg)
+		if err != nil {
+			sshClient.Close()
+			return nil, err
+		}
+		return &SFTPClient{sshClient: sshClient, sftpClient: sftpClient, defaultDir: cfg.DefaultDir, runAs: &cfg}, nil
+	}
+
 	sftpClient, err := sftp.NewClient(sshClient)
 	if err != nil {
 		sshClient.Close()
 		return nil, NewConnectError(ErrCatSessionFailed, "SFTP subsystem not supported by server", err)
 	}
 
-	return &SFTPClient{sshClient: sshClient, sftpClient: sftpClient}, nil
+	return &SFTPClient{sshClient: sshClient, sftpClient: sftpClient, defaultDir: cfg.DefaultDir}, nil
+}
+
+// sftpServerCommand execs the first sftp-server found in the usual
+// distribution locations.
+const sftpServerCommand = `for p in /usr/lib/openssh/sftp-server /usr/libexec/openssh/sftp-server /usr/lib/ssh/sftp-server /usr/libexec/sftp-server /usr/lib/sftp-server; do [ -x "$p" ] && exec "$p"; done; echo "sftp-server not found" >&2; exit 127`
+
+// openRunAsSFTP starts sftp-server as cfg.RunAs through the server's sudo or
+// doas and speaks SFTP over the session's stdin/stdout, so every file
+// operation is subject to that account's permissions.
+func openRunAsSFTP(sshClient *cryptossh.Client, cfg ConnectorConfig) (*sftp.Client, error) {
+	command, err := cfg.AsUser(sftpServerCommand)
+	if err != nil {
+		return nil, NewConnectError(ErrCatSessionFailed, err.Error(), err)
+	}
+	// A missing account would otherwise surface as a bare broken pipe.
+	if _, err := runSessionCommand(sshClient, "id -u -- "+ShellQuote(cfg.RunAs), ""); err != nil {
+		return nil, NewConnectError(ErrCatSessionFailed, fmt.Sprintf("run-as user %q does not exist", cfg.RunAs), err)
+	}
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, NewConnectError(ErrCatSessionFailed, "SFTP session failed", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, NewConnectError(ErrCatSessionFailed, "SFTP session failed", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, NewConnectError(ErrCatSessionFailed, "SFTP session failed", err)
+	}
+	var stderr strings.Builder
+	session.Stderr = &stderr
+	if err := session.Start(command); err != nil {
+		session.Close()
+		return nil, NewConnectError(ErrCatSessionFailed, "SFTP session failed", err)
+	}
+	// sudo -S reads the password a byte at a time up to the newline, so the
+	// SFTP handshake that follows is left for sftp-server.
+	if password := cfg.privilegedStdin(command); password != "" {
+		if _, err := io.WriteString(stdin, password); err != nil {
+			session.Close()
+			return nil, NewConnectError(ErrCatSessionFailed, "SFTP session failed", err)
+		}
+	}
+
+	client, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		session.Close()
+		_ = session.Wait()
+		msg := fmt.Sprintf("cannot open SFTP as %q", cfg.RunAs)
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			msg += ": " + detail
+		}
+		return nil, NewConnectError(ErrCatSessionFailed, msg, err)
+	}
+	return client, nil
+}
+
+// DefaultDir returns the server's configured default directory, or "/".
+// A confined client falls back to its root when the default directory lies
+// outside it.
+func (c *SFTPClient) DefaultDir() string {
+	if c.root != "" && (c.defaultDir == "" || !withinRoot(path.Clean(c.defaultDir), c.root)) {
+		return c.root
+	}
+	if c.defaultDir == "" {
+		return "/"
+	}
+	return c.defaultDir
 }
 
-// Close releases SFTP and SSH connections.
+// SetTransferRate limits each subsequent Download, Upload and Copy to kbps
+// KB/s. Zero or a negative value removes the limit.
+func (c *SFTPClient) SetTransferRate(kbps int) {
+	c.transferKBps = max(kbps, 0)
+}
+
+// Close releases SFTP and SSH connections. It is safe to call more than once,
+// e.g. when an operator terminates a stream whose handler also closes it.
 func (c *SFTPClient) Close() error {
-	_ = c.sftpClient.Close()
-	return c.sshClient.Close()
+	c.closeOnce.Do(func() {
+		if c.release != nil {
+			defer c.release()
+		}
+		_ = c.sftpClient.Close()
+		c.closeErr = c.sshClient.Close()
+	})
+	return c.closeErr
+}
+
+// ReleaseOnClose makes Close also call release, e.g. to free the server slot
+// taken with AcquireServerSlot before the client was opened.
+func (c *SFTPClient) ReleaseOnClose(release func()) {
+	c.release = release
 }
 
 // DirEntry is a single file or directory entry returned by ListDir.
@@ -108,11 +236,28 @@ type FileAttrs struct {
 }
 
 func (c *SFTPClient) runRemoteCommand(cmd string) (string, error) {
-	session, err := c.sshClient.NewSession()
+	stdin := ""
+	if c.runAs != nil {
+		wrapped, err := c.runAs.AsUser(cmd)
+		if err != nil {
+			return "", fmt.Errorf("sftp: %w", err)
+		}
+		cmd, stdin = wrapped, c.runAs.privilegedStdin(wrapped)
+	}
+	return runSessionCommand(c.sshClient, cmd, stdin)
+}
+
+// runSessionCommand runs cmd in a new session on client and returns its
+// trimmed combined output.
+func runSessionCommand(client *cryptossh.Client, cmd, stdin string) (string, error) {
+	session, err := client.NewSession()
 	if err != nil {
 		return "", fmt.Errorf("sftp: ssh session: %w", err)
 	}
 	defer session.Close()
+	if stdin != "" {
+		session.Stdin = strings.NewReader(stdin)
+	}
 
 	out, err := session.CombinedOutput(cmd)
 	if err != nil {
@@ -181,56 +326,185 @@ func (c *SFTPClient) resolveGroupID(name string) (int, error) {
 	return gid, nil
 }
 
-// ListDir returns all entries (including dot-files) in the given remote path.
-func (c *SFTPClient) ListDir(dirPath string) ([]DirEntry, error) {
-	infos, err := c.sftpClient.ReadDir(dirPath)
+const (
+	// sftpListDefaultLimit is the page size used when the caller sets none.
+	sftpListDefaultLimit = 1000
+	// sftpListMaxLimit bounds a single page regardless of what was requested.
+	sftpListMaxLimit = 5000
+	// sftpListMaxEntries caps how many directory entries are considered at all;
+	// anything beyond it is dropped and the listing is marked truncated.
+	sftpListMaxEntries = 100000
+	// sftpListTimeout bounds the remote readdir; entries read before the
+	// deadline are still returned, marked truncated.
+	sftpListTimeout = 30 * time.Second
+)
+
+// ListOptions selects a page of a directory listing. Limit <= 0 uses
+// sftpListDefaultLimit; values above sftpListMaxLimit are clamped.
+type ListOptions struct {
+	Offset int
+	Limit  int
+}
+
+// DirListing is one page of a directory listing. Entries are sorted with
+// directories first, then by name, so offsets are stable between requests.
+// Total counts the entries considered; Truncated reports that the directory
+// held more than could be read (entry cap or timeout).
+type DirListing struct {
+	Entries   []DirEntry
+	Total     int
+	Offset    int
+	Limit     int
+	Truncated bool
+}
+
+// HasMore reports whether entries remain after this page.
+func (l DirListing) HasMore() bool {
+	return l.Offset+len(l.Entries) < l.Total
+}
+
+// ListDir returns one page of entries (including dot-files) in the given
+// remote path. Only entries on the returned page are Lstat'ed. A listing
+// that outlasts sftpListTimeout is cut short and marked Truncated;
+// cancelling ctx aborts it.
+func (c *SFTPClient) ListDir(ctx context.Context, dirPath string, opts ListOptions) (DirListing, error) {
+	if err := c.confine(dirPath); err != nil {
+		return DirListing{}, err
+	}
+	readCtx, cancel := context.WithTimeout(ctx, sftpListTimeout)
+	defer cancel()
+
+	infos, err := c.sftpClient.ReadDirContext(readCtx, dirPath)
+	timedOut := false
 	if err != nil {
-		return nil, fmt.Errorf("sftp: readdir %q: %w", dirPath, err)
+		if ctx.Err() != nil {
+			return DirListing{}, fmt.Errorf("sftp: readdir %q: %w", dirPath, ctx.Err())
+		}
+		if readCtx.Err() == nil || len(infos) == 0 {
+			return DirListing{}, fmt.Errorf("sftp: readdir %q: %w", dirPath, err)
+		}
+		timedOut = true
 	}
 
-	entries := make([]DirEntry, 0, len(infos))
-	for _, fi := range infos {
+	page, listing := pageDirInfos(infos, opts)
+	listing.Truncated = listing.Truncated || timedOut
+
+	listing.Entries = make([]DirEntry, 0, len(page))
+	for _, fi := range page {
+		if err := ctx.Err(); err != nil {
+			return DirListing{}, fmt.Errorf("sftp: list %q: %w", dirPath, err)
+		}
 		fullPath := path.Join(dirPath, fi.Name())
 		if lfi, lerr := c.sftpClient.Lstat(fullPath); lerr == nil {
 			fi = lfi
 		}
+		listing.Entries = append(listing.Entries, dirEntryFromInfo(fi))
+	}
+	return listing, nil
+}
+
+// pageDirInfos applies the entry cap, sorts infos (directories first, then
+// name) and slices out the page selected by opts. The returned listing has
+// everything but Entries filled in.
+func pageDirInfos(infos []os.FileInfo, opts ListOptions) ([]os.FileInfo, DirListing) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = sftpListDefaultLimit
+	}
+	if limit > sftpListMaxLimit {
+		limit = sftpListMaxLimit
+	}
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	listing := DirListing{Offset: offset, Limit: limit}
+	if len(infos) > sftpListMaxEntries {
+		infos = infos[:sftpListMaxEntries]
+		listing.Truncated = true
+	}
+	listing.Total = len(infos)
 
-		t := "file"
-		if fi.IsDir() {
-			t = "dir"
-		} else if fi.Mode()&os.ModeSymlink != 0 {
-			t = "symlink"
+	sort.SliceStable(infos, func(i, j int) bool {
+		di, dj := infos[i].IsDir(), infos[j].IsDir()
+		if di != dj {
+			return di
 		}
-		entries = append(entries, DirEntry{
-			Name:       fi.Name(),
-			Type:       t,
-			Size:       fi.Size(),
-			Mode:       fi.Mode().String(),
-			ModifiedAt: fi.ModTime().UTC(),
-		})
+		return infos[i].Name() < infos[j].Name()
+	})
+
+	if offset >= len(infos) {
+		return nil, listing
 	}
-	return entries, nil
+	end := offset + limit
+	if end > len(infos) {
+		end = len(infos)
+	}
+	return infos[offset:end], listing
 }
 
-// Download streams the remote file to dst (e.g. http.ResponseWriter).
-func (c *SFTPClient) Download(remotePath string, dst io.Writer) error {
+func dirEntryFromInfo(fi os.FileInfo) DirEntry {
+	t := "file"
+	if fi.IsDir() {
+		t = "dir"
+	} else if fi.Mode()&os.ModeSymlink != 0 {
+		t = "symlink"
+	}
+	return DirEntry{
+		Name:       fi.Name(),
+		Type:       t,
+		Size:       fi.Size(),
+		Mode:       fi.Mode().String(),
+		ModifiedAt: fi.ModTime().UTC(),
+	}
+}
+
+// Download streams the remote file to dst (e.g. http.ResponseWriter) until
+// it ends or ctx is cancelled.
+func (c *SFTPClient) Download(ctx context.Context, remotePath string, dst io.Writer) error {
+	if err := c.confine(remotePath); err != nil {
+		return err
+	}
 	f, err := c.sftpClient.Open(remotePath)
 	if err != nil {
 		return fmt.Errorf("sftp: open %q: %w", remotePath, err)
 	}
 	defer f.Close()
-	_, err = io.Copy(dst, f)
+	_, err = io.Copy(ThrottleWriter(ctx, contextWriter{ctx, dst}, c.transferKBps), f)
 	return err
 }
 
+// ErrRemoteExists is returned by Upload when the destination already exists
+// and overwrite was not requested.
+var ErrRemoteExists = errors.New("sftp: destination already exists")
+
 // Upload writes src to remotePath. The total read from src must not exceed
 // sftpMaxUploadBytes (50 MB); excess bytes cause an error without data corruption
-// because the remote file is only committed on success.
-func (c *SFTPClient) Upload(remotePath string, src io.Reader) error {
-	limited := io.LimitReader(src, sftpMaxUploadBytes+1)
+// because the remote file is only committed on success. Without overwrite an
+// existing destination is left untouched and ErrRemoteExists is returned; the
+// file is then created exclusively so a concurrent writer cannot be clobbered.
+// Cancelling ctx aborts the transfer.
+func (c *SFTPClient) Upload(ctx context.Context, remotePath string, src io.Reader, overwrite bool) error {
+	if err := c.confine(remotePath); err != nil {
+		return err
+	}
+	limited := ThrottleReader(ctx, contextReader{ctx, io.LimitReader(src, sftpMaxUploadBytes+1)}, c.transferKBps)
 
-	f, err := c.sftpClient.Create(remotePath)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		if _, err := c.sftpClient.Lstat(remotePath); err == nil {
+			return fmt.Errorf("%w: %q", ErrRemoteExists, remotePath)
+		}
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	f, err := c.sftpClient.OpenFile(remotePath, flags)
 	if err != nil {
+		if !overwrite {
+			if _, statErr := c.sftpClient.Lstat(remotePath); statErr == nil {
+				return fmt.Errorf("%w: %q", ErrRemoteExists, remotePath)
+			}
+		}
 		return fmt.Errorf("sftp: create %q: %w", remotePath, err)
 	}
 	defer f.Close()
@@ -249,6 +523,9 @@ func (c *SFTPClient) Upload(remotePath string, src io.Reader) error {
 
 // Mkdir creates the directory at path (does not create intermediate directories).
 func (c *SFTPClient) Mkdir(dirPath string) error {
+	if err := c.confine(dirPath); err != nil {
+		return err
+	}
 	if err := c.sftpClient.Mkdir(dirPath); err != nil {
 		return fmt.Errorf("sftp: mkdir %q: %w", dirPath, err)
 	}
@@ -257,6 +534,12 @@ func (c *SFTPClient) Mkdir(dirPath string) error {
 
 // Rename moves/renames from→to.
 func (c *SFTPClient) Rename(from, to string) error {
+	if err := c.confineEntry(from); err != nil {
+		return err
+	}
+	if err := c.confineEntry(to); err != nil {
+		return err
+	}
 	if err := c.sftpClient.Rename(from, to); err != nil {
 		return fmt.Errorf("sftp: rename %q→%q: %w", from, to, err)
 	}
@@ -265,6 +548,9 @@ func (c *SFTPClient) Rename(from, to string) error {
 
 // Delete removes a file or an empty directory.
 func (c *SFTPClient) Delete(filePath string) error {
+	if err := c.confineEntry(filePath); err != nil {
+		return err
+	}
 	fi, err := c.sftpClient.Lstat(filePath)
 	if err != nil {
 		return fmt.Errorf("sftp: stat %q: %w", filePath, err)
@@ -287,8 +573,92 @@ func (c *SFTPClient) Delete(filePath string) error {
 	return nil
 }
 
+// DeleteFailure is one path a recursive delete could not remove.
+type DeleteFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// DeleteError is returned by DeleteRecursive when part of the tree could not
+// be removed. Everything else has been removed; the directories containing
+// the failed paths are left in place and not listed.
+type DeleteError struct {
+	Failed []DeleteFailure
+}
+
+func (e *DeleteError) Error() string {
+	paths := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		paths[i] = f.Path
+	}
+	return fmt.Sprintf("sftp: %d path(s) could not be removed: %s", len(e.Failed), strings.Join(paths, ", "))
+}
+
+// DeleteRecursive removes filePath and, when it is a directory, everything
+// under it: files first, then directories deepest first. Symlinks are removed
+// themselves and never followed. Removal carries on past failures and
+// reports them together as a *DeleteError.
+func (c *SFTPClient) DeleteRecursive(filePath string) error {
+	if err := c.confineEntry(filePath); err != nil {
+		return err
+	}
+	fi, err := c.sftpClient.Lstat(filePath)
+	if err != nil {
+		return fmt.Errorf("sftp: stat %q: %w", filePath, err)
+	}
+	if !fi.IsDir() {
+		return c.Delete(filePath)
+	}
+
+	var dirs []string
+	var failed []DeleteFailure
+	// Walk lstats entries and visits a directory before its children.
+	walker := c.sftpClient.Walk(filePath)
+	for walker.Step() {
+		p := walker.Path()
+		if err := walker.Err(); err != nil {
+			failed = append(failed, DeleteFailure{Path: p, Error: err.Error()})
+			continue
+		}
+		if walker.Stat().IsDir() {
+			dirs = append(dirs, p)
+			continue
+		}
+		if err := c.sftpClient.Remove(p); err != nil {
+			failed = append(failed, DeleteFailure{Path: p, Error: err.Error()})
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		if containsFailure(failed, dir) {
+			continue
+		}
+		if err := c.sftpClient.RemoveDirectory(dir); err != nil {
+			failed = append(failed, DeleteFailure{Path: dir, Error: err.Error()})
+		}
+	}
+	if len(failed) > 0 {
+		return &DeleteError{Failed: failed}
+	}
+	return nil
+}
+
+// containsFailure reports whether a failed path lies at or below dir.
+func containsFailure(failed []DeleteFailure, dir string) bool {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for _, f := range failed {
+		if f.Path == dir || strings.HasPrefix(f.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // ReadFile reads up to maxBytes of a remote file and returns it as a string.
 func (c *SFTPClient) ReadFile(filePath string, maxBytes int64) (string, error) {
+	if err := c.confine(filePath); err != nil {
+		return "", err
+	}
 	f, err := c.sftpClient.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("sftp: open %q: %w", filePath, err)
@@ -306,6 +676,77 @@ func (c *SFTPClient) ReadFile(filePath string, maxBytes int64) (string, error) {
 	return string(data), nil
 }
 
+// checksumCommands maps supported checksum algorithms to the coreutils
+// binary that computes them on the remote host.
+var checksumCommands = map[string]string{
+	"sha256": "sha256sum",
+	"sha1":   "sha1sum",
+	"md5":    "md5sum",
+}
+
+// IsChecksumAlgorithm reports whether algo is supported by Checksum.
+func IsChecksumAlgorithm(algo string) bool {
+	_, ok := checksumCommands[algo]
+	return ok
+}
+
+// Checksum returns the hex digest of a remote file. It prefers running the
+// matching coreutils command (sha256sum, sha1sum, md5sum) over SSH so the file
+// never crosses the wire, and falls back to streaming the file through a local
+// hash when the command is unavailable or fails. Cancelling ctx stops the
+// fallback read.
+func (c *SFTPClient) Checksum(ctx context.Context, filePath, algo string) (string, error) {
+	if err := c.confine(filePath); err != nil {
+		return "", err
+	}
+	command, ok := checksumCommands[algo]
+	if !ok {
+		return "", fmt.Errorf("sftp: unsupported checksum algorithm %q", algo)
+	}
+
+	fi, err := c.sftpClient.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("sftp: stat %q: %w", filePath, err)
+	}
+	if fi.IsDir() {
+		return "", fmt.Errorf("sftp: %q is a directory", filePath)
+	}
+
+	if out, err := c.runRemoteCommand(command + " -- " + ShellQuote(filePath)); err == nil {
+		if fields := strings.Fields(out); len(fields) > 0 && isHexDigest(fields[0], algo) {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	var h hash.Hash
+	switch algo {
+	case "sha1":
+		h = sha1.New() // #nosec G401 -- integrity comparison only
+	case "md5":
+		h = md5.New() // #nosec G401 -- integrity comparison only
+	default:
+		h = sha256.New()
+	}
+	f, err := c.sftpClient.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("sftp: open %q: %w", filePath, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(contextWriter{ctx, h}, f); err != nil {
+		return "", fmt.Errorf("sftp: read %q: %w", filePath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func isHexDigest(s, algo string) bool {
+	want := map[string]int{"sha256": 64, "sha1": 40, "md5": 32}[algo]
+	if len(s) != want {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
 // SearchResult is a single match returned by SearchFiles.
 type SearchResult struct {
 	Path       string    `json:"path"`
@@ -316,16 +757,29 @@ type SearchResult struct {
 	ModifiedAt time.Time `json:"modified_at"`
 }
 
-const searchMaxResults = 500
+const (
+	searchMaxResults = 500
+	// searchMaxVisited caps how many filesystem nodes one search may walk, so
+	// a query against a huge tree cannot run unbounded.
+	searchMaxVisited = 50000
+)
 
 // SearchFiles recursively walks basePath and returns entries whose names
-// contain query (case-insensitive). Returns at most searchMaxResults results.
-func (c *SFTPClient) SearchFiles(basePath, query string) ([]SearchResult, error) {
+// contain query (case-insensitive). It stops after searchMaxResults matches or
+// searchMaxVisited walked nodes; truncated reports that either limit was hit.
+// Cancelling ctx stops the walk with ctx's error.
+func (c *SFTPClient) SearchFiles(ctx context.Context, basePath, query string) (results []SearchResult, truncated bool, err error) {
+	if err := c.confine(basePath); err != nil {
+		return nil, false, err
+	}
 	q := strings.ToLower(query)
-	var results []SearchResult
+	visited := 0
 
 	walker := c.sftpClient.Walk(basePath)
 	for walker.Step() {
+		if err := ctx.Err(); err != nil {
+			return nil, false, fmt.Errorf("sftp: search %q: %w", basePath, err)
+		}
 		if walker.Err() != nil {
 			continue // skip unreadable dirs
 		}
@@ -333,34 +787,35 @@ func (c *SFTPClient) SearchFiles(basePath, query string) ([]SearchResult, error)
 		if p == basePath {
 			continue // skip root
 		}
+		visited++
+		if visited > searchMaxVisited {
+			return results, true, nil
+		}
 		fi := walker.Stat()
-		name := fi.Name()
-		if !strings.Contains(strings.ToLower(name), q) {
+		if !strings.Contains(strings.ToLower(fi.Name()), q) {
 			continue
 		}
-		t := "file"
-		if fi.IsDir() {
-			t = "dir"
-		} else if fi.Mode()&os.ModeSymlink != 0 {
-			t = "symlink"
-		}
+		entry := dirEntryFromInfo(fi)
 		results = append(results, SearchResult{
 			Path:       p,
-			Name:       name,
-			Type:       t,
-			Size:       fi.Size(),
-			Mode:       fi.Mode().String(),
-			ModifiedAt: fi.ModTime().UTC(),
+			Name:       entry.Name,
+			Type:       entry.Type,
+			Size:       entry.Size,
+			Mode:       entry.Mode,
+			ModifiedAt: entry.ModifiedAt,
 		})
 		if len(results) >= searchMaxResults {
-			break
+			return results, true, nil
 		}
 	}
-	return results, nil
+	return results, false, nil
 }
 
 // WriteFile writes content to a remote file, creating or truncating it.
 func (c *SFTPClient) WriteFile(filePath string, content string) error {
+	if err := c.confine(filePath); err != nil {
+		return err
+	}
 	if int64(len(content)) > sftpMaxWriteBytes {
 		return fmt.Errorf("sftp: content exceeds %d bytes limit", sftpMaxWriteBytes)
 	}
@@ -378,6 +833,9 @@ func (c *SFTPClient) WriteFile(filePath string, content string) error {
 
 // MkdirAll creates a directory tree on the remote server.
 func (c *SFTPClient) MkdirAll(target string) error {
+	if err := c.confine(target); err != nil {
+		return err
+	}
 	if strings.TrimSpace(target) == "" {
 		return fmt.Errorf("sftp: target path is required")
 	}
@@ -389,6 +847,9 @@ func (c *SFTPClient) MkdirAll(target string) error {
 
 // Stat returns full metadata for a file or directory.
 func (c *SFTPClient) Stat(filePath string) (FileAttrs, error) {
+	if err := c.confine(filePath); err != nil {
+		return FileAttrs{}, err
+	}
 	fi, err := c.sftpClient.Stat(filePath)
 	if err != nil {
 		return FileAttrs{}, fmt.Errorf("sftp: stat %q: %w", filePath, err)
@@ -435,6 +896,9 @@ func (c *SFTPClient) Stat(filePath string) (FileAttrs, error) {
 
 // Chmod updates remote file mode.
 func (c *SFTPClient) Chmod(filePath string, mode os.FileMode) error {
+	if err := c.confine(filePath); err != nil {
+		return err
+	}
 	if err := c.sftpClient.Chmod(filePath, mode); err != nil {
 		return fmt.Errorf("sftp: chmod %q: %w", filePath, err)
 	}
@@ -443,6 +907,9 @@ func (c *SFTPClient) Chmod(filePath string, mode os.FileMode) error {
 
 // ChmodRecursive updates mode for the path and all children when path is a directory.
 func (c *SFTPClient) ChmodRecursive(filePath string, mode os.FileMode) error {
+	if err := c.confine(filePath); err != nil {
+		return err
+	}
 	fi, err := c.sftpClient.Lstat(filePath)
 	if err != nil {
 		return fmt.Errorf("sftp: stat %q: %w", filePath, err)
@@ -456,6 +923,12 @@ func (c *SFTPClient) ChmodRecursive(filePath string, mode os.FileMode) error {
 		if walker.Err() != nil {
 			continue
 		}
+		// Chmod follows symlinks, so a link must not lead out of the root.
+		if walker.Stat().Mode()&os.ModeSymlink != 0 {
+			if err := c.confine(walker.Path()); err != nil {
+				continue
+			}
+		}
 		if err := c.sftpClient.Chmod(walker.Path(), mode); err != nil {
 			return fmt.Errorf("sftp: chmod recursive %q: %w", walker.Path(), err)
 		}
@@ -465,6 +938,9 @@ func (c *SFTPClient) ChmodRecursive(filePath string, mode os.FileMode) error {
 
 // Chown updates remote uid/gid.
 func (c *SFTPClient) Chown(filePath string, uid, gid int) error {
+	if err := c.confine(filePath); err != nil {
+		return err
+	}
 	if err := c.sftpClient.Chown(filePath, uid, gid); err != nil {
 		return fmt.Errorf("sftp: chown %q: %w", filePath, err)
 	}
@@ -486,6 +962,18 @@ func (c *SFTPClient) ChownByName(filePath, ownerName, groupName string) error {
 
 // Symlink creates a symbolic link from linkPath -> target.
 func (c *SFTPClient) Symlink(target, linkPath string) error {
+	if err := c.confineEntry(linkPath); err != nil {
+		return err
+	}
+	if c.root != "" {
+		resolved := target
+		if !path.IsAbs(resolved) {
+			resolved = path.Join(path.Dir(linkPath), resolved)
+		}
+		if err := c.confine(resolved); err != nil {
+			return err
+		}
+	}
 	if err := c.sftpClient.Symlink(target, linkPath); err != nil {
 		return fmt.Errorf("sftp: symlink %q -> %q: %w", linkPath, target, err)
 	}
@@ -493,20 +981,29 @@ func (c *SFTPClient) Symlink(target, linkPath string) error {
 }
 
 // Copy recursively copies file/dir from source to target.
-// onProgress is called with copied and total bytes for files.
-func (c *SFTPClient) Copy(source, target string, onProgress func(copied, total int64)) (int64, error) {
+// onProgress is called with copied and total bytes for files. Cancelling
+// ctx stops the copy and removes the file being written.
+func (c *SFTPClient) Copy(ctx context.Context, source, target string, onProgress func(copied, total int64)) (int64, error) {
+	if err := c.confine(source); err != nil {
+		return 0, err
+	}
+	if err := c.confine(target); err != nil {
+		return 0, err
+	}
 	fi, err := c.sftpClient.Stat(source)
 	if err != nil {
 		return 0, fmt.Errorf("sftp: stat %q: %w", source, err)
 	}
 
+	// One limiter spans the whole copy, including every file of a directory.
+	limiter := newTransferLimiter(c.transferKBps)
 	if fi.IsDir() {
-		return 0, c.copyDir(source, target)
+		return 0, c.copyDir(ctx, source, target, limiter)
 	}
 
 	total := fi.Size()
 	var copied int64
-	if err := c.copyFile(source, target, func(n int64) {
+	if err := c.copyFile(ctx, source, target, limiter, func(n int64) {
 		copied += n
 		if onProgress != nil {
 			onProgress(copied, total)
@@ -518,7 +1015,7 @@ func (c *SFTPClient) Copy(source, target string, onProgress func(copied, total i
 	return copied, nil
 }
 
-func (c *SFTPClient) copyDir(source, target string) error {
+func (c *SFTPClient) copyDir(ctx context.Context, source, target string, limiter *rate.Limiter) error {
 	if err := c.sftpClient.MkdirAll(target); err != nil {
 		return fmt.Errorf("sftp: mkdirall %q: %w", target, err)
 	}
@@ -529,27 +1026,35 @@ func (c *SFTPClient) copyDir(source, target string) error {
 	}
 
 	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("sftp: copy %q: %w", source, err)
+		}
 		src := path.Join(source, item.Name())
 		dst := path.Join(target, item.Name())
+		// Copying opens symlinked entries, so each must stay inside the root.
+		if err := c.confine(src); err != nil {
+			return err
+		}
 		if item.IsDir() {
-			if err := c.copyDir(src, dst); err != nil {
+			if err := c.copyDir(ctx, src, dst, limiter); err != nil {
 				return err
 			}
 			continue
 		}
-		if err := c.copyFile(src, dst, nil); err != nil {
+		if err := c.copyFile(ctx, src, dst, limiter, nil); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (c *SFTPClient) copyFile(source, target string, onChunk func(n int64)) error {
-	src, err := c.sftpClient.Open(source)
+func (c *SFTPClient) copyFile(ctx context.Context, source, target string, limiter *rate.Limiter, onChunk func(n int64)) error {
+	f, err := c.sftpClient.Open(source)
 	if err != nil {
 		return fmt.Errorf("sftp: open %q: %w", source, err)
 	}
-	defer src.Close()
+	defer f.Close()
+	src := throttleReader(ctx, contextReader{ctx, f}, limiter)
 
 	dst, err := c.sftpClient.Create(target)
 	if err != nil {
@@ -1,9 +1,13 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +15,9 @@ import (
 	"context"
 
 	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 )
 
 const sshDialTimeout = 10 * time.Second
@@ -24,14 +31,15 @@ type SSHConnector struct{}
 
 // Connect opens an SSH connection and returns a Session backed by a remote PTY.
 func (c *SSHConnector) Connect(ctx context.Context, cfg ConnectorConfig) (Session, error) {
-	authMethod, err := AuthMethodFromConfig(cfg)
+	authMethods, releaseAuth, err := AuthMethodsFromConfig(cfg)
 	if err != nil {
 		return nil, NewConnectError(ErrCatCredentialInvalid, fmt.Sprintf("credential config error for user %q", cfg.User), err)
 	}
+	defer releaseAuth()
 
 	clientCfg := &cryptossh.ClientConfig{
 		User:    cfg.User,
-		Auth:    []cryptossh.AuthMethod{authMethod},
+		Auth:    authMethods,
 		Timeout: sshDialTimeout,
 	}
 
@@ -40,6 +48,9 @@ func (c *SSHConnector) Connect(ctx context.Context, cfg ConnectorConfig) (Sessio
 		return nil, NewConnectError(ErrCatCredentialInvalid, fmt.Sprintf("ssh host key verification setup failed for %q", cfg.Host), err)
 	}
 	clientCfg.HostKeyCallback = hostKeyCallback
+	if cfg.LegacySSH {
+		sshconfig.EnableLegacyAlgorithms(clientCfg)
+	}
 
 	addr := net.JoinHostPort(cfg.Host, fmt.Sprintf("%d", cfg.Port))
 	type dialResult struct {
@@ -48,7 +59,7 @@ func (c *SSHConnector) Connect(ctx context.Context, cfg ConnectorConfig) (Sessio
 	}
 	ch := make(chan dialResult, 1)
 	go func() {
-		cl, err := cryptossh.Dial("tcp", addr, clientCfg)
+		cl, err := sshconfig.Dial(addr, clientCfg, cfg.ProxyJump)
 		ch <- dialResult{cl, err}
 	}()
 
@@ -59,7 +70,7 @@ func (c *SSHConnector) Connect(ctx context.Context, cfg ConnectorConfig) (Sessio
 		if r.err != nil {
 			return nil, classifySSHDialError(r.err, addr, cfg.User)
 		}
-		return newSSHSession(r.client, cfg.Shell)
+		return newSSHSession(r.client, cfg)
 	}
 }
 
@@ -74,7 +85,8 @@ type sshSession struct {
 	mu      sync.Mutex
 }
 
-func newSSHSession(client *cryptossh.Client, shell string) (*sshSession, error) {
+func newSSHSession(client *cryptossh.Client, cfg ConnectorConfig) (*sshSession, error) {
+	shell := cfg.Shell
 	sess, err := client.NewSession()
 	if err != nil {
 		client.Close()
@@ -105,6 +117,24 @@ func newSSHSession(client *cryptossh.Client, shell string) (*sshSession, error)
 	}
 	_ = stderr
 
+	// Pass the server's default environment through SSH first; whatever
+	// sshd refuses (AcceptEnv) is exported by the start command instead.
+	var refused map[string]string
+	for _, name := range sortedKeys(cfg.Env) {
+		if err := sess.Setenv(name, cfg.Env[name]); err != nil {
+			if refused == nil {
+				refused = map[string]string{}
+			}
+			refused[name] = cfg.Env[name]
+		}
+	}
+	// The rest runs as the command sshd hands to the login shell, so it is
+	// neither echoed nor kept in history. It is POSIX syntax, so other login
+	// shells (fish, csh) start without it.
+	if init := sessionInitCommand(cfg.DefaultDir, refused); init != "" && isPOSIXShell(loginShell(client)) {
+		shell = sessionStartCommand(init, shell)
+	}
+
 	if shell != "" {
 		if err := sess.Start(shell); err != nil {
 			if err2 := sess.Shell(); err2 != nil {
@@ -193,23 +223,132 @@ func (s *sshSession) Close() error {
 	return s.client.Close()
 }
 
+// sessionInitCommand builds the POSIX shell commands that export env and
+// change into dir. Returns "" when there is nothing to apply.
+func sessionInitCommand(dir string, env map[string]string) string {
+	var parts []string
+	if len(env) > 0 {
+		assigns := make([]string, 0, len(env))
+		for _, name := range sortedKeys(env) {
+			assigns = append(assigns, name+"="+ShellQuote(env[name]))
+		}
+		parts = append(parts, "export "+strings.Join(assigns, " "))
+	}
+	if dir != "" {
+		parts = append(parts, "cd -- "+ShellQuote(dir))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sessionStartCommand runs init and then replaces itself with shell, or with
+// the user's login shell when shell is empty.
+func sessionStartCommand(init, shell string) string {
+	if shell == "" {
+		shell = `"${SHELL:-/bin/sh}" -l`
+	}
+	return init + "; exec " + shell
+}
+
+// posixShells are the login shells sessionInitCommand's syntax works in.
+var posixShells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "ash": true,
+	"zsh": true, "ksh": true, "mksh": true, "busybox": true,
+}
+
+func isPOSIXShell(name string) bool { return posixShells[name] }
+
+// loginShell returns the base name of the remote user's $SHELL, or "" when
+// it cannot be read.
+func loginShell(client *cryptossh.Client) string {
+	sess, err := client.NewSession()
+	if err != nil {
+		return ""
+	}
+	defer sess.Close()
+	out, err := sess.Output(`echo "$SHELL"`)
+	if err != nil {
+		return ""
+	}
+	return path.Base(strings.TrimSpace(string(out)))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // ─── Auth ─────────────────────────────────────────────────────────────────────
 
-// AuthMethodFromConfig builds the SSH auth method from a ConnectorConfig.
+// AuthMethodsFromConfig builds the SSH auth methods for a ConnectorConfig in
+// the order the server is offered them: the AuthType method first, then
+// cfg.Password when one is set alongside a key or agent. release closes the
+// ssh-agent connection used by agent auth and must be called once the
+// handshake is over.
 // Exported so operational tools (e.g. ExecuteSSHCommand in servers package) can reuse it.
-func AuthMethodFromConfig(cfg ConnectorConfig) (cryptossh.AuthMethod, error) {
+func AuthMethodsFromConfig(cfg ConnectorConfig) (methods []cryptossh.AuthMethod, release func(), err error) {
+	release = func() {}
 	switch cfg.AuthType {
 	case AuthMethodPrivateKey, "key", "ssh_key":
-		signer, err := cryptossh.ParsePrivateKey([]byte(cfg.Secret))
+		signer, err := parsePrivateKey(cfg.Secret, cfg.Passphrase)
 		if err != nil {
-			return nil, fmt.Errorf("private key format invalid or passphrase required: %w", err)
+			return nil, release, err
 		}
-		return cryptossh.PublicKeys(signer), nil
+		methods = append(methods, cryptossh.PublicKeys(signer))
 	case AuthMethodPassword:
-		return cryptossh.Password(cfg.Secret), nil
+		methods = append(methods, cryptossh.Password(cfg.Secret))
+	case AuthMethodAgent:
+		method, closeAgent, err := agentAuthMethod()
+		if err != nil {
+			return nil, release, err
+		}
+		methods = append(methods, method)
+		release = closeAgent
 	default:
-		return nil, fmt.Errorf("unsupported auth_type %q; expected password or private_key", cfg.AuthType)
+		return nil, release, fmt.Errorf("unsupported auth_type %q; expected password, private_key or agent", cfg.AuthType)
+	}
+	if cfg.Password != "" && cfg.AuthType != AuthMethodPassword {
+		methods = append(methods, cryptossh.Password(cfg.Password))
+	}
+	return methods, release, nil
+}
+
+// parsePrivateKey parses a PEM private key, decrypting it with passphrase
+// when it is encrypted. A passphrase given for a plain key is ignored.
+func parsePrivateKey(key, passphrase string) (cryptossh.Signer, error) {
+	signer, err := cryptossh.ParsePrivateKey([]byte(key))
+	var missing *cryptossh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		if err != nil {
+			return nil, fmt.Errorf("private key format invalid: %w", err)
+		}
+		return signer, nil
+	}
+	if passphrase == "" {
+		return nil, errors.New("private key is encrypted and no passphrase is configured")
+	}
+	signer, err = cryptossh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("private key passphrase invalid: %w", err)
+	}
+	return signer, nil
+}
+
+// agentAuthMethod offers the keys of the ssh-agent at SSH_AUTH_SOCK. The
+// returned func closes the agent connection.
+func agentAuthMethod() (cryptossh.AuthMethod, func(), error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, nil, errors.New("agent auth requires SSH_AUTH_SOCK to point at a running ssh-agent")
+	}
+	conn, err := net.DialTimeout("unix", sock, sshDialTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to ssh-agent at %s: %w", sock, err)
 	}
+	return cryptossh.PublicKeysCallback(agent.NewClient(conn).Signers), func() { _ = conn.Close() }, nil
 }
 
 // ─── Error classification ─────────────────────────────────────────────────────
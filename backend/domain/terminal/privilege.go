@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PrivilegeEscalation names how Privileged raises a command to root.
+type PrivilegeEscalation string
+
+const (
+	// PrivilegeNone runs commands as the login user.
+	PrivilegeNone PrivilegeEscalation = "none"
+	// PrivilegeSudo runs commands through sudo; a password credential answers
+	// its prompt over stdin.
+	PrivilegeSudo PrivilegeEscalation = "sudo"
+	// PrivilegeSudoNoPasswd runs commands through `sudo -n`.
+	PrivilegeSudoNoPasswd PrivilegeEscalation = "sudo-nopasswd"
+	// PrivilegeDoas runs commands through `doas -n`.
+	PrivilegeDoas PrivilegeEscalation = "doas"
+)
+
+// sudoStdinPrefix starts commands whose sudo reads the password from stdin;
+// ExecuteSSHCommand supplies it for commands carrying this prefix.
+const sudoStdinPrefix = "sudo -S -p '' "
+
+// ErrRunAsUnsupported is returned by AsUser when the server's privilege
+// escalation cannot switch to another account.
+var ErrRunAsUnsupported = errors.New("run as another user requires sudo or doas privilege escalation")
+
+// runAsUserPattern accepts portable POSIX login names.
+var runAsUserPattern = regexp.MustCompile(`^[a-z_][a-z0-9_.-]{0,31}$`)
+
+// ValidRunAsUser reports whether name is an acceptable RunAs account name.
+func ValidRunAsUser(name string) bool {
+	return runAsUserPattern.MatchString(name)
+}
+
+// Privileged wraps a shell command so it runs as root under cfg.Privilege.
+// The whole command runs in a single escalated `sh -c`, so compound commands
+// escalate once and a failure is reported instead of retried unprivileged.
+func (cfg ConnectorConfig) Privileged(command string) string {
+	switch cfg.Privilege {
+	case PrivilegeSudo:
+		if cfg.sudoPassword() != "" {
+			return sudoStdinPrefix + "-- sh -c " + ShellQuote(command)
+		}
+		return "sudo -n -- sh -c " + ShellQuote(command)
+	case PrivilegeSudoNoPasswd:
+		return "sudo -n -- sh -c " + ShellQuote(command)
+	case PrivilegeDoas:
+		return "doas -n -- sh -c " + ShellQuote(command)
+	}
+	return command
+}
+
+// AsUser wraps a shell command so it runs as cfg.RunAs through the server's
+// sudo or doas. An empty RunAs returns command unchanged.
+func (cfg ConnectorConfig) AsUser(command string) (string, error) {
+	if cfg.RunAs == "" {
+		return command, nil
+	}
+	if !ValidRunAsUser(cfg.RunAs) {
+		return "", fmt.Errorf("invalid run-as user %q", cfg.RunAs)
+	}
+	target := "-u " + ShellQuote(cfg.RunAs) + " -- sh -c " + ShellQuote(command)
+	switch cfg.Privilege {
+	case PrivilegeSudo:
+		if cfg.sudoPassword() != "" {
+			return sudoStdinPrefix + target, nil
+		}
+		return "sudo -n " + target, nil
+	case PrivilegeSudoNoPasswd:
+		return "sudo -n " + target, nil
+	case PrivilegeDoas:
+		return "doas -n " + target, nil
+	}
+	return "", ErrRunAsUnsupported
+}
+
+// sudoPassword returns the password sudo is answered with under
+// PrivilegeSudo: the login password of a password credential, or the
+// Password configured alongside a key or agent. Otherwise it is empty.
+func (cfg ConnectorConfig) sudoPassword() string {
+	if cfg.Privilege != PrivilegeSudo {
+		return ""
+	}
+	if cfg.AuthType == AuthMethodPassword {
+		return cfg.Secret
+	}
+	return cfg.Password
+}
+
+// privilegedStdin returns the stdin to send with command, if any.
+func (cfg ConnectorConfig) privilegedStdin(command string) string {
+	password := cfg.sudoPassword()
+	if password == "" || !strings.HasPrefix(command, sudoStdinPrefix) {
+		return ""
+	}
+	return password + "\n"
+}
@@ -1,34 +1,114 @@
 package terminal
 
 import (
+	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const sessionIdleTimeout = 30 * time.Minute
+const defaultSessionIdleTimeout = 30 * time.Minute
 const idleMonitorInterval = time.Minute
 
-// sessionRegistry tracks active terminal sessions and enforces idle timeouts.
+// SessionLimits bounds the lifetime of terminal sessions. Idle closes a
+// session that has received no message for that long; MaxDuration closes it
+// that long after it started, active or not. Zero disables a limit.
+type SessionLimits struct {
+	Idle        time.Duration
+	MaxDuration time.Duration
+}
+
+// Reasons passed to ConnectionMeta.OnExpire.
+const (
+	ExpiredIdle        = "idle_timeout"
+	ExpiredMaxDuration = "max_duration"
+)
+
+// sessionRegistry tracks active terminal sessions and other long-lived
+// connections (SSE streams) and enforces session limits on terminal sessions.
 // The WebSocket route handler calls Touch on each message received; the
-// background janitor calls Close on sessions that have been idle too long.
+// background janitor calls Close on sessions that have been idle too long or
+// open longer than the maximum duration.
 type sessionRegistry struct {
 	mu             sync.Mutex
 	sessions       map[string]*registeredSession
+	limits         func() SessionLimits
 	monitorStopCh  chan struct{}
 	monitorDoneCh  chan struct{}
 	monitorRunning bool
 }
 
 type registeredSession struct {
-	id      string
-	session Session
-	lastMsg time.Time
+	id        string
+	closer    io.Closer
+	meta      ConnectionMeta
+	startedAt time.Time
+	lastMsg   time.Time
+	// idle marks entries subject to SessionLimits (terminal sessions).
+	idle bool
+}
+
+// Connection types reported by Connections.
+const (
+	ConnectionSSH          = "ssh"
+	ConnectionLocal        = "local"
+	ConnectionDockerExec   = "docker_exec"
+	ConnectionSFTPCopy     = "sftp_copy"
+	ConnectionFileTail     = "file_tail"
+	ConnectionTunnelStatus = "tunnel_status"
+)
+
+// ConnectionMeta describes a registered connection. ServerID, when set, ties
+// the connection to a servers record so TerminateServer can close it. BytesIn
+// and BytesOut, when set, are the handler's live traffic counters. OnExpire,
+// when set, is called with ExpiredIdle or ExpiredMaxDuration just before the
+// janitor closes the session, so the handler can tell its client why.
+type ConnectionMeta struct {
+	Type     string
+	UserID   string
+	Target   string
+	ServerID string
+	BytesIn  *atomic.Int64
+	BytesOut *atomic.Int64
+	OnExpire func(reason string)
+}
+
+// Connection is a point-in-time view of one registered connection.
+type Connection struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	UserID       string    `json:"user_id"`
+	Target       string    `json:"target"`
+	StartedAt    time.Time `json:"started_at"`
+	LastActivity time.Time `json:"last_activity"`
+	BytesIn      int64     `json:"bytes_in"`
+	BytesOut     int64     `json:"bytes_out"`
 }
 
 var registry = &sessionRegistry{
 	sessions: make(map[string]*registeredSession),
 }
 
+// SetSessionLimits makes the janitor read the terminal session limits from
+// limits on every sweep, so settings changes apply to open sessions. Until it
+// is called sessions idle out after 30 minutes and have no maximum duration.
+func SetSessionLimits(limits func() SessionLimits) {
+	registry.mu.Lock()
+	registry.limits = limits
+	registry.mu.Unlock()
+}
+
+func (r *sessionRegistry) currentLimits() SessionLimits {
+	r.mu.Lock()
+	limits := r.limits
+	r.mu.Unlock()
+	if limits == nil {
+		return SessionLimits{Idle: defaultSessionIdleTimeout}
+	}
+	return limits()
+}
+
 // StartIdleMonitor starts the background idle-session janitor.
 // Safe to call multiple times.
 func StartIdleMonitor() {
@@ -82,27 +162,46 @@ func StopIdleMonitor() {
 }
 
 func (r *sessionRegistry) closeExpiredSessions(now time.Time) {
+	limits := r.currentLimits()
+
+	type expiredSession struct {
+		rs     *registeredSession
+		reason string
+	}
 	r.mu.Lock()
-	toClose := make([]Session, 0)
+	toClose := make([]expiredSession, 0)
 	for id, rs := range r.sessions {
-		if now.Sub(rs.lastMsg) >= sessionIdleTimeout {
-			delete(r.sessions, id)
-			toClose = append(toClose, rs.session)
+		if !rs.idle {
+			continue
 		}
+		reason := ""
+		switch {
+		case limits.MaxDuration > 0 && now.Sub(rs.startedAt) >= limits.MaxDuration:
+			reason = ExpiredMaxDuration
+		case limits.Idle > 0 && now.Sub(rs.lastMsg) >= limits.Idle:
+			reason = ExpiredIdle
+		default:
+			continue
+		}
+		delete(r.sessions, id)
+		toClose = append(toClose, expiredSession{rs: rs, reason: reason})
 	}
 	r.mu.Unlock()
 
-	for _, sess := range toClose {
-		_ = sess.Close()
+	for _, x := range toClose {
+		if x.rs.meta.OnExpire != nil {
+			x.rs.meta.OnExpire(x.reason)
+		}
+		_ = x.rs.closer.Close()
 	}
 }
 
 func (r *sessionRegistry) closeAllSessions() {
 	r.mu.Lock()
-	toClose := make([]Session, 0, len(r.sessions))
+	toClose := make([]io.Closer, 0, len(r.sessions))
 	for id, rs := range r.sessions {
 		delete(r.sessions, id)
-		toClose = append(toClose, rs.session)
+		toClose = append(toClose, rs.closer)
 	}
 	r.mu.Unlock()
 
@@ -112,15 +211,99 @@ func (r *sessionRegistry) closeAllSessions() {
 }
 
 // Register adds a session to the registry. The session is automatically closed
-// after sessionIdleTimeout of inactivity.
-func Register(id string, sess Session) {
+// when it exceeds the SessionLimits.
+func Register(id string, sess Session, meta ConnectionMeta) {
+	registry.add(id, sess, meta, true)
+}
+
+// Track adds a long-lived connection that is not subject to the idle timeout,
+// such as an SSE stream. Terminate closes it through closer.
+func Track(id string, closer io.Closer, meta ConnectionMeta) {
+	registry.add(id, closer, meta, false)
+}
+
+func (r *sessionRegistry) add(id string, closer io.Closer, meta ConnectionMeta, idle bool) {
+	now := time.Now()
+	r.mu.Lock()
+	r.sessions[id] = &registeredSession{
+		id:        id,
+		closer:    closer,
+		meta:      meta,
+		startedAt: now,
+		lastMsg:   now,
+		idle:      idle,
+	}
+	r.mu.Unlock()
+}
+
+// Connections returns all registered connections, oldest first.
+func Connections() []Connection {
+	registry.mu.Lock()
+	out := make([]Connection, 0, len(registry.sessions))
+	for _, rs := range registry.sessions {
+		c := Connection{
+			ID:           rs.id,
+			Type:         rs.meta.Type,
+			UserID:       rs.meta.UserID,
+			Target:       rs.meta.Target,
+			StartedAt:    rs.startedAt.UTC(),
+			LastActivity: rs.lastMsg.UTC(),
+		}
+		if rs.meta.BytesIn != nil {
+			c.BytesIn = rs.meta.BytesIn.Load()
+		}
+		if rs.meta.BytesOut != nil {
+			c.BytesOut = rs.meta.BytesOut.Load()
+		}
+		out = append(out, c)
+	}
+	registry.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].StartedAt.Equal(out[j].StartedAt) {
+			return out[i].StartedAt.Before(out[j].StartedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Terminate removes the connection and closes it. It reports false when id
+// is not registered.
+func Terminate(id string) bool {
+	registry.mu.Lock()
+	rs, ok := registry.sessions[id]
+	if ok {
+		delete(registry.sessions, id)
+	}
+	registry.mu.Unlock()
+
+	if ok {
+		_ = rs.closer.Close()
+	}
+	return ok
+}
+
+// TerminateServer removes and closes every connection tied to serverID and
+// returns how many were closed.
+func TerminateServer(serverID string) int {
+	if serverID == "" {
+		return 0
+	}
 	registry.mu.Lock()
-	registry.sessions[id] = &registeredSession{
-		id:      id,
-		session: sess,
-		lastMsg: time.Now(),
+	toClose := make([]io.Closer, 0)
+	for id, rs := range registry.sessions {
+		if rs.meta.ServerID == serverID {
+			delete(registry.sessions, id)
+			toClose = append(toClose, rs.closer)
+		}
 	}
 	registry.mu.Unlock()
+
+	for _, c := range toClose {
+		_ = c.Close()
+	}
+	return len(toClose)
 }
 
 // Touch updates the last-activity timestamp, resetting the idle timer.
@@ -133,8 +316,9 @@ func Touch(id string) {
 	registry.mu.Unlock()
 }
 
-// Unregister removes the session from the registry (called on WebSocket close).
-// It does NOT close the Session itself; the caller is responsible for that.
+// Unregister removes the session or connection from the registry (called on
+// WebSocket or stream close). It does NOT close it; the caller is responsible
+// for that.
 func Unregister(id string) {
 	registry.mu.Lock()
 	delete(registry.sessions, id)
@@ -0,0 +1,58 @@
+package terminal
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ServerBusyError is returned by AcquireServerSlot when a server already has
+// its maximum number of concurrent SSH/SFTP connections open.
+type ServerBusyError struct {
+	ServerID string
+	Limit    int
+}
+
+func (e *ServerBusyError) Error() string {
+	return fmt.Sprintf("server %s is busy: %d concurrent SSH/SFTP sessions already open, try again later", e.ServerID, e.Limit)
+}
+
+// serverSlots counts the open SSH/SFTP connections per server id. Slots are
+// counted even without a limit so a limit set later applies to sessions that
+// are already open.
+var serverSlots = struct {
+	mu   sync.Mutex
+	open map[string]int
+}{open: make(map[string]int)}
+
+// AcquireServerSlot reserves one connection slot for serverID before a new
+// SSH or SFTP connection is opened. limit <= 0 means unlimited. The returned
+// release func frees the slot and is safe to call more than once.
+func AcquireServerSlot(serverID string, limit int) (release func(), err error) {
+	serverSlots.mu.Lock()
+	defer serverSlots.mu.Unlock()
+
+	if limit > 0 && serverSlots.open[serverID] >= limit {
+		return nil, &ServerBusyError{ServerID: serverID, Limit: limit}
+	}
+	serverSlots.open[serverID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			serverSlots.mu.Lock()
+			defer serverSlots.mu.Unlock()
+			if serverSlots.open[serverID] <= 1 {
+				delete(serverSlots.open, serverID)
+				return
+			}
+			serverSlots.open[serverID]--
+		})
+	}, nil
+}
+
+// OpenServerSlots returns the number of slots currently held for serverID.
+func OpenServerSlots(serverID string) int {
+	serverSlots.mu.Lock()
+	defer serverSlots.mu.Unlock()
+	return serverSlots.open[serverID]
+}
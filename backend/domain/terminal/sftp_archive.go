@@ -0,0 +1,154 @@
+package terminal
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrArchiveTooLarge is returned when the files to archive exceed the
+// uncompressed size limit.
+var ErrArchiveTooLarge = errors.New("sftp: archive exceeds size limit")
+
+// ArchiveEntry is one path to be written by WriteZip. Name is relative to
+// the archive root and uses forward slashes.
+type ArchiveEntry struct {
+	Path string
+	Name string
+	Info os.FileInfo
+}
+
+// ArchivePlan lists what archiving a directory will write. Size is the
+// total uncompressed size of its regular files.
+type ArchivePlan struct {
+	Root    string
+	Entries []ArchiveEntry
+	Files   int
+	Size    int64
+}
+
+// PlanArchive walks the directory root without following symlinks and
+// returns its entries, named under the directory's own base name so the
+// archive extracts into a single folder. It fails with ErrArchiveTooLarge
+// when the regular files add up to more than maxBytes, and on the first
+// entry that cannot be read, so nothing has been sent when it fails.
+// Cancelling ctx stops the walk.
+func (c *SFTPClient) PlanArchive(ctx context.Context, root string, maxBytes int64) (ArchivePlan, error) {
+	if err := c.confine(root); err != nil {
+		return ArchivePlan{}, err
+	}
+	root = path.Clean(root)
+	fi, err := c.sftpClient.Lstat(root)
+	if err != nil {
+		return ArchivePlan{}, fmt.Errorf("sftp: stat %q: %w", root, err)
+	}
+	if !fi.IsDir() {
+		return ArchivePlan{}, fmt.Errorf("sftp: %q is not a directory", root)
+	}
+	base := path.Base(root)
+	if base == "/" {
+		base = "root"
+	}
+
+	plan := ArchivePlan{Root: root}
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := ctx.Err(); err != nil {
+			return ArchivePlan{}, fmt.Errorf("sftp: walk %q: %w", root, err)
+		}
+		if err := walker.Err(); err != nil {
+			return ArchivePlan{}, fmt.Errorf("sftp: walk %q: %w", walker.Path(), err)
+		}
+		p, info := walker.Path(), walker.Stat()
+		name := base + strings.TrimPrefix(p, root)
+		if root == "/" && p != "/" {
+			name = base + p
+		}
+		switch {
+		case info.IsDir():
+			name += "/"
+		case info.Mode().IsRegular():
+			plan.Files++
+			plan.Size += info.Size()
+			if plan.Size > maxBytes {
+				return ArchivePlan{}, fmt.Errorf("%w: more than %d bytes under %q", ErrArchiveTooLarge, maxBytes, root)
+			}
+		case info.Mode()&os.ModeSymlink == 0:
+			continue // devices, sockets and pipes have no content to archive
+		}
+		plan.Entries = append(plan.Entries, ArchiveEntry{Path: p, Name: name, Info: info})
+	}
+	return plan, nil
+}
+
+// WriteZip streams the planned entries to dst as a zip archive, keeping
+// each entry's mode and modification time. Symlinks are stored as links.
+// Files that grew since planning may push the total past maxBytes, which
+// stops the archive with ErrArchiveTooLarge, as does cancelling ctx with
+// ctx's error. It returns the uncompressed bytes written.
+func (c *SFTPClient) WriteZip(ctx context.Context, plan ArchivePlan, dst io.Writer, maxBytes int64) (int64, error) {
+	zw := zip.NewWriter(dst)
+	var written int64
+	for _, entry := range plan.Entries {
+		if err := ctx.Err(); err != nil {
+			return written, fmt.Errorf("sftp: zip %q: %w", plan.Root, err)
+		}
+		header, err := zip.FileInfoHeader(entry.Info)
+		if err != nil {
+			return written, fmt.Errorf("sftp: zip header %q: %w", entry.Path, err)
+		}
+		header.Name = entry.Name
+		if entry.Info.Mode().IsRegular() {
+			header.Method = zip.Deflate
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return written, fmt.Errorf("sftp: zip %q: %w", entry.Path, err)
+		}
+
+		switch {
+		case entry.Info.Mode()&os.ModeSymlink != 0:
+			target, err := c.sftpClient.ReadLink(entry.Path)
+			if err != nil {
+				return written, fmt.Errorf("sftp: readlink %q: %w", entry.Path, err)
+			}
+			if _, err := io.WriteString(w, target); err != nil {
+				return written, err
+			}
+		case entry.Info.Mode().IsRegular():
+			n, err := c.copyToZip(ctx, entry.Path, w, maxBytes-written)
+			written += n
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return written, fmt.Errorf("sftp: finish zip: %w", err)
+	}
+	return written, nil
+}
+
+// copyToZip copies one remote file into w, reading at most limit bytes.
+func (c *SFTPClient) copyToZip(ctx context.Context, remotePath string, w io.Writer, limit int64) (int64, error) {
+	f, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return 0, fmt.Errorf("sftp: open %q: %w", remotePath, err)
+	}
+	defer f.Close()
+
+	src := ThrottleReader(ctx, contextReader{ctx, io.LimitReader(f, limit+1)}, c.transferKBps)
+	n, err := io.Copy(w, src)
+	if err != nil {
+		return n, fmt.Errorf("sftp: read %q: %w", remotePath, err)
+	}
+	if n > limit {
+		return n, fmt.Errorf("%w: %q grew while archiving", ErrArchiveTooLarge, remotePath)
+	}
+	return n, nil
+}
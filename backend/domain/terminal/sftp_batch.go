@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBatchTooLarge is returned for a file whose content would take a batch
+// read past its total size limit.
+var ErrBatchTooLarge = errors.New("sftp: batch read exceeds total size limit")
+
+// BatchReadResult is the outcome of reading one path in ReadFiles: Content
+// when Err is nil.
+type BatchReadResult struct {
+	Path    string
+	Content string
+	Err     error
+}
+
+// ReadFiles reads each path with ReadFile over the one connection and
+// returns a result per path, in order. A file larger than maxFileBytes, or
+// one that would bring the returned content past maxTotalBytes, gets an
+// error instead of content; later files may still fit. Cancelling ctx fails
+// the paths not yet read.
+func (c *SFTPClient) ReadFiles(ctx context.Context, paths []string, maxFileBytes, maxTotalBytes int64) []BatchReadResult {
+	results := make([]BatchReadResult, len(paths))
+	var total int64
+	for i, p := range paths {
+		results[i].Path = p
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		content, err := c.ReadFile(p, maxFileBytes)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if total+int64(len(content)) > maxTotalBytes {
+			results[i].Err = ErrBatchTooLarge
+			continue
+		}
+		total += int64(len(content))
+		results[i].Content = content
+	}
+	return results
+}
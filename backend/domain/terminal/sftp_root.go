@@ -0,0 +1,93 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrOutsideRoot is returned for a path that lies outside the root set with
+// SetRoot, lexically or once symlinks are resolved.
+var ErrOutsideRoot = errors.New("sftp: path is outside the allowed root")
+
+// SetRoot confines every later operation of c to the directory root: paths
+// must be absolute, may not climb out of root with "..", and may not reach
+// outside it through a symlink. root itself is resolved on the server and
+// must exist. An empty root removes the confinement.
+func (c *SFTPClient) SetRoot(root string) error {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		c.root = ""
+		return nil
+	}
+	if !path.IsAbs(root) {
+		return fmt.Errorf("sftp: root %q must be absolute", root)
+	}
+	resolved, err := c.sftpClient.RealPath(path.Clean(root))
+	if err != nil {
+		return fmt.Errorf("sftp: resolve root %q: %w", root, err)
+	}
+	c.root = path.Clean(resolved)
+	return nil
+}
+
+// Root returns the directory set with SetRoot, or "" when c is unconfined.
+func (c *SFTPClient) Root() string {
+	return c.root
+}
+
+// confine checks a path that an operation follows, such as a file that is
+// read or written: the path and whatever it resolves to must be inside the
+// root.
+func (c *SFTPClient) confine(p string) error {
+	return c.checkRoot(p, true)
+}
+
+// confineEntry checks a path whose directory entry an operation acts on
+// itself, such as a symlink that is deleted or renamed: only its parent is
+// resolved, so a link pointing outside the root can still be removed.
+func (c *SFTPClient) confineEntry(p string) error {
+	return c.checkRoot(p, false)
+}
+
+func (c *SFTPClient) checkRoot(p string, follow bool) error {
+	if c.root == "" {
+		return nil
+	}
+	if !path.IsAbs(p) {
+		return fmt.Errorf("%w: %q is not absolute", ErrOutsideRoot, p)
+	}
+	cleaned := path.Clean(p)
+	if !withinRoot(cleaned, c.root) {
+		return fmt.Errorf("%w: %q", ErrOutsideRoot, p)
+	}
+	probe := cleaned
+	if !follow && cleaned != c.root {
+		probe = path.Dir(cleaned)
+	}
+	// The deepest existing ancestor decides: a path that does not exist yet
+	// is created inside whatever its parent resolves to.
+	for {
+		resolved, err := c.sftpClient.RealPath(probe)
+		if err == nil {
+			if !withinRoot(path.Clean(resolved), c.root) {
+				return fmt.Errorf("%w: %q resolves to %q", ErrOutsideRoot, p, resolved)
+			}
+			return nil
+		}
+		if probe == c.root || probe == "/" {
+			return fmt.Errorf("sftp: resolve %q: %w", p, err)
+		}
+		probe = path.Dir(probe)
+	}
+}
+
+// withinRoot reports whether the clean absolute path p is root or lies
+// below it.
+func withinRoot(p, root string) bool {
+	if root == "/" || p == root {
+		return true
+	}
+	return strings.HasPrefix(p, root+"/")
+}
@@ -2,12 +2,15 @@ package terminal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
 	"time"
 
 	cryptossh "golang.org/x/crypto/ssh"
+
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 )
 
 // ShellQuote wraps a value in single quotes, escaping any embedded single quotes.
@@ -19,8 +22,9 @@ func ShellQuote(value string) string {
 }
 
 // ExecuteSSHCommand runs a one-shot command on a remote server via SSH and
-// returns the combined stdout+stderr output. If timeout <= 0, a 20-second
-// default is applied.
+// returns the combined stdout+stderr output. Commands built by
+// cfg.Privileged receive the sudo password on stdin when one is needed. If
+// timeout <= 0, a 20-second default is applied.
 func ExecuteSSHCommand(ctx context.Context, cfg ConnectorConfig, command string, timeout time.Duration) (string, error) {
 	if timeout <= 0 {
 		timeout = 20 * time.Second
@@ -28,10 +32,11 @@ func ExecuteSSHCommand(ctx context.Context, cfg ConnectorConfig, command string,
 	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	authMethod, err := AuthMethodFromConfig(cfg)
+	authMethods, releaseAuth, err := AuthMethodsFromConfig(cfg)
 	if err != nil {
 		return "", err
 	}
+	defer releaseAuth()
 	hostKeyCallback, err := HostKeyCallback()
 	if err != nil {
 		return "", err
@@ -39,10 +44,13 @@ func ExecuteSSHCommand(ctx context.Context, cfg ConnectorConfig, command string,
 
 	clientCfg := &cryptossh.ClientConfig{
 		User:            cfg.User,
-		Auth:            []cryptossh.AuthMethod{authMethod},
+		Auth:            authMethods,
 		HostKeyCallback: hostKeyCallback,
 		Timeout:         10 * time.Second,
 	}
+	if cfg.LegacySSH {
+		sshconfig.EnableLegacyAlgorithms(clientCfg)
+	}
 
 	addr := net.JoinHostPort(cfg.Host, fmt.Sprintf("%d", cfg.Port))
 	type dialResult struct {
@@ -51,7 +59,7 @@ func ExecuteSSHCommand(ctx context.Context, cfg ConnectorConfig, command string,
 	}
 	dialCh := make(chan dialResult, 1)
 	go func() {
-		client, dialErr := cryptossh.Dial("tcp", addr, clientCfg)
+		client, dialErr := sshconfig.Dial(addr, clientCfg, cfg.ProxyJump)
 		dialCh <- dialResult{client: client, err: dialErr}
 	}()
 
@@ -72,6 +80,9 @@ func ExecuteSSHCommand(ctx context.Context, cfg ConnectorConfig, command string,
 		return "", fmt.Errorf("ssh new session failed: %w", err)
 	}
 	defer session.Close()
+	if stdin := cfg.privilegedStdin(command); stdin != "" {
+		session.Stdin = strings.NewReader(stdin)
+	}
 
 	type commandResult struct {
 		output []byte
@@ -98,3 +109,17 @@ func ExecuteSSHCommand(ctx context.Context, cfg ConnectorConfig, command string,
 		return output, nil
 	}
 }
+
+// ExitCode returns the remote exit status behind an ExecuteSSHCommand error:
+// 0 for a nil error. ok is false when the command did not run to completion,
+// e.g. the dial failed or the timeout expired.
+func ExitCode(err error) (code int, ok bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr *cryptossh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+	return 0, false
+}
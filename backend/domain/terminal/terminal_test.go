@@ -1,12 +1,27 @@
 package terminal
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/pkg/sftp"
+	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 // mockSession implements Session for testing the session registry.
@@ -22,7 +37,7 @@ func (m *mockSession) Close() error                { m.closed = true; return nil
 func TestSessionRegistryTouchPreventsTimeout(t *testing.T) {
 	sess := &mockSession{}
 	id := "test-touch"
-	Register(id, sess)
+	Register(id, sess, ConnectionMeta{Type: ConnectionSSH})
 	defer Unregister(id)
 
 	// Touch should update lastMsg
@@ -44,7 +59,7 @@ func TestSessionRegistryTouchPreventsTimeout(t *testing.T) {
 func TestSessionRegistryUnregister(t *testing.T) {
 	sess := &mockSession{}
 	id := "test-unregister"
-	Register(id, sess)
+	Register(id, sess, ConnectionMeta{Type: ConnectionSSH})
 	Unregister(id)
 
 	registry.mu.Lock()
@@ -56,6 +71,100 @@ func TestSessionRegistryUnregister(t *testing.T) {
 	}
 }
 
+func TestSessionLimitsCloseWithReason(t *testing.T) {
+	SetSessionLimits(func() SessionLimits {
+		return SessionLimits{Idle: time.Hour, MaxDuration: 2 * time.Hour}
+	})
+	defer SetSessionLimits(nil)
+
+	reasons := map[string]string{}
+	register := func(id string) *mockSession {
+		sess := &mockSession{}
+		Register(id, sess, ConnectionMeta{Type: ConnectionSSH, OnExpire: func(reason string) {
+			if sess.closed {
+				t.Errorf("%s: OnExpire called after Close", id)
+			}
+			reasons[id] = reason
+		}})
+		t.Cleanup(func() { Unregister(id) })
+		return sess
+	}
+	busy, idle, fresh := register("test-limit-busy"), register("test-limit-idle"), register("test-limit-fresh")
+
+	now := time.Now()
+	registry.mu.Lock()
+	registry.sessions["test-limit-busy"].startedAt = now.Add(-3 * time.Hour)
+	registry.sessions["test-limit-idle"].lastMsg = now.Add(-90 * time.Minute)
+	registry.mu.Unlock()
+
+	registry.closeExpiredSessions(now)
+	if !busy.closed || reasons["test-limit-busy"] != ExpiredMaxDuration {
+		t.Fatalf("busy session: closed=%v reason=%q", busy.closed, reasons["test-limit-busy"])
+	}
+	if !idle.closed || reasons["test-limit-idle"] != ExpiredIdle {
+		t.Fatalf("idle session: closed=%v reason=%q", idle.closed, reasons["test-limit-idle"])
+	}
+	if fresh.closed {
+		t.Fatal("expected the fresh session to stay open")
+	}
+}
+
+func TestConnectionsReportsAndTerminates(t *testing.T) {
+	var bytesOut atomic.Int64
+	bytesOut.Store(42)
+	sess := &mockSession{}
+	stream := &mockSession{}
+	Register("test-conn-ssh", sess, ConnectionMeta{Type: ConnectionSSH, UserID: "u1", Target: "srv1", BytesOut: &bytesOut})
+	defer Unregister("test-conn-ssh")
+	time.Sleep(time.Millisecond)
+	Track("test-conn-tail", stream, ConnectionMeta{Type: ConnectionFileTail, UserID: "u1", Target: "logs/app.log"})
+	defer Unregister("test-conn-tail")
+
+	var found []Connection
+	for _, c := range Connections() {
+		if strings.HasPrefix(c.ID, "test-conn-") {
+			found = append(found, c)
+		}
+	}
+	if len(found) != 2 || found[0].ID != "test-conn-ssh" || found[1].Type != ConnectionFileTail {
+		t.Fatalf("unexpected connections: %+v", found)
+	}
+	if found[0].BytesOut != 42 || found[0].Target != "srv1" {
+		t.Fatalf("unexpected ssh connection view: %+v", found[0])
+	}
+
+	// Streams registered with Track never idle out.
+	registry.closeExpiredSessions(time.Now().Add(2 * defaultSessionIdleTimeout))
+	if !sess.closed || stream.closed {
+		t.Fatalf("idle sweep: session closed=%v, stream closed=%v", sess.closed, stream.closed)
+	}
+
+	if !Terminate("test-conn-tail") || !stream.closed {
+		t.Fatal("expected Terminate to close the stream")
+	}
+	if Terminate("test-conn-tail") {
+		t.Fatal("expected second Terminate to report false")
+	}
+}
+
+func TestTerminateServerClosesMatchingConnections(t *testing.T) {
+	a, b := &mockSession{}, &mockSession{}
+	Register("test-ts-a", a, ConnectionMeta{Type: ConnectionSSH, ServerID: "srv1"})
+	defer Unregister("test-ts-a")
+	Register("test-ts-b", b, ConnectionMeta{Type: ConnectionSSH, ServerID: "srv2"})
+	defer Unregister("test-ts-b")
+
+	if n := TerminateServer("srv1"); n != 1 {
+		t.Fatalf("expected 1 connection closed, got %d", n)
+	}
+	if !a.closed || b.closed {
+		t.Fatalf("expected only srv1 closed, got a=%v b=%v", a.closed, b.closed)
+	}
+	if TerminateServer("") != 0 {
+		t.Fatal("expected empty server id to match nothing")
+	}
+}
+
 func TestIdleMonitorStartStopIdempotent(t *testing.T) {
 	StopIdleMonitor()
 	StartIdleMonitor()
@@ -70,7 +179,7 @@ func TestStopIdleMonitorClosesTrackedSessions(t *testing.T) {
 
 	id := "test-stop-closes"
 	sess := &mockSession{}
-	Register(id, sess)
+	Register(id, sess, ConnectionMeta{Type: ConnectionSSH})
 
 	StopIdleMonitor()
 
@@ -86,50 +195,202 @@ func TestStopIdleMonitorClosesTrackedSessions(t *testing.T) {
 	}
 }
 
-func TestAuthMethodFromConfig_Password(t *testing.T) {
+func TestAuthMethodsFromConfig_Password(t *testing.T) {
 	cfg := ConnectorConfig{
 		AuthType: "password",
 		Secret:   "secret123",
 	}
-	method, err := AuthMethodFromConfig(cfg)
+	methods, release, err := AuthMethodsFromConfig(cfg)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if method == nil {
-		t.Fatal("expected non-nil auth method")
+	defer release()
+	if len(methods) != 1 {
+		t.Fatalf("expected one auth method, got %d", len(methods))
 	}
 }
 
-func TestAuthMethodFromConfig_InvalidType(t *testing.T) {
+func TestAuthMethodsFromConfig_InvalidType(t *testing.T) {
 	cfg := ConnectorConfig{AuthType: "unknown"}
-	_, err := AuthMethodFromConfig(cfg)
+	_, _, err := AuthMethodsFromConfig(cfg)
 	if err == nil {
 		t.Fatal("expected error for unknown auth type")
 	}
 }
 
-func TestAuthMethodFromConfig_PrivateKey_Invalid(t *testing.T) {
+func TestAuthMethodsFromConfig_PrivateKey_Invalid(t *testing.T) {
 	cfg := ConnectorConfig{
 		AuthType: "private_key",
 		Secret:   "not-a-valid-key",
 	}
-	_, err := AuthMethodFromConfig(cfg)
+	_, _, err := AuthMethodsFromConfig(cfg)
 	if err == nil {
 		t.Fatal("expected error for invalid private key")
 	}
 }
 
-func TestAuthMethodFromConfig_SSHKeyAlias_Invalid(t *testing.T) {
+func TestAuthMethodsFromConfig_SSHKeyAlias_Invalid(t *testing.T) {
 	cfg := ConnectorConfig{
 		AuthType: "ssh_key",
 		Secret:   "not-a-valid-key",
 	}
-	_, err := AuthMethodFromConfig(cfg)
+	_, _, err := AuthMethodsFromConfig(cfg)
 	if err == nil {
 		t.Fatal("expected error for invalid ssh_key alias")
 	}
 }
 
+// encryptedTestKey returns an ed25519 key as a PEM block encrypted with passphrase.
+func encryptedTestKey(t *testing.T, passphrase string) (ed25519.PrivateKey, string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := cryptossh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(block))
+}
+
+func TestAuthMethodsFromConfig_EncryptedKey(t *testing.T) {
+	_, pemKey := encryptedTestKey(t, "s3cret")
+
+	if _, _, err := AuthMethodsFromConfig(ConnectorConfig{AuthType: AuthMethodPrivateKey, Secret: pemKey}); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Fatalf("expected missing passphrase error, got %v", err)
+	}
+	if _, _, err := AuthMethodsFromConfig(ConnectorConfig{AuthType: AuthMethodPrivateKey, Secret: pemKey, Passphrase: "wrong"}); err == nil {
+		t.Fatal("expected error for wrong passphrase")
+	}
+	methods, _, err := AuthMethodsFromConfig(ConnectorConfig{AuthType: AuthMethodPrivateKey, Secret: pemKey, Passphrase: "s3cret", Password: "pw"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 2 {
+		t.Fatalf("expected key then password fallback, got %d methods", len(methods))
+	}
+}
+
+func TestAuthMethodsFromConfig_Agent(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if _, _, err := AuthMethodsFromConfig(ConnectorConfig{AuthType: AuthMethodAgent}); err == nil || !strings.Contains(err.Error(), "SSH_AUTH_SOCK") {
+		t.Fatalf("expected SSH_AUTH_SOCK error, got %v", err)
+	}
+
+	key, _ := encryptedTestKey(t, "unused")
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	signer, err := cryptossh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods, release, err := AuthMethodsFromConfig(ConnectorConfig{AuthType: AuthMethodAgent})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+	if err := sshHandshake(t, methods, func(_ cryptossh.ConnMetadata, pub cryptossh.PublicKey) bool {
+		return bytes.Equal(pub.Marshal(), signer.PublicKey().Marshal())
+	}, ""); err != nil {
+		t.Fatalf("expected agent key to authenticate: %v", err)
+	}
+}
+
+func TestAuthMethodsFromConfig_FallsBackToPassword(t *testing.T) {
+	_, pemKey := encryptedTestKey(t, "s3cret")
+	methods, _, err := AuthMethodsFromConfig(ConnectorConfig{AuthType: AuthMethodPrivateKey, Secret: pemKey, Passphrase: "s3cret", Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejectKeys := func(cryptossh.ConnMetadata, cryptossh.PublicKey) bool { return false }
+	if err := sshHandshake(t, methods, rejectKeys, "pw"); err != nil {
+		t.Fatalf("expected password fallback after rejected key: %v", err)
+	}
+}
+
+// sshHandshake runs an SSH handshake with methods against an in-process
+// server that accepts public keys approved by acceptKey and, when non-empty,
+// password.
+func sshHandshake(t *testing.T, methods []cryptossh.AuthMethod, acceptKey func(cryptossh.ConnMetadata, cryptossh.PublicKey) bool, password string) error {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := cryptossh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCfg := &cryptossh.ServerConfig{
+		PublicKeyCallback: func(meta cryptossh.ConnMetadata, key cryptossh.PublicKey) (*cryptossh.Permissions, error) {
+			if acceptKey(meta, key) {
+				return &cryptossh.Permissions{}, nil
+			}
+			return nil, errors.New("key rejected")
+		},
+		PasswordCallback: func(_ cryptossh.ConnMetadata, pw []byte) (*cryptossh.Permissions, error) {
+			if password != "" && string(pw) == password {
+				return &cryptossh.Permissions{}, nil
+			}
+			return nil, errors.New("password rejected")
+		},
+	}
+	serverCfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		serverConn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer serverConn.Close()
+		if conn, _, _, err := cryptossh.NewServerConn(serverConn, serverCfg); err == nil {
+			_ = conn.Close()
+		}
+	}()
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+	conn, _, _, err := cryptossh.NewClientConn(clientConn, "test", &cryptossh.ClientConfig{
+		User:            "deploy",
+		Auth:            methods,
+		HostKeyCallback: cryptossh.InsecureIgnoreHostKey(),
+	})
+	if err == nil {
+		_ = conn.Close()
+	}
+	return err
+}
+
 func TestSFTPMaxUploadConstant(t *testing.T) {
 	expected := int64(50 << 20)
 	if sftpMaxUploadBytes != expected {
@@ -137,6 +398,70 @@ func TestSFTPMaxUploadConstant(t *testing.T) {
 	}
 }
 
+type fakeFileInfo struct {
+	name string
+	dir  bool
+}
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return 0 }
+func (f fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeFileInfo) IsDir() bool        { return f.dir }
+func (f fakeFileInfo) Sys() any           { return nil }
+func (f fakeFileInfo) Mode() os.FileMode {
+	if f.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func TestPageDirInfosSortsDirsFirstAndPages(t *testing.T) {
+	infos := []os.FileInfo{
+		fakeFileInfo{name: "b.txt"},
+		fakeFileInfo{name: "zeta", dir: true},
+		fakeFileInfo{name: "a.txt"},
+		fakeFileInfo{name: "alpha", dir: true},
+		fakeFileInfo{name: "c.txt"},
+	}
+
+	page, listing := pageDirInfos(infos, ListOptions{Offset: 1, Limit: 3})
+	var names []string
+	for _, fi := range page {
+		names = append(names, fi.Name())
+	}
+	if got, want := strings.Join(names, ","), "zeta,a.txt,b.txt"; got != want {
+		t.Fatalf("page = %s, want %s", got, want)
+	}
+	if listing.Total != 5 || listing.Offset != 1 || listing.Limit != 3 || listing.Truncated {
+		t.Fatalf("unexpected listing meta: %+v", listing)
+	}
+	listing.Entries = make([]DirEntry, len(page))
+	if !listing.HasMore() {
+		t.Fatal("expected more entries after the page")
+	}
+
+	page, listing = pageDirInfos(infos, ListOptions{Offset: 10})
+	if len(page) != 0 || listing.Limit != sftpListDefaultLimit || listing.HasMore() {
+		t.Fatalf("past-the-end page: got %d entries, listing %+v", len(page), listing)
+	}
+
+	_, listing = pageDirInfos(infos, ListOptions{Limit: sftpListMaxLimit + 1})
+	if listing.Limit != sftpListMaxLimit {
+		t.Fatalf("limit = %d, want clamp to %d", listing.Limit, sftpListMaxLimit)
+	}
+}
+
+func TestPageDirInfosCapsEntries(t *testing.T) {
+	infos := make([]os.FileInfo, sftpListMaxEntries+10)
+	for i := range infos {
+		infos[i] = fakeFileInfo{name: fmt.Sprintf("f%06d", i)}
+	}
+	_, listing := pageDirInfos(infos, ListOptions{})
+	if !listing.Truncated || listing.Total != sftpListMaxEntries {
+		t.Fatalf("expected truncation at %d, got %+v", sftpListMaxEntries, listing)
+	}
+}
+
 func TestConnectorConfigFields(t *testing.T) {
 	cfg := ConnectorConfig{
 		Host:     "example.com",
@@ -323,3 +648,658 @@ func TestSSHConnectorCredentialInvalid(t *testing.T) {
 		t.Fatalf("category: got %q, want %q", ce.Category, ErrCatCredentialInvalid)
 	}
 }
+
+func TestSessionInitCommand(t *testing.T) {
+	if got := sessionInitCommand("", nil); got != "" {
+		t.Fatalf("expected no init command, got %q", got)
+	}
+	got := sessionInitCommand("/srv/it's here", map[string]string{"B": "2", "A": "x y"})
+	want := "export A='x y' B='2'; cd -- '/srv/it'\\''s here'"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got, want := sessionStartCommand("cd -- '/srv'", ""), `cd -- '/srv'; exec "${SHELL:-/bin/sh}" -l`; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got, want := sessionStartCommand("cd -- '/srv'", "/bin/bash"), "cd -- '/srv'; exec /bin/bash"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if !isPOSIXShell("bash") || isPOSIXShell("fish") || isPOSIXShell("tcsh") || isPOSIXShell("") {
+		t.Fatal("expected only POSIX login shells to get the init command")
+	}
+}
+
+func TestPrivilegedWrapsWholeCommand(t *testing.T) {
+	command := "mkdir -p /etc/x && tee /etc/x/y"
+	cases := []struct {
+		cfg  ConnectorConfig
+		want string
+	}{
+		{ConnectorConfig{Privilege: PrivilegeNone}, command},
+		{ConnectorConfig{}, command},
+		{ConnectorConfig{Privilege: PrivilegeSudoNoPasswd}, "sudo -n -- sh -c " + ShellQuote(command)},
+		{ConnectorConfig{Privilege: PrivilegeDoas}, "doas -n -- sh -c " + ShellQuote(command)},
+		{ConnectorConfig{Privilege: PrivilegeSudo, AuthType: AuthMethodPrivateKey, Secret: "pem"}, "sudo -n -- sh -c " + ShellQuote(command)},
+		{ConnectorConfig{Privilege: PrivilegeSudo, AuthType: AuthMethodPassword, Secret: "pw"}, "sudo -S -p '' -- sh -c " + ShellQuote(command)},
+	}
+	for _, tc := range cases {
+		if got := tc.cfg.Privileged(command); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.cfg.Privilege, tc.want, got)
+		}
+	}
+}
+
+func TestPrivilegedStdinOnlyForSudoPasswordCommands(t *testing.T) {
+	cfg := ConnectorConfig{Privilege: PrivilegeSudo, AuthType: AuthMethodPassword, Secret: "pw"}
+	if got := cfg.privilegedStdin(cfg.Privileged("id -u")); got != "pw\n" {
+		t.Fatalf("expected password on stdin, got %q", got)
+	}
+	if got := cfg.privilegedStdin("id -u"); got != "" {
+		t.Fatalf("unprivileged command must not receive the password, got %q", got)
+	}
+	cfg.Privilege = PrivilegeSudoNoPasswd
+	if got := cfg.privilegedStdin(cfg.Privileged("id -u")); got != "" {
+		t.Fatalf("sudo -n must not receive the password, got %q", got)
+	}
+}
+
+func TestAsUserSwitchesAccountThroughEscalation(t *testing.T) {
+	command := "ls /srv"
+	cfg := ConnectorConfig{Privilege: PrivilegeSudoNoPasswd, RunAs: "www-data"}
+	if got, err := cfg.AsUser(command); err != nil || got != "sudo -n -u 'www-data' -- sh -c 'ls /srv'" {
+		t.Fatalf("unexpected sudo wrapping %q (%v)", got, err)
+	}
+	cfg.Privilege = PrivilegeDoas
+	if got, err := cfg.AsUser(command); err != nil || got != "doas -n -u 'www-data' -- sh -c 'ls /srv'" {
+		t.Fatalf("unexpected doas wrapping %q (%v)", got, err)
+	}
+	cfg.Privilege = PrivilegeSudo
+	cfg.AuthType, cfg.Secret = AuthMethodPassword, "pw"
+	got, err := cfg.AsUser(command)
+	if err != nil || cfg.privilegedStdin(got) != "pw\n" {
+		t.Fatalf("expected sudo -S with the password on stdin, got %q (%v)", got, err)
+	}
+	cfg.Privilege = PrivilegeNone
+	if _, err := cfg.AsUser(command); !errors.Is(err, ErrRunAsUnsupported) {
+		t.Fatalf("expected ErrRunAsUnsupported, got %v", err)
+	}
+	cfg.RunAs = ""
+	if got, _ := cfg.AsUser(command); got != command {
+		t.Fatalf("expected unchanged command without RunAs, got %q", got)
+	}
+	for _, name := range []string{"root;id", "-u", "Bad", ""} {
+		if ValidRunAsUser(name) {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestThrottleLimitsThroughput(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 96*1024)
+
+	// 64 KB/s with a 32 KB burst: 96 KB needs at least ~1s.
+	start := time.Now()
+	var out bytes.Buffer
+	if _, err := io.Copy(&out, ThrottleReader(context.Background(), bytes.NewReader(payload), 64)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("reader not throttled: %s", elapsed)
+	}
+	if !bytes.Equal(out.Bytes(), payload) {
+		t.Fatal("reader altered data")
+	}
+
+	start = time.Now()
+	out.Reset()
+	if _, err := ThrottleWriter(context.Background(), &out, 64).Write(payload); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("writer not throttled: %s", elapsed)
+	}
+	if !bytes.Equal(out.Bytes(), payload) {
+		t.Fatal("writer altered data")
+	}
+}
+
+func TestThrottleDisabledAndCancelled(t *testing.T) {
+	r := bytes.NewReader(nil)
+	if ThrottleReader(context.Background(), r, 0) != io.Reader(r) {
+		t.Fatal("expected unlimited reader to be returned unchanged")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := ThrottleWriter(ctx, io.Discard, 1)
+	if _, err := w.Write(make([]byte, 4096)); err == nil {
+		t.Fatal("expected cancelled context to abort a throttled write")
+	}
+}
+
+func TestAcquireServerSlotEnforcesLimit(t *testing.T) {
+	releaseA, err := AcquireServerSlot("slot-srv", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseB, err := AcquireServerSlot("slot-srv", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AcquireServerSlot("slot-srv", 2)
+	var busy *ServerBusyError
+	if !errors.As(err, &busy) || busy.Limit != 2 {
+		t.Fatalf("expected ServerBusyError with limit 2, got %v", err)
+	}
+	// Other servers have their own slots.
+	releaseOther, err := AcquireServerSlot("slot-other", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseOther()
+
+	releaseA()
+	releaseA() // releasing twice frees one slot only
+	if got := OpenServerSlots("slot-srv"); got != 1 {
+		t.Fatalf("expected 1 open slot, got %d", got)
+	}
+	releaseC, err := AcquireServerSlot("slot-srv", 2)
+	if err != nil {
+		t.Fatalf("expected a freed slot, got %v", err)
+	}
+	releaseB()
+	releaseC()
+	if got := OpenServerSlots("slot-srv"); got != 0 {
+		t.Fatalf("expected no open slots, got %d", got)
+	}
+
+	// Unlimited still counts, so a limit set later sees open sessions.
+	release, _ := AcquireServerSlot("slot-srv", 0)
+	if _, err := AcquireServerSlot("slot-srv", 1); err == nil {
+		t.Fatal("expected limit 1 to reject while an unlimited slot is open")
+	}
+	release()
+}
+
+// newMemSFTPClient returns an SFTPClient backed by an in-memory SFTP server.
+func newMemSFTPClient(t *testing.T) *SFTPClient {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go func() { _ = server.Serve() }()
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return &SFTPClient{sftpClient: client}
+}
+
+func TestUploadRefusesExistingDestinationWithoutOverwrite(t *testing.T) {
+	c := newMemSFTPClient(t)
+
+	if err := c.Upload(context.Background(), "/a.txt", strings.NewReader("first"), false); err != nil {
+		t.Fatalf("first upload: %v", err)
+	}
+	if err := c.Upload(context.Background(), "/a.txt", strings.NewReader("second"), false); !errors.Is(err, ErrRemoteExists) {
+		t.Fatalf("expected ErrRemoteExists, got %v", err)
+	}
+	if content, _ := c.ReadFile("/a.txt", 1024); content != "first" {
+		t.Fatalf("expected original content kept, got %q", content)
+	}
+	if err := c.Upload(context.Background(), "/a.txt", strings.NewReader("third"), true); err != nil {
+		t.Fatalf("overwrite upload: %v", err)
+	}
+	if content, _ := c.ReadFile("/a.txt", 1024); content != "third" {
+		t.Fatalf("expected overwritten content, got %q", content)
+	}
+}
+
+func TestWriteChunkResumesAtOffsetAndCommitRespectsOverwrite(t *testing.T) {
+	c := newMemSFTPClient(t)
+
+	if n, err := c.WriteChunk(context.Background(), "/big.part", 0, strings.NewReader("hello "), 10); err != nil || n != 6 {
+		t.Fatalf("first chunk: n=%d err=%v", n, err)
+	}
+	// A failed attempt left extra bytes; the retry at the same offset replaces them.
+	if _, err := c.WriteChunk(context.Background(), "/big.part", 6, strings.NewReader("garbage"), 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.WriteChunk(context.Background(), "/big.part", 6, strings.NewReader("world"), 10); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := c.ReadFile("/big.part", 1024); content != "hello world" {
+		t.Fatalf("expected resumed content, got %q", content)
+	}
+	if _, err := c.WriteChunk(context.Background(), "/big.part", 20, strings.NewReader("x"), 10); !errors.Is(err, ErrChunkOffset) {
+		t.Fatalf("expected ErrChunkOffset, got %v", err)
+	}
+	if _, err := c.WriteChunk(context.Background(), "/big.part", 11, strings.NewReader("too long"), 3); !errors.Is(err, ErrChunkTooLarge) {
+		t.Fatalf("expected ErrChunkTooLarge, got %v", err)
+	}
+	if content, _ := c.ReadFile("/big.part", 1024); content != "hello world" {
+		t.Fatalf("oversized chunk must leave the file at its offset, got %q", content)
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WriteChunk(cancelled, "/big.part", 11, strings.NewReader("late"), 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled chunk to fail with context.Canceled, got %v", err)
+	}
+	if content, _ := c.ReadFile("/big.part", 1024); content != "hello world" {
+		t.Fatalf("cancelled chunk must leave the file at its offset, got %q", content)
+	}
+
+	if err := c.Upload(context.Background(), "/big.txt", strings.NewReader("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CommitUpload("/big.part", "/big.txt", false); !errors.Is(err, ErrRemoteExists) {
+		t.Fatalf("expected ErrRemoteExists, got %v", err)
+	}
+	if err := c.CommitUpload("/big.part", "/big.txt", true); err != nil {
+		t.Fatalf("commit with overwrite: %v", err)
+	}
+	if content, _ := c.ReadFile("/big.txt", 1024); content != "hello world" {
+		t.Fatalf("expected committed content, got %q", content)
+	}
+	if _, err := c.Stat("/big.part"); err == nil {
+		t.Fatal("expected staging file to be renamed away")
+	}
+}
+
+func TestChunkedUploadRegistryExpiresIdleUploads(t *testing.T) {
+	RegisterChunkedUpload(&ChunkedUpload{ID: "idle"}, time.Minute)
+	RegisterChunkedUpload(&ChunkedUpload{ID: "active"}, time.Hour)
+	t.Cleanup(func() {
+		RemoveChunkedUpload("idle")
+		RemoveChunkedUpload("active")
+	})
+
+	if _, ok := LookupChunkedUpload("idle", time.Minute); !ok {
+		t.Fatal("expected registered upload to be found")
+	}
+	expired := ExpireChunkedUploads(time.Now().Add(30 * time.Minute))
+	if len(expired) != 1 || expired[0].ID != "idle" {
+		t.Fatalf("expected only the idle upload to expire, got %+v", expired)
+	}
+	if _, ok := LookupChunkedUpload("idle", time.Minute); ok {
+		t.Fatal("expired upload must not be found")
+	}
+	if _, ok := LookupChunkedUpload("active", time.Hour); !ok {
+		t.Fatal("active upload must survive")
+	}
+}
+
+// cancelingWriter cancels its context after the first write.
+type cancelingWriter struct {
+	n      int
+	cancel context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	w.n += len(p)
+	return len(p), nil
+}
+
+func TestSFTPOperationsStopWhenContextIsCancelled(t *testing.T) {
+	c := newMemSFTPClient(t)
+
+	if err := c.MkdirAll("/srv/data"); err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("x", 256*1024)
+	if err := c.WriteFile("/srv/data/big.bin", big); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dst := &cancelingWriter{cancel: cancel}
+	if err := c.Download(ctx, "/srv/data/big.bin", dst); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the download to stop with context.Canceled, got %v", err)
+	}
+	if dst.n >= len(big) {
+		t.Fatalf("expected a partial download, got all %d bytes", dst.n)
+	}
+
+	if _, err := c.ListDir(ctx, "/srv/data", ListOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected ListDir to fail with context.Canceled, got %v", err)
+	}
+	if _, _, err := c.SearchFiles(ctx, "/srv", "big"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected SearchFiles to fail with context.Canceled, got %v", err)
+	}
+	if _, err := c.Copy(ctx, "/srv/data/big.bin", "/srv/data/copy.bin", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected Copy to fail with context.Canceled, got %v", err)
+	}
+	if _, err := c.Stat("/srv/data/copy.bin"); err == nil {
+		t.Fatal("expected the cancelled copy to remove its partial target")
+	}
+}
+
+func TestReadFilesReportsPerPathErrorsAndTotalLimit(t *testing.T) {
+	c := newMemSFTPClient(t)
+
+	if err := c.MkdirAll("/srv/app"); err != nil {
+		t.Fatal(err)
+	}
+	for p, content := range map[string]string{
+		"/srv/app/compose.yml": "services: {}",
+		"/srv/app/.env":        "A=1",
+		"/srv/app/big.log":     strings.Repeat("x", 64),
+		"/srv/app/tail.txt":    "0123456789",
+	} {
+		if err := c.WriteFile(p, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths := []string{"/srv/app/compose.yml", "/srv/app/missing", "/srv/app/big.log", "/srv/app/tail.txt", "/srv/app/.env"}
+	results := c.ReadFiles(context.Background(), paths, 32, 20)
+	if len(results) != len(paths) {
+		t.Fatalf("expected %d results, got %d", len(paths), len(results))
+	}
+	if results[0].Err != nil || results[0].Content != "services: {}" {
+		t.Fatalf("expected compose.yml content, got %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Fatal("expected an error for the missing file")
+	}
+	if results[2].Err == nil || results[2].Content != "" {
+		t.Fatalf("expected the per-file limit to reject big.log, got %+v", results[2])
+	}
+	if !errors.Is(results[3].Err, ErrBatchTooLarge) {
+		t.Fatalf("expected tail.txt to exceed the total limit, got %+v", results[3])
+	}
+	if results[4].Err != nil || results[4].Content != "A=1" {
+		t.Fatalf("expected .env to still fit, got %+v", results[4])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range c.ReadFiles(ctx, paths[:1], 32, 20) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %+v", r)
+		}
+	}
+}
+
+func TestWriteZipArchivesDirectoryWithRelativePathsAndModes(t *testing.T) {
+	c := newMemSFTPClient(t)
+
+	if err := c.MkdirAll("/etc/app/conf.d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteFile("/etc/app/main.conf", "listen 80"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteFile("/etc/app/conf.d/site.conf", "server {}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Symlink("main.conf", "/etc/app/current"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.PlanArchive(context.Background(), "/etc/app", 10); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("expected ErrArchiveTooLarge, got %v", err)
+	}
+	plan, err := c.PlanArchive(context.Background(), "/etc/app/", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Files != 2 || plan.Size != int64(len("listen 80")+len("server {}")) {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+
+	var buf bytes.Buffer
+	written, err := c.WriteZip(context.Background(), plan, &buf, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != plan.Size {
+		t.Fatalf("expected %d bytes written, got %d", plan.Size, written)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]*zip.File{}
+	for _, f := range zr.File {
+		got[f.Name] = f
+	}
+	for _, name := range []string{"app/", "app/conf.d/", "app/main.conf", "app/conf.d/site.conf", "app/current"} {
+		if got[name] == nil {
+			t.Fatalf("missing %s in archive; have %v", name, got)
+		}
+	}
+	for _, entry := range plan.Entries {
+		if mode := got[entry.Name].Mode(); mode != entry.Info.Mode() {
+			t.Fatalf("expected %s mode %v kept, got %v", entry.Name, entry.Info.Mode(), mode)
+		}
+	}
+	if got["app/current"].Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected symlink entry")
+	}
+	rc, err := got["app/conf.d/site.conf"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(content) != "server {}" {
+		t.Fatalf("unexpected content %q", content)
+	}
+}
+
+func TestCastRecorderWritesHeaderResizesAndSplitRunes(t *testing.T) {
+	rec, err := NewCastRecorder(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+
+	sess := RecordSession(&mockSession{}, rec)
+	if err := sess.Resize(40, 120); err != nil {
+		t.Fatal(err)
+	}
+	rec.Output([]byte("h\xc3"))   // "hé" split inside the two-byte é
+	rec.Output([]byte("\xa9 ok")) // completes it
+	if err := sess.Resize(50, 132); err != nil {
+		t.Fatal(err)
+	}
+	rec.Output([]byte("0123456789abcdef")) // over the 16-byte cap
+	if !rec.Truncated() {
+		t.Fatal("expected recording to be truncated")
+	}
+
+	var buf bytes.Buffer
+	if _, err := rec.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header and 3 events, got %q", buf.String())
+	}
+	var header struct {
+		Version int `json:"version"`
+		Width   int `json:"width"`
+		Height  int `json:"height"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 120 || header.Height != 40 {
+		t.Fatalf("unexpected header %s", lines[0])
+	}
+	want := []struct{ code, data string }{{"o", "h"}, {"o", "é ok"}, {"r", "132x50"}}
+	for i, w := range want {
+		var event []any
+		if err := json.Unmarshal([]byte(lines[i+1]), &event); err != nil {
+			t.Fatal(err)
+		}
+		if len(event) != 3 || event[1] != w.code || event[2] != w.data {
+			t.Fatalf("event %d = %s, want %s %q", i, lines[i+1], w.code, w.data)
+		}
+	}
+}
+
+func TestDeleteRecursiveRemovesTreeWithoutFollowingSymlinks(t *testing.T) {
+	c := newMemSFTPClient(t)
+
+	for _, dir := range []string{"/outside", "/d/sub/deeper"} {
+		if err := c.MkdirAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"/outside/keep.txt", "/d/a.txt", "/d/sub/b.txt", "/d/sub/deeper/c.txt"} {
+		if err := c.Upload(context.Background(), file, strings.NewReader("x"), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.sftpClient.Symlink("/outside", "/d/link"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Delete("/d"); err == nil {
+		t.Fatal("expected plain delete of a non-empty directory to fail")
+	}
+	if err := c.DeleteRecursive("/d"); err != nil {
+		t.Fatalf("recursive delete: %v", err)
+	}
+	if _, err := c.sftpClient.Lstat("/d"); err == nil {
+		t.Fatal("expected /d to be removed")
+	}
+	if content, err := c.ReadFile("/outside/keep.txt", 1024); err != nil || content != "x" {
+		t.Fatalf("expected symlink target kept, got %q, %v", content, err)
+	}
+
+	// A file is removed like Delete would.
+	if err := c.DeleteRecursive("/outside/keep.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.sftpClient.Lstat("/outside/keep.txt"); err == nil {
+		t.Fatal("expected file to be removed")
+	}
+}
+
+func TestDeleteErrorListsFailedPaths(t *testing.T) {
+	err := error(&DeleteError{Failed: []DeleteFailure{{Path: "/d/a", Error: "permission denied"}, {Path: "/d/b", Error: "permission denied"}}})
+	if got := err.Error(); got != "sftp: 2 path(s) could not be removed: /d/a, /d/b" {
+		t.Fatalf("unexpected message %q", got)
+	}
+}
+
+// symlinkRealPath resolves the symlinks in links (absolute link path →
+// absolute target) so the in-memory server's RealPath behaves like a real
+// sftp-server's.
+type symlinkRealPath struct {
+	sftp.FileLister
+	links map[string]string
+}
+
+func (s symlinkRealPath) RealPath(p string) (string, error) {
+	p = filepath.ToSlash(filepath.Clean("/" + p))
+	for link, target := range s.links {
+		if p == link || strings.HasPrefix(p, link+"/") {
+			return target + strings.TrimPrefix(p, link), nil
+		}
+	}
+	return p, nil
+}
+
+func TestSFTPRootConfinesPaths(t *testing.T) {
+	links := map[string]string{"/srv/app/escape": "/etc"}
+	handlers := sftp.InMemHandler()
+	handlers.FileList = symlinkRealPath{FileLister: handlers.FileList, links: links}
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, handlers)
+	go func() { _ = server.Serve() }()
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	c := &SFTPClient{sftpClient: client, defaultDir: "/home/ops"}
+
+	for _, dir := range []string{"/srv/app/data", "/etc"} {
+		if err := c.MkdirAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.WriteFile("/etc/passwd", "root:x:0:0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Symlink("/etc", "/srv/app/escape"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetRoot("/srv/app/"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.DefaultDir(); got != "/srv/app" {
+		t.Fatalf("expected the root as default dir, got %q", got)
+	}
+	if _, err := c.ListDir(context.Background(), "/srv/app", ListOptions{}); err != nil {
+		t.Fatalf("listing the root: %v", err)
+	}
+	if err := c.WriteFile("/srv/app/data/new.txt", "ok"); err != nil {
+		t.Fatalf("writing a new file inside the root: %v", err)
+	}
+
+	for _, p := range []string{"/etc/passwd", "/srv/app/../../etc/passwd", "/srv/application/x", "srv/app/data/new.txt", "/srv/app/escape/passwd"} {
+		if _, err := c.ReadFile(p, 1024); !errors.Is(err, ErrOutsideRoot) {
+			t.Errorf("ReadFile(%q): expected ErrOutsideRoot, got %v", p, err)
+		}
+	}
+	if err := c.WriteFile("/srv/app/escape/passwd", "pwned"); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("expected writing through the symlink to be refused, got %v", err)
+	}
+	if err := c.Rename("/srv/app/data/new.txt", "/etc/new.txt"); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("expected renaming out of the root to be refused, got %v", err)
+	}
+	if err := c.Symlink("../../../etc", "/srv/app/data/up"); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("expected a symlink leading out of the root to be refused, got %v", err)
+	}
+	if _, err := c.Copy(context.Background(), "/srv/app/escape/passwd", "/srv/app/data/passwd", nil); !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("expected copying from outside the root to be refused, got %v", err)
+	}
+
+	// The link itself lives inside the root and may be renamed or removed.
+	if err := c.confineEntry("/srv/app/escape"); err != nil {
+		t.Fatalf("expected the symlink entry inside the root to be allowed, got %v", err)
+	}
+	if content, _ := c.ReadFile("/srv/app/data/new.txt", 1024); content != "ok" {
+		t.Fatalf("expected the file inside the root, got %q", content)
+	}
+}
+
+func TestLocalSessionExportsEnv(t *testing.T) {
+	sess, err := NewLocalSession(context.Background(), "sh", map[string]string{"APPOS_APP": "wordpress"})
+	if err != nil {
+		t.Skipf("local PTY unavailable: %v", err)
+	}
+	defer sess.Close()
+
+	if _, err := sess.Write([]byte("echo \"app=$APPOS_APP\"; exit\n")); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	buf := make([]byte, 1024)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "app=wordpress") && time.Now().Before(deadline) {
+		n, err := sess.Read(buf)
+		out.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+	if !strings.Contains(out.String(), "app=wordpress") {
+		t.Fatalf("expected APPOS_APP in the shell environment, got %q", out.String())
+	}
+}
@@ -0,0 +1,123 @@
+package terminal
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// throttleChunkBytes caps a single read/write so a low rate limit yields
+// smooth output instead of long stalls followed by large bursts.
+const throttleChunkBytes = 32 * 1024
+
+// newTransferLimiter returns a limiter for kbps kilobytes per second, or nil
+// when kbps <= 0 (unlimited). One limiter covers one transfer.
+func newTransferLimiter(kbps int) *rate.Limiter {
+	if kbps <= 0 {
+		return nil
+	}
+	bytesPerSec := kbps * 1024
+	burst := min(bytesPerSec, throttleChunkBytes)
+	return rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+}
+
+// throttledReader delays reads so throughput stays within the limiter.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// ThrottleReader wraps r so reads are limited to kbps KB/s. It returns r
+// unchanged when kbps <= 0.
+func ThrottleReader(ctx context.Context, r io.Reader, kbps int) io.Reader {
+	return throttleReader(ctx, r, newTransferLimiter(kbps))
+}
+
+func throttleReader(ctx context.Context, r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, limiter: limiter}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if burst := t.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.WaitN(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttledWriter delays writes so throughput stays within the limiter.
+type throttledWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *rate.Limiter
+}
+
+// ThrottleWriter wraps w so writes are limited to kbps KB/s. It returns w
+// unchanged when kbps <= 0.
+func ThrottleWriter(ctx context.Context, w io.Writer, kbps int) io.Writer {
+	limiter := newTransferLimiter(kbps)
+	if limiter == nil {
+		return w
+	}
+	return &throttledWriter{ctx: ctx, w: w, limiter: limiter}
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	var written int
+	burst := t.limiter.Burst()
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > burst {
+			chunk = chunk[:burst]
+		}
+		if err := t.limiter.WaitN(t.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// contextReader fails reads once its context is done, so a copy loop over a
+// remote file stops at the next chunk after the caller goes away.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// contextWriter fails writes once its context is done. Wrapping the
+// destination rather than the source keeps sftp.File's concurrent WriteTo
+// in play for io.Copy.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c contextWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
@@ -0,0 +1,170 @@
+package transfer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/groups"
+	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/infra/crypto"
+)
+
+// Export writes the resources of the given types (all Types when empty) and
+// every group to a Document. Secret payloads are decrypted with the instance
+// key and sealed under passphrase. Archived records are left out.
+func Export(app core.App, passphrase string, only []string) (*Document, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, ErrWeakPassphrase
+	}
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.DeriveKey(passphrase, salt, crypto.PassphraseIterations)
+	if err != nil {
+		return nil, err
+	}
+	check, err := crypto.EncryptWithKey(Format, key)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Encryption: Encryption{
+			KDF:        kdfPBKDF2SHA256,
+			Iterations: crypto.PassphraseIterations,
+			Salt:       hex.EncodeToString(salt),
+			Check:      check,
+		},
+		Groups:    []Group{},
+		Resources: []Resource{},
+	}
+
+	groupRecords, err := app.FindAllRecords(groups.Collection)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(groupRecords, func(i, j int) bool {
+		return groupRecords[i].GetString("name") < groupRecords[j].GetString("name")
+	})
+	for _, rec := range groupRecords {
+		doc.Groups = append(doc.Groups, Group{
+			ID:          rec.Id,
+			Name:        rec.GetString("name"),
+			Description: rec.GetString("description"),
+		})
+	}
+
+	items, err := app.FindAllRecords(groups.ItemsCollection)
+	if err != nil {
+		return nil, err
+	}
+	memberships := map[string][]string{}
+	for _, item := range items {
+		member := item.GetString("object_type") + "/" + item.GetString("object_id")
+		memberships[member] = append(memberships[member], item.GetString("group_id"))
+	}
+
+	types, exported := resourceTypes(app)
+	var varFields map[string]core.Field
+	if varsCol, err := app.FindCollectionByNameOrId(envVarsCollection); err == nil {
+		varFields = portableFields(varsCol, exported)
+		delete(varFields, "set")
+	}
+
+	for _, typeKey := range Types {
+		rt, ok := types[typeKey]
+		if !ok || (len(only) > 0 && !containsType(only, rt)) {
+			continue
+		}
+		col, err := app.FindCollectionByNameOrId(rt.Collection)
+		if err != nil {
+			return nil, err
+		}
+		fields := portableFields(col, exported)
+		records, err := app.FindAllRecords(col)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].GetString(rt.NameField) < records[j].GetString(rt.NameField)
+		})
+
+		for _, rec := range records {
+			if archive.Supported(col) && archive.IsArchived(rec) {
+				continue
+			}
+			res := Resource{
+				Type:   rt.Key,
+				ID:     rec.Id,
+				Name:   rec.GetString(rt.NameField),
+				Fields: recordFields(rec, fields),
+				Groups: memberships[string(rt.ObjectType)+"/"+rec.Id],
+			}
+			if col.Name == secretsCollection {
+				sealed, err := sealPayload(rec, key)
+				if err != nil {
+					doc.Skipped = append(doc.Skipped, Skipped{Type: rt.Key, ID: rec.Id, Name: res.Name, Error: err.Error()})
+					continue
+				}
+				res.Payload = sealed
+			}
+			if rt.Collection == "env_sets" && varFields != nil {
+				vars, err := app.FindAllRecords(envVarsCollection, dbx.HashExp{"set": rec.Id})
+				if err != nil {
+					return nil, err
+				}
+				sort.Slice(vars, func(i, j int) bool { return vars[i].GetString("key") < vars[j].GetString("key") })
+				for _, v := range vars {
+					res.Vars = append(res.Vars, recordFields(v, varFields))
+				}
+			}
+			doc.Resources = append(doc.Resources, res)
+		}
+	}
+	return doc, nil
+}
+
+func containsType(only []string, rt groups.ResourceType) bool {
+	for _, token := range only {
+		if other, ok := groups.LookupResourceType(token); ok && other.Key == rt.Key {
+			return true
+		}
+	}
+	return false
+}
+
+func recordFields(rec *core.Record, fields map[string]core.Field) map[string]any {
+	out := make(map[string]any, len(fields))
+	for name, f := range fields {
+		if relation, ok := f.(*core.RelationField); ok && relation.IsMultiple() {
+			out[name] = rec.GetStringSlice(name)
+			continue
+		}
+		out[name] = rec.Get(name)
+	}
+	return out
+}
+
+// sealPayload decrypts a secret's payload with the instance key and seals
+// it under the document key.
+func sealPayload(rec *core.Record, key []byte) (string, error) {
+	payload, err := secrets.DecryptPayload(rec.GetString("payload_encrypted"))
+	if err != nil {
+		return "", err
+	}
+	plain, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return crypto.EncryptWithKey(string(plain), key)
+}
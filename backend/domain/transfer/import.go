@@ -0,0 +1,379 @@
+package transfer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/groups"
+	"github.com/websoft9/appos/backend/domain/secrets"
+	"github.com/websoft9/appos/backend/infra/crypto"
+)
+
+// Import statuses reported per group and per resource.
+const (
+	StatusCreated = "created"
+	StatusUpdated = "updated"
+	StatusSkipped = "skipped"
+	StatusExists  = "exists"
+	StatusFailed  = "failed"
+)
+
+// ImportOptions controls Import.
+type ImportOptions struct {
+	// Overwrite updates a resource whose type and name already exist;
+	// otherwise the existing resource is left untouched and reported skipped.
+	Overwrite bool
+	// UserID becomes created_by of created records.
+	UserID string
+}
+
+// Result is the outcome of importing one group or resource. ID is the
+// document's (source) ID and RecordID the record on this instance.
+type Result struct {
+	Type     string `json:"type,omitempty"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	RecordID string `json:"record_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report summarizes an import.
+type Report struct {
+	Groups    []Result `json:"groups"`
+	Resources []Result `json:"resources"`
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Skipped   int      `json:"skipped"`
+	Failed    int      `json:"failed"`
+}
+
+// Import recreates the groups and resources of doc. Groups are matched by
+// name and resources by type and name, so importing the same document twice
+// does not duplicate anything. Each resource is saved in its own
+// transaction; one failure does not stop the rest. Import only returns an
+// error when the document itself is unusable.
+func Import(app core.App, doc *Document, passphrase string, opts ImportOptions) (*Report, error) {
+	if doc == nil || doc.Format != Format || doc.Version != Version {
+		return nil, ErrUnsupportedDocument
+	}
+	salt, err := decodeSalt(doc.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.DeriveKey(passphrase, salt, doc.Encryption.Iterations)
+	if err != nil {
+		return nil, ErrUnsupportedDocument
+	}
+	if check, err := crypto.DecryptWithKey(doc.Encryption.Check, key); err != nil || check != Format {
+		return nil, ErrWrongPassphrase
+	}
+
+	report := &Report{Groups: []Result{}, Resources: []Result{}}
+	groupIDs := importGroups(app, doc.Groups, opts, report)
+
+	types, exported := resourceTypes(app)
+	resources := sortByType(doc.Resources)
+	im := &importer{
+		app:      app,
+		key:      key,
+		opts:     opts,
+		exported: exported,
+		groupIDs: groupIDs,
+		ids:      map[string]string{},
+	}
+	for _, res := range resources {
+		result := Result{Type: res.Type, ID: res.ID, Name: res.Name}
+		rt, ok := types[res.Type]
+		if !ok {
+			result.Status, result.Error = StatusFailed, "unsupported resource type "+res.Type
+		} else {
+			result.Status, result.RecordID, err = im.importResource(rt, res)
+			if err != nil {
+				result.Status, result.Error = StatusFailed, err.Error()
+			}
+		}
+		switch result.Status {
+		case StatusCreated:
+			report.Created++
+		case StatusUpdated:
+			report.Updated++
+		case StatusSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Resources = append(report.Resources, result)
+	}
+	return report, nil
+}
+
+// sortByType orders resources by Types so referenced secrets are
+// imported before the resources that use them.
+func sortByType(resources []Resource) []Resource {
+	sorted := append([]Resource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return typeOrder(sorted[i].Type) < typeOrder(sorted[j].Type)
+	})
+	return sorted
+}
+
+// importGroups maps every document group to a group of the same name,
+// creating missing ones, and returns source ID → target ID.
+func importGroups(app core.App, docGroups []Group, opts ImportOptions, report *Report) map[string]string {
+	ids := map[string]string{}
+	col, colErr := app.FindCollectionByNameOrId(groups.Collection)
+	for _, g := range docGroups {
+		result := Result{ID: g.ID, Name: g.Name}
+		name := strings.TrimSpace(g.Name)
+		switch {
+		case colErr != nil:
+			result.Status, result.Error = StatusFailed, colErr.Error()
+		case name == "":
+			result.Status, result.Error = StatusFailed, "group name is required"
+		default:
+			if existing, err := app.FindFirstRecordByData(col, "name", name); err == nil {
+				result.Status, result.RecordID = StatusExists, existing.Id
+			} else {
+				rec := core.NewRecord(col)
+				rec.Set("name", name)
+				rec.Set("description", g.Description)
+				rec.Set("created_by", opts.UserID)
+				if err := app.Save(rec); err != nil {
+					result.Status, result.Error = StatusFailed, err.Error()
+				} else {
+					result.Status, result.RecordID = StatusCreated, rec.Id
+				}
+			}
+		}
+		if result.RecordID != "" {
+			ids[g.ID] = result.RecordID
+		}
+		report.Groups = append(report.Groups, result)
+	}
+	return ids
+}
+
+type importer struct {
+	app      core.App
+	key      []byte
+	opts     ImportOptions
+	exported map[string]*core.Collection
+	groupIDs map[string]string
+	// ids maps source record IDs to the records they became here.
+	ids map[string]string
+}
+
+func (im *importer) importResource(rt groups.ResourceType, res Resource) (status, recordID string, err error) {
+	name := strings.TrimSpace(res.Name)
+	if name == "" {
+		return "", "", fmt.Errorf("%s is required", rt.NameField)
+	}
+	col, err := im.app.FindCollectionByNameOrId(rt.Collection)
+	if err != nil {
+		return "", "", err
+	}
+
+	existing, err := im.app.FindFirstRecordByData(col, rt.NameField, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", "", err
+	}
+	if existing != nil && !im.opts.Overwrite {
+		im.ids[res.ID] = existing.Id
+		return StatusSkipped, existing.Id, nil
+	}
+
+	record := existing
+	status = StatusUpdated
+	if record == nil {
+		record = core.NewRecord(col)
+		status = StatusCreated
+		if col.Fields.GetByName("created_by") != nil {
+			record.Set("created_by", im.opts.UserID)
+		}
+	}
+	if err := im.setFields(record, portableFields(col, im.exported), res.Fields); err != nil {
+		return "", "", err
+	}
+	record.Set(rt.NameField, name)
+	if col.Name == secretsCollection {
+		if err := im.setPayload(record, res.Payload, existing == nil); err != nil {
+			return "", "", err
+		}
+	}
+
+	err = im.app.RunInTransaction(func(txApp core.App) error {
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+		if rt.Collection == "env_sets" {
+			if err := im.importVars(txApp, record.Id, res.Vars); err != nil {
+				return err
+			}
+		}
+		var groupIDs []string
+		for _, id := range res.Groups {
+			if target, ok := im.groupIDs[id]; ok {
+				groupIDs = append(groupIDs, target)
+			}
+		}
+		return groups.Assign(txApp, rt.ObjectType, record.Id, groupIDs)
+	})
+	if err != nil {
+		return "", "", err
+	}
+	im.ids[res.ID] = record.Id
+	return status, record.Id, nil
+}
+
+// setFields copies the portable values of a document resource onto record,
+// remapping relation IDs and secretRef values to the records imported so
+// far. Fields this instance does not know are ignored.
+func (im *importer) setFields(record *core.Record, fields map[string]core.Field, values map[string]any) error {
+	for name, value := range values {
+		f, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if _, ok := f.(*core.RelationField); ok {
+			var mapped []string
+			for _, id := range relationIDs(value) {
+				target, ok := im.ids[id]
+				if !ok {
+					return fmt.Errorf("%s references %s, which was not imported", name, id)
+				}
+				mapped = append(mapped, target)
+			}
+			record.Set(name, mapped)
+			continue
+		}
+		record.Set(name, im.remapSecretRefs(value))
+	}
+	return nil
+}
+
+// setPayload unseals a secret payload from the document, validates it
+// against the secret's template and encrypts it with the instance key.
+func (im *importer) setPayload(record *core.Record, sealed string, created bool) error {
+	if sealed == "" {
+		return errors.New("secret payload is missing")
+	}
+	plain, err := crypto.DecryptWithKey(sealed, im.key)
+	if err != nil {
+		return errors.New("secret payload does not decrypt")
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(plain), &payload); err != nil {
+		return errors.New("secret payload is not a JSON object")
+	}
+	tpl, ok := secrets.FindTemplate(record.GetString("template_id"))
+	if !ok {
+		return errors.New("invalid template_id")
+	}
+	if err := secrets.ValidatePayload(payload, tpl); err != nil {
+		return err
+	}
+	enc, err := secrets.EncryptPayload(payload)
+	if err != nil {
+		return err
+	}
+	record.Set("payload_encrypted", enc)
+	record.Set("payload_meta", secrets.BuildPayloadMeta(payload, tpl))
+	record.Set("payload", nil)
+	if created {
+		record.Set("version", 1)
+	} else {
+		record.Set("version", record.GetInt("version")+1)
+	}
+	return nil
+}
+
+// importVars upserts an env set's variables by key. Variables the document
+// does not mention are kept.
+func (im *importer) importVars(txApp core.App, setID string, vars []map[string]any) error {
+	if len(vars) == 0 {
+		return nil
+	}
+	col, err := txApp.FindCollectionByNameOrId(envVarsCollection)
+	if err != nil {
+		return err
+	}
+	fields := portableFields(col, im.exported)
+	delete(fields, "set")
+	for _, values := range vars {
+		key, _ := values["key"].(string)
+		if strings.TrimSpace(key) == "" {
+			return errors.New("env set variable key is required")
+		}
+		record, err := txApp.FindFirstRecordByFilter(col, "set = {:set} && key = {:key}", dbx.Params{"set": setID, "key": key})
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			record = core.NewRecord(col)
+			record.Set("set", setID)
+		}
+		if err := im.setFields(record, fields, values); err != nil {
+			return fmt.Errorf("variable %s: %w", key, err)
+		}
+		if err := txApp.Save(record); err != nil {
+			return fmt.Errorf("variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// remapSecretRefs rewrites "secretRef:<id>" strings inside value to the
+// imported secret's ID.
+func (im *importer) remapSecretRefs(value any) any {
+	switch v := value.(type) {
+	case string:
+		if id, ok := secrets.ExtractSecretID(v); ok {
+			if target, ok := im.ids[id]; ok {
+				return secrets.SecretRefPrefix + target
+			}
+		}
+		return v
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = im.remapSecretRefs(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = im.remapSecretRefs(item)
+		}
+		return out
+	}
+	return value
+}
+
+func relationIDs(value any) []string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		ids := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				ids = append(ids, s)
+			}
+		}
+		return ids
+	}
+	return nil
+}
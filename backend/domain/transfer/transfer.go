@@ -0,0 +1,179 @@
+// Package transfer moves resource-store records between AppOS instances as
+// one JSON document.
+//
+// Secret payloads in a document are sealed under a caller-supplied
+// passphrase instead of the instance key, so the document can be imported
+// anywhere the passphrase is known. Importing matches records by type and
+// name: re-running an import updates or skips what is already there instead
+// of creating duplicates. Group memberships travel with the records and are
+// remapped to the target instance's groups by group name.
+package transfer
+
+import (
+	"encoding/hex"
+	"errors"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+	"github.com/websoft9/appos/backend/domain/groups"
+)
+
+const (
+	// Format identifies an AppOS resource document.
+	Format = "appos.resources"
+	// Version is the document layout written by Export.
+	Version = 1
+	// MinPassphraseLength is the shortest passphrase Export accepts.
+	MinPassphraseLength = 8
+
+	kdfPBKDF2SHA256   = "pbkdf2-sha256"
+	envVarsCollection = "env_set_vars"
+	secretsCollection = "secrets"
+)
+
+var (
+	ErrWeakPassphrase      = errors.New("passphrase must be at least 8 characters")
+	ErrWrongPassphrase     = errors.New("passphrase does not decrypt this document")
+	ErrUnsupportedDocument = errors.New("not a supported AppOS resource document")
+)
+
+// Types lists the resource types a document carries, in import order.
+// Secrets come first because the other types reference them.
+var Types = []string{
+	"secrets",
+	"servers",
+	"env_sets",
+	"instances",
+	"ai_providers",
+	"provider_accounts",
+	"certificates",
+	"connectors",
+	"scripts",
+}
+
+// Document is the portable form of a set of resources.
+type Document struct {
+	Format     string     `json:"format"`
+	Version    int        `json:"version"`
+	ExportedAt string     `json:"exported_at"`
+	Encryption Encryption `json:"encryption"`
+	Groups     []Group    `json:"groups"`
+	Resources  []Resource `json:"resources"`
+	// Skipped lists records Export could not include, e.g. secrets whose
+	// payload no longer decrypts with the instance key.
+	Skipped []Skipped `json:"skipped,omitempty"`
+}
+
+// Encryption describes how the passphrase key of a document is derived.
+// Check is Format sealed under that key, so a wrong passphrase is rejected
+// before any record is written.
+type Encryption struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Check      string `json:"check"`
+}
+
+// Group is a group as exported; ID is the source instance's ID.
+type Group struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Resource is one exported record. ID is the source instance's record ID:
+// relation fields and secretRef values refer to other resources by it.
+type Resource struct {
+	Type   string         `json:"type"`
+	ID     string         `json:"id"`
+	Name   string         `json:"name"`
+	Fields map[string]any `json:"fields"`
+	// Groups holds source group IDs.
+	Groups []string `json:"groups,omitempty"`
+	// Payload is a secret's payload, sealed under the document passphrase.
+	Payload string `json:"payload,omitempty"`
+	// Vars holds an env set's variables.
+	Vars []map[string]any `json:"vars,omitempty"`
+}
+
+// Skipped is a record left out of a document.
+type Skipped struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// skipFields are instance-local fields a document never carries.
+var skipFields = map[string]struct{}{
+	"created_by":   {},
+	"last_used_at": {},
+	"last_used_by": {},
+	"version":      {},
+	archive.Field:  {},
+}
+
+// resourceTypes resolves Types to their registry entries, skipping types
+// whose collection does not exist.
+func resourceTypes(app core.App) (map[string]groups.ResourceType, map[string]*core.Collection) {
+	types := map[string]groups.ResourceType{}
+	cols := map[string]*core.Collection{}
+	for _, key := range Types {
+		rt, ok := groups.LookupResourceType(key)
+		if !ok {
+			continue
+		}
+		col, err := app.FindCollectionByNameOrId(rt.Collection)
+		if err != nil {
+			continue
+		}
+		types[key] = rt
+		cols[col.Id] = col
+	}
+	return types, cols
+}
+
+// portableFields returns the fields of col a document carries: every
+// non-system field that is not hidden, a password, a file, an autodate, an
+// instance-local field or a relation to a collection outside exported.
+func portableFields(col *core.Collection, exported map[string]*core.Collection) map[string]core.Field {
+	fields := map[string]core.Field{}
+	for _, f := range col.Fields {
+		if f.GetSystem() || f.GetHidden() {
+			continue
+		}
+		if _, skip := skipFields[f.GetName()]; skip {
+			continue
+		}
+		switch field := f.(type) {
+		case *core.AutodateField, *core.PasswordField, *core.FileField:
+			continue
+		case *core.RelationField:
+			if _, ok := exported[field.CollectionId]; !ok {
+				continue
+			}
+		}
+		fields[f.GetName()] = f
+	}
+	return fields
+}
+
+func typeOrder(key string) int {
+	if i := slices.Index(Types, key); i >= 0 {
+		return i
+	}
+	return len(Types)
+}
+
+func decodeSalt(enc Encryption) ([]byte, error) {
+	if enc.KDF != kdfPBKDF2SHA256 {
+		return nil, ErrUnsupportedDocument
+	}
+	salt, err := hex.DecodeString(enc.Salt)
+	if err != nil {
+		return nil, ErrUnsupportedDocument
+	}
+	return salt, nil
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/websoft9/appos/backend/domain/archive"
+)
+
+const TaskArchivePurge = "archive:purge"
+
+func NewArchivePurgeTask() *asynq.Task {
+	return asynq.NewTask(TaskArchivePurge, nil)
+}
+
+func EnqueueArchivePurge(client *asynq.Client) error {
+	if client == nil {
+		return fmt.Errorf("asynq client is not configured")
+	}
+	_, err := client.Enqueue(NewArchivePurgeTask(), asynq.Queue("default"))
+	return err
+}
+
+// handleArchivePurge permanently deletes resource records archived longer
+// than archive.DefaultRetention ago.
+func (w *Worker) handleArchivePurge(_ context.Context, _ *asynq.Task) error {
+	purged, err := archive.Purge(w.app, time.Now().UTC(), archive.DefaultRetention)
+	if purged > 0 {
+		log.Printf("archive purge: deleted %d archived record(s)", purged)
+	}
+	return err
+}
@@ -386,7 +386,8 @@ func (w *Worker) executeNode(ctx context.Context, execCtx *lifecycleExecutionCon
 				appendOperationLog(w.app, execCtx.Operation, line)
 				appendNodeRunLog(w.app, nodeRun, line)
 			},
-			HealthCheck: operationHealthCheck,
+			HealthCheck:   operationHealthCheck,
+			HealthTimeout: lifecycleruntime.DeployHealthTimeout(w.app),
 		},
 	)
 	if err != nil {
@@ -414,6 +415,7 @@ func (w *Worker) finishOperationSucceeded(execCtx *lifecycleExecutionContext) er
 		if err != nil {
 			return w.finishOperationFailed(execCtx, nil, model.NodeDefinition{Key: "release_baseline", DisplayName: "Create Release Baseline", NodeType: "runtime_config", Phase: string(model.PipelinePhaseVerifying)}, err)
 		}
+		w.recordReleaseImageDigests(execCtx, releaseRecord, now)
 	}
 
 	execCtx.Operation.Set("terminal_status", "success")
@@ -563,6 +565,33 @@ func (w *Worker) promoteCandidateRelease(execCtx *lifecycleExecutionContext, now
 	return releaseRecord, nil
 }
 
+// releaseImageDigestsTimeout bounds the compose ps and inspect calls that pin
+// a release's image digests.
+const releaseImageDigestsTimeout = 30 * time.Second
+
+// recordReleaseImageDigests pins the images the project's containers run onto
+// the release, so drift from mutable tags can be detected later. It is best
+// effort: a failure is logged and the operation still succeeds.
+func (w *Worker) recordReleaseImageDigests(execCtx *lifecycleExecutionContext, releaseRecord *core.Record, now time.Time) {
+	if execCtx.docker == nil || releaseRecord == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), releaseImageDigestsTimeout)
+	defer cancel()
+	digests, err := execCtx.docker.ComposeImageDigests(ctx, execCtx.Operation.GetString("project_dir"))
+	if err != nil {
+		appendOperationLog(w.app, execCtx.Operation, "image digests not recorded: "+err.Error())
+		return
+	}
+	releaseRecord.Set("image_digests", digests)
+	releaseRecord.Set("image_digests_at", now)
+	if err := w.app.Save(releaseRecord); err != nil {
+		appendOperationLog(w.app, execCtx.Operation, "image digests not recorded: "+err.Error())
+		return
+	}
+	appendOperationLog(w.app, execCtx.Operation, fmt.Sprintf("image digests recorded for %d container(s)", len(digests)))
+}
+
 func (w *Worker) finishOperationFailed(execCtx *lifecycleExecutionContext, nodeRun *core.Record, node model.NodeDefinition, runErr error) error {
 	now := time.Now()
 	message := strings.TrimSpace(runErr.Error())
@@ -646,7 +675,7 @@ func (w *Worker) finishOperationCancelled(execCtx *lifecycleExecutionContext, no
 	}
 
 	if execCtx.docker != nil {
-		if output, err := execCtx.docker.ComposeDown(context.Background(), execCtx.Operation.GetString("project_dir"), false); err == nil {
+		if output, err := execCtx.docker.ComposeDown(context.Background(), execCtx.Operation.GetString("project_dir"), docker.ComposeDownOptions{}); err == nil {
 			if output != "" {
 				appendOperationLog(w.app, execCtx.Operation, "docker compose down output:\n"+output)
 			}
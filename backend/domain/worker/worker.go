@@ -21,6 +21,7 @@ import (
 	"github.com/websoft9/appos/backend/domain/audit"
 	"github.com/websoft9/appos/backend/domain/deploy"
 	lifecycleruntime "github.com/websoft9/appos/backend/domain/lifecycle/runtime"
+	"github.com/websoft9/appos/backend/infra/docker"
 )
 
 const (
@@ -164,6 +165,7 @@ func (w *Worker) Start() {
 
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(TaskDeployApp, w.handleDeployApp)
+	mux.HandleFunc(TaskArchivePurge, w.handleArchivePurge)
 	mux.HandleFunc(TaskMonitorAppHealthSweep, w.handleMonitorAppHealthSweep)
 	mux.HandleFunc(TaskMonitorCredentialSweep, w.handleMonitorCredentialSweep)
 	mux.HandleFunc(TaskMonitorHeartbeatFreshness, w.handleMonitorHeartbeatFreshness)
@@ -307,7 +309,7 @@ func (w *Worker) handleDeployApp(_ context.Context, t *asynq.Task) error {
 	output, err := client.ComposeUp(ctx, projectDir)
 	if err != nil {
 		appendDeploymentLog(w.app, record, "docker compose up failed: "+err.Error())
-		if cleanupOutput, cleanupErr := client.ComposeDown(context.Background(), projectDir, false); cleanupErr == nil && cleanupOutput != "" {
+		if cleanupOutput, cleanupErr := client.ComposeDown(context.Background(), projectDir, docker.ComposeDownOptions{}); cleanupErr == nil && cleanupOutput != "" {
 			appendDeploymentLog(w.app, record, "cleanup down output:\n"+cleanupOutput)
 		} else if cleanupErr != nil {
 			appendDeploymentLog(w.app, record, "cleanup down failed: "+cleanupErr.Error())
@@ -324,10 +326,15 @@ func (w *Worker) handleDeployApp(_ context.Context, t *asynq.Task) error {
 		return err
 	}
 	appendDeploymentLog(w.app, record, "health check started")
-	healthCtx, healthCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	healthCtx, healthCancel := context.WithTimeout(context.Background(), lifecycleruntime.DeployHealthTimeout(w.app))
 	defer healthCancel()
 	if err := lifecycleruntime.RunDeploymentHealthCheck(healthCtx, client, projectDir); err != nil {
 		appendDeploymentLog(w.app, record, "health check failed: "+err.Error())
+		if errors.Is(err, docker.ErrComposeUnhealthy) {
+			return markDeploymentFailedWithDetail(w.app, record, p, "deployment health check failed: "+err.Error(), map[string]any{
+				"result": deploy.ResultUnhealthy,
+			})
+		}
 		if isDeploymentTimeoutError(err) {
 			return markDeploymentTimedOut(w.app, record, p, "deployment verification timed out")
 		}
@@ -351,11 +358,20 @@ func (w *Worker) handleDeployApp(_ context.Context, t *asynq.Task) error {
 		ResourceID:   record.Id,
 		ResourceName: record.GetString("compose_project_name"),
 		Status:       audit.StatusSuccess,
+		Detail: map[string]any{
+			"result": deploy.ResultHealthy,
+		},
 	})
 	return nil
 }
 
 func markDeploymentFailed(app core.App, record *core.Record, payload DeployAppPayload, message string) error {
+	return markDeploymentFailedWithDetail(app, record, payload, message, nil)
+}
+
+// markDeploymentFailedWithDetail is markDeploymentFailed with extra audit
+// detail fields.
+func markDeploymentFailedWithDetail(app core.App, record *core.Record, payload DeployAppPayload, message string, extra map[string]any) error {
 	current := record.GetString("status")
 	appendDeploymentLog(app, record, "deployment failed: "+message)
 	if current != deploy.StatusFailed {
@@ -373,6 +389,10 @@ func markDeploymentFailed(app core.App, record *core.Record, payload DeployAppPa
 			return err
 		}
 	}
+	detail := map[string]any{"errorMessage": message}
+	for k, v := range extra {
+		detail[k] = v
+	}
 	audit.Write(app, audit.Entry{
 		UserID:       payload.UserID,
 		UserEmail:    payload.UserEmail,
@@ -381,9 +401,7 @@ func markDeploymentFailed(app core.App, record *core.Record, payload DeployAppPa
 		ResourceID:   record.Id,
 		ResourceName: record.GetString("compose_project_name"),
 		Status:       audit.StatusFailed,
-		Detail: map[string]any{
-			"errorMessage": message,
-		},
+		Detail:       detail,
 	})
 	return errors.New(message)
 }
@@ -1,6 +1,8 @@
 // Package crypto provides AES-256-GCM encryption/decryption for secret values.
 //
 // Used by both Resource Store secrets and App-scoped credentials (Epic 8).
+// DeriveKey and the *WithKey variants seal values under a passphrase instead,
+// for documents that move between instances.
 // The encryption key is sourced from the APPOS_ENCRYPTION_KEY environment variable
 // (32-byte hex string). If not set, a deterministic dev-only key is used.
 package crypto
@@ -8,7 +10,9 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/pbkdf2"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -62,15 +66,24 @@ func Encrypt(plaintext string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return EncryptWithKey(plaintext, k)
+}
 
-	block, err := aes.NewCipher(k)
+// Decrypt decrypts hex-encoded AES-256-GCM ciphertext and returns the plaintext.
+func Decrypt(ciphertextHex string) (string, error) {
+	k, err := key()
 	if err != nil {
-		return "", fmt.Errorf("crypto: %w", err)
+		return "", err
 	}
+	return DecryptWithKey(ciphertextHex, k)
+}
 
-	gcm, err := cipher.NewGCM(block)
+// EncryptWithKey is Encrypt under a caller-supplied 32-byte key, e.g. one
+// from DeriveKey.
+func EncryptWithKey(plaintext string, k []byte) (string, error) {
+	gcm, err := newGCM(k)
 	if err != nil {
-		return "", fmt.Errorf("crypto: %w", err)
+		return "", err
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
@@ -82,26 +95,16 @@ func Encrypt(plaintext string) (string, error) {
 	return hex.EncodeToString(sealed), nil
 }
 
-// Decrypt decrypts hex-encoded AES-256-GCM ciphertext and returns the plaintext.
-func Decrypt(ciphertextHex string) (string, error) {
-	k, err := key()
-	if err != nil {
-		return "", err
-	}
-
+// DecryptWithKey is Decrypt under a caller-supplied 32-byte key.
+func DecryptWithKey(ciphertextHex string, k []byte) (string, error) {
 	data, err := hex.DecodeString(ciphertextHex)
 	if err != nil {
 		return "", fmt.Errorf("crypto: invalid hex ciphertext: %w", err)
 	}
 
-	block, err := aes.NewCipher(k)
-	if err != nil {
-		return "", fmt.Errorf("crypto: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(k)
 	if err != nil {
-		return "", fmt.Errorf("crypto: %w", err)
+		return "", err
 	}
 
 	nonceSize := gcm.NonceSize()
@@ -118,6 +121,51 @@ func Decrypt(ciphertextHex string) (string, error) {
 	return string(plaintext), nil
 }
 
+func newGCM(k []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: %w", err)
+	}
+	return gcm, nil
+}
+
+// Passphrase key derivation parameters. Values sealed under a derived key are
+// portable between instances: they do not depend on APPOS_ENCRYPTION_KEY.
+const (
+	// PassphraseIterations is the PBKDF2-HMAC-SHA256 work factor for new keys.
+	PassphraseIterations = 600_000
+	// MaxPassphraseIterations bounds the work factor accepted from input.
+	MaxPassphraseIterations = 10_000_000
+	// SaltSize is the length of a random salt from NewSalt.
+	SaltSize = 16
+)
+
+// ErrWeakKDFParams is returned by DeriveKey for a short salt or an
+// out-of-range iteration count.
+var ErrWeakKDFParams = errors.New("crypto: invalid key derivation parameters")
+
+// NewSalt returns SaltSize random bytes for DeriveKey.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("crypto: %w", err)
+	}
+	return salt, nil
+}
+
+// DeriveKey derives a 32-byte AES key from passphrase with
+// PBKDF2-HMAC-SHA256.
+func DeriveKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	if len(salt) < SaltSize || iterations < 1 || iterations > MaxPassphraseIterations {
+		return nil, ErrWeakKDFParams
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+}
+
 // ResetKey is for testing only — resets the cached key so it can be re-resolved.
 func ResetKey() {
 	keyOnce = sync.Once{}
@@ -133,3 +133,41 @@ func TestInvalidKeyLength(t *testing.T) {
 		t.Error("expected error for invalid key length")
 	}
 }
+
+func TestPassphraseKeyRoundTrip(t *testing.T) {
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.DeriveKey("correct horse", salt, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := crypto.EncryptWithKey("portable secret", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := crypto.DeriveKey("correct horse", salt, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := crypto.DecryptWithKey(sealed, again); err != nil || plain != "portable secret" {
+		t.Fatalf("expected round trip, got %q, %v", plain, err)
+	}
+
+	wrong, err := crypto.DeriveKey("wrong horse", salt, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crypto.DecryptWithKey(sealed, wrong); err == nil {
+		t.Fatal("expected a wrong passphrase to fail decryption")
+	}
+
+	if _, err := crypto.DeriveKey("x", salt[:4], 1000); err != crypto.ErrWeakKDFParams {
+		t.Fatalf("expected ErrWeakKDFParams for a short salt, got %v", err)
+	}
+	if _, err := crypto.DeriveKey("x", salt, crypto.MaxPassphraseIterations+1); err != crypto.ErrWeakKDFParams {
+		t.Fatalf("expected ErrWeakKDFParams for too many iterations, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Package dbprobe tests database connection settings by opening a real
+// connection, authenticating and running a trivial round trip.
+//
+// Each database type has a Prober. The built-in probers use the
+// go-sql-driver/mysql, pgx, go-redis and mongo-driver clients to
+// authenticate, ping and learn the server version, encrypting the connection
+// as Target.TLS asks. Without TLS they refuse authentication that would expose
+// the password: a PostgreSQL cleartext password request, or a MySQL server
+// handing over an unverified RSA key to encrypt the password with. Register
+// replaces or adds a prober.
+package dbprobe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Database types, matching the databases collection type values.
+const (
+	TypeMySQL    = "mysql"
+	TypePostgres = "postgres"
+	TypeRedis    = "redis"
+	TypeMongoDB  = "mongodb"
+)
+
+// TLS modes for Target.TLS, matching the databases collection tls values.
+const (
+	TLSDisable = "disable" // plain TCP; the default
+	TLSRequire = "require" // encrypted, server certificate not checked
+	TLSVerify  = "verify"  // encrypted, certificate checked against the system roots and host name
+)
+
+// Probe outcomes.
+const (
+	StatusOnline     = "online"
+	StatusOffline    = "offline"
+	StatusAuthFailed = "auth_failed"
+	StatusError      = "error"
+)
+
+// DefaultTimeout bounds a probe when the caller passes no timeout.
+const DefaultTimeout = 5 * time.Second
+
+// MaxTimeout is the longest timeout a probe accepts.
+const MaxTimeout = 60 * time.Second
+
+var (
+	// ErrUnreachable marks a failure to open the TCP connection.
+	ErrUnreachable = errors.New("server unreachable")
+	// ErrAuthFailed marks the server rejecting the credentials.
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrInsecureAuth marks a server asking, over a connection without TLS,
+	// for authentication that would expose the password.
+	ErrInsecureAuth = errors.New("server requires an authentication method that would expose the password without TLS; enable TLS for this database")
+)
+
+// Target is where and as whom to connect. Database is optional; its meaning
+// depends on the type (schema, database name, Redis DB index). TLS is one of
+// the TLS modes; empty means TLSDisable.
+type Target struct {
+	Host     string
+	Port     int
+	Database string
+	User     string
+	Password string
+	TLS      string
+}
+
+// Addr returns host:port.
+func (t Target) Addr() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// TLSConfig returns the client TLS configuration for t.TLS, nil when the
+// connection is not encrypted.
+func (t Target) TLSConfig() (*tls.Config, error) {
+	switch t.TLS {
+	case "", TLSDisable:
+		return nil, nil
+	case TLSRequire:
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	case TLSVerify:
+		return &tls.Config{ServerName: t.Host}, nil
+	}
+	return nil, fmt.Errorf("unknown TLS mode %q", t.TLS)
+}
+
+// Info is what a successful probe learned about the server.
+type Info struct {
+	ServerVersion string
+}
+
+// Prober connects to one type of database server. It must honour ctx's
+// deadline, wrap dial failures in ErrUnreachable and credential rejections
+// in ErrAuthFailed.
+type Prober interface {
+	Probe(ctx context.Context, target Target) (Info, error)
+}
+
+// ProberFunc adapts a function to Prober.
+type ProberFunc func(ctx context.Context, target Target) (Info, error)
+
+// Probe calls f.
+func (f ProberFunc) Probe(ctx context.Context, target Target) (Info, error) {
+	return f(ctx, target)
+}
+
+var (
+	mu      sync.RWMutex
+	probers = map[string]Prober{
+		TypeMySQL:    ProberFunc(probeMySQL),
+		TypePostgres: ProberFunc(probePostgres),
+		TypeRedis:    ProberFunc(probeRedis),
+		TypeMongoDB:  ProberFunc(probeMongoDB),
+	}
+	defaultPorts = map[string]int{
+		TypeMySQL:    3306,
+		TypePostgres: 5432,
+		TypeRedis:    6379,
+		TypeMongoDB:  27017,
+	}
+)
+
+// Register sets the prober for dbType.
+func Register(dbType string, p Prober) {
+	mu.Lock()
+	defer mu.Unlock()
+	probers[dbType] = p
+}
+
+func lookup(dbType string) (Prober, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := probers[dbType]
+	return p, ok
+}
+
+// DefaultPort returns the usual port for dbType, or 0 when unknown.
+func DefaultPort(dbType string) int {
+	return defaultPorts[dbType]
+}
+
+// Result is the outcome of a connection test. It never carries the password.
+type Result struct {
+	Status        string `json:"status"`
+	LatencyMS     int64  `json:"latency_ms"`
+	ServerVersion string `json:"server_version,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// Probe tests target with the prober for dbType, giving up after timeout
+// (DefaultTimeout when zero, capped at MaxTimeout). A server that cannot be
+// reached is reported as StatusOffline rather than as an error.
+func Probe(ctx context.Context, dbType string, target Target, timeout time.Duration) Result {
+	p, ok := lookup(dbType)
+	if !ok {
+		return Result{Status: StatusError, Reason: fmt.Sprintf("connection test is not supported for type %q", dbType)}
+	}
+	target.Host = strings.TrimSpace(target.Host)
+	if target.Host == "" {
+		return Result{Status: StatusError, Reason: "host is required"}
+	}
+	if target.Port == 0 {
+		target.Port = DefaultPort(dbType)
+	}
+	if _, err := target.TLSConfig(); err != nil {
+		return Result{Status: StatusError, Reason: err.Error()}
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	timeout = min(timeout, MaxTimeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	info, err := p.Probe(ctx, target)
+	result := Result{LatencyMS: time.Since(start).Milliseconds()}
+	switch {
+	case err == nil:
+		result.Status = StatusOnline
+		result.ServerVersion = info.ServerVersion
+		return result
+	case errors.Is(err, ErrUnreachable):
+		result.Status = StatusOffline
+	case errors.Is(err, ErrAuthFailed):
+		result.Status = StatusAuthFailed
+	case errors.Is(err, context.DeadlineExceeded) || isTimeout(err):
+		result.Status = StatusError
+		err = fmt.Errorf("no response from server within %s", timeout)
+	default:
+		result.Status = StatusError
+	}
+	result.Reason = err.Error()
+	if target.Password != "" {
+		result.Reason = strings.ReplaceAll(result.Reason, target.Password, "[redacted]")
+	}
+	return result
+}
+
+// dial opens the TCP connection for target and applies ctx's deadline to it.
+func dial(ctx context.Context, target Target) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", target.Addr())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
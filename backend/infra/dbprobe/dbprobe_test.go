@@ -0,0 +1,526 @@
+package dbprobe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// serve accepts connections on a loopback listener, hands each to handle and
+// returns a Target pointing at the listener.
+func serve(t *testing.T, handle func(conn net.Conn)) Target {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveListener(t, ln, handle)
+}
+
+func serveListener(t *testing.T, ln net.Listener, handle func(conn net.Conn)) Target {
+	t.Helper()
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				handle(conn)
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return Target{Host: "127.0.0.1", Port: addr.Port}
+}
+
+func TestProbeReportsOfflineWhenUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	for _, dbType := range []string{TypeMySQL, TypePostgres, TypeRedis, TypeMongoDB} {
+		result := Probe(context.Background(), dbType, Target{Host: "127.0.0.1", Port: port}, time.Second)
+		if result.Status != StatusOffline || result.Reason == "" {
+			t.Fatalf("%s: expected offline with a reason, got %+v", dbType, result)
+		}
+	}
+	if result := Probe(context.Background(), "oracle", Target{Host: "127.0.0.1"}, 0); result.Status != StatusError {
+		t.Fatalf("expected unsupported type to be an error, got %+v", result)
+	}
+	if result := Probe(context.Background(), TypeMySQL, Target{}, 0); result.Status != StatusError {
+		t.Fatalf("expected missing host to be an error, got %+v", result)
+	}
+}
+
+func TestProbePostgresMD5(t *testing.T) {
+	fake := func(password string) func(net.Conn) {
+		return func(conn net.Conn) {
+			r := bufio.NewReader(conn)
+			user := readPGStartup(r)["user"]
+
+			salt := []byte{1, 2, 3, 4}
+			writePG(conn, 'R', append(binary.BigEndian.AppendUint32(nil, 5), salt...))
+			kind, body := readPG(r)
+			inner := md5.Sum([]byte(password + user))
+			outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+			if kind != 'p' || string(body) != "md5"+hex.EncodeToString(outer[:])+"\x00" {
+				writePG(conn, 'E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed for user \""+user+"\"\x00\x00"))
+				return
+			}
+			writePG(conn, 'R', binary.BigEndian.AppendUint32(nil, 0))
+			writePG(conn, 'S', []byte("server_version\x0016.2\x00"))
+			writePG(conn, 'Z', []byte("I"))
+			if kind, body := readPG(r); kind != 'Q' || string(body) != "SELECT 1\x00" {
+				return
+			}
+			writePG(conn, 'C', []byte("SELECT 1\x00"))
+			writePG(conn, 'Z', []byte("I"))
+		}
+	}
+
+	target := serve(t, fake("s3cret"))
+	target.User, target.Password = "app", "s3cret"
+	result := Probe(context.Background(), TypePostgres, target, time.Second)
+	if result.Status != StatusOnline || result.ServerVersion != "16.2" {
+		t.Fatalf("expected online 16.2, got %+v", result)
+	}
+
+	target = serve(t, fake("s3cret"))
+	target.User, target.Password = "app", "wrong"
+	result = Probe(context.Background(), TypePostgres, target, time.Second)
+	if result.Status != StatusAuthFailed || !strings.Contains(result.Reason, "password authentication failed") {
+		t.Fatalf("expected auth_failed, got %+v", result)
+	}
+}
+
+func TestProbePostgresRefusesCleartextPasswordWithoutTLS(t *testing.T) {
+	next := make(chan byte, 1)
+	target := serve(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		readPGStartup(r)
+		writePG(conn, 'R', binary.BigEndian.AppendUint32(nil, 3)) // AuthenticationCleartextPassword
+		kind, _ := readPG(r)
+		next <- kind
+	})
+	target.User, target.Password = "app", "s3cret"
+
+	result := Probe(context.Background(), TypePostgres, target, time.Second)
+	if result.Status != StatusError || !strings.Contains(result.Reason, "enable TLS") {
+		t.Fatalf("expected an insecure authentication error, got %+v", result)
+	}
+	if kind := <-next; kind == 'p' {
+		t.Fatal("the password was sent in cleartext")
+	}
+}
+
+// readPGStartup reads a startup message and returns its parameters.
+func readPGStartup(r io.Reader) map[string]string {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil || size < 8 {
+		return nil
+	}
+	startup := make([]byte, size-4)
+	_, _ = io.ReadFull(r, startup)
+	fields := strings.Split(strings.TrimRight(string(startup[4:]), "\x00"), "\x00")
+	params := map[string]string{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		params[fields[i]] = fields[i+1]
+	}
+	return params
+}
+
+func writePG(w io.Writer, kind byte, payload []byte) {
+	msg := append([]byte{kind}, binary.BigEndian.AppendUint32(nil, uint32(len(payload)+4))...)
+	_, _ = w.Write(append(msg, payload...))
+}
+
+func readPG(r io.Reader) (byte, []byte) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	_, _ = io.ReadFull(r, body)
+	return header[0], body
+}
+
+// MySQL protocol values the fake servers use.
+const (
+	testMySQLCapabilities = 0x00000200 | 0x00008000 | 0x00080000 // protocol 41, secure connection, plugin auth
+	testMySQLCharset      = 45
+)
+
+func TestProbeMySQLNativePassword(t *testing.T) {
+	scramble := []byte("abcdefghijklmnopqrst")
+	fake := func(password string) func(net.Conn) {
+		return func(conn net.Conn) {
+			r := bufio.NewReader(conn)
+			writeMySQL(conn, 0, mysqlGreeting(scramble, "mysql_native_password"))
+
+			seq, response := readMySQL(r)
+			if len(response) < 32 {
+				return
+			}
+			rest := response[32:]
+			user := string(rest[:bytes.IndexByte(rest, 0)])
+			rest = rest[len(user)+1:]
+			authData := rest[1 : 1+int(rest[0])]
+			if user != "app" || !bytes.Equal(authData, mysqlNativeAuth(password, scramble)) {
+				writeMySQL(conn, seq+1, append([]byte{0xff, 0x15, 0x04}, "#28000Access denied for user 'app'"...))
+				return
+			}
+			writeMySQL(conn, seq+1, []byte{0x00, 0, 0, 2, 0, 0, 0})
+			serveMySQLCommands(conn, r, "8.0.36")
+		}
+	}
+
+	target := serve(t, fake("s3cret"))
+	target.User, target.Password = "app", "s3cret"
+	result := Probe(context.Background(), TypeMySQL, target, time.Second)
+	if result.Status != StatusOnline || result.ServerVersion != "8.0.36" {
+		t.Fatalf("expected online 8.0.36, got %+v", result)
+	}
+
+	target = serve(t, fake("s3cret"))
+	target.User, target.Password = "app", "wrong"
+	result = Probe(context.Background(), TypeMySQL, target, time.Second)
+	if result.Status != StatusAuthFailed || !strings.Contains(result.Reason, "Access denied") {
+		t.Fatalf("expected auth_failed, got %+v", result)
+	}
+}
+
+func TestProbeMySQLRefusesUnverifiedPublicKeyWithoutTLS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	scramble := []byte("abcdefghijklmnopqrst")
+
+	next := make(chan []byte, 1)
+	target := serve(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		writeMySQL(conn, 0, mysqlGreeting(scramble, "caching_sha2_password"))
+		seq, _ := readMySQL(r)
+		writeMySQL(conn, seq+1, []byte{0x01, 0x04}) // perform full authentication
+		seq, request := readMySQL(r)
+		if !bytes.Equal(request, []byte{0x02}) { // request public key
+			next <- request
+			return
+		}
+		writeMySQL(conn, seq+1, append([]byte{0x01}, publicKey...))
+		_, password := readMySQL(r)
+		next <- password
+	})
+	target.User, target.Password = "app", "s3cret"
+
+	result := Probe(context.Background(), TypeMySQL, target, time.Second)
+	if result.Status != StatusError || !strings.Contains(result.Reason, "enable TLS") {
+		t.Fatalf("expected an insecure authentication error, got %+v", result)
+	}
+	if password := <-next; len(password) > 0 {
+		t.Fatalf("the password was sent encrypted to an unverified key (%d bytes)", len(password))
+	}
+}
+
+// mysqlGreeting is a protocol 10 handshake offering plugin.
+func mysqlGreeting(scramble []byte, plugin string) []byte {
+	var greeting bytes.Buffer
+	greeting.WriteByte(10)
+	greeting.WriteString("8.0.36\x00")
+	greeting.Write([]byte{1, 0, 0, 0})
+	greeting.Write(scramble[:8])
+	greeting.WriteByte(0)
+	_ = binary.Write(&greeting, binary.LittleEndian, uint16(testMySQLCapabilities&0xffff))
+	greeting.WriteByte(testMySQLCharset)
+	greeting.Write([]byte{2, 0})
+	_ = binary.Write(&greeting, binary.LittleEndian, uint16(testMySQLCapabilities>>16))
+	greeting.WriteByte(21)
+	greeting.Write(make([]byte, 10))
+	greeting.Write(scramble[8:])
+	greeting.WriteByte(0)
+	greeting.WriteString(plugin + "\x00")
+	return greeting.Bytes()
+}
+
+// mysqlNativeAuth is the mysql_native_password response to scramble:
+// SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password))).
+func mysqlNativeAuth(password string, scramble []byte) []byte {
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+	hash := sha1.Sum(append(bytes.Clone(scramble), stage2[:]...))
+	for i := range hash {
+		hash[i] ^= stage1[i]
+	}
+	return hash[:]
+}
+
+// serveMySQLCommands answers COM_PING and SELECT VERSION() until the client
+// quits.
+func serveMySQLCommands(conn net.Conn, r io.Reader, version string) {
+	ok := []byte{0x00, 0, 0, 2, 0, 0, 0}
+	eof := []byte{0xfe, 0, 0, 2, 0}
+	var column bytes.Buffer
+	for _, field := range []string{"def", "", "", "", "VERSION()", ""} {
+		column.WriteByte(byte(len(field)))
+		column.WriteString(field)
+	}
+	column.Write([]byte{0x0c, testMySQLCharset, 0, 0, 1, 0, 0, 0xfd, 0, 0, 0x1f, 0, 0})
+	for {
+		_, command := readMySQL(r)
+		if len(command) == 0 {
+			return
+		}
+		switch command[0] {
+		case 0x0e: // COM_PING
+			writeMySQL(conn, 1, ok)
+		case 0x03: // COM_QUERY
+			writeMySQL(conn, 1, []byte{1})
+			writeMySQL(conn, 2, column.Bytes())
+			writeMySQL(conn, 3, eof)
+			writeMySQL(conn, 4, append([]byte{byte(len(version))}, version...))
+			writeMySQL(conn, 5, eof)
+		default:
+			return
+		}
+	}
+}
+
+func writeMySQL(w io.Writer, seq byte, payload []byte) {
+	size := len(payload)
+	_, _ = w.Write(append([]byte{byte(size), byte(size >> 8), byte(size >> 16), seq}, payload...))
+}
+
+func readMySQL(r io.Reader) (byte, []byte) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil
+	}
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, _ = io.ReadFull(r, payload)
+	return header[3], payload
+}
+
+func TestProbeRedis(t *testing.T) {
+	target := serve(t, fakeRedis("s3cret"))
+	target.Password = "s3cret"
+	result := Probe(context.Background(), TypeRedis, target, time.Second)
+	if result.Status != StatusOnline || result.ServerVersion != "7.2.4" {
+		t.Fatalf("expected online 7.2.4, got %+v", result)
+	}
+
+	target = serve(t, fakeRedis("s3cret"))
+	target.Password = "wrong"
+	result = Probe(context.Background(), TypeRedis, target, time.Second)
+	if result.Status != StatusAuthFailed {
+		t.Fatalf("expected auth_failed, got %+v", result)
+	}
+}
+
+func TestProbeTLSModes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "db.test"},
+		DNSNames:     []string{"db.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := serveListener(t, ln, fakeRedis("s3cret"))
+	target.Password = "s3cret"
+
+	target.TLS = TLSRequire
+	if result := Probe(context.Background(), TypeRedis, target, time.Second); result.Status != StatusOnline {
+		t.Fatalf("require: expected online over TLS, got %+v", result)
+	}
+	target.TLS = TLSVerify
+	if result := Probe(context.Background(), TypeRedis, target, time.Second); result.Status != StatusError || !strings.Contains(result.Reason, "certificate") {
+		t.Fatalf("verify: expected the self-signed certificate to be rejected, got %+v", result)
+	}
+	target.TLS = "sometimes"
+	if result := Probe(context.Background(), TypeRedis, target, time.Second); result.Status != StatusError || !strings.Contains(result.Reason, "unknown TLS mode") {
+		t.Fatalf("expected an unknown TLS mode to be an error, got %+v", result)
+	}
+}
+
+// fakeRedis answers AUTH, PING and INFO, accepting only password.
+func fakeRedis(password string) func(net.Conn) {
+	return func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for {
+			args := readRESP(r)
+			if args == nil {
+				return
+			}
+			switch strings.ToUpper(args[0]) {
+			case "AUTH":
+				if args[len(args)-1] != password {
+					_, _ = io.WriteString(conn, "-WRONGPASS invalid username-password pair or user is disabled.\r\n")
+					return
+				}
+				_, _ = io.WriteString(conn, "+OK\r\n")
+			case "PING":
+				_, _ = io.WriteString(conn, "+PONG\r\n")
+			case "INFO":
+				info := "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n"
+				_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(info), info)
+			default:
+				_, _ = fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+			}
+		}
+	}
+}
+
+func readRESP(r *bufio.Reader) []string {
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "*") {
+		return nil
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, count)
+	for range count {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args
+}
+
+func TestProbeMongoDBWithoutAuth(t *testing.T) {
+	const (
+		opReply = 1
+		opQuery = 2004
+		opMsg   = 2013
+	)
+	hello := bson.D{
+		{Key: "isWritablePrimary", Value: true},
+		{Key: "ismaster", Value: true},
+		{Key: "helloOk", Value: true},
+		{Key: "maxBsonObjectSize", Value: 16 << 20},
+		{Key: "maxMessageSizeBytes", Value: 48000000},
+		{Key: "maxWriteBatchSize", Value: 100000},
+		{Key: "minWireVersion", Value: 0},
+		{Key: "maxWireVersion", Value: 21},
+		{Key: "ok", Value: 1},
+	}
+	target := serve(t, func(conn net.Conn) {
+		for {
+			var header [16]byte
+			if _, err := io.ReadFull(conn, header[:]); err != nil {
+				return
+			}
+			body := make([]byte, binary.LittleEndian.Uint32(header[:])-16)
+			if _, err := io.ReadFull(conn, body); err != nil {
+				return
+			}
+			opCode := binary.LittleEndian.Uint32(header[12:])
+			var command bson.Raw
+			switch opCode {
+			case opQuery: // the legacy hello: flags, collection, skip and limit precede the query
+				rest := body[4:]
+				command = bson.Raw(rest[bytes.IndexByte(rest, 0)+1+8:])
+			case opMsg: // flags, then a kind 0 section
+				command = bson.Raw(body[5:])
+			default:
+				return
+			}
+			first, err := command.IndexErr(0)
+			if err != nil {
+				return
+			}
+			reply := bson.D{{Key: "ok", Value: 1}}
+			switch first.Key() {
+			case "isMaster", "ismaster", "hello":
+				reply = hello
+			case "buildInfo":
+				reply = bson.D{{Key: "version", Value: "7.0.5"}, {Key: "ok", Value: 1}}
+			}
+			doc, _ := bson.Marshal(reply)
+
+			msg := make([]byte, 16)
+			binary.LittleEndian.PutUint32(msg[8:], binary.LittleEndian.Uint32(header[4:]))
+			if opCode == opQuery {
+				binary.LittleEndian.PutUint32(msg[12:], opReply)
+				msg = append(msg, make([]byte, 16)...) // flags, cursor id, starting from
+				msg = binary.LittleEndian.AppendUint32(msg, 1)
+			} else {
+				binary.LittleEndian.PutUint32(msg[12:], opMsg)
+				msg = append(msg, 0, 0, 0, 0, 0)
+			}
+			msg = append(msg, doc...)
+			binary.LittleEndian.PutUint32(msg, uint32(len(msg)))
+			if _, err := conn.Write(msg); err != nil {
+				return
+			}
+		}
+	})
+
+	result := Probe(context.Background(), TypeMongoDB, target, time.Second)
+	if result.Status != StatusOnline || result.ServerVersion != "7.0.5" {
+		t.Fatalf("expected online 7.0.5, got %+v", result)
+	}
+}
+
+func TestProbeRedactsPassword(t *testing.T) {
+	Register("echo", ProberFunc(func(context.Context, Target) (Info, error) {
+		return Info{}, fmt.Errorf("bad login with %s", "hunter2")
+	}))
+
+	result := Probe(context.Background(), "echo", Target{Host: "db", Port: 1, Password: "hunter2"}, 0)
+	if result.Status != StatusError || strings.Contains(result.Reason, "hunter2") {
+		t.Fatalf("expected a redacted error, got %+v", result)
+	}
+}
@@ -0,0 +1,104 @@
+package dbprobe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	mongoCodeAuthFailed = 18
+	mongoDefaultAuthDB  = "admin"
+)
+
+// probeMongoDB connects directly to the server with mongo-driver, which
+// authenticates with SCRAM when a user is set, then runs ping and buildInfo.
+// Like a connection string, Target.Database names the authentication
+// database and defaults to admin.
+func probeMongoDB(ctx context.Context, target Target) (Info, error) {
+	tlsConfig, err := target.TLSConfig()
+	if err != nil {
+		return Info{}, err
+	}
+	timeout := DefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	dialer := &mongoDialer{target: target}
+	opts := options.Client().
+		SetHosts([]string{target.Addr()}).
+		SetDirect(true).
+		SetAppName("appos").
+		SetDialer(dialer).
+		SetTLSConfig(tlsConfig).
+		SetConnectTimeout(timeout).
+		SetServerSelectionTimeout(timeout).
+		SetMaxPoolSize(1)
+	if target.User != "" {
+		authDB := target.Database
+		if authDB == "" {
+			authDB = mongoDefaultAuthDB
+		}
+		opts.SetAuth(options.Credential{Username: target.User, Password: target.Password, AuthSource: authDB})
+	}
+	client, err := mongo.Connect(opts)
+	if err != nil {
+		return Info{}, err
+	}
+	defer client.Disconnect(ctx)
+
+	admin := client.Database("admin")
+	if err := admin.RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Err(); err != nil {
+		return Info{}, dialer.mongoError(err)
+	}
+	var buildInfo struct {
+		Version string `bson:"version"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo); err != nil {
+		return Info{}, dialer.mongoError(err)
+	}
+	return Info{ServerVersion: buildInfo.Version}, nil
+}
+
+// mongoDialer dials through dial and keeps the first failure: the driver
+// only reports an unreachable server as a server selection timeout.
+type mongoDialer struct {
+	target Target
+	mu     sync.Mutex
+	err    error
+}
+
+func (d *mongoDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	conn, err := dial(ctx, d.target)
+	if err != nil {
+		d.mu.Lock()
+		if d.err == nil {
+			d.err = err
+		}
+		d.mu.Unlock()
+	}
+	return conn, err
+}
+
+// mongoError reports a failed dial as ErrUnreachable and error code 18 as
+// ErrAuthFailed.
+func (d *mongoDialer) mongoError(err error) error {
+	d.mu.Lock()
+	dialErr := d.err
+	d.mu.Unlock()
+	if dialErr != nil {
+		return dialErr
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == mongoCodeAuthFailed {
+		return fmt.Errorf("%w: %s", ErrAuthFailed, cmdErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package dbprobe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrAccessDenied   = 1045
+	mysqlErrDBAccessDenied = 1044
+	mysqlPacketOK          = 0x00
+	mysqlPacketError       = 0xff
+)
+
+// mysqlPublicKeyReply starts an AuthMoreData packet carrying a PEM public key.
+var mysqlPublicKeyReply = []byte("\x01-----BEGIN")
+
+// probeMySQL connects with go-sql-driver/mysql, pings and reads VERSION().
+// The driver never sends a cleartext password; without TLS the connection is
+// wrapped in mysqlPlainAuthConn so the password is not RSA-encrypted to a key
+// the server sent unauthenticated either.
+func probeMySQL(ctx context.Context, target Target) (Info, error) {
+	tlsConfig, err := target.TLSConfig()
+	if err != nil {
+		return Info{}, err
+	}
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = target.Addr()
+	config.User = target.User
+	config.Passwd = target.Password
+	config.DBName = target.Database
+	config.TLS = tlsConfig
+	config.Logger = &mysql.NopLogger{}
+	var refused atomic.Bool
+	config.DialFunc = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		conn, err := dial(ctx, target)
+		if err != nil || tlsConfig != nil {
+			return conn, err
+		}
+		return &mysqlPlainAuthConn{Conn: conn, r: bufio.NewReader(conn), refused: &refused}, nil
+	}
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return Info{}, err
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		if refused.Load() {
+			return Info{}, ErrInsecureAuth
+		}
+		return Info{}, mysqlError(err)
+	}
+	info := Info{}
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&info.ServerVersion); err != nil {
+		return info, mysqlError(err)
+	}
+	return info, nil
+}
+
+// mysqlPlainAuthConn reads the server's packets one at a time until the
+// handshake ends with OK or ERR. caching_sha2_password full authentication
+// and sha256_password have the server send its RSA public key over the plain
+// connection; the driver would encrypt the password to whatever key arrives,
+// so reading that packet fails and sets refused instead. The driver reports
+// any read failure as an invalid connection, hence the flag.
+type mysqlPlainAuthConn struct {
+	net.Conn
+	r       *bufio.Reader
+	refused *atomic.Bool
+	pending []byte
+	authed  bool
+}
+
+func (c *mysqlPlainAuthConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		if c.authed {
+			return c.r.Read(p)
+		}
+		var header [4]byte
+		if _, err := io.ReadFull(c.r, header[:]); err != nil {
+			return 0, err
+		}
+		size := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+		packet := make([]byte, len(header)+size)
+		copy(packet, header[:])
+		if _, err := io.ReadFull(c.r, packet[len(header):]); err != nil {
+			return 0, err
+		}
+		payload := packet[len(header):]
+		switch {
+		case bytes.HasPrefix(payload, []byte{mysqlPacketOK}) || bytes.HasPrefix(payload, []byte{mysqlPacketError}):
+			c.authed = true
+		case bytes.HasPrefix(payload, mysqlPublicKeyReply):
+			c.refused.Store(true)
+			return 0, ErrInsecureAuth
+		}
+		c.pending = packet
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// mysqlError reports access denied errors as ErrAuthFailed.
+func mysqlError(err error) error {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && (myErr.Number == mysqlErrAccessDenied || myErr.Number == mysqlErrDBAccessDenied) {
+		return fmt.Errorf("%w: %s", ErrAuthFailed, myErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package dbprobe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// probePostgres connects with pgx, then runs SELECT 1. Without TLS the
+// cleartext password method is refused. The server version comes from the
+// server_version parameter status.
+func probePostgres(ctx context.Context, target Target) (Info, error) {
+	tlsConfig, err := target.TLSConfig()
+	if err != nil {
+		return Info{}, err
+	}
+	// An empty connection string still reads the PG* environment; every
+	// setting it could supply is overwritten below.
+	config, err := pgconn.ParseConfig("")
+	if err != nil {
+		return Info{}, err
+	}
+	config.Host = target.Host
+	config.Port = uint16(target.Port)
+	config.User = target.User
+	config.Password = target.Password
+	config.Database = target.Database
+	config.TLSConfig = tlsConfig
+	config.Fallbacks = nil
+	config.ConnectTimeout = 0
+	config.RuntimeParams = map[string]string{"application_name": "appos"}
+	config.DialFunc = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dial(ctx, target)
+	}
+	config.RequireAuth = ""
+	if tlsConfig == nil {
+		config.RequireAuth = "!password"
+	}
+
+	conn, err := pgconn.ConnectConfig(ctx, config)
+	if err != nil {
+		return Info{}, postgresError(err, tlsConfig == nil)
+	}
+	defer conn.Close(ctx)
+
+	info := Info{ServerVersion: conn.ParameterStatus("server_version")}
+	if _, err := conn.Exec(ctx, "SELECT 1").ReadAll(); err != nil {
+		return info, postgresError(err, false)
+	}
+	return info, nil
+}
+
+// postgresError reports SQLSTATE class 28 (invalid authorization) as
+// ErrAuthFailed and, on a plain connection, the refused cleartext password
+// request as ErrInsecureAuth.
+func postgresError(err error, plain bool) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "28") {
+		return fmt.Errorf("%w: %s", ErrAuthFailed, pgErr.Message)
+	}
+	// pgx has no error type for a require_auth refusal.
+	if plain && strings.Contains(err.Error(), "require_auth") {
+		return fmt.Errorf("%w: %v", ErrInsecureAuth, err)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package dbprobe
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// probeRedis authenticates, selects Target.Database as the DB index when
+// set, and sends PING. The server version comes from INFO server.
+func probeRedis(ctx context.Context, target Target) (Info, error) {
+	tlsConfig, err := target.TLSConfig()
+	if err != nil {
+		return Info{}, err
+	}
+	db := 0
+	if target.Database != "" {
+		index, err := strconv.Atoi(target.Database)
+		if err != nil || index < 0 {
+			return Info{}, fmt.Errorf("redis: database must be a DB index, got %q", target.Database)
+		}
+		db = index
+	}
+	timeout := DefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     target.Addr(),
+		Username: target.User,
+		Password: target.Password,
+		DB:       db,
+		Protocol: 2,
+		// go-redis leaves TLS to a custom dialer.
+		Dialer: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			conn, err := dial(ctx, target)
+			if err != nil || tlsConfig == nil {
+				return conn, err
+			}
+			tlsConn := tls.Client(conn, tlsConfig)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		},
+		DialTimeout:     timeout,
+		ReadTimeout:     timeout,
+		WriteTimeout:    timeout,
+		MaxRetries:      -1,
+		PoolSize:        1,
+		DisableIdentity: true,
+	})
+	defer client.Close()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return Info{}, redisError(err)
+	}
+	info := Info{}
+	text, err := client.Info(ctx, "server").Result()
+	if err != nil {
+		return info, redisError(err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "redis_version:"); ok {
+			info.ServerVersion = version
+		}
+	}
+	return info, nil
+}
+
+// redisError reports credential rejections as ErrAuthFailed.
+func redisError(err error) error {
+	message := err.Error()
+	upper := strings.ToUpper(message)
+	if strings.HasPrefix(upper, "WRONGPASS") || strings.HasPrefix(upper, "NOAUTH") ||
+		strings.Contains(upper, "INVALID PASSWORD") || strings.Contains(upper, "INVALID USERNAME-PASSWORD") ||
+		strings.Contains(upper, "WITHOUT ANY PASSWORD CONFIGURED") {
+		return fmt.Errorf("%w: %s", ErrAuthFailed, message)
+	}
+	return err
+}
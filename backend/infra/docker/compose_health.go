@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrComposeUnhealthy is returned when a compose project's healthchecked
+// services did not all report healthy before the deadline.
+var ErrComposeUnhealthy = errors.New("compose services not healthy")
+
+// ComposeRunner runs "docker <args...>". *Client satisfies it.
+type ComposeRunner interface {
+	Exec(ctx context.Context, args ...string) (string, error)
+}
+
+// ComposeService is one container row of `docker compose ps --format json`.
+type ComposeService struct {
+	Service string `json:"Service"`
+	Name    string `json:"Name"`
+	State   string `json:"State"`
+	Health  string `json:"Health"`
+}
+
+// ComposeHealth groups a project's services by healthcheck status.
+// Services without a healthcheck are Unchecked and never block the gate.
+type ComposeHealth struct {
+	Healthy   []string `json:"healthy"`
+	Starting  []string `json:"starting"`
+	Unhealthy []string `json:"unhealthy"`
+	Unchecked []string `json:"unchecked"`
+}
+
+// OK reports whether every service with a healthcheck is healthy.
+func (h ComposeHealth) OK() bool {
+	return len(h.Starting) == 0 && len(h.Unhealthy) == 0
+}
+
+func (h ComposeHealth) String() string {
+	parts := make([]string, 0, 2)
+	if len(h.Unhealthy) > 0 {
+		parts = append(parts, "unhealthy: "+strings.Join(h.Unhealthy, ", "))
+	}
+	if len(h.Starting) > 0 {
+		parts = append(parts, "starting: "+strings.Join(h.Starting, ", "))
+	}
+	if len(parts) == 0 {
+		return "all healthchecks passing"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ComposePs lists every container of the compose project, stopped ones
+// included.
+func (c *Client) ComposePs(ctx context.Context, projectDir string) ([]ComposeService, error) {
+	output, err := c.exec.Run(ctx, "docker", "compose", "-f", c.composeFile(projectDir), "ps", "--all", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	return ParseComposePs(output)
+}
+
+// ParseComposePs decodes `docker compose ps --format json` output. Compose
+// before 2.21 prints one JSON array, later versions one object per line.
+func ParseComposePs(output string) ([]ComposeService, error) {
+	return parseJSONRows[ComposeService](output, "compose ps")
+}
+
+// EvaluateComposeHealth classifies services by their Health column.
+func EvaluateComposeHealth(services []ComposeService) ComposeHealth {
+	var h ComposeHealth
+	for _, svc := range services {
+		name := svc.Service
+		if name == "" {
+			name = svc.Name
+		}
+		switch strings.ToLower(strings.TrimSpace(svc.Health)) {
+		case "healthy":
+			h.Healthy = append(h.Healthy, name)
+		case "unhealthy":
+			h.Unhealthy = append(h.Unhealthy, name)
+		case "starting":
+			h.Starting = append(h.Starting, name)
+		default:
+			h.Unchecked = append(h.Unchecked, name)
+		}
+	}
+	return h
+}
+
+// WaitComposeHealthy polls the project's containers every interval until
+// all healthchecked services are healthy or ctx ends. An unhealthy service
+// may still recover, so polling continues until the deadline. When the
+// deadline passes with services not healthy the error wraps
+// ErrComposeUnhealthy; if compose ps never succeeded it wraps the last
+// command error instead.
+func WaitComposeHealthy(ctx context.Context, runner ComposeRunner, projectDir string, interval time.Duration) (ComposeHealth, error) {
+	composeFile := projectDir + "/docker-compose.yml"
+	var (
+		last    ComposeHealth
+		checked bool
+		lastErr error
+	)
+	for {
+		output, err := runner.Exec(ctx, "compose", "-f", composeFile, "ps", "--all", "--format", "json")
+		if err == nil {
+			var services []ComposeService
+			services, err = ParseComposePs(output)
+			if err == nil {
+				last, checked = EvaluateComposeHealth(services), true
+				if last.OK() {
+					return last, nil
+				}
+			}
+		}
+		if err != nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if checked {
+				return last, fmt.Errorf("%w (%s)", ErrComposeUnhealthy, last)
+			}
+			if lastErr != nil {
+				return last, fmt.Errorf("compose ps: %w", lastErr)
+			}
+			return last, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
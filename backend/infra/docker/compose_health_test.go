@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type scriptedRunner struct {
+	outputs []string
+	calls   int
+}
+
+func (r *scriptedRunner) Exec(ctx context.Context, args ...string) (string, error) {
+	out := r.outputs[min(r.calls, len(r.outputs)-1)]
+	r.calls++
+	return out, nil
+}
+
+func TestParseComposePsAcceptsArrayAndLines(t *testing.T) {
+	array := `[{"Service":"web","State":"running","Health":"healthy"},{"Service":"db","State":"running","Health":""}]`
+	lines := "{\"Service\":\"web\",\"State\":\"running\",\"Health\":\"healthy\"}\n{\"Service\":\"db\",\"State\":\"running\",\"Health\":\"\"}\n"
+	for name, output := range map[string]string{"array": array, "lines": lines} {
+		services, err := ParseComposePs(output)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(services) != 2 || services[0].Service != "web" || services[0].Health != "healthy" {
+			t.Fatalf("%s: unexpected services %+v", name, services)
+		}
+	}
+}
+
+func TestEvaluateComposeHealthIgnoresServicesWithoutHealthcheck(t *testing.T) {
+	health := EvaluateComposeHealth([]ComposeService{
+		{Service: "web", Health: "healthy"},
+		{Service: "worker", Health: ""},
+		{Service: "db", Health: "starting"},
+	})
+	if health.OK() {
+		t.Fatal("expected starting service to block the gate")
+	}
+	if len(health.Unchecked) != 1 || health.Unchecked[0] != "worker" {
+		t.Fatalf("unexpected unchecked list %v", health.Unchecked)
+	}
+	if !EvaluateComposeHealth([]ComposeService{{Service: "worker"}}).OK() {
+		t.Fatal("expected a project without healthchecks to pass")
+	}
+}
+
+func TestWaitComposeHealthy(t *testing.T) {
+	runner := &scriptedRunner{outputs: []string{
+		`{"Service":"web","State":"running","Health":"starting"}`,
+		`{"Service":"web","State":"running","Health":"healthy"}`,
+	}}
+	health, err := WaitComposeHealthy(context.Background(), runner, "/tmp/app", time.Millisecond)
+	if err != nil || !health.OK() || runner.calls != 2 {
+		t.Fatalf("expected healthy after second poll, got %+v calls=%d err=%v", health, runner.calls, err)
+	}
+
+	runner = &scriptedRunner{outputs: []string{`{"Service":"web","State":"running","Health":"unhealthy"}`}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	health, err = WaitComposeHealthy(ctx, runner, "/tmp/app", time.Millisecond)
+	if !errors.Is(err, ErrComposeUnhealthy) {
+		t.Fatalf("expected ErrComposeUnhealthy, got %v", err)
+	}
+	if len(health.Unhealthy) != 1 || health.Unhealthy[0] != "web" {
+		t.Fatalf("unexpected health %+v", health)
+	}
+}
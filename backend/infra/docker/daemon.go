@@ -0,0 +1,157 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReasonDaemonUnreachable is the reason code reported when the docker CLI is
+// missing or cannot reach the daemon on the target host.
+const ReasonDaemonUnreachable = "DOCKER_DAEMON_UNREACHABLE"
+
+const (
+	DaemonNotInstalled = "not_installed"
+	DaemonNotRunning   = "not_running"
+)
+
+// DaemonUnreachableError reports that docker is not installed or the daemon is
+// not running on Host. Reason is DaemonNotInstalled or DaemonNotRunning.
+type DaemonUnreachableError struct {
+	Host   string
+	Reason string
+	Err    error
+}
+
+func (e *DaemonUnreachableError) Error() string {
+	if e.Reason == DaemonNotInstalled {
+		return fmt.Sprintf("docker is not installed on %s", e.Host)
+	}
+	return fmt.Sprintf("docker is not running on %s", e.Host)
+}
+
+func (e *DaemonUnreachableError) Unwrap() error { return e.Err }
+
+// CLI output that means the daemon (rather than the command) is the problem.
+var (
+	notInstalledMarkers = []string{
+		"docker: command not found",
+		"docker: not found",
+		`"docker": executable file not found`,
+	}
+	notRunningMarkers = []string{
+		"cannot connect to the docker daemon",
+		"is the docker daemon running",
+		"error during connect",
+	}
+)
+
+// ClassifyDaemonError wraps err in a *DaemonUnreachableError when it shows the
+// docker CLI is missing or the daemon is down; otherwise it returns err.
+func ClassifyDaemonError(host string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var unreachable *DaemonUnreachableError
+	if errors.As(err, &unreachable) {
+		return err
+	}
+
+	text := strings.ToLower(err.Error())
+	for _, marker := range notInstalledMarkers {
+		if strings.Contains(text, strings.ToLower(marker)) {
+			return &DaemonUnreachableError{Host: host, Reason: DaemonNotInstalled, Err: err}
+		}
+	}
+	for _, marker := range notRunningMarkers {
+		if strings.Contains(text, marker) {
+			return &DaemonUnreachableError{Host: host, Reason: DaemonNotRunning, Err: err}
+		}
+	}
+	// Exit status 127 is the shell's "command not found".
+	var sshExit interface{ ExitStatus() int }
+	if errors.As(err, &sshExit) && sshExit.ExitStatus() == 127 {
+		return &DaemonUnreachableError{Host: host, Reason: DaemonNotInstalled, Err: err}
+	}
+	var localExit *exec.ExitError
+	if errors.As(err, &localExit) && localExit.ExitCode() == 127 {
+		return &DaemonUnreachableError{Host: host, Reason: DaemonNotInstalled, Err: err}
+	}
+	return err
+}
+
+// Version returns the daemon's server version. It fails when the CLI is
+// missing or the daemon is unreachable, which Ping-style echo checks miss.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	out, err := c.exec.Run(ctx, "docker", "version", "--format", "{{.Server.Version}}")
+	if err != nil {
+		return "", ClassifyDaemonError(c.Host(), err)
+	}
+	return out, nil
+}
+
+// DaemonChecker caches per-target daemon reachability so request paths run
+// `docker version` at most once per TTL. Failures expire sooner than successes
+// so a daemon that comes back up is noticed quickly.
+type DaemonChecker struct {
+	HealthyTTL   time.Duration
+	UnhealthyTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]daemonCheck
+}
+
+type daemonCheck struct {
+	err     error
+	checked time.Time
+}
+
+// NewDaemonChecker returns a checker with the given cache lifetimes.
+func NewDaemonChecker(healthyTTL, unhealthyTTL time.Duration) *DaemonChecker {
+	return &DaemonChecker{
+		HealthyTTL:   healthyTTL,
+		UnhealthyTTL: unhealthyTTL,
+		entries:      map[string]daemonCheck{},
+	}
+}
+
+// Check returns nil when the daemon behind client is reachable, a
+// *DaemonUnreachableError when it is not, or the transport error (SSH
+// failure, timeout) that kept `docker version` from running. key identifies
+// the target (e.g. a server ID). Every failure is cached for UnhealthyTTL so
+// an unreachable host is not dialled again on each request; a check cut short
+// by ctx is not cached.
+func (d *DaemonChecker) Check(ctx context.Context, key string, client *Client) error {
+	d.mu.Lock()
+	entry, ok := d.entries[key]
+	d.mu.Unlock()
+	if ok {
+		ttl := d.HealthyTTL
+		if entry.err != nil {
+			ttl = d.UnhealthyTTL
+		}
+		if time.Since(entry.checked) < ttl {
+			return entry.err
+		}
+	}
+
+	_, err := client.Version(ctx)
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.entries[key] = daemonCheck{err: err, checked: time.Now()}
+	d.mu.Unlock()
+	return err
+}
+
+// Invalidate forces the next Check for key to re-run `docker version`.
+func (d *DaemonChecker) Invalidate(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, key)
+}
@@ -0,0 +1,37 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyDaemonError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"daemon down", errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?: exit status 1"), DaemonNotRunning},
+		{"remote cli missing", errors.New("bash: line 1: docker: command not found: Process exited with status 127"), DaemonNotInstalled},
+		{"local cli missing", errors.New(`: exec: "docker": executable file not found in $PATH`), DaemonNotInstalled},
+		{"unrelated", errors.New("Error response from daemon: No such container: web"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unreachable *DaemonUnreachableError
+			got := errors.As(ClassifyDaemonError("host-a", tt.err), &unreachable)
+			if tt.reason == "" {
+				if got {
+					t.Fatalf("expected %q to stay unclassified", tt.err)
+				}
+				return
+			}
+			if !got || unreachable.Reason != tt.reason || unreachable.Host != "host-a" {
+				t.Fatalf("expected reason %q, got %+v", tt.reason, unreachable)
+			}
+			if !errors.Is(unreachable, tt.err) {
+				t.Fatal("expected the CLI error to stay wrapped")
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ImageDigest pins the image one compose container runs. ImageID is the
+// content-addressed local image ID and always present; RepoDigests is empty
+// for images that were built locally and never pushed or pulled.
+type ImageDigest struct {
+	Service     string   `json:"service"`
+	Container   string   `json:"container"`
+	Image       string   `json:"image"`
+	ImageID     string   `json:"image_id"`
+	RepoDigests []string `json:"repo_digests"`
+}
+
+// DigestChange is a service whose running image differs from the recorded one.
+type DigestChange struct {
+	Service         string `json:"service"`
+	Container       string `json:"container"`
+	Image           string `json:"image"`
+	RecordedImageID string `json:"recorded_image_id"`
+	CurrentImageID  string `json:"current_image_id"`
+}
+
+// DigestDrift compares running image digests with recorded ones. Added lists
+// containers of services that were not recorded; Missing lists recorded
+// services with no container now.
+type DigestDrift struct {
+	Drifted bool           `json:"drifted"`
+	Changed []DigestChange `json:"changed"`
+	Added   []ImageDigest  `json:"added"`
+	Missing []ImageDigest  `json:"missing"`
+}
+
+type containerImageInspect struct {
+	Name   string `json:"Name"`
+	Image  string `json:"Image"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+type imageDigestInspect struct {
+	ID          string   `json:"Id"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+// ComposeImageDigests resolves the image each container of the compose
+// project runs, stopped containers included, ordered by service and
+// container name.
+func (c *Client) ComposeImageDigests(ctx context.Context, projectDir string) ([]ImageDigest, error) {
+	services, err := c.ComposePs(ctx, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return []ImageDigest{}, nil
+	}
+	names := make([]string, 0, len(services))
+	for _, svc := range services {
+		names = append(names, svc.Name)
+	}
+	output, err := c.exec.Run(ctx, "docker", append([]string{"container", "inspect"}, names...)...)
+	if err != nil {
+		return nil, err
+	}
+	var containers []containerImageInspect
+	if err := json.Unmarshal([]byte(output), &containers); err != nil {
+		return nil, fmt.Errorf("parse container inspect: %w", err)
+	}
+
+	imageIDs := make([]string, 0, len(containers))
+	seen := map[string]bool{}
+	for _, ct := range containers {
+		if ct.Image != "" && !seen[ct.Image] {
+			seen[ct.Image] = true
+			imageIDs = append(imageIDs, ct.Image)
+		}
+	}
+	repoDigests := map[string][]string{}
+	if len(imageIDs) > 0 {
+		output, err = c.exec.Run(ctx, "docker", append([]string{"image", "inspect"}, imageIDs...)...)
+		if err != nil {
+			return nil, err
+		}
+		var images []imageDigestInspect
+		if err := json.Unmarshal([]byte(output), &images); err != nil {
+			return nil, fmt.Errorf("parse image inspect: %w", err)
+		}
+		for _, img := range images {
+			repoDigests[img.ID] = img.RepoDigests
+		}
+	}
+
+	digests := make([]ImageDigest, 0, len(containers))
+	for _, ct := range containers {
+		rd := repoDigests[ct.Image]
+		if rd == nil {
+			rd = []string{}
+		}
+		digests = append(digests, ImageDigest{
+			Service:     ct.Config.Labels["com.docker.compose.service"],
+			Container:   strings.TrimPrefix(ct.Name, "/"),
+			Image:       ct.Config.Image,
+			ImageID:     ct.Image,
+			RepoDigests: rd,
+		})
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		if digests[i].Service != digests[j].Service {
+			return digests[i].Service < digests[j].Service
+		}
+		return digests[i].Container < digests[j].Container
+	})
+	return digests, nil
+}
+
+// DiffImageDigests compares current digests with recorded ones by service.
+// Replicas of one service share its recorded image ID, so each running
+// container is checked against it.
+func DiffImageDigests(recorded, current []ImageDigest) DigestDrift {
+	drift := DigestDrift{Changed: []DigestChange{}, Added: []ImageDigest{}, Missing: []ImageDigest{}}
+	byService := map[string]ImageDigest{}
+	for _, d := range recorded {
+		if _, ok := byService[d.Service]; !ok {
+			byService[d.Service] = d
+		}
+	}
+	running := map[string]bool{}
+	for _, d := range current {
+		running[d.Service] = true
+		pinned, ok := byService[d.Service]
+		switch {
+		case !ok:
+			drift.Added = append(drift.Added, d)
+		case pinned.ImageID != d.ImageID:
+			drift.Changed = append(drift.Changed, DigestChange{
+				Service:         d.Service,
+				Container:       d.Container,
+				Image:           d.Image,
+				RecordedImageID: pinned.ImageID,
+				CurrentImageID:  d.ImageID,
+			})
+		}
+	}
+	for _, d := range recorded {
+		if !running[d.Service] {
+			drift.Missing = append(drift.Missing, d)
+			running[d.Service] = true // report each missing service once
+		}
+	}
+	drift.Drifted = len(drift.Changed) > 0 || len(drift.Added) > 0 || len(drift.Missing) > 0
+	return drift
+}
@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// digestExecutor answers compose ps, container inspect and image inspect.
+type digestExecutor struct {
+	imageID string
+}
+
+func (d *digestExecutor) Run(_ context.Context, _ string, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	switch {
+	case strings.HasPrefix(cmd, "compose "):
+		return `{"Service":"web","Name":"demo-web-1"}` + "\n" + `{"Service":"db","Name":"demo-db-1"}` + "\n", nil
+	case strings.HasPrefix(cmd, "container inspect "):
+		return `[{"Name":"/demo-web-1","Image":"` + d.imageID + `","Config":{"Image":"nginx:latest","Labels":{"com.docker.compose.service":"web"}}},` +
+			`{"Name":"/demo-db-1","Image":"sha256:db","Config":{"Image":"postgres:16","Labels":{"com.docker.compose.service":"db"}}}]`, nil
+	case strings.HasPrefix(cmd, "image inspect "):
+		return `[{"Id":"` + d.imageID + `","RepoDigests":["nginx@sha256:feed"]},{"Id":"sha256:db","RepoDigests":null}]`, nil
+	}
+	return "", nil
+}
+
+func (d *digestExecutor) RunStream(context.Context, string, ...string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+func (d *digestExecutor) Ping(context.Context) error { return nil }
+func (d *digestExecutor) Host() string               { return "test" }
+
+func TestComposeImageDigestsResolvesImagePerContainer(t *testing.T) {
+	client := New(&digestExecutor{imageID: "sha256:web1"})
+
+	digests, err := client.ComposeImageDigests(context.Background(), "/srv/demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 2 {
+		t.Fatalf("expected 2 digests, got %+v", digests)
+	}
+	db, web := digests[0], digests[1]
+	if db.Service != "db" || db.Container != "demo-db-1" || db.ImageID != "sha256:db" || db.RepoDigests == nil || len(db.RepoDigests) != 0 {
+		t.Fatalf("unexpected db digest %+v", db)
+	}
+	if web.Image != "nginx:latest" || web.ImageID != "sha256:web1" || len(web.RepoDigests) != 1 || web.RepoDigests[0] != "nginx@sha256:feed" {
+		t.Fatalf("unexpected web digest %+v", web)
+	}
+}
+
+func TestDiffImageDigestsReportsChangedAddedAndMissing(t *testing.T) {
+	recorded := []ImageDigest{
+		{Service: "web", Container: "demo-web-1", Image: "nginx:latest", ImageID: "sha256:old"},
+		{Service: "db", Container: "demo-db-1", Image: "postgres:16", ImageID: "sha256:db"},
+		{Service: "cache", Container: "demo-cache-1", Image: "redis:7", ImageID: "sha256:redis"},
+	}
+	if drift := DiffImageDigests(recorded, recorded); drift.Drifted || len(drift.Changed)+len(drift.Added)+len(drift.Missing) != 0 {
+		t.Fatalf("expected no drift against itself, got %+v", drift)
+	}
+
+	current := []ImageDigest{
+		{Service: "web", Container: "demo-web-1", Image: "nginx:latest", ImageID: "sha256:new"},
+		{Service: "web", Container: "demo-web-2", Image: "nginx:latest", ImageID: "sha256:old"},
+		{Service: "db", Container: "demo-db-1", Image: "postgres:16", ImageID: "sha256:db"},
+		{Service: "worker", Container: "demo-worker-1", Image: "app:latest", ImageID: "sha256:app"},
+	}
+	drift := DiffImageDigests(recorded, current)
+	if !drift.Drifted {
+		t.Fatal("expected drift")
+	}
+	if len(drift.Changed) != 1 || drift.Changed[0].Container != "demo-web-1" || drift.Changed[0].RecordedImageID != "sha256:old" || drift.Changed[0].CurrentImageID != "sha256:new" {
+		t.Fatalf("unexpected changed %+v", drift.Changed)
+	}
+	if len(drift.Added) != 1 || drift.Added[0].Service != "worker" {
+		t.Fatalf("unexpected added %+v", drift.Added)
+	}
+	if len(drift.Missing) != 1 || drift.Missing[0].Service != "cache" {
+		t.Fatalf("unexpected missing %+v", drift.Missing)
+	}
+}
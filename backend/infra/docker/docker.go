@@ -6,10 +6,15 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Client wraps Docker CLI operations using an Executor.
@@ -51,12 +56,32 @@ func (c *Client) ComposeUp(ctx context.Context, projectDir string) (string, erro
 	return c.exec.Run(ctx, "docker", "compose", "-f", c.composeFile(projectDir), "up", "-d")
 }
 
+// ComposeDownOptions selects what docker compose down removes besides the
+// project's containers and networks.
+type ComposeDownOptions struct {
+	RemoveVolumes bool   // -v: named and anonymous volumes
+	RemoveOrphans bool   // --remove-orphans: containers of services no longer in the file
+	RemoveImages  string // --rmi: "all" or "local"; empty keeps images
+}
+
+// Compose down --rmi scopes.
+const (
+	ComposeRemoveImagesAll   = "all"
+	ComposeRemoveImagesLocal = "local"
+)
+
 // ComposeDown runs docker compose down.
-func (c *Client) ComposeDown(ctx context.Context, projectDir string, removeVolumes bool) (string, error) {
+func (c *Client) ComposeDown(ctx context.Context, projectDir string, opts ComposeDownOptions) (string, error) {
 	args := []string{"compose", "-f", c.composeFile(projectDir), "down"}
-	if removeVolumes {
+	if opts.RemoveVolumes {
 		args = append(args, "-v")
 	}
+	if opts.RemoveOrphans {
+		args = append(args, "--remove-orphans")
+	}
+	if opts.RemoveImages != "" {
+		args = append(args, "--rmi", opts.RemoveImages)
+	}
 	return c.exec.Run(ctx, "docker", args...)
 }
 
@@ -103,6 +128,27 @@ func (c *Client) ComposeConfigWrite(projectDir string, content string) error {
 	return nil
 }
 
+// ComposeEnvRead reads the project's .env file. A missing file reads as empty.
+func (c *Client) ComposeEnvRead(projectDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(projectDir, ".env"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read compose env: %w", err)
+	}
+	return string(data), nil
+}
+
+// ComposeEnvWrite replaces the project's .env file with content.
+func (c *Client) ComposeEnvWrite(projectDir string, content string) error {
+	path := filepath.Join(projectDir, ".env")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("write compose env: %w", err)
+	}
+	return nil
+}
+
 // ComposeLs lists compose projects in JSON format.
 func (c *Client) ComposeLs(ctx context.Context) (string, error) {
 	return c.exec.Run(ctx, "docker", "compose", "ls", "--format", "json")
@@ -110,9 +156,16 @@ func (c *Client) ComposeLs(ctx context.Context) (string, error) {
 
 // ─── Image operations ────────────────────────────────────
 
-// ImageList returns images in JSON format.
-func (c *Client) ImageList(ctx context.Context) (string, error) {
-	return c.exec.Run(ctx, "docker", "image", "ls", "--format", "json")
+// ImageList returns images as one JSON object per line, narrowed by docker
+// image ls --filter expressions such as "label=maintainer=ops". The
+// '{{json .}}' template also works on docker releases that predate
+// --format json.
+func (c *Client) ImageList(ctx context.Context, filters ...string) (string, error) {
+	args := []string{"image", "ls", "--format", "{{json .}}"}
+	for _, f := range filters {
+		args = append(args, "--filter", f)
+	}
+	return c.exec.Run(ctx, "docker", args...)
 }
 
 // ImagePull pulls an image by name.
@@ -165,9 +218,28 @@ func (c *Client) ImagePrune(ctx context.Context) (string, error) {
 
 // ─── Container operations ────────────────────────────────
 
-// ContainerList returns all containers in JSON format.
-func (c *Client) ContainerList(ctx context.Context) (string, error) {
-	return c.exec.Run(ctx, "docker", "ps", "-a", "--format", "json")
+// ContainerList returns all containers in JSON format, narrowed by docker ps
+// --filter expressions such as "label=com.docker.compose.project=web".
+func (c *Client) ContainerList(ctx context.Context, filters ...string) (string, error) {
+	args := []string{"ps", "-a", "--format", "json"}
+	for _, f := range filters {
+		args = append(args, "--filter", f)
+	}
+	return c.exec.Run(ctx, "docker", args...)
+}
+
+// ParseLabels splits the "key=value,key2=value2" label summary printed by
+// docker ps and docker image ls into a map. A key without "=" maps to "".
+func ParseLabels(raw string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		labels[k] = v
+	}
+	return labels
 }
 
 // ContainerInspect returns detailed info for a container.
@@ -175,14 +247,103 @@ func (c *Client) ContainerInspect(ctx context.Context, id string) (string, error
 	return c.exec.Run(ctx, "docker", "inspect", id)
 }
 
+// EnvVar is one entry of a container's configured environment.
+type EnvVar struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ContainerEnv returns the environment a container was created with
+// (Config.Env), in the order docker reports it.
+func (c *Client) ContainerEnv(ctx context.Context, id string) ([]EnvVar, error) {
+	output, err := c.exec.Run(ctx, "docker", "inspect", "--format", "{{json .Config.Env}}", id)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnv(output)
+}
+
+// ParseEnv decodes a JSON array of "KEY=value" strings as printed for
+// Config.Env. An entry without "=" has an empty value.
+func ParseEnv(raw string) ([]EnvVar, error) {
+	var entries []string
+	if raw = strings.TrimSpace(raw); raw != "" && raw != "null" {
+		if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+			return nil, fmt.Errorf("parse container env: %w", err)
+		}
+	}
+	return envVars(entries), nil
+}
+
+// envVars splits "KEY=value" entries, skipping ones without a key.
+func envVars(entries []string) []EnvVar {
+	vars := make([]EnvVar, 0, len(entries))
+	for _, entry := range entries {
+		k, v, _ := strings.Cut(entry, "=")
+		if k == "" {
+			continue
+		}
+		vars = append(vars, EnvVar{Key: k, Value: v})
+	}
+	return vars
+}
+
 // ContainerStats returns one-shot stats for all containers in JSON format.
 func (c *Client) ContainerStats(ctx context.Context) (string, error) {
 	return c.exec.Run(ctx, "docker", "stats", "--no-stream", "--format", "json")
 }
 
-// ContainerLogs returns container logs with tail limit.
-func (c *Client) ContainerLogs(ctx context.Context, id string, tail int) (string, error) {
-	return c.exec.Run(ctx, "docker", "logs", "--tail", fmt.Sprintf("%d", tail), id)
+// LogOptions selects which container log lines are returned. Since and Until
+// take an RFC3339 timestamp or a relative duration such as "1h" (see
+// ParseLogTime); empty means unbounded. Tail <= 0 returns every line in the
+// window.
+type LogOptions struct {
+	Tail       int
+	Since      string
+	Until      string
+	Timestamps bool
+}
+
+// ContainerLogs returns container logs selected by opts.
+func (c *Client) ContainerLogs(ctx context.Context, id string, opts LogOptions) (string, error) {
+	return c.exec.Run(ctx, "docker", append(containerLogsArgs(opts), id)...)
+}
+
+func containerLogsArgs(opts LogOptions) []string {
+	args := []string{"logs"}
+	if opts.Tail > 0 {
+		args = append(args, "--tail", fmt.Sprintf("%d", opts.Tail))
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Until != "" {
+		args = append(args, "--until", opts.Until)
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	return args
+}
+
+// ContainerLogsStream follows container logs selected by opts (docker logs
+// -f). Closing the reader stops the follow process.
+func (c *Client) ContainerLogsStream(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	return c.exec.RunStream(ctx, "docker", append(containerLogsArgs(opts), "--follow", id)...)
+}
+
+// ParseLogTime validates a docker logs --since/--until value and resolves it
+// against now. It accepts RFC3339 timestamps and positive relative durations
+// ("90s", "15m", "1h30m"), which count back from now.
+func ParseLogTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time nor a relative duration such as 1h", value)
+	}
+	return now.Add(-d), nil
 }
 
 // ContainerStart starts a container.
@@ -190,14 +351,33 @@ func (c *Client) ContainerStart(ctx context.Context, id string) (string, error)
 	return c.exec.Run(ctx, "docker", "start", id)
 }
 
+// StopOptions controls how docker stop and docker restart shut a container
+// down. A nil Timeout and empty Signal keep the container's own defaults
+// (its StopTimeout and StopSignal, usually 10s and SIGTERM).
+type StopOptions struct {
+	Timeout *int   // -t: seconds to wait before SIGKILL
+	Signal  string // --signal: signal sent first, e.g. "SIGINT" or "2"
+}
+
 // ContainerStop stops a container.
-func (c *Client) ContainerStop(ctx context.Context, id string) (string, error) {
-	return c.exec.Run(ctx, "docker", "stop", id)
+func (c *Client) ContainerStop(ctx context.Context, id string, opts StopOptions) (string, error) {
+	return c.exec.Run(ctx, "docker", append(stopArgs("stop", opts), id)...)
 }
 
 // ContainerRestart restarts a container.
-func (c *Client) ContainerRestart(ctx context.Context, id string) (string, error) {
-	return c.exec.Run(ctx, "docker", "restart", id)
+func (c *Client) ContainerRestart(ctx context.Context, id string, opts StopOptions) (string, error) {
+	return c.exec.Run(ctx, "docker", append(stopArgs("restart", opts), id)...)
+}
+
+func stopArgs(command string, opts StopOptions) []string {
+	args := []string{command}
+	if opts.Timeout != nil {
+		args = append(args, "-t", strconv.Itoa(*opts.Timeout))
+	}
+	if opts.Signal != "" {
+		args = append(args, "--signal", opts.Signal)
+	}
+	return args
 }
 
 // ContainerRemove removes a container.
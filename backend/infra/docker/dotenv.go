@@ -0,0 +1,140 @@
+package docker
+
+import (
+	"sort"
+	"strings"
+)
+
+// EnvDiff lists the keys that differ between two env files. Only key names are
+// kept so the diff can be logged without leaking values.
+type EnvDiff struct {
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Removed []string `json:"removed"`
+}
+
+// Empty reports whether the two env files hold the same variables.
+func (d EnvDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// DiffEnv compares current and next key/value maps. Each list is sorted.
+func DiffEnv(current, next map[string]string) EnvDiff {
+	diff := EnvDiff{Added: []string{}, Changed: []string{}, Removed: []string{}}
+	for key, value := range next {
+		old, ok := current[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case old != value:
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range current {
+		if _, ok := next[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// MergeEnv returns current with overlay's values set on top of it and the
+// keys in remove deleted. Keys only current holds are kept.
+func MergeEnv(current, overlay map[string]string, remove []string) map[string]string {
+	merged := make(map[string]string, len(current)+len(overlay))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range overlay {
+		merged[key] = value
+	}
+	for _, key := range remove {
+		delete(merged, key)
+	}
+	return merged
+}
+
+// ParseDotEnv reads the KEY=value lines of a compose .env file. Blank lines,
+// comments and an optional "export " prefix are ignored; quoted values are
+// unquoted the way RenderDotEnv writes them.
+func ParseDotEnv(content string) map[string]string {
+	vars := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		vars[key] = parseDotEnvValue(strings.TrimSpace(value))
+	}
+	return vars
+}
+
+func parseDotEnvValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		var b strings.Builder
+		inner := value[1 : len(value)-1]
+		for i := 0; i < len(inner); i++ {
+			if inner[i] == '\\' && i+1 < len(inner) {
+				i++
+				switch inner[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(inner[i])
+				}
+				continue
+			}
+			b.WriteByte(inner[i])
+		}
+		return b.String()
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// RenderDotEnv writes vars as a compose .env file, one KEY=value line per
+// variable sorted by key. Values that compose would otherwise split,
+// interpolate or truncate are quoted.
+func RenderDotEnv(vars map[string]string) string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(quoteDotEnvValue(vars[key]))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func quoteDotEnvValue(value string) string {
+	if !strings.ContainsAny(value, " \t\r\n#'\"\\$`") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\r\n") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderDotEnvRoundTrips(t *testing.T) {
+	vars := map[string]string{
+		"PLAIN":   "value",
+		"EMPTY":   "",
+		"SPACED":  "hello world",
+		"DOLLAR":  "pa$$word",
+		"QUOTE":   "it's \"quoted\"",
+		"NEWLINE": "line1\nline2",
+		"HASH":    "a #comment",
+	}
+	content := RenderDotEnv(vars)
+	if got := ParseDotEnv(content); !reflect.DeepEqual(got, vars) {
+		t.Fatalf("round trip mismatch:\n%s\ngot %#v", content, got)
+	}
+	want := "DOLLAR='pa$$word'\nEMPTY=\n"
+	if content[:len(want)] != want {
+		t.Fatalf("expected sorted quoted output, got:\n%s", content)
+	}
+}
+
+func TestParseDotEnvSkipsCommentsAndExport(t *testing.T) {
+	got := ParseDotEnv("# header\n\nexport A=1\nB = two # note\ninvalid\n=novalue\n")
+	want := map[string]string{"A": "1", "B": "two"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseDotEnv = %#v, want %#v", got, want)
+	}
+}
+
+func TestDiffEnv(t *testing.T) {
+	diff := DiffEnv(
+		map[string]string{"KEEP": "1", "CHANGE": "old", "DROP": "x"},
+		map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "y"},
+	)
+	if !reflect.DeepEqual(diff.Added, []string{"ADD"}) ||
+		!reflect.DeepEqual(diff.Changed, []string{"CHANGE"}) ||
+		!reflect.DeepEqual(diff.Removed, []string{"DROP"}) {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if diff.Empty() {
+		t.Fatal("expected non-empty diff")
+	}
+	if !DiffEnv(map[string]string{"A": "1"}, map[string]string{"A": "1"}).Empty() {
+		t.Fatal("expected empty diff for identical envs")
+	}
+}
@@ -11,7 +11,9 @@ type Executor interface {
 	// Run executes a command and returns buffered stdout.
 	Run(ctx context.Context, command string, args ...string) (string, error)
 
-	// RunStream executes a command and returns a streaming reader for stdout.
+	// RunStream executes a command and returns a streaming reader for its
+	// combined stdout and stderr. Closing the reader stops the command, so
+	// follow-mode commands do not outlive their consumer.
 	RunStream(ctx context.Context, command string, args ...string) (io.ReadCloser, error)
 
 	// Ping checks if the execution target is reachable.
@@ -20,3 +22,9 @@ type Executor interface {
 	// Host returns a label identifying the execution target (e.g. "local", "192.168.1.10").
 	Host() string
 }
+
+// InputRunner is implemented by executors that can feed a command's stdin,
+// which keeps secrets such as registry passwords out of the command line.
+type InputRunner interface {
+	RunInput(ctx context.Context, input string, command string, args ...string) (string, error)
+}
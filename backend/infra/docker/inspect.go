@@ -0,0 +1,224 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrContainerNotFound is returned when docker inspect prints no object.
+var ErrContainerNotFound = errors.New("container not found")
+
+// ContainerDetails is the subset of `docker inspect` the container detail
+// views need, in a stable shape.
+type ContainerDetails struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	Image         string             `json:"image"`
+	ImageID       string             `json:"image_id"`
+	Created       string             `json:"created"`
+	State         ContainerState     `json:"state"`
+	RestartPolicy RestartPolicy      `json:"restart_policy"`
+	Env           []EnvVar           `json:"env"`
+	Labels        map[string]string  `json:"labels"`
+	Mounts        []ContainerMount   `json:"mounts"`
+	Networks      []ContainerNetwork `json:"networks"`
+	Ports         []ContainerPort    `json:"ports"`
+}
+
+// ContainerState is State from docker inspect. Health is nil when the
+// container has no healthcheck.
+type ContainerState struct {
+	Status     string           `json:"status"`
+	Running    bool             `json:"running"`
+	ExitCode   int              `json:"exit_code"`
+	StartedAt  string           `json:"started_at"`
+	FinishedAt string           `json:"finished_at"`
+	Health     *ContainerHealth `json:"health"`
+}
+
+// ContainerHealth is the healthcheck result of a container.
+type ContainerHealth struct {
+	Status        string `json:"status"`
+	FailingStreak int    `json:"failing_streak"`
+}
+
+// RestartPolicy is HostConfig.RestartPolicy.
+type RestartPolicy struct {
+	Name              string `json:"name"`
+	MaximumRetryCount int    `json:"maximum_retry_count"`
+}
+
+// ContainerMount is one entry of Mounts.
+type ContainerMount struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Mode        string `json:"mode"`
+	RW          bool   `json:"rw"`
+}
+
+// ContainerNetwork is one attached network from NetworkSettings.Networks.
+type ContainerNetwork struct {
+	Name       string   `json:"name"`
+	IPAddress  string   `json:"ip_address"`
+	Gateway    string   `json:"gateway"`
+	MacAddress string   `json:"mac_address"`
+	Aliases    []string `json:"aliases"`
+}
+
+// ContainerPort is one exposed port and, when published, its host binding.
+// An exposed port published on several host addresses yields one entry each.
+type ContainerPort struct {
+	ContainerPort string `json:"container_port"`
+	Protocol      string `json:"protocol"`
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      string `json:"host_port,omitempty"`
+}
+
+// inspectContainer mirrors the parts of docker's inspect JSON we read.
+type inspectContainer struct {
+	ID      string `json:"Id"`
+	Name    string `json:"Name"`
+	Image   string `json:"Image"`
+	Created string `json:"Created"`
+	State   struct {
+		Status     string `json:"Status"`
+		Running    bool   `json:"Running"`
+		ExitCode   int    `json:"ExitCode"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Env    []string          `json:"Env"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Name        string `json:"Name"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		Mode        string `json:"Mode"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+		Networks map[string]struct {
+			IPAddress  string   `json:"IPAddress"`
+			Gateway    string   `json:"Gateway"`
+			MacAddress string   `json:"MacAddress"`
+			Aliases    []string `json:"Aliases"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// ParseContainerInspect decodes `docker inspect <container>` output. docker
+// wraps the object in an array; a bare object is accepted too. It returns
+// the typed details and the raw object.
+func ParseContainerInspect(output string) (ContainerDetails, json.RawMessage, error) {
+	output = strings.TrimSpace(output)
+	var raw json.RawMessage
+	if strings.HasPrefix(output, "[") {
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(output), &items); err != nil {
+			return ContainerDetails{}, nil, fmt.Errorf("parse container inspect: %w", err)
+		}
+		if len(items) == 0 {
+			return ContainerDetails{}, nil, ErrContainerNotFound
+		}
+		raw = items[0]
+	} else {
+		raw = json.RawMessage(output)
+	}
+
+	var in inspectContainer
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return ContainerDetails{}, nil, fmt.Errorf("parse container inspect: %w", err)
+	}
+
+	details := ContainerDetails{
+		ID:      in.ID,
+		Name:    strings.TrimPrefix(in.Name, "/"),
+		Image:   in.Config.Image,
+		ImageID: in.Image,
+		Created: in.Created,
+		State: ContainerState{
+			Status:     in.State.Status,
+			Running:    in.State.Running,
+			ExitCode:   in.State.ExitCode,
+			StartedAt:  in.State.StartedAt,
+			FinishedAt: in.State.FinishedAt,
+		},
+		RestartPolicy: RestartPolicy{
+			Name:              in.HostConfig.RestartPolicy.Name,
+			MaximumRetryCount: in.HostConfig.RestartPolicy.MaximumRetryCount,
+		},
+		Labels:   in.Config.Labels,
+		Mounts:   make([]ContainerMount, 0, len(in.Mounts)),
+		Networks: make([]ContainerNetwork, 0, len(in.NetworkSettings.Networks)),
+		Ports:    []ContainerPort{},
+	}
+	if details.Labels == nil {
+		details.Labels = map[string]string{}
+	}
+	if h := in.State.Health; h != nil {
+		details.State.Health = &ContainerHealth{Status: h.Status, FailingStreak: h.FailingStreak}
+	}
+
+	details.Env = envVars(in.Config.Env)
+
+	for _, m := range in.Mounts {
+		details.Mounts = append(details.Mounts, ContainerMount{
+			Type: m.Type, Name: m.Name, Source: m.Source,
+			Destination: m.Destination, Mode: m.Mode, RW: m.RW,
+		})
+	}
+
+	for name, n := range in.NetworkSettings.Networks {
+		details.Networks = append(details.Networks, ContainerNetwork{
+			Name: name, IPAddress: n.IPAddress, Gateway: n.Gateway,
+			MacAddress: n.MacAddress, Aliases: n.Aliases,
+		})
+	}
+	sort.Slice(details.Networks, func(i, j int) bool { return details.Networks[i].Name < details.Networks[j].Name })
+
+	for spec, bindings := range in.NetworkSettings.Ports {
+		port, proto, _ := strings.Cut(spec, "/")
+		if len(bindings) == 0 {
+			details.Ports = append(details.Ports, ContainerPort{ContainerPort: port, Protocol: proto})
+			continue
+		}
+		for _, b := range bindings {
+			details.Ports = append(details.Ports, ContainerPort{ContainerPort: port, Protocol: proto, HostIP: b.HostIP, HostPort: b.HostPort})
+		}
+	}
+	sort.Slice(details.Ports, func(i, j int) bool {
+		a, b := details.Ports[i], details.Ports[j]
+		if a.ContainerPort != b.ContainerPort {
+			return a.ContainerPort < b.ContainerPort
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.HostIP < b.HostIP
+	})
+
+	return details, raw, nil
+}
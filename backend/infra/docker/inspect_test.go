@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const sampleContainerInspect = `[
+  {
+    "Id": "abc123",
+    "Name": "/web-1",
+    "Image": "sha256:img",
+    "Created": "2026-01-02T10:00:00Z",
+    "State": {"Status": "running", "Running": true, "ExitCode": 0, "StartedAt": "2026-01-02T10:00:01Z", "FinishedAt": "0001-01-01T00:00:00Z",
+      "Health": {"Status": "healthy", "FailingStreak": 0}},
+    "Config": {"Image": "nginx:1.27", "Env": ["PATH=/usr/bin", "MODE=prod"], "Labels": {"com.docker.compose.project": "web"}},
+    "HostConfig": {"RestartPolicy": {"Name": "unless-stopped", "MaximumRetryCount": 0}},
+    "Mounts": [{"Type": "volume", "Name": "data", "Source": "/var/lib/docker/volumes/data/_data", "Destination": "/data", "Mode": "z", "RW": true}],
+    "NetworkSettings": {
+      "Ports": {"80/tcp": [{"HostIp": "0.0.0.0", "HostPort": "8080"}, {"HostIp": "::", "HostPort": "8080"}], "9000/tcp": null},
+      "Networks": {"web_default": {"IPAddress": "172.18.0.2", "Gateway": "172.18.0.1", "MacAddress": "02:42:ac:12:00:02", "Aliases": ["web"]}}
+    }
+  }
+]`
+
+func TestParseContainerInspect(t *testing.T) {
+	details, raw, err := ParseContainerInspect(sampleContainerInspect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Name != "web-1" || details.Image != "nginx:1.27" || details.ImageID != "sha256:img" {
+		t.Fatalf("unexpected identity: %+v", details)
+	}
+	if !details.State.Running || details.State.Health == nil || details.State.Health.Status != "healthy" {
+		t.Fatalf("unexpected state: %+v", details.State)
+	}
+	if details.RestartPolicy.Name != "unless-stopped" {
+		t.Fatalf("unexpected restart policy: %+v", details.RestartPolicy)
+	}
+	if len(details.Env) != 2 || details.Env[1] != (EnvVar{Key: "MODE", Value: "prod"}) {
+		t.Fatalf("unexpected env: %+v", details.Env)
+	}
+	if len(details.Mounts) != 1 || details.Mounts[0].Destination != "/data" || !details.Mounts[0].RW {
+		t.Fatalf("unexpected mounts: %+v", details.Mounts)
+	}
+	if len(details.Networks) != 1 || details.Networks[0].Name != "web_default" || details.Networks[0].IPAddress != "172.18.0.2" {
+		t.Fatalf("unexpected networks: %+v", details.Networks)
+	}
+	wantPorts := []ContainerPort{
+		{ContainerPort: "80", Protocol: "tcp", HostIP: "0.0.0.0", HostPort: "8080"},
+		{ContainerPort: "80", Protocol: "tcp", HostIP: "::", HostPort: "8080"},
+		{ContainerPort: "9000", Protocol: "tcp"},
+	}
+	if len(details.Ports) != len(wantPorts) {
+		t.Fatalf("unexpected ports: %+v", details.Ports)
+	}
+	for i, want := range wantPorts {
+		if details.Ports[i] != want {
+			t.Fatalf("port %d: want %+v, got %+v", i, want, details.Ports[i])
+		}
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal(raw, &object); err != nil || object["Id"] != "abc123" {
+		t.Fatalf("expected unwrapped inspect object, got %s (%v)", raw, err)
+	}
+}
+
+func TestParseContainerInspectEdgeCases(t *testing.T) {
+	if _, _, err := ParseContainerInspect("[]"); !errors.Is(err, ErrContainerNotFound) {
+		t.Fatalf("expected ErrContainerNotFound, got %v", err)
+	}
+	if _, _, err := ParseContainerInspect("not json"); err == nil {
+		t.Fatal("expected parse error")
+	}
+
+	details, _, err := ParseContainerInspect(`{"Id":"abc","Name":"/bare","State":{"Status":"exited","ExitCode":1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Name != "bare" || details.State.Health != nil || details.State.ExitCode != 1 {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+	if details.Labels == nil || details.Mounts == nil || details.Networks == nil || details.Ports == nil || details.Env == nil {
+		t.Fatalf("expected empty collections instead of nil: %+v", details)
+	}
+}
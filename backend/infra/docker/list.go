@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ComposeProject is one row of `docker compose ls --format json`.
+type ComposeProject struct {
+	Name        string `json:"Name"`
+	Status      string `json:"Status"`
+	ConfigFiles string `json:"ConfigFiles"`
+}
+
+// Image is one row of `docker image ls --format '{{json .}}'`. Labels is
+// parsed from docker's "key=value,..." summary.
+type Image struct {
+	ID           string            `json:"ID"`
+	Repository   string            `json:"Repository"`
+	Tag          string            `json:"Tag"`
+	Digest       string            `json:"Digest"`
+	CreatedAt    string            `json:"CreatedAt"`
+	CreatedSince string            `json:"CreatedSince"`
+	Size         string            `json:"Size"`
+	Containers   string            `json:"Containers"`
+	Labels       map[string]string `json:"Labels"`
+}
+
+// UnmarshalJSON accepts Labels both as the CLI's comma-separated string and
+// as an already-parsed object.
+func (i *Image) UnmarshalJSON(data []byte) error {
+	type plain Image
+	var raw struct {
+		plain
+		Labels json.RawMessage `json:"Labels"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*i = Image(raw.plain)
+	var summary string
+	if err := json.Unmarshal(raw.Labels, &summary); err == nil || len(raw.Labels) == 0 {
+		i.Labels = ParseLabels(summary)
+		return nil
+	}
+	return json.Unmarshal(raw.Labels, &i.Labels)
+}
+
+// ComposeProjects lists compose projects as typed rows.
+func (c *Client) ComposeProjects(ctx context.Context) ([]ComposeProject, error) {
+	output, err := c.ComposeLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseComposeLs(output)
+}
+
+// Images lists images as typed rows; filters are as for ImageList.
+func (c *Client) Images(ctx context.Context, filters ...string) ([]Image, error) {
+	output, err := c.ImageList(ctx, filters...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseImageList(output)
+}
+
+// ParseComposeLs decodes `docker compose ls --format json` output.
+func ParseComposeLs(output string) ([]ComposeProject, error) {
+	return parseJSONRows[ComposeProject](output, "compose ls")
+}
+
+// ParseImageList decodes `docker image ls --format '{{json .}}'` output.
+func ParseImageList(output string) ([]Image, error) {
+	return parseJSONRows[Image](output, "image ls")
+}
+
+// parseJSONRows decodes CLI JSON output that is either one array or one
+// object per line; the shape varies across docker and compose versions.
+func parseJSONRows[T any](output, what string) ([]T, error) {
+	output = strings.TrimSpace(output)
+	rows := []T{}
+	if output == "" {
+		return rows, nil
+	}
+	if strings.HasPrefix(output, "[") {
+		if err := json.Unmarshal([]byte(output), &rows); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", what, err)
+		}
+		return rows, nil
+	}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var row T
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", what, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
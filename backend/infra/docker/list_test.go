@@ -0,0 +1,45 @@
+package docker
+
+import "testing"
+
+func TestParseComposeLsAcceptsArrayAndLines(t *testing.T) {
+	array := `[{"Name":"web","Status":"running(2)","ConfigFiles":"/srv/web/docker-compose.yml"}]`
+	lines := `{"Name":"web","Status":"running(2)","ConfigFiles":"/srv/web/docker-compose.yml"}` + "\n"
+	for name, output := range map[string]string{"array": array, "lines": lines} {
+		projects, err := ParseComposeLs(output)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(projects) != 1 || projects[0].Name != "web" || projects[0].Status != "running(2)" {
+			t.Fatalf("%s: unexpected projects %+v", name, projects)
+		}
+	}
+
+	projects, err := ParseComposeLs("  \n")
+	if err != nil || projects == nil || len(projects) != 0 {
+		t.Fatalf("expected empty non-nil list, got %#v, %v", projects, err)
+	}
+}
+
+func TestParseImageListParsesLabels(t *testing.T) {
+	output := `{"ID":"sha256:abc","Repository":"nginx","Tag":"1.27","Size":"188MB","Labels":"maintainer=ops,tier=web"}` + "\n" +
+		`{"ID":"sha256:def","Repository":"<none>","Tag":"<none>","Labels":""}` + "\n"
+
+	images, err := ParseImageList(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %+v", images)
+	}
+	if images[0].Repository != "nginx" || images[0].Tag != "1.27" || images[0].Labels["tier"] != "web" {
+		t.Fatalf("unexpected first image %+v", images[0])
+	}
+	if len(images[1].Labels) != 0 {
+		t.Fatalf("expected no labels, got %v", images[1].Labels)
+	}
+
+	if _, err := ParseImageList("not json"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // LocalExecutor runs commands via os/exec on the local host.
@@ -47,11 +49,20 @@ func (e *LocalExecutor) buildCmd(ctx context.Context, command string, args []str
 
 // Run executes a command and returns buffered stdout.
 func (e *LocalExecutor) Run(ctx context.Context, command string, args ...string) (string, error) {
+	return e.RunInput(ctx, "", command, args...)
+}
+
+// RunInput executes a command with input on its stdin and returns buffered
+// stdout. With sudo -S the password line is written first.
+func (e *LocalExecutor) RunInput(ctx context.Context, input string, command string, args ...string) (string, error) {
 	cmd := e.buildCmd(ctx, command, args)
 	cmd.Env = append(cmd.Environ(), "DOCKER_HOST="+e.DockerHost)
 
 	if e.SudoEnabled && e.SudoPassword != "" {
-		cmd.Stdin = strings.NewReader(e.SudoPassword + "\n")
+		input = e.SudoPassword + "\n" + input
+	}
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
 	}
 
 	var stdout, stderr bytes.Buffer
@@ -64,25 +75,44 @@ func (e *LocalExecutor) Run(ctx context.Context, command string, args ...string)
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// RunStream executes a command and returns a streaming reader for stdout.
+// RunStream executes a command and returns a streaming reader for its
+// combined stdout and stderr. Closing the reader or cancelling ctx stops the
+// command with SIGTERM, which sudo relays to its child.
 func (e *LocalExecutor) RunStream(ctx context.Context, command string, args ...string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	cmd := e.buildCmd(ctx, command, args)
 	cmd.Env = append(cmd.Environ(), "DOCKER_HOST="+e.DockerHost)
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = 5 * time.Second
 
 	if e.SudoEnabled && e.SudoPassword != "" {
 		cmd.Stdin = strings.NewReader(e.SudoPassword + "\n")
 	}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("stdout pipe: %w", err)
-	}
-
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
 	if err := cmd.Start(); err != nil {
+		cancel()
 		return nil, fmt.Errorf("start: %w", err)
 	}
+	go func() {
+		_ = cmd.Wait()
+		_ = pw.Close()
+	}()
+
+	return &localStream{PipeReader: pr, cancel: cancel}, nil
+}
+
+// localStream stops its command when closed.
+type localStream struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
 
-	return stdout, nil
+func (s *localStream) Close() error {
+	s.cancel()
+	return s.PipeReader.Close()
 }
 
 // Ping checks if the local execution target is reachable by running "echo ok".
@@ -0,0 +1,150 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Prune targets, in the order PruneAll runs them: removing stopped
+// containers first frees the images, networks and volumes they held.
+const (
+	PruneContainers = "containers"
+	PruneImages     = "images"
+	PruneNetworks   = "networks"
+	PruneBuildCache = "build_cache"
+	PruneVolumes    = "volumes"
+)
+
+// PruneEstimate is what pruning one target would remove.
+type PruneEstimate struct {
+	Target           string   `json:"target"`
+	Count            int      `json:"count"`
+	ReclaimableBytes int64    `json:"reclaimable_bytes"`
+	Items            []string `json:"items,omitempty"`
+}
+
+// PrunePreview estimates every target from docker system df and the list
+// of unused custom networks. Volumes are always estimated, whether or not
+// they will be pruned.
+func (c *Client) PrunePreview(ctx context.Context) ([]PruneEstimate, error) {
+	output, err := c.exec.Run(ctx, "docker", "system", "df", "--format", "{{json .}}")
+	if err != nil {
+		return nil, err
+	}
+	estimates := map[string]PruneEstimate{}
+	for _, row := range parseSystemDF(output) {
+		estimates[row.Target] = row
+	}
+	networks, err := c.exec.Run(ctx, "docker", "network", "ls", "--filter", "dangling=true", "--filter", "type=custom", "--format", "{{.Name}}")
+	if err != nil {
+		return nil, err
+	}
+	unused := strings.Fields(networks)
+	estimates[PruneNetworks] = PruneEstimate{Target: PruneNetworks, Count: len(unused), Items: unused}
+
+	preview := make([]PruneEstimate, 0, 5)
+	for _, target := range []string{PruneContainers, PruneImages, PruneNetworks, PruneBuildCache, PruneVolumes} {
+		est, ok := estimates[target]
+		if !ok {
+			est = PruneEstimate{Target: target}
+		}
+		preview = append(preview, est)
+	}
+	return preview, nil
+}
+
+// systemDFTargets maps docker system df types to prune targets.
+var systemDFTargets = map[string]string{
+	"Containers":    PruneContainers,
+	"Images":        PruneImages,
+	"Build Cache":   PruneBuildCache,
+	"Local Volumes": PruneVolumes,
+}
+
+// parseSystemDF reads docker system df JSON lines. An unused object is one
+// that is not active; reclaimable sizes look like "1.2GB (41%)".
+func parseSystemDF(output string) []PruneEstimate {
+	var rows []PruneEstimate
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var row struct {
+			Type        string
+			TotalCount  string
+			Active      string
+			Reclaimable string
+		}
+		if err := json.Unmarshal([]byte(scanner.Text()), &row); err != nil {
+			continue
+		}
+		target, ok := systemDFTargets[row.Type]
+		if !ok {
+			continue
+		}
+		total, _ := strconv.Atoi(row.TotalCount)
+		active, _ := strconv.Atoi(row.Active)
+		size, _, _ := strings.Cut(row.Reclaimable, " ")
+		rows = append(rows, PruneEstimate{
+			Target:           target,
+			Count:            max(total-active, 0),
+			ReclaimableBytes: parseHumanSize(size),
+		})
+	}
+	return rows
+}
+
+// PruneResult is the outcome of pruning one target. Error is set when
+// that prune failed; the others still run.
+type PruneResult struct {
+	Target         string `json:"target"`
+	ReclaimedBytes int64  `json:"reclaimed_bytes"`
+	Output         string `json:"output"`
+	Error          string `json:"error,omitempty"`
+}
+
+// pruneCommands are the docker arguments that prune each target. Images
+// include every unused image, not only dangling ones, to match what
+// PrunePreview reports as reclaimable.
+var pruneCommands = map[string][]string{
+	PruneContainers: {"container", "prune", "-f"},
+	PruneImages:     {"image", "prune", "-a", "-f"},
+	PruneNetworks:   {"network", "prune", "-f"},
+	PruneBuildCache: {"builder", "prune", "-f"},
+	PruneVolumes:    {"volume", "prune", "-f"},
+}
+
+// PruneAll prunes containers, images, networks and the build cache, and
+// volumes only when includeVolumes is set, since their data cannot be
+// recovered.
+func (c *Client) PruneAll(ctx context.Context, includeVolumes bool) []PruneResult {
+	targets := []string{PruneContainers, PruneImages, PruneNetworks, PruneBuildCache}
+	if includeVolumes {
+		targets = append(targets, PruneVolumes)
+	}
+	results := make([]PruneResult, 0, len(targets))
+	for _, target := range targets {
+		output, err := c.exec.Run(ctx, "docker", pruneCommands[target]...)
+		result := PruneResult{Target: target, Output: output, ReclaimedBytes: parseReclaimed(output)}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// parseReclaimed finds the size in the "Total reclaimed space: 1.2GB" line
+// of docker's prune commands, or the "Total: 1.2GB" line of builder prune.
+func parseReclaimed(output string) int64 {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"Total reclaimed space:", "Total:"} {
+			if size, ok := strings.CutPrefix(line, prefix); ok {
+				return parseHumanSize(strings.TrimSpace(size))
+			}
+		}
+	}
+	return 0
+}
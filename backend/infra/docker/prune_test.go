@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pruneExecutor answers system df and network ls, and prints a reclaimed
+// total for every prune.
+type pruneExecutor struct {
+	commands []string
+}
+
+func (p *pruneExecutor) Run(_ context.Context, _ string, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	p.commands = append(p.commands, cmd)
+	switch {
+	case strings.HasPrefix(cmd, "system df"):
+		return `{"Active":"2","Reclaimable":"1.2GB (41%)","Size":"2.9GB","TotalCount":"5","Type":"Images"}` + "\n" +
+			`{"Active":"1","Reclaimable":"12kB (50%)","Size":"24kB","TotalCount":"3","Type":"Containers"}` + "\n" +
+			`{"Active":"1","Reclaimable":"300MB (75%)","Size":"400MB","TotalCount":"4","Type":"Local Volumes"}` + "\n" +
+			`{"Active":"0","Reclaimable":"0B","Size":"0B","TotalCount":"0","Type":"Build Cache"}` + "\n", nil
+	case strings.HasPrefix(cmd, "network ls"):
+		return "old-frontend\nold-backend\n", nil
+	case strings.HasPrefix(cmd, "builder prune"):
+		return "Total:\t2MB\n", nil
+	case strings.HasPrefix(cmd, "image prune"):
+		return "Deleted Images:\nuntagged: nginx:1.25\n\nTotal reclaimed space: 1.2GB\n", nil
+	}
+	return "Total reclaimed space: 1kB\n", nil
+}
+
+func (p *pruneExecutor) RunStream(context.Context, string, ...string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+func (p *pruneExecutor) Ping(context.Context) error { return nil }
+func (p *pruneExecutor) Host() string               { return "test" }
+
+func TestPrunePreviewEstimatesEveryTarget(t *testing.T) {
+	client := New(&pruneExecutor{})
+
+	preview, err := client.PrunePreview(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PruneEstimate{
+		{Target: PruneContainers, Count: 2, ReclaimableBytes: 12_000},
+		{Target: PruneImages, Count: 3, ReclaimableBytes: 1_200_000_000},
+		{Target: PruneNetworks, Count: 2, Items: []string{"old-frontend", "old-backend"}},
+		{Target: PruneBuildCache},
+		{Target: PruneVolumes, Count: 3, ReclaimableBytes: 300_000_000},
+	}
+	if len(preview) != len(want) {
+		t.Fatalf("expected %d targets, got %+v", len(want), preview)
+	}
+	for i := range want {
+		got := preview[i]
+		if got.Target != want[i].Target || got.Count != want[i].Count || got.ReclaimableBytes != want[i].ReclaimableBytes || len(got.Items) != len(want[i].Items) {
+			t.Errorf("target %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+}
+
+func TestPruneAllSkipsVolumesUnlessIncluded(t *testing.T) {
+	exec := &pruneExecutor{}
+	results := New(exec).PruneAll(context.Background(), false)
+	if len(results) != 4 || strings.Contains(strings.Join(exec.commands, "\n"), "volume") {
+		t.Fatalf("expected volumes to be left alone, ran %q", exec.commands)
+	}
+	if results[1].ReclaimedBytes != 1_200_000_000 || results[3].ReclaimedBytes != 2_000_000 {
+		t.Fatalf("unexpected reclaimed sizes %+v", results)
+	}
+
+	exec = &pruneExecutor{}
+	results = New(exec).PruneAll(context.Background(), true)
+	if len(results) != 5 || exec.commands[4] != "volume prune -f" {
+		t.Fatalf("expected volumes to be pruned last, ran %q", exec.commands)
+	}
+}
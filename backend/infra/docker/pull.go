@@ -0,0 +1,173 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImagePullStream starts docker pull and returns its output as it is
+// written. Closing the reader or cancelling ctx stops the pull. Over SSH
+// only stdout is streamed, so a failed pull may end without an error line;
+// PullProgress.Complete tells a finished pull from an aborted one.
+func (c *Client) ImagePullStream(ctx context.Context, name string) (io.ReadCloser, error) {
+	return c.exec.RunStream(ctx, "docker", "pull", name)
+}
+
+// LayerProgress is the pull state of one image layer. Percent weighs the
+// download as the first 70% and the extraction as the rest.
+type LayerProgress struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Current int64  `json:"current,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+	Percent int    `json:"percent"`
+}
+
+// Share of a layer's progress reached once it is downloaded.
+const pullDownloadShare = 70
+
+var (
+	// pullLayerLine matches "<layer id>: <status>" lines.
+	pullLayerLine = regexp.MustCompile(`^([0-9a-f]{12}): (.+)$`)
+	// pullBytes matches the "12.5MB/31.4MB" part of a progress line.
+	pullBytes = regexp.MustCompile(`([0-9.]+\s*[kMGT]?B)/([0-9.]+\s*[kMGT]?B)`)
+)
+
+// PullProgress folds docker pull output lines into per-layer progress. It
+// understands both plain output, where layers only change status, and the
+// progress-bar lines docker writes to a terminal.
+type PullProgress struct {
+	layers map[string]*LayerProgress
+	order  []string
+	// Digest and Status are taken from the closing "Digest:" and "Status:"
+	// lines; Status is set only once the pull has finished.
+	Digest string
+	Status string
+	// Error is the last error line docker printed, if any.
+	Error string
+}
+
+// NewPullProgress returns an empty tracker.
+func NewPullProgress() *PullProgress {
+	return &PullProgress{layers: map[string]*LayerProgress{}}
+}
+
+// Feed consumes one output line and returns the layer it updated, or nil
+// for lines that are not about a single layer.
+func (p *PullProgress) Feed(line string) *LayerProgress {
+	// A terminal redraws progress bars with carriage returns.
+	if i := strings.LastIndex(line, "\r"); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+		return nil
+	case strings.HasPrefix(line, "Digest: "):
+		p.Digest = strings.TrimPrefix(line, "Digest: ")
+		return nil
+	case strings.HasPrefix(line, "Status: "):
+		p.Status = strings.TrimPrefix(line, "Status: ")
+		return nil
+	case strings.HasPrefix(line, "Error"), strings.HasPrefix(line, "error"):
+		p.Error = line
+		return nil
+	}
+
+	m := pullLayerLine.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	layer, ok := p.layers[m[1]]
+	if !ok {
+		layer = &LayerProgress{ID: m[1]}
+		p.layers[m[1]] = layer
+		p.order = append(p.order, m[1])
+	}
+	status := m[2]
+	if i := strings.Index(status, " ["); i >= 0 {
+		status = status[:i]
+	}
+	layer.Status = strings.TrimSpace(status)
+	layer.Current, layer.Total = 0, 0
+	if b := pullBytes.FindStringSubmatch(m[2]); b != nil {
+		layer.Current, layer.Total = parseHumanSize(b[1]), parseHumanSize(b[2])
+	}
+	layer.Percent = layerPercent(layer)
+	return layer
+}
+
+// Layers returns every layer seen so far in first-seen order.
+func (p *PullProgress) Layers() []LayerProgress {
+	out := make([]LayerProgress, 0, len(p.order))
+	for _, id := range p.order {
+		out = append(out, *p.layers[id])
+	}
+	return out
+}
+
+// Percent is the mean progress of all layers seen so far: 100 once the
+// pull has finished, 0 before any layer was reported.
+func (p *PullProgress) Percent() int {
+	if p.Complete() {
+		return 100
+	}
+	if len(p.order) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, id := range p.order {
+		sum += p.layers[id].Percent
+	}
+	return sum / len(p.order)
+}
+
+// Complete reports whether docker printed the closing status line, which
+// it only does for a successful pull.
+func (p *PullProgress) Complete() bool {
+	return p.Status != ""
+}
+
+func layerPercent(layer *LayerProgress) int {
+	fraction := func() int {
+		if layer.Total <= 0 {
+			return 0
+		}
+		return int(min(layer.Current*100/layer.Total, 100))
+	}
+	switch layer.Status {
+	case "Downloading":
+		return fraction() * pullDownloadShare / 100
+	case "Verifying Checksum", "Download complete":
+		return pullDownloadShare
+	case "Extracting":
+		return pullDownloadShare + fraction()*(100-pullDownloadShare)/100
+	case "Pull complete", "Already exists":
+		return 100
+	}
+	return 0
+}
+
+// parseHumanSize parses docker's decimal human sizes such as "512B",
+// "1.2kB" or "31.4MB". Unparsable sizes count as 0.
+func parseHumanSize(s string) int64 {
+	s = strings.ReplaceAll(s, " ", "")
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		scale  int64
+	}{{"kB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n * float64(unit))
+}
@@ -0,0 +1,57 @@
+package docker
+
+import "testing"
+
+func TestPullProgressFoldsLayerLines(t *testing.T) {
+	p := NewPullProgress()
+	for _, line := range []string{
+		"latest: Pulling from library/nginx",
+		"a2abf6c4d29d: Already exists",
+		"a9edb18cadd1: Pulling fs layer",
+		"a9edb18cadd1: Downloading [=====>          ]  12.5MB/25MB",
+	} {
+		p.Feed(line)
+	}
+	layers := p.Layers()
+	if len(layers) != 2 || layers[0].Percent != 100 {
+		t.Fatalf("unexpected layers %+v", layers)
+	}
+	if got := layers[1]; got.Status != "Downloading" || got.Current != 12_500_000 || got.Total != 25_000_000 || got.Percent != 35 {
+		t.Fatalf("unexpected downloading layer %+v", got)
+	}
+	if got := p.Percent(); got != 67 {
+		t.Fatalf("expected overall 67%%, got %d", got)
+	}
+
+	// A terminal redraws the bar in place with carriage returns.
+	layer := p.Feed("a9edb18cadd1: Extracting [=>   ]  5MB/25MB\ra9edb18cadd1: Extracting [====>  ]  25MB/25MB")
+	if layer == nil || layer.Percent != 100 {
+		t.Fatalf("expected the last redraw to win, got %+v", layer)
+	}
+	if p.Complete() {
+		t.Fatal("expected the pull to be incomplete before the status line")
+	}
+	p.Feed("Digest: sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31")
+	p.Feed("Status: Downloaded newer image for nginx:latest")
+	if !p.Complete() || p.Percent() != 100 || p.Digest == "" {
+		t.Fatalf("expected a complete pull, got %+v", p)
+	}
+}
+
+func TestPullProgressRecordsErrors(t *testing.T) {
+	p := NewPullProgress()
+	if p.Feed("Error response from daemon: pull access denied for nope") != nil {
+		t.Fatal("expected no layer for an error line")
+	}
+	if p.Error == "" || p.Complete() {
+		t.Fatalf("expected a recorded error, got %+v", p)
+	}
+}
+
+func TestParsePullSize(t *testing.T) {
+	for in, want := range map[string]int64{"512B": 512, "1.5kB": 1500, "31.4MB": 31_400_000, "2GB": 2_000_000_000, "x": 0} {
+		if got := parseHumanSize(in); got != want {
+			t.Errorf("parseHumanSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrStdinUnsupported is returned when a registry login is attempted over an
+// executor that cannot pass the password on stdin.
+var ErrStdinUnsupported = errors.New("executor cannot pass input on stdin")
+
+// DockerHubRegistry is the registry of image references without a host.
+const DockerHubRegistry = "docker.io"
+
+// RegistryAuth is a login for a private registry.
+type RegistryAuth struct {
+	Registry string // host[:port], e.g. "ghcr.io" or "registry.local:5000"
+	Username string
+	Password string
+}
+
+var registryHostPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]{1,5})?$`)
+
+// Validate checks that auth is complete and its host cannot be taken for a
+// flag.
+func (auth RegistryAuth) Validate() error {
+	if !registryHostPattern.MatchString(auth.Registry) {
+		return fmt.Errorf("registry %q must be a host name with an optional port", auth.Registry)
+	}
+	if strings.TrimSpace(auth.Username) == "" || auth.Password == "" {
+		return errors.New("registry username and password are required")
+	}
+	if strings.ContainsAny(auth.Username, "\r\n\x00") {
+		return errors.New("registry username may not contain line breaks")
+	}
+	return nil
+}
+
+// ValidateFor validates auth and checks that image is pulled from its
+// registry.
+func (auth RegistryAuth) ValidateFor(image string) error {
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+	if registry := ImageRegistry(image); !sameRegistry(registry, auth.Registry) {
+		return fmt.Errorf("image %q is pulled from %s, not %s", image, registry, auth.Registry)
+	}
+	return nil
+}
+
+// ImageRegistry returns the registry host an image reference is pulled
+// from: its first path component when that looks like a host, otherwise
+// Docker Hub.
+func ImageRegistry(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host
+	}
+	return DockerHubRegistry
+}
+
+// sameRegistry reports whether two registry hosts name the same registry,
+// treating Docker Hub's aliases as one.
+func sameRegistry(a, b string) bool {
+	hub := func(host string) string {
+		switch host = strings.ToLower(host); host {
+		case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+			return DockerHubRegistry
+		}
+		return host
+	}
+	return hub(a) == hub(b)
+}
+
+// RegistryLogin logs into auth.Registry. The password is written to docker
+// login's stdin so it never appears in the command line.
+func (c *Client) RegistryLogin(ctx context.Context, auth RegistryAuth) (string, error) {
+	if err := auth.Validate(); err != nil {
+		return "", err
+	}
+	runner, ok := c.exec.(InputRunner)
+	if !ok {
+		return "", ErrStdinUnsupported
+	}
+	return runner.RunInput(ctx, auth.Password, "docker", "login", "--username="+auth.Username, "--password-stdin", auth.Registry)
+}
+
+// RegistryLogout removes the stored login for registry.
+func (c *Client) RegistryLogout(ctx context.Context, registry string) (string, error) {
+	if !registryHostPattern.MatchString(registry) {
+		return "", fmt.Errorf("registry %q must be a host name with an optional port", registry)
+	}
+	return c.exec.Run(ctx, "docker", "logout", registry)
+}
+
+// ImagePullWithAuth logs into auth.Registry, pulls name and logs out again,
+// whether or not the pull succeeded. name must be hosted on that registry.
+func (c *Client) ImagePullWithAuth(ctx context.Context, name string, auth RegistryAuth) (string, error) {
+	if err := auth.ValidateFor(name); err != nil {
+		return "", err
+	}
+	if _, err := c.RegistryLogin(ctx, auth); err != nil {
+		return "", fmt.Errorf("registry login: %w", err)
+	}
+	output, err := c.ImagePull(ctx, name)
+	// Log out even when the request was cancelled.
+	_, _ = c.RegistryLogout(context.WithoutCancel(ctx), auth.Registry)
+	return output, err
+}
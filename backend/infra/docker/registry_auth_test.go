@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// loginExecutor records commands and the stdin they were given.
+type loginExecutor struct {
+	commands []string
+	inputs   []string
+	pullErr  error
+}
+
+func (l *loginExecutor) Run(ctx context.Context, command string, args ...string) (string, error) {
+	return l.RunInput(ctx, "", command, args...)
+}
+
+func (l *loginExecutor) RunInput(_ context.Context, input string, _ string, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	l.commands = append(l.commands, cmd)
+	l.inputs = append(l.inputs, input)
+	if strings.HasPrefix(cmd, "pull ") {
+		return "", l.pullErr
+	}
+	return "", nil
+}
+
+func (l *loginExecutor) RunStream(context.Context, string, ...string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+func (l *loginExecutor) Ping(context.Context) error { return nil }
+func (l *loginExecutor) Host() string               { return "test" }
+
+func TestImagePullWithAuthPassesPasswordOnStdinAndLogsOut(t *testing.T) {
+	exec := &loginExecutor{pullErr: errors.New("manifest unknown")}
+	auth := RegistryAuth{Registry: "ghcr.io", Username: "deploy", Password: "s3cret"}
+
+	if _, err := New(exec).ImagePullWithAuth(context.Background(), "ghcr.io/team/app:1", auth); err == nil {
+		t.Fatal("expected the pull error")
+	}
+	want := []string{"login --username=deploy --password-stdin ghcr.io", "pull ghcr.io/team/app:1", "logout ghcr.io"}
+	if strings.Join(exec.commands, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %q, got %q", want, exec.commands)
+	}
+	if exec.inputs[0] != "s3cret" || strings.Contains(strings.Join(exec.commands, " "), "s3cret") {
+		t.Fatalf("expected the password on stdin only, got commands %q inputs %q", exec.commands, exec.inputs)
+	}
+}
+
+func TestRegistryAuthValidateFor(t *testing.T) {
+	hub := RegistryAuth{Registry: "index.docker.io", Username: "u", Password: "p"}
+	if err := hub.ValidateFor("team/app"); err != nil {
+		t.Fatalf("expected Docker Hub aliases to match, got %v", err)
+	}
+	if err := hub.ValidateFor("ghcr.io/team/app"); err == nil {
+		t.Fatal("expected an image from another registry to be rejected")
+	}
+	if err := (RegistryAuth{Registry: "--insecure", Username: "u", Password: "p"}).Validate(); err == nil {
+		t.Fatal("expected a flag-like registry to be rejected")
+	}
+	if got := ImageRegistry("localhost:5000/app"); got != "localhost:5000" {
+		t.Fatalf("expected localhost:5000, got %q", got)
+	}
+}
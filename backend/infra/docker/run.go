@@ -0,0 +1,240 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RunOptions is the structured configuration of a new container. Every
+// value is validated and passed to docker run as its own argument, so no
+// part of it is ever parsed by a shell or taken for another flag.
+type RunOptions struct {
+	Image   string            `json:"image"`
+	Name    string            `json:"name,omitempty"`
+	Ports   []PortMapping     `json:"ports,omitempty"`
+	Volumes []VolumeMount     `json:"volumes,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// Restart is a docker restart policy: "no", "always", "unless-stopped",
+	// "on-failure" or "on-failure:<max retries>". Empty keeps docker's default.
+	Restart string `json:"restart,omitempty"`
+	Network string `json:"network,omitempty"`
+}
+
+// PortMapping publishes a container port. HostPort 0 lets docker pick a
+// free port; an empty HostIP binds every interface.
+type PortMapping struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      int    `json:"host_port,omitempty"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol,omitempty"` // tcp (default), udp or sctp
+}
+
+// VolumeMount mounts a host path or a named volume into the container.
+type VolumeMount struct {
+	Source   string `json:"source"` // absolute host path or volume name
+	Target   string `json:"target"` // absolute path inside the container
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// Limits that keep a single run request reasonable.
+const (
+	runMaxPorts   = 64
+	runMaxVolumes = 64
+	runMaxEnv     = 256
+)
+
+var (
+	// runImagePattern accepts references such as "nginx", "nginx:1.27",
+	// "registry:5000/team/app:v1" and "app@sha256:<digest>".
+	runImagePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/:@-]{0,254}$`)
+	// runNamePattern is docker's own rule for container, network and
+	// volume names.
+	runNamePattern    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,127}$`)
+	runEnvKeyPattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+	runRestartPattern = regexp.MustCompile(`^(no|always|unless-stopped|on-failure(:[0-9]{1,4})?)$`)
+)
+
+// ValidateImageRef rejects anything but a plain image reference or ID, so
+// the value cannot be read as a command-line flag.
+func ValidateImageRef(ref string) error {
+	if !runImagePattern.MatchString(ref) {
+		return fmt.Errorf("image %q is not a valid image reference", ref)
+	}
+	return nil
+}
+
+// Validate reports the first invalid value of opts.
+func (opts RunOptions) Validate() error {
+	if opts.Image == "" {
+		return errors.New("image is required")
+	}
+	if err := ValidateImageRef(opts.Image); err != nil {
+		return err
+	}
+	if opts.Name != "" && !runNamePattern.MatchString(opts.Name) {
+		return fmt.Errorf("name %q may only contain letters, digits, '_', '.' and '-' and must start with a letter or digit", opts.Name)
+	}
+	if len(opts.Ports) > runMaxPorts {
+		return fmt.Errorf("at most %d ports can be published", runMaxPorts)
+	}
+	for i, p := range opts.Ports {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("ports[%d]: %w", i, err)
+		}
+	}
+	if len(opts.Volumes) > runMaxVolumes {
+		return fmt.Errorf("at most %d volumes can be mounted", runMaxVolumes)
+	}
+	for i, v := range opts.Volumes {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("volumes[%d]: %w", i, err)
+		}
+	}
+	if len(opts.Env) > runMaxEnv {
+		return fmt.Errorf("at most %d environment variables can be set", runMaxEnv)
+	}
+	for key, value := range opts.Env {
+		if !runEnvKeyPattern.MatchString(key) {
+			return fmt.Errorf("env key %q is not a valid variable name", key)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("env %s contains a NUL byte", key)
+		}
+	}
+	if opts.Restart != "" && !runRestartPattern.MatchString(opts.Restart) {
+		return fmt.Errorf("restart %q must be no, always, unless-stopped or on-failure[:max-retries]", opts.Restart)
+	}
+	if opts.Network != "" && !runNamePattern.MatchString(opts.Network) {
+		return fmt.Errorf("network %q is not a valid network name", opts.Network)
+	}
+	return nil
+}
+
+func (p PortMapping) validate() error {
+	if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+		return errors.New("container_port must be between 1 and 65535")
+	}
+	if p.HostPort < 0 || p.HostPort > 65535 {
+		return errors.New("host_port must be between 0 and 65535")
+	}
+	switch p.Protocol {
+	case "", "tcp", "udp", "sctp":
+	default:
+		return fmt.Errorf("protocol %q must be tcp, udp or sctp", p.Protocol)
+	}
+	if p.HostIP != "" && net.ParseIP(p.HostIP) == nil {
+		return fmt.Errorf("host_ip %q is not an IP address", p.HostIP)
+	}
+	return nil
+}
+
+func (v VolumeMount) validate() error {
+	if v.Source == "" || v.Target == "" {
+		return errors.New("source and target are required")
+	}
+	// ':' and ',' separate the parts of a mount specification.
+	if strings.ContainsAny(v.Source, ":,\x00") || strings.ContainsAny(v.Target, ":,\x00") {
+		return errors.New("source and target may not contain ':', ',' or NUL")
+	}
+	if !path.IsAbs(v.Source) && !runNamePattern.MatchString(v.Source) {
+		return fmt.Errorf("source %q must be an absolute host path or a volume name", v.Source)
+	}
+	if !path.IsAbs(v.Target) {
+		return fmt.Errorf("target %q must be an absolute path", v.Target)
+	}
+	return nil
+}
+
+// runArgs builds the docker run arguments for validated opts. Values are
+// attached to their flags with "=" and the image reference never starts
+// with '-', so nothing can be mistaken for an option.
+func runArgs(opts RunOptions) []string {
+	args := []string{"run", "--detach"}
+	if opts.Name != "" {
+		args = append(args, "--name="+opts.Name)
+	}
+	for _, p := range opts.Ports {
+		args = append(args, "--publish="+p.spec())
+	}
+	for _, v := range opts.Volumes {
+		spec := v.Source + ":" + v.Target
+		if v.ReadOnly {
+			spec += ":ro"
+		}
+		args = append(args, "--volume="+spec)
+	}
+	keys := make([]string, 0, len(opts.Env))
+	for key := range opts.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--env="+key+"="+opts.Env[key])
+	}
+	if opts.Restart != "" {
+		args = append(args, "--restart="+opts.Restart)
+	}
+	if opts.Network != "" {
+		args = append(args, "--network="+opts.Network)
+	}
+	return append(args, opts.Image)
+}
+
+// RedactedArgs returns the docker run arguments for opts with every
+// environment value masked, for audit logs.
+func (opts RunOptions) RedactedArgs() []string {
+	masked := opts
+	masked.Env = make(map[string]string, len(opts.Env))
+	for key := range opts.Env {
+		masked.Env[key] = "***"
+	}
+	return runArgs(masked)
+}
+
+// spec renders p as [host_ip:][host_port:]container_port/protocol.
+func (p PortMapping) spec() string {
+	protocol := p.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	host := ""
+	if p.HostPort > 0 {
+		host = strconv.Itoa(p.HostPort)
+	}
+	if p.HostIP != "" {
+		ip := p.HostIP
+		if strings.Contains(ip, ":") {
+			ip = "[" + ip + "]"
+		}
+		host = ip + ":" + host
+	}
+	if host != "" {
+		host += ":"
+	}
+	return host + strconv.Itoa(p.ContainerPort) + "/" + protocol
+}
+
+// ContainerRun validates opts, creates and starts the container in the
+// background and returns its ID.
+func (c *Client) ContainerRun(ctx context.Context, opts RunOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	output, err := c.exec.Run(ctx, "docker", runArgs(opts)...)
+	if err != nil {
+		return "", err
+	}
+	// docker run -d prints the container ID last, after any pull output.
+	lines := strings.Fields(output)
+	if len(lines) == 0 {
+		return "", errors.New("docker run did not print a container id")
+	}
+	return lines[len(lines)-1], nil
+}
@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunArgsBuildsOneArgumentPerValue(t *testing.T) {
+	opts := RunOptions{
+		Image: "nginx:1.27",
+		Name:  "web",
+		Ports: []PortMapping{
+			{HostPort: 8080, ContainerPort: 80},
+			{HostIP: "127.0.0.1", ContainerPort: 53, Protocol: "udp"},
+			{HostIP: "::1", HostPort: 8443, ContainerPort: 443},
+		},
+		Volumes: []VolumeMount{
+			{Source: "/srv/www", Target: "/usr/share/nginx/html", ReadOnly: true},
+			{Source: "cache", Target: "/var/cache/nginx"},
+		},
+		Env:     map[string]string{"B": "two words; rm -rf /", "A": "1"},
+		Restart: "on-failure:3",
+		Network: "frontend",
+	}
+	if err := opts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"run", "--detach", "--name=web",
+		"--publish=8080:80/tcp", "--publish=127.0.0.1::53/udp", "--publish=[::1]:8443:443/tcp",
+		"--volume=/srv/www:/usr/share/nginx/html:ro", "--volume=cache:/var/cache/nginx",
+		"--env=A=1", "--env=B=two words; rm -rf /",
+		"--restart=on-failure:3", "--network=frontend",
+		"nginx:1.27",
+	}
+	if got := runArgs(opts); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args\n got %q\nwant %q", got, want)
+	}
+	if got := strings.Join(opts.RedactedArgs(), " "); strings.Contains(got, "rm -rf") || !strings.Contains(got, "--env=B=***") {
+		t.Fatalf("expected masked env values, got %q", got)
+	}
+}
+
+func TestRunOptionsValidateRejectsUnsafeValues(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts RunOptions
+		want string
+	}{
+		"missing image":   {RunOptions{}, "image is required"},
+		"flag image":      {RunOptions{Image: "--privileged"}, "image"},
+		"flag name":       {RunOptions{Image: "nginx", Name: "-x"}, "name"},
+		"port range":      {RunOptions{Image: "nginx", Ports: []PortMapping{{ContainerPort: 70000}}}, "ports[0]"},
+		"port protocol":   {RunOptions{Image: "nginx", Ports: []PortMapping{{ContainerPort: 80, Protocol: "icmp"}}}, "protocol"},
+		"host ip":         {RunOptions{Image: "nginx", Ports: []PortMapping{{HostIP: "localhost", ContainerPort: 80}}}, "host_ip"},
+		"volume options":  {RunOptions{Image: "nginx", Volumes: []VolumeMount{{Source: "/", Target: "/host:rw"}}}, "volumes[0]"},
+		"relative target": {RunOptions{Image: "nginx", Volumes: []VolumeMount{{Source: "data", Target: "data"}}}, "target"},
+		"env key":         {RunOptions{Image: "nginx", Env: map[string]string{"A=B": "c"}}, "env key"},
+		"restart":         {RunOptions{Image: "nginx", Restart: "sometimes"}, "restart"},
+		"network":         {RunOptions{Image: "nginx", Network: "--net=host"}, "network"},
+	} {
+		err := tc.opts.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error mentioning %q, got %v", name, tc.want, err)
+		}
+	}
+}
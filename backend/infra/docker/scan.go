@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity levels reported by trivy, highest first.
+var scanSeverities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+// ScanReport is the subset of a trivy JSON report (`trivy image --format
+// json`) the scan summary is built from. Integration endpoints return the
+// same shape.
+type ScanReport struct {
+	ArtifactName string       `json:"ArtifactName"`
+	Results      []ScanResult `json:"Results"`
+}
+
+// ScanResult is one scanned target (OS packages, a language lockfile, ...).
+type ScanResult struct {
+	Target          string              `json:"Target"`
+	Vulnerabilities []ScanVulnerability `json:"Vulnerabilities"`
+}
+
+// ScanVulnerability is one finding in a ScanResult.
+type ScanVulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	Severity         string `json:"Severity"`
+	Title            string `json:"Title"`
+	PrimaryURL       string `json:"PrimaryURL"`
+}
+
+// ScanSummary counts findings by severity and lists the most severe ones.
+type ScanSummary struct {
+	Image    string              `json:"image"`
+	Total    int                 `json:"total"`
+	Critical int                 `json:"critical"`
+	High     int                 `json:"high"`
+	Medium   int                 `json:"medium"`
+	Low      int                 `json:"low"`
+	Unknown  int                 `json:"unknown"`
+	Top      []ScanVulnerability `json:"top"`
+}
+
+// ImageScan runs trivy against ref on the executor's host and returns its
+// raw JSON report. trivy must be installed there; it reads the image from
+// the local Docker daemon when present and pulls it otherwise. ref must pass
+// ValidateImageRef.
+func (c *Client) ImageScan(ctx context.Context, ref string) (string, error) {
+	if err := ValidateImageRef(ref); err != nil {
+		return "", err
+	}
+	return c.exec.Run(ctx, "trivy", "image", "--format", "json", "--quiet", "--scanners", "vuln", "--", ref)
+}
+
+// ParseScanReport decodes a trivy JSON report.
+func ParseScanReport(raw []byte) (ScanReport, error) {
+	var report ScanReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return ScanReport{}, fmt.Errorf("parse scan report: %w", err)
+	}
+	return report, nil
+}
+
+// Summarize counts the report's findings by severity and keeps the top most
+// severe ones, ordered by severity and then ID. A CVE reported for several
+// packages counts once per package, matching trivy's own totals.
+func (r ScanReport) Summarize(top int) ScanSummary {
+	summary := ScanSummary{Image: r.ArtifactName, Top: []ScanVulnerability{}}
+	var all []ScanVulnerability
+	for _, result := range r.Results {
+		for _, v := range result.Vulnerabilities {
+			v.Severity = strings.ToUpper(v.Severity)
+			switch v.Severity {
+			case "CRITICAL":
+				summary.Critical++
+			case "HIGH":
+				summary.High++
+			case "MEDIUM":
+				summary.Medium++
+			case "LOW":
+				summary.Low++
+			default:
+				v.Severity = "UNKNOWN"
+				summary.Unknown++
+			}
+			summary.Total++
+			all = append(all, v)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		ri, rj := severityRank(all[i].Severity), severityRank(all[j].Severity)
+		if ri != rj {
+			return ri < rj
+		}
+		return all[i].VulnerabilityID < all[j].VulnerabilityID
+	})
+	if top > len(all) {
+		top = len(all)
+	}
+	if top > 0 {
+		summary.Top = append(summary.Top, all[:top]...)
+	}
+	return summary
+}
+
+func severityRank(severity string) int {
+	for i, s := range scanSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(scanSeverities)
+}
@@ -0,0 +1,52 @@
+package docker
+
+import "testing"
+
+func TestScanReportSummarizeCountsAndOrdersBySeverity(t *testing.T) {
+	raw := `{"ArtifactName":"nginx:1.27","Results":[
+		{"Target":"nginx:1.27 (debian 12)","Vulnerabilities":[
+			{"VulnerabilityID":"CVE-2024-0002","PkgName":"libc6","Severity":"MEDIUM"},
+			{"VulnerabilityID":"CVE-2024-0003","PkgName":"openssl","Severity":"CRITICAL","FixedVersion":"3.0.14"},
+			{"VulnerabilityID":"CVE-2024-0001","PkgName":"zlib","Severity":"HIGH"}
+		]},
+		{"Target":"app/package-lock.json","Vulnerabilities":[
+			{"VulnerabilityID":"GHSA-xxxx","PkgName":"lodash","Severity":"low"},
+			{"VulnerabilityID":"CVE-2023-9999","PkgName":"left-pad","Severity":"weird"},
+			{"VulnerabilityID":"CVE-2023-0001","PkgName":"express","Severity":"HIGH"}
+		]},
+		{"Target":"usr/bin/app"}
+	]}`
+
+	report, err := ParseScanReport([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := report.Summarize(3)
+	if summary.Image != "nginx:1.27" || summary.Total != 6 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summary.Critical != 1 || summary.High != 2 || summary.Medium != 1 || summary.Low != 1 || summary.Unknown != 1 {
+		t.Fatalf("unexpected counts %+v", summary)
+	}
+	want := []string{"CVE-2024-0003", "CVE-2023-0001", "CVE-2024-0001"}
+	if len(summary.Top) != len(want) {
+		t.Fatalf("expected %d top findings, got %+v", len(want), summary.Top)
+	}
+	for i, id := range want {
+		if summary.Top[i].VulnerabilityID != id {
+			t.Fatalf("top[%d] = %s, want %s", i, summary.Top[i].VulnerabilityID, id)
+		}
+	}
+
+	clean, err := ParseScanReport([]byte(`{"ArtifactName":"alpine:3.20","Results":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := clean.Summarize(10); s.Total != 0 || s.Top == nil || len(s.Top) != 0 {
+		t.Fatalf("expected empty non-nil top list, got %#v", s)
+	}
+
+	if _, err := ParseScanReport([]byte("FATAL: image not found")); err == nil {
+		t.Fatal("expected parse error for non-JSON output")
+	}
+}
@@ -3,6 +3,7 @@ package docker
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,8 @@ import (
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/websoft9/appos/backend/infra/sshconfig"
 )
 
 // SSHConfig holds connection parameters for an SSH executor.
@@ -22,14 +25,36 @@ type SSHConfig struct {
 	AuthType string // "password" or "private_key" (also accepts "key", "ssh_key")
 	Secret   string // decrypted: password string or PEM private key
 
-	// SudoEnabled wraps every command with `sudo` when the remote user is not root.
+	// SudoEnabled wraps every command with `sudo` (or `doas`) when the remote user is not root.
 	SudoEnabled bool
 
 	// SudoPassword is the password for `sudo -S`. Empty means passwordless sudo (NOPASSWD).
 	// For password-based auth it defaults to the SSH password credential.
 	SudoPassword string
+
+	// Doas makes SudoEnabled wrap commands with `doas -n` instead of sudo.
+	Doas bool
+
+	// ProxyJump lists intermediate SSH hosts to tunnel through, in order.
+	ProxyJump []sshconfig.Hop
+
+	// LegacySSH enables the weak SSH algorithms old servers still require.
+	LegacySSH bool
+
+	// DialTimeout bounds the TCP connect and SSH handshake. Zero means
+	// DefaultSSHDialTimeout.
+	DialTimeout time.Duration
+	// CommandTimeout bounds each Run call. Zero means no limit beyond the
+	// caller's context.
+	CommandTimeout time.Duration
+	// RetryDial retries a failed connect once. Commands themselves are never
+	// retried since they may not be idempotent.
+	RetryDial bool
 }
 
+// DefaultSSHDialTimeout is the connect timeout used when SSHConfig.DialTimeout is zero.
+const DefaultSSHDialTimeout = 10 * time.Second
+
 // SSHExecutor runs commands on a remote host over SSH.
 type SSHExecutor struct {
 	cfg SSHConfig
@@ -40,9 +65,22 @@ func NewSSHExecutor(cfg SSHConfig) *SSHExecutor {
 	if cfg.Port == 0 {
 		cfg.Port = 22
 	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultSSHDialTimeout
+	}
 	return &SSHExecutor{cfg: cfg}
 }
 
+// DialTimeout returns the connect timeout in effect.
+func (e *SSHExecutor) DialTimeout() time.Duration {
+	return e.cfg.DialTimeout
+}
+
+// CommandTimeout returns the per-command timeout in effect (zero = none).
+func (e *SSHExecutor) CommandTimeout() time.Duration {
+	return e.cfg.CommandTimeout
+}
+
 func (e *SSHExecutor) clientConfig() (*ssh.ClientConfig, error) {
 	var authMethods []ssh.AuthMethod
 
@@ -63,12 +101,16 @@ func (e *SSHExecutor) clientConfig() (*ssh.ClientConfig, error) {
 		return nil, err
 	}
 
-	return &ssh.ClientConfig{
+	cfg := &ssh.ClientConfig{
 		User:            e.cfg.User,
 		Auth:            authMethods,
 		HostKeyCallback: hostKeyCallback,
-		Timeout:         10 * time.Second,
-	}, nil
+		Timeout:         e.cfg.DialTimeout,
+	}
+	if e.cfg.LegacySSH {
+		sshconfig.EnableLegacyAlgorithms(cfg)
+	}
+	return cfg, nil
 }
 
 func (e *SSHExecutor) dial() (*ssh.Client, error) {
@@ -77,11 +119,28 @@ func (e *SSHExecutor) dial() (*ssh.Client, error) {
 		return nil, err
 	}
 	addr := fmt.Sprintf("%s:%d", e.cfg.Host, e.cfg.Port)
-	return ssh.Dial("tcp", addr, cfg)
+	client, err := sshconfig.Dial(addr, cfg, e.cfg.ProxyJump)
+	if err != nil && e.cfg.RetryDial {
+		client, err = sshconfig.Dial(addr, cfg, e.cfg.ProxyJump)
+	}
+	return client, err
 }
 
 // Run executes a command on the remote host and returns buffered stdout.
 func (e *SSHExecutor) Run(ctx context.Context, command string, args ...string) (string, error) {
+	return e.RunInput(ctx, "", command, args...)
+}
+
+// RunInput executes a command on the remote host with input on its stdin
+// and returns buffered stdout. With sudo -S the password line is written
+// first.
+func (e *SSHExecutor) RunInput(ctx context.Context, input string, command string, args ...string) (string, error) {
+	if e.cfg.CommandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.cfg.CommandTimeout)
+		defer cancel()
+	}
+
 	client, err := e.dial()
 	if err != nil {
 		return "", fmt.Errorf("ssh connect to %s: %w", e.cfg.Host, err)
@@ -94,16 +153,9 @@ func (e *SSHExecutor) Run(ctx context.Context, command string, args ...string) (
 	}
 	defer session.Close()
 
-	cmd := buildShellCommand(command, args...)
-	if e.cfg.SudoEnabled {
-		if e.cfg.SudoPassword != "" {
-			// -S: read password from stdin; -p '': suppress prompt text
-			session.Stdin = strings.NewReader(e.cfg.SudoPassword + "\n")
-			cmd = "sudo -S -p '' -- " + cmd
-		} else {
-			// Passwordless sudo (-n: non-interactive, fail if password needed)
-			cmd = "sudo -n -- " + cmd
-		}
+	cmd, stdin := e.privileged(buildShellCommand(command, args...))
+	if stdin += input; stdin != "" {
+		session.Stdin = strings.NewReader(stdin)
 	}
 
 	var stdout, stderr bytes.Buffer
@@ -117,6 +169,9 @@ func (e *SSHExecutor) Run(ctx context.Context, command string, args ...string) (
 	select {
 	case <-ctx.Done():
 		_ = client.Close()
+		if e.cfg.CommandTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("command timed out after %s: %w", e.cfg.CommandTimeout, ctx.Err())
+		}
 		return "", ctx.Err()
 	case err = <-done:
 		if err != nil {
@@ -127,7 +182,9 @@ func (e *SSHExecutor) Run(ctx context.Context, command string, args ...string) (
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// RunStream executes a command and returns a streaming reader for stdout.
+// RunStream executes a command and returns a streaming reader for its
+// combined stdout and stderr. The session's stdin stays open for the life of
+// the stream; see streamWatchdog.
 func (e *SSHExecutor) RunStream(ctx context.Context, command string, args ...string) (io.ReadCloser, error) {
 	client, err := e.dial()
 	if err != nil {
@@ -140,18 +197,14 @@ func (e *SSHExecutor) RunStream(ctx context.Context, command string, args ...str
 		return nil, fmt.Errorf("ssh session: %w", err)
 	}
 
-	cmd := buildShellCommand(command, args...)
-	if e.cfg.SudoEnabled {
-		if e.cfg.SudoPassword != "" {
-			// -S: read password from stdin; -p '': suppress prompt text
-			session.Stdin = strings.NewReader(e.cfg.SudoPassword + "\n")
-			cmd = "sudo -S -p '' -- " + cmd
-		} else {
-			// Passwordless sudo (-n: non-interactive, fail if password needed)
-			cmd = "sudo -n -- " + cmd
-		}
-	}
+	cmd, password := e.privileged(streamWatchdog(buildShellCommand(command, args...)))
 
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		client.Close()
+		return nil, err
+	}
 	stdout, err := session.StdoutPipe()
 	if err != nil {
 		session.Close()
@@ -164,11 +217,19 @@ func (e *SSHExecutor) RunStream(ctx context.Context, command string, args ...str
 		client.Close()
 		return nil, err
 	}
+	if password != "" {
+		if _, err := io.WriteString(stdin, password); err != nil {
+			session.Close()
+			client.Close()
+			return nil, err
+		}
+	}
 
 	// Watch context: if cancelled, forcefully close the connection so Read() unblocks.
 	watchCtx, cancel := context.WithCancel(ctx)
 	rc := &sshReadCloser{
 		ReadCloser: io.NopCloser(stdout),
+		stdin:      stdin,
 		session:    session,
 		client:     client,
 		cancel:     cancel,
@@ -194,6 +255,8 @@ func (e *SSHExecutor) Host() string {
 // sshReadCloser wraps an SSH stdout pipe and closes session+client when done.
 type sshReadCloser struct {
 	io.ReadCloser
+	// stdin is closed first so the remote watchdog stops the command.
+	stdin   io.WriteCloser
 	session *ssh.Session
 	client  *ssh.Client
 	cancel  context.CancelFunc // stops the ctx-watcher goroutine
@@ -201,12 +264,43 @@ type sshReadCloser struct {
 
 func (r *sshReadCloser) Close() error {
 	r.cancel() // stop context-watcher goroutine first
+	_ = r.stdin.Close()
 	err := r.ReadCloser.Close()
 	_ = r.session.Close()
 	_ = r.client.Close()
 	return err
 }
 
+// privileged wraps cmd for SudoEnabled. For sudo with a password it also
+// returns the line to send on stdin.
+func (e *SSHExecutor) privileged(cmd string) (string, string) {
+	switch {
+	case !e.cfg.SudoEnabled:
+		return cmd, ""
+	case e.cfg.Doas:
+		// doas has no stdin password mode; -n fails instead of prompting
+		return "doas -n -- " + cmd, ""
+	case e.cfg.SudoPassword != "":
+		// -S: read password from stdin; -p '': suppress prompt text
+		return "sudo -S -p '' -- " + cmd, e.cfg.SudoPassword + "\n"
+	default:
+		// Passwordless sudo (-n: non-interactive, fail if password needed)
+		return "sudo -n -- " + cmd, ""
+	}
+}
+
+// streamWatchdog runs cmd in the background with stderr merged into stdout
+// and kills it once stdin reaches EOF. sshd does not signal commands that run
+// without a PTY, so this is what stops `docker logs -f` and similar when the
+// stream is closed or the connection drops. The script exits with cmd's
+// status when cmd ends on its own.
+func streamWatchdog(cmd string) string {
+	script := "exec 3<&0; " + cmd + " </dev/null 2>&1 & p=$!; " +
+		"(cat <&3 >/dev/null 2>&1; kill $p 2>/dev/null) & w=$!; exec 3<&-; " +
+		"wait $p; s=$?; kill $w 2>/dev/null; exit $s"
+	return "sh -c " + shellQuote(script)
+}
+
 func buildShellCommand(command string, args ...string) string {
 	parts := make([]string, 0, len(args)+1)
 	parts = append(parts, shellQuote(command))
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/knownhosts"
@@ -63,3 +64,15 @@ func TestResolveHostKeyCallbackUsesConfiguredKnownHosts(t *testing.T) {
 		t.Fatal("expected non-nil host key callback")
 	}
 }
+
+func TestNewSSHExecutorDefaultsDialTimeout(t *testing.T) {
+	exec := NewSSHExecutor(SSHConfig{Host: "example.test"})
+	if exec.DialTimeout() != DefaultSSHDialTimeout {
+		t.Fatalf("expected default dial timeout, got %s", exec.DialTimeout())
+	}
+
+	exec = NewSSHExecutor(SSHConfig{Host: "example.test", DialTimeout: 45 * time.Second, CommandTimeout: time.Minute})
+	if exec.DialTimeout() != 45*time.Second || exec.CommandTimeout() != time.Minute {
+		t.Fatalf("unexpected timeouts: dial=%s command=%s", exec.DialTimeout(), exec.CommandTimeout())
+	}
+}
@@ -3,6 +3,8 @@
 package fileutil
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
 	"io/fs"
@@ -133,3 +135,44 @@ func CopyDir(src, dst string) error {
 		return CopyFile(path, target)
 	})
 }
+
+// HashDir returns a hex SHA-256 over the regular files under root: each
+// file's slash-separated relative path and content, in lexical order. Files
+// for which skip returns true are left out. Empty directories, modes and
+// timestamps do not affect the result.
+func HashDir(root string, skip func(rel string) bool) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if skip != nil && skip(rel) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fh := sha256.New()
+		if _, err := io.Copy(fh, f); err != nil {
+			return err
+		}
+		_, _ = io.WriteString(h, rel)
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write(fh.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -130,3 +130,30 @@ func TestCopyDir(t *testing.T) {
 		}
 	}
 }
+
+func TestHashDir(t *testing.T) {
+	src := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o600)
+	_ = os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0o600)
+
+	dst := t.TempDir()
+	if err := fileutil.CopyDir(src, dst); err != nil {
+		t.Fatalf("CopyDir: %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(dst, ".origin"), []byte("meta"), 0o600)
+	skip := func(rel string) bool { return rel == ".origin" }
+
+	srcHash, err := fileutil.HashDir(src, skip)
+	if err != nil {
+		t.Fatalf("HashDir: %v", err)
+	}
+	if dstHash, _ := fileutil.HashDir(dst, skip); dstHash != srcHash {
+		t.Errorf("copy hash %s differs from source %s", dstHash, srcHash)
+	}
+
+	_ = os.WriteFile(filepath.Join(dst, "sub", "b.txt"), []byte("changed"), 0o600)
+	if dstHash, _ := fileutil.HashDir(dst, skip); dstHash == srcHash {
+		t.Error("expected hash to change after editing a file")
+	}
+}
@@ -0,0 +1,187 @@
+// Package logfile provides a size-rotated log file writer. The active file
+// keeps its configured name; full files are renamed with a timestamp suffix
+// and old backups are pruned by age and count.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is appended to rotated file names. It sorts
+// lexicographically in time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Options configures a Writer. Zero values disable the matching limit.
+type Options struct {
+	Path       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+}
+
+// Writer is an io.WriteCloser that appends to Options.Path and rotates it
+// once it would exceed MaxSizeMB. It is safe for concurrent use.
+type Writer struct {
+	opts Options
+	now  func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open creates the log directory if needed and opens Options.Path for
+// appending.
+func Open(opts Options) (*Writer, error) {
+	if strings.TrimSpace(opts.Path) == "" {
+		return nil, fmt.Errorf("logfile: path is required")
+	}
+	w := &Writer{opts: opts, now: time.Now}
+	if err := w.openExisting(); err != nil {
+		return nil, err
+	}
+	w.prune()
+	return w, nil
+}
+
+// Write appends p, rotating first when p would push the file past the size
+// limit. A single write larger than the limit still goes to one file.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+	if limit := w.maxBytes(); limit > 0 && w.size > 0 && w.size+int64(len(p)) > limit {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the active file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *Writer) maxBytes() int64 {
+	return int64(w.opts.MaxSizeMB) * 1024 * 1024
+}
+
+func (w *Writer) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(w.opts.Path), 0o755); err != nil {
+		return fmt.Errorf("logfile: create directory: %w", err)
+	}
+	f, err := os.OpenFile(w.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("logfile: open: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("logfile: stat: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("logfile: close: %w", err)
+	}
+	w.file = nil
+	if err := os.Rename(w.opts.Path, w.backupName(w.now())); err != nil {
+		return fmt.Errorf("logfile: rotate: %w", err)
+	}
+	if err := w.openExisting(); err != nil {
+		return err
+	}
+	w.prune()
+	return nil
+}
+
+// backupName turns /var/log/appos.log into /var/log/appos-<time>.log.
+func (w *Writer) backupName(t time.Time) string {
+	dir := filepath.Dir(w.opts.Path)
+	base := filepath.Base(w.opts.Path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext)
+	return filepath.Join(dir, prefix+"-"+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+// Backups returns the rotated files of the writer, newest first.
+func (w *Writer) Backups() []string {
+	dir := filepath.Dir(w.opts.Path)
+	base := filepath.Base(w.opts.Path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		if w.rotatedAt(name).IsZero() {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups
+}
+
+// rotatedAt parses the rotation time from a backup's name; it is zero for
+// names that are not backups of this writer.
+func (w *Writer) rotatedAt(path string) time.Time {
+	base := filepath.Base(w.opts.Path)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), strings.TrimSuffix(base, ext)+"-"), ext)
+	t, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// prune removes backups beyond MaxBackups or older than MaxAgeDays.
+// Failures are ignored: a leftover backup must never stop logging.
+func (w *Writer) prune() {
+	cutoff := time.Time{}
+	if w.opts.MaxAgeDays > 0 {
+		cutoff = w.now().Add(-time.Duration(w.opts.MaxAgeDays) * 24 * time.Hour)
+	}
+	for i, path := range w.Backups() {
+		if w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups {
+			_ = os.Remove(path)
+			continue
+		}
+		if !cutoff.IsZero() && w.rotatedAt(path).Before(cutoff) {
+			_ = os.Remove(path)
+		}
+	}
+}
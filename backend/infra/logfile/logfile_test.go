@@ -0,0 +1,72 @@
+package logfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriterRotatesBySizeAndKeepsMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "appos.log")
+	w, err := Open(Options{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups := w.Backups()
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v", backups)
+	}
+	if filepath.Base(backups[0]) != "appos-2026-01-01T00-00-04.000.log" {
+		t.Fatalf("expected newest backup first, got %v", backups)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != int64(len(chunk)) {
+		t.Fatalf("expected active file with one chunk, got %v %v", info, err)
+	}
+}
+
+func TestOpenPrunesBackupsByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "appos.log")
+	old := filepath.Join(dir, "appos-"+time.Now().Add(-72*time.Hour).UTC().Format(backupTimeFormat)+".log")
+	recent := filepath.Join(dir, "appos-"+time.Now().Add(-time.Hour).UTC().Format(backupTimeFormat)+".log")
+	unrelated := filepath.Join(dir, "appos-notes.log")
+	for _, p := range []string{old, recent, unrelated} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := Open(Options{Path: path, MaxAgeDays: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatal("expected backup older than MaxAgeDays to be removed")
+	}
+	for _, p := range []string{recent, unrelated} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected %s to be kept: %v", filepath.Base(p), err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds servers.ssh_config_host: an optional ~/.ssh/config Host alias whose
+// HostName, User, Port, IdentityFile and ProxyJump are used to connect.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("ssh_config_host") == nil {
+			col.Fields.Add(&core.TextField{Name: "ssh_config_host", Max: 255})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return nil
+		}
+
+		field := col.Fields.GetByName("ssh_config_host")
+		if field != nil {
+			col.Fields.RemoveById(field.GetId())
+		}
+
+		return app.Save(col)
+	})
+}
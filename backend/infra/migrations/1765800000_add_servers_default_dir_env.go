@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds servers.default_dir and servers.env: the working directory and
+// environment variables applied when a terminal session starts. SFTP uses
+// default_dir as the initial listing path.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("default_dir") == nil {
+			col.Fields.Add(&core.TextField{Name: "default_dir", Max: 1024})
+		}
+		if col.Fields.GetByName("env") == nil {
+			col.Fields.Add(&core.JSONField{Name: "env", MaxSize: 64 * 1024})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return nil
+		}
+
+		for _, name := range []string{"default_dir", "env"} {
+			if field := col.Fields.GetByName(name); field != nil {
+				col.Fields.RemoveById(field.GetId())
+			}
+		}
+
+		return app.Save(col)
+	})
+}
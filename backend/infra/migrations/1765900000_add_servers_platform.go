@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds servers.platform and servers.platform_detected_at: the OS, version,
+// architecture and init system detected over SSH on first connect.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("platform") == nil {
+			col.Fields.Add(&core.JSONField{Name: "platform", MaxSize: 8 * 1024})
+		}
+		if col.Fields.GetByName("platform_detected_at") == nil {
+			col.Fields.Add(&core.DateField{Name: "platform_detected_at"})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return nil
+		}
+
+		for _, name := range []string{"platform", "platform_detected_at"} {
+			if field := col.Fields.GetByName(name); field != nil {
+				col.Fields.RemoveById(field.GetId())
+			}
+		}
+
+		return app.Save(col)
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds user_files.content_hash: the SHA-256 of the stored content, set by
+// the space record hooks and indexed per owner for duplicate detection.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("user_files")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("content_hash") == nil {
+			col.Fields.Add(&core.TextField{Name: "content_hash", Max: 64})
+		}
+		col.AddIndex("idx_user_files_owner_content_hash", false, "owner, content_hash", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("user_files")
+		if err != nil {
+			return nil
+		}
+
+		col.RemoveIndex("idx_user_files_owner_content_hash")
+		if field := col.Fields.GetByName("content_hash"); field != nil {
+			col.Fields.RemoveById(field.GetId())
+		}
+
+		return app.Save(col)
+	})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Creates saved_commands: superuser-approved command templates that the
+// users listed in allowed_users may run on servers without terminal access.
+// Users only see enabled commands delegated to them; all writes are
+// superuser-only.
+func init() {
+	m.Register(func(app core.App) error {
+		serversCol, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+		usersCol, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		col := core.NewBaseCollection("saved_commands")
+
+		delegated := "@request.auth.id != '' && enabled = true && allowed_users ?= @request.auth.id"
+		col.ListRule = &delegated
+		col.ViewRule = &delegated
+
+		col.Fields.Add(&core.TextField{Name: "name", Required: true, Max: 100})
+		col.Fields.Add(&core.TextField{Name: "description", Max: 500})
+		col.Fields.Add(&core.TextField{Name: "command", Required: true, Max: 4096})
+		col.Fields.Add(&core.JSONField{Name: "params", MaxSize: 16 * 1024})
+		col.Fields.Add(&core.RelationField{Name: "servers", CollectionId: serversCol.Id, MaxSelect: 1000})
+		col.Fields.Add(&core.RelationField{Name: "allowed_users", CollectionId: usersCol.Id, MaxSelect: 1000})
+		col.Fields.Add(&core.BoolField{Name: "enabled"})
+		col.Fields.Add(&core.NumberField{Name: "timeout_seconds", OnlyInt: true, Min: types.Pointer(1.0), Max: types.Pointer(600.0)})
+		col.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+		col.Fields.Add(&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true})
+
+		col.AddIndex("idx_saved_commands_name", true, "name", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("saved_commands")
+		if err != nil {
+			return nil
+		}
+		return app.Delete(col)
+	})
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Adds `archived_at` to scripts for archive (soft-delete) support. Archived
+// scripts are hidden from default lists and purged by the worker once the
+// retention window has passed; until then they can be restored.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("scripts")
+		if err != nil {
+			return err
+		}
+
+		col.Fields.Add(&core.DateField{Name: "archived_at"})
+		col.ListRule = types.Pointer("@request.auth.id != '' && archived_at = ''")
+		col.AddIndex("idx_scripts_archived_at", false, "archived_at", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("scripts")
+		if err != nil {
+			return nil // already removed
+		}
+
+		col.RemoveIndex("idx_scripts_archived_at")
+		col.Fields.RemoveByName("archived_at")
+		col.ListRule = types.Pointer("@request.auth.id != ''")
+		return app.Save(col)
+	})
+}
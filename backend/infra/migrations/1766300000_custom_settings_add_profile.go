@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds `profile` to custom_settings for environment-scoped overrides. Rows
+// with an empty profile are the global groups; a row with a profile holds
+// only the fields that profile overrides. The unique index moves to
+// (module, key, profile) so each profile has at most one row per group.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("custom_settings")
+		if err != nil {
+			return err
+		}
+
+		col.Fields.Add(&core.TextField{Name: "profile", Max: 32})
+		col.Indexes = []string{
+			"CREATE UNIQUE INDEX idx_custom_settings_module_key_profile ON custom_settings (module, `key`, profile)",
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("custom_settings")
+		if err != nil {
+			return nil // already removed
+		}
+
+		if _, err := app.DB().NewQuery("DELETE FROM custom_settings WHERE profile != ''").Execute(); err != nil {
+			return err
+		}
+		col.Indexes = []string{
+			"CREATE UNIQUE INDEX idx_custom_settings_module_key ON custom_settings (module, `key`)",
+		}
+		col.Fields.RemoveByName("profile")
+		return app.Save(col)
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Creates stepup_totp: one TOTP enrollment per auth record, used to confirm
+// step-up actions. secret holds the encrypted shared key and is hidden from
+// the record API; last_step rejects replay of an already used code. All
+// access goes through /api/ext/stepup, so every rule stays superuser-only.
+func init() {
+	m.Register(func(app core.App) error {
+		col := core.NewBaseCollection("stepup_totp")
+
+		col.Fields.Add(&core.TextField{Name: "auth_collection", Required: true, Max: 100})
+		col.Fields.Add(&core.TextField{Name: "auth_id", Required: true, Max: 100})
+		col.Fields.Add(&core.TextField{Name: "secret", Required: true, Hidden: true})
+		col.Fields.Add(&core.BoolField{Name: "confirmed"})
+		col.Fields.Add(&core.NumberField{Name: "last_step", OnlyInt: true})
+		col.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+		col.Fields.Add(&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true})
+
+		col.AddIndex("idx_stepup_totp_auth", true, "auth_collection, auth_id", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("stepup_totp")
+		if err != nil {
+			return nil
+		}
+		return app.Delete(col)
+	})
+}
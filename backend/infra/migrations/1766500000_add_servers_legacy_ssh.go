@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds servers.legacy_ssh: when true, SSH connections to the server also offer
+// the ciphers, key exchanges and host key algorithms disabled by default, for
+// old appliances that cannot negotiate anything newer.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("legacy_ssh") == nil {
+			col.Fields.Add(&core.BoolField{Name: "legacy_ssh"})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return nil
+		}
+
+		if field := col.Fields.GetByName("legacy_ssh"); field != nil {
+			col.Fields.RemoveById(field.GetId())
+		}
+
+		return app.Save(col)
+	})
+}
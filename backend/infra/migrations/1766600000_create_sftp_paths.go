@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Creates sftp_paths: per-user, per-server file manager bookmarks and the
+// recently listed directories. Records are managed through the terminal
+// SFTP routes only, so no collection API rules are set.
+func init() {
+	m.Register(func(app core.App) error {
+		serversCol, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		col := core.NewBaseCollection("sftp_paths")
+
+		col.Fields.Add(&core.TextField{Name: "user_id", Required: true, Max: 100})
+		col.Fields.Add(&core.RelationField{Name: "server", CollectionId: serversCol.Id, MaxSelect: 1, Required: true, CascadeDelete: true})
+		col.Fields.Add(&core.SelectField{Name: "kind", Required: true, MaxSelect: 1, Values: []string{"bookmark", "recent"}})
+		col.Fields.Add(&core.TextField{Name: "path", Required: true, Max: 4096})
+		col.Fields.Add(&core.TextField{Name: "label", Max: 100})
+		col.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+		col.Fields.Add(&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true})
+
+		col.AddIndex("idx_sftp_paths_owner_path", true, "user_id, server, kind, path", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("sftp_paths")
+		if err != nil {
+			return nil
+		}
+		return app.Delete(col)
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds servers.privilege_escalation: how commands that need root are run on
+// the server. Empty means auto (none for root, sudo otherwise).
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("privilege_escalation") == nil {
+			col.Fields.Add(&core.SelectField{
+				Name:      "privilege_escalation",
+				MaxSelect: 1,
+				Values:    []string{"none", "sudo", "sudo-nopasswd", "doas"},
+			})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return nil
+		}
+
+		if field := col.Fields.GetByName("privilege_escalation"); field != nil {
+			col.Fields.RemoveById(field.GetId())
+		}
+
+		return app.Save(col)
+	})
+}
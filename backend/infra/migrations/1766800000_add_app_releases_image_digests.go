@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds app_releases.image_digests: the image each compose container ran when
+// the release was deployed, used to detect drift from mutable tags.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("app_releases")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("image_digests") == nil {
+			col.Fields.Add(&core.JSONField{Name: "image_digests"})
+		}
+		if col.Fields.GetByName("image_digests_at") == nil {
+			col.Fields.Add(&core.DateField{Name: "image_digests_at"})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("app_releases")
+		if err != nil {
+			return nil
+		}
+
+		for _, name := range []string{"image_digests", "image_digests_at"} {
+			if field := col.Fields.GetByName(name); field != nil {
+				col.Fields.RemoveById(field.GetId())
+			}
+		}
+
+		return app.Save(col)
+	})
+}
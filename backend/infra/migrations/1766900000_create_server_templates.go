@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Creates server_templates: named sets of default connection fields that a
+// new server can be created from, or that can be applied to existing servers
+// in bulk. Templates may reference a credential secret, so like servers they
+// are superuser-only.
+func init() {
+	m.Register(func(app core.App) error {
+		secretsCol, err := app.FindCollectionByNameOrId("secrets")
+		if err != nil {
+			return err
+		}
+
+		col := core.NewBaseCollection("server_templates")
+
+		col.Fields.Add(&core.TextField{Name: "name", Required: true, Max: 100})
+		col.Fields.Add(&core.TextField{Name: "description", Max: 500})
+		col.Fields.Add(&core.NumberField{Name: "port", OnlyInt: true, Min: types.Pointer(1.0), Max: types.Pointer(65535.0)})
+		col.Fields.Add(&core.TextField{Name: "user", Max: 200})
+		col.Fields.Add(&core.TextField{Name: "connect_type"})
+		col.Fields.Add(&core.RelationField{Name: "credential", CollectionId: secretsCol.Id, MaxSelect: 1})
+		col.Fields.Add(&core.TextField{Name: "shell"})
+		col.Fields.Add(&core.TextField{Name: "default_dir", Max: 1024})
+		col.Fields.Add(&core.JSONField{Name: "env", MaxSize: 64 * 1024})
+		col.Fields.Add(&core.BoolField{Name: "legacy_ssh"})
+		col.Fields.Add(&core.SelectField{
+			Name:      "privilege_escalation",
+			MaxSelect: 1,
+			Values:    []string{"none", "sudo", "sudo-nopasswd", "doas"},
+		})
+		col.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+		col.Fields.Add(&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true})
+
+		col.AddIndex("idx_server_templates_name", true, "name", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("server_templates")
+		if err != nil {
+			return nil
+		}
+		return app.Delete(col)
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Indexes audit_logs by resource so a resource's activity timeline does not
+// scan the whole log.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("audit_logs")
+		if err != nil {
+			return err
+		}
+
+		col.AddIndex("idx_audit_logs_resource", false, "resource_type, resource_id", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("audit_logs")
+		if err != nil {
+			return nil // already removed
+		}
+
+		col.RemoveIndex("idx_audit_logs_resource")
+		return app.Save(col)
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds servers.terminal_banner: the warning shown when an SSH terminal to
+// the server opens. An empty banner falls back to connect/terminal banner.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("terminal_banner") == nil {
+			col.Fields.Add(&core.TextField{Name: "terminal_banner", Max: 2000})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return nil
+		}
+
+		if field := col.Fields.GetByName("terminal_banner"); field != nil {
+			col.Fields.RemoveById(field.GetId())
+		}
+
+		return app.Save(col)
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Creates sftp_jails: the directory a user's SFTP file access is confined
+// to, on one server or, with server empty, on every server. No collection
+// API rules are set, so only superusers manage jails through the records API,
+// their own included: a jail limits the SFTP file routes, nothing else.
+func init() {
+	m.Register(func(app core.App) error {
+		serversCol, err := app.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return err
+		}
+
+		col := core.NewBaseCollection("sftp_jails")
+
+		col.Fields.Add(&core.TextField{Name: "user_id", Required: true, Max: 100})
+		col.Fields.Add(&core.RelationField{Name: "server", CollectionId: serversCol.Id, MaxSelect: 1, CascadeDelete: true})
+		col.Fields.Add(&core.TextField{Name: "root", Required: true, Max: 4096, Pattern: `^/`})
+		col.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+		col.Fields.Add(&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true})
+
+		col.AddIndex("idx_sftp_jails_user_server", true, "user_id, server", "")
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("sftp_jails")
+		if err != nil {
+			return nil
+		}
+		return app.Delete(col)
+	})
+}
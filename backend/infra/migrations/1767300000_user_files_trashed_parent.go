@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds user_files.trashed_parent: the folder an item was in when it was
+// moved to trash. Trashed items are detached to the trash root (parent = "")
+// and restore puts them back under trashed_parent.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("user_files")
+		if err != nil {
+			return err
+		}
+
+		if col.Fields.GetByName("trashed_parent") == nil {
+			col.Fields.Add(&core.TextField{Name: "trashed_parent", Max: 64})
+		}
+
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("user_files")
+		if err != nil {
+			return nil
+		}
+
+		if field := col.Fields.GetByName("trashed_parent"); field != nil {
+			col.Fields.RemoveById(field.GetId())
+		}
+
+		return app.Save(col)
+	})
+}
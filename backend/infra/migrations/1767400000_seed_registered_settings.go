@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/websoft9/appos/backend/domain/config/sysconfig"
+)
+
+// Seeds custom_settings rows for registered settings groups that have none
+// yet, so installs created before the default registry get every group.
+// Existing rows, edited or not, are left untouched.
+func init() {
+	m.Register(func(app core.App) error {
+		return sysconfig.SeedRegisteredGroups(app)
+	}, func(app core.App) error {
+		// Down: no-op; seeded rows are indistinguishable from saved ones.
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// archivedListSuffix hides archived records from record-API lists.
+const archivedListSuffix = " && archived_at = ''"
+
+// Adds `archived_at` to servers and secrets for archive (soft-delete)
+// support, as scripts got in 1766200000. Deleting through the records API
+// archives them; archived records are hidden from lists and purged by the
+// worker once the retention window has passed.
+func init() {
+	m.Register(func(app core.App) error {
+		for _, name := range []string{"servers", "secrets"} {
+			col, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			if col.Fields.GetByName("archived_at") == nil {
+				col.Fields.Add(&core.DateField{Name: "archived_at"})
+			}
+			if col.ListRule != nil {
+				rule := "archived_at = ''"
+				if *col.ListRule != "" {
+					rule = "(" + *col.ListRule + ")" + archivedListSuffix
+				}
+				col.ListRule = types.Pointer(rule)
+			}
+			col.AddIndex("idx_"+name+"_archived_at", false, "archived_at", "")
+
+			if err := app.Save(col); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		for _, name := range []string{"servers", "secrets"} {
+			col, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				continue // already removed
+			}
+
+			col.RemoveIndex("idx_" + name + "_archived_at")
+			col.Fields.RemoveByName("archived_at")
+			if col.ListRule != nil {
+				rule := *col.ListRule
+				switch {
+				case rule == "archived_at = ''":
+					rule = ""
+				case strings.HasPrefix(rule, "(") && strings.HasSuffix(rule, ")"+archivedListSuffix):
+					rule = strings.TrimSuffix(strings.TrimPrefix(rule, "("), ")"+archivedListSuffix)
+				}
+				col.ListRule = types.Pointer(rule)
+			}
+			if err := app.Save(col); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Adds `tls` to databases: how the connection test encrypts its connection.
+// Empty or "disable" connects in plain text, "require" encrypts without
+// checking the server certificate and "verify" also checks the certificate
+// against the system roots and the host name.
+func init() {
+	m.Register(func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("databases")
+		if err != nil {
+			return err
+		}
+		if col.Fields.GetByName("tls") == nil {
+			col.Fields.Add(&core.SelectField{
+				Name:      "tls",
+				MaxSelect: 1,
+				Values:    []string{"disable", "require", "verify"},
+			})
+		}
+		return app.Save(col)
+	}, func(app core.App) error {
+		col, err := app.FindCollectionByNameOrId("databases")
+		if err != nil {
+			return nil
+		}
+		col.Fields.RemoveByName("tls")
+		return app.Save(col)
+	})
+}
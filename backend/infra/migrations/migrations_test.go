@@ -33,6 +33,10 @@ func TestResourceCollectionsCreated(t *testing.T) {
 		"app_exposures",
 		"pipeline_runs",
 		"pipeline_node_runs",
+		"saved_commands",
+		"sftp_paths",
+		"sftp_jails",
+		"server_templates",
 	}
 
 	for _, name := range expected {
@@ -182,6 +186,8 @@ func TestAppReleasesCollectionFields(t *testing.T) {
 	assertFieldExists(t, col, "resolved_env_json", core.FieldTypeJSON, false)
 	assertFieldExists(t, col, "config_digest", core.FieldTypeText, false)
 	assertFieldExists(t, col, "artifact_digest", core.FieldTypeText, false)
+	assertFieldExists(t, col, "image_digests", core.FieldTypeJSON, false)
+	assertFieldExists(t, col, "image_digests_at", core.FieldTypeDate, false)
 	assertFieldExists(t, col, "is_active", core.FieldTypeBool, false)
 	assertFieldExists(t, col, "is_last_known_good", core.FieldTypeBool, false)
 	assertFieldExists(t, col, "activated_at", core.FieldTypeDate, false)
@@ -345,6 +351,14 @@ func TestServersCollectionFields(t *testing.T) {
 	assertFieldExists(t, col, "description", core.FieldTypeText, false)
 	assertFieldExists(t, col, "facts_json", core.FieldTypeJSON, false)
 	assertFieldExists(t, col, "facts_observed_at", core.FieldTypeDate, false)
+	assertFieldExists(t, col, "ssh_config_host", core.FieldTypeText, false)
+	assertFieldExists(t, col, "default_dir", core.FieldTypeText, false)
+	assertFieldExists(t, col, "env", core.FieldTypeJSON, false)
+	assertFieldExists(t, col, "terminal_banner", core.FieldTypeText, false)
+	assertFieldExists(t, col, "legacy_ssh", core.FieldTypeBool, false)
+	assertFieldExists(t, col, "privilege_escalation", core.FieldTypeSelect, false)
+	assertFieldExists(t, col, "platform", core.FieldTypeJSON, false)
+	assertFieldExists(t, col, "platform_detected_at", core.FieldTypeDate, false)
 
 	// Verify credential relation points to secrets
 	assertRelationTarget(t, app, col, "credential", "secrets")
@@ -401,6 +415,7 @@ func TestDatabasesCollectionFields(t *testing.T) {
 	assertFieldExists(t, col, "user", core.FieldTypeText, false)
 	assertFieldExists(t, col, "password", core.FieldTypeRelation, false)
 	assertFieldExists(t, col, "description", core.FieldTypeText, false)
+	assertFieldExists(t, col, "tls", core.FieldTypeSelect, false)
 
 	assertRelationTarget(t, app, col, "password", "secrets")
 }
@@ -970,3 +985,22 @@ func TestSecretsPolicySeedExists(t *testing.T) {
 		t.Fatalf("expected clipboardClearSeconds 0, got %#v", policy.ClipboardClearSeconds)
 	}
 }
+
+// TestSeededSettingsMatchRegisteredDefaults guards against drift between the
+// seed migration and the sysconfig default registry.
+func TestSeededSettingsMatchRegisteredDefaults(t *testing.T) {
+	app := newMigrationsTestApp(t)
+
+	if drifts := sysconfig.CheckDefaults(app); len(drifts) != 0 {
+		t.Fatalf("seeded settings drift from registered defaults: %+v", drifts)
+	}
+	for _, g := range sysconfig.RegisteredGroups() {
+		value, err := sysconfig.GetGroup(app, g.Module, g.Key, nil)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", g.Module, g.Key, err)
+		}
+		if !sysconfig.MatchesDefaults(g.Module, g.Key, value) {
+			t.Errorf("%s/%s: seeded value %v does not match registered defaults %v", g.Module, g.Key, value, g.Defaults)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Package sshconfig resolves OpenSSH client configuration (~/.ssh/config)
+// host aliases and dials SSH targets through ProxyJump chains.
+//
+// Only the keywords AppOS needs are interpreted: HostName, User, Port,
+// IdentityFile and ProxyJump. Match and Include blocks are not supported;
+// Match blocks are skipped.
+package sshconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EnvConfigPath overrides the ssh_config file read by LoadDefault.
+const EnvConfigPath = "APPOS_SSH_CONFIG"
+
+// Host is the effective configuration for one alias.
+type Host struct {
+	Alias         string
+	HostName      string
+	Port          int
+	User          string
+	IdentityFiles []string
+	ProxyJump     []Jump
+}
+
+// Jump is one ProxyJump entry: [user@]host[:port]. Host may itself be an alias.
+type Jump struct {
+	User string
+	Host string
+	Port int
+}
+
+// Config is a parsed ssh_config file.
+type Config struct {
+	blocks []block
+}
+
+type block struct {
+	patterns []string
+	// match is false for Match blocks, which are never applied.
+	match  bool
+	params []param
+}
+
+type param struct {
+	key  string
+	args []string
+}
+
+// DefaultPath returns $APPOS_SSH_CONFIG or ~/.ssh/config.
+func DefaultPath() string {
+	if p := strings.TrimSpace(os.Getenv(EnvConfigPath)); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// LoadDefault parses the file at DefaultPath.
+func LoadDefault() (*Config, error) {
+	p := DefaultPath()
+	if p == "" {
+		return nil, fmt.Errorf("ssh config path could not be determined")
+	}
+	return Load(p)
+}
+
+// Load parses the ssh_config file at path.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path) // #nosec G304 -- operator-configured ssh_config path
+	if err != nil {
+		return nil, fmt.Errorf("open ssh config: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads ssh_config content. Directives before the first Host line
+// apply to every host.
+func Parse(r io.Reader) (*Config, error) {
+	cfg := &Config{blocks: []block{{patterns: []string{"*"}, match: true}}}
+	current := &cfg.blocks[0]
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, args, err := splitDirective(line)
+		if err != nil {
+			return nil, fmt.Errorf("ssh config line %d: %w", lineNo, err)
+		}
+
+		switch key {
+		case "host":
+			cfg.blocks = append(cfg.blocks, block{patterns: args, match: true})
+			current = &cfg.blocks[len(cfg.blocks)-1]
+		case "match":
+			cfg.blocks = append(cfg.blocks, block{match: false})
+			current = &cfg.blocks[len(cfg.blocks)-1]
+		default:
+			current.params = append(current.params, param{key: key, args: args})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ssh config: %w", err)
+	}
+	return cfg, nil
+}
+
+// splitDirective splits "Keyword value" or "Keyword=value" and lowercases the keyword.
+func splitDirective(line string) (string, []string, error) {
+	idx := strings.IndexAny(line, " \t=")
+	if idx < 0 {
+		return "", nil, fmt.Errorf("missing value for %q", line)
+	}
+	key := strings.ToLower(line[:idx])
+	rest := strings.TrimLeft(line[idx:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	args := splitArgs(strings.TrimSpace(rest))
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("missing value for %q", key)
+	}
+	return key, args, nil
+}
+
+// splitArgs splits on whitespace, honouring double quotes.
+func splitArgs(s string) []string {
+	var (
+		args   []string
+		b      strings.Builder
+		quoted bool
+		hasArg bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			hasArg = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if hasArg {
+				args = append(args, b.String())
+				b.Reset()
+				hasArg = false
+			}
+		default:
+			b.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, b.String())
+	}
+	return args
+}
+
+// Lookup returns the effective settings for alias. As in OpenSSH, the first
+// obtained value of each keyword wins, while IdentityFile values accumulate.
+func (c *Config) Lookup(alias string) (Host, error) {
+	h := Host{Alias: alias}
+	seen := map[string]bool{}
+	var proxyJump string
+
+	for _, b := range c.blocks {
+		if !b.match || !matchPatterns(b.patterns, alias) {
+			continue
+		}
+		for _, p := range b.params {
+			if p.key == "identityfile" {
+				h.IdentityFiles = append(h.IdentityFiles, p.args[0])
+				continue
+			}
+			if seen[p.key] {
+				continue
+			}
+			seen[p.key] = true
+			switch p.key {
+			case "hostname":
+				h.HostName = p.args[0]
+			case "user":
+				h.User = p.args[0]
+			case "port":
+				port, err := strconv.Atoi(p.args[0])
+				if err != nil || port < 1 || port > 65535 {
+					return Host{}, fmt.Errorf("invalid Port %q for host %q", p.args[0], alias)
+				}
+				h.Port = port
+			case "proxyjump":
+				proxyJump = p.args[0]
+			}
+		}
+	}
+
+	if h.HostName == "" {
+		h.HostName = alias
+	}
+	h.HostName = strings.ReplaceAll(h.HostName, "%h", alias)
+	for i, f := range h.IdentityFiles {
+		h.IdentityFiles[i] = expandPath(f, h)
+	}
+
+	if proxyJump != "" && !strings.EqualFold(proxyJump, "none") {
+		jumps, err := ParseProxyJump(proxyJump)
+		if err != nil {
+			return Host{}, fmt.Errorf("host %q: %w", alias, err)
+		}
+		h.ProxyJump = jumps
+	}
+	return h, nil
+}
+
+// ParseProxyJump parses a comma-separated ProxyJump value.
+func ParseProxyJump(value string) ([]Jump, error) {
+	var jumps []Jump
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "ssh://"))
+		if raw == "" {
+			continue
+		}
+		var j Jump
+		if at := strings.LastIndex(raw, "@"); at >= 0 {
+			j.User = raw[:at]
+			raw = raw[at+1:]
+		}
+		j.Host = raw
+		if host, port, err := net.SplitHostPort(raw); err == nil {
+			p, convErr := strconv.Atoi(port)
+			if convErr != nil || p < 1 || p > 65535 {
+				return nil, fmt.Errorf("invalid ProxyJump port in %q", value)
+			}
+			j.Host, j.Port = host, p
+		}
+		if j.Host == "" {
+			return nil, fmt.Errorf("invalid ProxyJump entry in %q", value)
+		}
+		jumps = append(jumps, j)
+	}
+	return jumps, nil
+}
+
+// matchPatterns applies ssh_config Host pattern rules: any negated match
+// rejects the host, otherwise at least one positive pattern must match.
+func matchPatterns(patterns []string, host string) bool {
+	matched := false
+	for _, p := range patterns {
+		negated := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+		ok, err := path.Match(strings.ToLower(p), strings.ToLower(host))
+		if err != nil || !ok {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// expandPath expands ~ and the %d, %h, %r, %u and %% tokens in IdentityFile.
+func expandPath(p string, h Host) string {
+	home, _ := os.UserHomeDir()
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		p = home + p[1:]
+	}
+	localUser := ""
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+	return strings.NewReplacer(
+		"%%", "%",
+		"%d", home,
+		"%h", h.HostName,
+		"%r", h.User,
+		"%u", localUser,
+	).Replace(p)
+}
+
+// ReadIdentity returns the contents of the first readable identity file.
+func ReadIdentity(files []string) (string, error) {
+	var lastErr error
+	for _, f := range files {
+		data, err := os.ReadFile(f) // #nosec G304 -- identity files come from the operator's ssh_config
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return string(data), nil
+	}
+	if lastErr == nil {
+		return "", fmt.Errorf("no IdentityFile configured")
+	}
+	return "", fmt.Errorf("read identity file: %w", lastErr)
+}
@@ -0,0 +1,135 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = `
+# global defaults
+User fallback
+
+Host bastion
+    HostName bastion.example.com
+    Port 2200
+    IdentityFile %d/keys/bastion
+
+Host web-* !web-legacy
+    HostName %h.internal
+    User deploy
+    ProxyJump bastion
+
+Host web-1
+    Port 2222
+    User ignored
+
+Match host web-1
+    User matched
+
+Host *
+    Port 22
+    IdentityFile ~/.ssh/id_default
+`
+
+func TestLookupAppliesFirstValueWinsAndPatterns(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(sampleConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := cfg.Lookup("web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.HostName != "web-1.internal" {
+		t.Fatalf("expected %%h expansion, got %q", h.HostName)
+	}
+	// The global User comes first, so it wins over the Host blocks.
+	if h.User != "fallback" {
+		t.Fatalf("expected first user to win, got %q", h.User)
+	}
+	if h.Port != 2222 {
+		t.Fatalf("expected port 2222, got %d", h.Port)
+	}
+	if len(h.ProxyJump) != 1 || h.ProxyJump[0].Host != "bastion" {
+		t.Fatalf("unexpected proxy jump: %+v", h.ProxyJump)
+	}
+	if len(h.IdentityFiles) != 1 || !strings.HasSuffix(h.IdentityFiles[0], filepath.Join(".ssh", "id_default")) {
+		t.Fatalf("expected expanded default identity, got %v", h.IdentityFiles)
+	}
+
+	legacy, err := cfg.Lookup("web-legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.HostName != "web-legacy" || len(legacy.ProxyJump) != 0 {
+		t.Fatalf("expected negated pattern to skip the web-* block, got %+v", legacy)
+	}
+}
+
+func TestParseProxyJump(t *testing.T) {
+	jumps, err := ParseProxyJump("ops@jump1:2201, ssh://jump2,[fd00::1]:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Jump{
+		{User: "ops", Host: "jump1", Port: 2201},
+		{Host: "jump2"},
+		{Host: "fd00::1", Port: 22},
+	}
+	if len(jumps) != len(want) {
+		t.Fatalf("expected %d jumps, got %+v", len(want), jumps)
+	}
+	for i := range want {
+		if jumps[i] != want[i] {
+			t.Fatalf("jump %d: expected %+v, got %+v", i, want[i], jumps[i])
+		}
+	}
+
+	if _, err := ParseProxyJump("jump:notaport"); err == nil {
+		t.Fatal("expected invalid port to fail")
+	}
+}
+
+func TestResolveHopsReadsJumpIdentity(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "bastion_key")
+	if err := os.WriteFile(keyPath, []byte("PEM"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Parse(strings.NewReader(`
+Host target
+    ProxyJump admin@bastion
+Host bastion
+    HostName 10.0.0.1
+    Port 2200
+    User ignored
+    IdentityFile ` + keyPath + `
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := cfg.Lookup("target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hops, err := cfg.ResolveHops(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hops) != 1 {
+		t.Fatalf("expected 1 hop, got %+v", hops)
+	}
+	if hops[0].Addr() != "10.0.0.1:2200" || hops[0].User != "admin" || hops[0].PrivateKey != "PEM" {
+		t.Fatalf("unexpected hop: %+v", hops[0])
+	}
+}
+
+func TestParseRejectsDirectiveWithoutValue(t *testing.T) {
+	if _, err := Parse(strings.NewReader("Host web\n  HostName\n")); err == nil {
+		t.Fatal("expected error for directive without value")
+	}
+}
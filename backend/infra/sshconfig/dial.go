@@ -0,0 +1,146 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrHopNoKey is returned by Dial for a jump host without an IdentityFile.
+var ErrHopNoKey = errors.New("proxy jump host has no IdentityFile; jump hosts need their own key")
+
+// Hop is one resolved intermediate SSH server on the way to a target.
+type Hop struct {
+	Host string
+	Port int
+	// User defaults to the target user when empty.
+	User string
+	// PrivateKey is the PEM key for this hop. It is required: the target's
+	// credentials are never offered to a jump host.
+	PrivateKey string
+}
+
+// Addr returns host:port for the hop, defaulting to port 22.
+func (h Hop) Addr() string {
+	port := h.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(h.Host, strconv.Itoa(port))
+}
+
+// ResolveHops turns h.ProxyJump into dialable hops. Each jump host is itself
+// looked up as an alias so its HostName, User, Port and IdentityFile apply.
+// A jump host's own ProxyJump is not followed.
+func (c *Config) ResolveHops(h Host) ([]Hop, error) {
+	hops := make([]Hop, 0, len(h.ProxyJump))
+	for _, j := range h.ProxyJump {
+		jh, err := c.Lookup(j.Host)
+		if err != nil {
+			return nil, err
+		}
+		hop := Hop{Host: jh.HostName, Port: jh.Port, User: jh.User}
+		if j.Port != 0 {
+			hop.Port = j.Port
+		}
+		if j.User != "" {
+			hop.User = j.User
+		}
+		if len(jh.IdentityFiles) > 0 {
+			key, err := ReadIdentity(jh.IdentityFiles)
+			if err != nil {
+				return nil, fmt.Errorf("proxy jump %s: %w", j.Host, err)
+			}
+			hop.PrivateKey = key
+		}
+		hops = append(hops, hop)
+	}
+	return hops, nil
+}
+
+// Dial connects to addr with cfg, tunnelling through hops in order. Without
+// hops it is equivalent to ssh.Dial. Intermediate connections are closed when
+// the returned client is closed.
+func Dial(addr string, cfg *ssh.ClientConfig, hops []Hop) (*ssh.Client, error) {
+	if len(hops) == 0 {
+		return ssh.Dial("tcp", addr, cfg)
+	}
+
+	chain := make([]*ssh.Client, 0, len(hops))
+	closeChain := func() {
+		for i := len(chain) - 1; i >= 0; i-- {
+			_ = chain[i].Close()
+		}
+	}
+
+	for i, hop := range hops {
+		hopCfg, err := hopClientConfig(hop, cfg)
+		if err != nil {
+			closeChain()
+			return nil, err
+		}
+		var client *ssh.Client
+		if i == 0 {
+			client, err = ssh.Dial("tcp", hop.Addr(), hopCfg)
+		} else {
+			client, err = dialThrough(chain[i-1], hop.Addr(), hopCfg)
+		}
+		if err != nil {
+			closeChain()
+			return nil, fmt.Errorf("proxy jump %s: %w", hop.Addr(), err)
+		}
+		chain = append(chain, client)
+	}
+
+	target, err := dialThrough(chain[len(chain)-1], addr, cfg)
+	if err != nil {
+		closeChain()
+		return nil, err
+	}
+	go func() {
+		_ = target.Wait()
+		closeChain()
+	}()
+	return target, nil
+}
+
+func dialThrough(via *ssh.Client, addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// hopClientConfig authenticates to hop with its own key only, so a target
+// password never reaches an intermediate server.
+func hopClientConfig(hop Hop, target *ssh.ClientConfig) (*ssh.ClientConfig, error) {
+	if hop.PrivateKey == "" {
+		return nil, fmt.Errorf("%w: %s", ErrHopNoKey, hop.Addr())
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(hop.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("proxy jump %s: parse private key: %w", hop.Addr(), err)
+	}
+	cfg := &ssh.ClientConfig{
+		User:            target.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: target.HostKeyCallback,
+		Timeout:         target.Timeout,
+		// Jump hosts of a legacy target are usually just as old.
+		Config:            target.Config,
+		HostKeyAlgorithms: target.HostKeyAlgorithms,
+	}
+	if hop.User != "" {
+		cfg.User = hop.User
+	}
+	return cfg, nil
+}
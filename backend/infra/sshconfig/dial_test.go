@@ -0,0 +1,199 @@
+package sshconfig
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSSHServer runs an SSH server on 127.0.0.1 that accepts either
+// password or clientKey (when non-nil). It answers exec requests with
+// "<name>:<command>" and, when allowForward is set, serves direct-tcpip
+// channels so it can act as a jump host.
+func startTestSSHServer(t *testing.T, name, password string, clientKey ssh.PublicKey, allowForward bool) string {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, fmt.Errorf("denied")
+			}
+			return nil, nil
+		},
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if clientKey == nil || !bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, fmt.Errorf("denied")
+			}
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, cfg, name, allowForward)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveTestConn(conn net.Conn, cfg *ssh.ServerConfig, name string, allowForward bool) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		switch {
+		case newCh.ChannelType() == "session":
+			ch, chReqs, err := newCh.Accept()
+			if err != nil {
+				continue
+			}
+			go func() {
+				for req := range chReqs {
+					if req.Type != "exec" {
+						_ = req.Reply(false, nil)
+						continue
+					}
+					_ = req.Reply(true, nil)
+					cmdLen := binary.BigEndian.Uint32(req.Payload[:4])
+					_, _ = io.WriteString(ch, name+":"+string(req.Payload[4:4+cmdLen]))
+					_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+					_ = ch.Close()
+				}
+			}()
+		case newCh.ChannelType() == "direct-tcpip" && allowForward:
+			var payload struct {
+				Host     string
+				Port     uint32
+				OrigHost string
+				OrigPort uint32
+			}
+			if err := ssh.Unmarshal(newCh.ExtraData(), &payload); err != nil {
+				_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+			if err != nil {
+				_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			ch, chReqs, err := newCh.Accept()
+			if err != nil {
+				_ = target.Close()
+				continue
+			}
+			go ssh.DiscardRequests(chReqs)
+			go func() {
+				defer ch.Close()
+				defer target.Close()
+				go func() { _, _ = io.Copy(target, ch) }()
+				_, _ = io.Copy(ch, target)
+			}()
+		default:
+			_ = newCh.Reject(ssh.Prohibited, "unsupported")
+		}
+	}
+}
+
+func TestDialThroughProxyJump(t *testing.T) {
+	_, jumpPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jumpSigner, err := ssh.NewSignerFromKey(jumpPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(jumpPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jumpPEM := string(pem.EncodeToMemory(block))
+
+	jumpAddr := startTestSSHServer(t, "jump", "", jumpSigner.PublicKey(), true)
+	targetAddr := startTestSSHServer(t, "target", "target-secret", nil, false)
+
+	jumpHost, jumpPort, _ := net.SplitHostPort(jumpAddr)
+	port, _ := strconv.Atoi(jumpPort)
+
+	cfg := &ssh.ClientConfig{
+		User:            "root",
+		Auth:            []ssh.AuthMethod{ssh.Password("target-secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // #nosec G106 -- in-process test servers
+		Timeout:         5 * time.Second,
+	}
+
+	// Without its own key the hop is refused before the target password could
+	// be offered to it.
+	if _, err := Dial(targetAddr, cfg, []Hop{{Host: jumpHost, Port: port}}); !errors.Is(err, ErrHopNoKey) {
+		t.Fatalf("expected ErrHopNoKey for a jump host without a key, got %v", err)
+	}
+
+	// Two hops through the same jump server, then the target.
+	hop := Hop{Host: jumpHost, Port: port, User: "jumper", PrivateKey: jumpPEM}
+	client, err := Dial(targetAddr, cfg, []Hop{hop, hop})
+	if err != nil {
+		t.Fatalf("dial through jumps: %v", err)
+	}
+	defer client.Close()
+
+	sess, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sess.Close()
+	out, err := sess.Output("hostname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "target:hostname" {
+		t.Fatalf("expected command to run on target, got %q", out)
+	}
+}
+
+func TestDialWithoutHopsDialsDirectly(t *testing.T) {
+	targetAddr := startTestSSHServer(t, "target", "target-secret", nil, false)
+
+	client, err := Dial(targetAddr, &ssh.ClientConfig{
+		User:            "root",
+		Auth:            []ssh.AuthMethod{ssh.Password("target-secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // #nosec G106 -- in-process test server
+		Timeout:         5 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = client.Close()
+}
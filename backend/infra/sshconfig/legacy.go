@@ -0,0 +1,21 @@
+package sshconfig
+
+import (
+	"slices"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// EnableLegacyAlgorithms widens cfg to also offer the ciphers, key exchanges,
+// MACs and host key algorithms x/crypto implements but disables by default
+// (CBC/3DES/RC4, SHA-1 Diffie-Hellman, ssh-rsa, ssh-dss). It is meant for old
+// appliances that cannot negotiate anything newer; the secure algorithms stay
+// first so modern servers are unaffected.
+func EnableLegacyAlgorithms(cfg *ssh.ClientConfig) {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+	cfg.Ciphers = slices.Concat(supported.Ciphers, insecure.Ciphers)
+	cfg.KeyExchanges = slices.Concat(supported.KeyExchanges, insecure.KeyExchanges)
+	cfg.MACs = slices.Concat(supported.MACs, insecure.MACs)
+	cfg.HostKeyAlgorithms = slices.Concat(supported.HostKeys, insecure.HostKeys)
+}
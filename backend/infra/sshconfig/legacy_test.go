@@ -0,0 +1,69 @@
+package sshconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// handshakeWithCBCOnlyServer runs a client handshake against a server that
+// only offers aes128-cbc and returns the client's error.
+func handshakeWithCBCOnlyServer(t *testing.T, clientCfg *ssh.ClientConfig) error {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCfg := &ssh.ServerConfig{NoClientAuth: true}
+	serverCfg.Ciphers = []string{ssh.InsecureCipherAES128CBC}
+	serverCfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _, _, _ = ssh.NewServerConn(conn, serverCfg)
+	}()
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+
+	c, _, _, err := ssh.NewClientConn(clientConn, "legacy:22", clientCfg)
+	if err == nil {
+		_ = c.Close()
+	}
+	return err
+}
+
+func TestEnableLegacyAlgorithms(t *testing.T) {
+	cfg := &ssh.ClientConfig{User: "root", HostKeyCallback: ssh.InsecureIgnoreHostKey()}
+	if err := handshakeWithCBCOnlyServer(t, cfg); err == nil {
+		t.Fatal("expected default config to reject a CBC-only server")
+	}
+
+	EnableLegacyAlgorithms(cfg)
+	if cfg.Ciphers[0] != ssh.SupportedAlgorithms().Ciphers[0] {
+		t.Fatalf("secure ciphers should stay preferred, got %v", cfg.Ciphers)
+	}
+	if err := handshakeWithCBCOnlyServer(t, cfg); err != nil {
+		t.Fatalf("legacy config handshake: %v", err)
+	}
+}
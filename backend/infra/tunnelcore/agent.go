@@ -0,0 +1,128 @@
+package tunnelcore
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// AgentChannelType is the SSH channel type an optional tunnel agent opens on
+// its tunnel connection to report status. Plain autossh clients never open it
+// and keep working with port forwarding alone.
+//
+// Protocol: the client opens one channel of this type (no extra data) and
+// writes newline-delimited JSON objects, each a full [AgentReport]. The server
+// never writes to the channel; the latest valid report replaces the previous
+// one on the session. Lines longer than [MaxAgentReportBytes] close the channel.
+const AgentChannelType = "appos-agent@websoft9.com"
+
+const (
+	// MaxAgentReportBytes caps a single JSON report line.
+	MaxAgentReportBytes = 64 * 1024
+	// MaxAgentMetrics caps the number of metrics kept from one report.
+	MaxAgentMetrics = 64
+	// maxAgentInvalidReports closes the channel after this many bad lines.
+	maxAgentInvalidReports = 10
+)
+
+// AgentReport is one status update sent by a tunnel agent.
+type AgentReport struct {
+	Hostname      string             `json:"hostname"`
+	AgentVersion  string             `json:"agent_version,omitempty"`
+	Health        string             `json:"health,omitempty"`
+	Message       string             `json:"message,omitempty"`
+	UptimeSeconds int64              `json:"uptime_seconds,omitempty"`
+	Metrics       map[string]float64 `json:"metrics,omitempty"`
+}
+
+// AgentStatus is the latest agent report stored on a session.
+type AgentStatus struct {
+	AgentReport
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Agent health values; anything else is reported as "unknown".
+const (
+	AgentHealthOK       = "ok"
+	AgentHealthDegraded = "degraded"
+	AgentHealthError    = "error"
+	AgentHealthUnknown  = "unknown"
+)
+
+func normalizeAgentReport(r AgentReport) AgentReport {
+	r.Hostname = truncate(strings.TrimSpace(r.Hostname), 255)
+	r.AgentVersion = truncate(strings.TrimSpace(r.AgentVersion), 64)
+	r.Message = truncate(strings.TrimSpace(r.Message), 1024)
+	switch health := strings.ToLower(strings.TrimSpace(r.Health)); health {
+	case AgentHealthOK, AgentHealthDegraded, AgentHealthError:
+		r.Health = health
+	default:
+		r.Health = AgentHealthUnknown
+	}
+	if r.UptimeSeconds < 0 {
+		r.UptimeSeconds = 0
+	}
+	if len(r.Metrics) > MaxAgentMetrics {
+		kept := make(map[string]float64, MaxAgentMetrics)
+		for name, value := range r.Metrics {
+			if len(kept) == MaxAgentMetrics {
+				break
+			}
+			kept[name] = value
+		}
+		r.Metrics = kept
+	}
+	return r
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// handleAgentChannel accepts an agent channel and records its reports on sess
+// until the client closes it or the connection drops.
+func (s *Server) handleAgentChannel(sess *Session, newChan ssh.NewChannel) {
+	ch, reqs, err := newChan.Accept()
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+	go ssh.DiscardRequests(reqs)
+
+	readAgentReports(sess, ch)
+}
+
+// readAgentReports parses newline-delimited reports from r into sess.
+func readAgentReports(sess *Session, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), MaxAgentReportBytes)
+
+	invalid := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var report AgentReport
+		if err := json.Unmarshal([]byte(line), &report); err != nil {
+			invalid++
+			if invalid >= maxAgentInvalidReports {
+				log.Printf("[tunnel] closing agent channel for client %s: too many invalid reports", sess.ClientID)
+				return
+			}
+			continue
+		}
+		sess.SetAgentStatus(AgentStatus{AgentReport: normalizeAgentReport(report), ReceivedAt: time.Now().UTC()})
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("[tunnel] agent channel for client %s closed: %v", sess.ClientID, err)
+	}
+}
@@ -0,0 +1,138 @@
+package tunnelcore
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestReadAgentReportsKeepsLatestValidReport(t *testing.T) {
+	sess := newTestSession("srv1")
+	if sess.AgentStatus() != nil {
+		t.Fatal("expected no agent status before any report")
+	}
+
+	input := strings.Join([]string{
+		`{"hostname":"web-1","health":"OK","uptime_seconds":10,"metrics":{"load1":0.5}}`,
+		`not json`,
+		``,
+		`{"hostname":"web-1","health":"on fire","agent_version":"1.2.0","metrics":{"load1":1.5,"mem_used_pct":42}}`,
+	}, "\n")
+	readAgentReports(sess, strings.NewReader(input))
+
+	status := sess.AgentStatus()
+	if status == nil {
+		t.Fatal("expected agent status to be stored")
+	}
+	if status.Hostname != "web-1" || status.AgentVersion != "1.2.0" || status.Health != AgentHealthUnknown {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if status.Metrics["load1"] != 1.5 || status.Metrics["mem_used_pct"] != 42 || status.ReceivedAt.IsZero() {
+		t.Fatalf("unexpected metrics or timestamp %+v", status)
+	}
+
+	// The returned status is a copy.
+	status.Metrics["load1"] = 99
+	if sess.AgentStatus().Metrics["load1"] != 1.5 {
+		t.Fatal("AgentStatus must return a copy of the metrics")
+	}
+}
+
+func TestReadAgentReportsStopsOnOversizedOrInvalidStream(t *testing.T) {
+	sess := newTestSession("srv1")
+	oversized := `{"hostname":"` + strings.Repeat("x", MaxAgentReportBytes) + `"}`
+	readAgentReports(sess, strings.NewReader(oversized+"\n"+`{"hostname":"late"}`))
+	if sess.AgentStatus() != nil {
+		t.Fatal("expected oversized line to close the stream before later reports")
+	}
+
+	bad := strings.Repeat("garbage\n", maxAgentInvalidReports) + `{"hostname":"late"}` + "\n"
+	readAgentReports(sess, strings.NewReader(bad))
+	if sess.AgentStatus() != nil {
+		t.Fatal("expected too many invalid reports to close the stream")
+	}
+}
+
+type staticValidator struct{ token, clientID string }
+
+func (v staticValidator) Validate(token string) (string, bool) {
+	return v.clientID, token == v.token
+}
+
+func TestServerAcceptsAgentChannel(t *testing.T) {
+	s := &Server{
+		DataDir:         t.TempDir(),
+		Validator:       staticValidator{token: "tok", clientID: "srv1"},
+		Hooks:           noopHooks{},
+		Pool:            NewPortPool(59300, 59399),
+		ForwardResolver: noopForwardResolver{},
+		Sessions:        NewRegistry(),
+	}
+	if err := s.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serverSide, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		s.handleConn(serverSide)
+	}()
+
+	clientSide, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(clientSide, ln.Addr().String(), &ssh.ClientConfig{
+		User:            "tok",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // #nosec G106 -- in-process test server
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ssh.NewClient(conn, chans, reqs)
+
+	// Plain session channels stay rejected.
+	if _, _, err := client.OpenChannel("session", nil); err == nil {
+		t.Fatal("expected session channel to be rejected")
+	}
+
+	ch, chReqs, err := client.OpenChannel(AgentChannelType, nil)
+	if err != nil {
+		t.Fatalf("agent channel rejected: %v", err)
+	}
+	go ssh.DiscardRequests(chReqs)
+	if _, err := ch.Write([]byte(`{"hostname":"edge-1","health":"ok"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if sess, ok := s.Sessions.Get("srv1"); ok {
+			if status := sess.AgentStatus(); status != nil {
+				if status.Hostname != "edge-1" || status.Health != AgentHealthOK {
+					t.Fatalf("unexpected status %+v", status)
+				}
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("agent report was not recorded on the session")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_ = client.Close()
+	<-done
+}
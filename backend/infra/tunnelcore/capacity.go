@@ -0,0 +1,150 @@
+package tunnelcore
+
+import "fmt"
+
+// PortCapacityWarnRatio is the share of the port range that, once required by
+// tunnel servers, causes the range to be reported as nearly full.
+const PortCapacityWarnRatio = 0.8
+
+// CapacityLevel classifies how close the port range is to exhaustion.
+type CapacityLevel string
+
+const (
+	CapacityOK        CapacityLevel = "ok"
+	CapacityWarning   CapacityLevel = "warning"
+	CapacityExhausted CapacityLevel = "exhausted"
+)
+
+// PortDemand describes the tunnel ports one client needs and currently holds.
+type PortDemand struct {
+	// Forwards is the number of tunnel ports the client needs when connected.
+	Forwards int
+	// Assigned lists the tunnel ports currently stored for the client.
+	Assigned []int
+}
+
+// PortCapacity reports how many tunnel servers a port range can support.
+type PortCapacity struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// TotalPorts is the number of ports in [Start, End].
+	TotalPorts int `json:"total_ports"`
+	// Servers is the number of tunnel servers counted against the range.
+	Servers int `json:"servers"`
+	// PortsPerServer is the largest number of forwards any server needs
+	// (ports per service × expected services).
+	PortsPerServer int `json:"ports_per_server"`
+	// RequiredPorts is the number of ports needed when every server is connected.
+	RequiredPorts int `json:"required_ports"`
+	// AssignedPorts counts distinct stored tunnel ports inside the range.
+	AssignedPorts int `json:"assigned_ports"`
+	// OutOfRangePorts counts stored tunnel ports outside the range. They stay
+	// reserved for their server but are not available to new servers.
+	OutOfRangePorts int `json:"out_of_range_ports"`
+	// FreePorts is TotalPorts minus RequiredPorts, never negative.
+	FreePorts int `json:"free_ports"`
+	// FreeServerSlots is how many more servers fit at PortsPerServer each.
+	FreeServerSlots int           `json:"free_server_slots"`
+	Level           CapacityLevel `json:"level"`
+}
+
+// Size returns the number of ports in the range (inclusive).
+func (p PortRange) Size() int {
+	if p.End < p.Start {
+		return 0
+	}
+	return p.End - p.Start + 1
+}
+
+// Contains reports whether port falls inside the range.
+func (p PortRange) Contains(port int) bool {
+	return port >= p.Start && port <= p.End
+}
+
+// ComputePortCapacity sizes portRange against the given client demands.
+// A client needs the larger of its desired forwards and its stored in-range
+// ports, so both connected and not-yet-connected servers are accounted for.
+func ComputePortCapacity(portRange PortRange, demands []PortDemand) PortCapacity {
+	c := PortCapacity{
+		Start:          portRange.Start,
+		End:            portRange.End,
+		TotalPorts:     portRange.Size(),
+		Servers:        len(demands),
+		PortsPerServer: len(defaultForwardSpecs),
+	}
+
+	seen := make(map[int]struct{})
+	maxForwards := 0
+	for _, d := range demands {
+		inRange := 0
+		for _, port := range d.Assigned {
+			if _, dup := seen[port]; dup {
+				continue
+			}
+			seen[port] = struct{}{}
+			if portRange.Contains(port) {
+				inRange++
+			} else {
+				c.OutOfRangePorts++
+			}
+		}
+		c.AssignedPorts += inRange
+
+		need := d.Forwards
+		if need <= 0 {
+			need = len(defaultForwardSpecs)
+		}
+		if need > maxForwards {
+			maxForwards = need
+		}
+		// Out-of-range ports are reused on reconnect, so only the remainder
+		// has to come from the range.
+		outside := len(d.Assigned) - inRange
+		if remainder := need - outside; remainder > inRange {
+			c.RequiredPorts += remainder
+		} else {
+			c.RequiredPorts += inRange
+		}
+	}
+	if maxForwards > 0 {
+		c.PortsPerServer = maxForwards
+	}
+
+	if free := c.TotalPorts - c.RequiredPorts; free > 0 {
+		c.FreePorts = free
+		c.FreeServerSlots = free / c.PortsPerServer
+	}
+
+	// Exhausted: existing servers cannot all connect at once.
+	// Warning: the range is nearly full or has no room for another server.
+	switch {
+	case c.RequiredPorts > c.TotalPorts:
+		c.Level = CapacityExhausted
+	case c.FreeServerSlots == 0 || float64(c.RequiredPorts) >= PortCapacityWarnRatio*float64(c.TotalPorts):
+		c.Level = CapacityWarning
+	default:
+		c.Level = CapacityOK
+	}
+	return c
+}
+
+// Warnings returns operator-facing messages for a capacity that is not OK.
+func (c PortCapacity) Warnings() []string {
+	var warnings []string
+	switch c.Level {
+	case CapacityExhausted:
+		warnings = append(warnings, fmt.Sprintf(
+			"port range %d-%d provides %d ports but %d tunnel servers need %d; new tunnel connections will be rejected",
+			c.Start, c.End, c.TotalPorts, c.Servers, c.RequiredPorts))
+	case CapacityWarning:
+		warnings = append(warnings, fmt.Sprintf(
+			"port range %d-%d is nearly full: %d of %d ports required, room for %d more tunnel server(s)",
+			c.Start, c.End, c.RequiredPorts, c.TotalPorts, c.FreeServerSlots))
+	}
+	if c.OutOfRangePorts > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"%d assigned tunnel ports are outside %d-%d; they stay reserved until their servers are reassigned",
+			c.OutOfRangePorts, c.Start, c.End))
+	}
+	return warnings
+}
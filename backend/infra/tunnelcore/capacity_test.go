@@ -0,0 +1,67 @@
+package tunnelcore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputePortCapacityEmptyRange(t *testing.T) {
+	c := ComputePortCapacity(PortRange{Start: 40000, End: 40009}, nil)
+
+	if c.TotalPorts != 10 || c.PortsPerServer != 2 || c.FreeServerSlots != 5 {
+		t.Fatalf("unexpected capacity: %+v", c)
+	}
+	if c.Level != CapacityOK || len(c.Warnings()) != 0 {
+		t.Fatalf("expected ok without warnings, got %s %v", c.Level, c.Warnings())
+	}
+}
+
+func TestComputePortCapacityCountsUnconnectedServers(t *testing.T) {
+	c := ComputePortCapacity(PortRange{Start: 40000, End: 40009}, []PortDemand{
+		{Forwards: 2, Assigned: []int{40000, 40001}},
+		{Forwards: 3},
+		{Forwards: 2},
+	})
+
+	if c.RequiredPorts != 7 || c.AssignedPorts != 2 || c.PortsPerServer != 3 {
+		t.Fatalf("unexpected capacity: %+v", c)
+	}
+	if c.FreePorts != 3 || c.FreeServerSlots != 1 {
+		t.Fatalf("expected 3 free ports and 1 slot, got %+v", c)
+	}
+	if c.Level != CapacityOK {
+		t.Fatalf("expected ok at 70%%, got %s", c.Level)
+	}
+}
+
+func TestComputePortCapacityWarnsWhenNearlyFull(t *testing.T) {
+	c := ComputePortCapacity(PortRange{Start: 40000, End: 40009}, []PortDemand{
+		{Forwards: 2}, {Forwards: 2}, {Forwards: 2}, {Forwards: 2},
+	})
+
+	if c.Level != CapacityWarning {
+		t.Fatalf("expected warning at 80%%, got %s (%+v)", c.Level, c)
+	}
+	if w := c.Warnings(); len(w) != 1 || !strings.Contains(w[0], "nearly full") {
+		t.Fatalf("unexpected warnings: %v", w)
+	}
+}
+
+func TestComputePortCapacityExhaustedAndOutOfRange(t *testing.T) {
+	c := ComputePortCapacity(PortRange{Start: 41000, End: 41002}, []PortDemand{
+		{Forwards: 2, Assigned: []int{40000, 40001}},
+		{Forwards: 2},
+		{Forwards: 2},
+	})
+
+	// The first server keeps its out-of-range ports, so only 4 ports are required.
+	if c.OutOfRangePorts != 2 || c.RequiredPorts != 4 {
+		t.Fatalf("unexpected capacity: %+v", c)
+	}
+	if c.Level != CapacityExhausted || c.FreePorts != 0 || c.FreeServerSlots != 0 {
+		t.Fatalf("expected exhausted, got %+v", c)
+	}
+	if w := c.Warnings(); len(w) != 2 {
+		t.Fatalf("expected exhausted and out-of-range warnings, got %v", w)
+	}
+}
@@ -0,0 +1,85 @@
+package tunnelcore
+
+import "time"
+
+// SessionEventType classifies a registry transition.
+type SessionEventType string
+
+const (
+	SessionEventConnected    SessionEventType = "connected"
+	SessionEventDisconnected SessionEventType = "disconnected"
+)
+
+// sessionEventBuffer is the per-subscriber queue length. Events are dropped
+// for a subscriber whose queue is full rather than blocking the SSH server.
+const sessionEventBuffer = 16
+
+// SessionEvent is published when a session is registered or removed.
+type SessionEvent struct {
+	Type      SessionEventType     `json:"type"`
+	ClientID  string               `json:"client_id"`
+	Services  []Service            `json:"services,omitempty"`
+	Conflicts []ConflictResolution `json:"conflicts,omitempty"`
+	Reason    DisconnectReason     `json:"reason,omitempty"`
+	At        time.Time            `json:"at"`
+}
+
+type sessionSubscriber struct {
+	clientID string
+	ch       chan SessionEvent
+}
+
+// Subscribe returns a channel receiving connect/disconnect events for
+// clientID, or for every client when clientID is empty. The returned cancel
+// function unsubscribes and closes the channel; it is safe to call twice.
+func (r *Registry) Subscribe(clientID string) (<-chan SessionEvent, func()) {
+	sub := &sessionSubscriber{clientID: clientID, ch: make(chan SessionEvent, sessionEventBuffer)}
+	r.subMu.Lock()
+	if r.subscribers == nil {
+		r.subscribers = map[*sessionSubscriber]struct{}{}
+	}
+	r.subscribers[sub] = struct{}{}
+	r.subMu.Unlock()
+
+	cancel := func() {
+		r.subMu.Lock()
+		defer r.subMu.Unlock()
+		if _, ok := r.subscribers[sub]; ok {
+			delete(r.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, cancel
+}
+
+func (r *Registry) publish(event SessionEvent) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for sub := range r.subscribers {
+		if sub.clientID != "" && sub.clientID != event.ClientID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+func connectedEvent(clientID string, sess *Session) SessionEvent {
+	event := SessionEvent{Type: SessionEventConnected, ClientID: clientID, At: time.Now().UTC()}
+	if sess != nil {
+		event.Services = append([]Service(nil), sess.Services...)
+		event.Conflicts = append([]ConflictResolution(nil), sess.Conflicts...)
+	}
+	return event
+}
+
+func disconnectedEvent(clientID string, sess *Session) SessionEvent {
+	return SessionEvent{
+		Type:     SessionEventDisconnected,
+		ClientID: clientID,
+		Reason:   sess.DisconnectReason(),
+		At:       time.Now().UTC(),
+	}
+}
@@ -28,11 +28,11 @@ type ForwardSpec struct {
 // The caller (routes/tunnel.go) must update the DB record and write an audit entry.
 type ConflictResolution struct {
 	// ServiceName identifies which service's port was replaced.
-	ServiceName string
+	ServiceName string `json:"service_name"`
 	// OldPort is the port that was previously stored but found occupied.
-	OldPort int
+	OldPort int `json:"old_port"`
 	// NewPort is the freshly allocated replacement port.
-	NewPort int
+	NewPort int `json:"new_port"`
 }
 
 // PortRecord carries the persisted service assignments for one client.
@@ -129,6 +129,26 @@ func (p *PortPool) AcquireOrReuse(clientID string, desired []ForwardSpec) ([]Ser
 	return p.allocateNew(clientID, desired)
 }
 
+// Range returns the port range the pool allocates from.
+func (p *PortPool) Range() PortRange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PortRange{Start: p.start, End: p.end}
+}
+
+// SetRange changes the range new ports are allocated from and reports whether
+// it differed. Ports already assigned are kept, even outside the new range,
+// so connected servers are not re-pointed.
+func (p *PortPool) SetRange(r PortRange) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start == r.Start && p.end == r.End {
+		return false
+	}
+	p.start, p.end = r.Start, r.End
+	return true
+}
+
 // Release frees all ports assigned to clientID so they can be given to new clients.
 // It is a no-op when clientID has no reservation.
 func (p *PortPool) Release(clientID string) {
@@ -158,6 +158,32 @@ func TestPortPool_Release_Noop(t *testing.T) {
 	p.Release("nobody")
 }
 
+// ---- SetRange ------------------------------------------------------------
+
+func TestPortPool_SetRange_KeepsAssignedPorts(t *testing.T) {
+	p := newTestPool()
+	before, _ := p.AcquireOrReuse("srv1", testDesiredForwards())
+
+	if p.SetRange(PortRange{Start: testStart, End: testEnd}) {
+		t.Fatal("expected unchanged range to report false")
+	}
+	if !p.SetRange(PortRange{Start: testStart + 50, End: testEnd}) {
+		t.Fatal("expected new range to report true")
+	}
+	if r := p.Range(); r.Start != testStart+50 {
+		t.Fatalf("expected start %d, got %d", testStart+50, r.Start)
+	}
+
+	after, _ := p.AcquireOrReuse("srv1", testDesiredForwards())
+	if after[0].TunnelPort != before[0].TunnelPort {
+		t.Fatalf("expected srv1 to keep port %d, got %d", before[0].TunnelPort, after[0].TunnelPort)
+	}
+	fresh, _ := p.AcquireOrReuse("srv2", testDesiredForwards())
+	if len(fresh) == 0 || fresh[0].TunnelPort < testStart+50 {
+		t.Fatalf("expected srv2 to get ports from the new range, got %+v", fresh)
+	}
+}
+
 // ---- Conflict resolution -------------------------------------------------
 
 func TestPortPool_Conflict_OSPortInUse(t *testing.T) {
@@ -169,7 +169,8 @@ func (s *Server) handleConn(conn net.Conn) {
 	desiredForwards := s.ForwardResolver.Resolve(clientID)
 	services, conflicts := s.Pool.AcquireOrReuse(clientID, desiredForwards)
 	if services == nil {
-		log.Printf("[tunnel] port range exhausted for client %s", clientID)
+		r := s.Pool.Range()
+		log.Printf("[tunnel] port range %d-%d exhausted for client %s; widen tunnel/port_range", r.Start, r.End, clientID)
 		_ = sshConn.Close()
 		return
 	}
@@ -178,6 +179,7 @@ func (s *Server) handleConn(conn net.Conn) {
 		ClientID:    clientID,
 		Conn:        sshConn,
 		Services:    services,
+		Conflicts:   conflicts,
 		ConnectedAt: time.Now().UTC(),
 	}
 	s.Sessions.Register(clientID, sess)
@@ -189,11 +191,15 @@ func (s *Server) handleConn(conn net.Conn) {
 		_ = sshConn.Close()
 	}()
 
-	// Discard all incoming channel requests — this is a forward-only tunnel.
-	// Channels opened by the client (e.g. session) are rejected; only the
-	// server-initiated "forwarded-tcpip" channels are used.
+	// Client-opened channels are rejected (e.g. session) except the optional
+	// agent status channel; forwarding uses server-initiated "forwarded-tcpip"
+	// channels only.
 	go func() {
 		for newChan := range chans {
+			if newChan.ChannelType() == AgentChannelType {
+				go s.handleAgentChannel(sess, newChan)
+				continue
+			}
 			_ = newChan.Reject(ssh.Prohibited, "forward-only tunnel")
 		}
 	}()
@@ -452,6 +458,21 @@ func (s *Server) init() error {
 	return nil
 }
 
+// HostKeyFingerprint returns the SHA256 fingerprint (as printed by
+// ssh-keygen -lf) of the host key persisted in dataDir. It returns
+// os.ErrNotExist when the server has not generated a key yet.
+func HostKeyFingerprint(dataDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, HostKeyFile))
+	if err != nil {
+		return "", err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		return "", fmt.Errorf("tunnel: parse host key: %w", err)
+	}
+	return ssh.FingerprintSHA256(signer.PublicKey()), nil
+}
+
 // loadOrGenerateHostKey reads the Ed25519 host key from DataDir/tunnel_host_key.
 // If the file does not exist, a new key is generated and saved.
 func (s *Server) loadOrGenerateHostKey() (ssh.Signer, error) {
@@ -1,9 +1,12 @@
 package tunnelcore
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"golang.org/x/crypto/ssh"
 )
 
 // ---- Host key ------------------------------------------------------------
@@ -35,6 +38,26 @@ func TestServer_HostKeyPersisted(t *testing.T) {
 	}
 }
 
+func TestHostKeyFingerprintMatchesPersistedKey(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := HostKeyFingerprint(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist before key generation, got %v", err)
+	}
+
+	s := &Server{DataDir: dir}
+	signer, err := s.loadOrGenerateHostKey()
+	if err != nil {
+		t.Fatalf("loadOrGenerateHostKey: %v", err)
+	}
+	got, err := HostKeyFingerprint(dir)
+	if err != nil {
+		t.Fatalf("HostKeyFingerprint: %v", err)
+	}
+	if want := ssh.FingerprintSHA256(signer.PublicKey()); got != want {
+		t.Errorf("fingerprint = %q, want %q", got, want)
+	}
+}
+
 func TestServer_HostKeyGeneratedOnMissingFile(t *testing.T) {
 	dir := t.TempDir()
 	s := &Server{DataDir: dir}
@@ -18,6 +18,7 @@ const (
 	DisconnectReasonPausedByOperator   DisconnectReason = "paused_by_operator"
 	DisconnectReasonTokenRotated       DisconnectReason = "token_rotated"
 	DisconnectReasonSessionReplaced    DisconnectReason = "session_replaced"
+	DisconnectReasonServerDeleted      DisconnectReason = "server_deleted"
 )
 
 // Session represents an active reverse-SSH tunnel connection from one remote client.
@@ -29,10 +30,48 @@ type Session struct {
 	Conn *ssh.ServerConn
 	// Services describes the forwarded port pairs established for this session.
 	Services []Service
+	// Conflicts lists ports reassigned at connect time because the stored port
+	// was occupied.
+	Conflicts []ConflictResolution
 	// ConnectedAt is the UTC time the session was authenticated and registered.
 	ConnectedAt time.Time
 	// disconnectReason tracks the best-known classification for the session close.
 	disconnectReason DisconnectReason
+	// agentStatus is the latest report from an agent channel; nil for plain
+	// autossh clients.
+	agentStatus *AgentStatus
+}
+
+// SetAgentStatus stores the latest agent report for the session.
+func (s *Session) SetAgentStatus(status AgentStatus) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.agentStatus = &status
+	s.mu.Unlock()
+}
+
+// AgentStatus returns a copy of the latest agent report, or nil when the
+// client has not reported through an agent channel.
+func (s *Session) AgentStatus() *AgentStatus {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.agentStatus == nil {
+		return nil
+	}
+	status := *s.agentStatus
+	if status.Metrics != nil {
+		metrics := make(map[string]float64, len(status.Metrics))
+		for name, value := range status.Metrics {
+			metrics[name] = value
+		}
+		status.Metrics = metrics
+	}
+	return &status
 }
 
 func (s *Session) SetDisconnectReason(reason DisconnectReason) {
@@ -63,6 +102,9 @@ func (s *Session) DisconnectReason() DisconnectReason {
 type Registry struct {
 	mu       sync.RWMutex
 	sessions map[string]*Session
+
+	subMu       sync.Mutex
+	subscribers map[*sessionSubscriber]struct{}
 }
 
 // NewRegistry returns an initialised, empty Registry.
@@ -84,14 +126,19 @@ func (r *Registry) Register(clientID string, sess *Session) {
 	}
 	r.sessions[clientID] = sess
 	r.mu.Unlock()
+	r.publish(connectedEvent(clientID, sess))
 }
 
 // Unregister removes the session entry for clientID.
 // It is safe to call when no session exists for clientID.
 func (r *Registry) Unregister(clientID string) {
 	r.mu.Lock()
+	sess, ok := r.sessions[clientID]
 	delete(r.sessions, clientID)
 	r.mu.Unlock()
+	if ok {
+		r.publish(disconnectedEvent(clientID, sess))
+	}
 }
 
 // UnregisterConn removes the session entry for clientID only if the stored
@@ -99,10 +146,15 @@ func (r *Registry) Unregister(clientID string) {
 // connection from accidentally removing a newer replacement session.
 func (r *Registry) UnregisterConn(clientID string, conn *ssh.ServerConn) {
 	r.mu.Lock()
-	if s, ok := r.sessions[clientID]; ok && s.Conn == conn {
+	s, ok := r.sessions[clientID]
+	removed := ok && s.Conn == conn
+	if removed {
 		delete(r.sessions, clientID)
 	}
 	r.mu.Unlock()
+	if removed {
+		r.publish(disconnectedEvent(clientID, s))
+	}
 }
 
 // Get returns the Session for clientID, or (nil, false) when not found.
@@ -120,3 +120,35 @@ func TestRegistry_ConcurrentSafe(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestRegistry_SubscribeReceivesTransitions(t *testing.T) {
+	r := NewRegistry()
+	events, cancel := r.Subscribe("srv1")
+	defer cancel()
+
+	sess := newTestSession("srv1")
+	sess.Services = []Service{{Name: "ssh", LocalPort: 22, TunnelPort: 40001}}
+	sess.Conflicts = []ConflictResolution{{ServiceName: "ssh", OldPort: 40000, NewPort: 40001}}
+	r.Register("other", newTestSession("other"))
+	r.Register("srv1", sess)
+	sess.SetDisconnectReason(DisconnectReasonKeepaliveTimeout)
+	r.UnregisterConn("srv1", nil)
+
+	connected := <-events
+	if connected.Type != SessionEventConnected || connected.ClientID != "srv1" {
+		t.Fatalf("first event = %+v, want connected for srv1", connected)
+	}
+	if len(connected.Services) != 1 || len(connected.Conflicts) != 1 || connected.Conflicts[0].NewPort != 40001 {
+		t.Errorf("connected event services/conflicts = %+v / %+v", connected.Services, connected.Conflicts)
+	}
+	disconnected := <-events
+	if disconnected.Type != SessionEventDisconnected || disconnected.Reason != DisconnectReasonKeepaliveTimeout {
+		t.Errorf("second event = %+v, want disconnected with keepalive_timeout", disconnected)
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("channel should be closed after cancel")
+	}
+	r.Register("srv1", newTestSession("srv1")) // must not panic on closed subscriber
+}
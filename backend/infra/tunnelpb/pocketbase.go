@@ -8,7 +8,6 @@ import (
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/websoft9/appos/backend/domain/config/sysconfig"
-	settingscatalog "github.com/websoft9/appos/backend/domain/config/sysconfig/catalog"
 	tunnelcore "github.com/websoft9/appos/backend/infra/tunnelcore"
 )
 
@@ -22,7 +21,7 @@ func LoadPortRange(app core.App) tunnelcore.PortRange {
 		return tunnelcore.DefaultPortRange()
 	}
 
-	raw, _ := sysconfig.GetGroup(app, SettingsModule, PortRangeKey, settingscatalog.DefaultGroup(SettingsModule, PortRangeKey))
+	raw, _ := sysconfig.GetGroup(app, SettingsModule, PortRangeKey, nil)
 	return tunnelcore.NormalizePortRange(raw)
 }
 
@@ -43,7 +42,8 @@ func (v *pbForwardResolver) Resolve(serverID string) []tunnelcore.ForwardSpec {
 
 // Start builds and starts the reverse-SSH tunnel server using
 // PocketBase-backed adapters. It keeps HTTP routing concerns outside the tunnel kernel.
-func Start(app core.App, sessions *tunnelcore.Registry, tokenCache *sync.Map, pauseUntil func(*core.Record) time.Time, disconnectReasonLabel func(string) string, forwardLoader func(serverID string) ([]tunnelcore.ForwardSpec, error)) {
+// The returned pool lets callers apply port range changes without a restart.
+func Start(app core.App, sessions *tunnelcore.Registry, tokenCache *sync.Map, pauseUntil func(*core.Record) time.Time, disconnectReasonLabel func(string) string, forwardLoader func(serverID string) ([]tunnelcore.ForwardSpec, error)) *tunnelcore.PortPool {
 	portRange := LoadPortRange(app)
 	pool := tunnelcore.NewPortPool(portRange.Start, portRange.End)
 
@@ -73,4 +73,5 @@ func Start(app core.App, sessions *tunnelcore.Registry, tokenCache *sync.Map, pa
 			log.Printf("[tunnel] server stopped: %v", err)
 		}
 	}()
+	return pool
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/websoft9/appos/backend/domain/archive"
 	servers "github.com/websoft9/appos/backend/domain/resource/servers"
 	sec "github.com/websoft9/appos/backend/domain/secrets"
 	"github.com/websoft9/appos/backend/infra/tunnelcore"
@@ -20,7 +21,7 @@ type tunnelRepository struct {
 }
 
 func (r tunnelRepository) findManagedServerRecord(managedServerID string) (*core.Record, error) {
-	return r.app.FindRecordById("servers", managedServerID)
+	return archive.FindLive(r.app, "servers", managedServerID)
 }
 
 func (r tunnelRepository) findTunnelTokenSecret(managedServerID string) (*core.Record, error) {
@@ -42,7 +43,7 @@ func (r tunnelRepository) resolveManagedServerID(secret *core.Record) (string, e
 
 	server, err := r.app.FindFirstRecordByFilter(
 		"servers",
-		"credential = {:cid} && connect_type = 'tunnel'",
+		"credential = {:cid} && connect_type = 'tunnel' && "+archive.Field+" = ''",
 		dbx.Params{"cid": secret.Id},
 	)
 	if err != nil {
@@ -62,7 +63,7 @@ func (r tunnelRepository) updateTunnelTokenSecret(secret *core.Record, managedSe
 }
 
 func (r tunnelRepository) loadExistingPortRecords() ([]tunnelcore.PortRecord, error) {
-	records, err := r.app.FindAllRecords("servers")
+	records, err := r.app.FindAllRecords("servers", archive.Live())
 	if err != nil {
 		return nil, err
 	}
@@ -36,6 +36,7 @@ func ensureTunnelServersCollection(t *testing.T, app core.App) {
 	col.Fields.Add(&core.DateField{Name: "tunnel_pause_until"})
 	col.Fields.Add(&core.JSONField{Name: "tunnel_services"})
 	col.Fields.Add(&core.JSONField{Name: "tunnel_forwards"})
+	col.Fields.Add(&core.DateField{Name: "archived_at"})
 
 	if err := app.Save(col); err != nil {
 		t.Fatalf("create servers collection: %v", err)
@@ -1,6 +1,8 @@
 package tunnelpb
 
 import (
+	"database/sql"
+	"errors"
 	"log"
 	"time"
 
@@ -70,7 +72,13 @@ func (h *SessionHooks) OnDisconnect(managedServerID string, reason tunnelcore.Di
 
 	repo := tunnelRepository{app: h.App}
 	disconnectAt := time.Now().UTC()
-	_ = repo.saveDisconnectedState(managedServerID, reason, disconnectAt)
+	if err := repo.saveDisconnectedState(managedServerID, reason, disconnectAt); err != nil {
+		// The server record is gone when the session was torn down by its
+		// deletion; there is no state left to persist.
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[tunnel] OnDisconnect: save server %s: %v", managedServerID, err)
+		}
+	}
 
 	reasonLabel := string(reason)
 	if h.DisconnectReasonLabel != nil {
@@ -34,7 +34,7 @@ func (s *TokenService) Get(managedServerID string) (string, bool, error) {
 
 	rawToken, err := sec.ReadSystemSingleValue(sec.From(secret))
 	if err != nil {
-		return "", false, err
+		return "", false, sec.ReportDecryptFailure(s.App, err)
 	}
 	return rawToken, true, nil
 }
@@ -56,7 +56,11 @@ func (v *TokenValidator) validateAndPopulateCache(rawToken string) (string, bool
 
 	for _, secret := range secrets {
 		dec, err := sec.ReadSystemSingleValue(sec.From(secret))
-		if err != nil || dec == "" {
+		if err != nil {
+			sec.ReportDecryptFailure(v.App, err)
+			continue
+		}
+		if dec == "" {
 			continue
 		}
 
@@ -117,17 +117,6 @@ function parseDockerJsonLines<T>(output: string): T[] {
     .filter(Boolean) as T[]
 }
 
-function parseComposeProjects(output: string): OverviewComposeProject[] {
-  if (!output.trim()) return []
-  try {
-    const parsed = JSON.parse(output)
-    if (Array.isArray(parsed)) return parsed as OverviewComposeProject[]
-    return [parsed as OverviewComposeProject]
-  } catch {
-    return parseDockerJsonLines<OverviewComposeProject>(output)
-  }
-}
-
 function composeStatusVariant(status: string): 'default' | 'secondary' | 'destructive' | 'outline' {
   const value = status.toLowerCase()
   if (value.includes('running') || value.includes('healthy')) return 'default'
@@ -162,10 +151,10 @@ function OverviewTab({ serverId, disabled }: { serverId: string; disabled: boole
   const imagesQuery = useQuery<OverviewImage[]>({
     queryKey: ['docker', 'images', serverId],
     queryFn: async () => {
-      const res = await pb.send(`/api/ext/docker/images?server_id=${serverId}`, {
+      const res = (await pb.send(`/api/ext/docker/images?server_id=${serverId}&format=json`, {
         method: 'GET',
-      })
-      return parseDockerJsonLines<OverviewImage>(res.output)
+      })) as { items?: OverviewImage[] }
+      return res.items ?? []
     },
     enabled: !disabled,
     staleTime: 60_000,
@@ -204,10 +193,10 @@ function OverviewTab({ serverId, disabled }: { serverId: string; disabled: boole
   const composeQuery = useQuery<OverviewComposeProject[]>({
     queryKey: ['docker', 'compose', serverId],
     queryFn: async () => {
-      const res = await pb.send(`/api/ext/docker/compose/ls?server_id=${serverId}`, {
+      const res = (await pb.send(`/api/ext/docker/compose/ls?server_id=${serverId}&format=json`, {
         method: 'GET',
-      })
-      return parseComposeProjects(res.output)
+      })) as { items?: OverviewComposeProject[] }
+      return res.items ?? []
     },
     enabled: !disabled,
     staleTime: 60_000,
@@ -19,8 +19,10 @@ vi.mock('@/lib/connect-api', async () => {
     sftpChmod: (...args: unknown[]) => mockSftpChmod(...args),
     sftpChown: (...args: unknown[]) => mockSftpChown(...args),
     sftpUpload: vi.fn(async () => {}),
+    sftpUploadChunked: vi.fn(async () => {}),
     sftpSearch: vi.fn(async () => ({ path: '/', query: '', results: [] })),
     sftpDownloadUrl: vi.fn(() => '/download'),
+    sftpDownloadArchiveUrl: vi.fn(() => '/download-archive'),
     sftpMkdir: vi.fn(async () => {}),
     sftpRename: vi.fn(async () => {}),
     sftpDelete: vi.fn(async () => {}),
@@ -24,6 +24,8 @@ import {
   Share2,
   Copy,
   Check,
+  Star,
+  History,
 } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
@@ -31,6 +33,8 @@ import {
   DropdownMenu,
   DropdownMenuContent,
   DropdownMenuItem,
+  DropdownMenuLabel,
+  DropdownMenuSeparator,
   DropdownMenuTrigger,
 } from '@/components/ui/dropdown-menu'
 import {
@@ -66,15 +70,22 @@ import {
   sftpChown,
   sftpSymlink,
   sftpMove,
+  sftpDownloadArchiveUrl,
   sftpDownloadUrl,
   sftpUpload,
+  sftpUploadChunked,
   sftpMkdir,
   sftpRename,
   sftpDelete,
+  sftpBookmarks,
+  sftpAddBookmark,
+  sftpDeleteBookmark,
+  sftpRecentPaths,
   loadPreferences,
   savePreferences,
   type DirEntry,
   type SearchResult,
+  type SFTPPathEntry,
 } from '@/lib/connect-api'
 import { pb } from '@/lib/pb'
 import { FileEditorDialog } from './FileEditorDialog'
@@ -122,6 +133,15 @@ function formatDate(iso: string): string {
   })
 }
 
+// Dirs first, then alphabetically.
+function sortDirEntries(entries: DirEntry[]): DirEntry[] {
+  return [...entries].sort((a, b) => {
+    if (a.type === 'dir' && b.type !== 'dir') return -1
+    if (a.type !== 'dir' && b.type === 'dir') return 1
+    return a.name.localeCompare(b.name)
+  })
+}
+
 function joinPath(base: string, name: string): string {
   if (base === '/') return '/' + name
   return base + '/' + name
@@ -223,6 +243,14 @@ export function FileManagerPanel({
   const scopedInitialPath = clampPathToRoot(initialPath, lockedRootPath)
   const [currentPath, setCurrentPath] = useState('/')
   const [entries, setEntries] = useState<DirEntry[]>([])
+  const [listMeta, setListMeta] = useState<{
+    total: number
+    hasMore: boolean
+    truncated: boolean
+  } | null>(null)
+  const [loadingMore, setLoadingMore] = useState(false)
+  const [bookmarks, setBookmarks] = useState<SFTPPathEntry[]>([])
+  const [recentPaths, setRecentPaths] = useState<SFTPPathEntry[]>([])
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState<string | null>(null)
   const [showHidden, setShowHidden] = useState(() => loadPreferences().sftp_show_hidden)
@@ -233,6 +261,7 @@ export function FileManagerPanel({
   const [searchResults, setSearchResults] = useState<SearchResult[]>([])
   const [searchLoading, setSearchLoading] = useState(false)
   const [searchError, setSearchError] = useState<string | null>(null)
+  const [searchTruncated, setSearchTruncated] = useState(false)
 
   // Inline editing states
   const [mkdirMode, setMkdirMode] = useState(false)
@@ -296,13 +325,12 @@ export function FileManagerPanel({
       setError(null)
       try {
         const res = await sftpList(serverId, nextPath)
-        // Sort: dirs first, then alphabetically
-        const sorted = [...res.entries].sort((a, b) => {
-          if (a.type === 'dir' && b.type !== 'dir') return -1
-          if (a.type !== 'dir' && b.type === 'dir') return 1
-          return a.name.localeCompare(b.name)
+        setEntries(sortDirEntries(res.entries))
+        setListMeta({
+          total: res.total ?? res.entries.length,
+          hasMore: !!res.has_more,
+          truncated: !!res.truncated,
         })
-        setEntries(sorted)
         setCurrentPath(clampPathToRoot(res.path || nextPath, lockedRootPath))
       } catch (err) {
         setError(getApiErrorMessage(err, 'Failed to list directory'))
@@ -313,6 +341,24 @@ export function FileManagerPanel({
     [lockedRootPath, serverId]
   )
 
+  const loadMoreEntries = useCallback(async () => {
+    setLoadingMore(true)
+    setError(null)
+    try {
+      const res = await sftpList(serverId, currentPath, { offset: entries.length })
+      setEntries(prev => sortDirEntries([...prev, ...res.entries]))
+      setListMeta({
+        total: res.total ?? entries.length + res.entries.length,
+        hasMore: !!res.has_more,
+        truncated: !!res.truncated,
+      })
+    } catch (err) {
+      setError(getApiErrorMessage(err, 'Failed to list directory'))
+    } finally {
+      setLoadingMore(false)
+    }
+  }, [currentPath, entries.length, serverId])
+
   useEffect(() => {
     fetchEntries(scopedInitialPath)
   }, [fetchEntries, scopedInitialPath])
@@ -341,6 +387,39 @@ export function FileManagerPanel({
     fetchEntries(currentPath)
   }, [fetchEntries, currentPath])
 
+  // ─── Bookmarks & recent paths ─────────────────────────────────────────────
+
+  const loadQuickPaths = useCallback(async () => {
+    const inScope = (items: SFTPPathEntry[]) =>
+      items.filter(item => isPathWithinRoot(item.path, scopedRootPath))
+    try {
+      const [marks, recent] = await Promise.all([
+        sftpBookmarks(serverId),
+        sftpRecentPaths(serverId),
+      ])
+      setBookmarks(inScope(marks))
+      setRecentPaths(inScope(recent))
+    } catch {
+      setBookmarks([])
+      setRecentPaths([])
+    }
+  }, [scopedRootPath, serverId])
+
+  const currentBookmark = bookmarks.find(item => item.path === currentPath)
+
+  const handleToggleBookmark = async () => {
+    try {
+      if (currentBookmark) {
+        await sftpDeleteBookmark(serverId, currentBookmark.id)
+      } else {
+        await sftpAddBookmark(serverId, currentPath)
+      }
+      await loadQuickPaths()
+    } catch (err) {
+      setError(getApiErrorMessage(err, 'Failed to update bookmark'))
+    }
+  }
+
   // ─── Filtered entries ─────────────────────────────────────────────────────
 
   const visibleEntries = (() => {
@@ -380,6 +459,7 @@ export function FileManagerPanel({
     if (!searchRecursive || !searchQuery.trim()) {
       setSearchResults([])
       setSearchError(null)
+      setSearchTruncated(false)
       return
     }
     const q = searchQuery.trim()
@@ -388,10 +468,12 @@ export function FileManagerPanel({
       setSearchError(null)
       try {
         const res = await sftpSearch(serverId, currentPath, q)
-        setSearchResults(res.results)
+        setSearchResults(res.results ?? [])
+        setSearchTruncated(!!res.truncated)
       } catch (err) {
         setSearchError(getApiErrorMessage(err, 'Search failed'))
         setSearchResults([])
+        setSearchTruncated(false)
       } finally {
         setSearchLoading(false)
       }
@@ -417,9 +499,14 @@ export function FileManagerPanel({
   }
 
   const handleDownload = (entry: DirEntry) => {
-    const url = sftpDownloadUrl(serverId, joinPath(currentPath, entry.name))
+    const fullPath = joinPath(currentPath, entry.name)
+    // Directories are zipped on the server and streamed as one archive.
+    const isDir = entry.type === 'dir'
+    const url = isDir
+      ? sftpDownloadArchiveUrl(serverId, fullPath)
+      : sftpDownloadUrl(serverId, fullPath)
     const a = document.createElement('a')
-    a.download = entry.name
+    a.download = isDir ? `${entry.name}.zip` : entry.name
     // Use fetch with auth token; check HTTP status before creating blob
     fetch(url, { headers: { Authorization: pb.authStore.token } })
       .then(r => {
@@ -447,12 +534,16 @@ export function FileManagerPanel({
     setBusyMessage('Uploading files...')
     try {
       for (const file of Array.from(files)) {
-        if (file.size > MAX_UPLOAD_SIZE) {
-          setError(`File "${file.name}" exceeds 50 MB limit`)
-          continue
-        }
+        // Files over the single-request limit go through the chunked protocol.
+        const upload = file.size > MAX_UPLOAD_SIZE ? sftpUploadChunked : sftpUpload
         // Pass the current directory; backend appends the original filename.
-        await sftpUpload(serverId, currentPath, file)
+        try {
+          await upload(serverId, currentPath, file)
+        } catch (err) {
+          if ((err as { status?: number })?.status !== 409) throw err
+          if (!window.confirm(`"${file.name}" already exists. Overwrite it?`)) continue
+          await upload(serverId, currentPath, file, { overwrite: true })
+        }
       }
       refresh()
     } catch (err) {
@@ -763,7 +854,11 @@ export function FileManagerPanel({
     if (!deleteTarget) return
     setBusy(true)
     try {
-      await sftpDelete(serverId, joinPath(currentPath, deleteTarget.name))
+      await sftpDelete(
+        serverId,
+        joinPath(currentPath, deleteTarget.name),
+        deleteTarget.type === 'dir'
+      )
       setDeleteTarget(null)
       refresh()
     } catch (err) {
@@ -819,6 +914,45 @@ export function FileManagerPanel({
         >
           <ArrowUp className="h-4 w-4" />
         </Button>
+        <DropdownMenu onOpenChange={open => open && loadQuickPaths()}>
+          <DropdownMenuTrigger asChild>
+            <Button variant="ghost" size="icon" className="h-7 w-7" title="Bookmarks and recent">
+              <Star className={cn('h-4 w-4', currentBookmark && 'fill-current')} />
+            </Button>
+          </DropdownMenuTrigger>
+          <DropdownMenuContent align="start" className="w-72">
+            <DropdownMenuItem onClick={handleToggleBookmark}>
+              <Star className="h-4 w-4 mr-2" />
+              {currentBookmark ? 'Remove bookmark' : 'Bookmark this folder'}
+            </DropdownMenuItem>
+            <DropdownMenuSeparator />
+            <DropdownMenuLabel className="text-xs text-muted-foreground">
+              Bookmarks
+            </DropdownMenuLabel>
+            {bookmarks.length === 0 ? (
+              <DropdownMenuItem disabled>No bookmarks</DropdownMenuItem>
+            ) : (
+              bookmarks.map(item => (
+                <DropdownMenuItem key={item.id} onClick={() => navigateTo(item.path)}>
+                  <Folder className="h-4 w-4 mr-2 shrink-0" />
+                  <span className="truncate">{item.label || item.path}</span>
+                </DropdownMenuItem>
+              ))
+            )}
+            <DropdownMenuSeparator />
+            <DropdownMenuLabel className="text-xs text-muted-foreground">Recent</DropdownMenuLabel>
+            {recentPaths.length === 0 ? (
+              <DropdownMenuItem disabled>No recent folders</DropdownMenuItem>
+            ) : (
+              recentPaths.map(item => (
+                <DropdownMenuItem key={item.id} onClick={() => navigateTo(item.path)}>
+                  <History className="h-4 w-4 mr-2 shrink-0" />
+                  <span className="truncate">{item.path}</span>
+                </DropdownMenuItem>
+              ))
+            )}
+          </DropdownMenuContent>
+        </DropdownMenu>
 
         <div className="flex items-center gap-0.5 text-sm text-muted-foreground overflow-x-auto flex-1 min-w-0">
           {segments.map((seg, i) => (
@@ -1186,12 +1320,10 @@ export function FileManagerPanel({
                             Edit
                           </DropdownMenuItem>
                         )}
-                        {entry.type !== 'dir' && (
-                          <DropdownMenuItem onClick={() => handleDownload(entry)}>
-                            <Download className="h-4 w-4 mr-2" />
-                            Download
-                          </DropdownMenuItem>
-                        )}
+                        <DropdownMenuItem onClick={() => handleDownload(entry)}>
+                          <Download className="h-4 w-4 mr-2" />
+                          {entry.type === 'dir' ? 'Download as zip' : 'Download'}
+                        </DropdownMenuItem>
                         <DropdownMenuItem
                           data-testid={`properties-${entry.name}`}
                           onClick={() => handleProperties(entry)}
@@ -1303,12 +1435,10 @@ export function FileManagerPanel({
                               Edit
                             </DropdownMenuItem>
                           )}
-                          {entry.type !== 'dir' && (
-                            <DropdownMenuItem onClick={() => handleDownload(entry)}>
-                              <Download className="h-4 w-4 mr-2" />
-                              Download
-                            </DropdownMenuItem>
-                          )}
+                          <DropdownMenuItem onClick={() => handleDownload(entry)}>
+                            <Download className="h-4 w-4 mr-2" />
+                            {entry.type === 'dir' ? 'Download as zip' : 'Download'}
+                          </DropdownMenuItem>
                           <DropdownMenuItem
                             data-testid={`properties-${entry.name}`}
                             onClick={() => handleProperties(entry)}
@@ -1372,7 +1502,7 @@ export function FileManagerPanel({
       <div className="flex items-center justify-between px-3 py-1 border-t text-xs text-muted-foreground shrink-0">
         {searchRecursive && searchQuery.trim() ? (
           <span>
-            {searchResults.length} results{searchResults.length >= 500 ? ' (limit reached)' : ''}
+            {searchResults.length} results{searchTruncated ? ' (limit reached)' : ''}
           </span>
         ) : (
           <span>
@@ -1380,6 +1510,18 @@ export function FileManagerPanel({
             {entries.length !== visibleEntries.length &&
               ` (${entries.length - visibleEntries.length} hidden)`}
             {!searchRecursive && searchQuery.trim() && ` · filtered`}
+            {listMeta?.hasMore && ` · ${entries.length} of ${listMeta.total} loaded`}
+            {listMeta?.truncated && ` · listing truncated`}
+            {listMeta?.hasMore && (
+              <button
+                type="button"
+                className="ml-2 underline hover:text-foreground disabled:opacity-50"
+                onClick={loadMoreEntries}
+                disabled={loadingMore}
+              >
+                {loadingMore ? 'Loading…' : 'Load more'}
+              </button>
+            )}
           </span>
         )}
         <span className="truncate max-w-[200px]">{currentPath}</span>
@@ -1393,8 +1535,9 @@ export function FileManagerPanel({
               Delete {deleteTarget?.type === 'dir' ? 'folder' : 'file'}
             </AlertDialogTitle>
             <AlertDialogDescription>
-              Are you sure you want to delete <strong>{deleteTarget?.name}</strong>? This action
-              cannot be undone.
+              Are you sure you want to delete <strong>{deleteTarget?.name}</strong>
+              {deleteTarget?.type === 'dir' ? ' and everything in it' : ''}? This action cannot be
+              undone.
             </AlertDialogDescription>
           </AlertDialogHeader>
           <AlertDialogFooter>
@@ -55,8 +55,18 @@ const categoryMeta: Record<ConnectErrorCategory, { icon: typeof AlertCircle; lab
   server_disconnected: { icon: Unplug, label: 'Server Disconnected' },
 }
 
-function makeResizeFrame(cols: number, rows: number): Uint8Array {
+// Terminal WebSocket subprotocols. Under v1, text frames carry JSON control
+// messages and binary frames carry terminal data only. The legacy protocol
+// (also used when the server negotiates none) marks control frames with a
+// 0x00 prefix on binary frames.
+const TERMINAL_PROTOCOL_V1 = 'appos-terminal-v1'
+const TERMINAL_PROTOCOL_LEGACY = 'appos-terminal-legacy'
+
+function makeResizeFrame(protocol: string, cols: number, rows: number): string | Uint8Array {
   const json = JSON.stringify({ type: 'resize', cols, rows })
+  if (protocol === TERMINAL_PROTOCOL_V1) {
+    return json
+  }
   const payload = new TextEncoder().encode(json)
   const frame = new Uint8Array(1 + payload.length)
   frame[0] = 0x00 // control frame prefix
@@ -109,7 +119,7 @@ export const TerminalPanel = forwardRef<TerminalPanelHandle, TerminalPanelProps>
       if (!fitAddon || !terminal) return
       fitAddon.fit()
       if (ws && ws.readyState === WebSocket.OPEN) {
-        ws.send(makeResizeFrame(terminal.cols, terminal.rows))
+        ws.send(makeResizeFrame(ws.protocol, terminal.cols, terminal.rows))
       }
     }, [])
 
@@ -227,7 +237,7 @@ export const TerminalPanel = forwardRef<TerminalPanelHandle, TerminalPanelProps>
       window.setTimeout(() => scheduleFitAndSync(), 0)
 
       // Open WebSocket
-      const ws = new WebSocket(url.toString())
+      const ws = new WebSocket(url.toString(), [TERMINAL_PROTOCOL_V1, TERMINAL_PROTOCOL_LEGACY])
       ws.binaryType = 'arraybuffer'
       wsRef.current = ws
 
@@ -236,37 +246,54 @@ export const TerminalPanel = forwardRef<TerminalPanelHandle, TerminalPanelProps>
         terminal.focus()
         // Send initial resize
         const { cols, rows } = terminal
-        ws.send(makeResizeFrame(cols, rows))
+        ws.send(makeResizeFrame(ws.protocol, cols, rows))
+      }
+
+      // Control message (banner/error/close/timeout sent by backend) as a JSON payload
+      const handleControl = (json: string) => {
+        try {
+          const ctrl = JSON.parse(json) as {
+            type: string
+            category?: string
+            message?: string
+          }
+          if (ctrl.type === 'banner') {
+            if (ctrl.message) {
+              terminal.write(`\x1b[1;33m${ctrl.message.replace(/\r?\n/g, '\r\n')}\x1b[0m\r\n\r\n`)
+            }
+          } else if (ctrl.type === 'timeout') {
+            structuredErrorRef.current = true
+            setError(ctrl.message ?? 'Session closed after timing out')
+            setErrorCategory(null)
+            ws.close(1000)
+          } else if (ctrl.type === 'error' || ctrl.type === 'close') {
+            structuredErrorRef.current = true
+            setError(ctrl.message ?? `Connection ${ctrl.type}`)
+            if (ctrl.category && ctrl.category in categoryMeta) {
+              setErrorCategory(ctrl.category as ConnectErrorCategory)
+            } else {
+              setErrorCategory(null)
+            }
+            ws.close(1000)
+          }
+        } catch {
+          // Not a valid control frame — ignore silently
+        }
       }
 
       ws.onmessage = event => {
+        const strict = ws.protocol === TERMINAL_PROTOCOL_V1
         if (event.data instanceof ArrayBuffer) {
           const bytes = new Uint8Array(event.data)
-          // Control frame: 0x00 prefix + JSON payload (error/close sent by backend)
-          if (bytes.length > 0 && bytes[0] === 0x00) {
-            try {
-              const ctrl = JSON.parse(new TextDecoder().decode(bytes.slice(1))) as {
-                type: string
-                category?: string
-                message?: string
-              }
-              if (ctrl.type === 'error' || ctrl.type === 'close') {
-                structuredErrorRef.current = true
-                setError(ctrl.message ?? `Connection ${ctrl.type}`)
-                if (ctrl.category && ctrl.category in categoryMeta) {
-                  setErrorCategory(ctrl.category as ConnectErrorCategory)
-                } else {
-                  setErrorCategory(null)
-                }
-                ws.close(1000)
-              }
-            } catch {
-              // Not a valid control frame — ignore silently
-            }
+          // Legacy control frame: 0x00 prefix + JSON payload
+          if (!strict && bytes.length > 0 && bytes[0] === 0x00) {
+            handleControl(new TextDecoder().decode(bytes.slice(1)))
             return
           }
           terminal.write(bytes)
           scrollToBottom()
+        } else if (strict) {
+          handleControl(event.data)
         } else {
           terminal.write(event.data)
           scrollToBottom()
@@ -301,7 +328,7 @@ export const TerminalPanel = forwardRef<TerminalPanelHandle, TerminalPanelProps>
       // Terminal resize → control frame
       terminal.onResize(({ cols, rows }) => {
         if (ws.readyState === WebSocket.OPEN) {
-          ws.send(makeResizeFrame(cols, rows))
+          ws.send(makeResizeFrame(ws.protocol, cols, rows))
         }
       })
     }, [
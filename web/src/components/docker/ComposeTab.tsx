@@ -85,39 +85,6 @@ function parseContainers(output: string): Container[] {
     .filter(Boolean) as Container[]
 }
 
-function parseInspect(output: string): Record<string, any> | null {
-  try {
-    const parsed = JSON.parse(output)
-    if (Array.isArray(parsed) && parsed[0]) return parsed[0] as Record<string, any>
-    return null
-  } catch {
-    return null
-  }
-}
-
-function parseProjects(output: string): ComposeProject[] {
-  if (!output.trim()) return []
-  try {
-    // docker compose ls --format json returns a JSON array
-    const parsed = JSON.parse(output)
-    if (Array.isArray(parsed)) return parsed
-    return [parsed]
-  } catch {
-    // fallback: try NDJSON (one JSON object per line)
-    return output
-      .trim()
-      .split('\n')
-      .map(line => {
-        try {
-          return JSON.parse(line)
-        } catch {
-          return null
-        }
-      })
-      .filter(Boolean) as ComposeProject[]
-  }
-}
-
 function statusVariant(status: string): 'default' | 'secondary' | 'destructive' | 'outline' {
   if (status?.toLowerCase().includes('running')) return 'default'
   if (status?.toLowerCase().includes('exited') || status?.toLowerCase().includes('dead'))
@@ -205,10 +172,10 @@ export function ComposeTab({
   } = useQuery<ComposeProject[]>({
     queryKey: ['docker', 'compose', serverId],
     queryFn: async () => {
-      const res = await pb.send(`/api/ext/docker/compose/ls?server_id=${serverId}`, {
+      const res = (await pb.send(`/api/ext/docker/compose/ls?server_id=${serverId}&format=json`, {
         method: 'GET',
-      })
-      return parseProjects(res.output)
+      })) as { items?: ComposeProject[] }
+      return res.items ?? []
     },
     staleTime: 60_000,
     gcTime: 5 * 60_000,
@@ -251,7 +218,7 @@ export function ComposeTab({
                 `/api/ext/docker/containers/${container.ID}?server_id=${serverId}`,
                 { method: 'GET' }
               )
-              return [container, parseInspect(inspectRes.output)] as const
+              return [container, inspectRes.inspect ?? null] as const
             } catch {
               return [container, null] as const
             }
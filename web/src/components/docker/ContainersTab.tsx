@@ -193,16 +193,6 @@ function hostPublishedPorts(rawPorts?: string): string {
   return Array.from(new Set(values)).join(', ')
 }
 
-function parseInspect(output: string): Record<string, any> | null {
-  try {
-    const parsed = JSON.parse(output)
-    if (Array.isArray(parsed) && parsed[0]) return parsed[0] as Record<string, any>
-    return null
-  } catch {
-    return null
-  }
-}
-
 function containerIP(inspect?: Record<string, any> | null): string {
   const networks = inspect?.NetworkSettings?.Networks as Record<string, any> | undefined
   if (!networks) return '-'
@@ -424,7 +414,7 @@ export function ContainersTab({
           `/api/ext/docker/containers/${containerId}?server_id=${serverId}`,
           { method: 'GET' }
         )
-        const inspect = parseInspect(inspectRes.output)
+        const inspect = inspectRes.inspect ?? null
         if (inspect) {
           setInspectMap(state => ({ ...state, [containerId]: inspect }))
         }
@@ -450,7 +440,7 @@ export function ContainersTab({
               `/api/ext/docker/containers/${container.ID}?server_id=${serverId}`,
               { method: 'GET' }
             )
-            return [container.ID, parseInspect(inspectRes.output)] as const
+            return [container.ID, inspectRes.inspect ?? null] as const
           } catch {
             return [container.ID, null] as const
           }
@@ -86,21 +86,6 @@ interface RegistrySearchItem {
   is_official?: boolean
 }
 
-function parseImages(output: string): DockerImage[] {
-  if (!output.trim()) return []
-  return output
-    .trim()
-    .split('\n')
-    .map(line => {
-      try {
-        return JSON.parse(line)
-      } catch {
-        return null
-      }
-    })
-    .filter(Boolean) as DockerImage[]
-}
-
 function parseContainers(output: string): DockerContainerRow[] {
   if (!output.trim()) return []
   return output
@@ -238,8 +223,10 @@ export function ImagesTab({ serverId }: { serverId: string }) {
   } = useQuery<DockerImage[]>({
     queryKey: ['docker', 'images', serverId],
     queryFn: async () => {
-      const res = await pb.send(`/api/ext/docker/images?server_id=${serverId}`, { method: 'GET' })
-      return parseImages(res.output)
+      const res = (await pb.send(`/api/ext/docker/images?server_id=${serverId}&format=json`, {
+        method: 'GET',
+      })) as { items?: DockerImage[] }
+      return res.items ?? []
     },
     staleTime: 10_000,
     gcTime: 5 * 60_000,
@@ -403,11 +390,53 @@ export function ImagesTab({ serverId }: { serverId: string }) {
       setActionError(null)
       setPulling(true)
       setPullLog(`Pulling ${name}...`)
-      const res = await pb.send(`/api/ext/docker/images/pull?server_id=${serverId}`, {
-        method: 'POST',
-        body: { name },
-      })
-      setPullLog(String(res.output || '(no output)'))
+      const res = await fetch(
+        `/api/ext/docker/images/pull-stream?server_id=${encodeURIComponent(serverId)}&name=${encodeURIComponent(name)}`,
+        { headers: { Authorization: pb.authStore.token } }
+      )
+      if (!res.ok || !res.body) {
+        const data = await res.json().catch(() => null)
+        throw new Error(data?.message || `Pull failed: ${res.status}`)
+      }
+      // Layer lines are kept in first-seen order and updated in place.
+      const layers = new Map<string, string>()
+      let percent = 0
+      const render = (tail = '') =>
+        setPullLog(
+          [`Pulling ${name}... ${percent}%`, ...layers.values(), tail].filter(Boolean).join('\n')
+        )
+      const reader = res.body.getReader()
+      const decoder = new TextDecoder()
+      let buf = ''
+      for (;;) {
+        const { done, value } = await reader.read()
+        if (done) break
+        buf += decoder.decode(value, { stream: true })
+        const chunks = buf.split('\n\n')
+        buf = chunks.pop() ?? ''
+        for (const chunk of chunks) {
+          const event = chunk.split('\n').find(l => l.startsWith('event: '))?.slice(7)
+          const line = chunk.split('\n').find(l => l.startsWith('data: '))
+          if (!line) continue
+          const data = JSON.parse(line.slice(6)) as {
+            layer?: { id: string; status: string; percent: number }
+            percent?: number
+            status?: string
+            digest?: string
+            message?: string
+          }
+          if (event === 'error') throw new Error(data.message || 'Failed to pull image')
+          if (event === 'progress' && data.layer) {
+            layers.set(data.layer.id, `${data.layer.id}: ${data.layer.status} (${data.layer.percent}%)`)
+            percent = data.percent ?? percent
+            render()
+          }
+          if (event === 'done') {
+            percent = 100
+            render([data.status, data.digest && `Digest: ${data.digest}`].filter(Boolean).join('\n'))
+          }
+        }
+      }
       await Promise.all([
         queryClient.invalidateQueries({ queryKey: ['docker', 'images', serverId] }),
         queryClient.invalidateQueries({
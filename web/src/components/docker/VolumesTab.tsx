@@ -80,16 +80,6 @@ function parseContainers(output: string): Container[] {
     .filter(Boolean) as Container[]
 }
 
-function parseInspect(output: string): Record<string, any> | null {
-  try {
-    const parsed = JSON.parse(output)
-    if (Array.isArray(parsed) && parsed[0]) return parsed[0] as Record<string, any>
-    return null
-  } catch {
-    return null
-  }
-}
-
 function parseVolumes(output: string): Volume[] {
   if (!output.trim()) return []
   return output
@@ -197,7 +187,7 @@ export function VolumesTab({
               `/api/ext/docker/containers/${container.ID}?server_id=${serverId}`,
               { method: 'GET' }
             )
-            return [container.Names, parseInspect(inspectRes.output)] as const
+            return [container.Names, inspectRes.inspect ?? null] as const
           } catch {
             return [container.Names, null] as const
           }
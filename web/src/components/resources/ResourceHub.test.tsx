@@ -84,7 +84,13 @@ describe('ResourceHub', () => {
         return Promise.resolve([{ id: 'conn-1' }, { id: 'conn-2' }, { id: 'conn-3' }])
       }
       if (path === '/api/ext/resources/scripts') {
-        return Promise.resolve([{ id: 'script-1' }])
+        return Promise.resolve({
+          items: [{ id: 'script-1' }],
+          page: 1,
+          perPage: 50,
+          totalItems: 1,
+          totalPages: 1,
+        })
       }
       return Promise.resolve([])
     })
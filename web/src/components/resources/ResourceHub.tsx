@@ -224,7 +224,8 @@ export function ResourceHub() {
             typeof data === 'object' &&
             Array.isArray((data as { items?: unknown[] }).items)
           ) {
-            return { key: r.key, count: (data as { items: unknown[] }).items.length }
+            const page = data as { items: unknown[]; totalItems?: number }
+            return { key: r.key, count: page.totalItems ?? page.items.length }
           }
           return { key: r.key, count: 0 }
         })
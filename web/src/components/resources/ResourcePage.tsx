@@ -519,8 +519,11 @@ export function ResourcePage({ config }: { config: ResourcePageConfig }) {
     try {
       const data = listItemsRef.current
         ? await listItemsRef.current()
-        : await pb.send<Record<string, unknown>[]>(config.apiPath, {})
-      setItems(Array.isArray(data) ? data : [])
+        : await pb.send<Record<string, unknown>[] | { items?: Record<string, unknown>[] }>(
+            config.apiPath,
+            {}
+          )
+      setItems(Array.isArray(data) ? data : Array.isArray(data?.items) ? data.items : [])
       setError('')
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to load data')
@@ -851,16 +854,20 @@ export function ResourcePage({ config }: { config: ResourcePageConfig }) {
         }
         await syncGroupMemberships(String(editingItem.id), selectedGroups)
       } else {
+        // The create APIs accept `groups` directly; custom creators may not
+        // forward it, so their memberships are synced afterwards.
         const created = config.createItem
           ? await config.createItem(payload)
           : await pb.send(config.apiPath, {
               method: 'POST',
-              body: payload,
+              body: selectedGroups.length > 0 ? { ...payload, groups: selectedGroups } : payload,
             })
-        await syncGroupMemberships(
-          String((created as Record<string, unknown>).id ?? ''),
-          selectedGroups
-        )
+        if (config.createItem) {
+          await syncGroupMemberships(
+            String((created as Record<string, unknown>).id ?? ''),
+            selectedGroups
+          )
+        }
         setDialogOpen(false)
         await fetchItems()
         config.onCreateSuccess?.(created as Record<string, unknown>)
@@ -3,9 +3,14 @@ type ErrorPayload = {
   data?: {
     error?: unknown
     message?: unknown
+    reason_code?: unknown
   }
 }
 
+// Reason codes whose message is already user-facing; the raw CLI detail is
+// noise for these.
+const SELF_DESCRIBING_REASON_CODES = new Set(['DOCKER_DAEMON_UNREACHABLE'])
+
 function normalizeString(value: unknown): string {
   if (typeof value === 'string') return value.trim()
   if (typeof value === 'number' || typeof value === 'boolean') return String(value)
@@ -29,7 +34,11 @@ export function getApiErrorMessage(error: unknown, fallback: string): string {
   const statusText = normalizeString(err?.status)
 
   const baseMessage = responseMessage || responseDataMessage || dataMessage || topMessage
+  const reasonCode = normalizeString(err?.response?.data?.reason_code || err?.data?.reason_code)
 
+  if (baseMessage && SELF_DESCRIBING_REASON_CODES.has(reasonCode)) {
+    return baseMessage
+  }
   if (baseMessage && detail && !baseMessage.includes(detail)) {
     return `${baseMessage}: ${detail}`
   }
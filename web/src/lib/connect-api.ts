@@ -18,6 +18,11 @@ export interface DirEntry {
 export interface SFTPListResponse {
   path: string
   entries: DirEntry[]
+  total: number // entries in the directory (after the server-side cap)
+  offset: number
+  limit: number
+  has_more: boolean
+  truncated: boolean // directory exceeded the server-side cap or time budget
 }
 
 export interface SearchResult {
@@ -47,6 +52,14 @@ export interface SFTPSearchResponse {
   path: string // search base path
   query: string
   results: SearchResult[]
+  truncated: boolean // result or walk limit reached
+}
+
+export interface SFTPPathEntry {
+  id: string
+  path: string
+  label?: string
+  updated_at: string
 }
 
 export interface Server {
@@ -102,6 +115,7 @@ export interface SystemdUnitApplyResponse {
   verify_output?: string
   reload_output?: string
   apply_output?: string
+  forced?: boolean
 }
 
 export interface MonitorAgentDeployResponse {
@@ -209,11 +223,15 @@ function terminalSftpBasePath(serverId: string): string {
   return `/api/terminal/sftp/${serverId}`
 }
 
-export async function sftpList(serverId: string, path: string): Promise<SFTPListResponse> {
-  return pb.send<SFTPListResponse>(
-    `${terminalSftpBasePath(serverId)}/list?path=${encodeURIComponent(path)}`,
-    {}
-  )
+export async function sftpList(
+  serverId: string,
+  path: string,
+  page: { offset?: number; limit?: number } = {}
+): Promise<SFTPListResponse> {
+  const params = new URLSearchParams({ path })
+  if (page.offset) params.set('offset', String(page.offset))
+  if (page.limit) params.set('limit', String(page.limit))
+  return pb.send<SFTPListResponse>(`${terminalSftpBasePath(serverId)}/list?${params}`, {})
 }
 
 export async function getLocalDockerBridgeAddress(): Promise<string> {
@@ -227,17 +245,82 @@ export function sftpDownloadUrl(serverId: string, path: string): string {
   return `${terminalSftpBasePath(serverId)}/download?path=${encodeURIComponent(path)}`
 }
 
-// sftpUpload uploads a single file to the given remote DIRECTORY.
-// The backend appends the file's original name to form the final path.
-export async function sftpUpload(serverId: string, remoteDir: string, file: File): Promise<void> {
+// sftpDownloadArchiveUrl returns the URL that streams a remote directory as a zip.
+export function sftpDownloadArchiveUrl(serverId: string, path: string): string {
+  return `${terminalSftpBasePath(serverId)}/download-archive?path=${encodeURIComponent(path)}`
+}
+
+// sftpUpload uploads a single file to the given remote DIRECTORY, named
+// options.name or the file's own name. An existing destination fails with 409
+// unless options.overwrite is set.
+export async function sftpUpload(
+  serverId: string,
+  remoteDir: string,
+  file: File,
+  options: { name?: string; overwrite?: boolean } = {}
+): Promise<void> {
   const formData = new FormData()
   formData.append('file', file)
-  await pb.send(`${terminalSftpBasePath(serverId)}/upload?path=${encodeURIComponent(remoteDir)}`, {
+  const params = new URLSearchParams({ path: remoteDir })
+  if (options.name) params.set('name', options.name)
+  if (options.overwrite) params.set('overwrite', 'true')
+  await pb.send(`${terminalSftpBasePath(serverId)}/upload?${params.toString()}`, {
     method: 'POST',
     body: formData,
   })
 }
 
+// SFTP_UPLOAD_CHUNK_BYTES is the chunk size used by sftpUploadChunked.
+const SFTP_UPLOAD_CHUNK_BYTES = 16 * 1024 * 1024
+
+// sftpUploadChunked uploads a file of any size in chunks through
+// upload/init, upload/chunk and upload/complete. A failed chunk is retried
+// from the offset the server last recorded.
+export async function sftpUploadChunked(
+  serverId: string,
+  remoteDir: string,
+  file: File,
+  options: { name?: string; overwrite?: boolean; onProgress?: (sent: number) => void } = {}
+): Promise<void> {
+  const base = `${terminalSftpBasePath(serverId)}/upload`
+  const init = await pb.send<{ upload_id: string; max_chunk_bytes: number }>(`${base}/init`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({
+      path: remoteDir,
+      name: options.name || file.name,
+      size: file.size,
+      overwrite: !!options.overwrite,
+    }),
+  })
+  const chunkBytes = Math.min(SFTP_UPLOAD_CHUNK_BYTES, init.max_chunk_bytes)
+  let offset = 0
+  let retries = 0
+  while (offset < file.size) {
+    const params = new URLSearchParams({ upload_id: init.upload_id, offset: String(offset) })
+    try {
+      const res = await pb.send<{ offset: number }>(`${base}/chunk?${params.toString()}`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/octet-stream' },
+        body: file.slice(offset, offset + chunkBytes),
+      })
+      offset = res.offset
+      retries = 0
+      options.onProgress?.(offset)
+    } catch (err) {
+      const e = err as { status?: number; response?: { offset?: number } }
+      if (retries >= 3 || e.status === 404 || e.status === 413) throw err
+      retries++
+      if (typeof e.response?.offset === 'number') offset = e.response.offset
+    }
+  }
+  await pb.send(`${base}/complete`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ upload_id: init.upload_id }),
+  })
+}
+
 export async function sftpMkdir(serverId: string, path: string): Promise<void> {
   await pb.send(`${terminalSftpBasePath(serverId)}/mkdir`, {
     method: 'POST',
@@ -259,8 +342,9 @@ export async function sftpRename(
   })
 }
 
-export async function sftpDelete(serverId: string, path: string): Promise<void> {
-  await pb.send(`${terminalSftpBasePath(serverId)}/delete?path=${encodeURIComponent(path)}`, {
+export async function sftpDelete(serverId: string, path: string, recursive = false): Promise<void> {
+  const query = `path=${encodeURIComponent(path)}${recursive ? '&recursive=true' : ''}`
+  await pb.send(`${terminalSftpBasePath(serverId)}/delete?${query}`, {
     method: 'DELETE',
   })
 }
@@ -302,6 +386,40 @@ export async function sftpConstraints(serverId: string): Promise<{ max_upload_fi
   return pb.send<{ max_upload_files: number }>(`${terminalSftpBasePath(serverId)}/constraints`, {})
 }
 
+export async function sftpBookmarks(serverId: string): Promise<SFTPPathEntry[]> {
+  const res = await pb.send<{ items: SFTPPathEntry[] }>(
+    `${terminalSftpBasePath(serverId)}/bookmarks`,
+    {}
+  )
+  return res.items ?? []
+}
+
+export async function sftpAddBookmark(
+  serverId: string,
+  path: string,
+  label = ''
+): Promise<SFTPPathEntry> {
+  return pb.send<SFTPPathEntry>(`${terminalSftpBasePath(serverId)}/bookmarks`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ path, label }),
+  })
+}
+
+export async function sftpDeleteBookmark(serverId: string, id: string): Promise<void> {
+  await pb.send(`${terminalSftpBasePath(serverId)}/bookmarks/${encodeURIComponent(id)}`, {
+    method: 'DELETE',
+  })
+}
+
+export async function sftpRecentPaths(serverId: string): Promise<SFTPPathEntry[]> {
+  const res = await pb.send<{ items: SFTPPathEntry[] }>(
+    `${terminalSftpBasePath(serverId)}/recent`,
+    {}
+  )
+  return res.items ?? []
+}
+
 export async function sftpStat(serverId: string, path: string): Promise<{ attrs: FileAttrs }> {
   return pb.send<{ attrs: FileAttrs }>(
     `${terminalSftpBasePath(serverId)}/stat?path=${encodeURIComponent(path)}`,
@@ -476,13 +594,16 @@ export async function getSystemdUnit(
   )
 }
 
+// updateSystemdUnit validates the unit server-side before writing it; pass
+// force to save content that fails validation.
 export async function updateSystemdUnit(
   serverId: string,
   service: string,
-  content: string
+  content: string,
+  force = false
 ): Promise<SystemdUnitApplyResponse> {
   return pb.send<SystemdUnitApplyResponse>(
-    `/api/servers/${serverId}/ops/systemd/${encodeURIComponent(service)}/unit`,
+    `/api/servers/${serverId}/ops/systemd/${encodeURIComponent(service)}/unit${force ? '?force=true' : ''}`,
     {
       method: 'PUT',
       headers: { 'Content-Type': 'application/json' },
@@ -639,9 +760,26 @@ export async function getConnectTerminalSettings(): Promise<ConnectTerminalSetti
 
 // ─── WebSocket URL helper ─────────────────────────────────────────────────────
 
-export function sshWebSocketUrl(serverId: string): string {
+// sshWebSocketUrl returns the SSH terminal URL; options.record saves the
+// session as an asciinema cast, served later by sshRecordingUrl. options.env
+// is exported in the shell when every name is on the connect/terminal
+// sessionEnvAllowlist; otherwise the connect is refused.
+export function sshWebSocketUrl(
+  serverId: string,
+  options: { record?: boolean; env?: Record<string, string> } = {}
+): string {
   const proto = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-  return `${proto}//${window.location.host}/api/terminal/ssh/${serverId}`
+  const params = new URLSearchParams()
+  if (options.record) params.set('record', 'true')
+  if (options.env && Object.keys(options.env).length > 0) {
+    params.set('env', JSON.stringify(options.env))
+  }
+  const query = params.size > 0 ? `?${params.toString()}` : ''
+  return `${proto}//${window.location.host}/api/terminal/ssh/${serverId}${query}`
+}
+
+export function sshRecordingUrl(sessionId: string): string {
+  return `/api/terminal/recordings/${sessionId}`
 }
 
 export function dockerWebSocketUrl(containerId: string): string {
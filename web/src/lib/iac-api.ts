@@ -88,6 +88,24 @@ export async function iacLibraryCopy(sourceKey: string, destKey?: string): Promi
   }
 }
 
+export interface IacLibraryUpdate {
+  destKey: string
+  path: string
+  sourceKey: string
+  copiedAt: string
+  copiedHash: string
+  libraryHash?: string
+  updateAvailable: boolean
+  locallyModified: boolean
+  sourceMissing?: boolean
+}
+
+/** List library copies under data/templates/apps/ and whether their library source changed. */
+export async function iacLibraryUpdates(): Promise<IacLibraryUpdate[]> {
+  const res = await pb.send<{ items: IacLibraryUpdate[] }>('/api/ext/iac/library/updates', {})
+  return res.items ?? []
+}
+
 // ─── Write ────────────────────────────────────────────────────────────────────
 
 /** Create a directory (mkdir -p semantics). Ignores 409 if dir already exists. */
@@ -114,6 +132,19 @@ export async function iacCreateFile(path: string, content: string): Promise<void
   })
 }
 
+/**
+ * Create a new file, picking the next free name ("file (1).yml") when the path
+ * is taken. Resolves to the path actually created.
+ */
+export async function iacCreateFileWithSuffix(path: string, content: string): Promise<string> {
+  const res = (await pb.send('/api/ext/iac?ifExists=suffix', {
+    method: 'POST',
+    body: JSON.stringify({ path, type: 'file', content }),
+    headers: { 'Content-Type': 'application/json' },
+  })) as { path: string }
+  return res.path
+}
+
 /** Overwrite an existing file. Fails with 404 if file does not exist. */
 export async function iacWriteFile(path: string, content: string): Promise<void> {
   await pb.send('/api/ext/iac/content', {
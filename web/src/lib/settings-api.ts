@@ -1,6 +1,7 @@
 export const SETTINGS_SCHEMA_API_PATH = '/api/settings/schema'
 export const SETTINGS_ENTRIES_API_PATH = '/api/settings/entries'
 export const SETTINGS_ACTIONS_API_PATH = '/api/settings/actions'
+export const SETTINGS_PROFILES_API_PATH = '/api/settings/profiles'
 
 export type SettingsSection = string
 export type SettingsSource = 'native' | 'custom'
@@ -47,14 +48,22 @@ export interface SettingsSchemaResponse {
 export interface SettingsEntryResponse<T = unknown> {
   id: SettingsEntryId
   value: T
+  profile?: string
+  overrides?: Partial<T>
+}
+
+export interface SettingsProfilesResponse {
+  active: string
+  items: string[]
 }
 
 export interface SettingsEntriesListResponse {
   items: SettingsEntryResponse[]
 }
 
-export function settingsEntryPath(entryId: SettingsEntryId): string {
-  return `${SETTINGS_ENTRIES_API_PATH}/${entryId}`
+export function settingsEntryPath(entryId: SettingsEntryId, profile?: string): string {
+  const path = `${SETTINGS_ENTRIES_API_PATH}/${entryId}`
+  return profile ? `${path}?profile=${encodeURIComponent(profile)}` : path
 }
 
 export function settingsActionPath(actionId: SettingsActionId): string {
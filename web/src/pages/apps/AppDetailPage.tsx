@@ -62,7 +62,6 @@ import {
   parentDir,
   parseBackupProjection,
   parseCpuPercent,
-  parseDockerInspect,
   parseDockerJsonLines,
   parseMemoryUsageBytes,
   parseReleaseAttribution,
@@ -366,11 +365,11 @@ export function AppDetailPage({ appId }: { appId: string }) {
       const results = await Promise.all(
         containerIds.map(async containerId => {
           try {
-            const response = await pb.send<{ output?: string }>(
+            const response = await pb.send<{ inspect?: Record<string, unknown> }>(
               `/api/ext/docker/containers/${containerId}${query}`,
               { method: 'GET' }
             )
-            return [containerId, parseDockerInspect(response.output)] as const
+            return [containerId, response.inspect ?? null] as const
           } catch {
             return [containerId, null] as const
           }
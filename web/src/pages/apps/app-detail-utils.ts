@@ -200,19 +200,6 @@ export function parseDockerJsonLines<T>(output: string): T[] {
     .filter(Boolean) as T[]
 }
 
-export function parseDockerInspect(output?: string): Record<string, unknown> | null {
-  if (!output?.trim()) return null
-  try {
-    const parsed = JSON.parse(output)
-    if (Array.isArray(parsed) && parsed[0] && typeof parsed[0] === 'object') {
-      return parsed[0] as Record<string, unknown>
-    }
-    return parsed && typeof parsed === 'object' ? (parsed as Record<string, unknown>) : null
-  } catch {
-    return null
-  }
-}
-
 export function parentDir(path: string): string {
   const normalized = path.replace(/\\/g, '/').replace(/\/+/g, '/')
   const segments = normalized.split('/').filter(Boolean)
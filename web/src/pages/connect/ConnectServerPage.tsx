@@ -875,6 +875,14 @@ export function ConnectServerPage({
     }
   }, [activeServerId, systemdSelected])
 
+  // Save rejections carry lint errors or systemd-analyze output worth showing in full.
+  const showSystemdUnitRejection = useCallback((error: unknown) => {
+    if (!(error instanceof ClientResponseError) || error.status !== 400) return
+    const data = error.response as { errors?: string[]; verify_output?: string }
+    const details = [...(data.errors ?? []), data.verify_output ?? ''].filter(Boolean).join('\n')
+    if (details) setSystemdUnitResult(details)
+  }, [])
+
   const validateSystemdUnitFromEditor = useCallback(async () => {
     if (!systemdSelected) return
     setSystemdActionLoading(true)
@@ -890,10 +898,11 @@ export function ConnectServerPage({
       setSystemdView('cat')
     } catch (error) {
       setSystemdError(error instanceof Error ? error.message : 'Failed to validate unit file')
+      showSystemdUnitRejection(error)
     } finally {
       setSystemdActionLoading(false)
     }
-  }, [activeServerId, systemdSelected, systemdUnitContent])
+  }, [activeServerId, systemdSelected, systemdUnitContent, showSystemdUnitRejection])
 
   const applySystemdUnitFromEditor = useCallback(async () => {
     if (!systemdSelected) return
@@ -915,10 +924,11 @@ export function ConnectServerPage({
       setSystemdView('status')
     } catch (error) {
       setSystemdError(error instanceof Error ? error.message : 'Failed to apply unit file')
+      showSystemdUnitRejection(error)
     } finally {
       setSystemdActionLoading(false)
     }
-  }, [activeServerId, systemdSelected, systemdUnitContent])
+  }, [activeServerId, systemdSelected, systemdUnitContent, showSystemdUnitRejection])
 
   const requestSystemdConfirm = useCallback(
     (action: SystemdControlAction | 'verify-unit' | 'apply-unit') => {
@@ -35,11 +35,31 @@ export function parseConnectTerminalApiErrors(
     parsed.idleTimeoutSeconds = idleError
   }
 
+  const maxSessionError = extractFieldError(bag.maxSessionSeconds)
+  if (maxSessionError) {
+    parsed.maxSessionSeconds = maxSessionError
+  }
+
   const maxError = extractFieldError(bag.maxConnections)
   if (maxError) {
     parsed.maxConnections = maxError
   }
 
+  const perServerError = extractFieldError(bag.maxSessionsPerServer)
+  if (perServerError) {
+    parsed.maxSessionsPerServer = perServerError
+  }
+
+  const allowlistError = extractFieldError(bag.sessionEnvAllowlist)
+  if (allowlistError) {
+    parsed.sessionEnvAllowlist = allowlistError
+  }
+
+  const bannerError = extractFieldError(bag.banner)
+  if (bannerError) {
+    parsed.banner = bannerError
+  }
+
   return parsed
 }
 
@@ -9,18 +9,34 @@ import {
   type SecretPolicy,
 } from '@/lib/secrets-policy'
 import {
+  DEFAULT_AUDIT_ACTIONS,
+  DEFAULT_AUDIT_EXPORT,
   DEFAULT_CONNECT_SFTP,
   DEFAULT_CONNECT_TERMINAL,
   DEFAULT_DEPLOY_PREFLIGHT,
+  DEFAULT_DOCKER_SCAN,
+  DEFAULT_DOCKER_SSH,
+  DEFAULT_HTTP_COMPRESSION,
+  DEFAULT_HTTP_LIMITS,
   DEFAULT_IAC_FILES,
+  DEFAULT_RATE_LIMIT_ROUTES,
+  DEFAULT_SECURITY_STEPUP,
   DEFAULT_SPACE_QUOTA,
   DEFAULT_TUNNEL_PORT_RANGE,
   EMPTY_PROXY,
+  type AuditActionsGroup,
+  type AuditExportGroup,
   type ConnectSftpGroup,
   type ConnectTerminalGroup,
   type DeployPreflightGroup,
+  type DockerScanGroup,
+  type DockerSshGroup,
+  type HttpCompressionGroup,
+  type HttpLimitsGroup,
   type IacFilesGroup,
   type ProxyNetwork,
+  type RateLimitRoutesGroup,
+  type SecurityStepUpGroup,
   type SpaceQuota,
   type TunnelPortRange,
 } from './-settings-sections/types'
@@ -31,6 +47,83 @@ import {
   type ShowToast,
 } from './-settings-controller-shared'
 
+const RATE_LIMIT_ROUTE_FIELDS = Object.keys(
+  DEFAULT_RATE_LIMIT_ROUTES
+) as (keyof RateLimitRoutesGroup)[]
+
+function normalizeRateLimitRoutes(value: Partial<RateLimitRoutesGroup>): RateLimitRoutesGroup {
+  const next = { ...DEFAULT_RATE_LIMIT_ROUTES }
+  for (const field of RATE_LIMIT_ROUTE_FIELDS) {
+    const min = field.endsWith('Burst') ? 1 : 0
+    const n = Number(value[field])
+    if (Number.isFinite(n) && n >= min) next[field] = Math.floor(n)
+  }
+  return next
+}
+
+function normalizeActionList(value: unknown): string[] {
+  return Array.isArray(value)
+    ? value
+        .filter((item): item is string => typeof item === 'string')
+        .map(item => item.trim())
+        .filter(Boolean)
+    : []
+}
+
+function normalizeAuditActions(value: Partial<AuditActionsGroup>): AuditActionsGroup {
+  return {
+    excludeActions: normalizeActionList(value.excludeActions),
+    includeActions: normalizeActionList(value.includeActions),
+  }
+}
+
+function normalizeDockerSsh(value: Partial<DockerSshGroup>): DockerSshGroup {
+  const dialTimeoutSeconds = Number(value.dialTimeoutSeconds)
+  const commandTimeoutSeconds = Number(value.commandTimeoutSeconds)
+  return {
+    dialTimeoutSeconds:
+      Number.isFinite(dialTimeoutSeconds) && dialTimeoutSeconds >= 1
+        ? Math.floor(dialTimeoutSeconds)
+        : DEFAULT_DOCKER_SSH.dialTimeoutSeconds,
+    commandTimeoutSeconds:
+      Number.isFinite(commandTimeoutSeconds) && commandTimeoutSeconds >= 0
+        ? Math.floor(commandTimeoutSeconds)
+        : DEFAULT_DOCKER_SSH.commandTimeoutSeconds,
+    retryDial:
+      typeof value.retryDial === 'boolean' ? value.retryDial : DEFAULT_DOCKER_SSH.retryDial,
+  }
+}
+
+function normalizeDockerScan(value: Partial<DockerScanGroup>): DockerScanGroup {
+  const timeoutSeconds = Number(value.timeoutSeconds)
+  const topLimit = Number(value.topLimit)
+  return {
+    enabled: typeof value.enabled === 'boolean' ? value.enabled : DEFAULT_DOCKER_SCAN.enabled,
+    scanner: value.scanner === 'endpoint' ? 'endpoint' : 'trivy',
+    endpoint: typeof value.endpoint === 'string' ? value.endpoint : '',
+    endpointToken: typeof value.endpointToken === 'string' ? value.endpointToken : '',
+    timeoutSeconds:
+      Number.isFinite(timeoutSeconds) && timeoutSeconds >= 10
+        ? Math.floor(timeoutSeconds)
+        : DEFAULT_DOCKER_SCAN.timeoutSeconds,
+    topLimit:
+      Number.isFinite(topLimit) && topLimit >= 0
+        ? Math.floor(topLimit)
+        : DEFAULT_DOCKER_SCAN.topLimit,
+  }
+}
+
+function normalizeHttpCompression(value: Partial<HttpCompressionGroup>): HttpCompressionGroup {
+  const minSizeBytes = Number(value.minSizeBytes)
+  return {
+    enabled: typeof value.enabled === 'boolean' ? value.enabled : DEFAULT_HTTP_COMPRESSION.enabled,
+    minSizeBytes:
+      Number.isFinite(minSizeBytes) && minSizeBytes >= 0
+        ? Math.floor(minSizeBytes)
+        : DEFAULT_HTTP_COMPRESSION.minSizeBytes,
+  }
+}
+
 export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
   const [spaceQuotaForm, setSpaceQuotaForm] = useState<SpaceQuota>(DEFAULT_SPACE_QUOTA)
   const [spaceQuotaSaving, setSpaceQuotaSaving] = useState(false)
@@ -40,6 +133,7 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
   const [allowExtsText, setAllowExtsText] = useState('')
   const [denyExtsText, setDenyExtsText] = useState('')
   const [disallowedFolderNamesText, setDisallowedFolderNamesText] = useState('')
+  const [previewMimeTypesText, setPreviewMimeTypesText] = useState('')
 
   const [connectTerminalForm, setConnectTerminalForm] =
     useState<ConnectTerminalGroup>(DEFAULT_CONNECT_TERMINAL)
@@ -61,6 +155,58 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     Partial<Record<keyof DeployPreflightGroup, string>>
   >({})
 
+  const [rateLimitRoutesForm, setRateLimitRoutesForm] =
+    useState<RateLimitRoutesGroup>(DEFAULT_RATE_LIMIT_ROUTES)
+  const [rateLimitRoutesSaving, setRateLimitRoutesSaving] = useState(false)
+  const [rateLimitRoutesErrors, setRateLimitRoutesErrors] = useState<
+    Partial<Record<keyof RateLimitRoutesGroup, string>>
+  >({})
+
+  const [dockerSshForm, setDockerSshForm] = useState<DockerSshGroup>(DEFAULT_DOCKER_SSH)
+  const [dockerSshSaving, setDockerSshSaving] = useState(false)
+  const [dockerSshErrors, setDockerSshErrors] = useState<
+    Partial<Record<keyof DockerSshGroup, string>>
+  >({})
+
+  const [dockerScanForm, setDockerScanForm] = useState<DockerScanGroup>(DEFAULT_DOCKER_SCAN)
+  const [dockerScanSaving, setDockerScanSaving] = useState(false)
+  const [dockerScanErrors, setDockerScanErrors] = useState<
+    Partial<Record<keyof DockerScanGroup, string>>
+  >({})
+
+  const [httpCompressionForm, setHttpCompressionForm] =
+    useState<HttpCompressionGroup>(DEFAULT_HTTP_COMPRESSION)
+  const [httpCompressionSaving, setHttpCompressionSaving] = useState(false)
+  const [httpCompressionErrors, setHttpCompressionErrors] = useState<
+    Partial<Record<keyof HttpCompressionGroup, string>>
+  >({})
+
+  const [httpLimitsForm, setHttpLimitsForm] = useState<HttpLimitsGroup>(DEFAULT_HTTP_LIMITS)
+  const [httpLimitsSaving, setHttpLimitsSaving] = useState(false)
+  const [httpLimitsErrors, setHttpLimitsErrors] = useState<
+    Partial<Record<keyof HttpLimitsGroup, string>>
+  >({})
+
+  const [securityStepUpForm, setSecurityStepUpForm] =
+    useState<SecurityStepUpGroup>(DEFAULT_SECURITY_STEPUP)
+  const [securityStepUpSaving, setSecurityStepUpSaving] = useState(false)
+  const [securityStepUpErrors, setSecurityStepUpErrors] = useState<
+    Partial<Record<keyof SecurityStepUpGroup, string>>
+  >({})
+
+  const [auditActionsForm, setAuditActionsForm] =
+    useState<AuditActionsGroup>(DEFAULT_AUDIT_ACTIONS)
+  const [auditActionsSaving, setAuditActionsSaving] = useState(false)
+  const [auditActionsErrors, setAuditActionsErrors] = useState<
+    Partial<Record<keyof AuditActionsGroup, string>>
+  >({})
+
+  const [auditExportForm, setAuditExportForm] = useState<AuditExportGroup>(DEFAULT_AUDIT_EXPORT)
+  const [auditExportSaving, setAuditExportSaving] = useState(false)
+  const [auditExportErrors, setAuditExportErrors] = useState<
+    Partial<Record<keyof AuditExportGroup, string>>
+  >({})
+
   const [iacFilesForm, setIacFilesForm] = useState<IacFilesGroup>(DEFAULT_IAC_FILES)
   const [iacFilesSaving, setIacFilesSaving] = useState(false)
   const [iacFilesErrors, setIacFilesErrors] = useState<
@@ -94,42 +240,121 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
       disallowedFolderNames: Array.isArray(quota.disallowedFolderNames)
         ? quota.disallowedFolderNames
         : [],
+      previewMimeTypes: Array.isArray(quota.previewMimeTypes)
+        ? quota.previewMimeTypes
+        : DEFAULT_SPACE_QUOTA.previewMimeTypes,
     }
     setSpaceQuotaForm(mergedQuota)
     setAllowExtsText(mergedQuota.uploadAllowExts.join(', '))
     setDenyExtsText(mergedQuota.uploadDenyExts.join(', '))
     setDisallowedFolderNamesText(mergedQuota.disallowedFolderNames.join(', '))
+    setPreviewMimeTypesText(mergedQuota.previewMimeTypes.join(', '))
 
     const terminal = (entryMap.get('connect-terminal') as Partial<ConnectTerminalGroup>) ?? {}
     const idleTimeoutSeconds = Number(terminal.idleTimeoutSeconds)
+    const maxSessionSeconds = Number(terminal.maxSessionSeconds)
     const maxConnections = Number(terminal.maxConnections)
+    const maxSessionsPerServer = Number(terminal.maxSessionsPerServer)
     setConnectTerminalForm({
       idleTimeoutSeconds:
         Number.isFinite(idleTimeoutSeconds) && idleTimeoutSeconds >= 60
           ? Math.floor(idleTimeoutSeconds)
           : DEFAULT_CONNECT_TERMINAL.idleTimeoutSeconds,
+      maxSessionSeconds:
+        Number.isFinite(maxSessionSeconds) && (maxSessionSeconds === 0 || maxSessionSeconds >= 60)
+          ? Math.floor(maxSessionSeconds)
+          : DEFAULT_CONNECT_TERMINAL.maxSessionSeconds,
       maxConnections:
         Number.isFinite(maxConnections) && maxConnections >= 0
           ? Math.floor(maxConnections)
           : DEFAULT_CONNECT_TERMINAL.maxConnections,
+      maxSessionsPerServer:
+        Number.isFinite(maxSessionsPerServer) && maxSessionsPerServer >= 0
+          ? Math.floor(maxSessionsPerServer)
+          : DEFAULT_CONNECT_TERMINAL.maxSessionsPerServer,
+      sessionEnvAllowlist: Array.isArray(terminal.sessionEnvAllowlist)
+        ? terminal.sessionEnvAllowlist
+        : DEFAULT_CONNECT_TERMINAL.sessionEnvAllowlist,
+      banner:
+        typeof terminal.banner === 'string' ? terminal.banner : DEFAULT_CONNECT_TERMINAL.banner,
     })
 
     const sftp = (entryMap.get('connect-sftp') as Partial<ConnectSftpGroup>) ?? {}
     const sftpMaxUploadFiles = Number(sftp.maxUploadFiles)
+    const sftpTransferRateKBps = Number(sftp.transferRateKBps)
+    const sftpMaxArchiveMB = Number(sftp.maxArchiveMB)
     setConnectSftpForm({
       maxUploadFiles:
         Number.isFinite(sftpMaxUploadFiles) && sftpMaxUploadFiles >= 1
           ? Math.floor(sftpMaxUploadFiles)
           : DEFAULT_CONNECT_SFTP.maxUploadFiles,
+      transferRateKBps:
+        Number.isFinite(sftpTransferRateKBps) && sftpTransferRateKBps >= 0
+          ? Math.floor(sftpTransferRateKBps)
+          : DEFAULT_CONNECT_SFTP.transferRateKBps,
+      maxArchiveMB:
+        Number.isFinite(sftpMaxArchiveMB) && sftpMaxArchiveMB >= 1
+          ? Math.floor(sftpMaxArchiveMB)
+          : DEFAULT_CONNECT_SFTP.maxArchiveMB,
     })
 
     const preflight = (entryMap.get('deploy-preflight') as Partial<DeployPreflightGroup>) ?? {}
     const minFreeDiskBytes = Number(preflight.minFreeDiskBytes)
+    const healthTimeoutSeconds = Number(preflight.healthTimeoutSeconds)
     setDeployPreflightForm({
       minFreeDiskBytes:
         Number.isFinite(minFreeDiskBytes) && minFreeDiskBytes >= 0
           ? Math.floor(minFreeDiskBytes)
           : DEFAULT_DEPLOY_PREFLIGHT.minFreeDiskBytes,
+      healthTimeoutSeconds:
+        Number.isFinite(healthTimeoutSeconds) && healthTimeoutSeconds >= 10
+          ? Math.floor(healthTimeoutSeconds)
+          : DEFAULT_DEPLOY_PREFLIGHT.healthTimeoutSeconds,
+    })
+
+    setRateLimitRoutesForm(
+      normalizeRateLimitRoutes(
+        (entryMap.get('ratelimit-routes') as Partial<RateLimitRoutesGroup>) ?? {}
+      )
+    )
+
+    setDockerSshForm(
+      normalizeDockerSsh((entryMap.get('docker-ssh') as Partial<DockerSshGroup>) ?? {})
+    )
+
+    setDockerScanForm(
+      normalizeDockerScan((entryMap.get('docker-scan') as Partial<DockerScanGroup>) ?? {})
+    )
+
+    setHttpCompressionForm(
+      normalizeHttpCompression(
+        (entryMap.get('http-compression') as Partial<HttpCompressionGroup>) ?? {}
+      )
+    )
+
+    const httpLimits = (entryMap.get('http-limits') as Partial<HttpLimitsGroup>) ?? {}
+    const maxBodyMB = Number(httpLimits.maxBodyMB)
+    setHttpLimitsForm({
+      maxBodyMB:
+        Number.isInteger(maxBodyMB) && maxBodyMB >= 1 ? maxBodyMB : DEFAULT_HTTP_LIMITS.maxBodyMB,
+    })
+
+    const securityStepUp =
+      (entryMap.get('security-stepup') as Partial<SecurityStepUpGroup>) ?? {}
+    setSecurityStepUpForm({
+      actions: Array.isArray(securityStepUp.actions)
+        ? securityStepUp.actions.filter((item): item is string => typeof item === 'string')
+        : DEFAULT_SECURITY_STEPUP.actions,
+    })
+
+    setAuditActionsForm(
+      normalizeAuditActions((entryMap.get('audit-actions') as Partial<AuditActionsGroup>) ?? {})
+    )
+
+    const auditExport = (entryMap.get('audit-export') as Partial<AuditExportGroup>) ?? {}
+    const maxRows = Number(auditExport.maxRows)
+    setAuditExportForm({
+      maxRows: Number.isInteger(maxRows) && maxRows >= 1 ? maxRows : DEFAULT_AUDIT_EXPORT.maxRows,
     })
 
     const iacFiles = (entryMap.get('iac-files') as Partial<IacFilesGroup>) ?? {}
@@ -201,6 +426,10 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
         .split(',')
         .map(s => s.trim())
         .filter(Boolean),
+      previewMimeTypes: previewMimeTypesText
+        .split(',')
+        .map(s => s.trim().toLowerCase())
+        .filter(Boolean),
     }
     try {
       const res = (await pb.send(settingsEntryPath('space-quota'), {
@@ -216,13 +445,30 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
         disallowedFolderNames: Array.isArray(quota.disallowedFolderNames)
           ? quota.disallowedFolderNames
           : [],
+        previewMimeTypes: Array.isArray(quota.previewMimeTypes)
+          ? quota.previewMimeTypes
+          : payload.previewMimeTypes,
       }
       setSpaceQuotaForm(merged)
       setAllowExtsText(merged.uploadAllowExts.join(', '))
       setDenyExtsText(merged.uploadDenyExts.join(', '))
       setDisallowedFolderNamesText(merged.disallowedFolderNames.join(', '))
+      setPreviewMimeTypesText(merged.previewMimeTypes.join(', '))
       showToast('Space quota saved')
     } catch (err: unknown) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const previewError = extractFieldError(bag.previewMimeTypes)
+        if (previewError) {
+          setSpaceQuotaErrors({ previewMimeTypes: previewError })
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
       showToast('Failed: ' + ((err as { message?: string })?.message ?? String(err)), false)
     } finally {
       setSpaceQuotaSaving(false)
@@ -253,12 +499,33 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     ) {
       errors.idleTimeoutSeconds = 'Must be an integer ≥ 60 seconds'
     }
+    if (
+      !Number.isInteger(connectTerminalForm.maxSessionSeconds) ||
+      (connectTerminalForm.maxSessionSeconds !== 0 && connectTerminalForm.maxSessionSeconds < 60)
+    ) {
+      errors.maxSessionSeconds = 'Must be 0 (unlimited) or an integer ≥ 60 seconds'
+    }
     if (
       !Number.isInteger(connectTerminalForm.maxConnections) ||
       connectTerminalForm.maxConnections < 0
     ) {
       errors.maxConnections = 'Must be an integer ≥ 0 (0 means unlimited)'
     }
+    if (
+      !Number.isInteger(connectTerminalForm.maxSessionsPerServer) ||
+      connectTerminalForm.maxSessionsPerServer < 0
+    ) {
+      errors.maxSessionsPerServer = 'Must be an integer ≥ 0 (0 means unlimited)'
+    }
+    const invalidEnvName = connectTerminalForm.sessionEnvAllowlist.find(
+      name => name !== '' && !/^[A-Za-z_][A-Za-z0-9_]*\*?$/.test(name)
+    )
+    if (invalidEnvName !== undefined) {
+      errors.sessionEnvAllowlist = `Invalid variable name pattern ${invalidEnvName}`
+    }
+    if (connectTerminalForm.banner.length > 2000) {
+      errors.banner = 'Must be at most 2000 characters'
+    }
     setConnectTerminalErrors(errors)
     return Object.keys(errors).length === 0
   }
@@ -272,7 +539,11 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
         method: 'PATCH',
         body: {
           idleTimeoutSeconds: connectTerminalForm.idleTimeoutSeconds,
+          maxSessionSeconds: connectTerminalForm.maxSessionSeconds,
           maxConnections: connectTerminalForm.maxConnections,
+          maxSessionsPerServer: connectTerminalForm.maxSessionsPerServer,
+          sessionEnvAllowlist: connectTerminalForm.sessionEnvAllowlist.filter(Boolean),
+          banner: connectTerminalForm.banner,
         },
       })
       showToast('Connect terminal settings saved')
@@ -296,6 +567,12 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     if (!Number.isInteger(connectSftpForm.maxUploadFiles) || connectSftpForm.maxUploadFiles < 1) {
       errors.maxUploadFiles = 'Must be an integer ≥ 1'
     }
+    if (!Number.isInteger(connectSftpForm.transferRateKBps) || connectSftpForm.transferRateKBps < 0) {
+      errors.transferRateKBps = 'Must be an integer ≥ 0 (0 = unlimited)'
+    }
+    if (!Number.isInteger(connectSftpForm.maxArchiveMB) || connectSftpForm.maxArchiveMB < 1) {
+      errors.maxArchiveMB = 'Must be an integer ≥ 1'
+    }
     setConnectSftpErrors(errors)
     return Object.keys(errors).length === 0
   }
@@ -307,11 +584,17 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     try {
       const res = (await pb.send(settingsEntryPath('connect-sftp'), {
         method: 'PATCH',
-        body: { maxUploadFiles: connectSftpForm.maxUploadFiles },
+        body: {
+          maxUploadFiles: connectSftpForm.maxUploadFiles,
+          transferRateKBps: connectSftpForm.transferRateKBps,
+          maxArchiveMB: connectSftpForm.maxArchiveMB,
+        },
       })) as { value?: Partial<ConnectSftpGroup> }
       const next = res.value ?? connectSftpForm
       setConnectSftpForm({
         maxUploadFiles: Number(next.maxUploadFiles ?? connectSftpForm.maxUploadFiles),
+        transferRateKBps: Number(next.transferRateKBps ?? connectSftpForm.transferRateKBps),
+        maxArchiveMB: Number(next.maxArchiveMB ?? connectSftpForm.maxArchiveMB),
       })
       showToast('Connect SFTP settings saved')
     } catch (err) {
@@ -323,6 +606,8 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
             : root
         const nextErrors = {
           maxUploadFiles: extractFieldError(bag.maxUploadFiles) ?? undefined,
+          transferRateKBps: extractFieldError(bag.transferRateKBps) ?? undefined,
+          maxArchiveMB: extractFieldError(bag.maxArchiveMB) ?? undefined,
         }
         if (Object.values(nextErrors).some(Boolean)) {
           setConnectSftpErrors(nextErrors)
@@ -344,6 +629,13 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     ) {
       errors.minFreeDiskBytes = 'Must be an integer ≥ 0 bytes'
     }
+    if (
+      !Number.isInteger(deployPreflightForm.healthTimeoutSeconds) ||
+      deployPreflightForm.healthTimeoutSeconds < 10 ||
+      deployPreflightForm.healthTimeoutSeconds > 3600
+    ) {
+      errors.healthTimeoutSeconds = 'Must be an integer between 10 and 3600'
+    }
     setDeployPreflightErrors(errors)
     return Object.keys(errors).length === 0
   }
@@ -357,6 +649,7 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
         method: 'PATCH',
         body: {
           minFreeDiskBytes: deployPreflightForm.minFreeDiskBytes,
+          healthTimeoutSeconds: deployPreflightForm.healthTimeoutSeconds,
         },
       })) as { value?: Partial<DeployPreflightGroup> }
       const preflight = res.value ?? deployPreflightForm
@@ -364,6 +657,9 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
         minFreeDiskBytes: Number(
           preflight.minFreeDiskBytes ?? deployPreflightForm.minFreeDiskBytes
         ),
+        healthTimeoutSeconds: Number(
+          preflight.healthTimeoutSeconds ?? deployPreflightForm.healthTimeoutSeconds
+        ),
       })
       showToast('Deploy preflight settings saved')
     } catch (err) {
@@ -375,6 +671,7 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
             : root
         const nextErrors = {
           minFreeDiskBytes: extractFieldError(bag.minFreeDiskBytes) ?? undefined,
+          healthTimeoutSeconds: extractFieldError(bag.healthTimeoutSeconds) ?? undefined,
         }
         if (Object.values(nextErrors).some(Boolean)) {
           setDeployPreflightErrors(nextErrors)
@@ -388,6 +685,336 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     }
   }
 
+  const validateRateLimitRoutes = (): boolean => {
+    const errors: Partial<Record<keyof RateLimitRoutesGroup, string>> = {}
+    for (const field of RATE_LIMIT_ROUTE_FIELDS) {
+      const min = field.endsWith('Burst') ? 1 : 0
+      const value = rateLimitRoutesForm[field]
+      if (!Number.isInteger(value) || value < min) {
+        errors[field] = `Must be an integer >= ${min}`
+      }
+    }
+    setRateLimitRoutesErrors(errors)
+    return Object.keys(errors).length === 0
+  }
+
+  const saveRateLimitRoutes = async () => {
+    if (!validateRateLimitRoutes()) return
+    setRateLimitRoutesSaving(true)
+    setRateLimitRoutesErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('ratelimit-routes'), {
+        method: 'PATCH',
+        body: { ...rateLimitRoutesForm },
+      })) as { value?: Partial<RateLimitRoutesGroup> }
+      setRateLimitRoutesForm(normalizeRateLimitRoutes(res.value ?? rateLimitRoutesForm))
+      showToast('Rate limit settings saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const nextErrors: Partial<Record<keyof RateLimitRoutesGroup, string>> = {}
+        for (const field of RATE_LIMIT_ROUTE_FIELDS) {
+          const message = extractFieldError(bag[field])
+          if (message) nextErrors[field] = message
+        }
+        if (Object.keys(nextErrors).length > 0) {
+          setRateLimitRoutesErrors(nextErrors)
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setRateLimitRoutesSaving(false)
+    }
+  }
+
+  const saveDockerSsh = async () => {
+    const errors: Partial<Record<keyof DockerSshGroup, string>> = {}
+    const { dialTimeoutSeconds, commandTimeoutSeconds } = dockerSshForm
+    if (
+      !Number.isInteger(dialTimeoutSeconds) ||
+      dialTimeoutSeconds < 1 ||
+      dialTimeoutSeconds > 300
+    ) {
+      errors.dialTimeoutSeconds = 'Must be an integer between 1 and 300'
+    }
+    if (!Number.isInteger(commandTimeoutSeconds) || commandTimeoutSeconds < 0) {
+      errors.commandTimeoutSeconds = 'Must be an integer >= 0 (0 means no limit)'
+    }
+    if (Object.keys(errors).length > 0) {
+      setDockerSshErrors(errors)
+      return
+    }
+    setDockerSshSaving(true)
+    setDockerSshErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('docker-ssh'), {
+        method: 'PATCH',
+        body: { ...dockerSshForm },
+      })) as { value?: Partial<DockerSshGroup> }
+      setDockerSshForm(normalizeDockerSsh(res.value ?? dockerSshForm))
+      showToast('Docker SSH settings saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const nextErrors: Partial<Record<keyof DockerSshGroup, string>> = {}
+        for (const field of ['dialTimeoutSeconds', 'commandTimeoutSeconds', 'retryDial'] as const) {
+          const message = extractFieldError(bag[field])
+          if (message) nextErrors[field] = message
+        }
+        if (Object.keys(nextErrors).length > 0) {
+          setDockerSshErrors(nextErrors)
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setDockerSshSaving(false)
+    }
+  }
+
+  const saveDockerScan = async () => {
+    const errors: Partial<Record<keyof DockerScanGroup, string>> = {}
+    const { enabled, scanner, endpoint, timeoutSeconds, topLimit } = dockerScanForm
+    if (enabled && scanner === 'endpoint' && !endpoint.trim()) {
+      errors.endpoint = 'Required when the scanner is an endpoint'
+    }
+    if (!Number.isInteger(timeoutSeconds) || timeoutSeconds < 10 || timeoutSeconds > 3600) {
+      errors.timeoutSeconds = 'Must be an integer between 10 and 3600'
+    }
+    if (!Number.isInteger(topLimit) || topLimit < 0 || topLimit > 100) {
+      errors.topLimit = 'Must be an integer between 0 and 100'
+    }
+    if (Object.keys(errors).length > 0) {
+      setDockerScanErrors(errors)
+      return
+    }
+    setDockerScanSaving(true)
+    setDockerScanErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('docker-scan'), {
+        method: 'PATCH',
+        body: { ...dockerScanForm, endpoint: endpoint.trim() },
+      })) as { value?: Partial<DockerScanGroup> }
+      setDockerScanForm(normalizeDockerScan(res.value ?? dockerScanForm))
+      showToast('Image scan settings saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const nextErrors: Partial<Record<keyof DockerScanGroup, string>> = {}
+        for (const field of [
+          'enabled',
+          'scanner',
+          'endpoint',
+          'timeoutSeconds',
+          'topLimit',
+        ] as const) {
+          const message = extractFieldError(bag[field])
+          if (message) nextErrors[field] = message
+        }
+        if (Object.keys(nextErrors).length > 0) {
+          setDockerScanErrors(nextErrors)
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setDockerScanSaving(false)
+    }
+  }
+
+  const saveHttpCompression = async () => {
+    const { minSizeBytes } = httpCompressionForm
+    if (!Number.isInteger(minSizeBytes) || minSizeBytes < 0) {
+      setHttpCompressionErrors({ minSizeBytes: 'Must be an integer >= 0' })
+      return
+    }
+    setHttpCompressionSaving(true)
+    setHttpCompressionErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('http-compression'), {
+        method: 'PATCH',
+        body: { ...httpCompressionForm },
+      })) as { value?: Partial<HttpCompressionGroup> }
+      setHttpCompressionForm(normalizeHttpCompression(res.value ?? httpCompressionForm))
+      showToast('Compression settings saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const nextErrors: Partial<Record<keyof HttpCompressionGroup, string>> = {}
+        for (const field of ['enabled', 'minSizeBytes'] as const) {
+          const message = extractFieldError(bag[field])
+          if (message) nextErrors[field] = message
+        }
+        if (Object.keys(nextErrors).length > 0) {
+          setHttpCompressionErrors(nextErrors)
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setHttpCompressionSaving(false)
+    }
+  }
+
+  const saveHttpLimits = async () => {
+    const { maxBodyMB } = httpLimitsForm
+    if (!Number.isInteger(maxBodyMB) || maxBodyMB < 1 || maxBodyMB > 1024) {
+      setHttpLimitsErrors({ maxBodyMB: 'Must be an integer between 1 and 1024' })
+      return
+    }
+    setHttpLimitsSaving(true)
+    setHttpLimitsErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('http-limits'), {
+        method: 'PATCH',
+        body: { maxBodyMB },
+      })) as { value?: Partial<HttpLimitsGroup> }
+      setHttpLimitsForm({ maxBodyMB: Number(res.value?.maxBodyMB ?? maxBodyMB) })
+      showToast('Request limit saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const message = extractFieldError(bag.maxBodyMB)
+        if (message) {
+          setHttpLimitsErrors({ maxBodyMB: message })
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setHttpLimitsSaving(false)
+    }
+  }
+
+  const saveSecurityStepUp = async () => {
+    const { actions } = securityStepUpForm
+    setSecurityStepUpSaving(true)
+    setSecurityStepUpErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('security-stepup'), {
+        method: 'PATCH',
+        body: { actions },
+      })) as { value?: Partial<SecurityStepUpGroup> }
+      setSecurityStepUpForm({
+        actions: Array.isArray(res.value?.actions) ? res.value.actions : actions,
+      })
+      showToast('Step-up confirmation saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const message = extractFieldError(bag.actions)
+        if (message) {
+          setSecurityStepUpErrors({ actions: message })
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setSecurityStepUpSaving(false)
+    }
+  }
+
+  const saveAuditActions = async () => {
+    const value = normalizeAuditActions(auditActionsForm)
+    setAuditActionsSaving(true)
+    setAuditActionsErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('audit-actions'), {
+        method: 'PATCH',
+        body: value,
+      })) as { value?: Partial<AuditActionsGroup> }
+      setAuditActionsForm(res.value ? normalizeAuditActions(res.value) : value)
+      showToast('Audit actions saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const errors: Partial<Record<keyof AuditActionsGroup, string>> = {}
+        for (const field of ['excludeActions', 'includeActions'] as const) {
+          const message = extractFieldError(bag[field])
+          if (message) errors[field] = message
+        }
+        if (Object.keys(errors).length > 0) {
+          setAuditActionsErrors(errors)
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setAuditActionsSaving(false)
+    }
+  }
+
+  const saveAuditExport = async () => {
+    const { maxRows } = auditExportForm
+    if (!Number.isInteger(maxRows) || maxRows < 1 || maxRows > 10000000) {
+      setAuditExportErrors({ maxRows: 'Must be an integer between 1 and 10000000' })
+      return
+    }
+    setAuditExportSaving(true)
+    setAuditExportErrors({})
+    try {
+      const res = (await pb.send(settingsEntryPath('audit-export'), {
+        method: 'PATCH',
+        body: { maxRows },
+      })) as { value?: Partial<AuditExportGroup> }
+      setAuditExportForm({ maxRows: Number(res.value?.maxRows ?? maxRows) })
+      showToast('Audit export limit saved')
+    } catch (err) {
+      if (err instanceof ClientResponseError && (err.status === 400 || err.status === 422)) {
+        const root = err.response as Record<string, unknown>
+        const bag =
+          root.errors && typeof root.errors === 'object'
+            ? (root.errors as Record<string, unknown>)
+            : root
+        const message = extractFieldError(bag.maxRows)
+        if (message) {
+          setAuditExportErrors({ maxRows: message })
+          showToast('Please fix validation errors and try again.', false)
+          return
+        }
+      }
+      showToast('Failed: ' + (err instanceof Error ? err.message : String(err)), false)
+    } finally {
+      setAuditExportSaving(false)
+    }
+  }
+
   const validateIacFiles = (): boolean => {
     const errors: Partial<Record<keyof IacFilesGroup, string>> = {}
     if (!Number.isInteger(iacFilesForm.maxSizeMB) || iacFilesForm.maxSizeMB < 1) {
@@ -559,10 +1186,12 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     allowExtsText,
     denyExtsText,
     disallowedFolderNamesText,
+    previewMimeTypesText,
     setSpaceQuotaForm,
     setAllowExtsText,
     setDenyExtsText,
     setDisallowedFolderNamesText,
+    setPreviewMimeTypesText,
     saveSpaceQuota,
     connectTerminalForm,
     connectTerminalSaving,
@@ -579,6 +1208,46 @@ export function useWorkspaceSimpleSettingsController(showToast: ShowToast) {
     deployPreflightErrors,
     setDeployPreflightForm,
     saveDeployPreflight,
+    rateLimitRoutesForm,
+    rateLimitRoutesSaving,
+    rateLimitRoutesErrors,
+    setRateLimitRoutesForm,
+    saveRateLimitRoutes,
+    dockerSshForm,
+    dockerSshSaving,
+    dockerSshErrors,
+    setDockerSshForm,
+    saveDockerSsh,
+    dockerScanForm,
+    dockerScanSaving,
+    dockerScanErrors,
+    setDockerScanForm,
+    saveDockerScan,
+    httpCompressionForm,
+    httpCompressionSaving,
+    httpCompressionErrors,
+    setHttpCompressionForm,
+    saveHttpCompression,
+    httpLimitsForm,
+    httpLimitsSaving,
+    httpLimitsErrors,
+    setHttpLimitsForm,
+    saveHttpLimits,
+    securityStepUpForm,
+    securityStepUpSaving,
+    securityStepUpErrors,
+    setSecurityStepUpForm,
+    saveSecurityStepUp,
+    auditActionsForm,
+    auditActionsSaving,
+    auditActionsErrors,
+    setAuditActionsForm,
+    saveAuditActions,
+    auditExportForm,
+    auditExportSaving,
+    auditExportErrors,
+    setAuditExportForm,
+    saveAuditExport,
     iacFilesForm,
     iacFilesSaving,
     iacFilesErrors,
@@ -4,11 +4,19 @@ import { type SettingsSection } from '@/lib/settings-api'
 import { ConnectorReferenceSection, sectionLabel } from './-settings-sections/shared'
 import { BasicSection, LogsSection, S3Section } from './-settings-sections/system-sections'
 import {
+  AuditActionsSection,
+  AuditExportSection,
   ConnectSftpSection,
   ConnectTerminalSection,
   DeployPreflightSection,
+  DockerScanSection,
+  DockerSshSection,
+  HttpCompressionSection,
+  HttpLimitsSection,
   IacFilesSection,
   ProxySection,
+  RateLimitRoutesSection,
+  SecurityStepUpSection,
   SecretsSection,
   SpaceQuotaSection,
   TunnelSection,
@@ -103,12 +111,14 @@ function renderSection(controller: SettingsPageController) {
           allowExtsText={controller.allowExtsText}
           denyExtsText={controller.denyExtsText}
           disallowedFolderNamesText={controller.disallowedFolderNamesText}
+          previewMimeTypesText={controller.previewMimeTypesText}
           saving={controller.spaceQuotaSaving}
           parseExtListInput={parseExtListInput}
           setForm={controller.setSpaceQuotaForm}
           setAllowExtsText={controller.setAllowExtsText}
           setDenyExtsText={controller.setDenyExtsText}
           setDisallowedFolderNamesText={controller.setDisallowedFolderNamesText}
+          setPreviewMimeTypesText={controller.setPreviewMimeTypesText}
           save={controller.saveSpaceQuota}
         />
       )
@@ -167,6 +177,72 @@ function renderSection(controller: SettingsPageController) {
           save={controller.saveTunnelPortRange}
         />
       ) : null
+    case 'ratelimit-routes':
+      return findSchemaEntry(controller, 'ratelimit-routes') ? (
+        <RateLimitRoutesSection
+          entry={findSchemaEntry(controller, 'ratelimit-routes')!}
+          form={controller.rateLimitRoutesForm}
+          errors={controller.rateLimitRoutesErrors}
+          saving={controller.rateLimitRoutesSaving}
+          setForm={controller.setRateLimitRoutesForm}
+          save={controller.saveRateLimitRoutes}
+        />
+      ) : null
+    case 'http-compression':
+      return findSchemaEntry(controller, 'http-compression') ? (
+        <HttpCompressionSection
+          entry={findSchemaEntry(controller, 'http-compression')!}
+          form={controller.httpCompressionForm}
+          errors={controller.httpCompressionErrors}
+          saving={controller.httpCompressionSaving}
+          setForm={controller.setHttpCompressionForm}
+          save={controller.saveHttpCompression}
+        />
+      ) : null
+    case 'http-limits':
+      return findSchemaEntry(controller, 'http-limits') ? (
+        <HttpLimitsSection
+          entry={findSchemaEntry(controller, 'http-limits')!}
+          form={controller.httpLimitsForm}
+          errors={controller.httpLimitsErrors}
+          saving={controller.httpLimitsSaving}
+          setForm={controller.setHttpLimitsForm}
+          save={controller.saveHttpLimits}
+        />
+      ) : null
+    case 'security-stepup':
+      return findSchemaEntry(controller, 'security-stepup') ? (
+        <SecurityStepUpSection
+          entry={findSchemaEntry(controller, 'security-stepup')!}
+          form={controller.securityStepUpForm}
+          errors={controller.securityStepUpErrors}
+          saving={controller.securityStepUpSaving}
+          setForm={controller.setSecurityStepUpForm}
+          save={controller.saveSecurityStepUp}
+        />
+      ) : null
+    case 'audit-actions':
+      return findSchemaEntry(controller, 'audit-actions') ? (
+        <AuditActionsSection
+          entry={findSchemaEntry(controller, 'audit-actions')!}
+          form={controller.auditActionsForm}
+          errors={controller.auditActionsErrors}
+          saving={controller.auditActionsSaving}
+          setForm={controller.setAuditActionsForm}
+          save={controller.saveAuditActions}
+        />
+      ) : null
+    case 'audit-export':
+      return findSchemaEntry(controller, 'audit-export') ? (
+        <AuditExportSection
+          entry={findSchemaEntry(controller, 'audit-export')!}
+          form={controller.auditExportForm}
+          errors={controller.auditExportErrors}
+          saving={controller.auditExportSaving}
+          setForm={controller.setAuditExportForm}
+          save={controller.saveAuditExport}
+        />
+      ) : null
     case 'secrets-policy':
       return (
         <SecretsSection
@@ -198,6 +274,28 @@ function renderSection(controller: SettingsPageController) {
           saveDockerMirrors={controller.saveDockerMirrors}
         />
       )
+    case 'docker-ssh':
+      return findSchemaEntry(controller, 'docker-ssh') ? (
+        <DockerSshSection
+          entry={findSchemaEntry(controller, 'docker-ssh')!}
+          form={controller.dockerSshForm}
+          errors={controller.dockerSshErrors}
+          saving={controller.dockerSshSaving}
+          setForm={controller.setDockerSshForm}
+          save={controller.saveDockerSsh}
+        />
+      ) : null
+    case 'docker-scan':
+      return findSchemaEntry(controller, 'docker-scan') ? (
+        <DockerScanSection
+          entry={findSchemaEntry(controller, 'docker-scan')!}
+          form={controller.dockerScanForm}
+          errors={controller.dockerScanErrors}
+          saving={controller.dockerScanSaving}
+          setForm={controller.setDockerScanForm}
+          save={controller.saveDockerScan}
+        />
+      ) : null
     case 'docker-registries':
       return (
         <ConnectorReferenceSection
@@ -9,6 +9,7 @@ export interface SpaceQuota {
   uploadAllowExts: string[]
   uploadDenyExts: string[]
   disallowedFolderNames: string[]
+  previewMimeTypes: string[]
 }
 
 export interface ProxyNetwork {
@@ -26,11 +27,17 @@ export interface DockerMirror {
 
 export interface ConnectTerminalGroup {
   idleTimeoutSeconds: number
+  maxSessionSeconds: number
   maxConnections: number
+  maxSessionsPerServer: number
+  sessionEnvAllowlist: string[]
+  banner: string
 }
 
 export interface ConnectSftpGroup {
   maxUploadFiles: number
+  transferRateKBps: number
+  maxArchiveMB: number
 }
 
 export interface TunnelPortRange {
@@ -40,8 +47,63 @@ export interface TunnelPortRange {
 
 export interface DeployPreflightGroup {
   minFreeDiskBytes: number
+  healthTimeoutSeconds: number
 }
 
+export interface RateLimitRoutesGroup {
+  spaceFetchPerMinute: number
+  spaceFetchBurst: number
+  dockerPullPerMinute: number
+  dockerPullBurst: number
+  serverOpsPerMinute: number
+  serverOpsBurst: number
+}
+
+export interface DockerSshGroup {
+  dialTimeoutSeconds: number
+  commandTimeoutSeconds: number
+  retryDial: boolean
+}
+
+export interface DockerScanGroup {
+  enabled: boolean
+  scanner: 'trivy' | 'endpoint'
+  endpoint: string
+  endpointToken: string
+  timeoutSeconds: number
+  topLimit: number
+}
+
+export interface HttpCompressionGroup {
+  enabled: boolean
+  minSizeBytes: number
+}
+
+export interface HttpLimitsGroup {
+  maxBodyMB: number
+}
+
+export interface SecurityStepUpGroup {
+  actions: string[]
+}
+
+export interface AuditActionsGroup {
+  excludeActions: string[]
+  includeActions: string[]
+}
+
+export interface AuditExportGroup {
+  maxRows: number
+}
+
+export const STEPUP_ACTION_OPTIONS: { id: string; label: string }[] = [
+  { id: 'server.power', label: 'Power off or reboot a server' },
+  { id: 'superuser.delete', label: 'Delete a superuser' },
+  { id: 'secret.payload.update', label: "Replace a secret's value" },
+  { id: 'docker.exec', label: 'Run an arbitrary docker command' },
+  { id: 'terminal.broadcast', label: 'Run a command on many servers at once' },
+]
+
 export interface IacFilesGroup {
   maxSizeMB: number
   maxZipSizeMB: number
@@ -57,6 +119,25 @@ export const DEFAULT_SPACE_QUOTA: SpaceQuota = {
   uploadAllowExts: [],
   uploadDenyExts: [],
   disallowedFolderNames: [],
+  previewMimeTypes: [
+    'image/png',
+    'image/jpeg',
+    'image/gif',
+    'image/webp',
+    'image/svg+xml',
+    'image/bmp',
+    'image/x-icon',
+    'application/pdf',
+    'audio/mpeg',
+    'audio/wav',
+    'audio/ogg',
+    'audio/aac',
+    'audio/flac',
+    'audio/webm',
+    'video/mp4',
+    'video/webm',
+    'video/ogg',
+  ],
 }
 
 export const EMPTY_PROXY: ProxyNetwork = {
@@ -69,11 +150,17 @@ export const EMPTY_PROXY: ProxyNetwork = {
 
 export const DEFAULT_CONNECT_TERMINAL: ConnectTerminalGroup = {
   idleTimeoutSeconds: 1800,
+  maxSessionSeconds: 0,
   maxConnections: 0,
+  maxSessionsPerServer: 10,
+  sessionEnvAllowlist: ['APPOS_*'],
+  banner: '',
 }
 
 export const DEFAULT_CONNECT_SFTP: ConnectSftpGroup = {
   maxUploadFiles: 10,
+  transferRateKBps: 0,
+  maxArchiveMB: 1024,
 }
 
 export const DEFAULT_TUNNEL_PORT_RANGE: TunnelPortRange = {
@@ -83,6 +170,53 @@ export const DEFAULT_TUNNEL_PORT_RANGE: TunnelPortRange = {
 
 export const DEFAULT_DEPLOY_PREFLIGHT: DeployPreflightGroup = {
   minFreeDiskBytes: 512 * 1024 * 1024,
+  healthTimeoutSeconds: 120,
+}
+
+export const DEFAULT_RATE_LIMIT_ROUTES: RateLimitRoutesGroup = {
+  spaceFetchPerMinute: 10,
+  spaceFetchBurst: 5,
+  dockerPullPerMinute: 10,
+  dockerPullBurst: 3,
+  serverOpsPerMinute: 120,
+  serverOpsBurst: 30,
+}
+
+export const DEFAULT_DOCKER_SSH: DockerSshGroup = {
+  dialTimeoutSeconds: 10,
+  commandTimeoutSeconds: 0,
+  retryDial: false,
+}
+
+export const DEFAULT_DOCKER_SCAN: DockerScanGroup = {
+  enabled: false,
+  scanner: 'trivy',
+  endpoint: '',
+  endpointToken: '',
+  timeoutSeconds: 300,
+  topLimit: 10,
+}
+
+export const DEFAULT_HTTP_COMPRESSION: HttpCompressionGroup = {
+  enabled: true,
+  minSizeBytes: 1024,
+}
+
+export const DEFAULT_HTTP_LIMITS: HttpLimitsGroup = {
+  maxBodyMB: 2,
+}
+
+export const DEFAULT_SECURITY_STEPUP: SecurityStepUpGroup = {
+  actions: [],
+}
+
+export const DEFAULT_AUDIT_ACTIONS: AuditActionsGroup = {
+  excludeActions: [],
+  includeActions: [],
+}
+
+export const DEFAULT_AUDIT_EXPORT: AuditExportGroup = {
+  maxRows: 100000,
 }
 
 export const DEFAULT_IAC_FILES: IacFilesGroup = {
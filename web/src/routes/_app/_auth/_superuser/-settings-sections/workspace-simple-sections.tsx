@@ -6,17 +6,27 @@ import { Button } from '@/components/ui/button'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
+import { Textarea } from '@/components/ui/textarea'
 import { SaveButton, Toggle, selectClass } from './shared'
 import type {
+  AuditActionsGroup,
+  AuditExportGroup,
   ConnectSftpGroup,
   ConnectTerminalGroup,
   DeployPreflightGroup,
+  DockerScanGroup,
+  DockerSshGroup,
+  HttpCompressionGroup,
+  HttpLimitsGroup,
   IacFilesGroup,
   ProxyNetwork,
+  RateLimitRoutesGroup,
   SecretPolicyErrors,
+  SecurityStepUpGroup,
   SpaceQuota,
   TunnelPortRange,
 } from './types'
+import { STEPUP_ACTION_OPTIONS } from './types'
 
 type SchemaNumberFieldOptions = {
   inputId: string
@@ -139,12 +149,14 @@ export function SpaceQuotaSection({
   allowExtsText,
   denyExtsText,
   disallowedFolderNamesText,
+  previewMimeTypesText,
   saving,
   parseExtListInput,
   setForm,
   setAllowExtsText,
   setDenyExtsText,
   setDisallowedFolderNamesText,
+  setPreviewMimeTypesText,
   save,
 }: {
   form: SpaceQuota
@@ -152,12 +164,14 @@ export function SpaceQuotaSection({
   allowExtsText: string
   denyExtsText: string
   disallowedFolderNamesText: string
+  previewMimeTypesText: string
   saving: boolean
   parseExtListInput: (value: string) => string[]
   setForm: React.Dispatch<React.SetStateAction<SpaceQuota>>
   setAllowExtsText: (value: string) => void
   setDenyExtsText: (value: string) => void
   setDisallowedFolderNamesText: (value: string) => void
+  setPreviewMimeTypesText: (value: string) => void
   save: () => void
 }) {
   return (
@@ -284,6 +298,21 @@ export function SpaceQuotaSection({
               Folder names users are not allowed to create at any level. Case-sensitive.
             </p>
           </div>
+          <div className="col-span-2 space-y-1">
+            <Label htmlFor="previewMimeTypes">Preview MIME Types (comma-separated)</Label>
+            <Input
+              id="previewMimeTypes"
+              value={previewMimeTypesText}
+              onChange={e => setPreviewMimeTypesText(e.target.value)}
+              placeholder="image/png, image/jpeg, application/pdf"
+            />
+            <p className="text-xs text-muted-foreground">
+              File types that can be previewed inline. Leave empty to disable inline preview.
+            </p>
+            {errors.previewMimeTypes && (
+              <p className="text-xs text-destructive">{errors.previewMimeTypes}</p>
+            )}
+          </div>
         </div>
         <SaveButton onClick={save} saving={saving} />
       </CardContent>
@@ -379,6 +408,8 @@ export function ConnectTerminalSection({
   setForm: React.Dispatch<React.SetStateAction<ConnectTerminalGroup>>
   save: () => void
 }) {
+  const envAllowlistSchema = entry.fields.find(item => item.id === 'sessionEnvAllowlist')
+  const bannerSchema = entry.fields.find(item => item.id === 'banner')
   return (
     <Card>
       <CardHeader>
@@ -397,13 +428,54 @@ export function ConnectTerminalSection({
                 inputId: 'connectIdleTimeout',
                 min: 60,
               },
+              maxSessionSeconds: {
+                inputId: 'connectMaxSessionSeconds',
+                min: 0,
+              },
               maxConnections: {
                 inputId: 'connectMaxConnections',
                 min: 0,
               },
+              maxSessionsPerServer: {
+                inputId: 'connectMaxSessionsPerServer',
+                min: 0,
+              },
             },
           })}
         </div>
+        <div className="space-y-1">
+          <Label htmlFor="connectSessionEnvAllowlist">
+            {envAllowlistSchema?.label ?? 'Session Env Allowlist'} (comma-separated)
+          </Label>
+          <Input
+            id="connectSessionEnvAllowlist"
+            value={form.sessionEnvAllowlist.join(', ')}
+            onChange={e =>
+              setForm(f => ({ ...f, sessionEnvAllowlist: splitCommaList(e.target.value) }))
+            }
+            placeholder="APPOS_*, APP_NAME"
+          />
+          {envAllowlistSchema?.helpText && (
+            <p className="text-xs text-muted-foreground">{envAllowlistSchema.helpText}</p>
+          )}
+          {errors.sessionEnvAllowlist && (
+            <p className="text-xs text-destructive">{errors.sessionEnvAllowlist}</p>
+          )}
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="connectTerminalBanner">{bannerSchema?.label ?? 'Banner'}</Label>
+          <Textarea
+            id="connectTerminalBanner"
+            rows={3}
+            value={form.banner}
+            onChange={e => setForm(f => ({ ...f, banner: e.target.value }))}
+            placeholder="Production system - actions are audited"
+          />
+          {bannerSchema?.helpText && (
+            <p className="text-xs text-muted-foreground">{bannerSchema.helpText}</p>
+          )}
+          {errors.banner && <p className="text-xs text-destructive">{errors.banner}</p>}
+        </div>
         <SaveButton onClick={save} saving={saving} />
       </CardContent>
     </Card>
@@ -429,7 +501,9 @@ export function ConnectSftpSection({
     <Card>
       <CardHeader>
         <CardTitle>{entry.title}</CardTitle>
-        <CardDescription>File upload limits for SFTP connections</CardDescription>
+        <CardDescription>
+          File upload, bandwidth and archive limits for SFTP connections
+        </CardDescription>
       </CardHeader>
       <CardContent className="space-y-4">
         {renderSchemaNumberFields({
@@ -442,6 +516,14 @@ export function ConnectSftpSection({
               inputId: 'sftpMaxUploadFiles',
               min: 1,
             },
+            transferRateKBps: {
+              inputId: 'sftpTransferRateKBps',
+              min: 0,
+            },
+            maxArchiveMB: {
+              inputId: 'sftpMaxArchiveMB',
+              min: 1,
+            },
           },
         })}
         <SaveButton onClick={save} saving={saving} />
@@ -469,7 +551,9 @@ export function DeployPreflightSection({
     <Card>
       <CardHeader>
         <CardTitle>{entry.title}</CardTitle>
-        <CardDescription>Disk-capacity guardrails used during install preflight</CardDescription>
+        <CardDescription>
+          Disk-capacity guardrails used during install preflight and the post-deploy health gate
+        </CardDescription>
       </CardHeader>
       <CardContent className="space-y-4">
         <div className="grid grid-cols-2 gap-4">
@@ -484,6 +568,427 @@ export function DeployPreflightSection({
                 min: 0,
                 helpText: 'Default is 536870912 bytes (0.5 GiB).',
               },
+              healthTimeoutSeconds: {
+                inputId: 'deployHealthTimeoutSeconds',
+                min: 10,
+                max: 3600,
+              },
+            },
+          })}
+        </div>
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function RateLimitRoutesSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: RateLimitRoutesGroup
+  errors: Partial<Record<keyof RateLimitRoutesGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<RateLimitRoutesGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>
+          Per-user request limits for endpoints that fetch URLs, pull images or run commands on
+          servers
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="grid grid-cols-2 gap-4">
+          {renderSchemaNumberFields({
+            entry,
+            form,
+            errors,
+            setForm,
+            fieldOptions: {
+              spaceFetchPerMinute: { inputId: 'rateLimitSpaceFetchPerMinute', min: 0 },
+              spaceFetchBurst: { inputId: 'rateLimitSpaceFetchBurst', min: 1 },
+              dockerPullPerMinute: { inputId: 'rateLimitDockerPullPerMinute', min: 0 },
+              dockerPullBurst: { inputId: 'rateLimitDockerPullBurst', min: 1 },
+              serverOpsPerMinute: { inputId: 'rateLimitServerOpsPerMinute', min: 0 },
+              serverOpsBurst: { inputId: 'rateLimitServerOpsBurst', min: 1 },
+            },
+          })}
+        </div>
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function DockerSshSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: DockerSshGroup
+  errors: Partial<Record<keyof DockerSshGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<DockerSshGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>
+          Timeouts for Docker operations and server status checks over SSH
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="grid grid-cols-2 gap-4">
+          {renderSchemaNumberFields({
+            entry,
+            form,
+            errors,
+            setForm,
+            fieldOptions: {
+              dialTimeoutSeconds: { inputId: 'dockerSshDialTimeoutSeconds', min: 1 },
+              commandTimeoutSeconds: { inputId: 'dockerSshCommandTimeoutSeconds', min: 0 },
+            },
+          })}
+        </div>
+        <div className="flex items-center gap-3">
+          <Toggle
+            id="dockerSshRetryDial"
+            checked={form.retryDial}
+            onChange={retryDial => setForm(current => ({ ...current, retryDial }))}
+          />
+          <Label htmlFor="dockerSshRetryDial">Retry connect once</Label>
+        </div>
+        {errors.retryDial && <p className="text-xs text-destructive">{errors.retryDial}</p>}
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function DockerScanSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: DockerScanGroup
+  errors: Partial<Record<keyof DockerScanGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<DockerScanGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>{entry.description}</CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="flex items-center gap-3">
+          <Toggle
+            id="dockerScanEnabled"
+            checked={form.enabled}
+            onChange={enabled => setForm(current => ({ ...current, enabled }))}
+          />
+          <Label htmlFor="dockerScanEnabled">Enable image scanning</Label>
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="dockerScanScanner">Scanner</Label>
+          <select
+            id="dockerScanScanner"
+            className={selectClass}
+            value={form.scanner}
+            onChange={e =>
+              setForm(current => ({
+                ...current,
+                scanner: e.target.value === 'endpoint' ? 'endpoint' : 'trivy',
+              }))
+            }
+          >
+            <option value="trivy">trivy on the server</option>
+            <option value="endpoint">Integration endpoint</option>
+          </select>
+          {errors.scanner && <p className="text-xs text-destructive">{errors.scanner}</p>}
+        </div>
+        {form.scanner === 'endpoint' && (
+          <div className="grid grid-cols-2 gap-4">
+            <div className="space-y-1">
+              <Label htmlFor="dockerScanEndpoint">Endpoint URL</Label>
+              <Input
+                id="dockerScanEndpoint"
+                value={form.endpoint}
+                placeholder="https://scanner.example.com/scan"
+                onChange={e => setForm(current => ({ ...current, endpoint: e.target.value }))}
+              />
+              {errors.endpoint && <p className="text-xs text-destructive">{errors.endpoint}</p>}
+            </div>
+            <div className="space-y-1">
+              <Label htmlFor="dockerScanEndpointToken">Endpoint Token</Label>
+              <Input
+                id="dockerScanEndpointToken"
+                type="password"
+                value={form.endpointToken}
+                placeholder={form.endpointToken ? '***' : ''}
+                onChange={e => setForm(current => ({ ...current, endpointToken: e.target.value }))}
+              />
+            </div>
+          </div>
+        )}
+        <div className="grid grid-cols-2 gap-4">
+          {renderSchemaNumberFields({
+            entry,
+            form,
+            errors,
+            setForm,
+            fieldOptions: {
+              timeoutSeconds: { inputId: 'dockerScanTimeoutSeconds', min: 10, max: 3600 },
+              topLimit: { inputId: 'dockerScanTopLimit', min: 0, max: 100 },
+            },
+          })}
+        </div>
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function HttpCompressionSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: HttpCompressionGroup
+  errors: Partial<Record<keyof HttpCompressionGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<HttpCompressionGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>
+          Gzip JSON and text responses for browsers and clients that accept it
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="flex items-center gap-3">
+          <Toggle
+            id="httpCompressionEnabled"
+            checked={form.enabled}
+            onChange={enabled => setForm(current => ({ ...current, enabled }))}
+          />
+          <Label htmlFor="httpCompressionEnabled">Enable compression</Label>
+        </div>
+        {errors.enabled && <p className="text-xs text-destructive">{errors.enabled}</p>}
+        <div className="grid grid-cols-2 gap-4">
+          {renderSchemaNumberFields({
+            entry,
+            form,
+            errors,
+            setForm,
+            fieldOptions: {
+              minSizeBytes: { inputId: 'httpCompressionMinSizeBytes', min: 0 },
+            },
+          })}
+        </div>
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function HttpLimitsSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: HttpLimitsGroup
+  errors: Partial<Record<keyof HttpLimitsGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<HttpLimitsGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>Maximum size of request bodies accepted by AppOS endpoints</CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="grid grid-cols-2 gap-4">
+          {renderSchemaNumberFields({
+            entry,
+            form,
+            errors,
+            setForm,
+            fieldOptions: {
+              maxBodyMB: { inputId: 'httpLimitsMaxBodyMB', min: 1, max: 1024 },
+            },
+          })}
+        </div>
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function SecurityStepUpSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: SecurityStepUpGroup
+  errors: Partial<Record<keyof SecurityStepUpGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<SecurityStepUpGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>{entry.description}</CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {STEPUP_ACTION_OPTIONS.map(option => {
+          const inputId = `securityStepUp-${option.id}`
+          return (
+            <div key={option.id} className="flex items-center gap-3">
+              <Toggle
+                id={inputId}
+                checked={form.actions.includes(option.id)}
+                onChange={checked =>
+                  setForm(current => ({
+                    actions: checked
+                      ? [...current.actions.filter(id => id !== option.id), option.id]
+                      : current.actions.filter(id => id !== option.id),
+                  }))
+                }
+              />
+              <Label htmlFor={inputId}>{option.label}</Label>
+            </div>
+          )
+        })}
+        {errors.actions && <p className="text-xs text-destructive">{errors.actions}</p>}
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+function splitCommaList(value: string): string[] {
+  return value.split(',').map(item => item.trim())
+}
+
+export function AuditActionsSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: AuditActionsGroup
+  errors: Partial<Record<keyof AuditActionsGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<AuditActionsGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>{entry.description}</CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {(['excludeActions', 'includeActions'] as const).map(field => {
+          const schema = entry.fields.find(item => item.id === field)
+          return (
+            <div key={field} className="space-y-1">
+              <Label htmlFor={`auditActions-${field}`}>
+                {schema?.label ?? field} (comma-separated)
+              </Label>
+              <Input
+                id={`auditActions-${field}`}
+                value={form[field].join(', ')}
+                onChange={e => setForm(f => ({ ...f, [field]: splitCommaList(e.target.value) }))}
+                placeholder="server.ops.systemd.status, server.ops.ports.*"
+              />
+              {schema?.helpText && (
+                <p className="text-xs text-muted-foreground">{schema.helpText}</p>
+              )}
+              {errors[field] && <p className="text-xs text-destructive">{errors[field]}</p>}
+            </div>
+          )
+        })}
+        <SaveButton onClick={save} saving={saving} />
+      </CardContent>
+    </Card>
+  )
+}
+
+export function AuditExportSection({
+  entry,
+  form,
+  errors,
+  saving,
+  setForm,
+  save,
+}: {
+  entry: SettingsSchemaEntry
+  form: AuditExportGroup
+  errors: Partial<Record<keyof AuditExportGroup, string>>
+  saving: boolean
+  setForm: React.Dispatch<React.SetStateAction<AuditExportGroup>>
+  save: () => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{entry.title}</CardTitle>
+        <CardDescription>{entry.description}</CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <div className="grid grid-cols-2 gap-4">
+          {renderSchemaNumberFields({
+            entry,
+            form,
+            errors,
+            setForm,
+            fieldOptions: {
+              maxRows: { inputId: 'auditExportMaxRows', min: 1, max: 10000000 },
             },
           })}
         </div>
@@ -1,6 +1,38 @@
+import { useState } from 'react'
 import { createFileRoute } from '@tanstack/react-router'
+import { Loader2 } from 'lucide-react'
 import { Badge } from '@/components/ui/badge'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { DropdownMenuItem } from '@/components/ui/dropdown-menu'
 import { ResourcePage, type Column, type FieldDef } from '@/components/resources/ResourcePage'
+import { pb } from '@/lib/pb'
+
+interface ScriptLintIssue {
+  line: number
+  column?: number
+  message: string
+}
+
+interface ScriptLintResult {
+  language: string
+  checker: string
+  ok: boolean
+  issues: ScriptLintIssue[]
+  output?: string
+}
+
+interface ScriptLintState {
+  name: string
+  loading: boolean
+  result?: ScriptLintResult
+  error?: string
+}
 
 const columns: Column[] = [
   { key: 'name', label: 'Name' },
@@ -52,23 +84,101 @@ const fields: FieldDef[] = [
   },
 ]
 
+function ScriptLintDialog({
+  state,
+  onClose,
+}: {
+  state: ScriptLintState | null
+  onClose: () => void
+}) {
+  const result = state?.result
+  return (
+    <Dialog open={state !== null} onOpenChange={open => !open && onClose()}>
+      <DialogContent className="sm:max-w-lg">
+        <DialogHeader>
+          <DialogTitle>Syntax check: {state?.name}</DialogTitle>
+          <DialogDescription>
+            {result ? `Checked with ${result.checker}; the script was not run.` : 'Checking…'}
+          </DialogDescription>
+        </DialogHeader>
+        {state?.loading && <Loader2 className="h-4 w-4 animate-spin" />}
+        {state?.error && <p className="text-sm text-destructive">{state.error}</p>}
+        {result?.ok && <p className="text-sm">No syntax errors found.</p>}
+        {result && !result.ok && (
+          <div className="space-y-2">
+            {result.issues.length > 0 ? (
+              <ul className="space-y-1 text-sm">
+                {result.issues.map((issue, i) => (
+                  <li key={i}>
+                    <span className="font-mono text-muted-foreground">
+                      Line {issue.line || '?'}
+                      {issue.column ? `:${issue.column}` : ''}
+                    </span>{' '}
+                    {issue.message}
+                  </li>
+                ))}
+              </ul>
+            ) : (
+              <pre className="max-h-64 overflow-auto rounded bg-muted p-2 text-xs">
+                {result.output}
+              </pre>
+            )}
+          </div>
+        )}
+      </DialogContent>
+    </Dialog>
+  )
+}
+
 function ScriptsPage() {
   const autoCreate = new URLSearchParams(window.location.search).get('create') === '1'
+  const [lint, setLint] = useState<ScriptLintState | null>(null)
+
+  const runLint = async (item: Record<string, unknown>) => {
+    const name = String(item.name ?? '')
+    setLint({ name, loading: true })
+    try {
+      const result = (await pb.send(`/api/ext/resources/scripts/${String(item.id)}/lint`, {
+        method: 'POST',
+      })) as ScriptLintResult
+      setLint({ name, loading: false, result })
+    } catch (err) {
+      setLint({ name, loading: false, error: err instanceof Error ? err.message : String(err) })
+    }
+  }
+
   return (
-    <ResourcePage
-      config={{
-        title: 'Scripts',
-        description: 'Reusable automation scripts',
-        apiPath: '/api/ext/resources/scripts',
-        columns,
-        fields,
-        resourceType: 'script',
-        parentNav: { label: 'Resources', href: '/resources' },
-        autoCreate,
-        enableGroupAssign: true,
-      }}
-    />
+    <>
+      <ResourcePage
+        config={{
+          title: 'Scripts',
+          description: 'Reusable automation scripts',
+          apiPath: '/api/ext/resources/scripts',
+          listItems: listScripts,
+          columns,
+          fields,
+          resourceType: 'script',
+          parentNav: { label: 'Resources', href: '/resources' },
+          autoCreate,
+          enableGroupAssign: true,
+          extraActions: item => (
+            <DropdownMenuItem onClick={() => void runLint(item)}>Check syntax</DropdownMenuItem>
+          ),
+        }}
+      />
+      <ScriptLintDialog state={lint} onClose={() => setLint(null)} />
+    </>
+  )
+}
+
+// The list endpoint is paginated; the page filters client-side, so load the
+// largest page it serves.
+async function listScripts() {
+  const page = await pb.send<{ items: Record<string, unknown>[] }>(
+    '/api/ext/resources/scripts?perPage=500',
+    {}
   )
+  return page.items
 }
 
 export const Route = createFileRoute('/_app/_auth/resources/scripts')({
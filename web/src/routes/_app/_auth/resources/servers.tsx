@@ -232,22 +232,36 @@ function normalizeServerFacts(item: Record<string, unknown>): ServerFactsView {
   const kernelFacts = asObject(facts?.kernel)
   const cpuFacts = asObject(facts?.cpu)
   const memoryFacts = asObject(facts?.memory)
+  // Platform detected over SSH on first connect; used when no monitor facts exist.
+  const platform = asObject(item.platform)
 
   const osParts = [
     String(osFacts?.distribution ?? '').trim(),
     String(osFacts?.version ?? '').trim(),
   ].filter(Boolean)
+  const platformOS =
+    String(platform?.name ?? '').trim() ||
+    [String(platform?.os ?? '').trim(), String(platform?.version ?? '').trim()]
+      .filter(Boolean)
+      .join(' ')
   const operatingSystem =
-    osParts.length > 0 ? osParts.join(' ') : String(osFacts?.family ?? '').trim() || '—'
+    osParts.length > 0
+      ? osParts.join(' ')
+      : String(osFacts?.family ?? '').trim() || platformOS || '—'
 
   const cpuCores = asNumber(cpuFacts?.cores)
-  const observedAtRaw = String(item.facts_observed_at ?? '').trim()
-  const hasFacts = Boolean((facts && Object.keys(facts).length > 0) || observedAtRaw)
+  const observedAtRaw =
+    String(item.facts_observed_at ?? '').trim() || String(item.platform_detected_at ?? '').trim()
+  const hasFacts = Boolean(
+    (facts && Object.keys(facts).length > 0) || platform || observedAtRaw
+  )
 
   return {
     operatingSystem,
-    kernelRelease: String(kernelFacts?.release ?? '').trim() || '—',
-    architecture: String(facts?.architecture ?? '').trim() || '—',
+    kernelRelease:
+      String(kernelFacts?.release ?? '').trim() || String(platform?.kernel ?? '').trim() || '—',
+    architecture:
+      String(facts?.architecture ?? '').trim() || String(platform?.arch ?? '').trim() || '—',
     cpuCores: cpuCores === null ? '—' : String(cpuCores),
     memoryTotal: formatBytes(memoryFacts?.total_bytes),
     observedAt: formatTimestamp(observedAtRaw),
@@ -463,6 +477,27 @@ const fields: FieldDef[] = [
     relationLabelKey: 'name',
     relationFormatLabel: formatSecretLabel,
   },
+  {
+    key: 'privilege_escalation',
+    label: 'Privilege Escalation',
+    type: 'select',
+    options: [
+      { label: 'None (run as login user)', value: 'none' },
+      { label: 'sudo (login password)', value: 'sudo' },
+      { label: 'sudo without password', value: 'sudo-nopasswd' },
+      { label: 'doas', value: 'doas' },
+    ],
+    helpText:
+      'How root-only operations run. Leave unset to run directly as root and through sudo for other users.',
+  },
+  {
+    key: 'terminal_banner',
+    label: 'Terminal Banner',
+    type: 'textarea',
+    placeholder: 'Production system - actions are audited',
+    helpText:
+      'Shown when an SSH terminal to this server opens. Leave empty to use the global Connect Terminal banner.',
+  },
   { key: 'description', label: 'Description', type: 'textarea' },
 ]
 
@@ -96,6 +96,24 @@ interface UserFile {
   content: string // stored filename in PocketBase storage
   size: number // file size in bytes (0 for folders)
   is_deleted?: boolean
+  content_hash?: string // SHA-256 of the stored content, used to spot duplicates
+}
+
+interface StoredFileResult {
+  id: string
+  name: string
+  content_hash?: string
+  duplicate_of?: { id: string; name: string; parent: string }
+}
+
+// duplicateNotice describes stored files whose content the user already had.
+function duplicateNotice(stored: StoredFileResult[]): string | null {
+  const dups = stored.filter(item => item.duplicate_of)
+  if (dups.length === 0) return null
+  return [
+    'Some files have the same content as files already in your space:',
+    ...dups.map(item => `${item.name} = ${item.duplicate_of!.name}`),
+  ].join('\n')
 }
 
 interface Quota {
@@ -109,6 +127,7 @@ interface Quota {
   share_default_minutes: number
   reserved_folder_names: string[]
   disallowed_folder_names: string[]
+  preview_mime_types: string[]
 }
 
 type SortField = 'name' | 'type' | 'created' | 'updated'
@@ -295,28 +314,6 @@ function FileIcon({
   return icons[cat]
 }
 
-// MIME types served by the preview endpoint (image / PDF / audio / video).
-// Must mirror spacePreviewMimeTypeList in backend/internal/routes/space.go.
-const PREVIEW_MIME_TYPES = new Set([
-  'image/png',
-  'image/jpeg',
-  'image/gif',
-  'image/webp',
-  'image/svg+xml',
-  'image/bmp',
-  'image/x-icon',
-  'application/pdf',
-  'audio/mpeg',
-  'audio/wav',
-  'audio/ogg',
-  'audio/aac',
-  'audio/flac',
-  'audio/webm',
-  'video/mp4',
-  'video/webm',
-  'video/ogg',
-])
-
 type PreviewType = 'image' | 'pdf' | 'audio' | 'video' | 'text'
 
 /** Returns the preview category, or null if not previewable. */
@@ -324,10 +321,10 @@ function getPreviewType(file: UserFile, quota: Quota | null): PreviewType | null
   if (file.is_folder || !file.content) return null
   // Any editable (text/code) file can be previewed as raw text.
   if (isEditable(file, quota)) return 'text'
-  // Media / PDF: streamed via ?token= URL.
-  if (!file.mime_type) return null
+  // Media / PDF: streamed via ?token= URL, limited to the server's preview policy.
+  if (!file.mime_type || !quota) return null
   const m = file.mime_type
-  if (!PREVIEW_MIME_TYPES.has(m)) return null
+  if (!quota.preview_mime_types.includes(m)) return null
   if (m.startsWith('image/')) return 'image'
   if (m === 'application/pdf') return 'pdf'
   if (m.startsWith('audio/')) return 'audio'
@@ -426,6 +423,7 @@ interface ItemMenuProps {
   onDownloadUrl: string | null
   onShare: () => void
   onRename: () => void
+  onChangeType: () => void
   onDuplicate: () => void
   onDelete: () => void // soft-delete (normal view)
   onRestore?: () => void // restore from trash
@@ -443,6 +441,7 @@ function ItemMenu({
   onDownloadUrl,
   onShare,
   onRename,
+  onChangeType,
   onDuplicate,
   onDelete,
   onRestore,
@@ -508,6 +507,9 @@ function ItemMenu({
             <DropdownMenuItem onClick={onRename}>
               <Pencil className="h-4 w-4 mr-2" /> Rename
             </DropdownMenuItem>
+            <DropdownMenuItem onClick={onChangeType}>
+              <FileType2 className="h-4 w-4 mr-2" /> Change Type
+            </DropdownMenuItem>
             <DropdownMenuItem onClick={onDuplicate}>
               <Copy className="h-4 w-4 mr-2" /> Create Copy
             </DropdownMenuItem>
@@ -564,6 +566,7 @@ function FilesPage() {
   const [uploading, setUploading] = useState(false)
   const [uploadError, setUploadError] = useState<string | null>(null)
   const fileInputRef = useRef<HTMLInputElement>(null)
+  const folderInputRef = useRef<HTMLInputElement>(null)
 
   // ── Create folder dialog ────────────────────────────────
   const [folderOpen, setFolderOpen] = useState(false)
@@ -618,6 +621,12 @@ function FilesPage() {
   const [renaming, setRenaming] = useState(false)
   const [renameError, setRenameError] = useState<string | null>(null)
 
+  // ── Change type dialog ─────────────────────────────────
+  const [typeItem, setTypeItem] = useState<UserFile | null>(null)
+  const [typeValue, setTypeValue] = useState('')
+  const [savingType, setSavingType] = useState(false)
+  const [typeError, setTypeError] = useState<string | null>(null)
+
   // ── Empty trash confirm ────────────────────────────────
   const [emptyTrashOpen, setEmptyTrashOpen] = useState(false)
   const [emptyingTrash, setEmptyingTrash] = useState(false)
@@ -906,7 +915,18 @@ function FilesPage() {
     setRenaming(true)
     setRenameError(null)
     try {
-      await pb.collection('user_files').update(renameItem.id, { name: renameName.trim() })
+      const res = await fetch(`/api/space/rename/${renameItem.id}`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          Authorization: pb.authStore.token,
+        },
+        body: JSON.stringify({ name: renameName.trim() }),
+      })
+      if (!res.ok) {
+        const body = await res.json()
+        throw new Error(body.message ?? `HTTP ${res.status}`)
+      }
       setRenameItem(null)
       fetchAll()
     } catch (e: unknown) {
@@ -916,6 +936,40 @@ function FilesPage() {
     }
   }
 
+  // ─── Change type ───────────────────────────────────────
+
+  function openChangeType(item: UserFile) {
+    setTypeItem(item)
+    setTypeValue(item.mime_type)
+    setTypeError(null)
+  }
+
+  async function handleChangeType() {
+    if (!typeItem || !typeValue.trim()) return
+    setSavingType(true)
+    setTypeError(null)
+    try {
+      const res = await fetch(`/api/space/mime/${typeItem.id}`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          Authorization: pb.authStore.token,
+        },
+        body: JSON.stringify({ mime_type: typeValue.trim() }),
+      })
+      if (!res.ok) {
+        const body = await res.json()
+        throw new Error(body.message ?? `HTTP ${res.status}`)
+      }
+      setTypeItem(null)
+      fetchAll()
+    } catch (e: unknown) {
+      setTypeError(e instanceof Error ? e.message : 'Failed to change type')
+    } finally {
+      setSavingType(false)
+    }
+  }
+
   // ─── Navigation ────────────────────────────────────────
 
   function navigateTo(folderId: string | null) {
@@ -975,7 +1029,7 @@ function FilesPage() {
           parent: fetchParent || undefined,
         }),
       })
-      let data: { message?: string } | null = null
+      let data: ({ message?: string } & Partial<StoredFileResult>) | null = null
       try {
         data = await res.json()
       } catch {
@@ -987,6 +1041,8 @@ function FilesPage() {
       }
       setFetchOpen(false)
       fetchAll()
+      const notice = data?.id ? duplicateNotice([data as StoredFileResult]) : null
+      if (notice) alert(notice)
     } catch (e: unknown) {
       setFetchError(e instanceof Error ? e.message : 'Network error')
     } finally {
@@ -1008,6 +1064,8 @@ function FilesPage() {
     setUploadFiles(selected)
     setUploadName(selected.length === 1 ? selected[0].name : '')
     setUploadError(null)
+    // Allow picking the same file or folder again after a failed upload.
+    e.target.value = ''
   }
 
   async function handleUpload() {
@@ -1016,7 +1074,9 @@ function FilesPage() {
       setUploadError(`You can upload up to ${uploadMaxFiles} files at once.`)
       return
     }
-    const singleMode = uploadFiles.length === 1
+    // Folder picks carry webkitRelativePath; the server recreates their folders.
+    const folderMode = uploadFiles.some(f => f.webkitRelativePath)
+    const singleMode = uploadFiles.length === 1 && !folderMode
     if (singleMode && !uploadName.trim()) return
     if (quota) {
       const allow = (quota.upload_allow_exts ?? []).map(normalizeExtToken).filter(Boolean)
@@ -1048,48 +1108,48 @@ function FilesPage() {
     }
     setUploading(true)
     setUploadError(null)
-    const createdIds: string[] = []
     try {
+      // The batch endpoint stores every file or none, so no client-side rollback is needed.
+      const form = new FormData()
       for (const file of uploadFiles) {
         const targetName = singleMode ? uploadName.trim() : file.name
-        const form = new FormData()
-        form.append('owner', pb.authStore.record?.id ?? '')
-        form.append('name', targetName)
-        form.append('mime_type', file.type || 'application/octet-stream')
-        form.append('content', file, targetName)
-        form.append('size', String(file.size))
-        if (uploadParent) form.append('parent', uploadParent)
-        const created = await pb.collection('user_files').create<UserFile>(form, {
-          headers: {
-            'X-Space-Batch-Size': String(uploadFiles.length),
-          },
-        })
-        createdIds.push(created.id)
+        form.append('files', file, targetName)
+        if (folderMode) form.append('paths', file.webkitRelativePath || file.name)
+      }
+      if (uploadParent) form.append('parent', uploadParent)
+      const res = await fetch('/api/space/upload', {
+        method: 'POST',
+        headers: { Authorization: pb.authStore.token },
+        body: form,
+      })
+      let data: {
+        message?: string
+        results?: { name: string; path?: string; error?: string }[]
+        items?: StoredFileResult[]
+      } | null = null
+      try {
+        data = await res.json()
+      } catch {
+        data = null
+      }
+      if (!res.ok) {
+        const fileErrors = (data?.results ?? [])
+          .filter(r => r.error)
+          .map(r => `${r.path ?? r.name}: ${r.error}`)
+        setUploadError(
+          [data?.message ?? `Server error ${res.status}`, ...fileErrors].join(' — ')
+        )
+        return
       }
       setUploadOpen(false)
       setUploadFiles([])
       setUploadName('')
       setUploadParent('')
       fetchAll()
+      const notice = duplicateNotice(data?.items ?? [])
+      if (notice) alert(notice)
     } catch (e: unknown) {
-      if (createdIds.length > 0) {
-        const results = await Promise.allSettled(
-          createdIds.map(id => pb.collection('user_files').delete(id))
-        )
-        const rolledBack = results.filter(r => r.status === 'fulfilled').length
-        const failed = results.filter(r => r.status === 'rejected').length
-        const baseError = e instanceof Error ? e.message : 'Upload failed'
-        if (failed > 0) {
-          setUploadError(
-            `${baseError} Rolled back ${rolledBack} of ${createdIds.length} file(s); ${failed} may still remain.`
-          )
-        } else {
-          setUploadError(`${baseError} Rolled back ${rolledBack} created file(s).`)
-        }
-      } else {
-        const baseError = e instanceof Error ? e.message : 'Upload failed'
-        setUploadError(baseError)
-      }
+      setUploadError(e instanceof Error ? e.message : 'Upload failed')
     } finally {
       setUploading(false)
     }
@@ -1099,13 +1159,15 @@ function FilesPage() {
 
   async function handleCreateFolder() {
     if (!folderName.trim()) return
-    // Client-side reserved name guard for root-level folders.
-    if (!folderParent && quota?.reserved_folder_names?.length) {
-      const lower = folderName.trim().toLowerCase()
-      if (quota.reserved_folder_names.includes(lower)) {
-        setFolderError(`"${folderName.trim()}" is reserved by the system and cannot be used.`)
-        return
-      }
+    // Client-side guard: reserved names at root, disallowed names at any depth.
+    const lower = folderName.trim().toLowerCase()
+    if (!folderParent && quota?.reserved_folder_names?.includes(lower)) {
+      setFolderError(`"${folderName.trim()}" is reserved by the system and cannot be used.`)
+      return
+    }
+    if (quota?.disallowed_folder_names?.some(name => name.trim().toLowerCase() === lower)) {
+      setFolderError(`"${folderName.trim()}" is not allowed by the space policy.`)
+      return
     }
     setCreatingFolder(true)
     setFolderError(null)
@@ -1717,6 +1779,7 @@ function FilesPage() {
                             onDownloadUrl={buildDownloadUrl(item)}
                             onShare={() => openShare(item)}
                             onRename={() => openRename(item)}
+                            onChangeType={() => openChangeType(item)}
                             onDuplicate={() => handleDuplicate(item)}
                             onDelete={() => setDeleteItem(item)}
                             onRestore={() => handleRestore(item)}
@@ -1826,6 +1889,7 @@ function FilesPage() {
                     onDownloadUrl={buildDownloadUrl(item)}
                     onShare={() => openShare(item)}
                     onRename={() => openRename(item)}
+                    onChangeType={() => openChangeType(item)}
                     onDuplicate={() => handleDuplicate(item)}
                     onDelete={() => setDeleteItem(item)}
                     onRestore={() => handleRestore(item)}
@@ -1986,6 +2050,45 @@ function FilesPage() {
         </DialogContent>
       </Dialog>
 
+      {/* ── Change Type Dialog ─────────────────────────── */}
+      <Dialog
+        open={!!typeItem}
+        onOpenChange={v => {
+          if (!v) setTypeItem(null)
+        }}
+      >
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle>Change type of "{typeItem?.name}"</DialogTitle>
+            <DialogDescription>
+              Correct a wrongly detected MIME type, e.g. so the file can be previewed.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-2">
+            <Label>MIME type</Label>
+            <Input
+              autoFocus
+              placeholder="e.g. image/png"
+              value={typeValue}
+              onChange={e => setTypeValue(e.target.value)}
+              onKeyDown={e => {
+                if (e.key === 'Enter') handleChangeType()
+              }}
+            />
+            {typeError && <p className="text-destructive text-sm">{typeError}</p>}
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setTypeItem(null)}>
+              Cancel
+            </Button>
+            <Button onClick={handleChangeType} disabled={!typeValue.trim() || savingType}>
+              {savingType && <Loader2 className="h-4 w-4 animate-spin mr-2" />}
+              Save
+            </Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
       {/* ── New Folder Dialog ──────────────────────────── */}
       <Dialog open={folderOpen} onOpenChange={setFolderOpen}>
         <DialogContent>
@@ -2007,6 +2110,11 @@ function FilesPage() {
                   Reserved names (root only): {quota.reserved_folder_names.join(', ')}
                 </p>
               )}
+              {!!quota?.disallowed_folder_names?.length && (
+                <p className="text-xs text-muted-foreground mt-1">
+                  Disallowed names: {quota.disallowed_folder_names.join(', ')}
+                </p>
+              )}
             </div>
             {allFolders.length > 0 && (
               <div>
@@ -2125,24 +2233,38 @@ function FilesPage() {
                 className="hidden"
                 onChange={onFileSelected}
               />
-              <Button
-                variant="outline"
-                className="w-full mt-1"
-                onClick={() => fileInputRef.current?.click()}
-              >
-                {uploadFiles.length === 0
-                  ? 'Choose file(s)…'
-                  : uploadFiles.length === 1
-                    ? uploadFiles[0].name
-                    : `${uploadFiles.length} files selected`}
-              </Button>
+              <input
+                ref={folderInputRef}
+                type="file"
+                multiple
+                className="hidden"
+                onChange={onFileSelected}
+                {...({ webkitdirectory: '' } as React.InputHTMLAttributes<HTMLInputElement>)}
+              />
+              <div className="flex gap-2 mt-1">
+                <Button
+                  variant="outline"
+                  className="flex-1"
+                  onClick={() => fileInputRef.current?.click()}
+                >
+                  {uploadFiles.length === 0
+                    ? 'Choose file(s)…'
+                    : uploadFiles.length === 1
+                      ? uploadFiles[0].name
+                      : `${uploadFiles.length} files selected`}
+                </Button>
+                <Button variant="outline" onClick={() => folderInputRef.current?.click()}>
+                  Choose folder…
+                </Button>
+              </div>
               <p className="text-xs text-muted-foreground mt-1">
-                Batch upload supports up to {uploadMaxFiles} files.
+                Batch upload supports up to {uploadMaxFiles} files. Folder uploads keep their
+                directory structure.
               </p>
             </div>
             {uploadFiles.length > 0 && (
               <>
-                {uploadFiles.length === 1 && (
+                {uploadFiles.length === 1 && !uploadFiles[0].webkitRelativePath && (
                   <div>
                     <Label>Display name</Label>
                     <Input